@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/h44z/wg-portal/internal"
+	"github.com/h44z/wg-portal/internal/adapters"
+	"github.com/h44z/wg-portal/internal/app/agent"
+)
+
+// runAgent starts WireGuard Portal in agent mode. The agent connects to a central portal and manages
+// the WireGuard interfaces of the local host on behalf of the portal. No database is required.
+func runAgent(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("agent", flag.ExitOnError)
+	portalAddress := flags.String("portal", "", "address of the portal agent listener, for example: portal.example.com:8899")
+	hostName := flags.String("host", "", "name of this agent host, as configured in the portal")
+	token := flags.String("token", os.Getenv("WG_PORTAL_AGENT_TOKEN"),
+		"shared secret of this agent host, defaults to the WG_PORTAL_AGENT_TOKEN environment variable")
+	caFile := flags.String("caFile", "", "CA certificate file that the portal certificate must be signed by (required)")
+	serverName := flags.String("serverName", "",
+		"name that is verified in the portal certificate, defaults to the host of the portal address")
+	certFile := flags.String("certFile", "", "client certificate file, required if the portal enforces mutual TLS")
+	keyFile := flags.String("keyFile", "", "key file of the client certificate")
+	maxReconnectInterval := flags.Duration("maxReconnectInterval", time.Minute,
+		"maximum delay between connection attempts")
+	logLevel := flags.String("logLevel", "info", "log level, either trace, debug, info, warn or error")
+	_ = flags.Parse(args) // errors are handled by the flag set (ExitOnError)
+
+	internal.SetupLogging(*logLevel, false, false)
+
+	slog.Info("Starting WireGuard Portal agent...", "version", internal.Version, "host", *hostName)
+
+	wireGuard := adapters.NewWireGuardRepository()
+
+	wireGuardSoftware := adapters.NewWireGuardSoftwareRepository(wireGuard)
+	defer wireGuardSoftware.Close()
+
+	wgQuick := adapters.NewWgQuickRepo()
+
+	wgAgent, err := agent.NewAgent(agent.Config{
+		PortalAddress:        *portalAddress,
+		Host:                 *hostName,
+		Token:                *token,
+		CaFile:               *caFile,
+		ServerName:           *serverName,
+		CertFile:             *certFile,
+		KeyFile:              *keyFile,
+		MaxReconnectInterval: *maxReconnectInterval,
+	}, wireGuard, wireGuardSoftware, wgQuick)
+	if err != nil {
+		slog.Error("Failed to setup agent", "error", err)
+		os.Exit(1)
+	}
+
+	wgAgent.Run(ctx) // blocks until the context gets cancelled
+
+	slog.Info("Stopped WireGuard Portal agent")
+}
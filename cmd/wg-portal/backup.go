@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/h44z/wg-portal/internal"
+	"github.com/h44z/wg-portal/internal/adapters"
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/app/backup"
+	"github.com/h44z/wg-portal/internal/config"
+)
+
+// runBackup writes an encrypted backup of the configured database.
+func runBackup(ctx context.Context, args []string) {
+	cfg := loadCliConfig()
+
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	file := flags.String("file", "", "backup file, defaults to stdout")
+	passphrase := flags.String("passphrase", backupPassphrase(cfg),
+		"passphrase that encrypts the backup, defaults to the WG_PORTAL_BACKUP_PASSPHRASE environment variable")
+	_ = flags.Parse(args) // errors are handled by the flag set (ExitOnError)
+
+	rawDb, _ := openCliDatabase(cfg)
+
+	var out io.Writer = os.Stdout
+	if *file != "" {
+		f, err := os.OpenFile(*file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		internal.AssertNoError(err)
+		defer f.Close()
+		out = f
+	}
+
+	if err := backup.Write(ctx, rawDb, out, *passphrase); err != nil {
+		slog.Error("Backup failed", "error", err)
+		os.Exit(1)
+	}
+
+	slog.Info("Backup finished")
+}
+
+// runRestore restores a backup into the configured database, which must be empty.
+// The database type and DSN can be overridden, so that a backup can be restored into a different database.
+func runRestore(ctx context.Context, args []string) {
+	cfg := loadCliConfig()
+
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	file := flags.String("file", "", "backup file, defaults to stdin")
+	passphrase := flags.String("passphrase", backupPassphrase(cfg),
+		"passphrase that decrypts the backup, defaults to the WG_PORTAL_BACKUP_PASSPHRASE environment variable")
+	dbType := flags.String("dbType", string(cfg.Database.Type),
+		"target database type, either mysql, mssql, postgres or sqlite")
+	dsn := flags.String("dsn", cfg.Database.DSN, "target database DSN")
+	_ = flags.Parse(args) // errors are handled by the flag set (ExitOnError)
+
+	cfg.Database.Type = config.SupportedDatabase(*dbType)
+	cfg.Database.DSN = *dsn
+	rawDb, _ := openCliDatabase(cfg) // also creates the database schema
+
+	var in io.Reader = os.Stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		internal.AssertNoError(err)
+		defer f.Close()
+		in = f
+	}
+
+	content, err := backup.Restore(ctx, rawDb, in, *passphrase)
+	if err != nil {
+		slog.Error("Restore failed", "error", err)
+		os.Exit(1)
+	}
+
+	slog.Info("Restore finished", "entities", content.Counts())
+}
+
+// loadCliConfig loads the configuration for subcommands, log output is written to stderr.
+func loadCliConfig() *config.Config {
+	cfg, err := config.GetConfig()
+	internal.AssertNoError(err)
+	internal.SetupLogging(cfg.Advanced.LogLevel, cfg.Advanced.LogPretty, cfg.Advanced.LogJson)
+
+	return cfg
+}
+
+// openCliDatabase opens and migrates the configured database for subcommands.
+func openCliDatabase(cfg *config.Config) (*gorm.DB, *adapters.SqlRepo) {
+	schema.RegisterSerializer("encstr", encryptionSerializer(cfg))
+	rawDb, err := adapters.NewDatabase(cfg.Database)
+	internal.AssertNoError(err)
+
+	database, err := adapters.NewSqlRepository(rawDb)
+	internal.AssertNoError(err)
+
+	return rawDb, database
+}
+
+// encryptionSerializer returns the serializer for encrypted database columns.
+func encryptionSerializer(cfg *config.Config) app.GormEncryptedStringSerializer {
+	return app.NewGormKeyedEncryptedStringSerializer(cfg.Database.EncryptionKeyId, cfg.Database.EncryptionPassphrase,
+		cfg.Database.PreviousEncryptionPassphrases)
+}
+
+func backupPassphrase(cfg *config.Config) string {
+	if passphrase := os.Getenv("WG_PORTAL_BACKUP_PASSPHRASE"); passphrase != "" {
+		return passphrase
+	}
+
+	return cfg.Advanced.BackupPassphrase
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	evbus "github.com/vardius/message-bus"
+
+	"github.com/h44z/wg-portal/internal"
+	"github.com/h44z/wg-portal/internal/app/bundle"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// runBundle exports or imports interface bundles using the configured database. Imported interfaces are only
+// stored in the database, the physical interfaces are created on the next start of WireGuard Portal.
+func runBundle(ctx context.Context, args []string) {
+	if len(args) == 0 || (args[0] != "export" && args[0] != "import") {
+		fmt.Fprintln(os.Stderr, "usage: wg-portal bundle export|import [flags]")
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("bundle "+args[0], flag.ExitOnError)
+	passphrase := flags.String("passphrase", os.Getenv("WG_PORTAL_BUNDLE_PASSPHRASE"),
+		"passphrase used to encrypt or decrypt the bundle, defaults to the WG_PORTAL_BUNDLE_PASSPHRASE environment variable")
+	interfaceId := flags.String("interface", "",
+		"interface to export, or the new identifier of the imported interface")
+	file := flags.String("file", "", "bundle file, defaults to stdout for exports and stdin for imports")
+	format := flags.String("format", string(domain.BundleFormatYaml), "bundle format for exports, either json or yaml")
+	listenPort := flags.Int("listenPort", 0, "listen port of the imported interface, defaults to the bundled port")
+	conflicts := flags.String("conflicts", string(domain.BundleConflictFail),
+		"handling of existing peers and users on import, either fail, skip or overwrite")
+	allowOverlap := flags.Bool("allowOverlap", false, "allow imported networks that overlap with other interfaces")
+	dryRun := flags.Bool("dryRun", false, "only check the import for conflicts")
+	_ = flags.Parse(args[1:]) // errors are handled by the flag set (ExitOnError)
+
+	cfg := loadCliConfig()
+	_, database := openCliDatabase(cfg)
+
+	bundleManager, err := bundle.NewBundleManager(cfg, evbus.New(100), database, nil)
+	internal.AssertNoError(err)
+
+	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo())
+
+	switch args[0] {
+	case "export":
+		err = exportBundle(ctx, bundleManager, *interfaceId, *passphrase, *format, *file)
+	case "import":
+		err = importBundle(ctx, bundleManager, *file, *passphrase, domain.BundleImportOptions{
+			Interface:    domain.InterfaceIdentifier(*interfaceId),
+			ListenPort:   *listenPort,
+			Conflicts:    domain.BundleConflictStrategy(*conflicts),
+			AllowOverlap: *allowOverlap,
+			DryRun:       *dryRun,
+		})
+	}
+	if err != nil {
+		slog.Error("Bundle "+args[0]+" failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func exportBundle(ctx context.Context, m *bundle.Manager, id, passphrase, format, file string) error {
+	if id == "" {
+		return fmt.Errorf("missing interface identifier")
+	}
+
+	b, err := m.ExportInterface(ctx, domain.InterfaceIdentifier(id), passphrase)
+	if err != nil {
+		return err
+	}
+
+	data, err := domain.MarshalInterfaceBundle(b, domain.BundleFormat(format))
+	if err != nil {
+		return err
+	}
+
+	if file == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	return os.WriteFile(file, data, 0600)
+}
+
+func importBundle(
+	ctx context.Context,
+	m *bundle.Manager,
+	file, passphrase string,
+	opts domain.BundleImportOptions,
+) error {
+	var data []byte
+	var err error
+	if file == "" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	b, err := domain.UnmarshalInterfaceBundle(data)
+	if err != nil {
+		return err
+	}
+
+	result, err := m.ImportInterface(ctx, b, passphrase, opts)
+	if err != nil {
+		return err
+	}
+
+	summary, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(summary))
+
+	return nil
+}
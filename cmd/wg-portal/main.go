@@ -14,6 +14,7 @@ import (
 	"github.com/h44z/wg-portal/internal"
 	"github.com/h44z/wg-portal/internal/adapters"
 	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/app/agent"
 	"github.com/h44z/wg-portal/internal/app/api/core"
 	backendV0 "github.com/h44z/wg-portal/internal/app/api/v0/backend"
 	handlersV0 "github.com/h44z/wg-portal/internal/app/api/v0/handlers"
@@ -21,9 +22,13 @@ import (
 	handlersV1 "github.com/h44z/wg-portal/internal/app/api/v1/handlers"
 	"github.com/h44z/wg-portal/internal/app/audit"
 	"github.com/h44z/wg-portal/internal/app/auth"
+	"github.com/h44z/wg-portal/internal/app/backup"
+	"github.com/h44z/wg-portal/internal/app/bundle"
 	"github.com/h44z/wg-portal/internal/app/configfile"
 	"github.com/h44z/wg-portal/internal/app/mail"
 	"github.com/h44z/wg-portal/internal/app/route"
+	"github.com/h44z/wg-portal/internal/app/sharelink"
+	"github.com/h44z/wg-portal/internal/app/spec"
 	"github.com/h44z/wg-portal/internal/app/users"
 	"github.com/h44z/wg-portal/internal/app/webhooks"
 	"github.com/h44z/wg-portal/internal/app/wireguard"
@@ -34,6 +39,36 @@ import (
 func main() {
 	ctx := internal.SignalAwareContext(context.Background(), syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
 
+	if len(os.Args) > 1 && os.Args[1] == "agent" {
+		runAgent(ctx, os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "bundle" {
+		runBundle(ctx, os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "backup" {
+		runBackup(ctx, os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		runRestore(ctx, os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "spec" {
+		runSpec(ctx, os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "rekey" {
+		runRekey(ctx, os.Args[2:])
+		return
+	}
+
 	slog.Info("Starting WireGuard Portal V2...", "version", internal.Version)
 
 	cfg, err := config.GetConfig()
@@ -42,8 +77,7 @@ func main() {
 
 	cfg.LogStartupValues()
 
-	dbEncryptedSerializer := app.NewGormEncryptedStringSerializer(cfg.Database.EncryptionPassphrase)
-	schema.RegisterSerializer("encstr", dbEncryptedSerializer)
+	schema.RegisterSerializer("encstr", encryptionSerializer(cfg))
 	rawDb, err := adapters.NewDatabase(cfg.Database)
 	internal.AssertNoError(err)
 
@@ -52,8 +86,13 @@ func main() {
 
 	wireGuard := adapters.NewWireGuardRepository()
 
+	wireGuardSoftware := adapters.NewWireGuardSoftwareRepository(wireGuard)
+	defer wireGuardSoftware.Close()
+
 	wgQuick := adapters.NewWgQuickRepo()
 
+	wgQuickFiles := adapters.NewWgQuickFileRepository(cfg.Advanced.ConfigImportPath)
+
 	mailer := adapters.NewSmtpMailRepo(cfg.Mail)
 
 	metricsServer := adapters.NewMetricsServer(cfg)
@@ -91,31 +130,55 @@ func main() {
 	webAuthn, err := auth.NewWebAuthnAuthenticator(cfg, eventBus, userManager)
 	internal.AssertNoError(err)
 
-	wireGuardManager, err := wireguard.NewWireGuardManager(cfg, eventBus, wireGuard, wgQuick, database)
+	agentHub, err := agent.NewHub(cfg, eventBus)
+	internal.AssertNoError(err)
+
+	wireGuardManager, err := wireguard.NewWireGuardManager(cfg, eventBus, wireGuard, wireGuardSoftware, agentHub,
+		wgQuick, wgQuickFiles, database)
 	internal.AssertNoError(err)
 	wireGuardManager.StartBackgroundJobs(ctx)
 
-	statisticsCollector, err := wireguard.NewStatisticsCollector(cfg, eventBus, database, wireGuard, metricsServer)
+	// start listening for agents after the manager subscribed to agent events, so that agents are resynced
+	err = agentHub.StartBackgroundJobs(ctx)
+	internal.AssertNoError(err)
+
+	statisticsCollector, err := wireguard.NewStatisticsCollector(cfg, eventBus, database, wireGuard, agentHub,
+		metricsServer)
 	internal.AssertNoError(err)
 	statisticsCollector.StartBackgroundJobs(ctx)
 
 	cfgFileManager, err := configfile.NewConfigFileManager(cfg, eventBus, database, database, cfgFileSystem)
 	internal.AssertNoError(err)
 
-	mailManager, err := mail.NewMailManager(cfg, mailer, cfgFileManager, database, database)
+	shareLinkManager, err := sharelink.NewShareLinkManager(cfg, eventBus, database, database, cfgFileManager)
+	internal.AssertNoError(err)
+	shareLinkManager.StartBackgroundJobs(ctx)
+
+	mailManager, err := mail.NewMailManager(cfg, eventBus, mailer, cfgFileManager, shareLinkManager, database, database)
 	internal.AssertNoError(err)
 
 	routeManager, err := route.NewRouteManager(cfg, eventBus, database)
 	internal.AssertNoError(err)
 	routeManager.StartBackgroundJobs(ctx)
 
+	backupManager, err := backup.NewBackupManager(cfg, rawDb)
+	internal.AssertNoError(err)
+	backupManager.StartBackgroundJobs(ctx)
+
 	webhookManager, err := webhooks.NewManager(cfg, eventBus)
 	internal.AssertNoError(err)
 	webhookManager.StartBackgroundJobs(ctx)
 
+	bundleManager, err := bundle.NewBundleManager(cfg, eventBus, database, wireGuardManager)
+	internal.AssertNoError(err)
+
 	err = app.Initialize(cfg, wireGuardManager, userManager)
 	internal.AssertNoError(err)
 
+	specManager, err := spec.NewSpecManager(cfg, eventBus, database, wireGuardManager, userManager)
+	internal.AssertNoError(err)
+	specManager.StartBackgroundJobs(ctx)
+
 	validatorManager := validator.New()
 
 	// region API v0 (SPA frontend)
@@ -125,7 +188,8 @@ func main() {
 
 	apiV0BackendUsers := backendV0.NewUserService(cfg, userManager, wireGuardManager)
 	apiV0BackendInterfaces := backendV0.NewInterfaceService(cfg, wireGuardManager, cfgFileManager)
-	apiV0BackendPeers := backendV0.NewPeerService(cfg, wireGuardManager, cfgFileManager, mailManager)
+	apiV0BackendPeers := backendV0.NewPeerService(cfg, wireGuardManager, cfgFileManager, mailManager,
+		shareLinkManager)
 
 	apiV0EndpointAuth := handlersV0.NewAuthEndpoint(cfg, apiV0Auth, apiV0Session, validatorManager, authenticator,
 		webAuthn)
@@ -135,6 +199,7 @@ func main() {
 	apiV0EndpointPeers := handlersV0.NewPeerEndpoint(cfg, apiV0Auth, validatorManager, apiV0BackendPeers)
 	apiV0EndpointConfig := handlersV0.NewConfigEndpoint(cfg, apiV0Auth)
 	apiV0EndpointTest := handlersV0.NewTestEndpoint(apiV0Auth)
+	apiV0EndpointShare := handlersV0.NewShareEndpoint(apiV0BackendPeers)
 
 	apiFrontend := handlersV0.NewRestApi(apiV0Session,
 		apiV0EndpointAuth,
@@ -144,18 +209,25 @@ func main() {
 		apiV0EndpointPeers,
 		apiV0EndpointConfig,
 		apiV0EndpointTest,
+		apiV0EndpointShare,
 	)
 
 	// endregion API v0 (SPA frontend)
 
 	// region API v1 (User REST API)
 
-	apiV1Auth := handlersV1.NewAuthenticationHandler(userManager)
+	apiV1Auth := handlersV1.NewAuthenticationHandler(cfg, userManager, authenticator)
 	apiV1BackendUsers := backendV1.NewUserService(cfg, userManager)
-	apiV1BackendPeers := backendV1.NewPeerService(cfg, wireGuardManager, userManager)
+	apiV1BackendPeers := backendV1.NewPeerService(cfg, wireGuardManager, userManager, shareLinkManager)
 	apiV1BackendInterfaces := backendV1.NewInterfaceService(cfg, wireGuardManager)
 	apiV1BackendProvisioning := backendV1.NewProvisioningService(cfg, userManager, wireGuardManager, cfgFileManager)
 	apiV1BackendMetrics := backendV1.NewMetricsService(cfg, database, userManager, wireGuardManager)
+	apiV1BackendIpam := backendV1.NewIpamService(cfg, wireGuardManager)
+	apiV1BackendSiteNetworks := backendV1.NewSiteNetworkService(cfg, wireGuardManager, cfgFileManager)
+	apiV1BackendPeerTags := backendV1.NewPeerTagService(cfg, wireGuardManager, mailManager)
+	apiV1BackendBundles := backendV1.NewBundleService(cfg, bundleManager)
+	apiV1BackendSpecs := backendV1.NewSpecService(cfg, specManager)
+	apiV1BackendApiTokens := backendV1.NewApiTokenService(cfg, userManager)
 
 	apiV1EndpointUsers := handlersV1.NewUserEndpoint(apiV1Auth, validatorManager, apiV1BackendUsers)
 	apiV1EndpointPeers := handlersV1.NewPeerEndpoint(apiV1Auth, validatorManager, apiV1BackendPeers)
@@ -163,6 +235,13 @@ func main() {
 	apiV1EndpointProvisioning := handlersV1.NewProvisioningEndpoint(apiV1Auth, validatorManager,
 		apiV1BackendProvisioning)
 	apiV1EndpointMetrics := handlersV1.NewMetricsEndpoint(apiV1Auth, validatorManager, apiV1BackendMetrics)
+	apiV1EndpointIpam := handlersV1.NewIpamEndpoint(apiV1Auth, validatorManager, apiV1BackendIpam)
+	apiV1EndpointSiteNetworks := handlersV1.NewSiteNetworkEndpoint(apiV1Auth, validatorManager,
+		apiV1BackendSiteNetworks)
+	apiV1EndpointPeerTags := handlersV1.NewPeerTagEndpoint(apiV1Auth, validatorManager, apiV1BackendPeerTags)
+	apiV1EndpointBundles := handlersV1.NewBundleEndpoint(apiV1Auth, validatorManager, apiV1BackendBundles)
+	apiV1EndpointSpecs := handlersV1.NewSpecEndpoint(apiV1Auth, validatorManager, apiV1BackendSpecs)
+	apiV1EndpointApiTokens := handlersV1.NewApiTokenEndpoint(apiV1Auth, validatorManager, apiV1BackendApiTokens)
 
 	apiV1 := handlersV1.NewRestApi(
 		apiV1EndpointUsers,
@@ -170,6 +249,12 @@ func main() {
 		apiV1EndpointInterfaces,
 		apiV1EndpointProvisioning,
 		apiV1EndpointMetrics,
+		apiV1EndpointIpam,
+		apiV1EndpointSiteNetworks,
+		apiV1EndpointPeerTags,
+		apiV1EndpointBundles,
+		apiV1EndpointSpecs,
+		apiV1EndpointApiTokens,
 	)
 
 	// endregion API v1 (User REST API)
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/h44z/wg-portal/internal/app/rekey"
+)
+
+// runRekey re-encrypts all encrypted database columns with the configured encryption passphrase. Values that
+// were encrypted with an older passphrase are decrypted with the configured previous passphrases.
+// WireGuard Portal should be stopped while the database is re-encrypted.
+func runRekey(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("rekey", flag.ExitOnError)
+	dryRun := flags.Bool("dryRun", false, "only report the values that would be re-encrypted")
+	verifyOnly := flags.Bool("verify", false,
+		"only verify that all values are encrypted with the current passphrase")
+	_ = flags.Parse(args) // errors are handled by the flag set (ExitOnError)
+
+	cfg := loadCliConfig()
+	rawDb, _ := openCliDatabase(cfg)
+	serializer := encryptionSerializer(cfg)
+
+	if !*verifyOnly {
+		result, err := rekey.Rekey(ctx, rawDb, serializer, *dryRun)
+		if err != nil {
+			slog.Error("Rekey failed, no value was changed", "error", err)
+			os.Exit(1)
+		}
+		printRekeyResult(result)
+
+		if *dryRun {
+			return
+		}
+	}
+
+	result, err := rekey.Verify(ctx, rawDb, serializer)
+	if err != nil {
+		slog.Error("Verification failed", "error", err)
+		os.Exit(1)
+	}
+	printRekeyResult(result)
+
+	if result.Failed() > 0 {
+		slog.Error("Verification failed", "failed", result.Failed())
+		os.Exit(1)
+	}
+	slog.Info("Verification finished, all values are encrypted with the current passphrase")
+}
+
+func printRekeyResult(result *rekey.Result) {
+	for _, c := range result.Columns {
+		fmt.Printf("%s.%s: %d values", c.Table, c.Column, c.Values)
+		switch {
+		case c.Rekeyed > 0 && result.DryRun:
+			fmt.Printf(", %d to re-encrypt", c.Rekeyed)
+		case c.Rekeyed > 0:
+			fmt.Printf(", %d re-encrypted", c.Rekeyed)
+		}
+		if len(c.Failures) > 0 {
+			fmt.Printf(", %d failed", len(c.Failures))
+		}
+		fmt.Println()
+		for _, failure := range c.Failures {
+			fmt.Printf("  %s\n", failure)
+		}
+	}
+}
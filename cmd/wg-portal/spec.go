@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	evbus "github.com/vardius/message-bus"
+
+	"github.com/h44z/wg-portal/internal"
+	"github.com/h44z/wg-portal/internal/app/spec"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// runSpec validates a declarative spec and prints the changes that are needed to apply it to the configured
+// database. The spec is applied by WireGuard Portal itself, so that the physical interfaces are updated as well.
+func runSpec(ctx context.Context, args []string) {
+	if len(args) == 0 || args[0] != "plan" {
+		fmt.Fprintln(os.Stderr, "usage: wg-portal spec plan [flags]")
+		os.Exit(2)
+	}
+
+	cfg := loadCliConfig()
+
+	flags := flag.NewFlagSet("spec "+args[0], flag.ExitOnError)
+	path := flags.String("path", cfg.Advanced.SpecPath, "spec file or directory, defaults to the configured spec path")
+	_ = flags.Parse(args[1:]) // errors are handled by the flag set (ExitOnError)
+
+	if *path == "" {
+		fmt.Fprintln(os.Stderr, "missing spec path")
+		os.Exit(2)
+	}
+
+	s, err := spec.LoadSpec(*path)
+	if err != nil {
+		slog.Error("Invalid spec", "error", err)
+		os.Exit(1)
+	}
+
+	_, database := openCliDatabase(cfg)
+
+	specManager, err := spec.NewSpecManager(cfg, evbus.New(100), database, nil, nil)
+	internal.AssertNoError(err)
+
+	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo())
+	plan, err := specManager.Plan(ctx, s)
+	if err != nil {
+		slog.Error("Spec plan failed", "error", err)
+		os.Exit(1)
+	}
+
+	fmt.Print(plan.String())
+}
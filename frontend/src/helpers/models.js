@@ -5,6 +5,10 @@ export function freshInterface() {
     DisplayName: "",
     Identifier: "",
     Mode: "server",
+    DriverType: "linux",
+    AgentHost: "",
+    DriftMode: "alert",
+    ManagedBy: "",
 
     PublicKey: "",
     PrivateKey: "",
@@ -40,6 +44,19 @@ export function freshInterface() {
     PeerDefPostUp: "",
     PeerDefPreDown: "",
     PeerDefPostDown: "",
+    PeerDefQuota: freshQuota(),
+    PeerRenewalPolicy: {
+      Enabled: false,
+      Step: 30,
+      MaxLifetime: 0,
+      MaxRenewals: 0,
+      RequireApproval: false,
+    },
+    PeerDefIpv6: {
+      HashedAddresses: false,
+      DelegatedPrefix: "",
+      DelegatedPrefixLength: 64,
+    },
 
     TotalPeers: 0,
     EnabledPeers: 0,
@@ -56,6 +73,9 @@ export function freshPeer() {
     Disabled: false,
     ExpiresAt: null,
     Notes: "",
+    Tags: [],
+    ManagedBy: "",
+    Quota: freshQuota(),
 
     Endpoint: {
       Value: "",
@@ -136,6 +156,7 @@ export function freshUser() {
     Email: "",
     Source: "db",
     IsAdmin: false,
+    Roles: [],
 
     Firstname: "",
     Lastname: "",
@@ -151,6 +172,9 @@ export function freshUser() {
     LockedReason: "",
 
     ApiEnabled: false,
+    ManagedBy: "",
+
+    PeerQuota: freshQuota(),
 
     PeerCount: 0,
 
@@ -170,4 +194,13 @@ export function freshStats() {
     BytesReceived: 0,
     EndpointAddress: ""
   }
-}
\ No newline at end of file
+}
+export function freshQuota() {
+  return {
+    Limit: 0,
+    Direction: "total",
+    ResetCycle: "monthly",
+    ResetAnchor: null,
+    ResetInterval: 0
+  }
+}
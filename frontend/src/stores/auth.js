@@ -13,6 +13,7 @@ export const authStore = defineStore('auth',{
         providers: [],
         returnUrl: localStorage.getItem('returnUrl'),
         webAuthnCredentials: [],
+        secondFactor: null, // the pending second factor challenge of a password login
         fetching: false,
     }),
     getters: {
@@ -21,6 +22,8 @@ export const authStore = defineStore('auth',{
         LoginProviders: (state) => state.providers,
         IsAuthenticated: (state) => state.user != null,
         IsAdmin: (state) => state.user?.IsAdmin || false,
+        Permissions: (state) => state.user?.Permissions || [],
+        HasPermission: (state) => (permission) => state.user?.IsAdmin || (state.user?.Permissions || []).includes(permission),
         ReturnUrl: (state) => state.returnUrl || '/',
         IsWebAuthnEnabled: (state) => {
             if (state.webAuthnCredentials) {
@@ -29,6 +32,7 @@ export const authStore = defineStore('auth',{
             return false
         },
         WebAuthnCredentials: (state) => state.webAuthnCredentials || [],
+        SecondFactor: (state) => state.secondFactor,
         isFetching: (state) => state.fetching,
     },
     actions: {
@@ -90,12 +94,19 @@ export const authStore = defineStore('auth',{
                 })
         },
         // Login returns promise that might have been rejected if the login attempt was not successful.
+        // If a second factor is required, the promise resolves to the challenge and the login has to be completed
+        // with one of the LoginSecondFactor actions.
         async Login(username, password) {
+            this.secondFactor = null
             return apiWrapper.post(`/auth/login`, { username, password })
                 .then(user =>  {
+                    if (user.SecondFactorRequired === true) {
+                        this.secondFactor = user
+                        return user
+                    }
                     this.ResetReturnUrl()
                     this.setUserInfo(user)
-                    return user.Identifier
+                    return this.LoadSession() // the session also contains the permissions of the user
                 })
                 .catch(err => {
                     console.log("Login failed:", err)
@@ -103,6 +114,48 @@ export const authStore = defineStore('auth',{
                     return Promise.reject(new Error("login failed"))
                 })
         },
+        // LoginSecondFactor completes the password login with a TOTP code or a recovery code.
+        async LoginSecondFactor(code) {
+            return apiWrapper.post(`/auth/login/second-factor`, { Code: code })
+                .then(user => this.finishSecondFactor(user))
+                .catch(err => {
+                    console.log("Second factor login failed:", err)
+                    return Promise.reject(new Error("login failed"))
+                })
+        },
+        // LoginSecondFactorWebAuthn completes the password login with a passkey.
+        async LoginSecondFactorWebAuthn() {
+            if (!browserSupportsWebAuthn()) {
+                console.error("WebAuthn is not supported by this browser.");
+                return Promise.reject(new Error("WebAuthn not supported"));
+            }
+
+            return apiWrapper.post(`/auth/login/second-factor/webauthn/start`, {})
+                .then(optionsJSON => startAuthentication({ optionsJSON: optionsJSON.publicKey }))
+                .then(asseResp => apiWrapper.post(`/auth/login/second-factor/webauthn/finish`, asseResp))
+                .then(user => this.finishSecondFactor(user))
+                .catch(err => {
+                    console.error("Failed to verify passkey:", err)
+                    return Promise.reject(new Error("login failed"))
+                })
+        },
+        // StartSecondFactorEnrollment returns the TOTP secret for users that have to enroll a second factor.
+        async StartSecondFactorEnrollment() {
+            return apiWrapper.post(`/auth/login/second-factor/enroll/start`, {})
+        },
+        // FinishSecondFactorEnrollment confirms the TOTP enrollment and completes the login. The promise resolves
+        // to the recovery codes.
+        async FinishSecondFactorEnrollment(code) {
+            return apiWrapper.post(`/auth/login/second-factor/enroll/finish`, { Code: code })
+                .then(result => this.finishSecondFactor(result.User).then(() => result.RecoveryCodes))
+                .catch(err => {
+                    console.log("Second factor enrollment failed:", err)
+                    return Promise.reject(new Error("login failed"))
+                })
+        },
+        CancelSecondFactor() {
+            this.secondFactor = null
+        },
         async Logout() {
             this.setUserInfo(null)
             this.ResetReturnUrl() // just to be sure^^
@@ -254,7 +307,7 @@ export const authStore = defineStore('auth',{
                                 console.log("Passkey login finished successfully for user:", user.Identifier)
                                 this.ResetReturnUrl()
                                 this.setUserInfo(user)
-                                return user.Identifier
+                                return this.LoadSession() // the session also contains the permissions of the user
                             })
                             .catch(err => {
                                 console.error("Failed to login with passkey:", err)
@@ -274,6 +327,12 @@ export const authStore = defineStore('auth',{
                 })
         },
         // -- internal setters
+        finishSecondFactor(user) {
+            this.secondFactor = null
+            this.ResetReturnUrl()
+            this.setUserInfo(user)
+            return this.LoadSession() // the session also contains the permissions of the user
+        },
         setUserInfo(userInfo) {
             // store user details and jwt in local storage to keep user logged in between page refreshes
             if (userInfo) {
@@ -283,7 +342,9 @@ export const authStore = defineStore('auth',{
                         Firstname: userInfo['UserFirstname'],
                         Lastname: userInfo['UserLastname'],
                         Email: userInfo['UserEmail'],
-                        IsAdmin: userInfo['IsAdmin']
+                        IsAdmin: userInfo['IsAdmin'],
+                        Roles: userInfo['Roles'] || [],
+                        Permissions: userInfo['Permissions'] || []
                     }
                 } else { // user object
                     this.user = {
@@ -291,7 +352,9 @@ export const authStore = defineStore('auth',{
                         Firstname: userInfo['Firstname'],
                         Lastname: userInfo['Lastname'],
                         Email: userInfo['Email'],
-                        IsAdmin: userInfo['IsAdmin']
+                        IsAdmin: userInfo['IsAdmin'],
+                        Roles: userInfo['Roles'] || [],
+                        Permissions: []
                     }
                 }
                 localStorage.setItem('user', JSON.stringify(this.user))
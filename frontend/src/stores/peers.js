@@ -38,7 +38,8 @@ export const peerStore = defineStore('peers', {
         return state.peers
       }
       return state.peers.filter((p) => {
-        return p.DisplayName.includes(state.filter) || p.Identifier.includes(state.filter)
+        return p.DisplayName.includes(state.filter) || p.Identifier.includes(state.filter) ||
+          (p.Tags ?? []).includes(state.filter.toLowerCase())
       })
     },
     Sorted: (state) => {
@@ -231,6 +232,34 @@ export const peerStore = defineStore('peers', {
           throw new Error(error)
         })
     },
+    async RenewPeer(id) {
+      this.fetching = true
+      return apiWrapper.post(`${baseUrl}/${base64_url_encode(id)}/renew`)
+        .then(peer => {
+          let idx = this.peers.findIndex((p) => p.Identifier === id)
+          this.peers[idx] = peer
+          this.fetching = false
+        })
+        .catch(error => {
+          this.fetching = false
+          console.log(error)
+          throw new Error(error)
+        })
+    },
+    async RejectPeerRenewal(id) {
+      this.fetching = true
+      return apiWrapper.delete(`${baseUrl}/${base64_url_encode(id)}/renewal`)
+        .then(peer => {
+          let idx = this.peers.findIndex((p) => p.Identifier === id)
+          this.peers[idx] = peer
+          this.fetching = false
+        })
+        .catch(error => {
+          this.fetching = false
+          console.log(error)
+          throw new Error(error)
+        })
+    },
     async CreatePeer(interfaceId, formData) {
       this.fetching = true
       return apiWrapper.post(`${baseUrl}/iface/${base64_url_encode(interfaceId)}/new`, formData)
@@ -16,6 +16,8 @@ export const profileStore = defineStore('profile', {
     stats: {},
     statsEnabled: false,
     user: {},
+    apiTokens: [],
+    createdApiToken: null, // the last created token, the plain token is only available once
     filter: "",
     pageSize: 10,
     pageOffset: 0,
@@ -73,6 +75,7 @@ export const profileStore = defineStore('profile', {
     },
     hasStatistics: (state) => state.statsEnabled,
     CountInterfaces: (state) => state.interfaces.length,
+    ApiTokens: (state) => state.apiTokens,
   },
   actions: {
     afterPageSizeChange() {
@@ -193,6 +196,128 @@ export const profileStore = defineStore('profile', {
           })
         })
     },
+    async RenewPeer(id) {
+      this.fetching = true
+      return apiWrapper.post(`/peer/${base64_url_encode(id)}/renew`)
+        .then(peer => {
+          let idx = this.peers.findIndex((p) => p.Identifier === id)
+          this.peers[idx] = peer
+          this.fetching = false
+          return peer
+        })
+        .catch(error => {
+          this.fetching = false
+          console.log(error)
+          throw new Error(error)
+        })
+    },
+    setApiTokens(tokens) {
+      this.apiTokens = tokens
+      this.fetching = false
+    },
+    async LoadApiTokens() {
+      this.fetching = true
+      let currentUser = authStore().user.Identifier
+      return apiWrapper.get(`${baseUrl}/${base64_url_encode(currentUser)}/api-tokens`)
+          .then(this.setApiTokens)
+          .catch(error => {
+            this.setApiTokens([])
+            console.log("Failed to load api tokens for ", currentUser, ": ", error)
+            notify({
+              title: "Backend Connection Failure",
+              text: "Failed to load API tokens!",
+            })
+          })
+    },
+    async CreateApiToken(request) {
+      this.fetching = true
+      let currentUser = authStore().user.Identifier
+      return apiWrapper.post(`${baseUrl}/${base64_url_encode(currentUser)}/api-tokens`, request)
+          .then(token => {
+            this.createdApiToken = token
+            this.apiTokens.unshift(token)
+            this.fetching = false
+            return token
+          })
+          .catch(error => {
+            this.fetching = false
+            console.log("Failed to create api token for ", currentUser, ": ", error)
+            notify({
+              title: "Failed to create API token",
+              text: error,
+              type: 'error',
+            })
+          })
+    },
+    async DeleteApiToken(id) {
+      this.fetching = true
+      let currentUser = authStore().user.Identifier
+      return apiWrapper.delete(`${baseUrl}/${base64_url_encode(currentUser)}/api-tokens/${id}`)
+          .then(() => {
+            this.apiTokens = this.apiTokens.filter((t) => t.Identifier !== id)
+            if (this.createdApiToken && this.createdApiToken.Identifier === id) {
+              this.createdApiToken = null
+            }
+            this.fetching = false
+          })
+          .catch(error => {
+            this.fetching = false
+            console.log("Failed to delete api token ", id, ": ", error)
+            notify({
+              title: "Failed to revoke API token",
+              text: error,
+              type: 'error',
+            })
+          })
+    },
+    async StartTotpEnrollment() {
+      this.fetching = true
+      let currentUser = authStore().user.Identifier
+      return apiWrapper.post(`${baseUrl}/${base64_url_encode(currentUser)}/totp/enroll/start`)
+          .then(enrollment => {
+            this.fetching = false
+            return enrollment
+          })
+          .catch(error => {
+            this.fetching = false
+            console.log("Failed to start totp enrollment for ", currentUser, ": ", error)
+            notify({
+              title: "Failed to set up two-factor authentication",
+              text: error,
+              type: 'error',
+            })
+          })
+    },
+    // FinishTotpEnrollment, RenewRecoveryCodes and RemoveTotp require a code of the authenticator app.
+    async FinishTotpEnrollment(code) {
+      return this.postTotpCode('enroll/finish', code, "Failed to set up two-factor authentication")
+    },
+    async RenewRecoveryCodes(code) {
+      return this.postTotpCode('recovery-codes', code, "Failed to renew recovery codes")
+    },
+    async RemoveTotp(code) {
+      return this.postTotpCode('remove', code, "Failed to remove two-factor authentication")
+    },
+    async postTotpCode(action, code, errorTitle) {
+      this.fetching = true
+      let currentUser = authStore().user.Identifier
+      return apiWrapper.post(`${baseUrl}/${base64_url_encode(currentUser)}/totp/${action}`, { Code: code })
+          .then(result => {
+            this.fetching = false
+            this.LoadUser() // refresh the two-factor state of the user
+            return result?.RecoveryCodes || []
+          })
+          .catch(error => {
+            this.fetching = false
+            console.log("Failed to update totp of ", currentUser, ": ", error)
+            notify({
+              title: errorTitle,
+              text: error,
+              type: 'error',
+            })
+            return null
+          })
+    },
     async LoadInterfaces() {
       this.fetching = true
       let currentUser = authStore().user.Identifier
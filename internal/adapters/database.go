@@ -226,7 +226,20 @@ func (r *SqlRepo) migrate() error {
 	slog.Debug("running migration: peer", "result", r.db.AutoMigrate(&domain.Peer{}))
 	slog.Debug("running migration: peer status", "result", r.db.AutoMigrate(&domain.PeerStatus{}))
 	slog.Debug("running migration: interface status", "result", r.db.AutoMigrate(&domain.InterfaceStatus{}))
+	slog.Debug("running migration: traffic samples", "result", r.db.AutoMigrate(&domain.TrafficSample{}))
+	slog.Debug("running migration: peer sessions", "result", r.db.AutoMigrate(&domain.PeerSession{}))
+	slog.Debug("running migration: peer expiry notifications", "result",
+		r.db.AutoMigrate(&domain.PeerExpiryNotification{}))
 	slog.Debug("running migration: audit data", "result", r.db.AutoMigrate(&domain.AuditEntry{}))
+	slog.Debug("running migration: peer share links", "result", r.db.AutoMigrate(&domain.PeerShareLink{}))
+	slog.Debug("running migration: api tokens", "result", r.db.AutoMigrate(&domain.ApiToken{}))
+	slog.Debug("running migration: login attempts", "result", r.db.AutoMigrate(&domain.LoginAttempt{}))
+	slog.Debug("running migration: ip ranges", "result", r.db.AutoMigrate(&domain.IpRange{}))
+	slog.Debug("running migration: ip reservations", "result", r.db.AutoMigrate(&domain.IpReservation{}))
+	slog.Debug("running migration: site networks", "result", r.db.AutoMigrate(&domain.SiteNetwork{}))
+	slog.Debug("running migration: sites", "result", r.db.AutoMigrate(&domain.Site{}))
+	slog.Debug("running migration: site links", "result", r.db.AutoMigrate(&domain.SiteLink{}))
+	slog.Debug("running migration: peer tags", "result", r.db.AutoMigrate(&domain.PeerTag{}))
 
 	existingSysStat := SysStat{}
 	r.db.Where("schema_version = ?", SchemaVersion).First(&existingSysStat)
@@ -444,6 +457,29 @@ func (r *SqlRepo) DeleteInterface(ctx context.Context, id domain.InterfaceIdenti
 			return err
 		}
 
+		err = tx.Where("object_type = ? AND object_id = ?", domain.TrafficObjectInterface, id).
+			Delete(&domain.TrafficSample{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("interface_identifier = ?", id).Delete(&domain.IpRange{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("interface_identifier = ?", id).Delete(&domain.IpReservation{}).Error
+		if err != nil {
+			return err
+		}
+
+		// sites of the interface become remote sites, they keep their keys and addresses
+		err = tx.Model(&domain.Site{}).Where("interface_identifier = ?", id).
+			Update("interface_identifier", "").Error
+		if err != nil {
+			return err
+		}
+
 		err = tx.Select(clause.Associations).Delete(&domain.Interface{Identifier: id}).Error
 		if err != nil {
 			return err
@@ -515,23 +551,52 @@ func (r *SqlRepo) GetInterfacePeers(ctx context.Context, id domain.InterfaceIden
 
 // FindInterfacePeers returns all peers associated with the given interface id that match the given search string.
 // The search string is matched against the peer identifier, display name and IP address.
-func (r *SqlRepo) FindInterfacePeers(ctx context.Context, id domain.InterfaceIdentifier, search string) (
-	[]domain.Peer,
-	error,
-) {
+// If tags are given, only peers that are tagged with all of them are returned.
+func (r *SqlRepo) FindInterfacePeers(
+	ctx context.Context,
+	id domain.InterfaceIdentifier,
+	search string,
+	tags ...string,
+) ([]domain.Peer, error) {
 	var peers []domain.Peer
 
 	searchValue := "%" + strings.ToLower(search) + "%"
-	err := r.db.WithContext(ctx).Where("interface_identifier = ?", id).
-		Where("identifier LIKE ?", searchValue).
-		Or("display_name LIKE ?", searchValue).
-		Or("iface_address_str_v LIKE ?", searchValue).
-		Find(&peers).Error
+	tx := r.db.WithContext(ctx).Where("interface_identifier = ?", id).
+		Where(r.db.Where("identifier LIKE ?", searchValue).
+			Or("display_name LIKE ?", searchValue).
+			Or("identifier IN (?)", peerAddressSearch(r.db, searchValue)))
+	for _, tag := range tags {
+		tx = tx.Where("tags LIKE ?", tagSearchValue(tag))
+	}
+	err := tx.Find(&peers).Error
 	if err != nil {
 		return nil, err
 	}
 
-	return peers, nil
+	return domain.FilterPeersByTags(peers, tags), nil
+}
+
+// peerAddressSearch returns a sub query that selects the identifiers of all peers with a matching address.
+func peerAddressSearch(db *gorm.DB, searchValue string) *gorm.DB {
+	return db.Table("peer_addresses").Select("peer_identifier").Where("cidr_cidr LIKE ?", searchValue)
+}
+
+// GetTaggedPeers returns all peers that are tagged with the given tag.
+func (r *SqlRepo) GetTaggedPeers(ctx context.Context, tag string) ([]domain.Peer, error) {
+	var peers []domain.Peer
+
+	err := r.db.WithContext(ctx).Preload("Addresses").Where("tags LIKE ?", tagSearchValue(tag)).Find(&peers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.FilterPeersByTags(peers, []string{tag}), nil
+}
+
+// tagSearchValue returns a LIKE pattern that matches the JSON encoded tag list of peers with the given tag.
+// Tag names may contain the wildcard '_', so the results must be filtered again.
+func tagSearchValue(tag string) string {
+	return `%"` + tag + `"%`
 }
 
 // GetUserPeers returns all peers associated with the given user id.
@@ -553,9 +618,9 @@ func (r *SqlRepo) FindUserPeers(ctx context.Context, id domain.UserIdentifier, s
 
 	searchValue := "%" + strings.ToLower(search) + "%"
 	err := r.db.WithContext(ctx).Where("user_identifier = ?", id).
-		Where("identifier LIKE ?", searchValue).
-		Or("display_name LIKE ?", searchValue).
-		Or("iface_address_str_v LIKE ?", searchValue).
+		Where(r.db.Where("identifier LIKE ?", searchValue).
+			Or("display_name LIKE ?", searchValue).
+			Or("identifier IN (?)", peerAddressSearch(r.db, searchValue))).
 		Find(&peers).Error
 	if err != nil {
 		return nil, err
@@ -648,6 +713,22 @@ func (r *SqlRepo) DeletePeer(ctx context.Context, id domain.PeerIdentifier) erro
 			return err
 		}
 
+		err = tx.Where("object_type = ? AND object_id = ?", domain.TrafficObjectPeer, id).
+			Delete(&domain.TrafficSample{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("peer_id = ?", id).Delete(&domain.PeerSession{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("peer_id = ?", id).Delete(&domain.PeerExpiryNotification{}).Error
+		if err != nil {
+			return err
+		}
+
 		err = tx.Select(clause.Associations).Delete(&domain.Peer{Identifier: id}).Error
 		if err != nil {
 			return err
@@ -739,6 +820,33 @@ func (r *SqlRepo) GetUsedIpsPerSubnet(ctx context.Context, subnets []domain.Cidr
 	return result, nil
 }
 
+// GetPeerExpiryNotifications returns all expiry notifications that were sent for the given peer and expiry date.
+func (r *SqlRepo) GetPeerExpiryNotifications(
+	ctx context.Context,
+	id domain.PeerIdentifier,
+	expiresAt time.Time,
+) ([]domain.PeerExpiryNotification, error) {
+	var notifications []domain.PeerExpiryNotification
+	err := r.db.WithContext(ctx).
+		Where("peer_id = ? AND expires_at = ?", id, expiresAt).
+		Find(&notifications).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// SavePeerExpiryNotification records that the given expiry notification has been sent.
+func (r *SqlRepo) SavePeerExpiryNotification(ctx context.Context, notification *domain.PeerExpiryNotification) error {
+	err := r.db.WithContext(ctx).Save(notification).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // endregion peers
 
 // region users
@@ -871,7 +979,14 @@ func (r *SqlRepo) SaveUser(
 
 // DeleteUser deletes the user with the given id.
 func (r *SqlRepo) DeleteUser(ctx context.Context, id domain.UserIdentifier) error {
-	err := r.db.WithContext(ctx).Unscoped().Select(clause.Associations).Delete(&domain.User{Identifier: id}).Error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_identifier = ?", id).Delete(&domain.ApiToken{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Select(clause.Associations).Delete(&domain.User{Identifier: id}).Error
+	})
 	if err != nil {
 		return err
 	}
@@ -1058,6 +1173,209 @@ func (r *SqlRepo) DeletePeerStatus(ctx context.Context, id domain.PeerIdentifier
 	return nil
 }
 
+// SaveTrafficSamples stores the given traffic samples.
+func (r *SqlRepo) SaveTrafficSamples(ctx context.Context, samples ...domain.TrafficSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	err := r.db.WithContext(ctx).Create(&samples).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetTrafficSamples returns all traffic samples of the given object in the time range [from, to),
+// regardless of their resolution. The samples are sorted by timestamp.
+func (r *SqlRepo) GetTrafficSamples(
+	ctx context.Context,
+	objectType domain.TrafficObjectType,
+	objectId string,
+	from, to time.Time,
+) ([]domain.TrafficSample, error) {
+	var samples []domain.TrafficSample
+
+	err := r.db.WithContext(ctx).
+		Where("object_type = ? AND object_id = ? AND sampled_at >= ? AND sampled_at < ?",
+			objectType, objectId, from.UTC(), to.UTC()).
+		Order("sampled_at").
+		Find(&samples).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return samples, nil
+}
+
+// DownsampleTrafficSamples merges all samples of the source resolution that are older than the given timestamp
+// into samples of the target resolution. Existing samples of the target resolution are updated.
+func (r *SqlRepo) DownsampleTrafficSamples(
+	ctx context.Context,
+	source, target domain.TrafficResolution,
+	before time.Time,
+) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var samples []domain.TrafficSample
+		err := tx.Where("resolution = ? AND sampled_at < ?", source, before.UTC()).Find(&samples).Error
+		if err != nil {
+			return err
+		}
+		if len(samples) == 0 {
+			return nil
+		}
+
+		for _, bucket := range domain.DownsampleTraffic(samples, target) {
+			var existing []domain.TrafficSample
+			err := tx.Where("object_type = ? AND object_id = ? AND resolution = ? AND sampled_at = ?",
+				bucket.ObjectType, bucket.ObjectId, bucket.Resolution, bucket.Timestamp).
+				Limit(1).Find(&existing).Error
+			if err != nil {
+				return err
+			}
+
+			if len(existing) == 0 {
+				err = tx.Create(&bucket).Error
+			} else {
+				existing[0].BytesReceived += bucket.BytesReceived
+				existing[0].BytesTransmitted += bucket.BytesTransmitted
+				err = tx.Save(&existing[0]).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Where("resolution = ? AND sampled_at < ?", source, before.UTC()).
+			Delete(&domain.TrafficSample{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteTrafficSamples deletes all samples of the given resolution that are older than the given timestamp.
+func (r *SqlRepo) DeleteTrafficSamples(
+	ctx context.Context,
+	resolution domain.TrafficResolution,
+	before time.Time,
+) error {
+	err := r.db.WithContext(ctx).
+		Where("resolution = ? AND sampled_at < ?", resolution, before.UTC()).
+		Delete(&domain.TrafficSample{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RenameTrafficSamples moves all samples of the given object to a new object identifier.
+func (r *SqlRepo) RenameTrafficSamples(
+	ctx context.Context,
+	objectType domain.TrafficObjectType,
+	oldId, newId string,
+) error {
+	err := r.db.WithContext(ctx).Model(&domain.TrafficSample{}).
+		Where("object_type = ? AND object_id = ?", objectType, oldId).
+		Update("object_id", newId).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CreatePeerSession stores a new peer session.
+func (r *SqlRepo) CreatePeerSession(ctx context.Context, session *domain.PeerSession) error {
+	err := r.db.WithContext(ctx).Create(session).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateActivePeerSession updates the active session of the given peer.
+// If the peer has no active session, domain.ErrNotFound is returned.
+func (r *SqlRepo) UpdateActivePeerSession(
+	ctx context.Context,
+	id domain.PeerIdentifier,
+	updateFunc func(session *domain.PeerSession) (*domain.PeerSession, error),
+) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sessions []domain.PeerSession
+		err := tx.Where("peer_id = ? AND ended_at IS NULL", id).
+			Order("started_at DESC").Limit(1).Find(&sessions).Error
+		if err != nil {
+			return err
+		}
+		if len(sessions) == 0 {
+			return domain.ErrNotFound
+		}
+
+		session, err := updateFunc(&sessions[0])
+		if err != nil {
+			return err
+		}
+
+		return tx.Save(session).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetPeerSessions returns all sessions of the given peers that overlap the time range [from, to).
+// The sessions are sorted by start time, newest first.
+func (r *SqlRepo) GetPeerSessions(
+	ctx context.Context,
+	from, to time.Time,
+	ids ...domain.PeerIdentifier,
+) ([]domain.PeerSession, error) {
+	if len(ids) == 0 {
+		return []domain.PeerSession{}, nil
+	}
+
+	var sessions []domain.PeerSession
+	err := r.db.WithContext(ctx).
+		Where("peer_id IN ? AND started_at < ? AND (ended_at IS NULL OR ended_at >= ?)", ids, to, from).
+		Order("started_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// DeletePeerSessions deletes all sessions that ended before the given timestamp.
+func (r *SqlRepo) DeletePeerSessions(ctx context.Context, before time.Time) error {
+	err := r.db.WithContext(ctx).Where("ended_at < ?", before).Delete(&domain.PeerSession{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RenamePeerSessions moves all sessions of the given peer to a new peer identifier.
+func (r *SqlRepo) RenamePeerSessions(ctx context.Context, oldId, newId domain.PeerIdentifier) error {
+	err := r.db.WithContext(ctx).Model(&domain.PeerSession{}).
+		Where("peer_id = ?", oldId).
+		Update("peer_id", newId).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // endregion statistics
 
 // region audit
@@ -1085,3 +1403,665 @@ func (r *SqlRepo) GetAllAuditEntries(ctx context.Context) ([]domain.AuditEntry,
 }
 
 // endregion audit
+
+// region share links
+
+// GetPeerShareLink returns the share link with the given id.
+// If no share link is found, an error domain.ErrNotFound is returned.
+func (r *SqlRepo) GetPeerShareLink(ctx context.Context, id domain.PeerShareLinkIdentifier) (
+	*domain.PeerShareLink,
+	error,
+) {
+	var link domain.PeerShareLink
+
+	err := r.db.WithContext(ctx).First(&link, id).Error
+
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+// GetPeerShareLinks returns all share links of the given peer, newest first.
+func (r *SqlRepo) GetPeerShareLinks(ctx context.Context, peerId domain.PeerIdentifier) (
+	[]domain.PeerShareLink,
+	error,
+) {
+	var links []domain.PeerShareLink
+
+	err := r.db.WithContext(ctx).Where("peer_identifier = ?", peerId).Order("created_at desc").Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// SavePeerShareLink updates the share link with the given id.
+// If no share link is found, a new one is created.
+func (r *SqlRepo) SavePeerShareLink(
+	ctx context.Context,
+	id domain.PeerShareLinkIdentifier,
+	updateFunc func(l *domain.PeerShareLink) (*domain.PeerShareLink, error),
+) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var link domain.PeerShareLink
+
+		err := tx.Where("identifier = ?", id).Limit(1).Find(&link).Error
+		if err != nil {
+			return err
+		}
+		link.Identifier = id
+
+		updatedLink, err := updateFunc(&link)
+		if err != nil {
+			return err // return any error will roll back
+		}
+
+		err = tx.Save(updatedLink).Error
+		if err != nil {
+			return err
+		}
+
+		// return nil will commit the whole transaction
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeletePeerShareLinks deletes all share links of the given peer.
+func (r *SqlRepo) DeletePeerShareLinks(ctx context.Context, peerId domain.PeerIdentifier) error {
+	err := r.db.WithContext(ctx).Where("peer_identifier = ?", peerId).Delete(&domain.PeerShareLink{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteExpiredPeerShareLinks deletes all share links that expired before the given timestamp.
+func (r *SqlRepo) DeleteExpiredPeerShareLinks(ctx context.Context, before time.Time) error {
+	err := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&domain.PeerShareLink{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// endregion share links
+
+// region api tokens
+
+// GetApiToken returns the API token with the given id.
+// If no token is found, an error domain.ErrNotFound is returned.
+func (r *SqlRepo) GetApiToken(ctx context.Context, id domain.ApiTokenIdentifier) (*domain.ApiToken, error) {
+	var token domain.ApiToken
+
+	err := r.db.WithContext(ctx).First(&token, id).Error
+
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// GetUserApiTokens returns all API tokens of the given user, newest first.
+func (r *SqlRepo) GetUserApiTokens(ctx context.Context, userId domain.UserIdentifier) ([]domain.ApiToken, error) {
+	var tokens []domain.ApiToken
+
+	err := r.db.WithContext(ctx).Where("user_identifier = ?", userId).Order("created_at desc").Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// SaveApiToken updates the API token with the given id.
+// If no token is found, a new one is created.
+func (r *SqlRepo) SaveApiToken(
+	ctx context.Context,
+	id domain.ApiTokenIdentifier,
+	updateFunc func(t *domain.ApiToken) (*domain.ApiToken, error),
+) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var token domain.ApiToken
+
+		err := tx.Where("identifier = ?", id).Limit(1).Find(&token).Error
+		if err != nil {
+			return err
+		}
+		token.Identifier = id
+
+		updatedToken, err := updateFunc(&token)
+		if err != nil {
+			return err // return any error will roll back
+		}
+
+		err = tx.Save(updatedToken).Error
+		if err != nil {
+			return err
+		}
+
+		// return nil will commit the whole transaction
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteApiToken deletes the API token with the given id.
+func (r *SqlRepo) DeleteApiToken(ctx context.Context, id domain.ApiTokenIdentifier) error {
+	err := r.db.WithContext(ctx).Delete(&domain.ApiToken{}, id).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// endregion api tokens
+
+// region login attempts
+
+// GetLoginAttempt returns the login attempts with the given id.
+// If no attempts are found, an error domain.ErrNotFound is returned.
+func (r *SqlRepo) GetLoginAttempt(ctx context.Context, id string) (*domain.LoginAttempt, error) {
+	var attempt domain.LoginAttempt
+
+	err := r.db.WithContext(ctx).Where("identifier = ?", id).First(&attempt).Error
+
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+// GetBlockedLoginAttempts returns all login attempts that are blocked at the given time.
+func (r *SqlRepo) GetBlockedLoginAttempts(ctx context.Context, now time.Time) ([]domain.LoginAttempt, error) {
+	var attempts []domain.LoginAttempt
+
+	err := r.db.WithContext(ctx).Where("blocked_until > ?", now).Order("blocked_until desc").Find(&attempts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// SaveLoginAttempt updates the login attempts with the given id.
+// If no attempts are found, new ones are created.
+func (r *SqlRepo) SaveLoginAttempt(
+	ctx context.Context,
+	id string,
+	updateFunc func(a *domain.LoginAttempt) (*domain.LoginAttempt, error),
+) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var attempt domain.LoginAttempt
+
+		err := tx.Where("identifier = ?", id).Limit(1).Find(&attempt).Error
+		if err != nil {
+			return err
+		}
+		attempt.Identifier = id
+
+		updatedAttempt, err := updateFunc(&attempt)
+		if err != nil {
+			return err // return any error will roll back
+		}
+
+		err = tx.Save(updatedAttempt).Error
+		if err != nil {
+			return err
+		}
+
+		// return nil will commit the whole transaction
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteLoginAttempt deletes the login attempts with the given id.
+func (r *SqlRepo) DeleteLoginAttempt(ctx context.Context, id string) error {
+	err := r.db.WithContext(ctx).Where("identifier = ?", id).Delete(&domain.LoginAttempt{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteStaleLoginAttempts deletes all login attempts without failures after the given time that are not blocked.
+func (r *SqlRepo) DeleteStaleLoginAttempts(ctx context.Context, before time.Time) error {
+	err := r.db.WithContext(ctx).
+		Where("last_failure < ? AND (blocked_until IS NULL OR blocked_until < ?)", before, time.Now()).
+		Delete(&domain.LoginAttempt{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// endregion login attempts
+
+// region ipam
+
+// GetIpRanges returns all address pools and exclusion ranges of the given interface.
+func (r *SqlRepo) GetIpRanges(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.IpRange, error) {
+	var ranges []domain.IpRange
+
+	err := r.db.WithContext(ctx).Where("interface_identifier = ?", id).Order("priority, start_addr").
+		Find(&ranges).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return ranges, nil
+}
+
+// SaveIpRange updates the address range with the given id.
+// If no address range is found, a new one is created.
+func (r *SqlRepo) SaveIpRange(
+	ctx context.Context,
+	id domain.IpRangeIdentifier,
+	updateFunc func(in *domain.IpRange) (*domain.IpRange, error),
+) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ipRange domain.IpRange
+
+		err := tx.Where("identifier = ?", id).Limit(1).Find(&ipRange).Error
+		if err != nil {
+			return err
+		}
+		ipRange.Identifier = id
+
+		updatedRange, err := updateFunc(&ipRange)
+		if err != nil {
+			return err // return any error will roll back
+		}
+
+		err = tx.Save(updatedRange).Error
+		if err != nil {
+			return err
+		}
+
+		// return nil will commit the whole transaction
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteIpRange deletes the address range with the given id.
+func (r *SqlRepo) DeleteIpRange(
+	ctx context.Context,
+	id domain.InterfaceIdentifier,
+	rangeId domain.IpRangeIdentifier,
+) error {
+	err := r.db.WithContext(ctx).Where("interface_identifier = ? AND identifier = ?", id, rangeId).
+		Delete(&domain.IpRange{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetIpReservations returns all address reservations of the given interface.
+func (r *SqlRepo) GetIpReservations(ctx context.Context, id domain.InterfaceIdentifier) (
+	[]domain.IpReservation,
+	error,
+) {
+	var reservations []domain.IpReservation
+
+	err := r.db.WithContext(ctx).Where("interface_identifier = ?", id).Order("address").
+		Find(&reservations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// SaveIpReservation creates or updates the given address reservation.
+func (r *SqlRepo) SaveIpReservation(ctx context.Context, reservation *domain.IpReservation) error {
+	err := r.db.WithContext(ctx).Save(reservation).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteIpReservation deletes the reservation of the given address.
+func (r *SqlRepo) DeleteIpReservation(ctx context.Context, id domain.InterfaceIdentifier, address string) error {
+	err := r.db.WithContext(ctx).Where("interface_identifier = ? AND address = ?", id, address).
+		Delete(&domain.IpReservation{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// endregion ipam
+
+// region site-networks
+
+// GetSiteNetworks returns all site networks, including their sites and links.
+func (r *SqlRepo) GetSiteNetworks(ctx context.Context) ([]domain.SiteNetwork, error) {
+	var networks []domain.SiteNetwork
+
+	err := r.db.WithContext(ctx).Order("identifier").Find(&networks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range networks {
+		if err := r.loadSiteNetworkDetails(r.db.WithContext(ctx), &networks[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return networks, nil
+}
+
+// GetSiteNetwork returns the site network with the given id, including its sites and links.
+// If no site network is found, an error domain.ErrNotFound is returned.
+func (r *SqlRepo) GetSiteNetwork(ctx context.Context, id domain.SiteNetworkIdentifier) (
+	*domain.SiteNetwork,
+	error,
+) {
+	var network domain.SiteNetwork
+
+	err := r.db.WithContext(ctx).Where("identifier = ?", id).First(&network).Error
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadSiteNetworkDetails(r.db.WithContext(ctx), &network); err != nil {
+		return nil, err
+	}
+
+	return &network, nil
+}
+
+// GetInterfaceSites returns all sites that are linked to the given interface.
+func (r *SqlRepo) GetInterfaceSites(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.Site, error) {
+	var sites []domain.Site
+
+	err := r.db.WithContext(ctx).Where("interface_identifier = ?", id).Find(&sites).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sites, nil
+}
+
+// SaveSiteNetwork updates the site network with the given id, the sites and links are replaced.
+// If no site network is found, a new one is created.
+func (r *SqlRepo) SaveSiteNetwork(
+	ctx context.Context,
+	id domain.SiteNetworkIdentifier,
+	updateFunc func(in *domain.SiteNetwork) (*domain.SiteNetwork, error),
+) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var network domain.SiteNetwork
+
+		err := tx.Where("identifier = ?", id).Limit(1).Find(&network).Error
+		if err != nil {
+			return err
+		}
+		network.Identifier = id
+		if err := r.loadSiteNetworkDetails(tx, &network); err != nil {
+			return err
+		}
+
+		updatedNetwork, err := updateFunc(&network)
+		if err != nil {
+			return err // return any error will roll back
+		}
+
+		err = tx.Save(updatedNetwork).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("network_identifier = ?", id).Delete(&domain.Site{}).Error
+		if err != nil {
+			return err
+		}
+		for i := range updatedNetwork.Sites {
+			updatedNetwork.Sites[i].NetworkIdentifier = id
+			if err := tx.Create(&updatedNetwork.Sites[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		err = tx.Where("network_identifier = ?", id).Delete(&domain.SiteLink{}).Error
+		if err != nil {
+			return err
+		}
+		for i := range updatedNetwork.Links {
+			updatedNetwork.Links[i].NetworkIdentifier = id
+			if err := tx.Create(&updatedNetwork.Links[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		// return nil will commit the whole transaction
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteSiteNetwork deletes the site network with the given id, including its sites and links.
+func (r *SqlRepo) DeleteSiteNetwork(ctx context.Context, id domain.SiteNetworkIdentifier) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("network_identifier = ?", id).Delete(&domain.SiteLink{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("network_identifier = ?", id).Delete(&domain.Site{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("identifier = ?", id).Delete(&domain.SiteNetwork{}).Error
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *SqlRepo) loadSiteNetworkDetails(tx *gorm.DB, network *domain.SiteNetwork) error {
+	err := tx.Where("network_identifier = ?", network.Identifier).Order("identifier").Find(&network.Sites).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Where("network_identifier = ?", network.Identifier).Order("site_a, site_b").Find(&network.Links).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// endregion site-networks
+
+// region peer-tags
+
+// GetPeerTags returns all tags that carry a policy, ordered by name.
+func (r *SqlRepo) GetPeerTags(ctx context.Context) ([]domain.PeerTag, error) {
+	var tags []domain.PeerTag
+
+	err := r.db.WithContext(ctx).Order("name").Find(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// GetPeerTag returns the tag with the given name.
+// If no tag is found, an error domain.ErrNotFound is returned.
+func (r *SqlRepo) GetPeerTag(ctx context.Context, name string) (*domain.PeerTag, error) {
+	var tag domain.PeerTag
+
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&tag).Error
+
+	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+// SavePeerTag updates the tag with the given name.
+// If no tag is found, a new one is created.
+func (r *SqlRepo) SavePeerTag(
+	ctx context.Context,
+	name string,
+	updateFunc func(t *domain.PeerTag) (*domain.PeerTag, error),
+) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var tag domain.PeerTag
+
+		err := tx.Where("name = ?", name).Limit(1).Find(&tag).Error
+		if err != nil {
+			return err
+		}
+		tag.Name = name
+
+		updatedTag, err := updateFunc(&tag)
+		if err != nil {
+			return err // return any error will roll back
+		}
+
+		err = tx.Save(updatedTag).Error
+		if err != nil {
+			return err
+		}
+
+		// return nil will commit the whole transaction
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeletePeerTag deletes the tag with the given name. Peers keep the tag.
+func (r *SqlRepo) DeletePeerTag(ctx context.Context, name string) error {
+	err := r.db.WithContext(ctx).Where("name = ?", name).Delete(&domain.PeerTag{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// endregion peer-tags
+
+// region bundles
+
+// SaveInterfaceBundle stores the content of an imported interface bundle in a single transaction, so that a failed
+// import leaves no partially imported records behind. Existing records are overwritten, but keep their calculated
+// attributes. Existing users also keep their local attributes like their credentials.
+func (r *SqlRepo) SaveInterfaceBundle(ctx context.Context, content *domain.InterfaceBundleContent) error {
+	userInfo := domain.GetUserInfo(ctx)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		in, err := r.getOrCreateInterface(userInfo, tx, content.Interface.Identifier)
+		if err != nil {
+			return err // return any error will roll back
+		}
+		content.Interface.CopyCalculatedAttributes(in)
+		if err := r.upsertInterface(userInfo, tx, &content.Interface); err != nil {
+			return fmt.Errorf("failed to store interface %s: %w", content.Interface.Identifier, err)
+		}
+
+		for i := range content.Users {
+			user := &content.Users[i]
+			existing, err := r.getOrCreateUser(userInfo, tx, user.Identifier)
+			if err != nil {
+				return err
+			}
+			user.CopyCalculatedAttributes(existing)
+			user.CopyLocalAttributes(existing)
+			if err := r.upsertUser(userInfo, tx, user); err != nil {
+				return fmt.Errorf("failed to store user %s: %w", user.Identifier, err)
+			}
+		}
+
+		for i := range content.Peers {
+			peer := &content.Peers[i]
+			existing, err := r.getOrCreatePeer(userInfo, tx, peer.Identifier)
+			if err != nil {
+				return err
+			}
+			peer.CopyCalculatedAttributes(existing)
+			if err := r.upsertPeer(userInfo, tx, peer); err != nil {
+				return fmt.Errorf("failed to store peer %s: %w", peer.Identifier, err)
+			}
+		}
+
+		// return nil will commit the whole transaction
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// endregion bundles
@@ -25,12 +25,19 @@ type MetricsServer struct {
 	peerLastHandshakeSeconds *prometheus.GaugeVec
 	peerReceivedBytesTotal   *prometheus.GaugeVec
 	peerSendBytesTotal       *prometheus.GaugeVec
+	peerQuotaLimitBytes      *prometheus.GaugeVec
+	peerQuotaUsedBytes       *prometheus.GaugeVec
+	ipPoolSize               *prometheus.GaugeVec
+	ipPoolUsed               *prometheus.GaugeVec
+	ipPoolReserved           *prometheus.GaugeVec
+	ipPoolFree               *prometheus.GaugeVec
 }
 
 // Wireguard metrics labels
 var (
 	ifaceLabels = []string{"interface"}
 	peerLabels  = []string{"interface", "addresses", "id", "name"}
+	poolLabels  = []string{"interface", "network", "pool", "name"}
 )
 
 // NewMetricsServer returns a new prometheus server
@@ -83,6 +90,43 @@ func NewMetricsServer(cfg *config.Config) *MetricsServer {
 				Help: "Bytes sent to the peer.",
 			}, peerLabels,
 		),
+		peerQuotaLimitBytes: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "wireguard_peer_quota_limit_bytes",
+				Help: "Traffic quota of the peer per period (0 if no quota applies).",
+			}, peerLabels,
+		),
+		peerQuotaUsedBytes: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "wireguard_peer_quota_used_bytes",
+				Help: "Traffic of the peer in the current quota period.",
+			}, peerLabels,
+		),
+
+		ipPoolSize: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "wireguard_ip_pool_size",
+				Help: "Number of addresses in the address pool, excluding exclusion ranges.",
+			}, poolLabels,
+		),
+		ipPoolUsed: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "wireguard_ip_pool_used",
+				Help: "Number of addresses of the address pool that are assigned.",
+			}, poolLabels,
+		),
+		ipPoolReserved: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "wireguard_ip_pool_reserved",
+				Help: "Number of reserved addresses of the address pool that are not assigned yet.",
+			}, poolLabels,
+		),
+		ipPoolFree: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "wireguard_ip_pool_free",
+				Help: "Number of addresses of the address pool that can still be allocated.",
+			}, poolLabels,
+		),
 	}
 }
 
@@ -134,4 +178,23 @@ func (m *MetricsServer) UpdatePeerMetrics(peer *domain.Peer, status domain.PeerS
 	m.peerReceivedBytesTotal.WithLabelValues(labels...).Set(float64(status.BytesReceived))
 	m.peerSendBytesTotal.WithLabelValues(labels...).Set(float64(status.BytesTransmitted))
 	m.peerIsConnected.WithLabelValues(labels...).Set(internal.BoolToFloat64(status.IsConnected))
+	m.peerQuotaLimitBytes.WithLabelValues(labels...).Set(float64(status.QuotaLimit))
+	m.peerQuotaUsedBytes.WithLabelValues(labels...).Set(float64(status.QuotaUsed()))
+}
+
+// UpdateIpamMetrics updates the address pool metrics of the given interface.
+// Metrics of pools that no longer exist are removed.
+func (m *MetricsServer) UpdateIpamMetrics(id domain.InterfaceIdentifier, utilization []domain.IpPoolUtilization) {
+	gauges := []*prometheus.GaugeVec{m.ipPoolSize, m.ipPoolUsed, m.ipPoolReserved, m.ipPoolFree}
+	for _, gauge := range gauges {
+		gauge.DeletePartialMatch(prometheus.Labels{"interface": string(id)})
+	}
+
+	for _, pool := range utilization {
+		labels := []string{string(id), pool.Network, string(pool.Pool), pool.Name}
+		m.ipPoolSize.WithLabelValues(labels...).Set(float64(pool.Size - pool.Excluded))
+		m.ipPoolUsed.WithLabelValues(labels...).Set(float64(pool.Used))
+		m.ipPoolReserved.WithLabelValues(labels...).Set(float64(pool.Reserved))
+		m.ipPoolFree.WithLabelValues(labels...).Set(float64(pool.Free))
+	}
 }
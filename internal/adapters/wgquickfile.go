@@ -0,0 +1,379 @@
+package adapters
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+const wgPortalTag = "-WGP-"
+
+// timeStringLayout is the layout of time.Time.String(), used by the config file templates.
+const timeStringLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+// WgQuickFileRepo reads wg-quick configuration files from a directory, for example /etc/wireguard.
+type WgQuickFileRepo struct {
+	basePath string
+}
+
+// NewWgQuickFileRepository creates a new WgQuickFileRepo instance.
+// If the base path is empty, the repository will not return any interfaces.
+func NewWgQuickFileRepository(basePath string) *WgQuickFileRepo {
+	return &WgQuickFileRepo{basePath: basePath}
+}
+
+// GetInterfaceConfigs parses all *.conf files in the base directory.
+// Files that cannot be parsed are skipped and logged.
+func (r *WgQuickFileRepo) GetInterfaceConfigs(_ context.Context) ([]domain.ConfigFileInterface, error) {
+	if r.basePath == "" {
+		return nil, nil // file import disabled
+	}
+
+	files, err := filepath.Glob(filepath.Join(r.basePath, "*.conf"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list config files in %s: %w", r.basePath, err)
+	}
+
+	interfaces := make([]domain.ConfigFileInterface, 0, len(files))
+	for _, file := range files {
+		iface, err := r.parseFile(file)
+		if err != nil {
+			slog.Warn("skipping unparsable wg-quick config file", "file", file, "error", err)
+			continue
+		}
+		interfaces = append(interfaces, *iface)
+	}
+
+	return interfaces, nil
+}
+
+func (r *WgQuickFileRepo) parseFile(path string) (*domain.ConfigFileInterface, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func(file *os.File) {
+		if err := file.Close(); err != nil {
+			slog.Error("failed to close file", "file", file.Name(), "error", err)
+		}
+	}(file)
+
+	fileName := filepath.Base(path)
+	id := domain.InterfaceIdentifier(strings.TrimSuffix(fileName, ".conf"))
+
+	iface, err := ParseWgQuickConfig(id, file)
+	if err != nil {
+		return nil, err
+	}
+	iface.FileName = fileName
+
+	return iface, nil
+}
+
+// ParseWgQuickConfig parses a wg-quick configuration. Comments tagged with -WGP- (written by the
+// WireGuard Portal config templates) are used to restore portal specific data like display names or private keys.
+func ParseWgQuickConfig(id domain.InterfaceIdentifier, reader io.Reader) (*domain.ConfigFileInterface, error) {
+	iface := &domain.ConfigFileInterface{
+		PhysicalInterface: domain.PhysicalInterface{
+			Identifier:   id,
+			ImportSource: "file",
+		},
+	}
+
+	var (
+		section  string
+		peer     *domain.ConfigFilePeer
+		lineNo   int
+		tagType  domain.InterfaceType
+		scanner  = bufio.NewScanner(reader)
+		finalize = func() {
+			if peer != nil {
+				iface.Peers = append(iface.Peers, *peer)
+				peer = nil
+			}
+		}
+	)
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+			continue
+		case strings.EqualFold(line, "[Interface]"):
+			finalize()
+			section = "interface"
+			continue
+		case strings.EqualFold(line, "[Peer]"):
+			finalize()
+			section = "peer"
+			peer = &domain.ConfigFilePeer{}
+			continue
+		case strings.HasPrefix(line, "#"):
+			comment := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			switch section {
+			case "interface":
+				parseInterfaceComment(iface, &tagType, comment)
+			case "peer":
+				parsePeerComment(peer, comment)
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: invalid syntax", lineNo)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		var err error
+		switch section {
+		case "interface":
+			err = parseInterfaceValue(iface, key, value)
+		case "peer":
+			err = parsePeerValue(peer, key, value)
+		default:
+			err = fmt.Errorf("value outside of section")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	finalize()
+
+	if iface.PrivateKey == "" {
+		return nil, fmt.Errorf("missing interface private key")
+	}
+
+	iface.Type = tagType
+	if iface.Type == "" {
+		iface.Type = detectInterfaceType(iface)
+	}
+
+	return iface, nil
+}
+
+func parseInterfaceValue(iface *domain.ConfigFileInterface, key, value string) error {
+	var err error
+	switch key {
+	case "privatekey":
+		var privateKey wgtypes.Key
+		privateKey, err = wgtypes.ParseKey(value)
+		if err == nil {
+			iface.KeyPair = domain.KeyPair{
+				PrivateKey: privateKey.String(),
+				PublicKey:  privateKey.PublicKey().String(),
+			}
+		}
+	case "listenport":
+		iface.ListenPort, err = strconv.Atoi(value)
+	case "address":
+		var addresses []domain.Cidr
+		addresses, err = domain.CidrsFromString(value)
+		iface.Addresses = append(iface.Addresses, addresses...)
+	case "dns":
+		for _, dnsValue := range strings.Split(value, ",") {
+			dnsValue = strings.TrimSpace(dnsValue)
+			if _, ipErr := netip.ParseAddr(dnsValue); ipErr == nil {
+				iface.DnsStr = appendListValue(iface.DnsStr, dnsValue)
+			} else {
+				iface.DnsSearchStr = appendListValue(iface.DnsSearchStr, dnsValue)
+			}
+		}
+	case "mtu":
+		iface.Mtu, err = strconv.Atoi(value)
+	case "fwmark":
+		var fwMark uint64
+		fwMark, err = strconv.ParseUint(value, 0, 32)
+		iface.FirewallMark = uint32(fwMark)
+	case "table":
+		iface.RoutingTable = value
+	case "preup":
+		iface.PreUp = appendHookValue(iface.PreUp, value)
+	case "postup":
+		iface.PostUp = appendHookValue(iface.PostUp, value)
+	case "predown":
+		iface.PreDown = appendHookValue(iface.PreDown, value)
+	case "postdown":
+		iface.PostDown = appendHookValue(iface.PostDown, value)
+	case "saveconfig":
+		iface.SaveConfig, err = strconv.ParseBool(value)
+	default:
+		slog.Debug("ignoring unknown interface config key", "interface", iface.Identifier, "key", key)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return nil
+}
+
+func parsePeerValue(peer *domain.ConfigFilePeer, key, value string) error {
+	var err error
+	switch key {
+	case "publickey":
+		var publicKey wgtypes.Key
+		publicKey, err = wgtypes.ParseKey(value)
+		if err == nil {
+			peer.PublicKey = publicKey.String()
+			if peer.Identifier == "" {
+				peer.Identifier = domain.PeerIdentifier(peer.PublicKey)
+			}
+		}
+	case "presharedkey":
+		peer.PresharedKey = domain.PreSharedKey(value)
+	case "allowedips":
+		var allowedIPs []domain.Cidr
+		allowedIPs, err = domain.CidrsFromString(value)
+		peer.AllowedIPs = append(peer.AllowedIPs, allowedIPs...)
+	case "endpoint":
+		peer.Endpoint = value
+	case "persistentkeepalive":
+		if value != "off" {
+			peer.PersistentKeepalive, err = strconv.Atoi(value)
+		}
+	default:
+		slog.Debug("ignoring unknown peer config key", "key", key)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return nil
+}
+
+func parseInterfaceComment(iface *domain.ConfigFileInterface, ifaceType *domain.InterfaceType, comment string) {
+	key, value, ok := parseTaggedComment(comment)
+	if !ok {
+		return
+	}
+
+	switch key {
+	case "interface":
+		iface.Identifier = domain.InterfaceIdentifier(value)
+	case "display name":
+		iface.DisplayName = value
+	case "interface mode":
+		*ifaceType = domain.InterfaceType(value)
+	case "peer type": // configuration file of a single peer
+		*ifaceType = domain.InterfaceType(value)
+	case "created":
+		iface.CreatedAt = parseTimeString(value)
+	case "updated":
+		iface.UpdatedAt = parseTimeString(value)
+	}
+}
+
+func parsePeerComment(peer *domain.ConfigFilePeer, comment string) {
+	if strings.HasPrefix(comment, "friendly_name") {
+		_, value, _ := strings.Cut(comment, "=")
+		if peer.DisplayName == "" {
+			peer.DisplayName = strings.TrimSpace(value)
+		}
+		return
+	}
+
+	key, value, ok := parseTaggedComment(comment)
+	if !ok {
+		return
+	}
+
+	switch key {
+	case "peer":
+		peer.Identifier = domain.PeerIdentifier(value)
+	case "display name":
+		peer.DisplayName = value
+	case "privatekey":
+		peer.PrivateKey = value
+	case "created":
+		peer.CreatedAt = parseTimeString(value)
+	case "updated":
+		peer.UpdatedAt = parseTimeString(value)
+	}
+}
+
+// parseTaggedComment parses comments like "-WGP- Display name: value". The returned key is lower case.
+func parseTaggedComment(comment string) (key, value string, ok bool) {
+	if !strings.HasPrefix(comment, wgPortalTag) {
+		return "", "", false
+	}
+
+	comment = strings.TrimSpace(strings.TrimPrefix(comment, wgPortalTag))
+	key, value, ok = strings.Cut(comment, ":")
+	if !ok {
+		key, value, ok = strings.Cut(comment, "=")
+	}
+	if !ok {
+		return "", "", false
+	}
+
+	return strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value), true
+}
+
+func parseTimeString(value string) *time.Time {
+	// strip the monotonic clock reading, if present
+	if idx := strings.Index(value, " m="); idx != -1 {
+		value = value[:idx]
+	}
+
+	t, err := time.Parse(timeStringLayout, value)
+	if err != nil {
+		return nil
+	}
+
+	return &t
+}
+
+func detectInterfaceType(iface *domain.ConfigFileInterface) domain.InterfaceType {
+	if len(iface.Peers) == 0 {
+		return domain.InterfaceTypeServer
+	}
+
+	allPeersHaveEndpoints := true
+	for _, peer := range iface.Peers {
+		if peer.Endpoint == "" {
+			allPeersHaveEndpoints = false
+			break
+		}
+	}
+
+	switch {
+	case allPeersHaveEndpoints && iface.ListenPort == 0:
+		return domain.InterfaceTypeClient
+	case !allPeersHaveEndpoints && iface.ListenPort != 0:
+		return domain.InterfaceTypeServer
+	default:
+		return domain.InterfaceTypeAny
+	}
+}
+
+func appendListValue(list, value string) string {
+	if list == "" {
+		return value
+	}
+	return list + "," + value
+}
+
+func appendHookValue(hooks, value string) string {
+	if hooks == "" {
+		return value
+	}
+	return hooks + "; " + value
+}
@@ -0,0 +1,115 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+const testWgQuickConfig = `
+[Interface]
+PrivateKey = aPxGwq8zERHQ3Q1cOZFdJ+cvJX5Ka4mLN38AyYKYF10=
+Address = 10.0.0.1/24, fd00::1/64
+ListenPort = 51820
+DNS = 1.1.1.1, 2606:4700:4700::1111, example.com
+MTU = 1420
+FwMark = 0x10
+Table = off
+PreUp = echo pre
+PostUp = echo up1
+PostUp = echo up2
+PostDown = echo down
+
+[Peer]
+# friendly_name = Laptop
+PublicKey = xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=
+PresharedKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+AllowedIPs = 10.0.0.2/32, 192.168.5.0/24
+
+[Peer]
+PublicKey = TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0=
+AllowedIPs = 10.0.0.3/32
+`
+
+func TestParseWgQuickConfig(t *testing.T) {
+	iface, err := ParseWgQuickConfig("wg0", strings.NewReader(testWgQuickConfig))
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.InterfaceIdentifier("wg0"), iface.Identifier)
+	assert.Equal(t, "file", iface.ImportSource)
+	assert.Equal(t, "aPxGwq8zERHQ3Q1cOZFdJ+cvJX5Ka4mLN38AyYKYF10=", iface.PrivateKey)
+	assert.NotEmpty(t, iface.PublicKey)
+	assert.Equal(t, "10.0.0.1/24,fd00::1/64", domain.CidrsToString(iface.Addresses))
+	assert.Equal(t, 51820, iface.ListenPort)
+	assert.Equal(t, "1.1.1.1,2606:4700:4700::1111", iface.DnsStr)
+	assert.Equal(t, "example.com", iface.DnsSearchStr)
+	assert.Equal(t, 1420, iface.Mtu)
+	assert.Equal(t, uint32(16), iface.FirewallMark)
+	assert.Equal(t, "off", iface.RoutingTable)
+	assert.Equal(t, "echo pre", iface.PreUp)
+	assert.Equal(t, "echo up1; echo up2", iface.PostUp)
+	assert.Equal(t, "echo down", iface.PostDown)
+	assert.Equal(t, domain.InterfaceTypeServer, iface.Type)
+
+	require.Len(t, iface.Peers, 2)
+	assert.Equal(t, "Laptop", iface.Peers[0].DisplayName)
+	assert.Equal(t, domain.PeerIdentifier("xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="), iface.Peers[0].Identifier)
+	assert.Equal(t, domain.PreSharedKey("yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk="), iface.Peers[0].PresharedKey)
+	assert.Len(t, iface.Peers[0].AllowedIPs, 2)
+	assert.Empty(t, iface.Peers[1].DisplayName)
+}
+
+func TestParseWgQuickConfig_Invalid(t *testing.T) {
+	_, err := ParseWgQuickConfig("wg0", strings.NewReader("[Interface]\nAddress = 10.0.0.1/24\n"))
+	assert.Error(t, err, "missing private key")
+
+	_, err = ParseWgQuickConfig("wg0", strings.NewReader("[Interface]\nPrivateKey\n"))
+	assert.Error(t, err, "invalid syntax")
+
+	_, err = ParseWgQuickConfig("wg0", strings.NewReader("[Interface]\nListenPort = abc\n"))
+	assert.Error(t, err, "invalid port")
+}
+
+func TestParseWgQuickConfig_ClientDetection(t *testing.T) {
+	cfg := `[Interface]
+PrivateKey = aPxGwq8zERHQ3Q1cOZFdJ+cvJX5Ka4mLN38AyYKYF10=
+Address = 10.0.0.2/32
+
+[Peer]
+PublicKey = xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=
+AllowedIPs = 0.0.0.0/0
+Endpoint = vpn.example.com:51820
+PersistentKeepalive = 25
+`
+	iface, err := ParseWgQuickConfig("wg1", strings.NewReader(cfg))
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.InterfaceTypeClient, iface.Type)
+	require.Len(t, iface.Peers, 1)
+	assert.Equal(t, "vpn.example.com:51820", iface.Peers[0].Endpoint)
+	assert.Equal(t, 25, iface.Peers[0].PersistentKeepalive)
+}
+
+func TestWgQuickFileRepo_GetInterfaceConfigs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "wg0.conf"), []byte(testWgQuickConfig), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.conf"), []byte("garbage"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0600))
+
+	repo := NewWgQuickFileRepository(dir)
+	interfaces, err := repo.GetInterfaceConfigs(context.Background())
+	require.NoError(t, err)
+	require.Len(t, interfaces, 1)
+	assert.Equal(t, "wg0.conf", interfaces[0].FileName)
+
+	interfaces, err = NewWgQuickFileRepository("").GetInterfaceConfigs(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, interfaces)
+}
@@ -0,0 +1,397 @@
+package adapters
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// NewWireGuardNetstackRepository creates a new WgSoftwareRepo instance whose interfaces use an in-process
+// network stack (gVisor netstack) instead of a TUN device, so that no privileges are required.
+// Netstack interfaces are not visible to the host: they expose no UAPI socket, and the interface addresses,
+// MTU and link state are only kept in memory. Tunneled traffic terminates in the process.
+// This mode is intended for tests and for environments without access to /dev/net/tun.
+func NewWireGuardNetstackRepository() *WgSoftwareRepo {
+	repo := &WgSoftwareRepo{
+		netstack: true,
+		devices:  make(map[domain.InterfaceIdentifier]*softwareDevice),
+	}
+	repo.WgRepo = &WgRepo{
+		wg: netstackWireGuardClient{repo: repo},
+		nl: netstackNetlinkClient{repo: repo},
+	}
+
+	return repo
+}
+
+func (r *WgSoftwareRepo) netstackDevice(name string) (*softwareDevice, error) {
+	dev, ok := r.devices[domain.InterfaceIdentifier(name)]
+	if !ok {
+		return nil, fmt.Errorf("netstack interface %s: %w", name, os.ErrNotExist)
+	}
+
+	return dev, nil
+}
+
+// netstackWireGuardClient configures netstack devices directly through the UAPI protocol of wireguard-go.
+type netstackWireGuardClient struct {
+	repo *WgSoftwareRepo
+}
+
+func (c netstackWireGuardClient) Close() error { return nil }
+
+func (c netstackWireGuardClient) Devices() ([]*wgtypes.Device, error) {
+	c.repo.mux.Lock()
+	names := make([]string, 0, len(c.repo.devices))
+	for id := range c.repo.devices {
+		names = append(names, string(id))
+	}
+	c.repo.mux.Unlock()
+	slices.Sort(names)
+
+	devices := make([]*wgtypes.Device, 0, len(names))
+	for _, name := range names {
+		dev, err := c.Device(name)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, dev)
+	}
+
+	return devices, nil
+}
+
+func (c netstackWireGuardClient) Device(name string) (*wgtypes.Device, error) {
+	c.repo.mux.Lock()
+	dev, err := c.repo.netstackDevice(name)
+	c.repo.mux.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := dev.device.IpcGet()
+	if err != nil {
+		return nil, fmt.Errorf("uapi get failed: %w", err)
+	}
+
+	return parseUapiDevice(name, state)
+}
+
+func (c netstackWireGuardClient) ConfigureDevice(name string, cfg wgtypes.Config) error {
+	c.repo.mux.Lock()
+	dev, err := c.repo.netstackDevice(name)
+	c.repo.mux.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := dev.device.IpcSet(formatUapiConfig(cfg)); err != nil {
+		return fmt.Errorf("uapi set failed: %w", err)
+	}
+
+	return nil
+}
+
+// parseUapiDevice converts the response of a UAPI get operation.
+func parseUapiDevice(name, state string) (*wgtypes.Device, error) {
+	dev := &wgtypes.Device{Name: name, Type: wgtypes.Userspace}
+	var peer *wgtypes.Peer
+	var handshakeSec, handshakeNsec int64
+
+	finishPeer := func() {
+		if peer == nil {
+			return
+		}
+		if handshakeSec != 0 || handshakeNsec != 0 {
+			peer.LastHandshakeTime = time.Unix(handshakeSec, handshakeNsec)
+		}
+		dev.Peers = append(dev.Peers, *peer)
+		peer, handshakeSec, handshakeNsec = nil, 0, 0
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(state))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if !found {
+			continue
+		}
+
+		var err error
+		switch key {
+		case "private_key":
+			dev.PrivateKey, err = parseUapiKey(value)
+			dev.PublicKey = dev.PrivateKey.PublicKey()
+		case "listen_port":
+			dev.ListenPort, err = strconv.Atoi(value)
+		case "fwmark":
+			dev.FirewallMark, err = strconv.Atoi(value)
+		case "public_key":
+			finishPeer()
+			peer = &wgtypes.Peer{}
+			peer.PublicKey, err = parseUapiKey(value)
+		case "errno":
+			if value != "0" {
+				err = fmt.Errorf("uapi error %s", value)
+			}
+		default:
+			if peer == nil {
+				continue // unknown device field
+			}
+			err = parseUapiPeerField(peer, key, value, &handshakeSec, &handshakeNsec)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid uapi field %s: %w", key, err)
+		}
+	}
+	finishPeer()
+
+	return dev, scanner.Err()
+}
+
+func parseUapiPeerField(peer *wgtypes.Peer, key, value string, handshakeSec, handshakeNsec *int64) error {
+	var err error
+	switch key {
+	case "preshared_key":
+		peer.PresharedKey, err = parseUapiKey(value)
+	case "endpoint":
+		peer.Endpoint, err = net.ResolveUDPAddr("udp", value)
+	case "persistent_keepalive_interval":
+		var seconds int
+		seconds, err = strconv.Atoi(value)
+		peer.PersistentKeepaliveInterval = time.Duration(seconds) * time.Second
+	case "last_handshake_time_sec":
+		*handshakeSec, err = strconv.ParseInt(value, 10, 64)
+	case "last_handshake_time_nsec":
+		*handshakeNsec, err = strconv.ParseInt(value, 10, 64)
+	case "rx_bytes":
+		peer.ReceiveBytes, err = strconv.ParseInt(value, 10, 64)
+	case "tx_bytes":
+		peer.TransmitBytes, err = strconv.ParseInt(value, 10, 64)
+	case "protocol_version":
+		peer.ProtocolVersion, err = strconv.Atoi(value)
+	case "allowed_ip":
+		var allowedIp *net.IPNet
+		_, allowedIp, err = net.ParseCIDR(value)
+		if err == nil {
+			peer.AllowedIPs = append(peer.AllowedIPs, *allowedIp)
+		}
+	}
+
+	return err
+}
+
+func parseUapiKey(value string) (wgtypes.Key, error) {
+	raw, err := hex.DecodeString(value)
+	if err != nil {
+		return wgtypes.Key{}, err
+	}
+
+	return wgtypes.NewKey(raw)
+}
+
+// formatUapiConfig converts the configuration to the request of a UAPI set operation.
+func formatUapiConfig(cfg wgtypes.Config) string {
+	var b strings.Builder
+
+	if cfg.PrivateKey != nil {
+		fmt.Fprintf(&b, "private_key=%s\n", hex.EncodeToString(cfg.PrivateKey[:]))
+	}
+	if cfg.ListenPort != nil {
+		fmt.Fprintf(&b, "listen_port=%d\n", *cfg.ListenPort)
+	}
+	if cfg.FirewallMark != nil {
+		fmt.Fprintf(&b, "fwmark=%d\n", *cfg.FirewallMark)
+	}
+	if cfg.ReplacePeers {
+		b.WriteString("replace_peers=true\n")
+	}
+
+	for _, peer := range cfg.Peers {
+		fmt.Fprintf(&b, "public_key=%s\n", hex.EncodeToString(peer.PublicKey[:]))
+		if peer.Remove {
+			b.WriteString("remove=true\n")
+			continue
+		}
+		if peer.UpdateOnly {
+			b.WriteString("update_only=true\n")
+		}
+		if peer.PresharedKey != nil {
+			fmt.Fprintf(&b, "preshared_key=%s\n", hex.EncodeToString(peer.PresharedKey[:]))
+		}
+		if peer.Endpoint != nil {
+			fmt.Fprintf(&b, "endpoint=%s\n", peer.Endpoint.String())
+		}
+		if peer.PersistentKeepaliveInterval != nil {
+			fmt.Fprintf(&b, "persistent_keepalive_interval=%d\n", int(peer.PersistentKeepaliveInterval.Seconds()))
+		}
+		if peer.ReplaceAllowedIPs {
+			b.WriteString("replace_allowed_ips=true\n")
+		}
+		for _, allowedIp := range peer.AllowedIPs {
+			fmt.Fprintf(&b, "allowed_ip=%s\n", allowedIp.String())
+		}
+	}
+
+	return b.String()
+}
+
+// netstackNetlinkClient keeps the link state of netstack devices in memory.
+// Only the link and address operations that are used for WireGuard interfaces are supported.
+type netstackNetlinkClient struct {
+	repo *WgSoftwareRepo
+}
+
+func (c netstackNetlinkClient) device(link netlink.Link) (*softwareDevice, error) {
+	return c.repo.netstackDevice(link.Attrs().Name)
+}
+
+func (c netstackNetlinkClient) LinkAdd(netlink.Link) error { return errors.ErrUnsupported }
+
+func (c netstackNetlinkClient) LinkDel(netlink.Link) error { return errors.ErrUnsupported }
+
+func (c netstackNetlinkClient) LinkByName(name string) (netlink.Link, error) {
+	c.repo.mux.Lock()
+	defer c.repo.mux.Unlock()
+
+	dev, err := c.repo.netstackDevice(name)
+	if err != nil {
+		return nil, err
+	}
+	link := *dev.link // copy, so that the caller does not observe later changes
+
+	return &link, nil
+}
+
+func (c netstackNetlinkClient) LinkSetUp(link netlink.Link) error {
+	c.repo.mux.Lock()
+	defer c.repo.mux.Unlock()
+
+	dev, err := c.device(link)
+	if err != nil {
+		return err
+	}
+	if err := dev.device.Up(); err != nil {
+		return err
+	}
+	dev.link.OperState = netlink.OperUnknown // like kernel WireGuard links
+
+	return nil
+}
+
+func (c netstackNetlinkClient) LinkSetDown(link netlink.Link) error {
+	c.repo.mux.Lock()
+	defer c.repo.mux.Unlock()
+
+	dev, err := c.device(link)
+	if err != nil {
+		return err
+	}
+	if err := dev.device.Down(); err != nil {
+		return err
+	}
+	dev.link.OperState = netlink.OperDown
+
+	return nil
+}
+
+func (c netstackNetlinkClient) LinkSetMTU(link netlink.Link, mtu int) error {
+	c.repo.mux.Lock()
+	defer c.repo.mux.Unlock()
+
+	dev, err := c.device(link)
+	if err != nil {
+		return err
+	}
+	dev.link.MTU = mtu
+
+	return nil
+}
+
+func (c netstackNetlinkClient) AddrReplace(link netlink.Link, addr *netlink.Addr) error {
+	c.repo.mux.Lock()
+	defer c.repo.mux.Unlock()
+
+	dev, err := c.device(link)
+	if err != nil {
+		return err
+	}
+	dev.addresses = slices.DeleteFunc(dev.addresses, addr.Equal)
+	dev.addresses = append(dev.addresses, *addr)
+
+	return nil
+}
+
+func (c netstackNetlinkClient) AddrAdd(link netlink.Link, addr *netlink.Addr) error {
+	c.repo.mux.Lock()
+	defer c.repo.mux.Unlock()
+
+	dev, err := c.device(link)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(dev.addresses, addr.Equal) {
+		return os.ErrExist
+	}
+	dev.addresses = append(dev.addresses, *addr)
+
+	return nil
+}
+
+func (c netstackNetlinkClient) AddrList(link netlink.Link) ([]netlink.Addr, error) {
+	c.repo.mux.Lock()
+	defer c.repo.mux.Unlock()
+
+	dev, err := c.device(link)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.Clone(dev.addresses), nil
+}
+
+func (c netstackNetlinkClient) AddrDel(link netlink.Link, addr *netlink.Addr) error {
+	c.repo.mux.Lock()
+	defer c.repo.mux.Unlock()
+
+	dev, err := c.device(link)
+	if err != nil {
+		return err
+	}
+	dev.addresses = slices.DeleteFunc(dev.addresses, addr.Equal)
+
+	return nil
+}
+
+func (c netstackNetlinkClient) RouteAdd(*netlink.Route) error { return errors.ErrUnsupported }
+
+func (c netstackNetlinkClient) RouteDel(*netlink.Route) error { return errors.ErrUnsupported }
+
+func (c netstackNetlinkClient) RouteReplace(*netlink.Route) error { return errors.ErrUnsupported }
+
+func (c netstackNetlinkClient) RouteList(netlink.Link, int) ([]netlink.Route, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (c netstackNetlinkClient) RouteListFiltered(int, *netlink.Route, uint64) ([]netlink.Route, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (c netstackNetlinkClient) RuleAdd(*netlink.Rule) error { return errors.ErrUnsupported }
+
+func (c netstackNetlinkClient) RuleDel(*netlink.Rule) error { return errors.ErrUnsupported }
+
+func (c netstackNetlinkClient) RuleList(int) ([]netlink.Rule, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,150 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// saveNetstackInterface creates a netstack interface that listens on a random local port.
+func saveNetstackInterface(t *testing.T, repo *WgSoftwareRepo, id domain.InterfaceIdentifier, cidr string) (
+	*domain.PhysicalInterface,
+	domain.KeyPair,
+) {
+	keys, err := domain.NewFreshKeypair()
+	require.NoError(t, err)
+	addresses, err := domain.CidrsFromString(cidr)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	err = repo.SaveInterface(ctx, id, func(pi *domain.PhysicalInterface) (*domain.PhysicalInterface, error) {
+		pi.KeyPair = keys
+		pi.Addresses = addresses
+		pi.Mtu = 1380
+		pi.DeviceUp = true
+		return pi, nil
+	})
+	require.NoError(t, err)
+
+	iface, err := repo.GetInterface(ctx, id)
+	require.NoError(t, err)
+	return iface, keys
+}
+
+func TestWgNetstackRepo_InterfaceAndPeerRoundTrip(t *testing.T) {
+	repo := NewWireGuardNetstackRepository()
+	t.Cleanup(repo.Close)
+	ctx := context.Background()
+
+	iface, keys := saveNetstackInterface(t, repo, "wg_netstack_0", "10.11.14.1/24")
+	assert.Equal(t, keys.PublicKey, iface.PublicKey)
+	assert.NotZero(t, iface.ListenPort)
+	assert.Equal(t, "10.11.14.1/24", domain.CidrsToString(iface.Addresses))
+	assert.Equal(t, 1380, iface.Mtu)
+	assert.True(t, iface.DeviceUp)
+	assert.Equal(t, "userspace", iface.DeviceType)
+
+	peerKeys, err := domain.NewFreshKeypair()
+	require.NoError(t, err)
+	presharedKey, err := domain.NewPreSharedKey()
+	require.NoError(t, err)
+	allowedIps, err := domain.CidrsFromString("10.11.14.2/32,fd00::2/128")
+	require.NoError(t, err)
+	peerId := domain.PeerIdentifier(peerKeys.PublicKey)
+
+	err = repo.SavePeer(ctx, "wg_netstack_0", peerId, func(pp *domain.PhysicalPeer) (*domain.PhysicalPeer, error) {
+		pp.KeyPair = domain.KeyPair{PublicKey: peerKeys.PublicKey}
+		pp.PresharedKey = presharedKey
+		pp.AllowedIPs = allowedIps
+		pp.Endpoint = "192.0.2.1:51820"
+		pp.PersistentKeepalive = 25
+		return pp, nil
+	})
+	require.NoError(t, err)
+
+	peer, err := repo.GetPeer(ctx, "wg_netstack_0", peerId)
+	require.NoError(t, err)
+	assert.Equal(t, peerKeys.PublicKey, peer.PublicKey)
+	assert.Equal(t, presharedKey, peer.PresharedKey)
+	assert.Equal(t, allowedIps, peer.AllowedIPs)
+	assert.Equal(t, "192.0.2.1:51820", peer.Endpoint)
+	assert.Equal(t, 25, peer.PersistentKeepalive)
+
+	// changing the interface keeps the peers, removed addresses are dropped
+	err = repo.SaveInterface(ctx, "wg_netstack_0", func(pi *domain.PhysicalInterface) (*domain.PhysicalInterface, error) {
+		pi.Addresses = pi.Addresses[:0]
+		pi.DeviceUp = false
+		return pi, nil
+	})
+	require.NoError(t, err)
+	iface, err = repo.GetInterface(ctx, "wg_netstack_0")
+	require.NoError(t, err)
+	assert.Empty(t, iface.Addresses)
+	assert.False(t, iface.DeviceUp)
+	peers, err := repo.GetPeers(ctx, "wg_netstack_0")
+	require.NoError(t, err)
+	assert.Len(t, peers, 1)
+
+	require.NoError(t, repo.DeletePeer(ctx, "wg_netstack_0", peerId))
+	peers, err = repo.GetPeers(ctx, "wg_netstack_0")
+	require.NoError(t, err)
+	assert.Empty(t, peers)
+
+	require.NoError(t, repo.DeleteInterface(ctx, "wg_netstack_0"))
+	_, err = repo.GetInterface(ctx, "wg_netstack_0")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.NoError(t, repo.DeleteInterface(ctx, "wg_netstack_0"), "deleting a missing interface is not an error")
+}
+
+func TestWgNetstackRepo_Handshake(t *testing.T) {
+	repo := NewWireGuardNetstackRepository()
+	t.Cleanup(repo.Close)
+	ctx := context.Background()
+
+	server, serverKeys := saveNetstackInterface(t, repo, "wg_netstack_1", "10.11.15.1/24")
+	client, clientKeys := saveNetstackInterface(t, repo, "wg_netstack_2", "10.11.15.2/24")
+
+	interfaces, err := repo.GetInterfaces(ctx)
+	require.NoError(t, err)
+	assert.Len(t, interfaces, 2)
+
+	connect := func(id domain.InterfaceIdentifier, remote domain.KeyPair, cidr string, port, keepalive int) {
+		allowedIps, err := domain.CidrsFromString(cidr)
+		require.NoError(t, err)
+		peerId := domain.PeerIdentifier(remote.PublicKey)
+		err = repo.SavePeer(ctx, id, peerId, func(pp *domain.PhysicalPeer) (*domain.PhysicalPeer, error) {
+			pp.KeyPair = domain.KeyPair{PublicKey: remote.PublicKey}
+			pp.AllowedIPs = allowedIps
+			if port != 0 {
+				pp.Endpoint = fmt.Sprintf("127.0.0.1:%d", port)
+			}
+			pp.PersistentKeepalive = keepalive
+			return pp, nil
+		})
+		require.NoError(t, err)
+	}
+	connect("wg_netstack_1", clientKeys, "10.11.15.2/32", 0, 0)
+	// the keepalive of the client initiates the handshake
+	connect("wg_netstack_2", serverKeys, "10.11.15.0/24", server.ListenPort, 1)
+
+	assert.Eventually(t, func() bool {
+		serverPeer, err := repo.GetPeer(ctx, "wg_netstack_1", domain.PeerIdentifier(clientKeys.PublicKey))
+		if err != nil || serverPeer.LastHandshake.IsZero() {
+			return false
+		}
+		clientPeer, err := repo.GetPeer(ctx, "wg_netstack_2", domain.PeerIdentifier(serverKeys.PublicKey))
+		return err == nil && !clientPeer.LastHandshake.IsZero() && clientPeer.BytesDownload > 0
+	}, 10*time.Second, 50*time.Millisecond)
+
+	// the server learned the endpoint of the client from the handshake
+	serverPeer, err := repo.GetPeer(ctx, "wg_netstack_1", domain.PeerIdentifier(clientKeys.PublicKey))
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("127.0.0.1:%d", client.ListenPort), serverPeer.Endpoint)
+}
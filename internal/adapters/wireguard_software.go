@@ -0,0 +1,215 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/vishvananda/netlink"
+	"golang.zx2c4.com/wireguard/conn"
+	"golang.zx2c4.com/wireguard/device"
+	"golang.zx2c4.com/wireguard/ipc"
+	"golang.zx2c4.com/wireguard/tun"
+	"golang.zx2c4.com/wireguard/tun/netstack"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// WgSoftwareRepo implements all low-level WireGuard interactions for userspace (wireguard-go) interfaces.
+// Interfaces are created as TUN devices and expose the standard UAPI socket, so that wgctrl and the
+// wg command line tool can manage them like kernel interfaces.
+// All interfaces created by this repository are removed once the process exits.
+type WgSoftwareRepo struct {
+	*WgRepo
+
+	netstack bool // use an in-process network stack instead of TUN devices, see NewWireGuardNetstackRepository
+
+	mux     sync.Mutex
+	devices map[domain.InterfaceIdentifier]*softwareDevice
+}
+
+type softwareDevice struct {
+	device *device.Device
+	uapi   net.Listener // nil for netstack devices
+
+	// netstack devices have no kernel link, so the link state is only kept in memory
+	link      *netlink.Wireguard
+	addresses []netlink.Addr
+}
+
+// NewWireGuardSoftwareRepository creates a new WgSoftwareRepo instance.
+// Reading interfaces and managing peers is delegated to the given WgRepo, as wgctrl
+// transparently supports userspace interfaces through their UAPI socket.
+func NewWireGuardSoftwareRepository(wg *WgRepo) *WgSoftwareRepo {
+	return &WgSoftwareRepo{
+		WgRepo:  wg,
+		devices: make(map[domain.InterfaceIdentifier]*softwareDevice),
+	}
+}
+
+// SaveInterface updates the interface with the given id.
+// If no existing interface is found, a new userspace interface is created.
+// Updating the interface does not interrupt any existing connections.
+func (r *WgSoftwareRepo) SaveInterface(
+	_ context.Context,
+	id domain.InterfaceIdentifier,
+	updateFunc func(pi *domain.PhysicalInterface) (*domain.PhysicalInterface, error),
+) error {
+	physicalInterface, err := r.getOrCreateInterface(id)
+	if err != nil {
+		return err
+	}
+
+	if updateFunc != nil {
+		physicalInterface, err = updateFunc(physicalInterface)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := r.updateLowLevelInterface(physicalInterface); err != nil {
+		return err
+	}
+	if err := r.updateWireGuardInterface(physicalInterface); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *WgSoftwareRepo) getOrCreateInterface(id domain.InterfaceIdentifier) (*domain.PhysicalInterface, error) {
+	pi, err := r.getInterface(id)
+	if err == nil {
+		return pi, nil // interface exists
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("device error: %w", err) // unknown error
+	}
+
+	// create new device
+	if err := r.createSoftwareInterface(id); err != nil {
+		return nil, err
+	}
+
+	pi, err = r.getInterface(id)
+	return pi, err
+}
+
+func (r *WgSoftwareRepo) createSoftwareInterface(id domain.InterfaceIdentifier) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if _, exists := r.devices[id]; exists {
+		return fmt.Errorf("userspace interface %s already exists", id)
+	}
+
+	var tunDevice tun.Device
+	var err error
+	if r.netstack {
+		tunDevice, _, err = netstack.CreateNetTUN(nil, nil, device.DefaultMTU)
+	} else {
+		tunDevice, err = tun.CreateTUN(string(id), device.DefaultMTU)
+	}
+	if err != nil {
+		return fmt.Errorf("tun create failed: %w", err)
+	}
+
+	logger := &device.Logger{
+		Verbosef: func(format string, args ...any) {
+			slog.Debug(fmt.Sprintf(format, args...), "interface", id)
+		},
+		Errorf: func(format string, args ...any) {
+			slog.Error(fmt.Sprintf(format, args...), "interface", id)
+		},
+	}
+	wgDevice := device.NewDevice(tunDevice, conn.NewDefaultBind(), logger)
+
+	if r.netstack {
+		// the netstack device is brought up right away
+		r.devices[id] = &softwareDevice{
+			device: wgDevice,
+			link: &netlink.Wireguard{
+				LinkAttrs: netlink.LinkAttrs{Name: string(id), MTU: device.DefaultMTU, OperState: netlink.OperUnknown},
+			},
+		}
+
+		slog.Debug("created netstack interface", "interface", id)
+
+		return nil
+	}
+
+	uapiFile, err := ipc.UAPIOpen(string(id))
+	if err != nil {
+		wgDevice.Close()
+		return fmt.Errorf("uapi socket create failed: %w", err)
+	}
+	uapi, err := ipc.UAPIListen(string(id), uapiFile)
+	if err != nil {
+		wgDevice.Close()
+		return fmt.Errorf("uapi listen failed: %w", err)
+	}
+
+	go func() {
+		for {
+			uapiConn, err := uapi.Accept()
+			if err != nil {
+				return // listener closed
+			}
+			go wgDevice.IpcHandle(uapiConn)
+		}
+	}()
+
+	r.devices[id] = &softwareDevice{
+		device: wgDevice,
+		uapi:   uapi,
+	}
+
+	slog.Debug("created userspace interface", "interface", id)
+
+	return nil
+}
+
+// DeleteInterface deletes the interface with the given id.
+// If the requested interface is found, no error is returned.
+func (r *WgSoftwareRepo) DeleteInterface(_ context.Context, id domain.InterfaceIdentifier) error {
+	r.mux.Lock()
+	dev, exists := r.devices[id]
+	delete(r.devices, id)
+	r.mux.Unlock()
+
+	if !exists {
+		if r.netstack {
+			return nil // netstack interfaces never outlive the process
+		}
+		// the interface was not created by this process, try to remove the stale link
+		return r.deleteLowLevelInterface(id)
+	}
+
+	dev.close(id)
+
+	return nil
+}
+
+// Close shuts down all userspace interfaces that were created by this repository.
+func (r *WgSoftwareRepo) Close() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for id, dev := range r.devices {
+		dev.close(id)
+		delete(r.devices, id)
+	}
+}
+
+func (d *softwareDevice) close(id domain.InterfaceIdentifier) {
+	if d.uapi != nil {
+		if err := d.uapi.Close(); err != nil {
+			slog.Warn("failed to close uapi socket", "interface", id, "error", err)
+		}
+	}
+	d.device.Close() // also removes the TUN device
+}
@@ -0,0 +1,85 @@
+//go:build integration
+
+package adapters
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// The userspace device needs a TUN device, so the test has to run as root like the kernel tests
+// (go test -tags integration). It is therefore not part of the regular test run, the netstack tests cover
+// the userspace implementation without root.
+// The UAPI socket is created in the standard directory (/var/run/wireguard), a temporary directory can not be
+// used: wireguard-go only allows to change the directory at link time, and wgctrl, which is used to read the
+// device, only looks in the standard directories.
+func TestWgSoftwareRepo_InterfaceAndPeerRoundTrip(t *testing.T) {
+	repo := NewWireGuardSoftwareRepository(setup(t))
+	t.Cleanup(repo.Close)
+	ctx := context.Background()
+
+	interfaceName := domain.InterfaceIdentifier("wg_test_sw_001")
+	interfaceKeys, err := domain.NewFreshKeypair()
+	require.NoError(t, err)
+	addresses, err := domain.CidrsFromString("10.11.13.1/24")
+	require.NoError(t, err)
+
+	err = repo.SaveInterface(ctx, interfaceName, func(pi *domain.PhysicalInterface) (*domain.PhysicalInterface, error) {
+		pi.KeyPair = interfaceKeys
+		pi.ListenPort = 51999
+		pi.Addresses = addresses
+		pi.DeviceUp = true
+		return pi, nil
+	})
+	require.NoError(t, err)
+	require.FileExists(t, "/var/run/wireguard/"+string(interfaceName)+".sock")
+
+	iface, err := repo.GetInterface(ctx, interfaceName)
+	require.NoError(t, err)
+	assert.Equal(t, interfaceKeys.PublicKey, iface.PublicKey)
+	assert.Equal(t, 51999, iface.ListenPort)
+
+	peerKeys, err := domain.NewFreshKeypair()
+	require.NoError(t, err)
+	presharedKey, err := domain.NewPreSharedKey()
+	require.NoError(t, err)
+	allowedIps, err := domain.CidrsFromString("10.11.13.2/32")
+	require.NoError(t, err)
+	peerId := domain.PeerIdentifier(peerKeys.PublicKey)
+
+	err = repo.SavePeer(ctx, interfaceName, peerId, func(pp *domain.PhysicalPeer) (*domain.PhysicalPeer, error) {
+		pp.KeyPair = domain.KeyPair{PublicKey: peerKeys.PublicKey}
+		pp.PresharedKey = presharedKey
+		pp.AllowedIPs = allowedIps
+		pp.Endpoint = "192.0.2.1:51820"
+		pp.PersistentKeepalive = 25
+		return pp, nil
+	})
+	require.NoError(t, err)
+
+	peer, err := repo.GetPeer(ctx, interfaceName, peerId)
+	require.NoError(t, err)
+	assert.Equal(t, peerKeys.PublicKey, peer.PublicKey)
+	assert.Equal(t, presharedKey, peer.PresharedKey)
+	assert.Equal(t, allowedIps, peer.AllowedIPs)
+	assert.Equal(t, "192.0.2.1:51820", peer.Endpoint)
+	assert.Equal(t, 25, peer.PersistentKeepalive)
+
+	require.NoError(t, repo.DeletePeer(ctx, interfaceName, peerId))
+	peers, err := repo.GetPeers(ctx, interfaceName)
+	require.NoError(t, err)
+	assert.Empty(t, peers)
+
+	// closing the repository removes the device and its UAPI socket
+	repo.Close()
+	_, err = repo.GetInterface(ctx, interfaceName)
+	assert.Error(t, err)
+	_, err = os.Stat("/var/run/wireguard/" + string(interfaceName) + ".sock")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,283 @@
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/rpc"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app/wireguard"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// Config contains the settings of an agent. It is populated from the command line of the agent subcommand.
+type Config struct {
+	PortalAddress string // the address of the agent listener of the portal, for example: portal.example.com:8899
+	Host          string // the name of this agent host, must match a host in the portal configuration
+	Token         string // the shared secret of this agent host
+
+	CaFile     string // the CA certificate file that the portal certificate must be signed by (required)
+	ServerName string // optional name that is verified in the portal certificate, defaults to the portal host
+	CertFile   string // optional client certificate file, required if the portal enforces mutual TLS
+	KeyFile    string // the key file of the client certificate
+
+	MinReconnectInterval time.Duration // the initial delay before a lost connection is re-established
+	MaxReconnectInterval time.Duration // the maximum delay between connection attempts
+}
+
+// Agent connects to the portal and executes WireGuard operations on behalf of the portal.
+// The agent initiates the connection, so that no inbound port must be opened on the agent host.
+// Once authenticated, the roles are reversed: the portal acts as RPC client and the agent serves requests.
+type Agent struct {
+	cfg       Config
+	server    *rpc.Server
+	tlsConfig *tls.Config
+}
+
+// NewAgent creates a new agent. The controllers manage the local WireGuard interfaces.
+func NewAgent(
+	cfg Config,
+	wg wireguard.InterfaceController,
+	wgSoftware wireguard.InterfaceController,
+	quick wireguard.WgQuickController,
+) (*Agent, error) {
+	if cfg.PortalAddress == "" || cfg.Host == "" || cfg.Token == "" {
+		return nil, fmt.Errorf("portal address, host name and token are required")
+	}
+	if cfg.CaFile == "" {
+		return nil, fmt.Errorf("a CA certificate is required to verify the portal")
+	}
+	if cfg.MinReconnectInterval <= 0 {
+		cfg.MinReconnectInterval = time.Second
+	}
+	if cfg.MaxReconnectInterval < cfg.MinReconnectInterval {
+		cfg.MaxReconnectInterval = time.Minute
+	}
+
+	a := &Agent{
+		cfg:    cfg,
+		server: rpc.NewServer(),
+	}
+
+	service := &WireGuardService{
+		wg:         wg,
+		wgSoftware: wgSoftware,
+		quick:      quick,
+	}
+	if err := a.server.RegisterName(serviceName, service); err != nil {
+		return nil, fmt.Errorf("failed to register rpc service: %w", err)
+	}
+
+	tlsConfig, err := a.buildTlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	a.tlsConfig = tlsConfig
+
+	return a, nil
+}
+
+// buildTlsConfig returns the TLS configuration of the portal connection. The portal certificate is only trusted if
+// it is signed by the configured CA, the system certificate pool is not used.
+func (a *Agent) buildTlsConfig() (*tls.Config, error) {
+	serverName := a.cfg.ServerName
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(a.cfg.PortalAddress)
+		if err != nil {
+			return nil, fmt.Errorf("invalid portal address: %w", err)
+		}
+		serverName = host
+	}
+
+	pool, err := loadCertPool(a.cfg.CaFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName: serverName,
+		RootCAs:    pool,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if a.cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(a.cfg.CertFile, a.cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// Run connects to the portal and serves requests until the context is cancelled.
+// Lost connections are re-established with an exponential backoff.
+func (a *Agent) Run(ctx context.Context) {
+	backoff := a.cfg.MinReconnectInterval
+	for {
+		conn, err := a.connect(ctx)
+		if err != nil {
+			slog.Warn("failed to connect to portal", "portal", a.cfg.PortalAddress, "error", err,
+				"retry", backoff)
+		} else {
+			slog.Info("connected to portal", "portal", a.cfg.PortalAddress, "host", a.cfg.Host)
+			backoff = a.cfg.MinReconnectInterval // reset backoff after a successful connection
+			a.serve(ctx, conn)
+			slog.Warn("connection to portal lost", "portal", a.cfg.PortalAddress)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(2*backoff, a.cfg.MaxReconnectInterval)
+	}
+}
+
+func (a *Agent) connect(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:   handshakeTimeout,
+		KeepAlive: PingInterval,
+	}
+
+	tlsDialer := &tls.Dialer{NetDialer: dialer, Config: a.tlsConfig}
+	conn, err := tlsDialer.DialContext(ctx, "tcp", a.cfg.PortalAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.handshake(conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (a *Agent) handshake(conn net.Conn) error {
+	_ = conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer func() {
+		_ = conn.SetDeadline(time.Time{})
+	}()
+
+	if err := writeMessage(conn, hello{Host: a.cfg.Host, Token: a.cfg.Token}); err != nil {
+		return fmt.Errorf("failed to send hello: %w", err)
+	}
+
+	var response welcome
+	if err := readMessage(conn, &response); err != nil {
+		return fmt.Errorf("failed to read handshake response: %w", err)
+	}
+	if !response.Ok {
+		return fmt.Errorf("portal rejected connection: %s", response.Error)
+	}
+
+	return nil
+}
+
+// serve blocks until the connection is closed or the context is cancelled.
+func (a *Agent) serve(ctx context.Context, conn net.Conn) {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	// the portal sends pings regularly, so an idle connection indicates a network partition
+	a.server.ServeConn(idleTimeoutConn{Conn: conn, timeout: 3 * PingInterval})
+}
+
+// WireGuardService is the RPC service that is served by the agent.
+// It is exported, as net/rpc only serves exported types.
+type WireGuardService struct {
+	wg         wireguard.InterfaceController
+	wgSoftware wireguard.InterfaceController
+	quick      wireguard.WgQuickController
+}
+
+func (s *WireGuardService) controller(software bool) wireguard.InterfaceController {
+	if software {
+		return s.wgSoftware
+	}
+	return s.wg
+}
+
+func (s *WireGuardService) Ping(_ *Empty, _ *Empty) error {
+	return nil
+}
+
+func (s *WireGuardService) GetInterfaces(_ *Empty, reply *InterfacesReply) error {
+	interfaces, err := s.wg.GetInterfaces(context.Background())
+	if err != nil {
+		return encodeError(err)
+	}
+	reply.Interfaces = interfaces
+	return nil
+}
+
+func (s *WireGuardService) GetInterface(args *InterfaceArgs, reply *InterfaceReply) error {
+	pi, err := s.controller(args.Software).GetInterface(context.Background(), args.Id)
+	if err != nil {
+		return encodeError(err)
+	}
+	reply.Interface = *pi
+	return nil
+}
+
+func (s *WireGuardService) GetPeers(args *InterfaceArgs, reply *PeersReply) error {
+	peers, err := s.controller(args.Software).GetPeers(context.Background(), args.Id)
+	if err != nil {
+		return encodeError(err)
+	}
+	reply.Peers = peers
+	return nil
+}
+
+// SaveInterface applies the interface state that was calculated by the portal.
+func (s *WireGuardService) SaveInterface(args *SaveInterfaceArgs, _ *Empty) error {
+	err := s.controller(args.Software).SaveInterface(context.Background(), args.Interface.Identifier,
+		func(_ *domain.PhysicalInterface) (*domain.PhysicalInterface, error) {
+			return &args.Interface, nil
+		})
+	return encodeError(err)
+}
+
+func (s *WireGuardService) DeleteInterface(args *InterfaceArgs, _ *Empty) error {
+	return encodeError(s.controller(args.Software).DeleteInterface(context.Background(), args.Id))
+}
+
+// SavePeer applies the peer state that was calculated by the portal.
+func (s *WireGuardService) SavePeer(args *SavePeerArgs, _ *Empty) error {
+	err := s.wg.SavePeer(context.Background(), args.InterfaceId, args.Peer.Identifier,
+		func(_ *domain.PhysicalPeer) (*domain.PhysicalPeer, error) {
+			return &args.Peer, nil
+		})
+	return encodeError(err)
+}
+
+func (s *WireGuardService) DeletePeer(args *PeerArgs, _ *Empty) error {
+	return encodeError(s.wg.DeletePeer(context.Background(), args.InterfaceId, args.PeerId))
+}
+
+func (s *WireGuardService) ExecuteInterfaceHook(args *HookArgs, _ *Empty) error {
+	return encodeError(s.quick.ExecuteInterfaceHook(args.Id, args.Command))
+}
+
+func (s *WireGuardService) SetDNS(args *DnsArgs, _ *Empty) error {
+	return encodeError(s.quick.SetDNS(args.Id, args.Dns, args.DnsSearch))
+}
+
+func (s *WireGuardService) UnsetDNS(args *InterfaceArgs, _ *Empty) error {
+	return encodeError(s.quick.UnsetDNS(args.Id))
+}
@@ -0,0 +1,356 @@
+package agent
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type testBus struct {
+	mux    sync.Mutex
+	events []string
+}
+
+func (b *testBus) Publish(topic string, _ ...any) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.events = append(b.events, topic)
+}
+
+// testController is an in-memory WireGuard controller.
+type testController struct {
+	mux        sync.Mutex
+	interfaces map[domain.InterfaceIdentifier]domain.PhysicalInterface
+	peers      map[domain.InterfaceIdentifier][]domain.PhysicalPeer
+	hooks      []string
+}
+
+func newTestController() *testController {
+	return &testController{
+		interfaces: make(map[domain.InterfaceIdentifier]domain.PhysicalInterface),
+		peers:      make(map[domain.InterfaceIdentifier][]domain.PhysicalPeer),
+	}
+}
+
+func (c *testController) GetInterfaces(_ context.Context) ([]domain.PhysicalInterface, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	interfaces := make([]domain.PhysicalInterface, 0, len(c.interfaces))
+	for _, pi := range c.interfaces {
+		interfaces = append(interfaces, pi)
+	}
+	return interfaces, nil
+}
+
+func (c *testController) GetInterface(_ context.Context, id domain.InterfaceIdentifier) (
+	*domain.PhysicalInterface,
+	error,
+) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	pi, ok := c.interfaces[id]
+	if !ok {
+		return nil, fmt.Errorf("device error: %w", os.ErrNotExist)
+	}
+	return &pi, nil
+}
+
+func (c *testController) GetPeers(_ context.Context, deviceId domain.InterfaceIdentifier) (
+	[]domain.PhysicalPeer,
+	error,
+) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.peers[deviceId], nil
+}
+
+func (c *testController) SaveInterface(
+	_ context.Context,
+	id domain.InterfaceIdentifier,
+	updateFunc func(pi *domain.PhysicalInterface) (*domain.PhysicalInterface, error),
+) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	pi := c.interfaces[id]
+	pi.Identifier = id
+	updated, err := updateFunc(&pi)
+	if err != nil {
+		return err
+	}
+	c.interfaces[id] = *updated
+	return nil
+}
+
+func (c *testController) DeleteInterface(_ context.Context, id domain.InterfaceIdentifier) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	delete(c.interfaces, id)
+	return nil
+}
+
+func (c *testController) SavePeer(
+	_ context.Context,
+	deviceId domain.InterfaceIdentifier,
+	id domain.PeerIdentifier,
+	updateFunc func(pp *domain.PhysicalPeer) (*domain.PhysicalPeer, error),
+) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	updated, err := updateFunc(&domain.PhysicalPeer{Identifier: id})
+	if err != nil {
+		return err
+	}
+	c.peers[deviceId] = append(c.peers[deviceId], *updated)
+	return nil
+}
+
+func (c *testController) DeletePeer(_ context.Context, deviceId domain.InterfaceIdentifier, id domain.PeerIdentifier) error {
+	return nil
+}
+
+func (c *testController) ExecuteInterfaceHook(id domain.InterfaceIdentifier, hookCmd string) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.hooks = append(c.hooks, string(id)+":"+hookCmd)
+	return nil
+}
+
+func (c *testController) SetDNS(_ domain.InterfaceIdentifier, _, _ string) error { return nil }
+
+func (c *testController) UnsetDNS(_ domain.InterfaceIdentifier) error { return nil }
+
+// testPki contains the certificate files of a test CA, the portal and an agent.
+type testPki struct {
+	CaFile         string
+	PortalCertFile string
+	PortalKeyFile  string
+	AgentCertFile  string // issued for the host gateway-1
+	AgentKeyFile   string
+}
+
+// newTestPki creates a CA that issues a portal certificate for 127.0.0.1 and a client certificate for gateway-1.
+func newTestPki(t *testing.T) testPki {
+	t.Helper()
+
+	dir := t.TempDir()
+	writePem := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+		return path
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDer)
+	require.NoError(t, err)
+
+	issue := func(name string, serial int64, template *x509.Certificate) (string, string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template.SerialNumber = big.NewInt(serial)
+		template.NotBefore = time.Now().Add(-time.Hour)
+		template.NotAfter = time.Now().Add(time.Hour)
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		keyDer, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+		return writePem(name+".crt", "CERTIFICATE", der), writePem(name+".key", "EC PRIVATE KEY", keyDer)
+	}
+
+	pki := testPki{CaFile: writePem("ca.crt", "CERTIFICATE", caDer)}
+	pki.PortalCertFile, pki.PortalKeyFile = issue("portal", 2, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "portal"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	pki.AgentCertFile, pki.AgentKeyFile = issue("agent", 3, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "gateway-1"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	return pki
+}
+
+func startTestHub(t *testing.T, ctx context.Context, pki testPki, mutualTls bool) (*Hub, *testBus, string) {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Agents.ListeningAddress = "127.0.0.1:0"
+	cfg.Agents.CertFile = pki.PortalCertFile
+	cfg.Agents.KeyFile = pki.PortalKeyFile
+	if mutualTls {
+		cfg.Agents.ClientCaFile = pki.CaFile
+	}
+	cfg.Agents.RequestTimeout = 5 * time.Second
+	cfg.Agents.Hosts = []config.AgentHostConfig{{Name: "gateway-1", Token: "secret"}}
+
+	bus := &testBus{}
+	hub, err := NewHub(cfg, bus)
+	require.NoError(t, err)
+
+	listener, err := hub.listen()
+	require.NoError(t, err)
+	go hub.acceptConnections(ctx, listener)
+
+	return hub, bus, listener.Addr().String()
+}
+
+func startTestAgent(t *testing.T, ctx context.Context, cfg Config, ctrl *testController) {
+	t.Helper()
+
+	cfg.Host = "gateway-1"
+	cfg.MinReconnectInterval = 50 * time.Millisecond
+	a, err := NewAgent(cfg, ctrl, ctrl, ctrl)
+	require.NoError(t, err)
+	go a.Run(ctx)
+}
+
+func TestNewHub_InvalidHosts(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Agents.Hosts = []config.AgentHostConfig{{Name: "gateway-1", Token: "a"}, {Name: "gateway-1", Token: "b"}}
+	_, err := NewHub(cfg, &testBus{})
+	assert.Error(t, err)
+
+	cfg.Agents.Hosts = []config.AgentHostConfig{{Name: "gateway-1"}}
+	_, err = NewHub(cfg, &testBus{})
+	assert.Error(t, err)
+}
+
+func TestHub_RemoteController(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pki := newTestPki(t)
+	hub, bus, address := startTestHub(t, ctx, pki, false)
+	ctrl := newTestController()
+
+	remote := hub.InterfaceController("gateway-1", false)
+	_, err := remote.GetInterface(ctx, "wg0")
+	assert.ErrorIs(t, err, domain.ErrAgentNotConnected)
+
+	startTestAgent(t, ctx, Config{PortalAddress: address, Token: "secret", CaFile: pki.CaFile}, ctrl)
+	require.Eventually(t, func() bool { return hub.IsConnected("gateway-1") }, 5*time.Second, 10*time.Millisecond)
+
+	// unknown interfaces are reported as os.ErrNotExist
+	_, err = remote.GetInterface(ctx, "wg0")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	err = remote.SaveInterface(ctx, "wg0", func(pi *domain.PhysicalInterface) (*domain.PhysicalInterface, error) {
+		pi.ListenPort = 51820
+		return pi, nil
+	})
+	require.NoError(t, err)
+
+	pi, err := remote.GetInterface(ctx, "wg0")
+	require.NoError(t, err)
+	assert.Equal(t, domain.InterfaceIdentifier("wg0"), pi.Identifier)
+	assert.Equal(t, 51820, pi.ListenPort)
+
+	err = remote.SavePeer(ctx, "wg0", "peer-1", func(pp *domain.PhysicalPeer) (*domain.PhysicalPeer, error) {
+		pp.PersistentKeepalive = 25
+		return pp, nil
+	})
+	require.NoError(t, err)
+
+	peers, err := remote.GetPeers(ctx, "wg0")
+	require.NoError(t, err)
+	require.Len(t, peers, 1)
+	assert.Equal(t, "peer-1", peers[0].PublicKey)
+	assert.Equal(t, 25, peers[0].PersistentKeepalive)
+
+	err = hub.WgQuickController("gateway-1").ExecuteInterfaceHook("wg0", "echo up")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"wg0:echo up"}, ctrl.hooks)
+
+	bus.mux.Lock()
+	assert.Contains(t, bus.events, "agent:connected")
+	bus.mux.Unlock()
+}
+
+func TestHub_RejectsInvalidToken(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pki := newTestPki(t)
+	hub, _, address := startTestHub(t, ctx, pki, false)
+	startTestAgent(t, ctx, Config{PortalAddress: address, Token: "wrong", CaFile: pki.CaFile}, newTestController())
+
+	time.Sleep(200 * time.Millisecond)
+	assert.False(t, hub.IsConnected("gateway-1"))
+}
+
+func TestTls_RequiredOnBothSides(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Agents.ListeningAddress = "127.0.0.1:0"
+	_, err := NewHub(cfg, &testBus{})
+	assert.Error(t, err, "the listener must not start without a certificate")
+
+	ctrl := newTestController()
+	_, err = NewAgent(Config{PortalAddress: "127.0.0.1:8899", Host: "gateway-1", Token: "secret"}, ctrl, ctrl, ctrl)
+	assert.Error(t, err, "the agent must not start without a CA to verify the portal")
+}
+
+func TestAgent_RejectsUntrustedPortal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub, _, address := startTestHub(t, ctx, newTestPki(t), false)
+	ctrl := newTestController()
+	otherCa := newTestPki(t).CaFile // the portal certificate is not signed by this CA
+	startTestAgent(t, ctx, Config{PortalAddress: address, Token: "secret", CaFile: otherCa}, ctrl)
+
+	time.Sleep(200 * time.Millisecond)
+	assert.False(t, hub.IsConnected("gateway-1"))
+}
+
+func TestHub_MutualTls(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pki := newTestPki(t)
+	hub, _, address := startTestHub(t, ctx, pki, true)
+
+	// without a client certificate, the connection is rejected
+	startTestAgent(t, ctx, Config{PortalAddress: address, Token: "secret", CaFile: pki.CaFile}, newTestController())
+	time.Sleep(200 * time.Millisecond)
+	assert.False(t, hub.IsConnected("gateway-1"))
+
+	startTestAgent(t, ctx, Config{
+		PortalAddress: address,
+		Token:         "secret",
+		CaFile:        pki.CaFile,
+		CertFile:      pki.AgentCertFile,
+		KeyFile:       pki.AgentKeyFile,
+	}, newTestController())
+	require.Eventually(t, func() bool { return hub.IsConnected("gateway-1") }, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,300 @@
+package agent
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/rpc"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/app/wireguard"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// region dependencies
+
+type EventBus interface {
+	// Publish sends a message to the message bus.
+	Publish(topic string, args ...any)
+}
+
+// endregion dependencies
+
+// Hub accepts agent connections and provides the WireGuard controllers of the connected agents.
+type Hub struct {
+	cfg *config.Config
+	bus EventBus
+
+	mux     sync.RWMutex
+	clients map[string]*rpc.Client // the rpc clients of all connected agents, keyed by host name
+}
+
+// NewHub creates a new agent hub.
+func NewHub(cfg *config.Config, bus EventBus) (*Hub, error) {
+	if cfg.Agents.ListeningAddress != "" && (cfg.Agents.CertFile == "" || cfg.Agents.KeyFile == "") {
+		return nil, fmt.Errorf("the agent listener requires a TLS certificate and key")
+	}
+
+	seen := make([]string, 0, len(cfg.Agents.Hosts))
+	for _, host := range cfg.Agents.Hosts {
+		if host.Name == "" || host.Token == "" {
+			return nil, fmt.Errorf("agent hosts require a name and a token")
+		}
+		if slices.Contains(seen, host.Name) {
+			return nil, fmt.Errorf("duplicate agent host %s", host.Name)
+		}
+		seen = append(seen, host.Name)
+	}
+
+	return &Hub{
+		cfg:     cfg,
+		bus:     bus,
+		clients: make(map[string]*rpc.Client),
+	}, nil
+}
+
+// StartBackgroundJobs starts the agent listener. This method is non-blocking.
+func (h *Hub) StartBackgroundJobs(ctx context.Context) error {
+	if h.cfg.Agents.ListeningAddress == "" {
+		slog.Debug("skipping agent listener - feature disabled")
+		return nil
+	}
+
+	listener, err := h.listen()
+	if err != nil {
+		return fmt.Errorf("failed to start agent listener: %w", err)
+	}
+
+	go h.acceptConnections(ctx, listener)
+
+	slog.Debug("started agent listener", "address", h.cfg.Agents.ListeningAddress)
+
+	return nil
+}
+
+func (h *Hub) acceptConnections(ctx context.Context, listener net.Listener) {
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return // listener closed
+			}
+			slog.Warn("failed to accept agent connection", "error", err)
+			continue
+		}
+		go h.handleConnection(ctx, conn)
+	}
+}
+
+func (h *Hub) listen() (net.Listener, error) {
+	listenConfig := net.ListenConfig{KeepAlive: PingInterval}
+	listener, err := listenConfig.Listen(context.Background(), "tcp", h.cfg.Agents.ListeningAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := h.buildTlsConfig()
+	if err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+
+	return tls.NewListener(listener, tlsConfig), nil
+}
+
+// buildTlsConfig returns the TLS configuration of the agent listener. Plaintext connections are never accepted,
+// as the agents execute interface hooks and receive private keys.
+func (h *Hub) buildTlsConfig() (*tls.Config, error) {
+	if h.cfg.Agents.CertFile == "" || h.cfg.Agents.KeyFile == "" {
+		return nil, fmt.Errorf("the agent listener requires a TLS certificate and key")
+	}
+
+	cert, err := tls.LoadX509KeyPair(h.cfg.Agents.CertFile, h.cfg.Agents.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if h.cfg.Agents.ClientCaFile != "" {
+		pool, err := loadCertPool(h.cfg.Agents.ClientCaFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+func (h *Hub) handleConnection(ctx context.Context, conn net.Conn) {
+	host, err := h.authenticate(conn)
+	if err != nil {
+		slog.Warn("agent authentication failed", "remote", conn.RemoteAddr(), "error", err)
+		_ = conn.Close()
+		return
+	}
+
+	client := rpc.NewClient(idleTimeoutConn{Conn: conn, timeout: 3 * PingInterval})
+
+	h.mux.Lock()
+	if previous, exists := h.clients[host]; exists {
+		_ = previous.Close() // the agent reconnected before the old connection timed out
+	}
+	h.clients[host] = client
+	h.mux.Unlock()
+
+	slog.Info("agent connected", "host", host, "remote", conn.RemoteAddr())
+	h.bus.Publish(app.TopicAgentConnected, host)
+
+	h.monitor(ctx, host, client)
+
+	h.mux.Lock()
+	if h.clients[host] == client {
+		delete(h.clients, host)
+	}
+	h.mux.Unlock()
+	_ = client.Close()
+
+	slog.Warn("agent disconnected", "host", host)
+	h.bus.Publish(app.TopicAgentDisconnected, host)
+}
+
+func (h *Hub) authenticate(conn net.Conn) (string, error) {
+	_ = conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer func() {
+		_ = conn.SetDeadline(time.Time{})
+	}()
+
+	var request hello
+	if err := readMessage(conn, &request); err != nil {
+		return "", fmt.Errorf("failed to read hello: %w", err)
+	}
+
+	host, found := h.cfg.Agents.FindHost(request.Host)
+	if !found || subtle.ConstantTimeCompare([]byte(host.Token), []byte(request.Token)) != 1 {
+		_ = writeMessage(conn, welcome{Ok: false, Error: "invalid credentials"})
+		return "", fmt.Errorf("invalid credentials for host %q", request.Host)
+	}
+	if h.cfg.Agents.ClientCaFile != "" && !clientCertificateMatches(conn, host.Name) {
+		_ = writeMessage(conn, welcome{Ok: false, Error: "invalid client certificate"})
+		return "", fmt.Errorf("client certificate not issued for host %q", request.Host)
+	}
+
+	if err := writeMessage(conn, welcome{Ok: true}); err != nil {
+		return "", fmt.Errorf("failed to send welcome: %w", err)
+	}
+
+	return host.Name, nil
+}
+
+// monitor pings the agent until the connection fails or the context is cancelled.
+func (h *Hub) monitor(ctx context.Context, host string, client *rpc.Client) {
+	ticker := time.NewTicker(PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := h.callClient(ctx, client, "Ping", &Empty{}, &Empty{}); err != nil {
+			slog.Debug("agent ping failed", "host", host, "error", err)
+			return
+		}
+	}
+}
+
+// IsKnownHost returns true if an agent host with the given name is configured.
+func (h *Hub) IsKnownHost(host string) bool {
+	_, found := h.cfg.Agents.FindHost(host)
+	return found
+}
+
+// IsConnected returns true if the agent with the given name is currently connected.
+func (h *Hub) IsConnected(host string) bool {
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+
+	_, connected := h.clients[host]
+	return connected
+}
+
+// InterfaceController returns the controller for the WireGuard interfaces of the given agent host.
+// Requests fail with domain.ErrAgentNotConnected while the agent is not connected.
+func (h *Hub) InterfaceController(host string, software bool) wireguard.InterfaceController {
+	return &RemoteController{hub: h, host: host, software: software}
+}
+
+// WgQuickController returns the controller for the wg-quick actions of the given agent host.
+func (h *Hub) WgQuickController(host string) wireguard.WgQuickController {
+	return &RemoteController{hub: h, host: host}
+}
+
+func (h *Hub) call(ctx context.Context, host, method string, args, reply any) error {
+	h.mux.RLock()
+	client, connected := h.clients[host]
+	h.mux.RUnlock()
+
+	if !connected {
+		return fmt.Errorf("host %s: %w", host, domain.ErrAgentNotConnected)
+	}
+
+	if err := h.callClient(ctx, client, method, args, reply); err != nil {
+		return fmt.Errorf("host %s: %w", host, err)
+	}
+	return nil
+}
+
+func (h *Hub) callClient(ctx context.Context, client *rpc.Client, method string, args, reply any) error {
+	timeout := h.cfg.Agents.RequestTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	call := client.Go(serviceName+"."+method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	case <-call.Done:
+		return decodeError(call.Error)
+	}
+}
+
+// clientCertificateMatches checks that the verified client certificate of the connection is issued for the given
+// agent host, either as common name or as DNS subject alternative name.
+func clientCertificateMatches(conn net.Conn, host string) bool {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return false
+	}
+
+	state := tlsConn.ConnectionState()
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return false
+	}
+
+	cert := state.VerifiedChains[0][0]
+	return cert.Subject.CommonName == host || slices.Contains(cert.DNSNames, host)
+}
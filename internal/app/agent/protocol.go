@@ -0,0 +1,179 @@
+package agent
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/rpc"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// serviceName is the name of the RPC service that is served by the agent.
+const serviceName = "WireGuard"
+
+// maxHandshakeSize limits the size of the handshake messages.
+const maxHandshakeSize = 4096
+
+// handshakeTimeout is the maximum duration of the handshake after the connection has been established.
+const handshakeTimeout = 10 * time.Second
+
+// PingInterval is the interval in which the portal checks the connection to an agent.
+// If the agent does not receive any request for three intervals, it assumes a network partition and reconnects.
+const PingInterval = 15 * time.Second
+
+// errNotFoundPrefix marks errors that wrap os.ErrNotExist, as net/rpc only transports error strings.
+const errNotFoundPrefix = "not found: "
+
+// hello is the first message an agent sends after the connection has been established.
+type hello struct {
+	Host  string `json:"host"`
+	Token string `json:"token"`
+}
+
+// welcome is the response of the portal to the hello message.
+type welcome struct {
+	Ok    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// loadCertPool reads the PEM encoded CA certificates of the given file.
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	caCert, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("failed to parse CA file %s", caFile)
+	}
+
+	return pool, nil
+}
+
+// region rpc-messages
+
+type Empty struct{}
+
+type InterfaceArgs struct {
+	Software bool // true if the interface uses the userspace implementation
+	Id       domain.InterfaceIdentifier
+}
+
+type InterfaceReply struct {
+	Interface domain.PhysicalInterface
+}
+
+type InterfacesReply struct {
+	Interfaces []domain.PhysicalInterface
+}
+
+type PeersReply struct {
+	Peers []domain.PhysicalPeer
+}
+
+type SaveInterfaceArgs struct {
+	Software  bool
+	Interface domain.PhysicalInterface
+}
+
+type PeerArgs struct {
+	InterfaceId domain.InterfaceIdentifier
+	PeerId      domain.PeerIdentifier
+}
+
+type SavePeerArgs struct {
+	InterfaceId domain.InterfaceIdentifier
+	Peer        domain.PhysicalPeer
+}
+
+type HookArgs struct {
+	Id      domain.InterfaceIdentifier
+	Command string
+}
+
+type DnsArgs struct {
+	Id        domain.InterfaceIdentifier
+	Dns       string
+	DnsSearch string
+}
+
+// endregion rpc-messages
+
+// encodeError prepares an error for the transport to the portal.
+func encodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return errors.New(errNotFoundPrefix + err.Error())
+	}
+	return err
+}
+
+// decodeError restores errors received from an agent, so that os.ErrNotExist can be detected by the caller.
+func decodeError(err error) error {
+	var serverErr rpc.ServerError
+	if errors.As(err, &serverErr) {
+		if msg, found := strings.CutPrefix(string(serverErr), errNotFoundPrefix); found {
+			return fmt.Errorf("%s: %w", msg, os.ErrNotExist)
+		}
+		return errors.New(string(serverErr))
+	}
+	if errors.Is(err, rpc.ErrShutdown) {
+		return domain.ErrAgentNotConnected
+	}
+	return err
+}
+
+// writeMessage writes a single line JSON message, used during the handshake.
+func writeMessage(w io.Writer, msg any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// readMessage reads a single line JSON message, used during the handshake.
+// The connection is read byte by byte, so that no data of the following RPC stream is consumed.
+func readMessage(r io.Reader, msg any) error {
+	line := make([]byte, 0, 256)
+	buf := make([]byte, 1)
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return err
+		}
+		if buf[0] == '\n' {
+			break
+		}
+		if len(line) >= maxHandshakeSize {
+			return fmt.Errorf("handshake message too large")
+		}
+		line = append(line, buf[0])
+	}
+
+	return json.Unmarshal(line, msg)
+}
+
+// idleTimeoutConn closes the connection if no data is received within the timeout.
+// It is used to detect network partitions that are not reported by the TCP stack.
+type idleTimeoutConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c idleTimeoutConn) Read(b []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// RemoteController implements the InterfaceController and WgQuickController contracts for an agent host.
+// Update functions cannot be transferred, so they are applied on the current state fetched from the agent
+// and the resulting state is sent back to the agent.
+type RemoteController struct {
+	hub      *Hub
+	host     string
+	software bool
+}
+
+func (c *RemoteController) GetInterfaces(ctx context.Context) ([]domain.PhysicalInterface, error) {
+	var reply InterfacesReply
+	if err := c.hub.call(ctx, c.host, "GetInterfaces", &Empty{}, &reply); err != nil {
+		return nil, err
+	}
+	return reply.Interfaces, nil
+}
+
+func (c *RemoteController) GetInterface(ctx context.Context, id domain.InterfaceIdentifier) (
+	*domain.PhysicalInterface,
+	error,
+) {
+	var reply InterfaceReply
+	err := c.hub.call(ctx, c.host, "GetInterface", &InterfaceArgs{Software: c.software, Id: id}, &reply)
+	if err != nil {
+		return nil, err
+	}
+	return &reply.Interface, nil
+}
+
+func (c *RemoteController) GetPeers(ctx context.Context, deviceId domain.InterfaceIdentifier) (
+	[]domain.PhysicalPeer,
+	error,
+) {
+	var reply PeersReply
+	err := c.hub.call(ctx, c.host, "GetPeers", &InterfaceArgs{Software: c.software, Id: deviceId}, &reply)
+	if err != nil {
+		return nil, err
+	}
+	return reply.Peers, nil
+}
+
+func (c *RemoteController) SaveInterface(
+	ctx context.Context,
+	id domain.InterfaceIdentifier,
+	updateFunc func(pi *domain.PhysicalInterface) (*domain.PhysicalInterface, error),
+) error {
+	physicalInterface, err := c.GetInterface(ctx, id)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		physicalInterface = &domain.PhysicalInterface{Identifier: id} // the agent creates the interface
+	case err != nil:
+		return err
+	}
+
+	if updateFunc != nil {
+		physicalInterface, err = updateFunc(physicalInterface)
+		if err != nil {
+			return err
+		}
+	}
+
+	args := &SaveInterfaceArgs{Software: c.software, Interface: *physicalInterface}
+	return c.hub.call(ctx, c.host, "SaveInterface", args, &Empty{})
+}
+
+func (c *RemoteController) DeleteInterface(ctx context.Context, id domain.InterfaceIdentifier) error {
+	return c.hub.call(ctx, c.host, "DeleteInterface", &InterfaceArgs{Software: c.software, Id: id}, &Empty{})
+}
+
+func (c *RemoteController) SavePeer(
+	ctx context.Context,
+	deviceId domain.InterfaceIdentifier,
+	id domain.PeerIdentifier,
+	updateFunc func(pp *domain.PhysicalPeer) (*domain.PhysicalPeer, error),
+) error {
+	peers, err := c.GetPeers(ctx, deviceId)
+	if err != nil {
+		return fmt.Errorf("failed to load peers: %w", err)
+	}
+
+	physicalPeer := &domain.PhysicalPeer{
+		Identifier: id,
+		KeyPair:    domain.KeyPair{PublicKey: string(id)},
+	}
+	for i := range peers {
+		if peers[i].Identifier == id {
+			physicalPeer = &peers[i]
+			break
+		}
+	}
+
+	if updateFunc != nil {
+		physicalPeer, err = updateFunc(physicalPeer)
+		if err != nil {
+			return err
+		}
+	}
+
+	args := &SavePeerArgs{InterfaceId: deviceId, Peer: *physicalPeer}
+	return c.hub.call(ctx, c.host, "SavePeer", args, &Empty{})
+}
+
+func (c *RemoteController) DeletePeer(
+	ctx context.Context,
+	deviceId domain.InterfaceIdentifier,
+	id domain.PeerIdentifier,
+) error {
+	return c.hub.call(ctx, c.host, "DeletePeer", &PeerArgs{InterfaceId: deviceId, PeerId: id}, &Empty{})
+}
+
+func (c *RemoteController) ExecuteInterfaceHook(id domain.InterfaceIdentifier, hookCmd string) error {
+	if hookCmd == "" {
+		return nil
+	}
+	return c.hub.call(context.Background(), c.host, "ExecuteInterfaceHook",
+		&HookArgs{Id: id, Command: hookCmd}, &Empty{})
+}
+
+func (c *RemoteController) SetDNS(id domain.InterfaceIdentifier, dnsStr, dnsSearchStr string) error {
+	if dnsStr == "" && dnsSearchStr == "" {
+		return nil
+	}
+	return c.hub.call(context.Background(), c.host, "SetDNS",
+		&DnsArgs{Id: id, Dns: dnsStr, DnsSearch: dnsSearchStr}, &Empty{})
+}
+
+func (c *RemoteController) UnsetDNS(id domain.InterfaceIdentifier) error {
+	return c.hub.call(context.Background(), c.host, "UnsetDNS", &InterfaceArgs{Id: id}, &Empty{})
+}
@@ -83,6 +83,10 @@ func (i InterfaceService) GetInterfaceConfig(ctx context.Context, id domain.Inte
 }
 
 func (i InterfaceService) PersistInterfaceConfig(ctx context.Context, id domain.InterfaceIdentifier) error {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesWrite, id); err != nil {
+		return err
+	}
+
 	return i.configFile.PersistInterfaceConfig(ctx, id)
 }
 
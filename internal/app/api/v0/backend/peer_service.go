@@ -3,6 +3,7 @@ package backend
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/h44z/wg-portal/internal/config"
 	"github.com/h44z/wg-portal/internal/domain"
@@ -18,6 +19,8 @@ type PeerServicePeerManager interface {
 	CreatePeer(ctx context.Context, peer *domain.Peer) (*domain.Peer, error)
 	UpdatePeer(ctx context.Context, peer *domain.Peer) (*domain.Peer, error)
 	DeletePeer(ctx context.Context, id domain.PeerIdentifier) error
+	RenewPeer(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error)
+	RejectPeerRenewal(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error)
 	CreateMultiplePeers(
 		ctx context.Context,
 		interfaceId domain.InterfaceIdentifier,
@@ -35,6 +38,20 @@ type PeerServiceMailManager interface {
 	SendPeerEmail(ctx context.Context, linkOnly bool, style string, peers ...domain.PeerIdentifier) error
 }
 
+type PeerServiceShareLinkManager interface {
+	CreateShareLink(
+		ctx context.Context,
+		peerId domain.PeerIdentifier,
+		validity time.Duration,
+		maxUses *int,
+	) (*domain.PeerShareLink, error)
+	GetPeerShareLinks(ctx context.Context, peerId domain.PeerIdentifier) ([]domain.PeerShareLink, error)
+	RevokeShareLink(ctx context.Context, id domain.PeerShareLinkIdentifier) error
+	GetSharedPeerConfig(ctx context.Context, token, style string) (*domain.Peer, io.Reader, error)
+	GetSharedPeerConfigQrCode(ctx context.Context, token, style string) (*domain.Peer, io.Reader, error)
+	GetShareLinkUrl(token string) string
+}
+
 // endregion dependencies
 
 type PeerService struct {
@@ -43,6 +60,7 @@ type PeerService struct {
 	peers      PeerServicePeerManager
 	configFile PeerServiceConfigFileManager
 	mailer     PeerServiceMailManager
+	shareLinks PeerServiceShareLinkManager
 }
 
 func NewPeerService(
@@ -50,12 +68,14 @@ func NewPeerService(
 	peers PeerServicePeerManager,
 	configFile PeerServiceConfigFileManager,
 	mailer PeerServiceMailManager,
+	shareLinks PeerServiceShareLinkManager,
 ) *PeerService {
 	return &PeerService{
 		cfg:        cfg,
 		peers:      peers,
 		configFile: configFile,
 		mailer:     mailer,
+		shareLinks: shareLinks,
 	}
 }
 
@@ -95,6 +115,14 @@ func (p PeerService) DeletePeer(ctx context.Context, id domain.PeerIdentifier) e
 	return p.peers.DeletePeer(ctx, id)
 }
 
+func (p PeerService) RenewPeer(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error) {
+	return p.peers.RenewPeer(ctx, id)
+}
+
+func (p PeerService) RejectPeerRenewal(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error) {
+	return p.peers.RejectPeerRenewal(ctx, id)
+}
+
 func (p PeerService) GetPeerConfig(ctx context.Context, id domain.PeerIdentifier, style string) (io.Reader, error) {
 	return p.configFile.GetPeerConfig(ctx, id, style)
 }
@@ -118,3 +146,40 @@ func (p PeerService) SendPeerEmail(
 func (p PeerService) GetPeerStats(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.PeerStatus, error) {
 	return p.peers.GetPeerStats(ctx, id)
 }
+
+func (p PeerService) CreatePeerShareLink(
+	ctx context.Context,
+	id domain.PeerIdentifier,
+	validity time.Duration,
+	maxUses *int,
+) (*domain.PeerShareLink, error) {
+	return p.shareLinks.CreateShareLink(ctx, id, validity, maxUses)
+}
+
+func (p PeerService) GetPeerShareLinks(ctx context.Context, id domain.PeerIdentifier) ([]domain.PeerShareLink, error) {
+	return p.shareLinks.GetPeerShareLinks(ctx, id)
+}
+
+func (p PeerService) RevokePeerShareLink(ctx context.Context, id domain.PeerShareLinkIdentifier) error {
+	return p.shareLinks.RevokeShareLink(ctx, id)
+}
+
+func (p PeerService) GetSharedPeerConfig(ctx context.Context, token, style string) (
+	*domain.Peer,
+	io.Reader,
+	error,
+) {
+	return p.shareLinks.GetSharedPeerConfig(ctx, token, style)
+}
+
+func (p PeerService) GetSharedPeerConfigQrCode(ctx context.Context, token, style string) (
+	*domain.Peer,
+	io.Reader,
+	error,
+) {
+	return p.shareLinks.GetSharedPeerConfigQrCode(ctx, token, style)
+}
+
+func (p PeerService) GetShareLinkUrl(token string) string {
+	return p.shareLinks.GetShareLinkUrl(token)
+}
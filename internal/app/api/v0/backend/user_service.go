@@ -17,6 +17,19 @@ type UserServiceUserManager interface {
 	DeleteUser(ctx context.Context, id domain.UserIdentifier) error
 	ActivateApi(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
 	DeactivateApi(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
+	GetApiTokens(ctx context.Context, userId domain.UserIdentifier) ([]domain.ApiToken, error)
+	CreateApiToken(ctx context.Context, userId domain.UserIdentifier, template *domain.ApiToken) (
+		*domain.ApiToken,
+		error,
+	)
+	DeleteApiToken(ctx context.Context, id domain.ApiTokenIdentifier) error
+	UnlockUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
+	GetBlockedLogins(ctx context.Context) ([]domain.LoginAttempt, error)
+	UnlockSource(ctx context.Context, sourceAddr string) error
+	StartTotpEnrollment(ctx context.Context, id domain.UserIdentifier) (*domain.TotpEnrollment, error)
+	FinishTotpEnrollment(ctx context.Context, id domain.UserIdentifier, code string) ([]string, error)
+	RenewRecoveryCodes(ctx context.Context, id domain.UserIdentifier, code string) ([]string, error)
+	RemoveTotp(ctx context.Context, id domain.UserIdentifier, code string) error
 }
 
 type UserServiceWireGuardManager interface {
@@ -70,6 +83,55 @@ func (u UserService) DeactivateApi(ctx context.Context, id domain.UserIdentifier
 	return u.users.DeactivateApi(ctx, id)
 }
 
+func (u UserService) GetApiTokens(ctx context.Context, id domain.UserIdentifier) ([]domain.ApiToken, error) {
+	return u.users.GetApiTokens(ctx, id)
+}
+
+func (u UserService) CreateApiToken(ctx context.Context, id domain.UserIdentifier, template *domain.ApiToken) (
+	*domain.ApiToken,
+	error,
+) {
+	return u.users.CreateApiToken(ctx, id, template)
+}
+
+func (u UserService) DeleteApiToken(ctx context.Context, id domain.ApiTokenIdentifier) error {
+	return u.users.DeleteApiToken(ctx, id)
+}
+
+func (u UserService) UnlockUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, error) {
+	return u.users.UnlockUser(ctx, id)
+}
+
+func (u UserService) GetBlockedLogins(ctx context.Context) ([]domain.LoginAttempt, error) {
+	return u.users.GetBlockedLogins(ctx)
+}
+
+func (u UserService) UnlockSource(ctx context.Context, sourceAddr string) error {
+	return u.users.UnlockSource(ctx, sourceAddr)
+}
+
+func (u UserService) StartTotpEnrollment(
+	ctx context.Context,
+	id domain.UserIdentifier,
+) (*domain.TotpEnrollment, error) {
+	return u.users.StartTotpEnrollment(ctx, id)
+}
+
+func (u UserService) FinishTotpEnrollment(ctx context.Context, id domain.UserIdentifier, code string) (
+	[]string,
+	error,
+) {
+	return u.users.FinishTotpEnrollment(ctx, id, code)
+}
+
+func (u UserService) RenewRecoveryCodes(ctx context.Context, id domain.UserIdentifier, code string) ([]string, error) {
+	return u.users.RenewRecoveryCodes(ctx, id, code)
+}
+
+func (u UserService) RemoveTotp(ctx context.Context, id domain.UserIdentifier, code string) error {
+	return u.users.RemoveTotp(ctx, id, code)
+}
+
 func (u UserService) GetUserPeers(ctx context.Context, id domain.UserIdentifier) ([]domain.Peer, error) {
 	return u.wg.GetUserPeers(ctx, id)
 }
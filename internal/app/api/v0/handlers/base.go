@@ -98,7 +98,8 @@ type Authenticator interface {
 	// LoggedIn checks if a user is logged in. If scopes are given, they are validated as well.
 	LoggedIn(scopes ...Scope) func(next http.Handler) http.Handler
 	// UserIdMatch checks if the user id in the session matches the user id in the request. If not, the request is aborted.
-	UserIdMatch(idParameter string) func(next http.Handler) http.Handler
+	// Users with roles that grant all given scopes can access the data of other users as well.
+	UserIdMatch(idParameter string, scopes ...Scope) func(next http.Handler) http.Handler
 	// InfoOnly only add user info to the request context. No login check is performed.
 	InfoOnly() func(next http.Handler) http.Handler
 }
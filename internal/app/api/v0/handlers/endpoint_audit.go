@@ -41,7 +41,7 @@ func (e AuditEndpoint) GetName() string {
 
 func (e AuditEndpoint) RegisterRoutes(g *routegroup.Bundle) {
 	apiGroup := g.Mount("/audit")
-	apiGroup.Use(e.authenticator.LoggedIn(ScopeAdmin))
+	apiGroup.Use(e.authenticator.LoggedIn(ScopeAuditRead))
 
 	apiGroup.HandleFunc("GET /entries", e.handleEntriesGet())
 }
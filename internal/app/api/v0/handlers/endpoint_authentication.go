@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/url"
@@ -22,11 +23,23 @@ type AuthenticationService interface {
 	// GetExternalLoginProviders returns a list of all available external login providers.
 	GetExternalLoginProviders(_ context.Context) []domain.LoginProviderInfo
 	// PlainLogin authenticates a user with a username and password.
-	PlainLogin(ctx context.Context, username, password string) (*domain.User, error)
+	PlainLogin(ctx context.Context, username, password, sourceAddr string) (*domain.User, error)
 	// OauthLoginStep1 initiates the OAuth login flow.
 	OauthLoginStep1(_ context.Context, providerId string) (authCodeUrl, state, nonce string, err error)
 	// OauthLoginStep2 completes the OAuth login flow and logins the user in.
 	OauthLoginStep2(ctx context.Context, providerId, nonce, code string) (*domain.User, error)
+	// SecondFactorChallenge returns the second factor that is required to complete the password login.
+	SecondFactorChallenge(user *domain.User) *domain.SecondFactorChallenge
+	// SecondFactorLogin completes a password login with a TOTP code or a recovery code.
+	SecondFactorLogin(ctx context.Context, userId domain.UserIdentifier, code, sourceAddr string) (*domain.User, error)
+	// StartTotpEnrollment creates a TOTP secret for a user that has to enroll a second factor during the login.
+	StartTotpEnrollment(ctx context.Context, userId domain.UserIdentifier) (*domain.TotpEnrollment, error)
+	// TotpEnrollmentLogin confirms the TOTP enrollment and completes the login.
+	TotpEnrollmentLogin(ctx context.Context, userId domain.UserIdentifier, code, sourceAddr string) (
+		*domain.User,
+		[]string,
+		error,
+	)
 }
 
 type WebAuthnService interface {
@@ -68,8 +81,22 @@ type WebAuthnService interface {
 		sessionDataAsJSON []byte,
 		r *http.Request,
 	) (*domain.User, error)
+	StartWebAuthnSecondFactor(ctx context.Context, userId domain.UserIdentifier) (
+		optionsAsJSON []byte,
+		sessionDataAsJSON []byte,
+		err error,
+	)
+	FinishWebAuthnSecondFactor(
+		ctx context.Context,
+		userId domain.UserIdentifier,
+		sessionDataAsJSON []byte,
+		r *http.Request,
+	) (*domain.User, error)
 }
 
+// secondFactorTimeout limits the time between the password login and the second factor.
+const secondFactorTimeout = 5 * time.Minute
+
 type AuthEndpoint struct {
 	cfg           *config.Config
 	authService   AuthenticationService
@@ -124,6 +151,11 @@ func (e AuthEndpoint) RegisterRoutes(g *routegroup.Bundle) {
 		e.handleWebAuthnCredentialsPut())
 
 	apiGroup.HandleFunc("POST /login", e.handleLoginPost())
+	apiGroup.HandleFunc("POST /login/second-factor", e.handleSecondFactorPost())
+	apiGroup.HandleFunc("POST /login/second-factor/webauthn/start", e.handleSecondFactorWebAuthnStart())
+	apiGroup.HandleFunc("POST /login/second-factor/webauthn/finish", e.handleSecondFactorWebAuthnFinish())
+	apiGroup.HandleFunc("POST /login/second-factor/enroll/start", e.handleSecondFactorEnrollStart())
+	apiGroup.HandleFunc("POST /login/second-factor/enroll/finish", e.handleSecondFactorEnrollFinish())
 	apiGroup.With(e.authenticator.LoggedIn()).HandleFunc("POST /logout", e.handleLogoutPost())
 }
 
@@ -175,6 +207,8 @@ func (e AuthEndpoint) handleSessionInfoGet() http.HandlerFunc {
 		respond.JSON(w, http.StatusOK, model.SessionInfo{
 			LoggedIn:       currentSession.LoggedIn,
 			IsAdmin:        currentSession.IsAdmin,
+			Roles:          model.NewRoles(currentSession.Roles),
+			Permissions:    model.NewPermissions(currentSession.IsAdmin, currentSession.Roles),
 			UserIdentifier: loggedInUid,
 			UserFirstname:  firstname,
 			UserLastname:   lastname,
@@ -368,6 +402,7 @@ func (e AuthEndpoint) setAuthenticatedUser(r *http.Request, user *domain.User) {
 
 	currentSession.LoggedIn = true
 	currentSession.IsAdmin = user.IsAdmin
+	currentSession.Roles = user.Roles
 	currentSession.UserIdentifier = string(user.Identifier)
 	currentSession.Firstname = user.Firstname
 	currentSession.Lastname = user.Lastname
@@ -385,9 +420,14 @@ func (e AuthEndpoint) setAuthenticatedUser(r *http.Request, user *domain.User) {
 //
 // @ID auth_handleLoginPost
 // @Tags Authentication
-// @Summary Get all available external login providers.
+// @Summary Log in with username and password.
+// @Description If the user has to provide a second factor, a challenge is returned instead of the user.
+// @Description The login is completed with one of the /auth/login/second-factor endpoints.
 // @Produce json
-// @Success 200 {object} []model.LoginProviderInfo
+// @Success 200 {object} model.User
+// @Success 202 {object} model.SecondFactorChallenge
+// @Failure 401 {object} model.Error
+// @Failure 429 {object} model.Error
 // @Router /auth/login [post]
 func (e AuthEndpoint) handleLoginPost() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -412,19 +452,264 @@ func (e AuthEndpoint) handleLoginPost() http.HandlerFunc {
 		}
 
 		user, err := e.authService.PlainLogin(context.Background(), loginData.Username,
-			loginData.Password)
+			loginData.Password, request.ClientIp(r, e.cfg.Web.TrustedProxies...))
+		if errors.Is(err, domain.ErrLoginThrottled) {
+			respond.JSON(w, http.StatusTooManyRequests,
+				model.Error{Code: http.StatusTooManyRequests, Message: "too many failed login attempts"})
+			return
+		}
 		if err != nil {
 			respond.JSON(w, http.StatusUnauthorized,
 				model.Error{Code: http.StatusUnauthorized, Message: "login failed"})
 			return
 		}
 
+		if challenge := e.authService.SecondFactorChallenge(user); challenge != nil {
+			e.setSecondFactorUser(r, user)
+			respond.JSON(w, http.StatusAccepted, model.NewSecondFactorChallenge(challenge))
+			return
+		}
+
 		e.setAuthenticatedUser(r, user)
 
 		respond.JSON(w, http.StatusOK, user)
 	}
 }
 
+// setSecondFactorUser starts a fresh session that waits for the second factor of the user.
+func (e AuthEndpoint) setSecondFactorUser(r *http.Request, user *domain.User) {
+	e.session.DestroyData(r.Context())
+
+	currentSession := e.session.GetData(r.Context())
+	currentSession.SecondFactorUser = string(user.Identifier)
+	currentSession.SecondFactorExpires = time.Now().Add(secondFactorTimeout)
+
+	e.session.SetData(r.Context(), currentSession)
+}
+
+// secondFactorUser returns the user of the pending password login. If no login is pending or the second factor
+// took too long, an error response is sent.
+func (e AuthEndpoint) secondFactorUser(w http.ResponseWriter, r *http.Request) (domain.UserIdentifier, bool) {
+	currentSession := e.session.GetData(r.Context())
+	if currentSession.LoggedIn {
+		respond.JSON(w, http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: "already logged in"})
+		return "", false
+	}
+	if currentSession.SecondFactorUser == "" || time.Now().After(currentSession.SecondFactorExpires) {
+		respond.JSON(w, http.StatusUnauthorized,
+			model.Error{Code: http.StatusUnauthorized, Message: "no pending login"})
+		return "", false
+	}
+
+	return domain.UserIdentifier(currentSession.SecondFactorUser), true
+}
+
+// handleSecondFactorPost returns a gorm Handler function.
+//
+// @ID auth_handleSecondFactorPost
+// @Tags Authentication
+// @Summary Complete the password login with a TOTP code or a recovery code.
+// @Produce json
+// @Param request body model.SecondFactorCodeRequest true "The code"
+// @Success 200 {object} model.User
+// @Failure 400 {object} model.Error
+// @Failure 401 {object} model.Error
+// @Failure 429 {object} model.Error
+// @Router /auth/login/second-factor [post]
+func (e AuthEndpoint) handleSecondFactorPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := e.secondFactorUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req model.SecondFactorCodeRequest
+		if err := request.BodyJson(r, &req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validate.Struct(req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		user, err := e.authService.SecondFactorLogin(context.Background(), userId, req.Code,
+			request.ClientIp(r, e.cfg.Web.TrustedProxies...))
+		if errors.Is(err, domain.ErrLoginThrottled) {
+			respond.JSON(w, http.StatusTooManyRequests,
+				model.Error{Code: http.StatusTooManyRequests, Message: "too many failed login attempts"})
+			return
+		}
+		if err != nil {
+			respond.JSON(w, http.StatusUnauthorized,
+				model.Error{Code: http.StatusUnauthorized, Message: "invalid code"})
+			return
+		}
+
+		e.setAuthenticatedUser(r, user)
+
+		respond.JSON(w, http.StatusOK, model.NewUser(user, false))
+	}
+}
+
+// handleSecondFactorWebAuthnStart returns a gorm Handler function.
+//
+// @ID auth_handleSecondFactorWebAuthnStart
+// @Tags Authentication
+// @Summary Start the passkey verification that completes the password login.
+// @Produce json
+// @Success 200 {object} object "The WebAuthn assertion options"
+// @Failure 400 {object} model.Error
+// @Failure 401 {object} model.Error
+// @Router /auth/login/second-factor/webauthn/start [post]
+func (e AuthEndpoint) handleSecondFactorWebAuthnStart() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !e.webAuthn.Enabled() {
+			respond.JSON(w, http.StatusBadRequest,
+				model.Error{Code: http.StatusBadRequest, Message: "WebAuthn is not enabled"})
+			return
+		}
+
+		userId, ok := e.secondFactorUser(w, r)
+		if !ok {
+			return
+		}
+
+		options, sessionData, err := e.webAuthn.StartWebAuthnSecondFactor(r.Context(), userId)
+		if err != nil {
+			respond.JSON(w, http.StatusBadRequest,
+				model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		currentSession := e.session.GetData(r.Context())
+		currentSession.WebAuthnData = string(sessionData)
+		e.session.SetData(r.Context(), currentSession)
+
+		respond.Data(w, http.StatusOK, "application/json", options)
+	}
+}
+
+// handleSecondFactorWebAuthnFinish returns a gorm Handler function.
+//
+// @ID auth_handleSecondFactorWebAuthnFinish
+// @Tags Authentication
+// @Summary Complete the password login with a passkey.
+// @Produce json
+// @Success 200 {object} model.User
+// @Failure 400 {object} model.Error
+// @Failure 401 {object} model.Error
+// @Router /auth/login/second-factor/webauthn/finish [post]
+func (e AuthEndpoint) handleSecondFactorWebAuthnFinish() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !e.webAuthn.Enabled() {
+			respond.JSON(w, http.StatusBadRequest,
+				model.Error{Code: http.StatusBadRequest, Message: "WebAuthn is not enabled"})
+			return
+		}
+
+		userId, ok := e.secondFactorUser(w, r)
+		if !ok {
+			return
+		}
+
+		currentSession := e.session.GetData(r.Context())
+		webAuthnSessionData := []byte(currentSession.WebAuthnData)
+		currentSession.WebAuthnData = "" // clear the session data
+		e.session.SetData(r.Context(), currentSession)
+
+		user, err := e.webAuthn.FinishWebAuthnSecondFactor(r.Context(), userId, webAuthnSessionData, r)
+		if err != nil {
+			respond.JSON(w, http.StatusBadRequest,
+				model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		e.setAuthenticatedUser(r, user)
+
+		respond.JSON(w, http.StatusOK, model.NewUser(user, false))
+	}
+}
+
+// handleSecondFactorEnrollStart returns a gorm Handler function.
+//
+// @ID auth_handleSecondFactorEnrollStart
+// @Tags Authentication
+// @Summary Start the TOTP enrollment of a user that has to provide a second factor, but has not enrolled one yet.
+// @Produce json
+// @Success 200 {object} model.TotpEnrollment
+// @Failure 400 {object} model.Error
+// @Failure 401 {object} model.Error
+// @Router /auth/login/second-factor/enroll/start [post]
+func (e AuthEndpoint) handleSecondFactorEnrollStart() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := e.secondFactorUser(w, r)
+		if !ok {
+			return
+		}
+
+		enrollment, err := e.authService.StartTotpEnrollment(r.Context(), userId)
+		if err != nil {
+			respond.JSON(w, http.StatusBadRequest,
+				model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, model.NewTotpEnrollment(enrollment))
+	}
+}
+
+// handleSecondFactorEnrollFinish returns a gorm Handler function.
+//
+// @ID auth_handleSecondFactorEnrollFinish
+// @Tags Authentication
+// @Summary Confirm the TOTP enrollment with a code of the authenticator app and complete the login.
+// @Produce json
+// @Param request body model.SecondFactorCodeRequest true "The code"
+// @Success 200 {object} model.RecoveryCodes
+// @Failure 400 {object} model.Error
+// @Failure 401 {object} model.Error
+// @Failure 429 {object} model.Error
+// @Router /auth/login/second-factor/enroll/finish [post]
+func (e AuthEndpoint) handleSecondFactorEnrollFinish() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := e.secondFactorUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req model.SecondFactorCodeRequest
+		if err := request.BodyJson(r, &req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validate.Struct(req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		user, recoveryCodes, err := e.authService.TotpEnrollmentLogin(context.Background(), userId, req.Code,
+			request.ClientIp(r, e.cfg.Web.TrustedProxies...))
+		if errors.Is(err, domain.ErrLoginThrottled) {
+			respond.JSON(w, http.StatusTooManyRequests,
+				model.Error{Code: http.StatusTooManyRequests, Message: "too many failed login attempts"})
+			return
+		}
+		if err != nil {
+			respond.JSON(w, http.StatusUnauthorized,
+				model.Error{Code: http.StatusUnauthorized, Message: "invalid code"})
+			return
+		}
+
+		e.setAuthenticatedUser(r, user)
+
+		respond.JSON(w, http.StatusOK, model.RecoveryCodes{
+			RecoveryCodes: recoveryCodes,
+			User:          model.NewUser(user, false),
+		})
+	}
+}
+
 // handleLogoutPost returns a gorm Handler function.
 //
 // @ID auth_handleLogoutPost
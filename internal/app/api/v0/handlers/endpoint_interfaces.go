@@ -62,17 +62,20 @@ func (e InterfaceEndpoint) GetName() string {
 
 func (e InterfaceEndpoint) RegisterRoutes(g *routegroup.Bundle) {
 	apiGroup := g.Mount("/interface")
-	apiGroup.Use(e.authenticator.LoggedIn(ScopeAdmin))
+	apiGroup.Use(e.authenticator.LoggedIn(ScopeInterfacesRead))
 
-	apiGroup.HandleFunc("GET /prepare", e.handlePrepareGet())
+	admin := e.authenticator.LoggedIn(ScopeAdmin)
+	write := e.authenticator.LoggedIn(ScopeInterfacesWrite)
+
+	apiGroup.With(admin).HandleFunc("GET /prepare", e.handlePrepareGet())
 	apiGroup.HandleFunc("GET /all", e.handleAllGet())
 	apiGroup.HandleFunc("GET /get/{id}", e.handleSingleGet())
-	apiGroup.HandleFunc("PUT /{id}", e.handleUpdatePut())
-	apiGroup.HandleFunc("DELETE /{id}", e.handleDelete())
-	apiGroup.HandleFunc("POST /new", e.handleCreatePost())
-	apiGroup.HandleFunc("GET /config/{id}", e.handleConfigGet())
-	apiGroup.HandleFunc("POST /{id}/save-config", e.handleSaveConfigPost())
-	apiGroup.HandleFunc("POST /{id}/apply-peer-defaults", e.handleApplyPeerDefaultsPost())
+	apiGroup.With(write).HandleFunc("PUT /{id}", e.handleUpdatePut())
+	apiGroup.With(write).HandleFunc("DELETE /{id}", e.handleDelete())
+	apiGroup.With(admin).HandleFunc("POST /new", e.handleCreatePost())
+	apiGroup.With(write).HandleFunc("GET /config/{id}", e.handleConfigGet())
+	apiGroup.With(write).HandleFunc("POST /{id}/save-config", e.handleSaveConfigPost())
+	apiGroup.With(write).HandleFunc("POST /{id}/apply-peer-defaults", e.handleApplyPeerDefaultsPost())
 
 	apiGroup.HandleFunc("GET /peers/{id}", e.handlePeersGet())
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/go-pkgz/routegroup"
 
@@ -33,6 +34,10 @@ type PeerService interface {
 	UpdatePeer(ctx context.Context, peer *domain.Peer) (*domain.Peer, error)
 	// DeletePeer deletes the peer with the given id.
 	DeletePeer(ctx context.Context, id domain.PeerIdentifier) error
+	// RenewPeer extends the expiry date of the peer with the given id according to the renewal policy.
+	RenewPeer(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error)
+	// RejectPeerRenewal discards the pending renewal request of the peer with the given id.
+	RejectPeerRenewal(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error)
 	// GetPeerConfig returns the peer configuration for the given id.
 	GetPeerConfig(ctx context.Context, id domain.PeerIdentifier, style string) (io.Reader, error)
 	// GetPeerConfigQrCode returns the peer configuration as qr code for the given id.
@@ -41,6 +46,19 @@ type PeerService interface {
 	SendPeerEmail(ctx context.Context, linkOnly bool, style string, peers ...domain.PeerIdentifier) error
 	// GetPeerStats returns the peer stats for the given interface.
 	GetPeerStats(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.PeerStatus, error)
+	// CreatePeerShareLink creates a new configuration download link for the given peer.
+	CreatePeerShareLink(
+		ctx context.Context,
+		id domain.PeerIdentifier,
+		validity time.Duration,
+		maxUses *int,
+	) (*domain.PeerShareLink, error)
+	// GetPeerShareLinks returns all configuration download links of the given peer.
+	GetPeerShareLinks(ctx context.Context, id domain.PeerIdentifier) ([]domain.PeerShareLink, error)
+	// RevokePeerShareLink invalidates the given configuration download link.
+	RevokePeerShareLink(ctx context.Context, id domain.PeerShareLinkIdentifier) error
+	// GetShareLinkUrl returns the public download url for the given plain token.
+	GetShareLinkUrl(token string) string
 }
 
 type PeerEndpoint struct {
@@ -72,18 +90,25 @@ func (e PeerEndpoint) RegisterRoutes(g *routegroup.Bundle) {
 	apiGroup := g.Mount("/peer")
 	apiGroup.Use(e.authenticator.LoggedIn())
 
-	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("GET /iface/{iface}/all", e.handleAllGet())
-	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("GET /iface/{iface}/stats", e.handleStatsGet())
+	apiGroup.With(e.authenticator.LoggedIn(ScopePeersRead)).HandleFunc("GET /iface/{iface}/all", e.handleAllGet())
+	apiGroup.With(e.authenticator.LoggedIn(ScopePeersRead)).HandleFunc("GET /iface/{iface}/stats", e.handleStatsGet())
 	apiGroup.HandleFunc("GET /iface/{iface}/prepare", e.handlePrepareGet())
 	apiGroup.HandleFunc("POST /iface/{iface}/new", e.handleCreatePost())
-	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("POST /iface/{iface}/multiplenew",
+	apiGroup.With(e.authenticator.LoggedIn(ScopePeersWrite)).HandleFunc("POST /iface/{iface}/multiplenew",
 		e.handleCreateMultiplePost())
 	apiGroup.HandleFunc("GET /config-qr/{id}", e.handleQrCodeGet())
 	apiGroup.HandleFunc("POST /config-mail", e.handleEmailPost())
 	apiGroup.HandleFunc("GET /config/{id}", e.handleConfigGet())
+	apiGroup.HandleFunc("POST /share-link/{id}", e.handleShareLinkPost())
+	apiGroup.HandleFunc("GET /share-links/{id}", e.handleShareLinksGet())
+	apiGroup.With(e.authenticator.LoggedIn(ScopePeersManage)).HandleFunc("DELETE /share-link/{linkId}",
+		e.handleShareLinkDelete())
 	apiGroup.HandleFunc("GET /{id}", e.handleSingleGet())
 	apiGroup.HandleFunc("PUT /{id}", e.handleUpdatePut())
 	apiGroup.HandleFunc("DELETE /{id}", e.handleDelete())
+	apiGroup.HandleFunc("POST /{id}/renew", e.handleRenewPost())
+	apiGroup.With(e.authenticator.LoggedIn(ScopePeersManage)).HandleFunc("DELETE /{id}/renewal",
+		e.handleRenewalDelete())
 }
 
 // handleAllGet returns a gorm Handler function.
@@ -348,6 +373,66 @@ func (e PeerEndpoint) handleDelete() http.HandlerFunc {
 	}
 }
 
+// handleRenewPost returns a gorm Handler function.
+//
+// @ID peers_handleRenewPost
+// @Tags Peer
+// @Summary Extend the expiry date of the peer according to the renewal policy of the interface.
+// @Produce json
+// @Param id path string true "The peer identifier"
+// @Success 200 {object} model.Peer
+// @Failure 400 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /peer/{id}/renew [post]
+func (e PeerEndpoint) handleRenewPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := Base64UrlDecode(request.Path(r, "id"))
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: "missing peer id"})
+			return
+		}
+
+		peer, err := e.peerService.RenewPeer(r.Context(), domain.PeerIdentifier(id))
+		if err != nil {
+			respond.JSON(w, http.StatusInternalServerError,
+				model.Error{Code: http.StatusInternalServerError, Message: err.Error()})
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, model.NewPeer(peer))
+	}
+}
+
+// handleRenewalDelete returns a gorm Handler function.
+//
+// @ID peers_handleRenewalDelete
+// @Tags Peer
+// @Summary Reject the pending renewal request of the peer.
+// @Produce json
+// @Param id path string true "The peer identifier"
+// @Success 200 {object} model.Peer
+// @Failure 400 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /peer/{id}/renewal [delete]
+func (e PeerEndpoint) handleRenewalDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := Base64UrlDecode(request.Path(r, "id"))
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: "missing peer id"})
+			return
+		}
+
+		peer, err := e.peerService.RejectPeerRenewal(r.Context(), domain.PeerIdentifier(id))
+		if err != nil {
+			respond.JSON(w, http.StatusInternalServerError,
+				model.Error{Code: http.StatusInternalServerError, Message: err.Error()})
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, model.NewPeer(peer))
+	}
+}
+
 // handleConfigGet returns a gorm Handler function.
 //
 // @ID peers_handleConfigGet
@@ -483,6 +568,105 @@ func (e PeerEndpoint) handleEmailPost() http.HandlerFunc {
 	}
 }
 
+// handleShareLinkPost returns a gorm Handler function.
+//
+// @ID peers_handleShareLinkPost
+// @Tags Peer
+// @Summary Create a configuration download link for the given peer.
+// @Produce json
+// @Param id path string true "The peer identifier"
+// @Param request body model.ShareLinkRequest true "The share link request data"
+// @Success 200 {object} model.ShareLink
+// @Failure 400 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /peer/share-link/{id} [post]
+func (e PeerEndpoint) handleShareLinkPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := Base64UrlDecode(request.Path(r, "id"))
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: "missing peer id"})
+			return
+		}
+
+		var req model.ShareLinkRequest
+		if err := request.BodyJson(r, &req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		link, err := e.peerService.CreatePeerShareLink(r.Context(), domain.PeerIdentifier(id),
+			time.Duration(req.ValidHours)*time.Hour, req.MaxUses)
+		if err != nil {
+			respond.JSON(w, http.StatusInternalServerError,
+				model.Error{Code: http.StatusInternalServerError, Message: err.Error()})
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, model.NewShareLink(link, e.peerService.GetShareLinkUrl(link.Token)))
+	}
+}
+
+// handleShareLinksGet returns a gorm Handler function.
+//
+// @ID peers_handleShareLinksGet
+// @Tags Peer
+// @Summary Get all configuration download links for the given peer.
+// @Produce json
+// @Param id path string true "The peer identifier"
+// @Success 200 {object} []model.ShareLink
+// @Failure 400 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /peer/share-links/{id} [get]
+func (e PeerEndpoint) handleShareLinksGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := Base64UrlDecode(request.Path(r, "id"))
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: "missing peer id"})
+			return
+		}
+
+		links, err := e.peerService.GetPeerShareLinks(r.Context(), domain.PeerIdentifier(id))
+		if err != nil {
+			respond.JSON(w, http.StatusInternalServerError,
+				model.Error{Code: http.StatusInternalServerError, Message: err.Error()})
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, model.NewShareLinks(links))
+	}
+}
+
+// handleShareLinkDelete returns a gorm Handler function.
+//
+// @ID peers_handleShareLinkDelete
+// @Tags Peer
+// @Summary Revoke the given configuration download link.
+// @Produce json
+// @Param linkId path string true "The share link identifier"
+// @Success 204 "No content if revocation was successful"
+// @Failure 400 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /peer/share-link/{linkId} [delete]
+func (e PeerEndpoint) handleShareLinkDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		linkId := request.Path(r, "linkId")
+		if linkId == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				model.Error{Code: http.StatusBadRequest, Message: "missing share link id"})
+			return
+		}
+
+		err := e.peerService.RevokePeerShareLink(r.Context(), domain.PeerShareLinkIdentifier(linkId))
+		if err != nil {
+			respond.JSON(w, http.StatusInternalServerError,
+				model.Error{Code: http.StatusInternalServerError, Message: err.Error()})
+			return
+		}
+
+		respond.Status(w, http.StatusNoContent)
+	}
+}
+
 // handleStatsGet returns a gorm Handler function.
 //
 // @ID peers_handleStatsGet
@@ -515,9 +699,5 @@ func (e PeerEndpoint) handleStatsGet() http.HandlerFunc {
 }
 
 func (e PeerEndpoint) getConfigStyle(r *http.Request) string {
-	configStyle := request.QueryDefault(r, "style", domain.ConfigStyleWgQuick)
-	if configStyle != domain.ConfigStyleWgQuick && configStyle != domain.ConfigStyleRaw {
-		configStyle = domain.ConfigStyleWgQuick // default to wg-quick style
-	}
-	return configStyle
+	return getConfigStyle(r)
 }
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-pkgz/routegroup"
+
+	"github.com/h44z/wg-portal/internal/app/api/core/request"
+	"github.com/h44z/wg-portal/internal/app/api/core/respond"
+	"github.com/h44z/wg-portal/internal/app/api/v0/model"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type ShareService interface {
+	// GetSharedPeerConfig returns the peer configuration for the given share link token.
+	GetSharedPeerConfig(ctx context.Context, token, style string) (*domain.Peer, io.Reader, error)
+	// GetSharedPeerConfigQrCode returns the peer configuration qr code for the given share link token.
+	GetSharedPeerConfigQrCode(ctx context.Context, token, style string) (*domain.Peer, io.Reader, error)
+}
+
+// ShareEndpoint serves peer configurations through share links. No login is required.
+type ShareEndpoint struct {
+	shareService ShareService
+}
+
+func NewShareEndpoint(shareService ShareService) ShareEndpoint {
+	return ShareEndpoint{
+		shareService: shareService,
+	}
+}
+
+func (e ShareEndpoint) GetName() string {
+	return "ShareEndpoint"
+}
+
+func (e ShareEndpoint) RegisterRoutes(g *routegroup.Bundle) {
+	apiGroup := g.Mount("/share")
+
+	apiGroup.HandleFunc("GET /{token}", e.handleConfigGet())
+	apiGroup.HandleFunc("GET /{token}/qr", e.handleQrCodeGet())
+}
+
+// handleConfigGet returns a gorm Handler function.
+//
+// @ID share_handleConfigGet
+// @Tags Share
+// @Summary Download a peer configuration file using a share link.
+// @Produce plain
+// @Produce json
+// @Param token path string true "The share link token"
+// @Param style query string false "The configuration style"
+// @Success 200 {file} binary
+// @Failure 404 {object} model.Error
+// @Failure 410 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /share/{token} [get]
+func (e ShareEndpoint) handleConfigGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := request.Path(r, "token")
+
+		peer, configTxt, err := e.shareService.GetSharedPeerConfig(r.Context(), token, getConfigStyle(r))
+		if err != nil {
+			code := shareLinkErrorStatus(err)
+			respond.JSON(w, code, model.Error{Code: code, Message: err.Error()})
+			return
+		}
+
+		configData, err := io.ReadAll(configTxt)
+		if err != nil {
+			respond.JSON(w, http.StatusInternalServerError, model.Error{
+				Code: http.StatusInternalServerError, Message: err.Error(),
+			})
+			return
+		}
+
+		respond.Attachment(w, http.StatusOK, peer.GetConfigFileName(), "text/plain", configData)
+	}
+}
+
+// handleQrCodeGet returns a gorm Handler function.
+//
+// @ID share_handleQrCodeGet
+// @Tags Share
+// @Summary Get a peer configuration qr code using a share link.
+// @Produce png
+// @Produce json
+// @Param token path string true "The share link token"
+// @Param style query string false "The configuration style"
+// @Success 200 {file} binary
+// @Failure 404 {object} model.Error
+// @Failure 410 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /share/{token}/qr [get]
+func (e ShareEndpoint) handleQrCodeGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := request.Path(r, "token")
+
+		_, configQr, err := e.shareService.GetSharedPeerConfigQrCode(r.Context(), token, getConfigStyle(r))
+		if err != nil {
+			code := shareLinkErrorStatus(err)
+			respond.JSON(w, code, model.Error{Code: code, Message: err.Error()})
+			return
+		}
+
+		configQrData, err := io.ReadAll(configQr)
+		if err != nil {
+			respond.JSON(w, http.StatusInternalServerError, model.Error{
+				Code: http.StatusInternalServerError, Message: err.Error(),
+			})
+			return
+		}
+
+		respond.Data(w, http.StatusOK, "image/png", configQrData)
+	}
+}
+
+func getConfigStyle(r *http.Request) string {
+	configStyle := request.QueryDefault(r, "style", domain.ConfigStyleWgQuick)
+	if configStyle != domain.ConfigStyleWgQuick && configStyle != domain.ConfigStyleRaw {
+		configStyle = domain.ConfigStyleWgQuick // default to wg-quick style
+	}
+	return configStyle
+}
+
+func shareLinkErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrShareLinkInvalid):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrShareLinkExpired),
+		errors.Is(err, domain.ErrShareLinkRevoked),
+		errors.Is(err, domain.ErrShareLinkExhausted):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/go-pkgz/routegroup"
@@ -28,6 +29,26 @@ type UserService interface {
 	ActivateApi(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
 	// DeactivateApi disables the API for the user with the given id.
 	DeactivateApi(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
+	// GetApiTokens returns all named API tokens of the user with the given id.
+	GetApiTokens(ctx context.Context, id domain.UserIdentifier) ([]domain.ApiToken, error)
+	// CreateApiToken creates a new named API token for the user with the given id.
+	CreateApiToken(ctx context.Context, id domain.UserIdentifier, template *domain.ApiToken) (*domain.ApiToken, error)
+	// DeleteApiToken revokes the named API token with the given id.
+	DeleteApiToken(ctx context.Context, id domain.ApiTokenIdentifier) error
+	// UnlockUser removes the lock and the failed logins of the user with the given id.
+	UnlockUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
+	// GetBlockedLogins returns all accounts and source addresses that are blocked after too many failed logins.
+	GetBlockedLogins(ctx context.Context) ([]domain.LoginAttempt, error)
+	// UnlockSource removes the block of the given source address.
+	UnlockSource(ctx context.Context, sourceAddr string) error
+	// StartTotpEnrollment creates a new TOTP secret for the user with the given id.
+	StartTotpEnrollment(ctx context.Context, id domain.UserIdentifier) (*domain.TotpEnrollment, error)
+	// FinishTotpEnrollment confirms the TOTP enrollment and returns the recovery codes.
+	FinishTotpEnrollment(ctx context.Context, id domain.UserIdentifier, code string) ([]string, error)
+	// RenewRecoveryCodes replaces the recovery codes of the user with the given id.
+	RenewRecoveryCodes(ctx context.Context, id domain.UserIdentifier, code string) ([]string, error)
+	// RemoveTotp removes the TOTP second factor of the user with the given id.
+	RemoveTotp(ctx context.Context, id domain.UserIdentifier, code string) error
 	// GetUserPeers returns all peers for the given user.
 	GetUserPeers(ctx context.Context, id domain.UserIdentifier) ([]domain.Peer, error)
 	// GetUserPeerStats returns all peer stats for the given user.
@@ -65,16 +86,33 @@ func (e UserEndpoint) RegisterRoutes(g *routegroup.Bundle) {
 	apiGroup := g.Mount("/user")
 	apiGroup.Use(e.authenticator.LoggedIn())
 
-	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("GET /all", e.handleAllGet())
-	apiGroup.With(e.authenticator.UserIdMatch("id")).HandleFunc("GET /{id}", e.handleSingleGet())
+	apiGroup.With(e.authenticator.LoggedIn(ScopeUsersRead)).HandleFunc("GET /all", e.handleAllGet())
+	apiGroup.With(e.authenticator.UserIdMatch("id", ScopeUsersRead)).HandleFunc("GET /{id}", e.handleSingleGet())
 	apiGroup.With(e.authenticator.UserIdMatch("id")).HandleFunc("PUT /{id}", e.handleUpdatePut())
 	apiGroup.With(e.authenticator.UserIdMatch("id")).HandleFunc("DELETE /{id}", e.handleDelete())
 	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("POST /new", e.handleCreatePost())
-	apiGroup.With(e.authenticator.UserIdMatch("id")).HandleFunc("GET /{id}/peers", e.handlePeersGet())
-	apiGroup.With(e.authenticator.UserIdMatch("id")).HandleFunc("GET /{id}/stats", e.handleStatsGet())
+	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("GET /login-blocks", e.handleLoginBlocksGet())
+	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("DELETE /login-blocks/{source}",
+		e.handleLoginBlockDelete())
+	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("POST /{id}/unlock", e.handleUnlockPost())
+	apiGroup.With(e.authenticator.UserIdMatch("id", ScopeUsersRead, ScopePeersRead)).HandleFunc("GET /{id}/peers",
+		e.handlePeersGet())
+	apiGroup.With(e.authenticator.UserIdMatch("id", ScopeUsersRead, ScopePeersRead)).HandleFunc("GET /{id}/stats",
+		e.handleStatsGet())
 	apiGroup.With(e.authenticator.UserIdMatch("id")).HandleFunc("GET /{id}/interfaces", e.handleInterfacesGet())
 	apiGroup.With(e.authenticator.UserIdMatch("id")).HandleFunc("POST /{id}/api/enable", e.handleApiEnablePost())
 	apiGroup.With(e.authenticator.UserIdMatch("id")).HandleFunc("POST /{id}/api/disable", e.handleApiDisablePost())
+	apiGroup.With(e.authenticator.UserIdMatch("id")).HandleFunc("GET /{id}/api-tokens", e.handleApiTokensGet())
+	apiGroup.With(e.authenticator.UserIdMatch("id")).HandleFunc("POST /{id}/api-tokens", e.handleApiTokenCreatePost())
+	apiGroup.With(e.authenticator.UserIdMatch("id")).HandleFunc("DELETE /{id}/api-tokens/{tokenId}",
+		e.handleApiTokenDelete())
+	apiGroup.With(e.authenticator.UserIdMatch("id")).HandleFunc("POST /{id}/totp/enroll/start",
+		e.handleTotpEnrollStartPost())
+	apiGroup.With(e.authenticator.UserIdMatch("id")).HandleFunc("POST /{id}/totp/enroll/finish",
+		e.handleTotpEnrollFinishPost())
+	apiGroup.With(e.authenticator.UserIdMatch("id")).HandleFunc("POST /{id}/totp/recovery-codes",
+		e.handleTotpRecoveryCodesPost())
+	apiGroup.With(e.authenticator.UserIdMatch("id")).HandleFunc("POST /{id}/totp/remove", e.handleTotpRemovePost())
 }
 
 // handleAllGet returns a gorm Handler function.
@@ -391,3 +429,354 @@ func (e UserEndpoint) handleApiDisablePost() http.HandlerFunc {
 		respond.JSON(w, http.StatusOK, model.NewUser(user, false))
 	}
 }
+
+// handleApiTokensGet returns a gorm Handler function.
+//
+// @ID users_handleApiTokensGet
+// @Tags Users
+// @Summary Get all named REST API tokens of the given user.
+// @Produce json
+// @Param id path string true "The user identifier"
+// @Success 200 {object} []model.ApiToken
+// @Failure 400 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /user/{id}/api-tokens [get]
+func (e UserEndpoint) handleApiTokensGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := Base64UrlDecode(request.Path(r, "id"))
+		if userId == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				model.Error{Code: http.StatusBadRequest, Message: "missing id parameter"})
+			return
+		}
+
+		tokens, err := e.userService.GetApiTokens(r.Context(), domain.UserIdentifier(userId))
+		if err != nil {
+			respond.JSON(w, http.StatusInternalServerError,
+				model.Error{Code: http.StatusInternalServerError, Message: err.Error()})
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, model.NewApiTokens(tokens))
+	}
+}
+
+// handleApiTokenCreatePost returns a gorm Handler function.
+//
+// @ID users_handleApiTokenCreatePost
+// @Tags Users
+// @Summary Create a new named REST API token for the given user.
+// @Description The plain token is only returned once in the response.
+// @Accept json
+// @Produce json
+// @Param id path string true "The user identifier"
+// @Param request body model.ApiTokenRequest true "The token parameters"
+// @Success 200 {object} model.ApiToken
+// @Failure 400 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /user/{id}/api-tokens [post]
+func (e UserEndpoint) handleApiTokenCreatePost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := Base64UrlDecode(request.Path(r, "id"))
+		if userId == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				model.Error{Code: http.StatusBadRequest, Message: "missing id parameter"})
+			return
+		}
+
+		var req model.ApiTokenRequest
+		if err := request.BodyJson(r, &req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		token, err := e.userService.CreateApiToken(r.Context(), domain.UserIdentifier(userId),
+			model.NewDomainApiToken(&req))
+		if err != nil {
+			respond.JSON(w, http.StatusInternalServerError,
+				model.Error{Code: http.StatusInternalServerError, Message: err.Error()})
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, model.NewApiToken(token))
+	}
+}
+
+// handleApiTokenDelete returns a gorm Handler function.
+//
+// @ID users_handleApiTokenDelete
+// @Tags Users
+// @Summary Revoke a named REST API token of the given user.
+// @Produce json
+// @Param id path string true "The user identifier"
+// @Param tokenId path string true "The token identifier"
+// @Success 204 "No content if deletion was successful"
+// @Failure 400 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /user/{id}/api-tokens/{tokenId} [delete]
+func (e UserEndpoint) handleApiTokenDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tokenId := request.Path(r, "tokenId")
+		if tokenId == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				model.Error{Code: http.StatusBadRequest, Message: "missing token id parameter"})
+			return
+		}
+
+		err := e.userService.DeleteApiToken(r.Context(), domain.ApiTokenIdentifier(tokenId))
+		if err != nil {
+			respond.JSON(w, http.StatusInternalServerError,
+				model.Error{Code: http.StatusInternalServerError, Message: err.Error()})
+			return
+		}
+
+		respond.Status(w, http.StatusNoContent)
+	}
+}
+
+// handleUnlockPost returns a gorm Handler function.
+//
+// @ID users_handleUnlockPost
+// @Tags Users
+// @Summary Unlock the given user and reset the failed logins.
+// @Produce json
+// @Param id path string true "The user identifier"
+// @Success 200 {object} model.User
+// @Failure 400 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /user/{id}/unlock [post]
+func (e UserEndpoint) handleUnlockPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := Base64UrlDecode(request.Path(r, "id"))
+		if userId == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				model.Error{Code: http.StatusBadRequest, Message: "missing id parameter"})
+			return
+		}
+
+		user, err := e.userService.UnlockUser(r.Context(), domain.UserIdentifier(userId))
+		if err != nil {
+			respond.JSON(w, http.StatusInternalServerError,
+				model.Error{Code: http.StatusInternalServerError, Message: err.Error()})
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, model.NewUser(user, false))
+	}
+}
+
+// handleLoginBlocksGet returns a gorm Handler function.
+//
+// @ID users_handleLoginBlocksGet
+// @Tags Users
+// @Summary Get all accounts and source addresses that are blocked after too many failed logins.
+// @Produce json
+// @Success 200 {object} []model.LoginBlock
+// @Failure 500 {object} model.Error
+// @Router /user/login-blocks [get]
+func (e UserEndpoint) handleLoginBlocksGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		blocks, err := e.userService.GetBlockedLogins(r.Context())
+		if err != nil {
+			respond.JSON(w, http.StatusInternalServerError,
+				model.Error{Code: http.StatusInternalServerError, Message: err.Error()})
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, model.NewLoginBlocks(blocks))
+	}
+}
+
+// handleLoginBlockDelete returns a gorm Handler function.
+//
+// @ID users_handleLoginBlockDelete
+// @Tags Users
+// @Summary Remove the block of the given source address.
+// @Produce json
+// @Param source path string true "The base64 url encoded source address"
+// @Success 204 "No content if the block was removed successfully"
+// @Failure 400 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /user/login-blocks/{source} [delete]
+func (e UserEndpoint) handleLoginBlockDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		source := Base64UrlDecode(request.Path(r, "source"))
+		if source == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				model.Error{Code: http.StatusBadRequest, Message: "missing source parameter"})
+			return
+		}
+
+		err := e.userService.UnlockSource(r.Context(), source)
+		if err != nil {
+			respond.JSON(w, http.StatusInternalServerError,
+				model.Error{Code: http.StatusInternalServerError, Message: err.Error()})
+			return
+		}
+
+		respond.Status(w, http.StatusNoContent)
+	}
+}
+
+// handleTotpEnrollStartPost returns a gorm Handler function.
+//
+// @ID users_handleTotpEnrollStartPost
+// @Tags Users
+// @Summary Start the TOTP enrollment of the given user.
+// @Description The enrollment has to be confirmed with a code of the authenticator app.
+// @Produce json
+// @Param id path string true "The user identifier"
+// @Success 200 {object} model.TotpEnrollment
+// @Failure 400 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /user/{id}/totp/enroll/start [post]
+func (e UserEndpoint) handleTotpEnrollStartPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := Base64UrlDecode(request.Path(r, "id"))
+		if userId == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				model.Error{Code: http.StatusBadRequest, Message: "missing id parameter"})
+			return
+		}
+
+		enrollment, err := e.userService.StartTotpEnrollment(r.Context(), domain.UserIdentifier(userId))
+		if err != nil {
+			status := secondFactorErrorStatus(err)
+			respond.JSON(w, status, model.Error{Code: status, Message: err.Error()})
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, model.NewTotpEnrollment(enrollment))
+	}
+}
+
+// handleTotpEnrollFinishPost returns a gorm Handler function.
+//
+// @ID users_handleTotpEnrollFinishPost
+// @Tags Users
+// @Summary Confirm the TOTP enrollment of the given user with a code of the authenticator app.
+// @Description The recovery codes are only returned once in the response.
+// @Accept json
+// @Produce json
+// @Param id path string true "The user identifier"
+// @Param request body model.SecondFactorCodeRequest true "The code"
+// @Success 200 {object} model.RecoveryCodes
+// @Failure 400 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /user/{id}/totp/enroll/finish [post]
+func (e UserEndpoint) handleTotpEnrollFinishPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := Base64UrlDecode(request.Path(r, "id"))
+		if userId == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				model.Error{Code: http.StatusBadRequest, Message: "missing id parameter"})
+			return
+		}
+
+		var req model.SecondFactorCodeRequest
+		if err := request.BodyJson(r, &req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		codes, err := e.userService.FinishTotpEnrollment(r.Context(), domain.UserIdentifier(userId), req.Code)
+		if err != nil {
+			status := secondFactorErrorStatus(err)
+			respond.JSON(w, status, model.Error{Code: status, Message: err.Error()})
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, model.RecoveryCodes{RecoveryCodes: codes})
+	}
+}
+
+// handleTotpRecoveryCodesPost returns a gorm Handler function.
+//
+// @ID users_handleTotpRecoveryCodesPost
+// @Tags Users
+// @Summary Replace the recovery codes of the given user.
+// @Description The renewal has to be confirmed with a code of the authenticator app.
+// @Description The recovery codes are only returned once in the response.
+// @Accept json
+// @Produce json
+// @Param id path string true "The user identifier"
+// @Param request body model.SecondFactorCodeRequest true "The code"
+// @Success 200 {object} model.RecoveryCodes
+// @Failure 400 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /user/{id}/totp/recovery-codes [post]
+func (e UserEndpoint) handleTotpRecoveryCodesPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := Base64UrlDecode(request.Path(r, "id"))
+		if userId == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				model.Error{Code: http.StatusBadRequest, Message: "missing id parameter"})
+			return
+		}
+
+		var req model.SecondFactorCodeRequest
+		if err := request.BodyJson(r, &req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		codes, err := e.userService.RenewRecoveryCodes(r.Context(), domain.UserIdentifier(userId), req.Code)
+		if err != nil {
+			status := secondFactorErrorStatus(err)
+			respond.JSON(w, status, model.Error{Code: status, Message: err.Error()})
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, model.RecoveryCodes{RecoveryCodes: codes})
+	}
+}
+
+// handleTotpRemovePost returns a gorm Handler function.
+//
+// @ID users_handleTotpRemovePost
+// @Tags Users
+// @Summary Remove the TOTP second factor of the given user.
+// @Description Users have to confirm the removal with a code, admins can remove the second factor of other users
+// @Description without a code.
+// @Accept json
+// @Produce json
+// @Param id path string true "The user identifier"
+// @Param request body model.SecondFactorCodeRequest true "The code"
+// @Success 204 "No content if the second factor was removed successfully"
+// @Failure 400 {object} model.Error
+// @Failure 500 {object} model.Error
+// @Router /user/{id}/totp/remove [post]
+func (e UserEndpoint) handleTotpRemovePost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId := Base64UrlDecode(request.Path(r, "id"))
+		if userId == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				model.Error{Code: http.StatusBadRequest, Message: "missing id parameter"})
+			return
+		}
+
+		var req model.SecondFactorCodeRequest
+		if err := request.BodyJson(r, &req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, model.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		err := e.userService.RemoveTotp(r.Context(), domain.UserIdentifier(userId), req.Code)
+		if err != nil {
+			status := secondFactorErrorStatus(err)
+			respond.JSON(w, status, model.Error{Code: status, Message: err.Error()})
+			return
+		}
+
+		respond.Status(w, http.StatusNoContent)
+	}
+}
+
+// secondFactorErrorStatus maps invalid codes and requests to a bad request status.
+func secondFactorErrorStatus(err error) int {
+	if errors.Is(err, domain.ErrSecondFactorInvalid) || errors.Is(err, domain.ErrInvalidData) {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
@@ -14,6 +14,17 @@ type Scope string
 
 const (
 	ScopeAdmin Scope = "ADMIN" // Admin scope contains all other scopes
+
+	// Permission scopes are granted to users with a role that contains the permission for at least one interface.
+	// The backend services check the permissions for the specific interfaces.
+
+	ScopeInterfacesRead  = Scope(domain.PermissionInterfacesRead)
+	ScopeInterfacesWrite = Scope(domain.PermissionInterfacesWrite)
+	ScopePeersRead       = Scope(domain.PermissionPeersRead)
+	ScopePeersManage     = Scope(domain.PermissionPeersManage)
+	ScopePeersWrite      = Scope(domain.PermissionPeersWrite)
+	ScopeUsersRead       = Scope(domain.PermissionUsersRead)
+	ScopeAuditRead       = Scope(domain.PermissionAuditRead)
 )
 
 type UserAuthenticator interface {
@@ -63,6 +74,7 @@ func (h AuthenticationHandler) LoggedIn(scopes ...Scope) func(next http.Handler)
 			ctx := context.WithValue(r.Context(), domain.CtxUserInfo, &domain.ContextUserInfo{
 				Id:      domain.UserIdentifier(session.UserIdentifier),
 				IsAdmin: session.IsAdmin,
+				Roles:   session.Roles,
 			})
 			r = r.WithContext(ctx)
 
@@ -87,6 +99,7 @@ func (h AuthenticationHandler) InfoOnly() func(next http.Handler) http.Handler {
 				newContext = domain.SetUserInfo(r.Context(), &domain.ContextUserInfo{
 					Id:      domain.UserIdentifier(session.UserIdentifier),
 					IsAdmin: session.IsAdmin,
+					Roles:   session.Roles,
 				})
 			}
 
@@ -99,7 +112,9 @@ func (h AuthenticationHandler) InfoOnly() func(next http.Handler) http.Handler {
 }
 
 // UserIdMatch checks if the user id in the session matches the user id in the request. If not, the request is aborted.
-func (h AuthenticationHandler) UserIdMatch(idParameter string) func(next http.Handler) http.Handler {
+// If scopes are given, users with roles that grant all scopes can access the data of other users as well,
+// the backend services validate their permissions for the specific interfaces.
+func (h AuthenticationHandler) UserIdMatch(idParameter string, scopes ...Scope) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			session := h.session.GetData(r.Context())
@@ -109,6 +124,11 @@ func (h AuthenticationHandler) UserIdMatch(idParameter string) func(next http.Ha
 				return
 			}
 
+			if len(scopes) > 0 && UserHasScopes(session, scopes...) {
+				next.ServeHTTP(w, r) // Permissions of roles are checked by the backend services
+				return
+			}
+
 			sessionUserId := domain.UserIdentifier(session.UserIdentifier)
 			requestUserId := domain.UserIdentifier(Base64UrlDecode(request.Path(r, idParameter)))
 
@@ -136,11 +156,14 @@ func UserHasScopes(session SessionData, scopes ...Scope) bool {
 		return true
 	}
 
-	// Check if admin scope is required
+	// Check if admin scope is required, all other scopes must be granted by the roles of the user
 	for _, scope := range scopes {
 		if scope == ScopeAdmin {
 			return false
 		}
+		if !domain.RolesHavePermission(session.Roles, domain.Permission(scope), "") {
+			return false
+		}
 	}
 
 	// For all other scopes, a logged-in user is sufficient (for now)
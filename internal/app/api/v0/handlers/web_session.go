@@ -10,6 +10,7 @@ import (
 	"github.com/alexedwards/scs/v2"
 
 	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
 )
 
 func init() {
@@ -19,6 +20,7 @@ func init() {
 type SessionData struct {
 	LoggedIn bool
 	IsAdmin  bool
+	Roles    []domain.Role
 
 	UserIdentifier string
 
@@ -33,6 +35,10 @@ type SessionData struct {
 
 	WebAuthnData string
 
+	// SecondFactorUser is set after a successful password login, if the user has to provide a second factor.
+	SecondFactorUser    string
+	SecondFactorExpires time.Time
+
 	CsrfToken string
 }
 
@@ -11,6 +11,7 @@ type Settings struct {
 	SelfProvisioning          bool `json:"SelfProvisioning"`
 	ApiAdminOnly              bool `json:"ApiAdminOnly"`
 	WebAuthnEnabled           bool `json:"WebAuthnEnabled"`
+	TotpEnabled               bool `json:"TotpEnabled"`
 	MinPasswordLength         int  `json:"MinPasswordLength"`
 	LoginFormVisible          bool `json:"LoginFormVisible"`
 }
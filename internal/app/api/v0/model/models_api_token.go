@@ -0,0 +1,75 @@
+package model
+
+import (
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type ApiToken struct {
+	Identifier     string     `json:"Identifier"`      // the public identifier of the token
+	UserIdentifier string     `json:"UserIdentifier"`  // the owner of the token
+	Name           string     `json:"Name"`            // the display name of the token
+	Token          string     `json:"Token,omitempty"` // the plain token, only set directly after creation
+	Scopes         []string   `json:"Scopes"`
+	AllowedCidrs   []string   `json:"AllowedCidrs"` // empty = all source addresses are allowed
+	CreatedAt      time.Time  `json:"CreatedAt"`
+	ExpiresAt      *time.Time `json:"ExpiresAt"`
+	LastUsedAt     *time.Time `json:"LastUsedAt"`
+	Expired        bool       `json:"Expired"`
+}
+
+func NewApiToken(src *domain.ApiToken) *ApiToken {
+	scopes := make([]string, len(src.Scopes))
+	for i, scope := range src.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	allowedCidrs := src.AllowedCidrs
+	if allowedCidrs == nil {
+		allowedCidrs = []string{}
+	}
+
+	return &ApiToken{
+		Identifier:     string(src.Identifier),
+		UserIdentifier: string(src.UserIdentifier),
+		Name:           src.Name,
+		Token:          src.Token,
+		Scopes:         scopes,
+		AllowedCidrs:   allowedCidrs,
+		CreatedAt:      src.CreatedAt,
+		ExpiresAt:      src.ExpiresAt,
+		LastUsedAt:     src.LastUsedAt,
+		Expired:        src.IsExpired(),
+	}
+}
+
+func NewApiTokens(src []domain.ApiToken) []ApiToken {
+	results := make([]ApiToken, len(src))
+	for i := range src {
+		results[i] = *NewApiToken(&src[i])
+	}
+
+	return results
+}
+
+type ApiTokenRequest struct {
+	Name         string     `json:"Name"`
+	Scopes       []string   `json:"Scopes"`
+	AllowedCidrs []string   `json:"AllowedCidrs"` // empty = all source addresses are allowed
+	ExpiresAt    *time.Time `json:"ExpiresAt"`    // nil = the token does not expire
+}
+
+func NewDomainApiToken(src *ApiTokenRequest) *domain.ApiToken {
+	scopes := make([]domain.ApiTokenScope, len(src.Scopes))
+	for i, scope := range src.Scopes {
+		scopes[i] = domain.ApiTokenScope(scope)
+	}
+
+	return &domain.ApiToken{
+		Name:         src.Name,
+		Scopes:       scopes,
+		AllowedCidrs: src.AllowedCidrs,
+		ExpiresAt:    src.ExpiresAt,
+	}
+}
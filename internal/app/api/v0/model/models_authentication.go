@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/base64"
 	"slices"
 	"strings"
 
@@ -32,12 +33,14 @@ func NewLoginProviderInfos(src []domain.LoginProviderInfo) []LoginProviderInfo {
 }
 
 type SessionInfo struct {
-	LoggedIn       bool    `json:"LoggedIn"`
-	IsAdmin        bool    `json:"IsAdmin,omitempty"`
-	UserIdentifier *string `json:"UserIdentifier,omitempty"`
-	UserFirstname  *string `json:"UserFirstname,omitempty"`
-	UserLastname   *string `json:"UserLastname,omitempty"`
-	UserEmail      *string `json:"UserEmail,omitempty"`
+	LoggedIn       bool     `json:"LoggedIn"`
+	IsAdmin        bool     `json:"IsAdmin,omitempty"`
+	Roles          []string `json:"Roles,omitempty"`
+	Permissions    []string `json:"Permissions,omitempty"` // permissions that are granted for at least one interface
+	UserIdentifier *string  `json:"UserIdentifier,omitempty"`
+	UserFirstname  *string  `json:"UserFirstname,omitempty"`
+	UserLastname   *string  `json:"UserLastname,omitempty"`
+	UserEmail      *string  `json:"UserEmail,omitempty"`
 }
 
 type OauthInitiationResponse struct {
@@ -73,3 +76,45 @@ func NewWebAuthnCredentialResponses(src []domain.UserWebauthnCredential) []WebAu
 	})
 	return credentials
 }
+
+type SecondFactorChallenge struct {
+	SecondFactorRequired bool     `json:"SecondFactorRequired"`
+	Methods              []string `json:"Methods"`            // totp, recovery or webauthn
+	EnrollmentRequired   bool     `json:"EnrollmentRequired"` // the user has to enroll TOTP to complete the login
+}
+
+func NewSecondFactorChallenge(src *domain.SecondFactorChallenge) *SecondFactorChallenge {
+	methods := make([]string, len(src.Methods))
+	for i, method := range src.Methods {
+		methods[i] = string(method)
+	}
+
+	return &SecondFactorChallenge{
+		SecondFactorRequired: true,
+		Methods:              methods,
+		EnrollmentRequired:   src.EnrollmentRequired,
+	}
+}
+
+type SecondFactorCodeRequest struct {
+	Code string `json:"Code" binding:"required"` // a code of the authenticator app or a recovery code
+}
+
+type TotpEnrollment struct {
+	Secret string `json:"Secret"`
+	Uri    string `json:"Uri"`
+	QrCode string `json:"QrCode"` // the URI as data URL of a PNG image
+}
+
+func NewTotpEnrollment(src *domain.TotpEnrollment) *TotpEnrollment {
+	return &TotpEnrollment{
+		Secret: src.Secret,
+		Uri:    src.Uri,
+		QrCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(src.QrCode),
+	}
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"RecoveryCodes"` // the single-use recovery codes, they are only shown once
+	User          *User    `json:"User,omitempty"`
+}
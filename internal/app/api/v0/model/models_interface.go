@@ -11,11 +11,15 @@ type Interface struct {
 	Identifier     string `json:"Identifier" example:"wg0"`      // device name, for example: wg0
 	DisplayName    string `json:"DisplayName"`                   // a nice display name/ description for the interface
 	Mode           string `json:"Mode" example:"server"`         // the interface type, either 'server', 'client' or 'any'
+	DriverType     string `json:"DriverType" example:"linux"`    // the interface driver type, either 'linux' or 'software'
+	AgentHost      string `json:"AgentHost"`                     // the agent host that manages the interface, empty for local interfaces
+	DriftMode      string `json:"DriftMode" example:"alert"`     // how drift is handled, either 'alert', 'correct' or 'import'
 	PrivateKey     string `json:"PrivateKey" example:"abcdef=="` // private Key of the server interface
 	PublicKey      string `json:"PublicKey" example:"abcdef=="`  // public Key of the server interface
 	Disabled       bool   `json:"Disabled"`                      // flag that specifies if the interface is enabled (up) or not (down)
 	DisabledReason string `json:"DisabledReason"`                // the reason why the interface has been disabled
 	SaveConfig     bool   `json:"SaveConfig"`                    // automatically persist config changes to the wgX.conf file
+	ManagedBy      string `json:"ManagedBy" readonly:"true"`     // set if the interface is managed by the declarative spec
 
 	ListenPort   int      `json:"ListenPort"`   // the listening port, for example: 51820
 	Addresses    []string `json:"Addresses"`    // the interface ip addresses
@@ -45,6 +49,12 @@ type Interface struct {
 	PeerDefPreDown  string `json:"PeerDefPreDown"`  // default action that is executed before the device is down
 	PeerDefPostDown string `json:"PeerDefPostDown"` // default action that is executed after the device is down
 
+	PeerDefQuota TrafficQuota `json:"PeerDefQuota"` // default traffic quota for peers without a peer or user quota
+
+	PeerRenewalPolicy PeerRenewalPolicy `json:"PeerRenewalPolicy"` // defines how users can renew their peers
+
+	PeerDefIpv6 PeerIpv6Settings `json:"PeerDefIpv6"` // defines how IPv6 addresses and prefixes are assigned to new peers
+
 	// Calculated values
 
 	EnabledPeers int    `json:"EnabledPeers"`
@@ -57,11 +67,15 @@ func NewInterface(src *domain.Interface, peers []domain.Peer) *Interface {
 		Identifier:                 string(src.Identifier),
 		DisplayName:                src.DisplayName,
 		Mode:                       string(src.Type),
+		DriverType:                 src.DriverType,
+		AgentHost:                  src.AgentHost,
+		DriftMode:                  string(src.GetDriftMode()),
 		PrivateKey:                 src.PrivateKey,
 		PublicKey:                  src.PublicKey,
 		Disabled:                   src.IsDisabled(),
 		DisabledReason:             src.DisabledReason,
 		SaveConfig:                 src.SaveConfig,
+		ManagedBy:                  src.ManagedBy,
 		ListenPort:                 src.ListenPort,
 		Addresses:                  domain.CidrsToStringSlice(src.Addresses),
 		Dns:                        internal.SliceString(src.DnsStr),
@@ -86,6 +100,9 @@ func NewInterface(src *domain.Interface, peers []domain.Peer) *Interface {
 		PeerDefPostUp:              src.PeerDefPostUp,
 		PeerDefPreDown:             src.PeerDefPreDown,
 		PeerDefPostDown:            src.PeerDefPostDown,
+		PeerDefQuota:               NewTrafficQuota(src.PeerDefQuota),
+		PeerRenewalPolicy:          NewPeerRenewalPolicy(src.PeerRenewalPolicy),
+		PeerDefIpv6:                NewPeerIpv6Settings(src.PeerDefIpv6),
 
 		EnabledPeers: 0,
 		TotalPeers:   0,
@@ -146,7 +163,9 @@ func NewDomainInterface(src *Interface) *domain.Interface {
 		SaveConfig:                 src.SaveConfig,
 		DisplayName:                src.DisplayName,
 		Type:                       domain.InterfaceType(src.Mode),
-		DriverType:                 "",  // currently unused
+		DriverType:                 src.DriverType,
+		AgentHost:                  src.AgentHost,
+		DriftMode:                  domain.DriftMode(src.DriftMode),
 		Disabled:                   nil, // set below
 		DisabledReason:             src.DisabledReason,
 		PeerDefNetworkStr:          internal.SliceToString(src.PeerDefNetwork),
@@ -162,6 +181,9 @@ func NewDomainInterface(src *Interface) *domain.Interface {
 		PeerDefPostUp:              src.PeerDefPostUp,
 		PeerDefPreDown:             src.PeerDefPreDown,
 		PeerDefPostDown:            src.PeerDefPostDown,
+		PeerDefQuota:               NewDomainTrafficQuota(src.PeerDefQuota),
+		PeerRenewalPolicy:          NewDomainPeerRenewalPolicy(src.PeerRenewalPolicy),
+		PeerDefIpv6:                NewDomainPeerIpv6Settings(src.PeerDefIpv6),
 	}
 
 	if src.Disabled {
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type PeerIpv6Settings struct {
+	HashedAddresses       bool   `json:"HashedAddresses"`       // if set, the host part of IPv6 peer addresses is derived from the peer public key
+	DelegatedPrefix       string `json:"DelegatedPrefix"`       // the prefix out of which a subnet is delegated to each new peer
+	DelegatedPrefixLength int    `json:"DelegatedPrefixLength"` // the length of the delegated subnets
+}
+
+func NewPeerIpv6Settings(src domain.PeerIpv6Settings) PeerIpv6Settings {
+	return PeerIpv6Settings{
+		HashedAddresses:       src.HashedAddresses,
+		DelegatedPrefix:       src.DelegatedPrefix,
+		DelegatedPrefixLength: src.DelegatedPrefixLength,
+	}
+}
+
+func NewDomainPeerIpv6Settings(src PeerIpv6Settings) domain.PeerIpv6Settings {
+	return domain.PeerIpv6Settings{
+		HashedAddresses:       src.HashedAddresses,
+		DelegatedPrefix:       src.DelegatedPrefix,
+		DelegatedPrefixLength: src.DelegatedPrefixLength,
+	}
+}
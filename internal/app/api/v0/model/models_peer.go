@@ -48,6 +48,13 @@ type Peer struct {
 	DisabledReason      string     `json:"DisabledReason"`                       // the reason why the peer has been disabled
 	ExpiresAt           ExpiryDate `json:"ExpiresAt,omitempty"`                  // expiry dates for peers
 	Notes               string     `json:"Notes"`                                // a note field for peers
+	Tags                []string   `json:"Tags"`                                 // the tags of the peer
+	ManagedBy           string     `json:"ManagedBy" readonly:"true"`            // set if the peer is managed by the declarative spec
+
+	Quota TrafficQuota `json:"Quota"` // the traffic quota of the peer, takes precedence over user and interface quotas
+
+	RenewalCount     int  `json:"RenewalCount" readonly:"true"`     // the number of renewals of the expiry date
+	RenewalRequested bool `json:"RenewalRequested" readonly:"true"` // if set, a renewal is waiting for approval
 
 	Endpoint            ConfigOption[string]   `json:"Endpoint"`            // the endpoint address
 	EndpointPublicKey   ConfigOption[string]   `json:"EndpointPublicKey"`   // the endpoint public key
@@ -89,6 +96,11 @@ func NewPeer(src *domain.Peer) *Peer {
 		DisabledReason:      src.DisabledReason,
 		ExpiresAt:           ExpiryDate{src.ExpiresAt},
 		Notes:               src.Notes,
+		Tags:                domain.NormalizeTags(src.Tags),
+		ManagedBy:           src.ManagedBy,
+		Quota:               NewTrafficQuota(src.Quota),
+		RenewalCount:        src.RenewalCount,
+		RenewalRequested:    src.IsRenewalRequested(),
 		Endpoint:            ConfigOptionFromDomain(src.Endpoint),
 		EndpointPublicKey:   ConfigOptionFromDomain(src.EndpointPublicKey),
 		AllowedIPs:          StringSliceConfigOptionFromDomain(src.AllowedIPsStr),
@@ -143,6 +155,8 @@ func NewDomainPeer(src *Peer) *domain.Peer {
 		DisabledReason:      src.DisabledReason,
 		ExpiresAt:           src.ExpiresAt.Time,
 		Notes:               src.Notes,
+		Tags:                domain.NormalizeTags(src.Tags),
+		Quota:               NewDomainTrafficQuota(src.Quota),
 		Interface: domain.PeerInterfaceConfig{
 			KeyPair: domain.KeyPair{
 				PrivateKey: src.PrivateKey,
@@ -206,6 +220,9 @@ func NewPeerStats(enabled bool, src []domain.PeerStatus) *PeerStats {
 			LastHandshake:    srcStat.LastHandshake,
 			EndpointAddress:  srcStat.Endpoint,
 			LastSessionStart: srcStat.LastSessionStart,
+			QuotaLimit:       srcStat.QuotaLimit,
+			QuotaUsed:        srcStat.QuotaUsed(),
+			QuotaPeriodStart: srcStat.QuotaPeriodStart,
 		}
 	}
 
@@ -227,4 +244,8 @@ type PeerStatData struct {
 	LastHandshake    *time.Time `json:"LastHandshake"`
 	EndpointAddress  string     `json:"EndpointAddress"`
 	LastSessionStart *time.Time `json:"LastSessionStart"`
+
+	QuotaLimit       uint64     `json:"QuotaLimit"` // 0 if no quota applies
+	QuotaUsed        uint64     `json:"QuotaUsed"`
+	QuotaPeriodStart *time.Time `json:"QuotaPeriodStart"`
 }
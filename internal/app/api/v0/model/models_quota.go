@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type TrafficQuota struct {
+	Limit         uint64     `json:"Limit"`                 // the maximum number of bytes per period, 0 disables the quota
+	Direction     string     `json:"Direction"`             // the counted traffic: rx, tx or total
+	ResetCycle    string     `json:"ResetCycle"`            // the length of a period: daily, weekly, monthly or custom
+	ResetAnchor   *time.Time `json:"ResetAnchor,omitempty"` // the start of an arbitrary period, all other periods are aligned to it
+	ResetInterval int        `json:"ResetInterval"`         // the length of a custom period in days
+}
+
+func NewTrafficQuota(src domain.TrafficQuota) TrafficQuota {
+	return TrafficQuota{
+		Limit:         src.Limit,
+		Direction:     string(src.Direction),
+		ResetCycle:    string(src.ResetCycle),
+		ResetAnchor:   src.ResetAnchor,
+		ResetInterval: src.ResetInterval,
+	}
+}
+
+func NewDomainTrafficQuota(src TrafficQuota) domain.TrafficQuota {
+	return domain.TrafficQuota{
+		Limit:         src.Limit,
+		Direction:     domain.QuotaDirection(src.Direction),
+		ResetCycle:    domain.QuotaResetCycle(src.ResetCycle),
+		ResetAnchor:   src.ResetAnchor,
+		ResetInterval: src.ResetInterval,
+	}
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type PeerRenewalPolicy struct {
+	Enabled         bool `json:"Enabled"`         // if set, users can renew their peers
+	Step            int  `json:"Step"`            // the number of days that are added to the expiry date per renewal
+	MaxLifetime     int  `json:"MaxLifetime"`     // the maximum number of days between peer creation and expiry, 0 means unlimited
+	MaxRenewals     int  `json:"MaxRenewals"`     // the maximum number of renewals per peer, 0 means unlimited
+	RequireApproval bool `json:"RequireApproval"` // if set, renewals requested by users must be approved by an admin
+}
+
+func NewPeerRenewalPolicy(src domain.PeerRenewalPolicy) PeerRenewalPolicy {
+	return PeerRenewalPolicy{
+		Enabled:         src.Enabled,
+		Step:            src.Step,
+		MaxLifetime:     src.MaxLifetime,
+		MaxRenewals:     src.MaxRenewals,
+		RequireApproval: src.RequireApproval,
+	}
+}
+
+func NewDomainPeerRenewalPolicy(src PeerRenewalPolicy) domain.PeerRenewalPolicy {
+	return domain.PeerRenewalPolicy{
+		Enabled:         src.Enabled,
+		Step:            src.Step,
+		MaxLifetime:     src.MaxLifetime,
+		MaxRenewals:     src.MaxRenewals,
+		RequireApproval: src.RequireApproval,
+	}
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type ShareLink struct {
+	Identifier     string `json:"Identifier"`      // the public identifier of the share link
+	PeerIdentifier string `json:"PeerIdentifier"`  // the shared peer
+	Token          string `json:"Token,omitempty"` // the plain token, only set directly after creation
+	Url            string `json:"Url,omitempty"`   // the download url, only set directly after creation
+
+	CreatedBy  string     `json:"CreatedBy"`
+	CreatedAt  time.Time  `json:"CreatedAt"`
+	ExpiresAt  time.Time  `json:"ExpiresAt"`
+	MaxUses    int        `json:"MaxUses"` // 0 = unlimited
+	UseCount   int        `json:"UseCount"`
+	LastUsedAt *time.Time `json:"LastUsedAt"`
+	Revoked    *time.Time `json:"Revoked"`
+	RevokedBy  string     `json:"RevokedBy"`
+	Usable     bool       `json:"Usable"` // true if the link can still be used to download the configuration
+}
+
+func NewShareLink(src *domain.PeerShareLink, url string) *ShareLink {
+	return &ShareLink{
+		Identifier:     string(src.Identifier),
+		PeerIdentifier: string(src.PeerIdentifier),
+		Token:          src.Token,
+		Url:            url,
+		CreatedBy:      src.CreatedBy,
+		CreatedAt:      src.CreatedAt,
+		ExpiresAt:      src.ExpiresAt,
+		MaxUses:        src.MaxUses,
+		UseCount:       src.UseCount,
+		LastUsedAt:     src.LastUsedAt,
+		Revoked:        src.Revoked,
+		RevokedBy:      src.RevokedBy,
+		Usable:         src.CheckUsable() == nil,
+	}
+}
+
+func NewShareLinks(src []domain.PeerShareLink) []ShareLink {
+	results := make([]ShareLink, len(src))
+	for i := range src {
+		results[i] = *NewShareLink(&src[i], "")
+	}
+
+	return results
+}
+
+type ShareLinkRequest struct {
+	ValidHours int  `json:"ValidHours"` // 0 = use the configured default
+	MaxUses    *int `json:"MaxUses"`    // 0 = unlimited, nil = use the configured default
+}
@@ -12,6 +12,8 @@ type User struct {
 	Source       string `json:"Source"`
 	ProviderName string `json:"ProviderName"`
 	IsAdmin      bool   `json:"IsAdmin"`
+	// Roles grant additional permissions, like "auditor", "helpdesk" or "operator:<interface>".
+	Roles []string `json:"Roles"`
 
 	Firstname  string `json:"Firstname"`
 	Lastname   string `json:"Lastname"`
@@ -25,10 +27,19 @@ type User struct {
 	Locked         bool   `json:"Locked"`         // if this field is set, the user is locked
 	LockedReason   string `json:"LockedReason"`   // the reason why the user has been locked
 
+	LockedUntil *time.Time `json:"LockedUntil,omitempty" readonly:"true"` // set if the user is locked temporarily
+
+	PeerQuota TrafficQuota `json:"PeerQuota"` // traffic quota for all peers of the user without an own quota
+
 	ApiToken        string     `json:"ApiToken"`
 	ApiTokenCreated *time.Time `json:"ApiTokenCreated,omitempty"`
 	ApiEnabled      bool       `json:"ApiEnabled"`
 
+	TotpEnabled       bool `json:"TotpEnabled" readonly:"true"`       // true if a TOTP authenticator app is enrolled
+	TotpRecoveryCodes int  `json:"TotpRecoveryCodes" readonly:"true"` // the number of unused recovery codes
+
+	ManagedBy string `json:"ManagedBy" readonly:"true"` // set if the user is managed by the declarative spec
+
 	// Calculated
 
 	PeerCount int `json:"PeerCount"`
@@ -41,6 +52,7 @@ func NewUser(src *domain.User, exposeCreds bool) *User {
 		Source:          string(src.Source),
 		ProviderName:    src.ProviderName,
 		IsAdmin:         src.IsAdmin,
+		Roles:           NewRoles(src.Roles),
 		Firstname:       src.Firstname,
 		Lastname:        src.Lastname,
 		Phone:           src.Phone,
@@ -51,13 +63,23 @@ func NewUser(src *domain.User, exposeCreds bool) *User {
 		DisabledReason:  src.DisabledReason,
 		Locked:          src.IsLocked(),
 		LockedReason:    src.LockedReason,
+		PeerQuota:       NewTrafficQuota(src.PeerQuota),
 		ApiToken:        "", // by default, do not expose API token
 		ApiTokenCreated: src.ApiTokenCreated,
 		ApiEnabled:      src.IsApiEnabled(),
 
+		TotpEnabled:       src.HasTotp(),
+		TotpRecoveryCodes: len(src.TotpRecoveryCodes),
+
+		ManagedBy: src.ManagedBy,
+
 		PeerCount: src.LinkedPeerCount,
 	}
 
+	if u.Locked {
+		u.LockedUntil = src.LockedUntil
+	}
+
 	if exposeCreds {
 		u.ApiToken = src.ApiToken
 	}
@@ -82,6 +104,7 @@ func NewDomainUser(src *User) *domain.User {
 		Source:          domain.UserSource(src.Source),
 		ProviderName:    src.ProviderName,
 		IsAdmin:         src.IsAdmin,
+		Roles:           NewDomainRoles(src.Roles),
 		Firstname:       src.Firstname,
 		Lastname:        src.Lastname,
 		Phone:           src.Phone,
@@ -92,6 +115,7 @@ func NewDomainUser(src *User) *domain.User {
 		DisabledReason:  src.DisabledReason,
 		Locked:          nil, // set below
 		LockedReason:    src.LockedReason,
+		PeerQuota:       NewDomainTrafficQuota(src.PeerQuota),
 		LinkedPeerCount: src.PeerCount,
 	}
 
@@ -111,3 +135,63 @@ func NewDomainUser(src *User) *domain.User {
 
 	return res
 }
+
+func NewRoles(src []domain.Role) []string {
+	roles := make([]string, len(src))
+	for i, role := range src {
+		roles[i] = string(role)
+	}
+
+	return roles
+}
+
+func NewDomainRoles(src []string) []domain.Role {
+	roles := make([]domain.Role, len(src))
+	for i, role := range src {
+		roles[i] = domain.Role(role)
+	}
+
+	return roles
+}
+
+// NewPermissions returns the permissions that are granted for at least one interface. Admins have all permissions.
+func NewPermissions(isAdmin bool, roles []domain.Role) []string {
+	permissions := domain.PermissionsOfRoles(roles)
+	if isAdmin {
+		permissions = domain.KnownPermissions
+	}
+
+	result := make([]string, len(permissions))
+	for i, permission := range permissions {
+		result[i] = string(permission)
+	}
+
+	return result
+}
+
+type LoginBlock struct {
+	Subject      string     `json:"Subject"` // the account or the source address
+	IsSource     bool       `json:"IsSource"`
+	Failures     int        `json:"Failures"`
+	LastFailure  time.Time  `json:"LastFailure"`
+	BlockedUntil *time.Time `json:"BlockedUntil"`
+}
+
+func NewLoginBlock(src *domain.LoginAttempt) *LoginBlock {
+	return &LoginBlock{
+		Subject:      src.Subject(),
+		IsSource:     src.IsSource(),
+		Failures:     src.Failures,
+		LastFailure:  src.LastFailure,
+		BlockedUntil: src.BlockedUntil,
+	}
+}
+
+func NewLoginBlocks(src []domain.LoginAttempt) []LoginBlock {
+	results := make([]LoginBlock, len(src))
+	for i := range src {
+		results[i] = *NewLoginBlock(&src[i])
+	}
+
+	return results
+}
@@ -0,0 +1,56 @@
+package backend
+
+import (
+	"context"
+	"errors"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type ApiTokenServiceManagerRepo interface {
+	GetApiTokens(ctx context.Context, userId domain.UserIdentifier) ([]domain.ApiToken, error)
+	CreateApiToken(ctx context.Context, userId domain.UserIdentifier, template *domain.ApiToken) (
+		*domain.ApiToken,
+		error,
+	)
+	DeleteApiToken(ctx context.Context, id domain.ApiTokenIdentifier) error
+}
+
+type ApiTokenService struct {
+	cfg *config.Config
+
+	tokens ApiTokenServiceManagerRepo
+}
+
+func NewApiTokenService(cfg *config.Config, tokens ApiTokenServiceManagerRepo) *ApiTokenService {
+	return &ApiTokenService{
+		cfg:    cfg,
+		tokens: tokens,
+	}
+}
+
+// GetAllForUser returns all named API tokens of the given user.
+func (s ApiTokenService) GetAllForUser(ctx context.Context, userId domain.UserIdentifier) (
+	[]domain.ApiToken,
+	error,
+) {
+	if s.cfg.Advanced.ApiAdminOnly && !domain.GetUserInfo(ctx).IsAdmin {
+		return nil, errors.Join(errors.New("only admins can access this endpoint"), domain.ErrNoPermission)
+	}
+
+	return s.tokens.GetApiTokens(ctx, userId)
+}
+
+// Create creates a new named API token for the given user.
+func (s ApiTokenService) Create(ctx context.Context, userId domain.UserIdentifier, template *domain.ApiToken) (
+	*domain.ApiToken,
+	error,
+) {
+	return s.tokens.CreateApiToken(ctx, userId, template)
+}
+
+// Delete revokes the given named API token.
+func (s ApiTokenService) Delete(ctx context.Context, id domain.ApiTokenIdentifier) error {
+	return s.tokens.DeleteApiToken(ctx, id)
+}
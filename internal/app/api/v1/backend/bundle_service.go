@@ -0,0 +1,65 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type BundleServiceManagerRepo interface {
+	ExportInterface(
+		ctx context.Context,
+		id domain.InterfaceIdentifier,
+		passphrase string,
+	) (*domain.InterfaceBundle, error)
+	ImportInterface(
+		ctx context.Context,
+		bundle *domain.InterfaceBundle,
+		passphrase string,
+		opts domain.BundleImportOptions,
+	) (*domain.BundleImportResult, error)
+}
+
+type BundleService struct {
+	cfg *config.Config
+
+	bundles BundleServiceManagerRepo
+}
+
+func NewBundleService(cfg *config.Config, bundles BundleServiceManagerRepo) *BundleService {
+	return &BundleService{
+		cfg:     cfg,
+		bundles: bundles,
+	}
+}
+
+// Export returns the encoded bundle of the given interface.
+func (s BundleService) Export(
+	ctx context.Context,
+	id domain.InterfaceIdentifier,
+	passphrase string,
+	format domain.BundleFormat,
+) ([]byte, error) {
+	bundle, err := s.bundles.ExportInterface(ctx, id, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.MarshalInterfaceBundle(bundle, format)
+}
+
+// Import decodes the given bundle and imports it.
+func (s BundleService) Import(
+	ctx context.Context,
+	data []byte,
+	passphrase string,
+	opts domain.BundleImportOptions,
+) (*domain.BundleImportResult, error) {
+	bundle, err := domain.UnmarshalInterfaceBundle(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.bundles.ImportInterface(ctx, bundle, passphrase, opts)
+}
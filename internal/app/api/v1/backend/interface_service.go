@@ -15,6 +15,7 @@ type InterfaceServiceInterfaceManagerRepo interface {
 	CreateInterface(ctx context.Context, in *domain.Interface) (*domain.Interface, error)
 	UpdateInterface(ctx context.Context, in *domain.Interface) (*domain.Interface, []domain.Peer, error)
 	DeleteInterface(ctx context.Context, id domain.InterfaceIdentifier) error
+	DetectDrift(ctx context.Context) ([]domain.InterfaceDrift, error)
 }
 
 type InterfaceService struct {
@@ -32,7 +33,7 @@ func NewInterfaceService(cfg *config.Config, interfaces InterfaceServiceInterfac
 }
 
 func (s InterfaceService) GetAll(ctx context.Context) ([]domain.Interface, [][]domain.Peer, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesRead, ""); err != nil {
 		return nil, nil, err
 	}
 
@@ -49,7 +50,7 @@ func (s InterfaceService) GetById(ctx context.Context, id domain.InterfaceIdenti
 	[]domain.Peer,
 	error,
 ) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesRead, id); err != nil {
 		return nil, nil, err
 	}
 
@@ -92,7 +93,7 @@ func (s InterfaceService) Update(ctx context.Context, id domain.InterfaceIdentif
 	[]domain.Peer,
 	error,
 ) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesWrite, id); err != nil {
 		return nil, nil, err
 	}
 
@@ -110,7 +111,7 @@ func (s InterfaceService) Update(ctx context.Context, id domain.InterfaceIdentif
 }
 
 func (s InterfaceService) Delete(ctx context.Context, id domain.InterfaceIdentifier) error {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesWrite, id); err != nil {
 		return err
 	}
 
@@ -121,3 +122,16 @@ func (s InterfaceService) Delete(ctx context.Context, id domain.InterfaceIdentif
 
 	return nil
 }
+
+func (s InterfaceService) GetDrift(ctx context.Context) ([]domain.InterfaceDrift, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	drifts, err := s.interfaces.DetectDrift(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return drifts, nil
+}
@@ -0,0 +1,93 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type IpamServiceIpamManagerRepo interface {
+	GetIpRanges(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.IpRange, error)
+	SaveIpRange(ctx context.Context, ipRange *domain.IpRange) (*domain.IpRange, error)
+	DeleteIpRange(ctx context.Context, id domain.InterfaceIdentifier, rangeId domain.IpRangeIdentifier) error
+	GetIpReservations(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.IpReservation, error)
+	SaveIpReservation(ctx context.Context, reservation *domain.IpReservation) (*domain.IpReservation, error)
+	DeleteIpReservation(ctx context.Context, id domain.InterfaceIdentifier, address string) error
+	GetIpUtilization(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.IpPoolUtilization, error)
+}
+
+type IpamService struct {
+	cfg *config.Config
+
+	ipam IpamServiceIpamManagerRepo
+}
+
+func NewIpamService(cfg *config.Config, ipam IpamServiceIpamManagerRepo) *IpamService {
+	return &IpamService{
+		cfg:  cfg,
+		ipam: ipam,
+	}
+}
+
+func (s IpamService) GetRanges(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.IpRange, error) {
+	return s.ipam.GetIpRanges(ctx, id)
+}
+
+func (s IpamService) CreateRange(
+	ctx context.Context,
+	id domain.InterfaceIdentifier,
+	ipRange *domain.IpRange,
+) (*domain.IpRange, error) {
+	ipRange.InterfaceIdentifier = id
+	ipRange.Identifier = "" // always create a new range
+	return s.ipam.SaveIpRange(ctx, ipRange)
+}
+
+func (s IpamService) UpdateRange(
+	ctx context.Context,
+	id domain.InterfaceIdentifier,
+	rangeId domain.IpRangeIdentifier,
+	ipRange *domain.IpRange,
+) (*domain.IpRange, error) {
+	ipRange.InterfaceIdentifier = id
+	ipRange.Identifier = rangeId
+	return s.ipam.SaveIpRange(ctx, ipRange)
+}
+
+func (s IpamService) DeleteRange(
+	ctx context.Context,
+	id domain.InterfaceIdentifier,
+	rangeId domain.IpRangeIdentifier,
+) error {
+	return s.ipam.DeleteIpRange(ctx, id, rangeId)
+}
+
+func (s IpamService) GetReservations(ctx context.Context, id domain.InterfaceIdentifier) (
+	[]domain.IpReservation,
+	error,
+) {
+	return s.ipam.GetIpReservations(ctx, id)
+}
+
+func (s IpamService) SaveReservation(
+	ctx context.Context,
+	id domain.InterfaceIdentifier,
+	address string,
+	reservation *domain.IpReservation,
+) (*domain.IpReservation, error) {
+	reservation.InterfaceIdentifier = id
+	reservation.Address = address
+	return s.ipam.SaveIpReservation(ctx, reservation)
+}
+
+func (s IpamService) DeleteReservation(ctx context.Context, id domain.InterfaceIdentifier, address string) error {
+	return s.ipam.DeleteIpReservation(ctx, id, address)
+}
+
+func (s IpamService) GetUtilization(ctx context.Context, id domain.InterfaceIdentifier) (
+	[]domain.IpPoolUtilization,
+	error,
+) {
+	return s.ipam.GetIpUtilization(ctx, id)
+}
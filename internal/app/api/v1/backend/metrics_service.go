@@ -3,6 +3,7 @@ package backend
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/h44z/wg-portal/internal/config"
 	"github.com/h44z/wg-portal/internal/domain"
@@ -15,6 +16,17 @@ type MetricsServiceDatabaseRepo interface {
 		error,
 	)
 	GetUserPeers(ctx context.Context, id domain.UserIdentifier) ([]domain.Peer, error)
+	GetInterfacePeers(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.Peer, error)
+	GetPeerSessions(ctx context.Context, from, to time.Time, ids ...domain.PeerIdentifier) (
+		[]domain.PeerSession,
+		error,
+	)
+	GetTrafficSamples(
+		ctx context.Context,
+		objectType domain.TrafficObjectType,
+		objectId string,
+		from, to time.Time,
+	) ([]domain.TrafficSample, error)
 }
 
 type MetricsServiceUserManagerRepo interface {
@@ -55,8 +67,8 @@ func (m MetricsService) GetForInterface(ctx context.Context, id domain.Interface
 		return nil, fmt.Errorf("interface statistics collection is disabled")
 	}
 
-	// validate admin rights
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	// validate permissions
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesRead, id); err != nil {
 		return nil, err
 	}
 
@@ -77,7 +89,7 @@ func (m MetricsService) GetForUser(ctx context.Context, id domain.UserIdentifier
 		return nil, nil, fmt.Errorf("statistics collection is disabled")
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+	if err := domain.ValidateUserOrPermission(ctx, id, domain.PermissionPeersRead, ""); err != nil {
 		return nil, nil, err
 	}
 
@@ -114,7 +126,9 @@ func (m MetricsService) GetForPeer(ctx context.Context, id domain.PeerIdentifier
 		return nil, err
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	err = domain.ValidateUserOrPermission(ctx, peer.UserIdentifier, domain.PermissionPeersRead,
+		peer.InterfaceIdentifier)
+	if err != nil {
 		return nil, err
 	}
 
@@ -129,3 +143,160 @@ func (m MetricsService) GetForPeer(ctx context.Context, id domain.PeerIdentifier
 
 	return &peerStats[0], nil
 }
+
+// GetPeerTraffic returns the traffic history of the given peer, aggregated according to the query.
+func (m MetricsService) GetPeerTraffic(
+	ctx context.Context,
+	id domain.PeerIdentifier,
+	query domain.TrafficQuery,
+) ([]domain.TrafficDataPoint, error) {
+	if !m.cfg.Statistics.CollectPeerData || !m.cfg.Statistics.CollectTrafficHistory {
+		return nil, fmt.Errorf("peer traffic history collection is disabled")
+	}
+
+	peer, err := m.peers.GetPeer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = domain.ValidateUserOrPermission(ctx, peer.UserIdentifier, domain.PermissionPeersRead,
+		peer.InterfaceIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.getTraffic(ctx, domain.TrafficObjectPeer, string(peer.Identifier), query)
+}
+
+// GetInterfaceTraffic returns the traffic history of the given interface, aggregated according to the query.
+func (m MetricsService) GetInterfaceTraffic(
+	ctx context.Context,
+	id domain.InterfaceIdentifier,
+	query domain.TrafficQuery,
+) ([]domain.TrafficDataPoint, error) {
+	if !m.cfg.Statistics.CollectInterfaceData || !m.cfg.Statistics.CollectTrafficHistory {
+		return nil, fmt.Errorf("interface traffic history collection is disabled")
+	}
+
+	// validate permissions
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesRead, id); err != nil {
+		return nil, err
+	}
+
+	return m.getTraffic(ctx, domain.TrafficObjectInterface, string(id), query)
+}
+
+func (m MetricsService) getTraffic(
+	ctx context.Context,
+	objectType domain.TrafficObjectType,
+	objectId string,
+	query domain.TrafficQuery,
+) ([]domain.TrafficDataPoint, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	// load all samples that belong to the buckets of the query
+	samples, err := m.db.GetTrafficSamples(ctx, objectType, objectId, query.Step.Start(query.From), query.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch traffic history for %s %s: %w", objectType, objectId, err)
+	}
+
+	return domain.AggregateTraffic(samples, query), nil
+}
+
+// GetSessionsForInterface returns the sessions of all peers of the given interface that overlap the time range.
+func (m MetricsService) GetSessionsForInterface(
+	ctx context.Context,
+	id domain.InterfaceIdentifier,
+	from, to time.Time,
+) ([]domain.PeerSession, error) {
+	if !m.cfg.Statistics.CollectPeerData {
+		return nil, fmt.Errorf("peer statistics collection is disabled")
+	}
+
+	// validate permissions
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesRead, id); err != nil {
+		return nil, err
+	}
+
+	peers, err := m.db.GetInterfacePeers(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch peers for interface %s: %w", id, err)
+	}
+
+	return m.getSessions(ctx, peers, from, to)
+}
+
+// GetSessionsForUser returns the sessions of all peers of the given user that overlap the time range.
+func (m MetricsService) GetSessionsForUser(
+	ctx context.Context,
+	id domain.UserIdentifier,
+	from, to time.Time,
+) ([]domain.PeerSession, error) {
+	if !m.cfg.Statistics.CollectPeerData {
+		return nil, fmt.Errorf("peer statistics collection is disabled")
+	}
+
+	if err := domain.ValidateUserOrPermission(ctx, id, domain.PermissionPeersRead, ""); err != nil {
+		return nil, err
+	}
+
+	user, err := m.users.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	peers, err := m.db.GetUserPeers(ctx, user.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch peers for user %s: %w", user.Identifier, err)
+	}
+
+	return m.getSessions(ctx, peers, from, to)
+}
+
+// GetSessionsForPeer returns the sessions of the given peer that overlap the time range.
+func (m MetricsService) GetSessionsForPeer(
+	ctx context.Context,
+	id domain.PeerIdentifier,
+	from, to time.Time,
+) ([]domain.PeerSession, error) {
+	if !m.cfg.Statistics.CollectPeerData {
+		return nil, fmt.Errorf("peer statistics collection is disabled")
+	}
+
+	peer, err := m.peers.GetPeer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = domain.ValidateUserOrPermission(ctx, peer.UserIdentifier, domain.PermissionPeersRead,
+		peer.InterfaceIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.getSessions(ctx, []domain.Peer{*peer}, from, to)
+}
+
+func (m MetricsService) getSessions(
+	ctx context.Context,
+	peers []domain.Peer,
+	from, to time.Time,
+) ([]domain.PeerSession, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to: %w", domain.ErrInvalidData)
+	}
+
+	peerIds := make([]domain.PeerIdentifier, len(peers))
+	for i, peer := range peers {
+		peerIds[i] = peer.Identifier
+	}
+
+	sessions, err := m.db.GetPeerSessions(ctx, from, to, peerIds...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch peer sessions: %w", err)
+	}
+
+	return sessions, nil
+}
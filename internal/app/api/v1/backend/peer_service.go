@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/h44z/wg-portal/internal/config"
 	"github.com/h44z/wg-portal/internal/domain"
@@ -17,33 +18,51 @@ type PeerServicePeerManagerRepo interface {
 	CreatePeer(ctx context.Context, peer *domain.Peer) (*domain.Peer, error)
 	UpdatePeer(ctx context.Context, peer *domain.Peer) (*domain.Peer, error)
 	DeletePeer(ctx context.Context, id domain.PeerIdentifier) error
+	RejectPeerRenewal(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error)
+	BulkUpdatePeers(ctx context.Context, req *domain.PeerBulkRequest) (*domain.BulkResult, error)
+	MovePeer(ctx context.Context, id domain.PeerIdentifier, target domain.InterfaceIdentifier) (*domain.Peer, error)
 }
 
 type PeerServiceUserManagerRepo interface {
 	GetUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
 }
 
+type PeerServiceShareLinkManagerRepo interface {
+	CreateShareLink(
+		ctx context.Context,
+		peerId domain.PeerIdentifier,
+		validity time.Duration,
+		maxUses *int,
+	) (*domain.PeerShareLink, error)
+	GetPeerShareLinks(ctx context.Context, peerId domain.PeerIdentifier) ([]domain.PeerShareLink, error)
+	RevokeShareLink(ctx context.Context, id domain.PeerShareLinkIdentifier) error
+	GetShareLinkUrl(token string) string
+}
+
 type PeerService struct {
 	cfg *config.Config
 
-	peers PeerServicePeerManagerRepo
-	users PeerServiceUserManagerRepo
+	peers      PeerServicePeerManagerRepo
+	users      PeerServiceUserManagerRepo
+	shareLinks PeerServiceShareLinkManagerRepo
 }
 
 func NewPeerService(
 	cfg *config.Config,
 	peers PeerServicePeerManagerRepo,
 	users PeerServiceUserManagerRepo,
+	shareLinks PeerServiceShareLinkManagerRepo,
 ) *PeerService {
 	return &PeerService{
-		cfg:   cfg,
-		peers: peers,
-		users: users,
+		cfg:        cfg,
+		peers:      peers,
+		users:      users,
+		shareLinks: shareLinks,
 	}
 }
 
 func (s PeerService) GetForInterface(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.Peer, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionPeersRead, id); err != nil {
 		return nil, err
 	}
 
@@ -56,7 +75,7 @@ func (s PeerService) GetForInterface(ctx context.Context, id domain.InterfaceIde
 }
 
 func (s PeerService) GetForUser(ctx context.Context, id domain.UserIdentifier) ([]domain.Peer, error) {
-	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+	if err := domain.ValidateUserOrPermission(ctx, id, domain.PermissionPeersRead, ""); err != nil {
 		return nil, err
 	}
 
@@ -88,8 +107,10 @@ func (s PeerService) GetById(ctx context.Context, id domain.PeerIdentifier) (*do
 	}
 
 	// Check if the user has access rights to the requested peer.
-	// If the peer is not linked to any user, access is granted only for admins.
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	// If the peer is not linked to any user, access is granted only for admins and users with the read permission.
+	err = domain.ValidateUserOrPermission(ctx, peer.UserIdentifier, domain.PermissionPeersRead,
+		peer.InterfaceIdentifier)
+	if err != nil {
 		return nil, err
 	}
 
@@ -97,7 +118,7 @@ func (s PeerService) GetById(ctx context.Context, id domain.PeerIdentifier) (*do
 }
 
 func (s PeerService) Prepare(ctx context.Context, id domain.InterfaceIdentifier) (*domain.Peer, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionPeersWrite, id); err != nil {
 		return nil, err
 	}
 
@@ -110,7 +131,7 @@ func (s PeerService) Prepare(ctx context.Context, id domain.InterfaceIdentifier)
 }
 
 func (s PeerService) Create(ctx context.Context, peer *domain.Peer) (*domain.Peer, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionPeersWrite, peer.InterfaceIdentifier); err != nil {
 		return nil, err
 	}
 
@@ -131,7 +152,7 @@ func (s PeerService) Update(ctx context.Context, _ domain.PeerIdentifier, peer *
 	*domain.Peer,
 	error,
 ) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionPeersWrite, peer.InterfaceIdentifier); err != nil {
 		return nil, err
 	}
 
@@ -144,11 +165,84 @@ func (s PeerService) Update(ctx context.Context, _ domain.PeerIdentifier, peer *
 }
 
 func (s PeerService) Delete(ctx context.Context, id domain.PeerIdentifier) error {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	peer, err := s.peers.GetPeer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := domain.ValidatePermission(ctx, domain.PermissionPeersWrite, peer.InterfaceIdentifier); err != nil {
 		return err
 	}
 
-	err := s.peers.DeletePeer(ctx, id)
+	err = s.peers.DeletePeer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s PeerService) Move(
+	ctx context.Context,
+	id domain.PeerIdentifier,
+	target domain.InterfaceIdentifier,
+) (*domain.Peer, error) {
+	if err := domain.ValidatePermission(ctx, domain.PermissionPeersWrite, target); err != nil {
+		return nil, err
+	}
+
+	return s.peers.MovePeer(ctx, id, target)
+}
+
+func (s PeerService) Bulk(ctx context.Context, req *domain.PeerBulkRequest) (*domain.BulkResult, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.peers.BulkUpdatePeers(ctx, req)
+}
+
+func (s PeerService) RejectRenewal(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error) {
+	// the peer manager validates the permission for the interface of the peer
+	return s.peers.RejectPeerRenewal(ctx, id)
+}
+
+// CreateShareLink creates a new configuration download link for the given peer.
+// The returned url is the only way to access the plain token.
+func (s PeerService) CreateShareLink(
+	ctx context.Context,
+	id domain.PeerIdentifier,
+	validity time.Duration,
+	maxUses *int,
+) (*domain.PeerShareLink, string, error) {
+	if s.cfg.Advanced.ApiAdminOnly && !domain.GetUserInfo(ctx).IsAdmin {
+		return nil, "", errors.Join(errors.New("only admins can access this endpoint"), domain.ErrNoPermission)
+	}
+
+	link, err := s.shareLinks.CreateShareLink(ctx, id, validity, maxUses)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return link, s.shareLinks.GetShareLinkUrl(link.Token), nil
+}
+
+func (s PeerService) GetShareLinks(ctx context.Context, id domain.PeerIdentifier) ([]domain.PeerShareLink, error) {
+	if s.cfg.Advanced.ApiAdminOnly && !domain.GetUserInfo(ctx).IsAdmin {
+		return nil, errors.Join(errors.New("only admins can access this endpoint"), domain.ErrNoPermission)
+	}
+
+	links, err := s.shareLinks.GetPeerShareLinks(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+func (s PeerService) RevokeShareLink(ctx context.Context, id domain.PeerShareLinkIdentifier) error {
+	// the share link manager validates the permission for the interface of the peer
+	err := s.shareLinks.RevokeShareLink(ctx, id)
 	if err != nil {
 		return err
 	}
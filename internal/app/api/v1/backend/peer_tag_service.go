@@ -0,0 +1,107 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type PeerTagServiceManagerRepo interface {
+	GetPeerTags(ctx context.Context) ([]domain.PeerTag, error)
+	GetPeerTag(ctx context.Context, name string) (*domain.PeerTag, error)
+	CreatePeerTag(ctx context.Context, tag *domain.PeerTag) (*domain.PeerTag, error)
+	UpdatePeerTag(ctx context.Context, tag *domain.PeerTag) (*domain.PeerTag, error)
+	DeletePeerTag(ctx context.Context, name string) error
+	GetTaggedPeers(ctx context.Context, tag string) ([]domain.Peer, error)
+	EnableTaggedPeers(ctx context.Context, tag string) ([]domain.Peer, error)
+	DisableTaggedPeers(ctx context.Context, tag string) ([]domain.Peer, error)
+	ApplyTagDefaults(ctx context.Context, tag string) ([]domain.Peer, error)
+	DeleteTaggedPeers(ctx context.Context, tag string) ([]domain.Peer, error)
+}
+
+type PeerTagServiceMailManagerRepo interface {
+	SendPeerEmail(ctx context.Context, linkOnly bool, style string, peers ...domain.PeerIdentifier) error
+}
+
+type PeerTagService struct {
+	cfg *config.Config
+
+	tags   PeerTagServiceManagerRepo
+	mailer PeerTagServiceMailManagerRepo
+}
+
+func NewPeerTagService(
+	cfg *config.Config,
+	tags PeerTagServiceManagerRepo,
+	mailer PeerTagServiceMailManagerRepo,
+) *PeerTagService {
+	return &PeerTagService{
+		cfg:    cfg,
+		tags:   tags,
+		mailer: mailer,
+	}
+}
+
+func (s PeerTagService) GetAll(ctx context.Context) ([]domain.PeerTag, error) {
+	return s.tags.GetPeerTags(ctx)
+}
+
+func (s PeerTagService) GetByName(ctx context.Context, name string) (*domain.PeerTag, error) {
+	return s.tags.GetPeerTag(ctx, name)
+}
+
+func (s PeerTagService) Create(ctx context.Context, tag *domain.PeerTag) (*domain.PeerTag, error) {
+	return s.tags.CreatePeerTag(ctx, tag)
+}
+
+func (s PeerTagService) Update(ctx context.Context, name string, tag *domain.PeerTag) (*domain.PeerTag, error) {
+	tag.Name = name
+	return s.tags.UpdatePeerTag(ctx, tag)
+}
+
+func (s PeerTagService) Delete(ctx context.Context, name string) error {
+	return s.tags.DeletePeerTag(ctx, name)
+}
+
+func (s PeerTagService) GetPeers(ctx context.Context, name string) ([]domain.Peer, error) {
+	return s.tags.GetTaggedPeers(ctx, name)
+}
+
+func (s PeerTagService) EnablePeers(ctx context.Context, name string) ([]domain.Peer, error) {
+	return s.tags.EnableTaggedPeers(ctx, name)
+}
+
+func (s PeerTagService) DisablePeers(ctx context.Context, name string) ([]domain.Peer, error) {
+	return s.tags.DisableTaggedPeers(ctx, name)
+}
+
+func (s PeerTagService) ApplyDefaults(ctx context.Context, name string) ([]domain.Peer, error) {
+	return s.tags.ApplyTagDefaults(ctx, name)
+}
+
+func (s PeerTagService) DeletePeers(ctx context.Context, name string) ([]domain.Peer, error) {
+	return s.tags.DeleteTaggedPeers(ctx, name)
+}
+
+// SendPeerEmails sends the configuration of all tagged peers to the linked users.
+func (s PeerTagService) SendPeerEmails(ctx context.Context, name string, linkOnly bool, style string) error {
+	peers, err := s.tags.GetTaggedPeers(ctx, name)
+	if err != nil {
+		return err
+	}
+	if len(peers) == 0 {
+		return nil
+	}
+
+	if style == "" {
+		style = domain.ConfigStyleWgQuick
+	}
+
+	peerIds := make([]domain.PeerIdentifier, len(peers))
+	for i := range peers {
+		peerIds[i] = peers[i].Identifier
+	}
+
+	return s.mailer.SendPeerEmail(ctx, linkOnly, style, peerIds...)
+}
@@ -20,6 +20,7 @@ type ProvisioningServicePeerManagerRepo interface {
 	GetUserPeers(context.Context, domain.UserIdentifier) ([]domain.Peer, error)
 	PreparePeer(ctx context.Context, id domain.InterfaceIdentifier) (*domain.Peer, error)
 	CreatePeer(ctx context.Context, p *domain.Peer) (*domain.Peer, error)
+	RenewPeer(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error)
 }
 
 type ProvisioningServiceConfigFileManagerRepo interface {
@@ -74,7 +75,7 @@ func (p ProvisioningService) GetUserAndPeers(
 		return nil, nil, fmt.Errorf("either UserId or Email must be set: %w", domain.ErrInvalidData)
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, user.Identifier); err != nil {
+	if err := domain.ValidateUserOrPermission(ctx, user.Identifier, domain.PermissionPeersRead, ""); err != nil {
 		return nil, nil, err
 	}
 
@@ -92,7 +93,9 @@ func (p ProvisioningService) GetPeerConfig(ctx context.Context, peerId domain.Pe
 		return nil, err
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	err = domain.ValidateUserOrPermission(ctx, peer.UserIdentifier, domain.PermissionPeersManage,
+		peer.InterfaceIdentifier)
+	if err != nil {
 		return nil, err
 	}
 
@@ -115,7 +118,9 @@ func (p ProvisioningService) GetPeerQrPng(ctx context.Context, peerId domain.Pee
 		return nil, err
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	err = domain.ValidateUserOrPermission(ctx, peer.UserIdentifier, domain.PermissionPeersManage,
+		peer.InterfaceIdentifier)
+	if err != nil {
 		return nil, err
 	}
 
@@ -138,12 +143,15 @@ func (p ProvisioningService) NewPeer(ctx context.Context, req models.Provisionin
 	}
 
 	// check permissions
-	if err := domain.ValidateUserAccessRights(ctx, domain.UserIdentifier(req.UserIdentifier)); err != nil {
+	ifaceId := domain.InterfaceIdentifier(req.InterfaceIdentifier)
+	err := domain.ValidateUserOrPermission(ctx, domain.UserIdentifier(req.UserIdentifier),
+		domain.PermissionPeersWrite, ifaceId)
+	if err != nil {
 		return nil, err
 	}
 	if !p.cfg.Core.SelfProvisioningAllowed {
-		// only admins can create new peers if self-provisioning is disabled
-		if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		// only privileged users can create new peers if self-provisioning is disabled
+		if err := domain.ValidatePermission(ctx, domain.PermissionPeersWrite, ifaceId); err != nil {
 			return nil, err
 		}
 	}
@@ -172,3 +180,12 @@ func (p ProvisioningService) NewPeer(ctx context.Context, req models.Provisionin
 
 	return peer, nil
 }
+
+func (p ProvisioningService) RenewPeer(ctx context.Context, req models.RenewalRequest) (*domain.Peer, error) {
+	peer, err := p.peers.RenewPeer(ctx, domain.PeerIdentifier(req.PeerIdentifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to renew peer: %w", err)
+	}
+
+	return peer, nil
+}
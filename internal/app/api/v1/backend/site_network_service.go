@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"context"
+	"io"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type SiteNetworkServiceManagerRepo interface {
+	GetSiteNetworks(ctx context.Context) ([]domain.SiteNetwork, error)
+	GetSiteNetwork(ctx context.Context, id domain.SiteNetworkIdentifier) (*domain.SiteNetwork, error)
+	CreateSiteNetwork(ctx context.Context, network *domain.SiteNetwork) (*domain.SiteNetwork, error)
+	UpdateSiteNetwork(ctx context.Context, network *domain.SiteNetwork) (*domain.SiteNetwork, error)
+	DeleteSiteNetwork(ctx context.Context, id domain.SiteNetworkIdentifier) error
+}
+
+type SiteNetworkServiceConfigFileManagerRepo interface {
+	GetSiteConfig(ctx context.Context, id domain.SiteNetworkIdentifier, siteId domain.SiteIdentifier) (io.Reader, error)
+}
+
+type SiteNetworkService struct {
+	cfg *config.Config
+
+	sites       SiteNetworkServiceManagerRepo
+	configFiles SiteNetworkServiceConfigFileManagerRepo
+}
+
+func NewSiteNetworkService(
+	cfg *config.Config,
+	sites SiteNetworkServiceManagerRepo,
+	configFiles SiteNetworkServiceConfigFileManagerRepo,
+) *SiteNetworkService {
+	return &SiteNetworkService{
+		cfg:         cfg,
+		sites:       sites,
+		configFiles: configFiles,
+	}
+}
+
+func (s SiteNetworkService) GetAll(ctx context.Context) ([]domain.SiteNetwork, error) {
+	return s.sites.GetSiteNetworks(ctx)
+}
+
+func (s SiteNetworkService) GetById(ctx context.Context, id domain.SiteNetworkIdentifier) (
+	*domain.SiteNetwork,
+	error,
+) {
+	return s.sites.GetSiteNetwork(ctx, id)
+}
+
+func (s SiteNetworkService) Create(ctx context.Context, network *domain.SiteNetwork) (*domain.SiteNetwork, error) {
+	return s.sites.CreateSiteNetwork(ctx, network)
+}
+
+func (s SiteNetworkService) Update(
+	ctx context.Context,
+	id domain.SiteNetworkIdentifier,
+	network *domain.SiteNetwork,
+) (*domain.SiteNetwork, error) {
+	network.Identifier = id
+	for i := range network.Sites {
+		network.Sites[i].NetworkIdentifier = id
+	}
+	for i := range network.Links {
+		network.Links[i].NetworkIdentifier = id
+	}
+	return s.sites.UpdateSiteNetwork(ctx, network)
+}
+
+func (s SiteNetworkService) Delete(ctx context.Context, id domain.SiteNetworkIdentifier) error {
+	return s.sites.DeleteSiteNetwork(ctx, id)
+}
+
+func (s SiteNetworkService) GetSiteConfig(
+	ctx context.Context,
+	id domain.SiteNetworkIdentifier,
+	siteId domain.SiteIdentifier,
+) ([]byte, error) {
+	cfgReader, err := s.configFiles.GetSiteConfig(ctx, id, siteId)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(cfgReader)
+}
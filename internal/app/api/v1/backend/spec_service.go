@@ -0,0 +1,36 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type SpecServiceManagerRepo interface {
+	PlanSpec(ctx context.Context) (*domain.SpecPlan, error)
+	ApplySpec(ctx context.Context) (*domain.SpecPlan, error)
+}
+
+type SpecService struct {
+	cfg *config.Config
+
+	specs SpecServiceManagerRepo
+}
+
+func NewSpecService(cfg *config.Config, specs SpecServiceManagerRepo) *SpecService {
+	return &SpecService{
+		cfg:   cfg,
+		specs: specs,
+	}
+}
+
+// Plan returns the changes that are needed to apply the configured spec.
+func (s SpecService) Plan(ctx context.Context) (*domain.SpecPlan, error) {
+	return s.specs.PlanSpec(ctx)
+}
+
+// Apply applies the configured spec.
+func (s SpecService) Apply(ctx context.Context) (*domain.SpecPlan, error) {
+	return s.specs.ApplySpec(ctx)
+}
@@ -15,6 +15,10 @@ type UserManagerRepo interface {
 	CreateUser(ctx context.Context, user *domain.User) (*domain.User, error)
 	UpdateUser(ctx context.Context, user *domain.User) (*domain.User, error)
 	DeleteUser(ctx context.Context, id domain.UserIdentifier) error
+	BulkUpdateUsers(ctx context.Context, req *domain.UserBulkRequest) (*domain.BulkResult, error)
+	UnlockUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
+	GetBlockedLogins(ctx context.Context) ([]domain.LoginAttempt, error)
+	UnlockSource(ctx context.Context, sourceAddr string) error
 }
 
 type UserService struct {
@@ -31,7 +35,7 @@ func NewUserService(cfg *config.Config, users UserManagerRepo) *UserService {
 }
 
 func (s UserService) GetAll(ctx context.Context) ([]domain.User, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionUsersRead, ""); err != nil {
 		return nil, err
 	}
 
@@ -44,7 +48,7 @@ func (s UserService) GetAll(ctx context.Context) ([]domain.User, error) {
 }
 
 func (s UserService) GetById(ctx context.Context, id domain.UserIdentifier) (*domain.User, error) {
-	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+	if err := domain.ValidateUserOrPermission(ctx, id, domain.PermissionUsersRead, ""); err != nil {
 		return nil, err
 	}
 
@@ -105,3 +109,35 @@ func (s UserService) Delete(ctx context.Context, id domain.UserIdentifier) error
 
 	return nil
 }
+
+func (s UserService) Bulk(ctx context.Context, req *domain.UserBulkRequest) (*domain.BulkResult, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.users.BulkUpdateUsers(ctx, req)
+}
+
+func (s UserService) Unlock(ctx context.Context, id domain.UserIdentifier) (*domain.User, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.users.UnlockUser(ctx, id)
+}
+
+func (s UserService) GetLoginBlocks(ctx context.Context) ([]domain.LoginAttempt, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.users.GetBlockedLogins(ctx)
+}
+
+func (s UserService) DeleteLoginBlock(ctx context.Context, sourceAddr string) error {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return err
+	}
+
+	return s.users.UnlockSource(ctx, sourceAddr)
+}
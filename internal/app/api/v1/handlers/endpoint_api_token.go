@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-pkgz/routegroup"
+
+	"github.com/h44z/wg-portal/internal/app/api/core/request"
+	"github.com/h44z/wg-portal/internal/app/api/core/respond"
+	"github.com/h44z/wg-portal/internal/app/api/v1/models"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type ApiTokenEndpointApiTokenService interface {
+	GetAllForUser(ctx context.Context, userId domain.UserIdentifier) ([]domain.ApiToken, error)
+	Create(ctx context.Context, userId domain.UserIdentifier, template *domain.ApiToken) (*domain.ApiToken, error)
+	Delete(ctx context.Context, id domain.ApiTokenIdentifier) error
+}
+
+type ApiTokenEndpoint struct {
+	tokens        ApiTokenEndpointApiTokenService
+	authenticator Authenticator
+	validator     Validator
+}
+
+func NewApiTokenEndpoint(
+	authenticator Authenticator,
+	validator Validator,
+	tokenService ApiTokenEndpointApiTokenService,
+) *ApiTokenEndpoint {
+	return &ApiTokenEndpoint{
+		authenticator: authenticator,
+		validator:     validator,
+		tokens:        tokenService,
+	}
+}
+
+func (e ApiTokenEndpoint) GetName() string {
+	return "ApiTokenEndpoint"
+}
+
+func (e ApiTokenEndpoint) RegisterRoutes(g *routegroup.Bundle) {
+	apiGroup := g.Mount("/api-token")
+	apiGroup.Use(e.authenticator.LoggedIn(ScopeTokens))
+
+	apiGroup.HandleFunc("GET /by-user/{id}", e.handleAllForUserGet())
+	apiGroup.HandleFunc("POST /by-user/{id}", e.handleCreatePost())
+	apiGroup.HandleFunc("DELETE /by-id/{id}", e.handleDelete())
+}
+
+// handleAllForUserGet returns a gorm Handler function.
+//
+// @ID api_tokens_handleAllForUserGet
+// @Tags API Tokens
+// @Summary Get all named API tokens of the given user.
+// @Description Normal users can only access their own tokens. The secret tokens are never returned.
+// @Param id path string true "The user identifier."
+// @Produce json
+// @Success 200 {object} []models.ApiToken
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /api-token/by-user/{id} [get]
+// @Security BasicAuth
+func (e ApiTokenEndpoint) handleAllForUserGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing user id"})
+			return
+		}
+
+		tokens, err := e.tokens.GetAllForUser(r.Context(), domain.UserIdentifier(id))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewApiTokens(tokens))
+	}
+}
+
+// handleCreatePost returns a gorm Handler function.
+//
+// @ID api_tokens_handleCreatePost
+// @Tags API Tokens
+// @Summary Create a new named API token for the given user.
+// @Description Users can only create tokens for themselves. The secret token is only returned once in the response.
+// @Param id path string true "The user identifier."
+// @Param request body models.ApiTokenRequest true "The token parameters."
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.ApiToken
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /api-token/by-user/{id} [post]
+// @Security BasicAuth
+func (e ApiTokenEndpoint) handleCreatePost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing user id"})
+			return
+		}
+
+		var req models.ApiTokenRequest
+		if err := request.BodyJson(r, &req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validator.Struct(req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		token, err := e.tokens.Create(r.Context(), domain.UserIdentifier(id), models.NewDomainApiToken(&req))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewApiToken(token))
+	}
+}
+
+// handleDelete returns a gorm Handler function.
+//
+// @ID api_tokens_handleDelete
+// @Tags API Tokens
+// @Summary Revoke the named API token with the given identifier.
+// @Description Normal users can only revoke their own tokens, admins can revoke the tokens of all users.
+// @Param id path string true "The token identifier."
+// @Produce json
+// @Success 204 "No content if deletion was successful."
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /api-token/by-id/{id} [delete]
+// @Security BasicAuth
+func (e ApiTokenEndpoint) handleDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing token id"})
+			return
+		}
+
+		err := e.tokens.Delete(r.Context(), domain.ApiTokenIdentifier(id))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.Status(w, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-pkgz/routegroup"
+
+	"github.com/h44z/wg-portal/internal/app/api/core/request"
+	"github.com/h44z/wg-portal/internal/app/api/core/respond"
+	"github.com/h44z/wg-portal/internal/app/api/v1/models"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type BundleEndpointBundleService interface {
+	Export(
+		ctx context.Context,
+		id domain.InterfaceIdentifier,
+		passphrase string,
+		format domain.BundleFormat,
+	) ([]byte, error)
+	Import(
+		ctx context.Context,
+		data []byte,
+		passphrase string,
+		opts domain.BundleImportOptions,
+	) (*domain.BundleImportResult, error)
+}
+
+type BundleEndpoint struct {
+	bundles       BundleEndpointBundleService
+	authenticator Authenticator
+	validator     Validator
+}
+
+func NewBundleEndpoint(
+	authenticator Authenticator,
+	validator Validator,
+	bundleService BundleEndpointBundleService,
+) *BundleEndpoint {
+	return &BundleEndpoint{
+		authenticator: authenticator,
+		validator:     validator,
+		bundles:       bundleService,
+	}
+}
+
+func (e BundleEndpoint) GetName() string {
+	return "BundleEndpoint"
+}
+
+func (e BundleEndpoint) RegisterRoutes(g *routegroup.Bundle) {
+	apiGroup := g.Mount("/bundle")
+	apiGroup.Use(e.authenticator.LoggedIn())
+
+	write := e.authenticator.LoggedIn(ScopeAdmin, ScopeInterfacesWrite)
+
+	apiGroup.With(write).HandleFunc("POST /export/{id}", e.handleExportPost())
+	apiGroup.With(write).HandleFunc("POST /import", e.handleImportPost())
+}
+
+// handleExportPost returns a gorm Handler function.
+//
+// @ID bundle_handleExportPost
+// @Tags Bundles
+// @Summary Export an interface, its peers and the linked users as bundle file.
+// @Description The bundle is signed with the bundle signing key of this instance. If a passphrase is given, the bundle content is encrypted. Passwords and API tokens of users are not exported.
+// @Param id path string true "The interface identifier."
+// @Param request body models.BundleExportRequest true "The export options."
+// @Produce json
+// @Produce plain
+// @Success 200 {file} binary
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /bundle/export/{id} [post]
+// @Security BasicAuth
+func (e BundleEndpoint) handleExportPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing interface id"})
+			return
+		}
+
+		var options models.BundleExportRequest
+		if err := request.BodyJson(r, &options); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validator.Struct(options); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		format := domain.BundleFormat(options.Format)
+		if format == "" {
+			format = domain.BundleFormatJson
+		}
+
+		data, err := e.bundles.Export(r.Context(), domain.InterfaceIdentifier(id), options.Passphrase, format)
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		contentType := "application/json"
+		if format == domain.BundleFormatYaml {
+			contentType = "application/yaml"
+		}
+		respond.Attachment(w, http.StatusOK, id+"."+string(format), contentType, data)
+	}
+}
+
+// handleImportPost returns a gorm Handler function.
+//
+// @ID bundle_handleImportPost
+// @Tags Bundles
+// @Summary Import an interface bundle.
+// @Description The bundle signature is verified with the bundle signing key of this instance. Conflicting identifiers, listen ports and overlapping networks are rejected unless the import options allow them. Imported users that do not exist yet have no password.
+// @Param request body models.BundleImportRequest true "The bundle and the import options."
+// @Produce json
+// @Success 200 {object} models.BundleImportResult
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /bundle/import [post]
+// @Security BasicAuth
+func (e BundleEndpoint) handleImportPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.BundleImportRequest
+		if err := request.BodyJson(r, &req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validator.Struct(req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		result, err := e.bundles.Import(r.Context(), []byte(req.Bundle), req.Passphrase,
+			models.NewDomainBundleImportOptions(&req))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewBundleImportResult(result))
+	}
+}
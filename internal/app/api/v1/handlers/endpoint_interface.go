@@ -19,6 +19,7 @@ type InterfaceEndpointInterfaceService interface {
 	Create(context.Context, *domain.Interface) (*domain.Interface, error)
 	Update(context.Context, domain.InterfaceIdentifier, *domain.Interface) (*domain.Interface, []domain.Peer, error)
 	Delete(context.Context, domain.InterfaceIdentifier) error
+	GetDrift(context.Context) ([]domain.InterfaceDrift, error)
 }
 
 type InterfaceEndpoint struct {
@@ -45,15 +46,19 @@ func (e InterfaceEndpoint) GetName() string {
 
 func (e InterfaceEndpoint) RegisterRoutes(g *routegroup.Bundle) {
 	apiGroup := g.Mount("/interface")
-	apiGroup.Use(e.authenticator.LoggedIn(ScopeAdmin))
+	apiGroup.Use(e.authenticator.LoggedIn())
 
-	apiGroup.HandleFunc("GET /all", e.handleAllGet())
-	apiGroup.HandleFunc("GET /by-id/{id}", e.handleByIdGet())
+	read := e.authenticator.LoggedIn(ScopeAdmin, ScopeInterfacesRead)
+	write := e.authenticator.LoggedIn(ScopeAdmin, ScopeInterfacesWrite)
 
-	apiGroup.HandleFunc("GET /prepare", e.handlePrepareGet())
-	apiGroup.HandleFunc("POST /new", e.handleCreatePost())
-	apiGroup.HandleFunc("PUT /by-id/{id}", e.handleUpdatePut())
-	apiGroup.HandleFunc("DELETE /by-id/{id}", e.handleDelete())
+	apiGroup.With(read).HandleFunc("GET /all", e.handleAllGet())
+	apiGroup.With(read).HandleFunc("GET /by-id/{id}", e.handleByIdGet())
+	apiGroup.With(read).HandleFunc("GET /drift", e.handleDriftGet())
+
+	apiGroup.With(read).HandleFunc("GET /prepare", e.handlePrepareGet())
+	apiGroup.With(write).HandleFunc("POST /new", e.handleCreatePost())
+	apiGroup.With(write).HandleFunc("PUT /by-id/{id}", e.handleUpdatePut())
+	apiGroup.With(write).HandleFunc("DELETE /by-id/{id}", e.handleDelete())
 }
 
 // handleAllGet returns a gorm Handler function.
@@ -114,6 +119,32 @@ func (e InterfaceEndpoint) handleByIdGet() http.HandlerFunc {
 	}
 }
 
+// handleDriftGet returns a gorm handler function.
+//
+// @ID interfaces_handleDriftGet
+// @Tags Interfaces
+// @Summary Get the drift report of all enabled interfaces.
+// @Description This endpoint compares the database state of all enabled interfaces and peers with the WireGuard interfaces on the system. No changes are applied.
+// @Produce json
+// @Success 200 {object} []models.InterfaceDrift
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /interface/drift [get]
+// @Security BasicAuth
+func (e InterfaceEndpoint) handleDriftGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		drifts, err := e.interfaces.GetDrift(r.Context())
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewInterfaceDrifts(drifts))
+	}
+}
+
 // handlePrepareGet returns a gorm handler function.
 //
 // @ID interfaces_handlePrepareGet
@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-pkgz/routegroup"
+
+	"github.com/h44z/wg-portal/internal/app/api/core/request"
+	"github.com/h44z/wg-portal/internal/app/api/core/respond"
+	"github.com/h44z/wg-portal/internal/app/api/v1/models"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type IpamEndpointIpamService interface {
+	GetRanges(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.IpRange, error)
+	CreateRange(ctx context.Context, id domain.InterfaceIdentifier, ipRange *domain.IpRange) (*domain.IpRange, error)
+	UpdateRange(
+		ctx context.Context,
+		id domain.InterfaceIdentifier,
+		rangeId domain.IpRangeIdentifier,
+		ipRange *domain.IpRange,
+	) (*domain.IpRange, error)
+	DeleteRange(ctx context.Context, id domain.InterfaceIdentifier, rangeId domain.IpRangeIdentifier) error
+	GetReservations(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.IpReservation, error)
+	SaveReservation(
+		ctx context.Context,
+		id domain.InterfaceIdentifier,
+		address string,
+		reservation *domain.IpReservation,
+	) (*domain.IpReservation, error)
+	DeleteReservation(ctx context.Context, id domain.InterfaceIdentifier, address string) error
+	GetUtilization(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.IpPoolUtilization, error)
+}
+
+type IpamEndpoint struct {
+	ipam          IpamEndpointIpamService
+	authenticator Authenticator
+	validator     Validator
+}
+
+func NewIpamEndpoint(
+	authenticator Authenticator,
+	validator Validator,
+	ipamService IpamEndpointIpamService,
+) *IpamEndpoint {
+	return &IpamEndpoint{
+		authenticator: authenticator,
+		validator:     validator,
+		ipam:          ipamService,
+	}
+}
+
+func (e IpamEndpoint) GetName() string {
+	return "IpamEndpoint"
+}
+
+func (e IpamEndpoint) RegisterRoutes(g *routegroup.Bundle) {
+	apiGroup := g.Mount("/ipam")
+	apiGroup.Use(e.authenticator.LoggedIn())
+
+	read := e.authenticator.LoggedIn(ScopeAdmin, ScopeInterfacesRead)
+	write := e.authenticator.LoggedIn(ScopeAdmin, ScopeInterfacesWrite)
+
+	apiGroup.With(read).HandleFunc("GET /by-interface/{id}/utilization", e.handleUtilizationGet())
+
+	apiGroup.With(read).HandleFunc("GET /by-interface/{id}/ranges", e.handleRangesGet())
+	apiGroup.With(write).HandleFunc("POST /by-interface/{id}/ranges", e.handleRangeCreatePost())
+	apiGroup.With(write).HandleFunc("PUT /by-interface/{id}/ranges/{rangeId}", e.handleRangeUpdatePut())
+	apiGroup.With(write).HandleFunc("DELETE /by-interface/{id}/ranges/{rangeId}", e.handleRangeDelete())
+
+	apiGroup.With(read).HandleFunc("GET /by-interface/{id}/reservations", e.handleReservationsGet())
+	apiGroup.With(write).HandleFunc("PUT /by-interface/{id}/reservations/{address}", e.handleReservationPut())
+	apiGroup.With(write).HandleFunc("DELETE /by-interface/{id}/reservations/{address}", e.handleReservationDelete())
+}
+
+// handleUtilizationGet returns a gorm Handler function.
+//
+// @ID ipam_handleUtilizationGet
+// @Tags IPAM
+// @Summary Get the usage of all address pools of an interface.
+// @Description If no pool is defined for a peer network of the interface, the usage of the default pool that spans the whole network is returned.
+// @Param id path string true "The interface identifier."
+// @Produce json
+// @Success 200 {object} []models.IpPoolUtilization
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /ipam/by-interface/{id}/utilization [get]
+// @Security BasicAuth
+func (e IpamEndpoint) handleUtilizationGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing interface id"})
+			return
+		}
+
+		utilization, err := e.ipam.GetUtilization(r.Context(), domain.InterfaceIdentifier(id))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewIpPoolUtilizations(utilization))
+	}
+}
+
+// handleRangesGet returns a gorm Handler function.
+//
+// @ID ipam_handleRangesGet
+// @Tags IPAM
+// @Summary Get all address pools and exclusion ranges of an interface.
+// @Param id path string true "The interface identifier."
+// @Produce json
+// @Success 200 {object} []models.IpRange
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /ipam/by-interface/{id}/ranges [get]
+// @Security BasicAuth
+func (e IpamEndpoint) handleRangesGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing interface id"})
+			return
+		}
+
+		ranges, err := e.ipam.GetRanges(r.Context(), domain.InterfaceIdentifier(id))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewIpRanges(ranges))
+	}
+}
+
+// handleRangeCreatePost returns a gorm Handler function.
+//
+// @ID ipam_handleRangeCreatePost
+// @Tags IPAM
+// @Summary Create a new address pool or exclusion range.
+// @Description The range must be part of a peer network of the interface.
+// @Param id path string true "The interface identifier."
+// @Param request body models.IpRange true "The range data."
+// @Produce json
+// @Success 200 {object} models.IpRange
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /ipam/by-interface/{id}/ranges [post]
+// @Security BasicAuth
+func (e IpamEndpoint) handleRangeCreatePost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing interface id"})
+			return
+		}
+
+		var ipRange models.IpRange
+		if err := request.BodyJson(r, &ipRange); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validator.Struct(ipRange); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		newRange, err := e.ipam.CreateRange(r.Context(), domain.InterfaceIdentifier(id),
+			models.NewDomainIpRange(&ipRange))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewIpRange(newRange))
+	}
+}
+
+// handleRangeUpdatePut returns a gorm Handler function.
+//
+// @ID ipam_handleRangeUpdatePut
+// @Tags IPAM
+// @Summary Update an address pool or exclusion range.
+// @Param id path string true "The interface identifier."
+// @Param rangeId path string true "The range identifier."
+// @Param request body models.IpRange true "The range data."
+// @Produce json
+// @Success 200 {object} models.IpRange
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /ipam/by-interface/{id}/ranges/{rangeId} [put]
+// @Security BasicAuth
+func (e IpamEndpoint) handleRangeUpdatePut() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		rangeId := request.Path(r, "rangeId")
+		if id == "" || rangeId == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing interface or range id"})
+			return
+		}
+
+		var ipRange models.IpRange
+		if err := request.BodyJson(r, &ipRange); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validator.Struct(ipRange); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		updatedRange, err := e.ipam.UpdateRange(r.Context(), domain.InterfaceIdentifier(id),
+			domain.IpRangeIdentifier(rangeId), models.NewDomainIpRange(&ipRange))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewIpRange(updatedRange))
+	}
+}
+
+// handleRangeDelete returns a gorm Handler function.
+//
+// @ID ipam_handleRangeDelete
+// @Tags IPAM
+// @Summary Delete an address pool or exclusion range.
+// @Param id path string true "The interface identifier."
+// @Param rangeId path string true "The range identifier."
+// @Produce json
+// @Success 204 "No content if deletion was successful."
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /ipam/by-interface/{id}/ranges/{rangeId} [delete]
+// @Security BasicAuth
+func (e IpamEndpoint) handleRangeDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		rangeId := request.Path(r, "rangeId")
+		if id == "" || rangeId == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing interface or range id"})
+			return
+		}
+
+		err := e.ipam.DeleteRange(r.Context(), domain.InterfaceIdentifier(id), domain.IpRangeIdentifier(rangeId))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.Status(w, http.StatusNoContent)
+	}
+}
+
+// handleReservationsGet returns a gorm Handler function.
+//
+// @ID ipam_handleReservationsGet
+// @Tags IPAM
+// @Summary Get all address reservations of an interface.
+// @Param id path string true "The interface identifier."
+// @Produce json
+// @Success 200 {object} []models.IpReservation
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /ipam/by-interface/{id}/reservations [get]
+// @Security BasicAuth
+func (e IpamEndpoint) handleReservationsGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing interface id"})
+			return
+		}
+
+		reservations, err := e.ipam.GetReservations(r.Context(), domain.InterfaceIdentifier(id))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewIpReservations(reservations))
+	}
+}
+
+// handleReservationPut returns a gorm Handler function.
+//
+// @ID ipam_handleReservationPut
+// @Tags IPAM
+// @Summary Create or update the reservation of an address.
+// @Description The address is reserved for the given user or peer. New peers of the user get the reserved address, other peers can not use it.
+// @Param id path string true "The interface identifier."
+// @Param address path string true "The reserved host address."
+// @Param request body models.IpReservation true "The reservation data."
+// @Produce json
+// @Success 200 {object} models.IpReservation
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /ipam/by-interface/{id}/reservations/{address} [put]
+// @Security BasicAuth
+func (e IpamEndpoint) handleReservationPut() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		address := request.Path(r, "address")
+		if id == "" || address == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing interface id or address"})
+			return
+		}
+
+		var reservation models.IpReservation
+		if err := request.BodyJson(r, &reservation); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validator.Struct(reservation); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		savedReservation, err := e.ipam.SaveReservation(r.Context(), domain.InterfaceIdentifier(id), address,
+			models.NewDomainIpReservation(&reservation))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewIpReservation(savedReservation))
+	}
+}
+
+// handleReservationDelete returns a gorm Handler function.
+//
+// @ID ipam_handleReservationDelete
+// @Tags IPAM
+// @Summary Delete the reservation of an address.
+// @Param id path string true "The interface identifier."
+// @Param address path string true "The reserved host address."
+// @Produce json
+// @Success 204 "No content if deletion was successful."
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /ipam/by-interface/{id}/reservations/{address} [delete]
+// @Security BasicAuth
+func (e IpamEndpoint) handleReservationDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		address := request.Path(r, "address")
+		if id == "" || address == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing interface id or address"})
+			return
+		}
+
+		err := e.ipam.DeleteReservation(r.Context(), domain.InterfaceIdentifier(id), address)
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.Status(w, http.StatusNoContent)
+	}
+}
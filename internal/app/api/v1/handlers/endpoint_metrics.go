@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-pkgz/routegroup"
 
@@ -16,6 +18,28 @@ type MetricsEndpointStatisticsService interface {
 	GetForInterface(ctx context.Context, id domain.InterfaceIdentifier) (*domain.InterfaceStatus, error)
 	GetForUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, []domain.PeerStatus, error)
 	GetForPeer(ctx context.Context, id domain.PeerIdentifier) (*domain.PeerStatus, error)
+	GetInterfaceTraffic(
+		ctx context.Context,
+		id domain.InterfaceIdentifier,
+		query domain.TrafficQuery,
+	) ([]domain.TrafficDataPoint, error)
+	GetPeerTraffic(
+		ctx context.Context,
+		id domain.PeerIdentifier,
+		query domain.TrafficQuery,
+	) ([]domain.TrafficDataPoint, error)
+	GetSessionsForInterface(ctx context.Context, id domain.InterfaceIdentifier, from, to time.Time) (
+		[]domain.PeerSession,
+		error,
+	)
+	GetSessionsForUser(ctx context.Context, id domain.UserIdentifier, from, to time.Time) (
+		[]domain.PeerSession,
+		error,
+	)
+	GetSessionsForPeer(ctx context.Context, id domain.PeerIdentifier, from, to time.Time) (
+		[]domain.PeerSession,
+		error,
+	)
 }
 
 type MetricsEndpoint struct {
@@ -42,12 +66,17 @@ func (e MetricsEndpoint) GetName() string {
 
 func (e MetricsEndpoint) RegisterRoutes(g *routegroup.Bundle) {
 	apiGroup := g.Mount("/metrics")
-	apiGroup.Use(e.authenticator.LoggedIn())
+	apiGroup.Use(e.authenticator.LoggedIn(ScopeMetrics))
 
 	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("GET /by-interface/{id}",
 		e.handleMetricsForInterfaceGet())
 	apiGroup.HandleFunc("GET /by-user/{id}", e.handleMetricsForUserGet())
 	apiGroup.HandleFunc("GET /by-peer/{id}", e.handleMetricsForPeerGet())
+
+	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("GET /sessions/by-interface/{id}",
+		e.handleSessionsForInterfaceGet())
+	apiGroup.HandleFunc("GET /sessions/by-user/{id}", e.handleSessionsForUserGet())
+	apiGroup.HandleFunc("GET /sessions/by-peer/{id}", e.handleSessionsForPeerGet())
 }
 
 // handleMetricsForInterfaceGet returns a gorm Handler function.
@@ -55,7 +84,11 @@ func (e MetricsEndpoint) RegisterRoutes(g *routegroup.Bundle) {
 // @ID metrics_handleMetricsForInterfaceGet
 // @Tags Metrics
 // @Summary Get all metrics for a WireGuard Portal interface.
+// @Description If one of the time range parameters is set, the traffic history of the interface is included.
 // @Param id path string true "The WireGuard interface identifier."
+// @Param from query string false "The start of the time range (RFC3339), defaults to 24 hours before to."
+// @Param to query string false "The end of the time range (RFC3339), defaults to now."
+// @Param step query string false "The bucket size: hour, day, week, month or a duration like 15m, defaults to hour."
 // @Produce json
 // @Success 200 {object} models.InterfaceMetrics
 // @Failure 400 {object} models.Error
@@ -80,7 +113,23 @@ func (e MetricsEndpoint) handleMetricsForInterfaceGet() http.HandlerFunc {
 			return
 		}
 
-		respond.JSON(w, http.StatusOK, models.NewInterfaceMetrics(interfaceMetrics))
+		result := models.NewInterfaceMetrics(interfaceMetrics)
+
+		if query, ok, err := parseTrafficQuery(r); err != nil {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		} else if ok {
+			history, err := e.metrics.GetInterfaceTraffic(r.Context(), domain.InterfaceIdentifier(id), query)
+			if err != nil {
+				status, model := ParseServiceError(err)
+				respond.JSON(w, status, model)
+				return
+			}
+			result.History = models.NewTrafficDataPoints(history)
+		}
+
+		respond.JSON(w, http.StatusOK, result)
 	}
 }
 
@@ -123,7 +172,11 @@ func (e MetricsEndpoint) handleMetricsForUserGet() http.HandlerFunc {
 // @ID metrics_handleMetricsForPeerGet
 // @Tags Metrics
 // @Summary Get all metrics for a WireGuard Portal peer.
+// @Description If one of the time range parameters is set, the traffic history of the peer is included.
 // @Param id path string true "The peer identifier (public key)."
+// @Param from query string false "The start of the time range (RFC3339), defaults to 24 hours before to."
+// @Param to query string false "The end of the time range (RFC3339), defaults to now."
+// @Param step query string false "The bucket size: hour, day, week, month or a duration like 15m, defaults to hour."
 // @Produce json
 // @Success 200 {object} models.PeerMetrics
 // @Failure 400 {object} models.Error
@@ -148,6 +201,208 @@ func (e MetricsEndpoint) handleMetricsForPeerGet() http.HandlerFunc {
 			return
 		}
 
-		respond.JSON(w, http.StatusOK, models.NewPeerMetrics(peerMetrics))
+		result := models.NewPeerMetrics(peerMetrics)
+
+		if query, ok, err := parseTrafficQuery(r); err != nil {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		} else if ok {
+			history, err := e.metrics.GetPeerTraffic(r.Context(), domain.PeerIdentifier(id), query)
+			if err != nil {
+				status, model := ParseServiceError(err)
+				respond.JSON(w, status, model)
+				return
+			}
+			result.History = models.NewTrafficDataPoints(history)
+		}
+
+		respond.JSON(w, http.StatusOK, result)
+	}
+}
+
+// handleSessionsForInterfaceGet returns a gorm Handler function.
+//
+// @ID metrics_handleSessionsForInterfaceGet
+// @Tags Metrics
+// @Summary Get the connection sessions of all peers of a WireGuard Portal interface.
+// @Param id path string true "The WireGuard interface identifier."
+// @Param from query string false "The start of the time range (RFC3339), defaults to 30 days before to."
+// @Param to query string false "The end of the time range (RFC3339), defaults to now."
+// @Produce json
+// @Success 200 {object} []models.PeerSession
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /metrics/sessions/by-interface/{id} [get]
+// @Security BasicAuth
+func (e MetricsEndpoint) handleSessionsForInterfaceGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing interface id"})
+			return
+		}
+
+		from, to, err := parseSessionRange(r)
+		if err != nil {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		sessions, err := e.metrics.GetSessionsForInterface(r.Context(), domain.InterfaceIdentifier(id), from, to)
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewPeerSessions(sessions))
+	}
+}
+
+// handleSessionsForUserGet returns a gorm Handler function.
+//
+// @ID metrics_handleSessionsForUserGet
+// @Tags Metrics
+// @Summary Get the connection sessions of all peers of a WireGuard Portal user.
+// @Param id path string true "The user identifier."
+// @Param from query string false "The start of the time range (RFC3339), defaults to 30 days before to."
+// @Param to query string false "The end of the time range (RFC3339), defaults to now."
+// @Produce json
+// @Success 200 {object} []models.PeerSession
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /metrics/sessions/by-user/{id} [get]
+// @Security BasicAuth
+func (e MetricsEndpoint) handleSessionsForUserGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing user id"})
+			return
+		}
+
+		from, to, err := parseSessionRange(r)
+		if err != nil {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		sessions, err := e.metrics.GetSessionsForUser(r.Context(), domain.UserIdentifier(id), from, to)
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewPeerSessions(sessions))
 	}
 }
+
+// handleSessionsForPeerGet returns a gorm Handler function.
+//
+// @ID metrics_handleSessionsForPeerGet
+// @Tags Metrics
+// @Summary Get the connection sessions of a WireGuard Portal peer.
+// @Param id path string true "The peer identifier (public key)."
+// @Param from query string false "The start of the time range (RFC3339), defaults to 30 days before to."
+// @Param to query string false "The end of the time range (RFC3339), defaults to now."
+// @Produce json
+// @Success 200 {object} []models.PeerSession
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /metrics/sessions/by-peer/{id} [get]
+// @Security BasicAuth
+func (e MetricsEndpoint) handleSessionsForPeerGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing peer id"})
+			return
+		}
+
+		from, to, err := parseSessionRange(r)
+		if err != nil {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		sessions, err := e.metrics.GetSessionsForPeer(r.Context(), domain.PeerIdentifier(id), from, to)
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewPeerSessions(sessions))
+	}
+}
+
+// parseSessionRange parses the optional time range parameters of the session endpoints.
+// By default, the sessions of the last 30 days are returned.
+func parseSessionRange(r *http.Request) (from, to time.Time, err error) {
+	to = time.Now()
+	if toStr := request.Query(r, "to"); toStr != "" {
+		if to, err = time.Parse(time.RFC3339, toStr); err != nil {
+			return from, to, fmt.Errorf("invalid to parameter: %w", err)
+		}
+	}
+	from = to.AddDate(0, 0, -30)
+	if fromStr := request.Query(r, "from"); fromStr != "" {
+		if from, err = time.Parse(time.RFC3339, fromStr); err != nil {
+			return from, to, fmt.Errorf("invalid from parameter: %w", err)
+		}
+	}
+
+	return from, to, nil
+}
+
+// parseTrafficQuery parses the optional time range parameters of the metrics endpoints.
+// If none of the parameters is set, false is returned.
+func parseTrafficQuery(r *http.Request) (domain.TrafficQuery, bool, error) {
+	fromStr := request.Query(r, "from")
+	toStr := request.Query(r, "to")
+	stepStr := request.Query(r, "step")
+	if fromStr == "" && toStr == "" && stepStr == "" {
+		return domain.TrafficQuery{}, false, nil
+	}
+
+	query := domain.TrafficQuery{
+		To:   time.Now(),
+		Step: domain.TrafficStepHour,
+	}
+
+	var err error
+	if toStr != "" {
+		if query.To, err = time.Parse(time.RFC3339, toStr); err != nil {
+			return query, false, fmt.Errorf("invalid to parameter: %w", err)
+		}
+	}
+	query.From = query.To.Add(-24 * time.Hour)
+	if fromStr != "" {
+		if query.From, err = time.Parse(time.RFC3339, fromStr); err != nil {
+			return query, false, fmt.Errorf("invalid from parameter: %w", err)
+		}
+	}
+	if stepStr != "" {
+		if query.Step, err = domain.ParseTrafficStep(stepStr); err != nil {
+			return query, false, err
+		}
+	}
+
+	return query, true, nil
+}
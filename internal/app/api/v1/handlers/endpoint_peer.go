@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/go-pkgz/routegroup"
 
@@ -20,6 +21,17 @@ type PeerService interface {
 	Create(context.Context, *domain.Peer) (*domain.Peer, error)
 	Update(context.Context, domain.PeerIdentifier, *domain.Peer) (*domain.Peer, error)
 	Delete(context.Context, domain.PeerIdentifier) error
+	RejectRenewal(context.Context, domain.PeerIdentifier) (*domain.Peer, error)
+	Move(context.Context, domain.PeerIdentifier, domain.InterfaceIdentifier) (*domain.Peer, error)
+	Bulk(context.Context, *domain.PeerBulkRequest) (*domain.BulkResult, error)
+	CreateShareLink(
+		ctx context.Context,
+		id domain.PeerIdentifier,
+		validity time.Duration,
+		maxUses *int,
+	) (*domain.PeerShareLink, string, error)
+	GetShareLinks(context.Context, domain.PeerIdentifier) ([]domain.PeerShareLink, error)
+	RevokeShareLink(context.Context, domain.PeerShareLinkIdentifier) error
 }
 
 type PeerEndpoint struct {
@@ -47,15 +59,27 @@ func (e PeerEndpoint) RegisterRoutes(g *routegroup.Bundle) {
 	apiGroup := g.Mount("/peer")
 	apiGroup.Use(e.authenticator.LoggedIn())
 
-	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("GET /by-interface/{id}",
-		e.handleAllForInterfaceGet())
-	apiGroup.HandleFunc("GET /by-user/{id}", e.handleAllForUserGet())
-	apiGroup.HandleFunc("GET /by-id/{id}", e.handleByIdGet())
-
-	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("GET /prepare/{id}", e.handlePrepareGet())
-	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("POST /new", e.handleCreatePost())
-	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("PUT /by-id/{id}", e.handleUpdatePut())
-	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("DELETE /by-id/{id}", e.handleDelete())
+	read := e.authenticator.LoggedIn(ScopePeersRead)
+	write := e.authenticator.LoggedIn(ScopePeersWrite)
+	adminRead := e.authenticator.LoggedIn(ScopeAdmin, ScopePeersRead)
+	adminManage := e.authenticator.LoggedIn(ScopeAdmin, ScopePeersManage)
+	adminWrite := e.authenticator.LoggedIn(ScopeAdmin, ScopePeersWrite)
+
+	apiGroup.With(adminRead).HandleFunc("GET /by-interface/{id}", e.handleAllForInterfaceGet())
+	apiGroup.With(read).HandleFunc("GET /by-user/{id}", e.handleAllForUserGet())
+	apiGroup.With(read).HandleFunc("GET /by-id/{id}", e.handleByIdGet())
+
+	apiGroup.With(adminRead).HandleFunc("GET /prepare/{id}", e.handlePrepareGet())
+	apiGroup.With(adminWrite).HandleFunc("POST /new", e.handleCreatePost())
+	apiGroup.With(adminWrite).HandleFunc("PUT /by-id/{id}", e.handleUpdatePut())
+	apiGroup.With(adminWrite).HandleFunc("DELETE /by-id/{id}", e.handleDelete())
+	apiGroup.With(adminManage).HandleFunc("DELETE /by-id/{id}/renewal", e.handleRenewalDelete())
+	apiGroup.With(adminWrite).HandleFunc("POST /by-id/{id}/move", e.handleMovePost())
+	apiGroup.With(adminWrite).HandleFunc("POST /bulk", e.handleBulkPost())
+
+	apiGroup.With(write).HandleFunc("POST /by-id/{id}/share-link", e.handleShareLinkPost())
+	apiGroup.With(read).HandleFunc("GET /by-id/{id}/share-links", e.handleShareLinksGet())
+	apiGroup.With(adminManage).HandleFunc("DELETE /share-link/{id}", e.handleShareLinkDelete())
 }
 
 // handleAllForInterfaceGet returns a gorm Handler function.
@@ -64,6 +88,7 @@ func (e PeerEndpoint) RegisterRoutes(g *routegroup.Bundle) {
 // @Tags Peers
 // @Summary Get all peer records for a given WireGuard interface.
 // @Param id path string true "The WireGuard interface identifier."
+// @Param tag query []string false "Only return peers that are tagged with all given tags." collectionFormat(multi)
 // @Produce json
 // @Success 200 {object} []models.Peer
 // @Failure 401 {object} models.Error
@@ -86,7 +111,8 @@ func (e PeerEndpoint) handleAllForInterfaceGet() http.HandlerFunc {
 			return
 		}
 
-		respond.JSON(w, http.StatusOK, models.NewPeers(interfacePeers))
+		tags := domain.NormalizeTags(request.QuerySlice(r, "tag"))
+		respond.JSON(w, http.StatusOK, models.NewPeers(domain.FilterPeersByTags(interfacePeers, tags)))
 	}
 }
 
@@ -97,6 +123,7 @@ func (e PeerEndpoint) handleAllForInterfaceGet() http.HandlerFunc {
 // @Summary Get all peer records for a given user.
 // @Description Normal users can only access their own records. Admins can access all records.
 // @Param id path string true "The user identifier."
+// @Param tag query []string false "Only return peers that are tagged with all given tags." collectionFormat(multi)
 // @Produce json
 // @Success 200 {object} []models.Peer
 // @Failure 401 {object} models.Error
@@ -119,7 +146,8 @@ func (e PeerEndpoint) handleAllForUserGet() http.HandlerFunc {
 			return
 		}
 
-		respond.JSON(w, http.StatusOK, models.NewPeers(interfacePeers))
+		tags := domain.NormalizeTags(request.QuerySlice(r, "tag"))
+		respond.JSON(w, http.StatusOK, models.NewPeers(domain.FilterPeersByTags(interfacePeers, tags)))
 	}
 }
 
@@ -314,3 +342,248 @@ func (e PeerEndpoint) handleDelete() http.HandlerFunc {
 		respond.Status(w, http.StatusNoContent)
 	}
 }
+
+// handleMovePost returns a gorm handler function.
+//
+// @ID peers_handleMovePost
+// @Tags Peers
+// @Summary Move the peer to another interface.
+// @Description The keypair of the peer is kept. The peer gets new addresses from the peer networks of the target
+// @Description interface and the peer defaults of the target interface are applied. The linked user receives the
+// @Description new configuration by mail.
+// @Param id path string true "The peer identifier."
+// @Param request body models.PeerMoveRequest true "The target interface."
+// @Produce json
+// @Success 200 {object} models.Peer
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /peer/by-id/{id}/move [post]
+// @Security BasicAuth
+func (e PeerEndpoint) handleMovePost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing peer id"})
+			return
+		}
+
+		var req models.PeerMoveRequest
+		if err := request.BodyJson(r, &req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validator.Struct(req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		movedPeer, err := e.peers.Move(r.Context(), domain.PeerIdentifier(id),
+			domain.InterfaceIdentifier(req.Interface))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewPeer(movedPeer))
+	}
+}
+
+// handleBulkPost returns a gorm handler function.
+//
+// @ID peers_handleBulkPost
+// @Tags Peers
+// @Summary Apply an action to all selected peers.
+// @Description Selected peers can be enabled, disabled, deleted, moved or get a new expiry date. In dry-run mode, nothing
+// @Description is changed and the result lists the peers that would be changed. A single audit entry is recorded.
+// @Param request body models.PeerBulkRequest true "The selector and the action."
+// @Produce json
+// @Success 200 {object} models.BulkResult
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /peer/bulk [post]
+// @Security BasicAuth
+func (e PeerEndpoint) handleBulkPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.PeerBulkRequest
+		if err := request.BodyJson(r, &req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validator.Struct(req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		result, err := e.peers.Bulk(r.Context(), models.NewDomainPeerBulkRequest(&req))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewBulkResult(result))
+	}
+}
+
+// handleRenewalDelete returns a gorm handler function.
+//
+// @ID peers_handleRenewalDelete
+// @Tags Peers
+// @Summary Reject the pending renewal request of the peer.
+// @Description Renewal requests are approved by renewing the peer using the provisioning API.
+// @Param id path string true "The peer identifier."
+// @Produce json
+// @Success 200 {object} models.Peer
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /peer/by-id/{id}/renewal [delete]
+// @Security BasicAuth
+func (e PeerEndpoint) handleRenewalDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing peer id"})
+			return
+		}
+
+		peer, err := e.peers.RejectRenewal(r.Context(), domain.PeerIdentifier(id))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewPeer(peer))
+	}
+}
+
+// handleShareLinkPost returns a gorm handler function.
+//
+// @ID peers_handleShareLinkPost
+// @Tags Peers
+// @Summary Create a configuration download link for the given peer.
+// @Description Normal users can only create links for their own peers. The returned url can be used without authentication until it expires, gets revoked or reaches its usage limit.
+// @Param id path string true "The peer identifier (public key)."
+// @Param request body models.ShareLinkRequest true "The share link parameters."
+// @Produce json
+// @Success 200 {object} models.ShareLink
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /peer/by-id/{id}/share-link [post]
+// @Security BasicAuth
+func (e PeerEndpoint) handleShareLinkPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing peer id"})
+			return
+		}
+
+		var req models.ShareLinkRequest
+		if err := request.BodyJson(r, &req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validator.Struct(req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		link, url, err := e.peers.CreateShareLink(r.Context(), domain.PeerIdentifier(id),
+			time.Duration(req.ValidHours)*time.Hour, req.MaxUses)
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewShareLink(link, url))
+	}
+}
+
+// handleShareLinksGet returns a gorm handler function.
+//
+// @ID peers_handleShareLinksGet
+// @Tags Peers
+// @Summary Get all configuration download links of the given peer.
+// @Description Normal users can only access the links of their own peers. Admins can access all links.
+// @Param id path string true "The peer identifier (public key)."
+// @Produce json
+// @Success 200 {object} []models.ShareLink
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /peer/by-id/{id}/share-links [get]
+// @Security BasicAuth
+func (e PeerEndpoint) handleShareLinksGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing peer id"})
+			return
+		}
+
+		links, err := e.peers.GetShareLinks(r.Context(), domain.PeerIdentifier(id))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewShareLinks(links))
+	}
+}
+
+// handleShareLinkDelete returns a gorm handler function.
+//
+// @ID peers_handleShareLinkDelete
+// @Tags Peers
+// @Summary Revoke a configuration download link.
+// @Description Admins and users that are allowed to manage peers of the interface can revoke share links.
+// @Param id path string true "The share link identifier."
+// @Produce json
+// @Success 204 "No content if revocation was successful."
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /peer/share-link/{id} [delete]
+// @Security BasicAuth
+func (e PeerEndpoint) handleShareLinkDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing share link id"})
+			return
+		}
+
+		err := e.peers.RevokeShareLink(r.Context(), domain.PeerShareLinkIdentifier(id))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.Status(w, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-pkgz/routegroup"
+
+	"github.com/h44z/wg-portal/internal/app/api/core/request"
+	"github.com/h44z/wg-portal/internal/app/api/core/respond"
+	"github.com/h44z/wg-portal/internal/app/api/v1/models"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type PeerTagEndpointPeerTagService interface {
+	GetAll(ctx context.Context) ([]domain.PeerTag, error)
+	GetByName(ctx context.Context, name string) (*domain.PeerTag, error)
+	Create(ctx context.Context, tag *domain.PeerTag) (*domain.PeerTag, error)
+	Update(ctx context.Context, name string, tag *domain.PeerTag) (*domain.PeerTag, error)
+	Delete(ctx context.Context, name string) error
+	GetPeers(ctx context.Context, name string) ([]domain.Peer, error)
+	EnablePeers(ctx context.Context, name string) ([]domain.Peer, error)
+	DisablePeers(ctx context.Context, name string) ([]domain.Peer, error)
+	ApplyDefaults(ctx context.Context, name string) ([]domain.Peer, error)
+	DeletePeers(ctx context.Context, name string) ([]domain.Peer, error)
+	SendPeerEmails(ctx context.Context, name string, linkOnly bool, style string) error
+}
+
+type PeerTagEndpoint struct {
+	tags          PeerTagEndpointPeerTagService
+	authenticator Authenticator
+	validator     Validator
+}
+
+func NewPeerTagEndpoint(
+	authenticator Authenticator,
+	validator Validator,
+	peerTagService PeerTagEndpointPeerTagService,
+) *PeerTagEndpoint {
+	return &PeerTagEndpoint{
+		authenticator: authenticator,
+		validator:     validator,
+		tags:          peerTagService,
+	}
+}
+
+func (e PeerTagEndpoint) GetName() string {
+	return "PeerTagEndpoint"
+}
+
+func (e PeerTagEndpoint) RegisterRoutes(g *routegroup.Bundle) {
+	apiGroup := g.Mount("/peer-tag")
+	apiGroup.Use(e.authenticator.LoggedIn())
+
+	read := e.authenticator.LoggedIn(ScopeAdmin, ScopePeersRead)
+	write := e.authenticator.LoggedIn(ScopeAdmin, ScopePeersWrite)
+
+	apiGroup.With(read).HandleFunc("GET /all", e.handleAllGet())
+	apiGroup.With(read).HandleFunc("GET /by-name/{name}", e.handleByNameGet())
+	apiGroup.With(write).HandleFunc("POST /new", e.handleCreatePost())
+	apiGroup.With(write).HandleFunc("PUT /by-name/{name}", e.handleUpdatePut())
+	apiGroup.With(write).HandleFunc("DELETE /by-name/{name}", e.handleDelete())
+
+	apiGroup.With(read).HandleFunc("GET /by-name/{name}/peers", e.handlePeersGet())
+	apiGroup.With(write).HandleFunc("POST /by-name/{name}/enable", e.handlePeerAction(e.tags.EnablePeers))
+	apiGroup.With(write).HandleFunc("POST /by-name/{name}/disable", e.handlePeerAction(e.tags.DisablePeers))
+	apiGroup.With(write).HandleFunc("POST /by-name/{name}/apply-defaults", e.handlePeerAction(e.tags.ApplyDefaults))
+	apiGroup.With(write).HandleFunc("POST /by-name/{name}/delete-peers", e.handlePeerAction(e.tags.DeletePeers))
+	apiGroup.With(write).HandleFunc("POST /by-name/{name}/send-mail", e.handleSendMailPost())
+}
+
+// handleAllGet returns a gorm Handler function.
+//
+// @ID peerTag_handleAllGet
+// @Tags Peer Tags
+// @Summary Get all tags that carry a policy.
+// @Produce json
+// @Success 200 {object} []models.PeerTag
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /peer-tag/all [get]
+// @Security BasicAuth
+func (e PeerTagEndpoint) handleAllGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tags, err := e.tags.GetAll(r.Context())
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewPeerTags(tags))
+	}
+}
+
+// handleByNameGet returns a gorm Handler function.
+//
+// @ID peerTag_handleByNameGet
+// @Tags Peer Tags
+// @Summary Get a specific tag by its name.
+// @Param name path string true "The tag name."
+// @Produce json
+// @Success 200 {object} models.PeerTag
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /peer-tag/by-name/{name} [get]
+// @Security BasicAuth
+func (e PeerTagEndpoint) handleByNameGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := request.Path(r, "name")
+		if name == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing tag name"})
+			return
+		}
+
+		tag, err := e.tags.GetByName(r.Context(), name)
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewPeerTag(tag))
+	}
+}
+
+// handleCreatePost returns a gorm Handler function.
+//
+// @ID peerTag_handleCreatePost
+// @Tags Peer Tags
+// @Summary Create the policy of a tag.
+// @Description Peers can be tagged with any tag, a tag record is only required to store a description or a policy.
+// @Param request body models.PeerTag true "The tag data."
+// @Produce json
+// @Success 200 {object} models.PeerTag
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /peer-tag/new [post]
+// @Security BasicAuth
+func (e PeerTagEndpoint) handleCreatePost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var tag models.PeerTag
+		if err := request.BodyJson(r, &tag); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validator.Struct(tag); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		newTag, err := e.tags.Create(r.Context(), models.NewDomainPeerTag(&tag))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewPeerTag(newTag))
+	}
+}
+
+// handleUpdatePut returns a gorm Handler function.
+//
+// @ID peerTag_handleUpdatePut
+// @Tags Peer Tags
+// @Summary Update the policy of a tag.
+// @Description The policy is not applied to already tagged peers. Use the apply-defaults action to update them.
+// @Param name path string true "The tag name."
+// @Param request body models.PeerTag true "The tag data."
+// @Produce json
+// @Success 200 {object} models.PeerTag
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /peer-tag/by-name/{name} [put]
+// @Security BasicAuth
+func (e PeerTagEndpoint) handleUpdatePut() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := request.Path(r, "name")
+		if name == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing tag name"})
+			return
+		}
+
+		var tag models.PeerTag
+		if err := request.BodyJson(r, &tag); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validator.Struct(tag); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		updatedTag, err := e.tags.Update(r.Context(), name, models.NewDomainPeerTag(&tag))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewPeerTag(updatedTag))
+	}
+}
+
+// handleDelete returns a gorm Handler function.
+//
+// @ID peerTag_handleDelete
+// @Tags Peer Tags
+// @Summary Delete the policy of a tag.
+// @Description Tagged peers keep the tag and all settings that were applied by the policy.
+// @Param name path string true "The tag name."
+// @Produce json
+// @Success 204 "No content if deletion was successful."
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /peer-tag/by-name/{name} [delete]
+// @Security BasicAuth
+func (e PeerTagEndpoint) handleDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := request.Path(r, "name")
+		if name == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing tag name"})
+			return
+		}
+
+		err := e.tags.Delete(r.Context(), name)
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.Status(w, http.StatusNoContent)
+	}
+}
+
+// handlePeersGet returns a gorm Handler function.
+//
+// @ID peerTag_handlePeersGet
+// @Tags Peer Tags
+// @Summary Get all peers with the given tag.
+// @Param name path string true "The tag name."
+// @Produce json
+// @Success 200 {object} []models.Peer
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /peer-tag/by-name/{name}/peers [get]
+// @Security BasicAuth
+func (e PeerTagEndpoint) handlePeersGet() http.HandlerFunc {
+	return e.handlePeerAction(e.tags.GetPeers)
+}
+
+// handlePeerAction returns a gorm Handler function that executes a bulk action for all peers with the given tag.
+//
+// @ID peerTag_handlePeerAction
+// @Tags Peer Tags
+// @Summary Execute an action for all peers with the given tag.
+// @Description The actions enable, disable, apply the interface defaults and the tag policies to, or delete all
+// @Description tagged peers. The affected peers are returned.
+// @Param name path string true "The tag name."
+// @Param action path string true "The action." Enums(enable, disable, apply-defaults, delete-peers)
+// @Produce json
+// @Success 200 {object} []models.Peer
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /peer-tag/by-name/{name}/{action} [post]
+// @Security BasicAuth
+func (e PeerTagEndpoint) handlePeerAction(
+	action func(ctx context.Context, name string) ([]domain.Peer, error),
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := request.Path(r, "name")
+		if name == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing tag name"})
+			return
+		}
+
+		peers, err := action(r.Context(), name)
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewPeers(peers))
+	}
+}
+
+// handleSendMailPost returns a gorm Handler function.
+//
+// @ID peerTag_handleSendMailPost
+// @Tags Peer Tags
+// @Summary Send the configuration of all peers with the given tag to the linked users.
+// @Description Peers without a linked user or users without a mail address are skipped.
+// @Param name path string true "The tag name."
+// @Param request body models.PeerTagMailRequest true "The mail options."
+// @Produce json
+// @Success 204 "No content if all mails were sent."
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /peer-tag/by-name/{name}/send-mail [post]
+// @Security BasicAuth
+func (e PeerTagEndpoint) handleSendMailPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := request.Path(r, "name")
+		if name == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing tag name"})
+			return
+		}
+
+		var req models.PeerTagMailRequest
+		if err := request.BodyJson(r, &req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validator.Struct(req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		err := e.tags.SendPeerEmails(r.Context(), name, req.LinkOnly, req.Style)
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.Status(w, http.StatusNoContent)
+	}
+}
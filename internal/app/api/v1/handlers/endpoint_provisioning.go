@@ -22,6 +22,7 @@ type ProvisioningEndpointProvisioningService interface {
 	GetPeerConfig(ctx context.Context, peerId domain.PeerIdentifier) ([]byte, error)
 	GetPeerQrPng(ctx context.Context, peerId domain.PeerIdentifier) ([]byte, error)
 	NewPeer(ctx context.Context, req models.ProvisioningRequest) (*domain.Peer, error)
+	RenewPeer(ctx context.Context, req models.RenewalRequest) (*domain.Peer, error)
 }
 
 type ProvisioningEndpoint struct {
@@ -48,13 +49,14 @@ func (e ProvisioningEndpoint) GetName() string {
 
 func (e ProvisioningEndpoint) RegisterRoutes(g *routegroup.Bundle) {
 	apiGroup := g.Mount("/provisioning")
-	apiGroup.Use(e.authenticator.LoggedIn())
+	apiGroup.Use(e.authenticator.LoggedIn(ScopeProvisioning))
 
 	apiGroup.HandleFunc("GET /data/user-info", e.handleUserInfoGet())
 	apiGroup.HandleFunc("GET /data/peer-config", e.handlePeerConfigGet())
 	apiGroup.HandleFunc("GET /data/peer-qr", e.handlePeerQrGet())
 
 	apiGroup.HandleFunc("POST /new-peer", e.handleNewPeerPost())
+	apiGroup.HandleFunc("POST /renew-peer", e.handleRenewPeerPost())
 }
 
 // handleUserInfoGet returns a gorm Handler function.
@@ -206,3 +208,42 @@ func (e ProvisioningEndpoint) handleNewPeerPost() http.HandlerFunc {
 		respond.JSON(w, http.StatusOK, models.NewPeer(peer))
 	}
 }
+
+// handleRenewPeerPost returns a gorm Handler function.
+//
+// @ID provisioning_handleRenewPeerPost
+// @Tags Provisioning
+// @Summary Extend the expiry date of the given peer.
+// @Description The renewal is limited by the renewal policy of the interface. If the policy requires approval, renewals of normal users are only recorded as pending request. Renewals by admins are applied immediately and approve pending requests.
+// @Param request body models.RenewalRequest true "Renewal request model."
+// @Produce json
+// @Success 200 {object} models.Peer
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /provisioning/renew-peer [post]
+// @Security BasicAuth
+func (e ProvisioningEndpoint) handleRenewPeerPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.RenewalRequest
+		if err := request.BodyJson(r, &req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validator.Struct(req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		peer, err := e.provisioning.RenewPeer(r.Context(), req)
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewPeer(peer))
+	}
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-pkgz/routegroup"
+
+	"github.com/h44z/wg-portal/internal/app/api/core/request"
+	"github.com/h44z/wg-portal/internal/app/api/core/respond"
+	"github.com/h44z/wg-portal/internal/app/api/v1/models"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type SiteNetworkEndpointSiteNetworkService interface {
+	GetAll(ctx context.Context) ([]domain.SiteNetwork, error)
+	GetById(ctx context.Context, id domain.SiteNetworkIdentifier) (*domain.SiteNetwork, error)
+	Create(ctx context.Context, network *domain.SiteNetwork) (*domain.SiteNetwork, error)
+	Update(
+		ctx context.Context,
+		id domain.SiteNetworkIdentifier,
+		network *domain.SiteNetwork,
+	) (*domain.SiteNetwork, error)
+	Delete(ctx context.Context, id domain.SiteNetworkIdentifier) error
+	GetSiteConfig(ctx context.Context, id domain.SiteNetworkIdentifier, siteId domain.SiteIdentifier) ([]byte, error)
+}
+
+type SiteNetworkEndpoint struct {
+	sites         SiteNetworkEndpointSiteNetworkService
+	authenticator Authenticator
+	validator     Validator
+}
+
+func NewSiteNetworkEndpoint(
+	authenticator Authenticator,
+	validator Validator,
+	siteNetworkService SiteNetworkEndpointSiteNetworkService,
+) *SiteNetworkEndpoint {
+	return &SiteNetworkEndpoint{
+		authenticator: authenticator,
+		validator:     validator,
+		sites:         siteNetworkService,
+	}
+}
+
+func (e SiteNetworkEndpoint) GetName() string {
+	return "SiteNetworkEndpoint"
+}
+
+func (e SiteNetworkEndpoint) RegisterRoutes(g *routegroup.Bundle) {
+	apiGroup := g.Mount("/site-network")
+	apiGroup.Use(e.authenticator.LoggedIn())
+
+	read := e.authenticator.LoggedIn(ScopeAdmin, ScopeInterfacesRead)
+	write := e.authenticator.LoggedIn(ScopeAdmin, ScopeInterfacesWrite)
+
+	apiGroup.With(read).HandleFunc("GET /all", e.handleAllGet())
+	apiGroup.With(read).HandleFunc("GET /by-id/{id}", e.handleByIdGet())
+	apiGroup.With(read).HandleFunc("GET /by-id/{id}/config/{siteId}", e.handleSiteConfigGet())
+
+	apiGroup.With(write).HandleFunc("POST /new", e.handleCreatePost())
+	apiGroup.With(write).HandleFunc("PUT /by-id/{id}", e.handleUpdatePut())
+	apiGroup.With(write).HandleFunc("DELETE /by-id/{id}", e.handleDelete())
+}
+
+// handleAllGet returns a gorm Handler function.
+//
+// @ID siteNetwork_handleAllGet
+// @Tags Site Networks
+// @Summary Get all site networks.
+// @Produce json
+// @Success 200 {object} []models.SiteNetwork
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /site-network/all [get]
+// @Security BasicAuth
+func (e SiteNetworkEndpoint) handleAllGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		networks, err := e.sites.GetAll(r.Context())
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewSiteNetworks(networks))
+	}
+}
+
+// handleByIdGet returns a gorm Handler function.
+//
+// @ID siteNetwork_handleByIdGet
+// @Tags Site Networks
+// @Summary Get a specific site network by its identifier.
+// @Param id path string true "The site network identifier."
+// @Produce json
+// @Success 200 {object} models.SiteNetwork
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /site-network/by-id/{id} [get]
+// @Security BasicAuth
+func (e SiteNetworkEndpoint) handleByIdGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing site network id"})
+			return
+		}
+
+		network, err := e.sites.GetById(r.Context(), domain.SiteNetworkIdentifier(id))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewSiteNetwork(network))
+	}
+}
+
+// handleSiteConfigGet returns a gorm Handler function.
+//
+// @ID siteNetwork_handleSiteConfigGet
+// @Tags Site Networks
+// @Summary Get the WireGuard configuration of a site.
+// @Description The configuration is structured in wg-quick format and contains a peer for every connected site.
+// @Param id path string true "The site network identifier."
+// @Param siteId path string true "The site identifier."
+// @Produce plain
+// @Success 200 {string} string "The WireGuard configuration file"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /site-network/by-id/{id}/config/{siteId} [get]
+// @Security BasicAuth
+func (e SiteNetworkEndpoint) handleSiteConfigGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		siteId := request.Path(r, "siteId")
+		if id == "" || siteId == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing site network or site id"})
+			return
+		}
+
+		siteConfig, err := e.sites.GetSiteConfig(r.Context(), domain.SiteNetworkIdentifier(id),
+			domain.SiteIdentifier(siteId))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.Data(w, http.StatusOK, "text/plain", siteConfig)
+	}
+}
+
+// handleCreatePost returns a gorm Handler function.
+//
+// @ID siteNetwork_handleCreatePost
+// @Tags Site Networks
+// @Summary Create a new site network.
+// @Description Keys are generated for all remote sites without a public key. Sites that are linked to an interface use the keys, the listen port and the addresses of the interface.
+// @Param request body models.SiteNetwork true "The site network data."
+// @Produce json
+// @Success 200 {object} models.SiteNetwork
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /site-network/new [post]
+// @Security BasicAuth
+func (e SiteNetworkEndpoint) handleCreatePost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var network models.SiteNetwork
+		if err := request.BodyJson(r, &network); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validator.Struct(network); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		newNetwork, err := e.sites.Create(r.Context(), models.NewDomainSiteNetwork(&network))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewSiteNetwork(newNetwork))
+	}
+}
+
+// handleUpdatePut returns a gorm Handler function.
+//
+// @ID siteNetwork_handleUpdatePut
+// @Tags Site Networks
+// @Summary Update a site network.
+// @Description The sites and links are replaced. The peers of all interfaces that are linked to a site are updated.
+// @Param id path string true "The site network identifier."
+// @Param request body models.SiteNetwork true "The site network data."
+// @Produce json
+// @Success 200 {object} models.SiteNetwork
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /site-network/by-id/{id} [put]
+// @Security BasicAuth
+func (e SiteNetworkEndpoint) handleUpdatePut() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing site network id"})
+			return
+		}
+
+		var network models.SiteNetwork
+		if err := request.BodyJson(r, &network); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validator.Struct(network); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		updatedNetwork, err := e.sites.Update(r.Context(), domain.SiteNetworkIdentifier(id),
+			models.NewDomainSiteNetwork(&network))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewSiteNetwork(updatedNetwork))
+	}
+}
+
+// handleDelete returns a gorm Handler function.
+//
+// @ID siteNetwork_handleDelete
+// @Tags Site Networks
+// @Summary Delete a site network.
+// @Description All peers that were created for the site network are deleted as well.
+// @Param id path string true "The site network identifier."
+// @Produce json
+// @Success 204 "No content if deletion was successful."
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /site-network/by-id/{id} [delete]
+// @Security BasicAuth
+func (e SiteNetworkEndpoint) handleDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing site network id"})
+			return
+		}
+
+		err := e.sites.Delete(r.Context(), domain.SiteNetworkIdentifier(id))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.Status(w, http.StatusNoContent)
+	}
+}
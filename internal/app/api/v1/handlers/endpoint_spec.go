@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-pkgz/routegroup"
+
+	"github.com/h44z/wg-portal/internal/app/api/core/respond"
+	"github.com/h44z/wg-portal/internal/app/api/v1/models"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type SpecEndpointSpecService interface {
+	Plan(ctx context.Context) (*domain.SpecPlan, error)
+	Apply(ctx context.Context) (*domain.SpecPlan, error)
+}
+
+type SpecEndpoint struct {
+	specs         SpecEndpointSpecService
+	authenticator Authenticator
+	validator     Validator
+}
+
+func NewSpecEndpoint(
+	authenticator Authenticator,
+	validator Validator,
+	specService SpecEndpointSpecService,
+) *SpecEndpoint {
+	return &SpecEndpoint{
+		authenticator: authenticator,
+		validator:     validator,
+		specs:         specService,
+	}
+}
+
+func (e SpecEndpoint) GetName() string {
+	return "SpecEndpoint"
+}
+
+func (e SpecEndpoint) RegisterRoutes(g *routegroup.Bundle) {
+	apiGroup := g.Mount("/spec")
+	apiGroup.Use(e.authenticator.LoggedIn(ScopeAdmin, ScopeSpec))
+
+	apiGroup.HandleFunc("GET /plan", e.handlePlanGet())
+	apiGroup.HandleFunc("POST /apply", e.handleApplyPost())
+}
+
+// handlePlanGet returns a gorm Handler function.
+//
+// @ID spec_handlePlanGet
+// @Tags Spec
+// @Summary Show the changes that are needed to apply the declarative spec.
+// @Description The spec is read from the configured spec path. Nothing is changed.
+// @Produce json
+// @Success 200 {object} models.SpecPlan
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /spec/plan [get]
+// @Security BasicAuth
+func (e SpecEndpoint) handlePlanGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		plan, err := e.specs.Plan(r.Context())
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewSpecPlan(plan))
+	}
+}
+
+// handleApplyPost returns a gorm Handler function.
+//
+// @ID spec_handleApplyPost
+// @Tags Spec
+// @Summary Apply the declarative spec.
+// @Description The spec is read from the configured spec path and applied immediately. Failed changes are reported in the result, all other changes are applied anyway.
+// @Produce json
+// @Success 200 {object} models.SpecPlan
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /spec/apply [post]
+// @Security BasicAuth
+func (e SpecEndpoint) handleApplyPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		plan, err := e.specs.Apply(r.Context())
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewSpecPlan(plan))
+	}
+}
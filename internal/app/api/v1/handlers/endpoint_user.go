@@ -18,6 +18,10 @@ type UserService interface {
 	Create(ctx context.Context, user *domain.User) (*domain.User, error)
 	Update(ctx context.Context, id domain.UserIdentifier, user *domain.User) (*domain.User, error)
 	Delete(ctx context.Context, id domain.UserIdentifier) error
+	Bulk(ctx context.Context, req *domain.UserBulkRequest) (*domain.BulkResult, error)
+	Unlock(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
+	GetLoginBlocks(ctx context.Context) ([]domain.LoginAttempt, error)
+	DeleteLoginBlock(ctx context.Context, sourceAddr string) error
 }
 
 type UserEndpoint struct {
@@ -46,11 +50,19 @@ func (e UserEndpoint) RegisterRoutes(g *routegroup.Bundle) {
 	apiGroup := g.Mount("/user")
 	apiGroup.Use(e.authenticator.LoggedIn())
 
-	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("GET /all", e.handleAllGet())
-	apiGroup.HandleFunc("GET /by-id/{id}", e.handleByIdGet())
-	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("POST /new", e.handleCreatePost())
-	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("PUT /by-id/{id}", e.handleUpdatePut())
-	apiGroup.With(e.authenticator.LoggedIn(ScopeAdmin)).HandleFunc("DELETE /by-id/{id}", e.handleDelete())
+	read := e.authenticator.LoggedIn(ScopeUsersRead)
+	adminRead := e.authenticator.LoggedIn(ScopeAdmin, ScopeUsersRead)
+	adminWrite := e.authenticator.LoggedIn(ScopeAdmin, ScopeUsersWrite)
+
+	apiGroup.With(adminRead).HandleFunc("GET /all", e.handleAllGet())
+	apiGroup.With(read).HandleFunc("GET /by-id/{id}", e.handleByIdGet())
+	apiGroup.With(adminWrite).HandleFunc("POST /new", e.handleCreatePost())
+	apiGroup.With(adminWrite).HandleFunc("PUT /by-id/{id}", e.handleUpdatePut())
+	apiGroup.With(adminWrite).HandleFunc("DELETE /by-id/{id}", e.handleDelete())
+	apiGroup.With(adminWrite).HandleFunc("POST /bulk", e.handleBulkPost())
+	apiGroup.With(adminWrite).HandleFunc("POST /by-id/{id}/unlock", e.handleUnlockPost())
+	apiGroup.With(adminRead).HandleFunc("GET /login-blocks", e.handleLoginBlocksGet())
+	apiGroup.With(adminWrite).HandleFunc("DELETE /login-blocks/{source}", e.handleLoginBlockDelete())
 }
 
 // handleAllGet returns a gorm Handler function.
@@ -232,3 +244,139 @@ func (e UserEndpoint) handleDelete() http.HandlerFunc {
 		respond.Status(w, http.StatusNoContent)
 	}
 }
+
+// handleBulkPost returns a gorm handler function.
+//
+// @ID users_handleBulkPost
+// @Tags Users
+// @Summary Apply an action to all selected users.
+// @Description Selected users can be enabled, disabled or deleted. In dry-run mode, nothing is changed and the
+// @Description result lists the users that would be changed. A single audit entry is recorded.
+// @Param request body models.UserBulkRequest true "The selector and the action."
+// @Produce json
+// @Success 200 {object} models.BulkResult
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /user/bulk [post]
+// @Security BasicAuth
+func (e UserEndpoint) handleBulkPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.UserBulkRequest
+		if err := request.BodyJson(r, &req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if err := e.validator.Struct(req); err != nil {
+			respond.JSON(w, http.StatusBadRequest, models.Error{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+
+		result, err := e.users.Bulk(r.Context(), models.NewDomainUserBulkRequest(&req))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewBulkResult(result))
+	}
+}
+
+// handleUnlockPost returns a gorm handler function.
+//
+// @ID users_handleUnlockPost
+// @Tags Users
+// @Summary Unlock the user record.
+// @Description Removes the lock of the user, including temporary locks after too many failed logins, and resets
+// @Description the failed logins of the account.
+// @Param id path string true "The user identifier."
+// @Produce json
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /user/by-id/{id}/unlock [post]
+// @Security BasicAuth
+func (e UserEndpoint) handleUnlockPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := request.Path(r, "id")
+		if id == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing user id"})
+			return
+		}
+
+		user, err := e.users.Unlock(r.Context(), domain.UserIdentifier(id))
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewUser(user, false))
+	}
+}
+
+// handleLoginBlocksGet returns a gorm handler function.
+//
+// @ID users_handleLoginBlocksGet
+// @Tags Users
+// @Summary Get all accounts and source addresses that are blocked after too many failed logins.
+// @Produce json
+// @Success 200 {object} []models.LoginBlock
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /user/login-blocks [get]
+// @Security BasicAuth
+func (e UserEndpoint) handleLoginBlocksGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		blocks, err := e.users.GetLoginBlocks(r.Context())
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, models.NewLoginBlocks(blocks))
+	}
+}
+
+// handleLoginBlockDelete returns a gorm handler function.
+//
+// @ID users_handleLoginBlockDelete
+// @Tags Users
+// @Summary Remove the block of a source address.
+// @Param source path string true "The blocked source address."
+// @Produce json
+// @Success 204 "No content if the block was removed successfully."
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /user/login-blocks/{source} [delete]
+// @Security BasicAuth
+func (e UserEndpoint) handleLoginBlockDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		source := request.Path(r, "source")
+		if source == "" {
+			respond.JSON(w, http.StatusBadRequest,
+				models.Error{Code: http.StatusBadRequest, Message: "missing source address"})
+			return
+		}
+
+		err := e.users.DeleteLoginBlock(r.Context(), source)
+		if err != nil {
+			status, model := ParseServiceError(err)
+			respond.JSON(w, status, model)
+			return
+		}
+
+		respond.Status(w, http.StatusNoContent)
+	}
+}
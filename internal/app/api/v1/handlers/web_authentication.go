@@ -2,74 +2,136 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strings"
 
+	"github.com/h44z/wg-portal/internal/app/api/core/request"
 	"github.com/h44z/wg-portal/internal/app/api/core/respond"
 	"github.com/h44z/wg-portal/internal/app/api/v0/model"
+	"github.com/h44z/wg-portal/internal/config"
 	"github.com/h44z/wg-portal/internal/domain"
 )
 
 type Scope string
 
 const (
-	ScopeAdmin Scope = "ADMIN" // Admin scope contains all other scopes
+	ScopeAdmin Scope = "ADMIN" // Admin scope requires an admin user or roles that grant all other scopes
+
+	// Token scopes limit the access of named API tokens, the legacy user API token grants all of them.
+
+	ScopePeersRead       = Scope(domain.ApiTokenScopePeersRead)
+	ScopePeersManage     = Scope(domain.ApiTokenScopePeersManage)
+	ScopePeersWrite      = Scope(domain.ApiTokenScopePeersWrite)
+	ScopeInterfacesRead  = Scope(domain.ApiTokenScopeInterfacesRead)
+	ScopeInterfacesWrite = Scope(domain.ApiTokenScopeInterfacesWrite)
+	ScopeUsersRead       = Scope(domain.ApiTokenScopeUsersRead)
+	ScopeUsersWrite      = Scope(domain.ApiTokenScopeUsersWrite)
+	ScopeProvisioning    = Scope(domain.ApiTokenScopeProvisioning)
+	ScopeMetrics         = Scope(domain.ApiTokenScopeMetrics)
+	ScopeSpec            = Scope(domain.ApiTokenScopeSpec)
+	ScopeTokens          = Scope(domain.ApiTokenScopeTokens)
 )
 
 type UserAuthenticator interface {
-	GetUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
+	// AuthenticateApiToken checks the API token of the user. For named tokens, the token is returned as well.
+	AuthenticateApiToken(ctx context.Context, id domain.UserIdentifier, token, sourceAddr string) (
+		*domain.User,
+		*domain.ApiToken,
+		error,
+	)
+}
+
+type BearerAuthenticator interface {
+	// AuthenticateBearerToken validates a bearer JWT of an OIDC provider. The scopes of the token are returned as
+	// an unsaved API token.
+	AuthenticateBearerToken(ctx context.Context, rawToken, sourceAddr string) (
+		*domain.User,
+		*domain.ApiToken,
+		error,
+	)
 }
 
 type AuthenticationHandler struct {
-	authenticator UserAuthenticator
+	cfg                 *config.Config
+	authenticator       UserAuthenticator
+	bearerAuthenticator BearerAuthenticator
 }
 
-func NewAuthenticationHandler(authenticator UserAuthenticator) AuthenticationHandler {
+func NewAuthenticationHandler(
+	cfg *config.Config,
+	authenticator UserAuthenticator,
+	bearerAuthenticator BearerAuthenticator,
+) AuthenticationHandler {
 	return AuthenticationHandler{
-		authenticator: authenticator,
+		cfg:                 cfg,
+		authenticator:       authenticator,
+		bearerAuthenticator: bearerAuthenticator,
 	}
 }
 
+// authContextKey stores the authenticated user and token, so that nested middlewares only check the scopes.
+type authContextKey struct{}
+
+type authInfo struct {
+	user  *domain.User
+	token *domain.ApiToken // nil for the legacy user API token, unsaved for bearer tokens
+}
+
 // LoggedIn checks if a user is logged in. If scopes are given, they are validated as well.
 func (h AuthenticationHandler) LoggedIn(scopes ...Scope) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			username, password, ok := r.BasicAuth()
-			if !ok || username == "" || password == "" {
-				// Abort the request with the appropriate error code
-				respond.JSON(w, http.StatusUnauthorized,
-					model.Error{Code: http.StatusUnauthorized, Message: "missing credentials"})
-				return
-			}
-
-			// check if user exists in DB
-
-			ctx := domain.SetUserInfo(r.Context(), domain.SystemAdminContextUserInfo())
-			user, err := h.authenticator.GetUser(ctx, domain.UserIdentifier(username))
-			if err != nil {
-				// Abort the request with the appropriate error code
-				respond.JSON(w, http.StatusUnauthorized,
-					model.Error{Code: http.StatusUnauthorized, Message: "invalid credentials"})
-				return
-			}
-
-			// validate API token
-			if err := user.CheckApiToken(password); err != nil {
-				// Abort the request with the appropriate error code
-				respond.JSON(w, http.StatusUnauthorized,
-					model.Error{Code: http.StatusUnauthorized, Message: "invalid credentials"})
-				return
+			auth, ok := r.Context().Value(authContextKey{}).(authInfo)
+			if !ok {
+				var user *domain.User
+				var token *domain.ApiToken
+				var err error
+
+				ctx := domain.SetUserInfo(r.Context(), domain.SystemAdminContextUserInfo())
+				clientIp := request.ClientIp(r, h.cfg.Web.TrustedProxies...)
+				if bearer, found := bearerToken(r); found {
+					// validate the JWT against the OIDC providers and map its claims to a user
+					user, token, err = h.bearerAuthenticator.AuthenticateBearerToken(ctx, bearer, clientIp)
+				} else {
+					username, password, ok := r.BasicAuth()
+					if !ok || username == "" || password == "" {
+						// Abort the request with the appropriate error code
+						respond.JSON(w, http.StatusUnauthorized,
+							model.Error{Code: http.StatusUnauthorized, Message: "missing credentials"})
+						return
+					}
+
+					// check if user exists in DB and validate API token
+					user, token, err = h.authenticator.AuthenticateApiToken(ctx, domain.UserIdentifier(username),
+						password, clientIp)
+				}
+				if errors.Is(err, domain.ErrLoginThrottled) {
+					respond.JSON(w, http.StatusTooManyRequests,
+						model.Error{Code: http.StatusTooManyRequests, Message: "too many failed login attempts"})
+					return
+				}
+				if err != nil {
+					// Abort the request with the appropriate error code
+					respond.JSON(w, http.StatusUnauthorized,
+						model.Error{Code: http.StatusUnauthorized, Message: "invalid credentials"})
+					return
+				}
+				auth = authInfo{user: user, token: token}
 			}
 
-			if !UserHasScopes(user, scopes...) {
+			if !UserHasScopes(auth.user, auth.token, scopes...) {
 				// Abort the request with the appropriate error code
 				respond.JSON(w, http.StatusForbidden,
 					model.Error{Code: http.StatusForbidden, Message: "not enough permissions"})
 				return
 			}
 
-			ctx = context.WithValue(r.Context(), domain.CtxUserInfo, &domain.ContextUserInfo{
-				Id:      user.Identifier,
-				IsAdmin: user.IsAdmin,
+			ctx := context.WithValue(r.Context(), authContextKey{}, auth)
+			ctx = context.WithValue(ctx, domain.CtxUserInfo, &domain.ContextUserInfo{
+				Id:      auth.user.Identifier,
+				IsAdmin: auth.user.IsAdmin,
+				Roles:   auth.user.Roles,
 			})
 			r = r.WithContext(ctx)
 
@@ -79,23 +141,49 @@ func (h AuthenticationHandler) LoggedIn(scopes ...Scope) func(next http.Handler)
 	}
 }
 
-func UserHasScopes(user *domain.User, scopes ...Scope) bool {
-	// No scopes give, so the check should succeed
-	if len(scopes) == 0 {
-		return true
+// bearerToken returns the token of a bearer authorization header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+
+	return token, token != ""
+}
+
+// UserHasScopes checks the given scopes. All scopes except the admin scope must be granted by the named API token.
+// If no named token is used, all other scopes are granted. The admin scope requires an admin user, or a user whose
+// roles grant the permissions of all other given scopes. The backend services validate the permissions of roles
+// for the specific interfaces.
+func UserHasScopes(user *domain.User, token *domain.ApiToken, scopes ...Scope) bool {
+	adminRequired := false
+	for _, scope := range scopes {
+		if scope == ScopeAdmin {
+			adminRequired = true
+			continue
+		}
+
+		if token != nil && !token.HasScope(domain.ApiTokenScope(scope)) {
+			return false
+		}
 	}
 
-	// check if user has admin scope
-	if user.IsAdmin {
+	if !adminRequired || user.IsAdmin {
 		return true
 	}
 
-	// Check if admin scope is required
+	// without an admin user, all other scopes must be granted by the roles of the user
+	granted := false
 	for _, scope := range scopes {
 		if scope == ScopeAdmin {
+			continue
+		}
+		if !domain.RolesHavePermission(user.Roles, domain.Permission(scope), "") {
 			return false
 		}
+		granted = true
 	}
 
-	return true
+	return granted
 }
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// cidrAuthenticator accepts a named token that is limited to a single source network.
+type cidrAuthenticator struct{}
+
+func (cidrAuthenticator) AuthenticateApiToken(_ context.Context, id domain.UserIdentifier, _, sourceAddr string) (
+	*domain.User,
+	*domain.ApiToken,
+	error,
+) {
+	token := &domain.ApiToken{AllowedCidrs: []string{"203.0.113.0/24"}}
+	if err := token.CheckUsable(sourceAddr); err != nil {
+		return nil, nil, err
+	}
+	return &domain.User{Identifier: id}, token, nil
+}
+
+func TestLoggedIn_allowedCidrsBehindProxy(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		want           int
+	}{
+		{"trusted proxy", []string{"10.0.0.1"}, "10.0.0.1:4242", http.StatusOK},
+		{"private proxies", []string{"PRIVATE"}, "10.0.0.1:4242", http.StatusOK},
+		{"untrusted proxy", nil, "10.0.0.1:4242", http.StatusUnauthorized},
+		{"other proxy", []string{"10.0.0.2"}, "10.0.0.1:4242", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Web.TrustedProxies = tt.trustedProxies
+			auth := NewAuthenticationHandler(cfg, cidrAuthenticator{}, nil)
+			handler := auth.LoggedIn()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			req.SetBasicAuth("user", "token")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestUserHasScopes(t *testing.T) {
+	admin := &domain.User{IsAdmin: true}
+	auditor := &domain.User{Roles: []domain.Role{domain.RoleAuditor}}
+	operator := &domain.User{Roles: []domain.Role{domain.OperatorRole("wg0")}}
+	helpdesk := &domain.User{Roles: []domain.Role{domain.RoleHelpdesk}}
+	user := &domain.User{}
+	readToken := &domain.ApiToken{Scopes: []domain.ApiTokenScope{domain.ApiTokenScopeInterfacesRead}}
+
+	tests := []struct {
+		name   string
+		user   *domain.User
+		token  *domain.ApiToken
+		scopes []Scope
+		want   bool
+	}{
+		{"admin", admin, nil, []Scope{ScopeAdmin, ScopeInterfacesWrite}, true},
+		{"admin token without scope", admin, readToken, []Scope{ScopeAdmin, ScopeInterfacesWrite}, false},
+		{"auditor read", auditor, nil, []Scope{ScopeAdmin, ScopeInterfacesRead}, true},
+		{"auditor write", auditor, nil, []Scope{ScopeAdmin, ScopeInterfacesWrite}, false},
+		{"operator write", operator, nil, []Scope{ScopeAdmin, ScopePeersWrite}, true},
+		{"helpdesk manage", helpdesk, nil, []Scope{ScopeAdmin, ScopePeersManage}, true},
+		{"helpdesk write", helpdesk, nil, []Scope{ScopeAdmin, ScopePeersWrite}, false},
+		{"operator token without scope", operator, readToken, []Scope{ScopeAdmin, ScopePeersWrite}, false},
+		{"role without other scopes", auditor, nil, []Scope{ScopeAdmin}, false},
+		{"role with token-only scope", auditor, nil, []Scope{ScopeAdmin, ScopeSpec}, false},
+		{"user", user, nil, []Scope{ScopeAdmin, ScopeInterfacesRead}, false},
+		{"user without admin scope", user, nil, []Scope{ScopePeersRead}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, UserHasScopes(tt.user, tt.token, tt.scopes...))
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// ApiToken represents a named REST API token of a user.
+type ApiToken struct {
+	// Identifier is the unique identifier of the token.
+	Identifier string `json:"Identifier" example:"4b8a7ec2d1a94f0c9f6e25a1b7c3d8e0"`
+	// UserIdentifier is the identifier of the user that owns the token.
+	UserIdentifier string `json:"UserIdentifier" example:"uid-1234567"`
+	// Name is the display name of the token.
+	Name string `json:"Name" example:"ci-pipeline"`
+	// Token is the secret token, it is used as password for the HTTP basic authentication.
+	// It is only returned once, directly after the token has been created.
+	Token string `json:"Token,omitempty" example:"wgp_4b8a7ec2d1a94f0c9f6e25a1b7c3d8e0_secret"`
+	// Scopes are the scopes that are granted by the token.
+	Scopes []string `json:"Scopes" example:"peers:read"`
+	// AllowedCidrs restricts the token to the given source networks. If empty, all sources are allowed.
+	AllowedCidrs []string `json:"AllowedCidrs" example:"10.0.0.0/24"`
+	// CreatedAt is the creation timestamp of the token.
+	CreatedAt time.Time `json:"CreatedAt"`
+	// ExpiresAt is the timestamp after which the token can no longer be used.
+	ExpiresAt *time.Time `json:"ExpiresAt,omitempty"`
+	// LastUsedAt is the timestamp of the last usage. It is updated at most once per minute.
+	LastUsedAt *time.Time `json:"LastUsedAt,omitempty"`
+	// Expired is a flag that specifies if the token has expired.
+	Expired bool `json:"Expired" example:"false"`
+}
+
+func NewApiToken(src *domain.ApiToken) *ApiToken {
+	scopes := make([]string, len(src.Scopes))
+	for i, scope := range src.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	return &ApiToken{
+		Identifier:     string(src.Identifier),
+		UserIdentifier: string(src.UserIdentifier),
+		Name:           src.Name,
+		Token:          src.Token,
+		Scopes:         scopes,
+		AllowedCidrs:   src.AllowedCidrs,
+		CreatedAt:      src.CreatedAt,
+		ExpiresAt:      src.ExpiresAt,
+		LastUsedAt:     src.LastUsedAt,
+		Expired:        src.IsExpired(),
+	}
+}
+
+func NewApiTokens(src []domain.ApiToken) []ApiToken {
+	results := make([]ApiToken, len(src))
+	for i := range src {
+		results[i] = *NewApiToken(&src[i])
+	}
+
+	return results
+}
+
+// ApiTokenRequest contains the parameters for a new API token.
+type ApiTokenRequest struct {
+	// Name is the display name of the token.
+	Name string `json:"Name" binding:"required" example:"ci-pipeline"`
+	// Scopes are the scopes that are granted by the token. A write scope includes the matching read scope.
+	Scopes []string `json:"Scopes" binding:"required" example:"peers:read"`
+	// AllowedCidrs restricts the token to the given source networks. If empty, all sources are allowed.
+	AllowedCidrs []string `json:"AllowedCidrs" binding:"omitempty,dive,cidr" example:"10.0.0.0/24"`
+	// ExpiresAt is the timestamp after which the token can no longer be used. If empty, the token does not expire.
+	ExpiresAt *time.Time `json:"ExpiresAt,omitempty"`
+}
+
+func NewDomainApiToken(src *ApiTokenRequest) *domain.ApiToken {
+	scopes := make([]domain.ApiTokenScope, len(src.Scopes))
+	for i, scope := range src.Scopes {
+		scopes[i] = domain.ApiTokenScope(scope)
+	}
+
+	return &domain.ApiToken{
+		Name:         src.Name,
+		Scopes:       scopes,
+		AllowedCidrs: src.AllowedCidrs,
+		ExpiresAt:    src.ExpiresAt,
+	}
+}
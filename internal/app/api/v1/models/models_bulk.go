@@ -0,0 +1,153 @@
+package models
+
+import (
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// PeerSelector selects the peers of a bulk action. All given criteria must match, at least one criterion is required.
+type PeerSelector struct {
+	// Identifiers is a list of peer identifiers (public keys).
+	Identifiers []string `json:"Identifiers" example:"xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="`
+	// Interface selects the peers of the given interface.
+	Interface string `json:"Interface" example:"wg0"`
+	// User selects the peers of the given user.
+	User string `json:"User" example:"uid-1234567"`
+	// Tag selects the peers with the given tag.
+	Tag string `json:"Tag" example:"contractors"`
+	// Search is a search expression. Terms are separated by whitespace and matched case-insensitively.
+	// A term of the form key:value only matches the given field (id, name, user, iface, tag, addr or notes),
+	// terms prefixed with '-' must not match.
+	Search string `json:"Search" example:"tag:contractors -name:laptop"`
+}
+
+// PeerBulkRequest describes a bulk action for peers.
+type PeerBulkRequest struct {
+	// Selector selects the peers.
+	Selector PeerSelector `json:"Selector"`
+	// Action is the action that is applied to all selected peers.
+	Action string `json:"Action" binding:"required,oneof=enable disable delete set-expiry move" example:"disable"`
+	// Reason is the reason for disabled peers.
+	Reason string `json:"Reason" example:"contract ended"`
+	// ExpiresAt is the new expiry date in YYYY-MM-DD format for the set-expiry action. Empty removes the expiry date.
+	ExpiresAt string `json:"ExpiresAt,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	// Target is the target interface for the move action.
+	Target string `json:"Target,omitempty" binding:"required_if=Action move" example:"wg1"`
+	// DryRun only reports the peers that would be changed.
+	DryRun bool `json:"DryRun" example:"true"`
+}
+
+func NewDomainPeerBulkRequest(src *PeerBulkRequest) *domain.PeerBulkRequest {
+	var expiresAt *time.Time
+	if src.ExpiresAt != "" {
+		if t, err := time.Parse(ExpiryDateTimeLayout, src.ExpiresAt); err == nil {
+			expiresAt = &t
+		}
+	}
+
+	identifiers := make([]domain.PeerIdentifier, len(src.Selector.Identifiers))
+	for i, id := range src.Selector.Identifiers {
+		identifiers[i] = domain.PeerIdentifier(id)
+	}
+
+	return &domain.PeerBulkRequest{
+		Selector: domain.PeerSelector{
+			Identifiers: identifiers,
+			Interface:   domain.InterfaceIdentifier(src.Selector.Interface),
+			User:        domain.UserIdentifier(src.Selector.User),
+			Tag:         src.Selector.Tag,
+			Search:      src.Selector.Search,
+		},
+		Action:    domain.BulkAction(src.Action),
+		Reason:    src.Reason,
+		ExpiresAt: expiresAt,
+		Target:    domain.InterfaceIdentifier(src.Target),
+		DryRun:    src.DryRun,
+	}
+}
+
+// UserSelector selects the users of a bulk action. All given criteria must match, at least one criterion is required.
+type UserSelector struct {
+	// Identifiers is a list of user identifiers.
+	Identifiers []string `json:"Identifiers" example:"uid-1234567"`
+	// Search is a search expression. Terms are separated by whitespace and matched case-insensitively.
+	// A term of the form key:value only matches the given field (id, email, name, department, source or notes),
+	// terms prefixed with '-' must not match.
+	Search string `json:"Search" example:"department:sales source:ldap"`
+}
+
+// UserBulkRequest describes a bulk action for users.
+type UserBulkRequest struct {
+	// Selector selects the users.
+	Selector UserSelector `json:"Selector"`
+	// Action is the action that is applied to all selected users.
+	Action string `json:"Action" binding:"required,oneof=enable disable delete" example:"disable"`
+	// Reason is the reason for disabled users.
+	Reason string `json:"Reason" example:"left the company"`
+	// DryRun only reports the users that would be changed.
+	DryRun bool `json:"DryRun" example:"true"`
+}
+
+func NewDomainUserBulkRequest(src *UserBulkRequest) *domain.UserBulkRequest {
+	identifiers := make([]domain.UserIdentifier, len(src.Selector.Identifiers))
+	for i, id := range src.Selector.Identifiers {
+		identifiers[i] = domain.UserIdentifier(id)
+	}
+
+	return &domain.UserBulkRequest{
+		Selector: domain.UserSelector{
+			Identifiers: identifiers,
+			Search:      src.Selector.Search,
+		},
+		Action: domain.BulkAction(src.Action),
+		Reason: src.Reason,
+		DryRun: src.DryRun,
+	}
+}
+
+// BulkResult contains the outcome of a bulk action.
+type BulkResult struct {
+	// Action is the executed action.
+	Action string `json:"Action" example:"disable"`
+	// DryRun is true if no changes were stored.
+	DryRun bool `json:"DryRun" example:"false"`
+	// Changed is the number of changed items.
+	Changed int `json:"Changed" example:"12"`
+	// Unchanged is the number of items that were not changed by the action.
+	Unchanged int `json:"Unchanged" example:"3"`
+	// Failed is the number of items that could not be changed.
+	Failed int `json:"Failed" example:"0"`
+	// Items contains the outcome for each selected item.
+	Items []BulkItemResult `json:"Items"`
+}
+
+// BulkItemResult is the outcome of a bulk action for a single peer or user.
+type BulkItemResult struct {
+	// Identifier is the identifier of the peer or user.
+	Identifier string `json:"Identifier" example:"uid-1234567"`
+	// Status is either changed, unchanged or failed.
+	Status string `json:"Status" example:"changed"`
+	// Error describes why the item could not be changed.
+	Error string `json:"Error,omitempty"`
+}
+
+func NewBulkResult(src *domain.BulkResult) *BulkResult {
+	items := make([]BulkItemResult, len(src.Items))
+	for i, item := range src.Items {
+		items[i] = BulkItemResult{
+			Identifier: item.Identifier,
+			Status:     string(item.Status),
+			Error:      item.Error,
+		}
+	}
+
+	return &BulkResult{
+		Action:    string(src.Action),
+		DryRun:    src.DryRun,
+		Changed:   src.Count(domain.BulkItemChanged),
+		Unchanged: src.Count(domain.BulkItemUnchanged),
+		Failed:    src.Count(domain.BulkItemFailed),
+		Items:     items,
+	}
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// BundleExportRequest controls how an interface bundle is exported.
+type BundleExportRequest struct {
+	// Passphrase encrypts the bundle content. If empty, the bundle is only signed.
+	Passphrase string `json:"Passphrase" example:"a long secret passphrase"`
+	// Format is the file format of the bundle, either json or yaml.
+	Format string `json:"Format" binding:"omitempty,oneof=json yaml" example:"yaml"`
+}
+
+// BundleImportRequest contains an interface bundle and controls how conflicts with existing records are handled.
+type BundleImportRequest struct {
+	// Bundle is the content of the bundle file, in JSON or YAML format.
+	Bundle string `json:"Bundle" binding:"required"`
+	// Passphrase decrypts encrypted bundles.
+	Passphrase string `json:"Passphrase" example:"a long secret passphrase"`
+	// Interface imports the interface with a different identifier.
+	Interface string `json:"Interface" example:"wg1"`
+	// ListenPort overrides the listen port of the interface.
+	ListenPort int `json:"ListenPort" binding:"omitempty,min=1,max=65535" example:"51821"`
+	// Conflicts defines how existing peers and users are handled: fail, skip or overwrite.
+	// An existing interface with the same identifier is only replaced with overwrite.
+	Conflicts string `json:"Conflicts" binding:"omitempty,oneof=fail skip overwrite" example:"skip"`
+	// AllowOverlap allows networks that overlap with the networks of other interfaces.
+	AllowOverlap bool `json:"AllowOverlap" example:"false"`
+	// DryRun only checks for conflicts, nothing is stored.
+	DryRun bool `json:"DryRun" example:"true"`
+}
+
+func NewDomainBundleImportOptions(src *BundleImportRequest) domain.BundleImportOptions {
+	return domain.BundleImportOptions{
+		Interface:    domain.InterfaceIdentifier(src.Interface),
+		ListenPort:   src.ListenPort,
+		Conflicts:    domain.BundleConflictStrategy(src.Conflicts),
+		AllowOverlap: src.AllowOverlap,
+		DryRun:       src.DryRun,
+	}
+}
+
+// BundleImportResult summarizes an import.
+type BundleImportResult struct {
+	// Interface is the identifier of the imported interface.
+	Interface string `json:"Interface" example:"wg1"`
+	// DryRun is true if nothing was stored.
+	DryRun bool `json:"DryRun" example:"false"`
+	// CreatedPeers are the identifiers of new peers.
+	CreatedPeers []string `json:"CreatedPeers"`
+	// OverwrittenPeers are the identifiers of existing peers that were replaced.
+	OverwrittenPeers []string `json:"OverwrittenPeers"`
+	// SkippedPeers are the identifiers of existing peers that were kept.
+	SkippedPeers []string `json:"SkippedPeers"`
+	// CreatedUsers are the identifiers of new users.
+	CreatedUsers []string `json:"CreatedUsers"`
+	// OverwrittenUsers are the identifiers of existing users that were replaced.
+	OverwrittenUsers []string `json:"OverwrittenUsers"`
+	// SkippedUsers are the identifiers of existing users that were kept.
+	SkippedUsers []string `json:"SkippedUsers"`
+}
+
+func NewBundleImportResult(src *domain.BundleImportResult) *BundleImportResult {
+	return &BundleImportResult{
+		Interface:        string(src.Interface),
+		DryRun:           src.DryRun,
+		CreatedPeers:     identifierStrings(src.CreatedPeers),
+		OverwrittenPeers: identifierStrings(src.OverwrittenPeers),
+		SkippedPeers:     identifierStrings(src.SkippedPeers),
+		CreatedUsers:     identifierStrings(src.CreatedUsers),
+		OverwrittenUsers: identifierStrings(src.OverwrittenUsers),
+		SkippedUsers:     identifierStrings(src.SkippedUsers),
+	}
+}
+
+func identifierStrings[T ~string](src []T) []string {
+	results := make([]string, len(src))
+	for i := range src {
+		results[i] = string(src[i])
+	}
+
+	return results
+}
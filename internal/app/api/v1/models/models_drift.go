@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// InterfaceDrift contains all differences between the database and the system state of an interface.
+type InterfaceDrift struct {
+	// InterfaceIdentifier is the identifier of the interface.
+	InterfaceIdentifier string `json:"InterfaceIdentifier" example:"wg0"`
+	// Mode is the drift mode of the interface, either 'alert', 'correct' or 'import'.
+	Mode string `json:"Mode" example:"alert"`
+	// CheckedAt is the timestamp of the drift check.
+	CheckedAt time.Time `json:"CheckedAt"`
+	// InSync is true if the database and the system state are equal.
+	InSync bool `json:"InSync" example:"false"`
+	// Items is the list of differences.
+	Items []DriftItem `json:"Items"`
+}
+
+// DriftItem describes a single difference between the database and the system state.
+type DriftItem struct {
+	// Type is the kind of difference, one of: 'interface-missing', 'interface-mismatch', 'peer-missing', 'peer-unexpected' or 'peer-mismatch'.
+	Type string `json:"Type" example:"peer-mismatch"`
+	// PeerIdentifier is the identifier of the affected peer. It is empty for interface related differences.
+	PeerIdentifier string `json:"PeerIdentifier,omitempty" example:"xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="`
+	// Field is the name of the differing setting. It is only set for mismatches.
+	Field string `json:"Field,omitempty" example:"AllowedIPs"`
+	// Expected is the value stored in the database.
+	Expected string `json:"Expected,omitempty" example:"10.11.12.2/32"`
+	// Actual is the value found on the system.
+	Actual string `json:"Actual,omitempty" example:"10.11.12.2/32,192.168.1.0/24"`
+}
+
+func NewInterfaceDrift(src domain.InterfaceDrift) InterfaceDrift {
+	items := make([]DriftItem, len(src.Items))
+	for i, item := range src.Items {
+		items[i] = DriftItem{
+			Type:           string(item.Type),
+			PeerIdentifier: string(item.PeerIdentifier),
+			Field:          item.Field,
+			Expected:       item.Expected,
+			Actual:         item.Actual,
+		}
+	}
+
+	return InterfaceDrift{
+		InterfaceIdentifier: string(src.InterfaceIdentifier),
+		Mode:                string(src.Mode),
+		CheckedAt:           src.CheckedAt,
+		InSync:              !src.HasDrift(),
+		Items:               items,
+	}
+}
+
+func NewInterfaceDrifts(src []domain.InterfaceDrift) []InterfaceDrift {
+	results := make([]InterfaceDrift, len(src))
+	for i := range src {
+		results[i] = NewInterfaceDrift(src[i])
+	}
+
+	return results
+}
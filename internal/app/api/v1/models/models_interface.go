@@ -15,6 +15,14 @@ type Interface struct {
 	DisplayName string `json:"DisplayName" binding:"omitempty,max=64" example:"My Interface"`
 	// Mode is the interface type, either 'server', 'client' or 'any'. The mode specifies how WireGuard Portal handles peers for this interface.
 	Mode string `json:"Mode" example:"server" binding:"required,oneof=server client any"`
+	// DriverType is the interface driver type. Either 'linux' (kernel module) or 'software' (userspace implementation). If empty, the kernel module is used.
+	DriverType string `json:"DriverType" example:"linux"`
+	// AgentHost is the name of the agent host that manages the interface. If empty, the interface is managed on the portal host. The host can not be changed after creation.
+	AgentHost string `json:"AgentHost" example:"gateway-1"`
+	// DriftMode specifies how differences between the database and the system state are handled. Either 'alert' (only report, default), 'correct' (restore the database state) or 'import' (update the database).
+	DriftMode string `json:"DriftMode" binding:"omitempty,oneof=alert correct import" example:"alert"`
+	// ManagedBy is set if the interface is managed by the declarative spec. Managed interfaces are read-only.
+	ManagedBy string `json:"ManagedBy" readonly:"true" example:""`
 	// PrivateKey is the private key of the interface.
 	PrivateKey string `json:"PrivateKey" example:"gI6EdUSYvn8ugXOt8QQD6Yc+JyiZxIhp3GInSWRfWGE=" binding:"required,len=44"`
 	// PublicKey is the public key of the server interface. The public key is used by peers to connect to the server.
@@ -78,6 +86,15 @@ type Interface struct {
 	// PeerDefPostDown specifies the default action that is executed after the device is down for a new peer.
 	PeerDefPostDown string `json:"PeerDefPostDown"`
 
+	// PeerDefQuota is the default traffic quota for peers that have no own quota and whose user has no quota.
+	PeerDefQuota TrafficQuota `json:"PeerDefQuota"`
+
+	// PeerRenewalPolicy defines how users can extend the expiry date of their own peers.
+	PeerRenewalPolicy PeerRenewalPolicy `json:"PeerRenewalPolicy"`
+
+	// PeerDefIpv6 defines how IPv6 addresses and prefixes are assigned to new peers.
+	PeerDefIpv6 PeerIpv6Settings `json:"PeerDefIpv6"`
+
 	// Calculated values
 
 	// EnabledPeers is the number of enabled peers for this interface. Only enabled peers are able to connect.
@@ -94,6 +111,10 @@ func NewInterface(src *domain.Interface, peers []domain.Peer) *Interface {
 		Identifier:                 string(src.Identifier),
 		DisplayName:                src.DisplayName,
 		Mode:                       string(src.Type),
+		DriverType:                 src.DriverType,
+		AgentHost:                  src.AgentHost,
+		DriftMode:                  string(src.GetDriftMode()),
+		ManagedBy:                  src.ManagedBy,
 		PrivateKey:                 src.PrivateKey,
 		PublicKey:                  src.PublicKey,
 		Disabled:                   src.IsDisabled(),
@@ -123,6 +144,9 @@ func NewInterface(src *domain.Interface, peers []domain.Peer) *Interface {
 		PeerDefPostUp:              src.PeerDefPostUp,
 		PeerDefPreDown:             src.PeerDefPreDown,
 		PeerDefPostDown:            src.PeerDefPostDown,
+		PeerDefQuota:               NewTrafficQuota(src.PeerDefQuota),
+		PeerRenewalPolicy:          NewPeerRenewalPolicy(src.PeerRenewalPolicy),
+		PeerDefIpv6:                NewPeerIpv6Settings(src.PeerDefIpv6),
 
 		EnabledPeers: 0,
 		TotalPeers:   0,
@@ -179,7 +203,9 @@ func NewDomainInterface(src *Interface) *domain.Interface {
 		SaveConfig:                 src.SaveConfig,
 		DisplayName:                src.DisplayName,
 		Type:                       domain.InterfaceType(src.Mode),
-		DriverType:                 "",  // currently unused
+		DriverType:                 src.DriverType,
+		AgentHost:                  src.AgentHost,
+		DriftMode:                  domain.DriftMode(src.DriftMode),
 		Disabled:                   nil, // set below
 		DisabledReason:             src.DisabledReason,
 		PeerDefNetworkStr:          internal.SliceToString(src.PeerDefNetwork),
@@ -195,6 +221,9 @@ func NewDomainInterface(src *Interface) *domain.Interface {
 		PeerDefPostUp:              src.PeerDefPostUp,
 		PeerDefPreDown:             src.PeerDefPreDown,
 		PeerDefPostDown:            src.PeerDefPostDown,
+		PeerDefQuota:               NewDomainTrafficQuota(src.PeerDefQuota),
+		PeerRenewalPolicy:          NewDomainPeerRenewalPolicy(src.PeerRenewalPolicy),
+		PeerDefIpv6:                NewDomainPeerIpv6Settings(src.PeerDefIpv6),
 	}
 
 	if src.Disabled {
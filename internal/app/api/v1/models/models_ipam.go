@@ -0,0 +1,175 @@
+package models
+
+import (
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// IpRange is an address pool or an exclusion range of an interface network.
+type IpRange struct {
+	// Identifier is the unique identifier of the range. It is generated on creation.
+	Identifier string `json:"Identifier" readonly:"true" example:"4e7ea7ad-2a6f-4a4c-9d3c-5b0b3e8f8a61"`
+	// InterfaceIdentifier is the identifier of the interface the range belongs to.
+	InterfaceIdentifier string `json:"InterfaceIdentifier" readonly:"true" example:"wg0"`
+	// Type is either pool (addresses for new peers are allocated from the range) or exclusion (addresses are never allocated).
+	Type string `json:"Type" binding:"required,oneof=pool exclusion" example:"pool"`
+	// Name is a description of the range.
+	Name string `json:"Name" example:"Office devices"`
+	// Start is the first address of the range.
+	Start string `json:"Start" binding:"required,ip" example:"10.11.12.100"`
+	// End is the last address of the range.
+	End string `json:"End" binding:"required,ip" example:"10.11.12.199"`
+	// Strategy is the allocation strategy of a pool: sequential (lowest free address), reverse (highest free address),
+	// random or hash (derived from the peer public key).
+	Strategy string `json:"Strategy" binding:"omitempty,oneof=sequential reverse random hash" example:"sequential"`
+	// Priority defines the order of the pools of a network, pools with a lower value are used first.
+	Priority int `json:"Priority" example:"0"`
+}
+
+func NewIpRange(src *domain.IpRange) *IpRange {
+	return &IpRange{
+		Identifier:          string(src.Identifier),
+		InterfaceIdentifier: string(src.InterfaceIdentifier),
+		Type:                string(src.Type),
+		Name:                src.Name,
+		Start:               src.Start,
+		End:                 src.End,
+		Strategy:            string(src.Strategy),
+		Priority:            src.Priority,
+	}
+}
+
+func NewIpRanges(src []domain.IpRange) []IpRange {
+	results := make([]IpRange, len(src))
+	for i := range src {
+		results[i] = *NewIpRange(&src[i])
+	}
+	return results
+}
+
+func NewDomainIpRange(src *IpRange) *domain.IpRange {
+	return &domain.IpRange{
+		Identifier:          domain.IpRangeIdentifier(src.Identifier),
+		InterfaceIdentifier: domain.InterfaceIdentifier(src.InterfaceIdentifier),
+		Type:                domain.IpRangeType(src.Type),
+		Name:                src.Name,
+		Start:               src.Start,
+		End:                 src.End,
+		Strategy:            domain.IpAllocationStrategy(src.Strategy),
+		Priority:            src.Priority,
+	}
+}
+
+// IpReservation reserves a single address of an interface network for a user or a peer.
+type IpReservation struct {
+	// InterfaceIdentifier is the identifier of the interface the reservation belongs to.
+	InterfaceIdentifier string `json:"InterfaceIdentifier" readonly:"true" example:"wg0"`
+	// Address is the reserved host address.
+	Address string `json:"Address" readonly:"true" example:"10.11.12.10"`
+	// UserIdentifier is the user the address is reserved for. New peers of the user get the reserved address.
+	UserIdentifier string `json:"UserIdentifier" example:"uid-1234567"`
+	// PeerIdentifier is the peer the address is reserved for.
+	PeerIdentifier string `json:"PeerIdentifier" example:"xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="`
+	// Description is an optional note.
+	Description string `json:"Description" example:"Printer"`
+}
+
+func NewIpReservation(src *domain.IpReservation) *IpReservation {
+	return &IpReservation{
+		InterfaceIdentifier: string(src.InterfaceIdentifier),
+		Address:             src.Address,
+		UserIdentifier:      string(src.UserIdentifier),
+		PeerIdentifier:      string(src.PeerIdentifier),
+		Description:         src.Description,
+	}
+}
+
+func NewIpReservations(src []domain.IpReservation) []IpReservation {
+	results := make([]IpReservation, len(src))
+	for i := range src {
+		results[i] = *NewIpReservation(&src[i])
+	}
+	return results
+}
+
+func NewDomainIpReservation(src *IpReservation) *domain.IpReservation {
+	return &domain.IpReservation{
+		InterfaceIdentifier: domain.InterfaceIdentifier(src.InterfaceIdentifier),
+		Address:             src.Address,
+		UserIdentifier:      domain.UserIdentifier(src.UserIdentifier),
+		PeerIdentifier:      domain.PeerIdentifier(src.PeerIdentifier),
+		Description:         src.Description,
+	}
+}
+
+// IpPoolUtilization describes the usage of an address pool.
+type IpPoolUtilization struct {
+	// Network is the interface network of the pool.
+	Network string `json:"Network" example:"10.11.12.0/24"`
+	// Pool is the identifier of the pool. It is empty for the default pool that spans the whole network.
+	Pool string `json:"Pool" example:"4e7ea7ad-2a6f-4a4c-9d3c-5b0b3e8f8a61"`
+	// Name is the name of the pool.
+	Name string `json:"Name" example:"Office devices"`
+	// Start is the first address of the pool.
+	Start string `json:"Start" example:"10.11.12.100"`
+	// End is the last address of the pool.
+	End string `json:"End" example:"10.11.12.199"`
+
+	// Size is the number of addresses in the pool.
+	Size uint64 `json:"Size" example:"100"`
+	// Excluded is the number of addresses that are covered by exclusion ranges.
+	Excluded uint64 `json:"Excluded" example:"10"`
+	// Used is the number of addresses that are assigned to peers or interfaces.
+	Used uint64 `json:"Used" example:"42"`
+	// Reserved is the number of reserved addresses that are not assigned yet.
+	Reserved uint64 `json:"Reserved" example:"3"`
+	// Free is the number of addresses that can still be allocated.
+	Free uint64 `json:"Free" example:"45"`
+	// Usage is the ratio of used and reserved addresses to all allocatable addresses (0 to 1).
+	Usage float64 `json:"Usage" example:"0.5"`
+}
+
+func NewIpPoolUtilizations(src []domain.IpPoolUtilization) []IpPoolUtilization {
+	results := make([]IpPoolUtilization, len(src))
+	for i, u := range src {
+		results[i] = IpPoolUtilization{
+			Network:  u.Network,
+			Pool:     string(u.Pool),
+			Name:     u.Name,
+			Start:    u.Start,
+			End:      u.End,
+			Size:     u.Size,
+			Excluded: u.Excluded,
+			Used:     u.Used,
+			Reserved: u.Reserved,
+			Free:     u.Free,
+			Usage:    u.Usage(),
+		}
+	}
+	return results
+}
+
+// PeerIpv6Settings define how IPv6 addresses and prefixes are assigned to new peers of an interface.
+type PeerIpv6Settings struct {
+	// HashedAddresses is a flag that specifies if the host part of IPv6 peer addresses is derived from the peer public key.
+	HashedAddresses bool `json:"HashedAddresses" example:"true"`
+	// DelegatedPrefix is the prefix out of which a subnet is delegated to each new peer. Empty to disable delegation.
+	DelegatedPrefix string `json:"DelegatedPrefix" binding:"omitempty,cidrv6" example:"fd00:1::/48"`
+	// DelegatedPrefixLength is the length of the delegated subnets.
+	DelegatedPrefixLength int `json:"DelegatedPrefixLength" binding:"omitempty,min=1,max=128" example:"64"`
+}
+
+func NewPeerIpv6Settings(src domain.PeerIpv6Settings) PeerIpv6Settings {
+	return PeerIpv6Settings{
+		HashedAddresses:       src.HashedAddresses,
+		DelegatedPrefix:       src.DelegatedPrefix,
+		DelegatedPrefixLength: src.DelegatedPrefixLength,
+	}
+}
+
+func NewDomainPeerIpv6Settings(src PeerIpv6Settings) domain.PeerIpv6Settings {
+	return domain.PeerIpv6Settings{
+		HashedAddresses:       src.HashedAddresses,
+		DelegatedPrefix:       src.DelegatedPrefix,
+		DelegatedPrefixLength: src.DelegatedPrefixLength,
+	}
+}
@@ -27,6 +27,18 @@ type PeerMetrics struct {
 	Endpoint string `json:"Endpoint" example:"12.34.56.78"`
 	// The last time the peer initiated a session.
 	LastSessionStart *time.Time `json:"LastSessionStart" example:"2021-01-01T12:00:00Z"`
+
+	// The traffic limit of the current quota period in bytes. A limit of 0 means that no quota applies.
+	QuotaLimit uint64 `json:"QuotaLimit" example:"10737418240"`
+	// The traffic that counted towards the quota in the current period.
+	QuotaUsed uint64 `json:"QuotaUsed" example:"123456789"`
+	// The start of the current quota period.
+	QuotaPeriodStart *time.Time `json:"QuotaPeriodStart,omitempty" example:"2021-01-01T00:00:00Z"`
+	// If this field is set, the peer has exceeded its quota.
+	QuotaExceeded bool `json:"QuotaExceeded" example:"false"`
+
+	// The traffic history of the peer. Only set if a time range was requested.
+	History []TrafficDataPoint `json:"History,omitempty"`
 }
 
 func NewPeerMetrics(src *domain.PeerStatus) *PeerMetrics {
@@ -39,6 +51,10 @@ func NewPeerMetrics(src *domain.PeerStatus) *PeerMetrics {
 		LastHandshake:    src.LastHandshake,
 		Endpoint:         src.Endpoint,
 		LastSessionStart: src.LastSessionStart,
+		QuotaLimit:       src.QuotaLimit,
+		QuotaUsed:        src.QuotaUsed(),
+		QuotaPeriodStart: src.QuotaPeriodStart,
+		QuotaExceeded:    src.IsQuotaExceeded(),
 	}
 }
 
@@ -51,6 +67,9 @@ type InterfaceMetrics struct {
 	BytesReceived uint64 `json:"BytesReceived" example:"123456789"`
 	// The number of bytes transmitted by the interface.
 	BytesTransmitted uint64 `json:"BytesTransmitted" example:"123456789"`
+
+	// The traffic history of the interface. Only set if a time range was requested.
+	History []TrafficDataPoint `json:"History,omitempty"`
 }
 
 func NewInterfaceMetrics(src *domain.InterfaceStatus) *InterfaceMetrics {
@@ -103,3 +122,82 @@ func NewUserMetrics(srcUser *domain.User, src []domain.PeerStatus) *UserMetrics
 
 	return um
 }
+
+// TrafficDataPoint represents the traffic of a single time bucket.
+type TrafficDataPoint struct {
+	// The start of the time bucket.
+	Timestamp time.Time `json:"Timestamp" example:"2021-01-01T12:00:00Z"`
+	// The number of bytes received in the time bucket.
+	BytesReceived uint64 `json:"BytesReceived" example:"123456"`
+	// The number of bytes transmitted in the time bucket.
+	BytesTransmitted uint64 `json:"BytesTransmitted" example:"123456"`
+}
+
+func NewTrafficDataPoints(src []domain.TrafficDataPoint) []TrafficDataPoint {
+	results := make([]TrafficDataPoint, len(src))
+	for i := range src {
+		results[i] = TrafficDataPoint{
+			Timestamp:        src[i].Timestamp,
+			BytesReceived:    src[i].BytesReceived,
+			BytesTransmitted: src[i].BytesTransmitted,
+		}
+	}
+
+	return results
+}
+
+// PeerSession represents a single connection session of a WireGuard peer.
+type PeerSession struct {
+	// The unique identifier of the peer.
+	PeerIdentifier string `json:"PeerIdentifier" example:"xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="`
+	// The identifier of the interface the peer was connected to.
+	InterfaceIdentifier string `json:"InterfaceIdentifier" example:"wg0"`
+
+	// The time the session started.
+	StartedAt time.Time `json:"StartedAt" example:"2021-01-01T12:00:00Z"`
+	// The time the disconnect was detected. Not set for active sessions.
+	EndedAt *time.Time `json:"EndedAt" example:"2021-01-01T14:00:00Z"`
+	// The duration of the session in seconds. For active sessions, this is the duration up to now.
+	DurationSeconds int64 `json:"DurationSeconds" example:"7200"`
+	// If this field is set, the session is still active.
+	Active bool `json:"Active" example:"false"`
+
+	// All endpoint addresses of the peer that were seen during the session.
+	Endpoints []string `json:"Endpoints" example:"12.34.56.78:51820"`
+	// The number of bytes received from the peer during the session.
+	BytesReceived uint64 `json:"BytesReceived" example:"123456789"`
+	// The number of bytes transmitted to the peer during the session.
+	BytesTransmitted uint64 `json:"BytesTransmitted" example:"123456789"`
+
+	// The way the disconnect was detected.
+	DisconnectReason string `json:"DisconnectReason" enums:"handshake-timeout,ping-timeout" example:"handshake-timeout"`
+}
+
+func NewPeerSession(src *domain.PeerSession) *PeerSession {
+	endpoints := src.Endpoints
+	if endpoints == nil {
+		endpoints = []string{}
+	}
+
+	return &PeerSession{
+		PeerIdentifier:      string(src.PeerId),
+		InterfaceIdentifier: string(src.InterfaceId),
+		StartedAt:           src.StartedAt,
+		EndedAt:             src.EndedAt,
+		DurationSeconds:     int64(src.Duration().Seconds()),
+		Active:              src.IsActive(),
+		Endpoints:           endpoints,
+		BytesReceived:       src.BytesReceived,
+		BytesTransmitted:    src.BytesTransmitted,
+		DisconnectReason:    string(src.DisconnectReason),
+	}
+}
+
+func NewPeerSessions(src []domain.PeerSession) []PeerSession {
+	results := make([]PeerSession, len(src))
+	for i := range src {
+		results[i] = *NewPeerSession(&src[i])
+	}
+
+	return results
+}
@@ -27,6 +27,16 @@ type Peer struct {
 	ExpiresAt string `json:"ExpiresAt,omitempty" binding:"omitempty,datetime=2006-01-02"`
 	// Notes is a note field for peers.
 	Notes string `json:"Notes" example:"This is a note for the peer."`
+	// Tags are used to group peers. Tags are lower case and may carry a policy.
+	Tags []string `json:"Tags" example:"staff,berlin"`
+	// ManagedBy is set if the peer is managed by the declarative spec. Managed peers are read-only.
+	ManagedBy string `json:"ManagedBy" readonly:"true" example:""`
+	// Quota is the traffic quota of the peer. It takes precedence over the quota of the user and the interface.
+	Quota TrafficQuota `json:"Quota"`
+	// RenewalCount is the number of renewals of the expiry date. It is only changed by renewals.
+	RenewalCount int `json:"RenewalCount" readonly:"true" example:"1"`
+	// RenewalRequested is a flag that specifies if a renewal of the peer is waiting for approval.
+	RenewalRequested bool `json:"RenewalRequested" readonly:"true" example:"false"`
 
 	// Endpoint is the endpoint address of the peer.
 	Endpoint ConfigOption[string] `json:"Endpoint"`
@@ -93,6 +103,11 @@ func NewPeer(src *domain.Peer) *Peer {
 		DisabledReason:      src.DisabledReason,
 		ExpiresAt:           expiresAt,
 		Notes:               src.Notes,
+		Tags:                domain.NormalizeTags(src.Tags),
+		ManagedBy:           src.ManagedBy,
+		Quota:               NewTrafficQuota(src.Quota),
+		RenewalCount:        src.RenewalCount,
+		RenewalRequested:    src.IsRenewalRequested(),
 		Endpoint:            ConfigOptionFromDomain(src.Endpoint),
 		EndpointPublicKey:   ConfigOptionFromDomain(src.EndpointPublicKey),
 		AllowedIPs:          StringSliceConfigOptionFromDomain(src.AllowedIPsStr),
@@ -153,6 +168,8 @@ func NewDomainPeer(src *Peer) *domain.Peer {
 		DisabledReason:      src.DisabledReason,
 		ExpiresAt:           expiresAt,
 		Notes:               src.Notes,
+		Tags:                domain.NormalizeTags(src.Tags),
+		Quota:               NewDomainTrafficQuota(src.Quota),
 		Interface: domain.PeerInterfaceConfig{
 			KeyPair: domain.KeyPair{
 				PrivateKey: src.PrivateKey,
@@ -179,3 +196,9 @@ func NewDomainPeer(src *Peer) *domain.Peer {
 
 	return res
 }
+
+// PeerMoveRequest selects the target interface of a peer move.
+type PeerMoveRequest struct {
+	// Interface is the identifier of the target interface.
+	Interface string `json:"Interface" binding:"required" example:"wg1"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"github.com/h44z/wg-portal/internal"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// PeerTag stores the description and the policy of a tag.
+type PeerTag struct {
+	// Name is the unique name of the tag. Tags are lower case and may contain the characters '_', '.', ':' and '-'.
+	Name string `json:"Name" binding:"required" example:"staff"`
+	// Description is a description of the tag.
+	Description string `json:"Description" example:"All employees"`
+	// Policy contains the overrides for tagged peers.
+	Policy PeerTagPolicy `json:"Policy"`
+}
+
+// PeerTagPolicy contains overrides for tagged peers. The policy is applied on top of the interface defaults
+// when a peer is created, when the tag is added to a peer and when the defaults are applied.
+type PeerTagPolicy struct {
+	// ExpiryDays sets the expiry date of peers without an expiry date. 0 disables the expiry date.
+	ExpiryDays int `json:"ExpiryDays" binding:"omitempty,min=0" example:"90"`
+	// ExtraAllowedIPs are added to the extra allowed ip subnets of the peers.
+	ExtraAllowedIPs []string `json:"ExtraAllowedIPs" binding:"omitempty,dive,cidr" example:"10.1.0.0/24"`
+	// Dns replaces the dns servers of the peers, if they are overridable.
+	Dns []string `json:"Dns" binding:"omitempty,dive,ip" example:"10.0.0.53"`
+	// DnsSearch replaces the dns search options of the peers, if they are overridable.
+	DnsSearch []string `json:"DnsSearch" example:"corp.local"`
+	// Quota is the traffic quota of peers without an own quota.
+	Quota TrafficQuota `json:"Quota"`
+}
+
+func NewPeerTag(src *domain.PeerTag) *PeerTag {
+	return &PeerTag{
+		Name:        src.Name,
+		Description: src.Description,
+		Policy: PeerTagPolicy{
+			ExpiryDays:      src.Policy.ExpiryDays,
+			ExtraAllowedIPs: internal.SliceString(src.Policy.ExtraAllowedIPsStr),
+			Dns:             internal.SliceString(src.Policy.DnsStr),
+			DnsSearch:       internal.SliceString(src.Policy.DnsSearchStr),
+			Quota:           NewTrafficQuota(src.Policy.Quota),
+		},
+	}
+}
+
+func NewPeerTags(src []domain.PeerTag) []PeerTag {
+	results := make([]PeerTag, len(src))
+	for i := range src {
+		results[i] = *NewPeerTag(&src[i])
+	}
+
+	return results
+}
+
+func NewDomainPeerTag(src *PeerTag) *domain.PeerTag {
+	return &domain.PeerTag{
+		Name:        src.Name,
+		Description: src.Description,
+		Policy: domain.PeerTagPolicy{
+			ExpiryDays:         src.Policy.ExpiryDays,
+			ExtraAllowedIPsStr: internal.SliceToString(src.Policy.ExtraAllowedIPs),
+			DnsStr:             internal.SliceToString(src.Policy.Dns),
+			DnsSearchStr:       internal.SliceToString(src.Policy.DnsSearch),
+			Quota:              NewDomainTrafficQuota(src.Policy.Quota),
+		},
+	}
+}
+
+// PeerTagMailRequest selects how the configurations of tagged peers are sent.
+type PeerTagMailRequest struct {
+	// LinkOnly sends a download link instead of attaching the configuration.
+	LinkOnly bool `json:"LinkOnly" example:"false"`
+	// Style is the configuration style: wgquick or raw.
+	Style string `json:"Style" binding:"omitempty,oneof=wgquick raw" example:"wgquick"`
+}
@@ -22,6 +22,10 @@ type UserInformationPeer struct {
 	IpAddresses []string `json:"IpAddresses" example:"10.11.12.2/24"`
 	// IsDisabled is a flag that specifies if the peer is enabled or not. Disabled peers are not able to connect.
 	IsDisabled bool `json:"IsDisabled,omitempty" example:"true"`
+	// ExpiresAt is the expiry date of the peer in YYYY-MM-DD format. An expired peer is not able to connect.
+	ExpiresAt string `json:"ExpiresAt,omitempty" example:"2025-12-31"`
+	// RenewalRequested is a flag that specifies if a renewal of the peer is waiting for approval.
+	RenewalRequested bool `json:"RenewalRequested,omitempty" example:"false"`
 
 	// InterfaceIdentifier is the unique identifier of the WireGuard Portal device the peer is connected to.
 	InterfaceIdentifier string `json:"InterfaceIdentifier" example:"wg0"`
@@ -54,9 +58,14 @@ func NewUserInformationPeer(peer domain.Peer) UserInformationPeer {
 		DisplayName:         peer.DisplayName,
 		IpAddresses:         domain.CidrsToStringSlice(peer.Interface.Addresses),
 		IsDisabled:          peer.IsDisabled(),
+		RenewalRequested:    peer.IsRenewalRequested(),
 		InterfaceIdentifier: string(peer.InterfaceIdentifier),
 	}
 
+	if peer.ExpiresAt != nil && !peer.ExpiresAt.IsZero() {
+		up.ExpiresAt = peer.ExpiresAt.Format(ExpiryDateTimeLayout)
+	}
+
 	return up
 }
 
@@ -73,3 +82,9 @@ type ProvisioningRequest struct {
 	// PresharedKey is the optional pre-shared key of the peer. If no pre-shared key is set, a new key is generated.
 	PresharedKey string `json:"PresharedKey" example:"yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=" binding:"omitempty,len=44"`
 }
+
+// RenewalRequest represents a request to extend the expiry date of a peer.
+type RenewalRequest struct {
+	// PeerIdentifier is the identifier (public key) of the peer that should be renewed.
+	PeerIdentifier string `json:"PeerIdentifier" example:"xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=" binding:"required"`
+}
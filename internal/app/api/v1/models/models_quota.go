@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// TrafficQuota represents a traffic quota for peers.
+type TrafficQuota struct {
+	// Limit is the maximum number of bytes per period. A limit of 0 disables the quota.
+	Limit uint64 `json:"Limit" example:"10737418240"`
+	// Direction specifies the counted traffic: rx (received from the peer), tx (sent to the peer) or total.
+	Direction string `json:"Direction" binding:"omitempty,oneof=rx tx total" example:"total"`
+	// ResetCycle specifies the length of a quota period.
+	ResetCycle string `json:"ResetCycle" binding:"omitempty,oneof=daily weekly monthly custom" example:"monthly"`
+	// ResetAnchor is the start of an arbitrary quota period, all other periods are aligned to it.
+	// If it is not set, periods start at midnight UTC, weeks on monday and months on the first day.
+	ResetAnchor *time.Time `json:"ResetAnchor,omitempty" example:"2025-01-15T00:00:00Z"`
+	// ResetInterval is the length of a custom quota period in days.
+	ResetInterval int `json:"ResetInterval" binding:"omitempty,min=1" example:"14"`
+}
+
+func NewTrafficQuota(src domain.TrafficQuota) TrafficQuota {
+	return TrafficQuota{
+		Limit:         src.Limit,
+		Direction:     string(src.Direction),
+		ResetCycle:    string(src.ResetCycle),
+		ResetAnchor:   src.ResetAnchor,
+		ResetInterval: src.ResetInterval,
+	}
+}
+
+func NewDomainTrafficQuota(src TrafficQuota) domain.TrafficQuota {
+	return domain.TrafficQuota{
+		Limit:         src.Limit,
+		Direction:     domain.QuotaDirection(src.Direction),
+		ResetCycle:    domain.QuotaResetCycle(src.ResetCycle),
+		ResetAnchor:   src.ResetAnchor,
+		ResetInterval: src.ResetInterval,
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// PeerRenewalPolicy defines how users can extend the expiry date of their own peers.
+type PeerRenewalPolicy struct {
+	// Enabled is a flag that specifies if users can renew their peers.
+	Enabled bool `json:"Enabled" example:"true"`
+	// Step is the number of days that are added to the expiry date per renewal.
+	Step int `json:"Step" binding:"omitempty,min=1" example:"30"`
+	// MaxLifetime is the maximum number of days between peer creation and expiry. 0 means unlimited.
+	MaxLifetime int `json:"MaxLifetime" binding:"omitempty,min=0" example:"365"`
+	// MaxRenewals is the maximum number of renewals per peer. 0 means unlimited.
+	MaxRenewals int `json:"MaxRenewals" binding:"omitempty,min=0" example:"6"`
+	// RequireApproval is a flag that specifies if renewals requested by users must be approved by an admin.
+	RequireApproval bool `json:"RequireApproval" example:"false"`
+}
+
+func NewPeerRenewalPolicy(src domain.PeerRenewalPolicy) PeerRenewalPolicy {
+	return PeerRenewalPolicy{
+		Enabled:         src.Enabled,
+		Step:            src.Step,
+		MaxLifetime:     src.MaxLifetime,
+		MaxRenewals:     src.MaxRenewals,
+		RequireApproval: src.RequireApproval,
+	}
+}
+
+func NewDomainPeerRenewalPolicy(src PeerRenewalPolicy) domain.PeerRenewalPolicy {
+	return domain.PeerRenewalPolicy{
+		Enabled:         src.Enabled,
+		Step:            src.Step,
+		MaxLifetime:     src.MaxLifetime,
+		MaxRenewals:     src.MaxRenewals,
+		RequireApproval: src.RequireApproval,
+	}
+}
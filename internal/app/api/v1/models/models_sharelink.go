@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// ShareLink represents a configuration download link for a peer.
+type ShareLink struct {
+	// Identifier is the unique identifier of the share link.
+	Identifier string `json:"Identifier" example:"4b8a7ec2d1a94f0c9f6e25a1b7c3d8e0"`
+	// PeerIdentifier is the identifier of the peer whose configuration is shared.
+	PeerIdentifier string `json:"PeerIdentifier" example:"xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="`
+	// Url is the public download url. It is only returned once, directly after the link has been created.
+	Url string `json:"Url,omitempty" example:"https://wg.example.com/api/v0/share/4b8a7ec2d1a94f0c9f6e25a1b7c3d8e0.secret"`
+	// CreatedBy is the identifier of the user that created the share link.
+	CreatedBy string `json:"CreatedBy" example:"admin"`
+	// CreatedAt is the creation timestamp of the share link.
+	CreatedAt time.Time `json:"CreatedAt"`
+	// ExpiresAt is the timestamp after which the share link can no longer be used.
+	ExpiresAt time.Time `json:"ExpiresAt"`
+	// MaxUses is the maximum number of downloads. 0 means unlimited.
+	MaxUses int `json:"MaxUses" example:"1"`
+	// UseCount is the number of downloads so far.
+	UseCount int `json:"UseCount" example:"0"`
+	// LastUsedAt is the timestamp of the last download.
+	LastUsedAt *time.Time `json:"LastUsedAt,omitempty"`
+	// Revoked is the timestamp when the share link has been revoked.
+	Revoked *time.Time `json:"Revoked,omitempty"`
+	// RevokedBy is the identifier of the user that revoked the share link.
+	RevokedBy string `json:"RevokedBy,omitempty" example:"admin"`
+	// Usable is a flag that specifies if the share link can still be used.
+	Usable bool `json:"Usable" example:"true"`
+}
+
+func NewShareLink(src *domain.PeerShareLink, url string) *ShareLink {
+	return &ShareLink{
+		Identifier:     string(src.Identifier),
+		PeerIdentifier: string(src.PeerIdentifier),
+		Url:            url,
+		CreatedBy:      src.CreatedBy,
+		CreatedAt:      src.CreatedAt,
+		ExpiresAt:      src.ExpiresAt,
+		MaxUses:        src.MaxUses,
+		UseCount:       src.UseCount,
+		LastUsedAt:     src.LastUsedAt,
+		Revoked:        src.Revoked,
+		RevokedBy:      src.RevokedBy,
+		Usable:         src.CheckUsable() == nil,
+	}
+}
+
+func NewShareLinks(src []domain.PeerShareLink) []ShareLink {
+	results := make([]ShareLink, len(src))
+	for i := range src {
+		results[i] = *NewShareLink(&src[i], "")
+	}
+
+	return results
+}
+
+// ShareLinkRequest contains the parameters for a new share link.
+type ShareLinkRequest struct {
+	// ValidHours is the validity of the share link in hours. 0 uses the configured default.
+	ValidHours int `json:"ValidHours" example:"72" binding:"omitempty,min=0"`
+	// MaxUses is the maximum number of downloads. 0 means unlimited, if omitted, the configured default is used.
+	MaxUses *int `json:"MaxUses,omitempty" example:"1" binding:"omitempty,min=0"`
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"github.com/h44z/wg-portal/internal"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// SiteNetwork connects several sites, for example, office locations, with WireGuard tunnels.
+type SiteNetwork struct {
+	// Identifier is the unique identifier of the site network.
+	Identifier string `json:"Identifier" binding:"required" example:"offices"`
+	// DisplayName is a nice display name / description for the site network.
+	DisplayName string `json:"DisplayName" example:"Office network"`
+	// Topology defines which sites are connected: hub-and-spoke, full-mesh or partial-mesh.
+	Topology string `json:"Topology" binding:"required,oneof=hub-and-spoke full-mesh partial-mesh" example:"hub-and-spoke"`
+	// PersistentKeepalive is the keep-alive interval of all tunnels in seconds. 0 disables keep-alive packets.
+	PersistentKeepalive int `json:"PersistentKeepalive" binding:"omitempty,min=0" example:"25"`
+
+	// Sites are all sites of the network.
+	Sites []Site `json:"Sites" binding:"dive"`
+	// Links are the tunnels between the sites of a partial mesh. They are ignored for other topologies.
+	Links []SiteLink `json:"Links" binding:"dive"`
+}
+
+// Site is a single location of a site network.
+type Site struct {
+	// Identifier is the unique identifier of the site within the site network.
+	Identifier string `json:"Identifier" binding:"required" example:"berlin"`
+	// DisplayName is a nice display name / description for the site.
+	DisplayName string `json:"DisplayName" example:"Berlin Office"`
+	// Hub is a flag that specifies if the site is the hub of a hub-and-spoke network.
+	Hub bool `json:"Hub" example:"false"`
+	// InterfaceIdentifier links the site to an interface of this portal. The keys, the listen port and the addresses
+	// of the site are taken from the interface, and the tunnels to the other sites are created as peers of the interface.
+	InterfaceIdentifier string `json:"InterfaceIdentifier" example:""`
+
+	// PublicKey is the public key of the site. If it is empty for a remote site, a new key pair is generated.
+	PublicKey string `json:"PublicKey" example:"xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="`
+	// Endpoint is the public address (host:port) of the site. Leave empty if the site is not reachable from the internet.
+	Endpoint string `json:"Endpoint" example:"berlin.example.com:51820"`
+	// ListenPort is the listen port of the site.
+	ListenPort int `json:"ListenPort" binding:"omitempty,min=1,max=65535" example:"51820"`
+	// Addresses are the tunnel addresses of the site.
+	Addresses []string `json:"Addresses" binding:"omitempty,dive,cidr" example:"10.99.0.2/24"`
+	// LanPrefixes are the local networks behind the site. They are routed to the site by all connected sites.
+	LanPrefixes []string `json:"LanPrefixes" binding:"omitempty,dive,cidr" example:"192.168.1.0/24"`
+}
+
+// SiteLink is a tunnel between two sites of a partial mesh.
+type SiteLink struct {
+	// SiteA is the identifier of the first site.
+	SiteA string `json:"SiteA" binding:"required" example:"berlin"`
+	// SiteB is the identifier of the second site.
+	SiteB string `json:"SiteB" binding:"required" example:"vienna"`
+}
+
+func NewSiteNetwork(src *domain.SiteNetwork) *SiteNetwork {
+	network := &SiteNetwork{
+		Identifier:          string(src.Identifier),
+		DisplayName:         src.DisplayName,
+		Topology:            string(src.Topology),
+		PersistentKeepalive: src.PersistentKeepalive,
+		Sites:               make([]Site, len(src.Sites)),
+		Links:               make([]SiteLink, len(src.Links)),
+	}
+
+	for i, site := range src.Sites {
+		network.Sites[i] = Site{
+			Identifier:          string(site.Identifier),
+			DisplayName:         site.DisplayName,
+			Hub:                 site.Hub,
+			InterfaceIdentifier: string(site.InterfaceIdentifier),
+			PublicKey:           site.PublicKey,
+			Endpoint:            site.Endpoint,
+			ListenPort:          site.ListenPort,
+			Addresses:           internal.SliceString(site.AddressStr),
+			LanPrefixes:         internal.SliceString(site.LanPrefixesStr),
+		}
+	}
+	for i, link := range src.Links {
+		network.Links[i] = SiteLink{
+			SiteA: string(link.SiteA),
+			SiteB: string(link.SiteB),
+		}
+	}
+
+	return network
+}
+
+func NewSiteNetworks(src []domain.SiteNetwork) []SiteNetwork {
+	results := make([]SiteNetwork, len(src))
+	for i := range src {
+		results[i] = *NewSiteNetwork(&src[i])
+	}
+	return results
+}
+
+func NewDomainSiteNetwork(src *SiteNetwork) *domain.SiteNetwork {
+	network := &domain.SiteNetwork{
+		Identifier:          domain.SiteNetworkIdentifier(src.Identifier),
+		DisplayName:         src.DisplayName,
+		Topology:            domain.SiteTopology(src.Topology),
+		PersistentKeepalive: src.PersistentKeepalive,
+		Sites:               make([]domain.Site, len(src.Sites)),
+		Links:               make([]domain.SiteLink, len(src.Links)),
+	}
+
+	for i, site := range src.Sites {
+		network.Sites[i] = domain.Site{
+			NetworkIdentifier:   network.Identifier,
+			Identifier:          domain.SiteIdentifier(site.Identifier),
+			DisplayName:         site.DisplayName,
+			Hub:                 site.Hub,
+			InterfaceIdentifier: domain.InterfaceIdentifier(site.InterfaceIdentifier),
+			KeyPair:             domain.KeyPair{PublicKey: site.PublicKey},
+			Endpoint:            site.Endpoint,
+			ListenPort:          site.ListenPort,
+			AddressStr:          internal.SliceToString(site.Addresses),
+			LanPrefixesStr:      internal.SliceToString(site.LanPrefixes),
+		}
+	}
+	for i, link := range src.Links {
+		network.Links[i] = domain.SiteLink{
+			NetworkIdentifier: network.Identifier,
+			SiteA:             domain.SiteIdentifier(link.SiteA),
+			SiteB:             domain.SiteIdentifier(link.SiteB),
+		}
+	}
+
+	return network
+}
@@ -0,0 +1,43 @@
+package models
+
+import "github.com/h44z/wg-portal/internal/domain"
+
+// SpecPlan lists the changes that are needed to reconcile the stored objects with the declarative spec.
+type SpecPlan struct {
+	// Changes are listed in the order in which they are applied.
+	Changes []SpecChange `json:"Changes"`
+	// Failed is the number of changes that could not be applied.
+	Failed int `json:"Failed" example:"0"`
+}
+
+// SpecChange is a single change of a spec plan.
+type SpecChange struct {
+	// Kind is either user, interface or peer.
+	Kind string `json:"Kind" example:"peer"`
+	// Identifier is the identifier of the user, interface or peer.
+	Identifier string `json:"Identifier" example:"xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="`
+	// Action is either create, update, adopt or delete.
+	Action string `json:"Action" example:"update"`
+	// Fields are the changed fields of updated and adopted objects.
+	Fields []string `json:"Fields,omitempty" example:"display_name"`
+	// Error describes why the change could not be applied.
+	Error string `json:"Error,omitempty"`
+}
+
+func NewSpecPlan(src *domain.SpecPlan) *SpecPlan {
+	plan := &SpecPlan{Changes: make([]SpecChange, len(src.Changes))}
+	for i, c := range src.Changes {
+		plan.Changes[i] = SpecChange{
+			Kind:       string(c.Kind),
+			Identifier: c.Identifier,
+			Action:     string(c.Action),
+			Fields:     c.Fields,
+			Error:      c.Error,
+		}
+		if c.Error != "" {
+			plan.Failed++
+		}
+	}
+
+	return plan
+}
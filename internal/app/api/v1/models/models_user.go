@@ -18,6 +18,9 @@ type User struct {
 	ProviderName string `json:"ProviderName,omitempty" readonly:"true" example:""`
 	// If this field is set, the user is an admin.
 	IsAdmin bool `json:"IsAdmin" example:"false"`
+	// Roles grant additional permissions to non-admin users. Known roles are "auditor", "helpdesk" and
+	// "operator:<interface>".
+	Roles []string `json:"Roles" example:"helpdesk"`
 
 	// The first name of the user. This field is optional.
 	Firstname string `json:"Firstname" example:"Max"`
@@ -40,11 +43,22 @@ type User struct {
 	Locked bool `json:"Locked" example:"false"`
 	// The reason why the user has been locked.
 	LockedReason string `json:"LockedReason" binding:"required_if=Locked true" example:""`
+	// If this field is set, the user is locked temporarily, for example after too many failed logins.
+	// The lock is removed automatically at the given time. This field is read-only.
+	LockedUntil *time.Time `json:"LockedUntil,omitempty" readonly:"true"`
+
+	// PeerQuota is the traffic quota for all peers of the user that have no own quota.
+	PeerQuota TrafficQuota `json:"PeerQuota"`
 
 	// The API token of the user. This field is never populated on bulk read operations.
 	ApiToken string `json:"ApiToken,omitempty" binding:"omitempty,min=32,max=64" example:""`
 	// If this field is set, the user is allowed to use the RESTful API. This field is read-only.
 	ApiEnabled bool `json:"ApiEnabled" readonly:"true" example:"false"`
+	// If this field is set, the user has enrolled a TOTP authenticator as second factor. This field is read-only.
+	TotpEnabled bool `json:"TotpEnabled" readonly:"true" example:"false"`
+
+	// ManagedBy is set if the user is managed by the declarative spec. Managed users are read-only.
+	ManagedBy string `json:"ManagedBy" readonly:"true" example:""`
 
 	// The number of peers linked to the user. This field is read-only.
 	PeerCount int `json:"PeerCount" readonly:"true" example:"2"`
@@ -57,6 +71,7 @@ func NewUser(src *domain.User, exposeCredentials bool) *User {
 		Source:         string(src.Source),
 		ProviderName:   src.ProviderName,
 		IsAdmin:        src.IsAdmin,
+		Roles:          NewRoles(src.Roles),
 		Firstname:      src.Firstname,
 		Lastname:       src.Lastname,
 		Phone:          src.Phone,
@@ -67,11 +82,18 @@ func NewUser(src *domain.User, exposeCredentials bool) *User {
 		DisabledReason: src.DisabledReason,
 		Locked:         src.IsLocked(),
 		LockedReason:   src.LockedReason,
+		PeerQuota:      NewTrafficQuota(src.PeerQuota),
 		ApiToken:       "", // by default, do not expose API token
 		ApiEnabled:     src.IsApiEnabled(),
+		TotpEnabled:    src.HasTotp(),
+		ManagedBy:      src.ManagedBy,
 		PeerCount:      src.LinkedPeerCount,
 	}
 
+	if u.Locked {
+		u.LockedUntil = src.LockedUntil
+	}
+
 	if exposeCredentials {
 		u.ApiToken = src.ApiToken
 	}
@@ -96,6 +118,7 @@ func NewDomainUser(src *User) *domain.User {
 		Source:         domain.UserSource(src.Source),
 		ProviderName:   src.ProviderName,
 		IsAdmin:        src.IsAdmin,
+		Roles:          NewDomainRoles(src.Roles),
 		Firstname:      src.Firstname,
 		Lastname:       src.Lastname,
 		Phone:          src.Phone,
@@ -106,6 +129,7 @@ func NewDomainUser(src *User) *domain.User {
 		DisabledReason: src.DisabledReason,
 		Locked:         nil, // set below
 		LockedReason:   src.LockedReason,
+		PeerQuota:      NewDomainTrafficQuota(src.PeerQuota),
 	}
 
 	if src.ApiToken != "" {
@@ -129,3 +153,54 @@ func NewDomainUser(src *User) *domain.User {
 
 	return res
 }
+
+func NewRoles(src []domain.Role) []string {
+	roles := make([]string, len(src))
+	for i, role := range src {
+		roles[i] = string(role)
+	}
+
+	return roles
+}
+
+func NewDomainRoles(src []string) []domain.Role {
+	roles := make([]domain.Role, len(src))
+	for i, role := range src {
+		roles[i] = domain.Role(role)
+	}
+
+	return roles
+}
+
+// LoginBlock represents an account or a source address that is blocked after too many failed logins.
+type LoginBlock struct {
+	// The blocked account identifier or source address.
+	Subject string `json:"Subject" example:"uid-1234567"`
+	// If this field is set, the subject is a source address and not an account.
+	IsSource bool `json:"IsSource" example:"false"`
+	// The number of consecutive failed logins.
+	Failures int `json:"Failures" example:"10"`
+	// The time of the last failed login.
+	LastFailure time.Time `json:"LastFailure" example:"2025-01-01T12:00:00Z"`
+	// No login is possible until this time.
+	BlockedUntil *time.Time `json:"BlockedUntil" example:"2025-01-01T12:15:00Z"`
+}
+
+func NewLoginBlock(src *domain.LoginAttempt) *LoginBlock {
+	return &LoginBlock{
+		Subject:      src.Subject(),
+		IsSource:     src.IsSource(),
+		Failures:     src.Failures,
+		LastFailure:  src.LastFailure,
+		BlockedUntil: src.BlockedUntil,
+	}
+}
+
+func NewLoginBlocks(src []domain.LoginAttempt) []LoginBlock {
+	results := make([]LoginBlock, len(src))
+	for i := range src {
+		results[i] = *NewLoginBlock(&src[i])
+	}
+
+	return results
+}
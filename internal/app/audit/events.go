@@ -16,3 +16,39 @@ type PeerEvent struct {
 	Peer   domain.Peer
 	Action string
 }
+
+type BulkEvent struct {
+	Target   string // peers or users
+	Selector string // a description of the selected items
+	Reason   string
+	Result   domain.BulkResult
+}
+
+type SpecEvent struct {
+	Source string // the spec file or directory
+	Plan   domain.SpecPlan
+}
+
+type ShareLinkEvent struct {
+	Link   domain.PeerShareLink
+	Action string
+	Error  string
+}
+
+// LockoutEvent is published if an account or a source address is locked or unlocked by the brute-force protection.
+type LockoutEvent struct {
+	Attempt domain.LoginAttempt
+	Action  string // lock or unlock
+}
+
+// SecondFactorEvent is published if the TOTP second factor of a user is enrolled, renewed or removed.
+type SecondFactorEvent struct {
+	Username string
+	Action   string // enroll, recovery-codes or remove
+}
+
+type ApiTokenEvent struct {
+	Token  domain.ApiToken
+	Action string
+	Error  string
+}
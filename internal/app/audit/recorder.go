@@ -84,12 +84,30 @@ func (r *Recorder) connectToMessageBus() error {
 	if err := r.bus.Subscribe(app.TopicAuditLoginFailed, r.handleAuthEvent); err != nil {
 		return fmt.Errorf("failed to subscribe to %s: %w", app.TopicAuditLoginFailed, err)
 	}
+	if err := r.bus.Subscribe(app.TopicAuditLoginLockout, r.handleLockoutEvent); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", app.TopicAuditLoginLockout, err)
+	}
+	if err := r.bus.Subscribe(app.TopicAuditSecondFactorChanged, r.handleSecondFactorEvent); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", app.TopicAuditSecondFactorChanged, err)
+	}
 	if err := r.bus.Subscribe(app.TopicAuditInterfaceChanged, r.handleInterfaceEvent); err != nil {
 		return fmt.Errorf("failed to subscribe to %s: %w", app.TopicAuditInterfaceChanged, err)
 	}
 	if err := r.bus.Subscribe(app.TopicAuditPeerChanged, r.handlePeerEvent); err != nil {
 		return fmt.Errorf("failed to subscribe to %s: %w", app.TopicAuditPeerChanged, err)
 	}
+	if err := r.bus.Subscribe(app.TopicAuditShareLinkChanged, r.handleShareLinkEvent); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", app.TopicAuditShareLinkChanged, err)
+	}
+	if err := r.bus.Subscribe(app.TopicAuditApiTokenChanged, r.handleApiTokenEvent); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", app.TopicAuditApiTokenChanged, err)
+	}
+	if err := r.bus.Subscribe(app.TopicAuditBulkAction, r.handleBulkEvent); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", app.TopicAuditBulkAction, err)
+	}
+	if err := r.bus.Subscribe(app.TopicAuditSpecApplied, r.handleSpecEvent); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", app.TopicAuditSpecApplied, err)
+	}
 
 	return nil
 }
@@ -102,6 +120,22 @@ func (r *Recorder) handleAuthEvent(event domain.AuditEventWrapper[AuthEvent]) {
 	}
 }
 
+func (r *Recorder) handleLockoutEvent(event domain.AuditEventWrapper[LockoutEvent]) {
+	err := r.db.SaveAuditEntry(context.Background(), r.lockoutEventToAuditEntry(event))
+	if err != nil {
+		slog.Error("failed to create audit entry for lockout event", "error", err)
+		return
+	}
+}
+
+func (r *Recorder) handleSecondFactorEvent(event domain.AuditEventWrapper[SecondFactorEvent]) {
+	err := r.db.SaveAuditEntry(context.Background(), r.secondFactorEventToAuditEntry(event))
+	if err != nil {
+		slog.Error("failed to create audit entry for second factor event", "error", err)
+		return
+	}
+}
+
 func (r *Recorder) handleInterfaceEvent(event domain.AuditEventWrapper[InterfaceEvent]) {
 	err := r.db.SaveAuditEntry(context.Background(), r.interfaceEventToAuditEntry(event))
 	if err != nil {
@@ -118,6 +152,38 @@ func (r *Recorder) handlePeerEvent(event domain.AuditEventWrapper[PeerEvent]) {
 	}
 }
 
+func (r *Recorder) handleShareLinkEvent(event domain.AuditEventWrapper[ShareLinkEvent]) {
+	err := r.db.SaveAuditEntry(context.Background(), r.shareLinkEventToAuditEntry(event))
+	if err != nil {
+		slog.Error("failed to create audit entry for share link event", "error", err)
+		return
+	}
+}
+
+func (r *Recorder) handleApiTokenEvent(event domain.AuditEventWrapper[ApiTokenEvent]) {
+	err := r.db.SaveAuditEntry(context.Background(), r.apiTokenEventToAuditEntry(event))
+	if err != nil {
+		slog.Error("failed to create audit entry for api token event", "error", err)
+		return
+	}
+}
+
+func (r *Recorder) handleBulkEvent(event domain.AuditEventWrapper[BulkEvent]) {
+	err := r.db.SaveAuditEntry(context.Background(), r.bulkEventToAuditEntry(event))
+	if err != nil {
+		slog.Error("failed to create audit entry for bulk event", "error", err)
+		return
+	}
+}
+
+func (r *Recorder) handleSpecEvent(event domain.AuditEventWrapper[SpecEvent]) {
+	err := r.db.SaveAuditEntry(context.Background(), r.specEventToAuditEntry(event))
+	if err != nil {
+		slog.Error("failed to create audit entry for spec event", "error", err)
+		return
+	}
+}
+
 func (r *Recorder) authEventToAuditEntry(event domain.AuditEventWrapper[AuthEvent]) *domain.AuditEntry {
 	contextUser := domain.GetUserInfo(event.Ctx)
 	e := domain.AuditEntry{
@@ -136,6 +202,62 @@ func (r *Recorder) authEventToAuditEntry(event domain.AuditEventWrapper[AuthEven
 	return &e
 }
 
+func (r *Recorder) lockoutEventToAuditEntry(event domain.AuditEventWrapper[LockoutEvent]) *domain.AuditEntry {
+	contextUser := domain.GetUserInfo(event.Ctx)
+	e := domain.AuditEntry{
+		CreatedAt:   time.Now(),
+		Severity:    domain.AuditSeverityLevelLow,
+		ContextUser: contextUser.UserId(),
+		Origin:      fmt.Sprintf("auth: lockout %s", event.Event.Action),
+	}
+
+	attempt := event.Event.Attempt
+	kind := "user"
+	if attempt.IsSource() {
+		kind = "source address"
+	}
+	switch event.Event.Action {
+	case "lock":
+		e.Severity = domain.AuditSeverityLevelHigh
+		e.Message = fmt.Sprintf("%s %s locked after %d failed logins", kind, attempt.Subject(), attempt.Failures)
+		if attempt.BlockedUntil != nil {
+			e.Message += fmt.Sprintf(" until %s", attempt.BlockedUntil.Format(time.RFC3339))
+		}
+	case "unlock":
+		e.Message = fmt.Sprintf("%s %s unlocked", kind, attempt.Subject())
+	default:
+		e.Message = fmt.Sprintf("%s %s: unknown action", kind, attempt.Subject())
+	}
+
+	return &e
+}
+
+func (r *Recorder) secondFactorEventToAuditEntry(
+	event domain.AuditEventWrapper[SecondFactorEvent],
+) *domain.AuditEntry {
+	contextUser := domain.GetUserInfo(event.Ctx)
+	e := domain.AuditEntry{
+		CreatedAt:   time.Now(),
+		Severity:    domain.AuditSeverityLevelLow,
+		ContextUser: contextUser.UserId(),
+		Origin:      fmt.Sprintf("auth: totp %s", event.Event.Action),
+	}
+
+	switch event.Event.Action {
+	case "enroll":
+		e.Message = fmt.Sprintf("%s enrolled a TOTP authenticator", event.Event.Username)
+	case "recovery-codes":
+		e.Message = fmt.Sprintf("%s renewed the recovery codes", event.Event.Username)
+	case "remove":
+		e.Severity = domain.AuditSeverityLevelHigh
+		e.Message = fmt.Sprintf("TOTP authenticator of %s removed", event.Event.Username)
+	default:
+		e.Message = fmt.Sprintf("%s: unknown action", event.Event.Username)
+	}
+
+	return &e
+}
+
 func (r *Recorder) interfaceEventToAuditEntry(event domain.AuditEventWrapper[InterfaceEvent]) *domain.AuditEntry {
 	contextUser := domain.GetUserInfo(event.Ctx)
 	e := domain.AuditEntry{
@@ -148,6 +270,12 @@ func (r *Recorder) interfaceEventToAuditEntry(event domain.AuditEventWrapper[Int
 	switch event.Event.Action {
 	case "save":
 		e.Message = fmt.Sprintf("%s updated", event.Event.Interface.Identifier)
+	case "export":
+		e.Severity = domain.AuditSeverityLevelHigh
+		e.Message = fmt.Sprintf("%s exported to bundle", event.Event.Interface.Identifier)
+	case "import":
+		e.Severity = domain.AuditSeverityLevelHigh
+		e.Message = fmt.Sprintf("%s imported from bundle", event.Event.Interface.Identifier)
 	default:
 		e.Message = fmt.Sprintf("%s: unknown action", event.Event.Interface.Identifier)
 	}
@@ -167,9 +295,125 @@ func (r *Recorder) peerEventToAuditEntry(event domain.AuditEventWrapper[PeerEven
 	switch event.Event.Action {
 	case "save":
 		e.Message = fmt.Sprintf("%s updated", event.Event.Peer.Identifier)
+	case "renew":
+		e.Message = fmt.Sprintf("%s renewed until %s", event.Event.Peer.Identifier,
+			event.Event.Peer.ExpiresAt.Format("2006-01-02"))
+	case "renew-request":
+		e.Message = fmt.Sprintf("%s renewal requested", event.Event.Peer.Identifier)
+	case "renew-reject":
+		e.Message = fmt.Sprintf("%s renewal rejected", event.Event.Peer.Identifier)
+	case "move":
+		e.Message = fmt.Sprintf("%s moved to %s", event.Event.Peer.Identifier,
+			event.Event.Peer.InterfaceIdentifier)
 	default:
 		e.Message = fmt.Sprintf("%s: unknown action", event.Event.Peer.Identifier)
 	}
 
 	return &e
 }
+
+func (r *Recorder) shareLinkEventToAuditEntry(event domain.AuditEventWrapper[ShareLinkEvent]) *domain.AuditEntry {
+	contextUser := domain.GetUserInfo(event.Ctx)
+	e := domain.AuditEntry{
+		CreatedAt:   time.Now(),
+		Severity:    domain.AuditSeverityLevelLow,
+		ContextUser: contextUser.UserId(),
+		Origin:      fmt.Sprintf("sharelink: %s", event.Event.Action),
+	}
+
+	link := event.Event.Link
+	switch event.Event.Action {
+	case "create":
+		e.Message = fmt.Sprintf("share link %s created for peer %s", link.Identifier, link.PeerIdentifier)
+	case "revoke":
+		e.Message = fmt.Sprintf("share link %s for peer %s revoked", link.Identifier, link.PeerIdentifier)
+	case "use":
+		e.Message = fmt.Sprintf("share link %s for peer %s used (%d/%d)", link.Identifier, link.PeerIdentifier,
+			link.UseCount, link.MaxUses)
+	case "denied":
+		e.Severity = domain.AuditSeverityLevelHigh
+		e.Message = fmt.Sprintf("share link %s rejected: %s", link.Identifier, event.Event.Error)
+	default:
+		e.Message = fmt.Sprintf("%s: unknown action", link.Identifier)
+	}
+
+	return &e
+}
+
+func (r *Recorder) apiTokenEventToAuditEntry(event domain.AuditEventWrapper[ApiTokenEvent]) *domain.AuditEntry {
+	contextUser := domain.GetUserInfo(event.Ctx)
+	e := domain.AuditEntry{
+		CreatedAt:   time.Now(),
+		Severity:    domain.AuditSeverityLevelLow,
+		ContextUser: contextUser.UserId(),
+		Origin:      fmt.Sprintf("apitoken: %s", event.Event.Action),
+	}
+
+	token := event.Event.Token
+	switch event.Event.Action {
+	case "create":
+		e.Message = fmt.Sprintf("api token %s (%s) created for user %s", token.Identifier, token.Name,
+			token.UserIdentifier)
+	case "delete":
+		e.Message = fmt.Sprintf("api token %s (%s) of user %s deleted", token.Identifier, token.Name,
+			token.UserIdentifier)
+	case "denied":
+		e.Severity = domain.AuditSeverityLevelHigh
+		e.Message = fmt.Sprintf("api token %s of user %s rejected: %s", token.Identifier, token.UserIdentifier,
+			event.Event.Error)
+	default:
+		e.Message = fmt.Sprintf("%s: unknown action", token.Identifier)
+	}
+
+	return &e
+}
+
+func (r *Recorder) bulkEventToAuditEntry(event domain.AuditEventWrapper[BulkEvent]) *domain.AuditEntry {
+	contextUser := domain.GetUserInfo(event.Ctx)
+	result := event.Event.Result
+	e := domain.AuditEntry{
+		CreatedAt:   time.Now(),
+		Severity:    domain.AuditSeverityLevelLow,
+		ContextUser: contextUser.UserId(),
+		Origin:      fmt.Sprintf("bulk: %s %s", event.Event.Target, result.Action),
+		Message: fmt.Sprintf("%s %s: %d changed, %d unchanged, %d failed (%s)", result.Action,
+			event.Event.Target, result.Count(domain.BulkItemChanged), result.Count(domain.BulkItemUnchanged),
+			result.Count(domain.BulkItemFailed), event.Event.Selector),
+	}
+
+	if event.Event.Reason != "" {
+		e.Message += ", reason: " + event.Event.Reason
+	}
+	if result.Action == domain.BulkActionDelete || result.Count(domain.BulkItemFailed) > 0 {
+		e.Severity = domain.AuditSeverityLevelHigh
+	}
+
+	return &e
+}
+
+func (r *Recorder) specEventToAuditEntry(event domain.AuditEventWrapper[SpecEvent]) *domain.AuditEntry {
+	contextUser := domain.GetUserInfo(event.Ctx)
+	e := domain.AuditEntry{
+		CreatedAt:   time.Now(),
+		Severity:    domain.AuditSeverityLevelLow,
+		ContextUser: contextUser.UserId(),
+		Origin:      fmt.Sprintf("spec: %s", event.Event.Source),
+	}
+
+	counts := make(map[domain.SpecAction]int)
+	failed := 0
+	for _, c := range event.Event.Plan.Changes {
+		counts[c.Action]++
+		if c.Error != "" {
+			failed++
+		}
+	}
+	e.Message = fmt.Sprintf("spec applied: %d created, %d updated, %d adopted, %d deleted, %d failed",
+		counts[domain.SpecActionCreate], counts[domain.SpecActionUpdate], counts[domain.SpecActionAdopt],
+		counts[domain.SpecActionDelete], failed)
+	if counts[domain.SpecActionDelete] > 0 || failed > 0 {
+		e.Severity = domain.AuditSeverityLevelHigh
+	}
+
+	return &e
+}
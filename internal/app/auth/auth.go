@@ -10,6 +10,7 @@ import (
 	"log/slog"
 	"net/url"
 	"path"
+	"slices"
 	"strings"
 	"time"
 
@@ -31,6 +32,22 @@ type UserManager interface {
 	RegisterUser(ctx context.Context, user *domain.User) error
 	// UpdateUser updates an existing user in the database.
 	UpdateUser(ctx context.Context, user *domain.User) (*domain.User, error)
+	// CheckLoginAllowed returns domain.ErrLoginThrottled if the account or source address has to wait.
+	CheckLoginAllowed(ctx context.Context, id domain.UserIdentifier, sourceAddr string) error
+	// RegisterLoginFailure counts a failed login for the account and the source address.
+	RegisterLoginFailure(ctx context.Context, id domain.UserIdentifier, sourceAddr string)
+	// RegisterLoginSuccess resets the failed logins of the account.
+	RegisterLoginSuccess(ctx context.Context, user *domain.User)
+	// VerifySecondFactor checks the TOTP code or recovery code of a user that completes a password login.
+	VerifySecondFactor(ctx context.Context, id domain.UserIdentifier, code, sourceAddr string) (
+		*domain.User,
+		domain.SecondFactorMethod,
+		error,
+	)
+	// StartTotpEnrollment creates a new TOTP secret for the user.
+	StartTotpEnrollment(ctx context.Context, id domain.UserIdentifier) (*domain.TotpEnrollment, error)
+	// FinishTotpEnrollment confirms the TOTP enrollment and returns the recovery codes.
+	FinishTotpEnrollment(ctx context.Context, id domain.UserIdentifier, code string) ([]string, error)
 }
 
 type EventBus interface {
@@ -81,14 +98,29 @@ type AuthenticatorLdap interface {
 	RegistrationEnabled() bool
 }
 
+// AuthenticatorBearer is the interface for all authenticators that can validate bearer tokens for the REST API.
+type AuthenticatorBearer interface {
+	// GetName returns the name of the authenticator.
+	GetName() string
+	// VerifyBearerToken validates the bearer token and returns its claims and expiry.
+	VerifyBearerToken(ctx context.Context, rawToken string) (map[string]any, time.Time, error)
+	// ParseUserInfo parses the token claims into a domain.AuthenticatorUserInfo struct.
+	ParseUserInfo(raw map[string]any) (*domain.AuthenticatorUserInfo, error)
+	// ParseBearerScopes returns the API scopes that are granted by the token claims.
+	ParseBearerScopes(raw map[string]any) []domain.ApiTokenScope
+	// ServiceIdentitiesAllowed returns whether tokens may belong to identities that are not stored as users.
+	ServiceIdentitiesAllowed() bool
+}
+
 // Authenticator is the main entry point for all authentication related tasks.
 // This includes password authentication and external authentication providers (OIDC, OAuth, LDAP).
 type Authenticator struct {
 	cfg *config.Auth
 	bus EventBus
 
-	oauthAuthenticators map[string]AuthenticatorOauth
-	ldapAuthenticators  map[string]AuthenticatorLdap
+	oauthAuthenticators  map[string]AuthenticatorOauth
+	ldapAuthenticators   map[string]AuthenticatorLdap
+	bearerAuthenticators []AuthenticatorBearer // in configuration order
 
 	// URL prefix for the callback endpoints, this is a combination of the external URL and the API prefix
 	callbackUrlPrefix string
@@ -144,6 +176,9 @@ func (a *Authenticator) setupExternalAuthProviders(ctx context.Context) error {
 			return fmt.Errorf("failed to setup oidc authentication provider %s: %w", providerCfg.ProviderName, err)
 		}
 		a.oauthAuthenticators[providerId] = provider
+		if provider.BearerEnabled() {
+			a.bearerAuthenticators = append(a.bearerAuthenticators, provider)
+		}
 	}
 	for i := range a.cfg.OAuth { // PLAIN OAUTH
 		providerCfg := &a.cfg.OAuth[i]
@@ -237,8 +272,9 @@ func (a *Authenticator) IsUserValid(ctx context.Context, id domain.UserIdentifie
 // region password authentication
 
 // PlainLogin performs a password authentication for a user. The username and password are trimmed before usage.
-// If the login is successful, the user is returned, otherwise an error.
-func (a *Authenticator) PlainLogin(ctx context.Context, username, password string) (*domain.User, error) {
+// If the login is successful, the user is returned, otherwise an error. If the user has to provide a second factor
+// (see SecondFactorChallenge), the login must be completed with SecondFactorLogin, TotpEnrollmentLogin or a passkey.
+func (a *Authenticator) PlainLogin(ctx context.Context, username, password, sourceAddr string) (*domain.User, error) {
 	// Validate form input
 	username = strings.TrimSpace(username)
 	password = strings.TrimSpace(password)
@@ -246,18 +282,37 @@ func (a *Authenticator) PlainLogin(ctx context.Context, username, password strin
 		return nil, fmt.Errorf("missing username or password")
 	}
 
-	user, err := a.passwordAuthentication(ctx, domain.UserIdentifier(username), password)
+	identifier := domain.UserIdentifier(username)
+	if err := a.users.CheckLoginAllowed(ctx, identifier, sourceAddr); err != nil {
+		a.bus.Publish(app.TopicAuditLoginFailed, domain.AuditEventWrapper[audit.AuthEvent]{
+			Ctx:    ctx,
+			Source: "plain",
+			Event: audit.AuthEvent{
+				Username: username, Error: fmt.Sprintf("%v (source %s)", err, sourceAddr),
+			},
+		})
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+
+	user, err := a.passwordAuthentication(ctx, identifier, password)
 	if err != nil {
+		a.users.RegisterLoginFailure(ctx, identifier, sourceAddr)
 		a.bus.Publish(app.TopicAuditLoginFailed, domain.AuditEventWrapper[audit.AuthEvent]{
 			Ctx:    ctx,
 			Source: "plain",
 			Event: audit.AuthEvent{
-				Username: username, Error: err.Error(),
+				Username: username, Error: fmt.Sprintf("%v (source %s)", err, sourceAddr),
 			},
 		})
 		return nil, fmt.Errorf("login failed: %w", err)
 	}
 
+	if a.SecondFactorChallenge(user) != nil {
+		return user, nil // failed logins are only reset after the second factor
+	}
+
+	a.users.RegisterLoginSuccess(ctx, user)
+
 	a.bus.Publish(app.TopicAuthLogin, user.Identifier)
 	a.bus.Publish(app.TopicAuditLoginSuccess, domain.AuditEventWrapper[audit.AuthEvent]{
 		Ctx:    ctx,
@@ -353,6 +408,126 @@ func (a *Authenticator) passwordAuthentication(
 
 // endregion password authentication
 
+// region second factor
+
+// SecondFactorChallenge returns the second factor that is required to complete the password login of the user.
+// If no second factor is required, nil is returned.
+func (a *Authenticator) SecondFactorChallenge(user *domain.User) *domain.SecondFactorChallenge {
+	return user.SecondFactorChallenge(a.cfg.TwoFactor, a.cfg.WebAuthn.Enabled)
+}
+
+// SecondFactorLogin completes a password login with a TOTP code or a recovery code.
+func (a *Authenticator) SecondFactorLogin(
+	ctx context.Context,
+	userId domain.UserIdentifier,
+	code, sourceAddr string,
+) (*domain.User, error) {
+	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo())
+
+	user, method, err := a.users.VerifySecondFactor(ctx, userId, code, sourceAddr)
+	if err != nil {
+		a.bus.Publish(app.TopicAuditLoginFailed, domain.AuditEventWrapper[audit.AuthEvent]{
+			Ctx:    ctx,
+			Source: string(domain.SecondFactorTotp),
+			Event: audit.AuthEvent{
+				Username: string(userId), Error: fmt.Sprintf("%v (source %s)", err, sourceAddr),
+			},
+		})
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+
+	a.publishSecondFactorLogin(ctx, user, method)
+
+	return user, nil
+}
+
+// StartTotpEnrollment creates a TOTP secret for a user that has to enroll a second factor during the login.
+func (a *Authenticator) StartTotpEnrollment(
+	ctx context.Context,
+	userId domain.UserIdentifier,
+) (*domain.TotpEnrollment, error) {
+	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo())
+
+	if err := a.checkEnrollmentRequired(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	return a.users.StartTotpEnrollment(ctx, userId)
+}
+
+// TotpEnrollmentLogin confirms the TOTP enrollment of a user that logs in and completes the login.
+// The recovery codes are returned along with the user.
+func (a *Authenticator) TotpEnrollmentLogin(
+	ctx context.Context,
+	userId domain.UserIdentifier,
+	code, sourceAddr string,
+) (*domain.User, []string, error) {
+	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo())
+
+	if err := a.users.CheckLoginAllowed(ctx, userId, sourceAddr); err != nil {
+		return nil, nil, fmt.Errorf("login failed: %w", err)
+	}
+	if err := a.checkEnrollmentRequired(ctx, userId); err != nil {
+		return nil, nil, fmt.Errorf("login failed: %w", err)
+	}
+
+	recoveryCodes, err := a.users.FinishTotpEnrollment(ctx, userId, code)
+	if err != nil {
+		a.users.RegisterLoginFailure(ctx, userId, sourceAddr)
+		a.bus.Publish(app.TopicAuditLoginFailed, domain.AuditEventWrapper[audit.AuthEvent]{
+			Ctx:    ctx,
+			Source: string(domain.SecondFactorTotp),
+			Event: audit.AuthEvent{
+				Username: string(userId), Error: fmt.Sprintf("%v (source %s)", err, sourceAddr),
+			},
+		})
+		return nil, nil, fmt.Errorf("login failed: %w", err)
+	}
+
+	user, err := a.users.GetUser(ctx, userId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("login failed: %w", err)
+	}
+
+	a.users.RegisterLoginSuccess(ctx, user)
+	a.publishSecondFactorLogin(ctx, user, domain.SecondFactorTotp)
+
+	return user, recoveryCodes, nil
+}
+
+// checkEnrollmentRequired ensures that the enrollment during the login is only possible for users without a second
+// factor. Otherwise, the existing second factor could be bypassed.
+func (a *Authenticator) checkEnrollmentRequired(ctx context.Context, userId domain.UserIdentifier) error {
+	user, err := a.users.GetUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	challenge := a.SecondFactorChallenge(user)
+	if challenge == nil || !challenge.EnrollmentRequired {
+		return fmt.Errorf("second factor enrollment not required: %w", domain.ErrNoPermission)
+	}
+
+	return nil
+}
+
+func (a *Authenticator) publishSecondFactorLogin(
+	ctx context.Context,
+	user *domain.User,
+	method domain.SecondFactorMethod,
+) {
+	a.bus.Publish(app.TopicAuthLogin, user.Identifier)
+	a.bus.Publish(app.TopicAuditLoginSuccess, domain.AuditEventWrapper[audit.AuthEvent]{
+		Ctx:    ctx,
+		Source: "plain+" + string(method),
+		Event: audit.AuthEvent{
+			Username: string(user.Identifier),
+		},
+	})
+}
+
+// endregion second factor
+
 // region oauth authentication
 
 // OauthLoginStep1 starts the oauth authentication flow by returning the authentication URL, state and nonce.
@@ -518,6 +693,7 @@ func (a *Authenticator) registerNewUser(
 		Source:       source,
 		ProviderName: provider,
 		IsAdmin:      userInfo.IsAdmin,
+		Roles:        userInfo.Roles,
 		Firstname:    userInfo.Firstname,
 		Lastname:     userInfo.Lastname,
 		Phone:        userInfo.Phone,
@@ -589,6 +765,10 @@ func (a *Authenticator) updateExternalUser(
 		existingUser.IsAdmin = userInfo.IsAdmin
 		isChanged = true
 	}
+	if userInfo.RolesMapped && !slices.Equal(existingUser.Roles, userInfo.Roles) {
+		existingUser.Roles = userInfo.Roles
+		isChanged = true
+	}
 	if existingUser.Source != source {
 		existingUser.Source = source
 		isChanged = true
@@ -616,3 +796,101 @@ func (a *Authenticator) updateExternalUser(
 }
 
 // endregion oauth authentication
+
+// region bearer authentication
+
+// AuthenticateBearerToken validates a bearer JWT against all OIDC providers that have bearer tokens enabled.
+// The user identity is mapped from the token claims using the field mapping of the provider. Tokens of unknown
+// identities are only accepted as service identities if the provider allows them.
+// The granted scopes are returned as an unsaved API token, so that they can be checked like named API tokens.
+// Rejected tokens count as failed logins of the source address.
+func (a *Authenticator) AuthenticateBearerToken(ctx context.Context, rawToken, sourceAddr string) (
+	*domain.User,
+	*domain.ApiToken,
+	error,
+) {
+	if err := a.users.CheckLoginAllowed(ctx, "", sourceAddr); err != nil {
+		return nil, nil, err
+	}
+
+	var verifyErrs []error
+	for _, provider := range a.bearerAuthenticators {
+		claims, expiry, err := provider.VerifyBearerToken(ctx, rawToken)
+		if err != nil {
+			verifyErrs = append(verifyErrs, fmt.Errorf("%s: %w", provider.GetName(), err))
+			continue // the token might have been issued by another provider
+		}
+
+		user, token, err := a.bearerIdentity(ctx, provider, claims, expiry)
+		if err != nil {
+			a.users.RegisterLoginFailure(ctx, "", sourceAddr)
+			a.bus.Publish(app.TopicAuditLoginFailed, domain.AuditEventWrapper[audit.AuthEvent]{
+				Ctx:    ctx,
+				Source: "bearer " + provider.GetName(),
+				Event: audit.AuthEvent{
+					Username: fmt.Sprintf("%v", claims["sub"]),
+					Error:    fmt.Sprintf("%v (source %s)", err, sourceAddr),
+				},
+			})
+			return nil, nil, err
+		}
+
+		return user, token, nil
+	}
+
+	a.users.RegisterLoginFailure(ctx, "", sourceAddr)
+
+	return nil, nil, errors.Join(append(verifyErrs, domain.ErrApiTokenInvalid)...)
+}
+
+func (a *Authenticator) bearerIdentity(
+	ctx context.Context,
+	provider AuthenticatorBearer,
+	claims map[string]any,
+	expiry time.Time,
+) (*domain.User, *domain.ApiToken, error) {
+	userInfo, err := provider.ParseUserInfo(claims)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse token claims: %w", err)
+	}
+	if userInfo.Identifier == "" {
+		return nil, nil, errors.New("token does not contain a user identifier")
+	}
+
+	scopes := provider.ParseBearerScopes(claims)
+	if len(scopes) == 0 {
+		return nil, nil, errors.New("token does not grant any API scope")
+	}
+
+	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo()) // switch to admin user context
+	user, err := a.users.GetUser(ctx, userInfo.Identifier)
+	switch {
+	case err == nil && (user.IsDisabled() || user.IsLocked()):
+		return nil, nil, errors.New("user disabled or locked")
+	case errors.Is(err, domain.ErrNotFound) && provider.ServiceIdentitiesAllowed():
+		// service identities only exist for the duration of the request
+		user = &domain.User{
+			Identifier:   userInfo.Identifier,
+			Email:        userInfo.Email,
+			Source:       domain.UserSourceOauth,
+			ProviderName: provider.GetName(),
+			IsAdmin:      userInfo.IsAdmin,
+			Roles:        userInfo.Roles,
+			Firstname:    userInfo.Firstname,
+			Lastname:     userInfo.Lastname,
+		}
+	case err != nil:
+		return nil, nil, fmt.Errorf("unable to find user %s: %w", userInfo.Identifier, err)
+	}
+
+	token := &domain.ApiToken{
+		UserIdentifier: user.Identifier,
+		Name:           "bearer " + provider.GetName(),
+		Scopes:         scopes,
+		ExpiresAt:      &expiry,
+	}
+
+	return user, token, nil
+}
+
+// endregion bearer authentication
@@ -30,6 +30,11 @@ func newLdapAuthenticator(_ context.Context, cfg *config.LdapProvider) (*LdapAut
 	}
 	provider.cfg.FieldMap = provider.getLdapFieldMapping(cfg.FieldMap)
 	provider.cfg.ParsedAdminGroupDN = dn
+	roleGroups, err := internal.LdapParseRoleGroups(cfg.RoleGroups)
+	if err != nil {
+		return nil, err
+	}
+	provider.cfg.ParsedRoleGroupDNs = roleGroups
 
 	return provider, nil
 }
@@ -139,6 +144,10 @@ func (l LdapAuthenticator) ParseUserInfo(raw map[string]any) (*domain.Authentica
 	if err != nil {
 		return nil, fmt.Errorf("failed to check admin group: %w", err)
 	}
+	roles, err := internal.LdapMemberRoles(raw[l.cfg.FieldMap.GroupMembership].([][]byte), l.cfg.ParsedRoleGroupDNs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check role groups: %w", err)
+	}
 	userInfo := &domain.AuthenticatorUserInfo{
 		Identifier: domain.UserIdentifier(internal.MapDefaultString(raw, l.cfg.FieldMap.UserIdentifier, "")),
 		Email:      internal.MapDefaultString(raw, l.cfg.FieldMap.Email, ""),
@@ -147,6 +156,9 @@ func (l LdapAuthenticator) ParseUserInfo(raw map[string]any) (*domain.Authentica
 		Phone:      internal.MapDefaultString(raw, l.cfg.FieldMap.Phone, ""),
 		Department: internal.MapDefaultString(raw, l.cfg.FieldMap.Department, ""),
 		IsAdmin:    isAdmin,
+
+		Roles:       domain.ParseRoles(roles...),
+		RolesMapped: len(l.cfg.RoleGroups) > 0,
 	}
 
 	return userInfo, nil
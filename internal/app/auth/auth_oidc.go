@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"golang.org/x/oauth2"
@@ -25,6 +26,11 @@ type OidcAuthenticator struct {
 	registrationEnabled bool
 	userInfoLogging     bool
 	allowedDomains      []string
+
+	// bearer token validation for the REST API, the verifier is nil if it is disabled
+	bearerVerifier          *oidc.IDTokenVerifier
+	bearerScopesClaim       string
+	bearerServiceIdentities bool
 }
 
 func newOidcAuthenticator(
@@ -60,6 +66,22 @@ func newOidcAuthenticator(
 	provider.userInfoLogging = cfg.LogUserInfo
 	provider.allowedDomains = cfg.AllowedDomains
 
+	if cfg.ApiBearer.Enabled {
+		audience := cfg.ApiBearer.Audience
+		if audience == "" {
+			audience = cfg.ClientID
+		}
+		// the verifier uses the cached key set of the provider, unknown key ids trigger a refresh of the JWKS
+		provider.bearerVerifier = provider.provider.Verifier(&oidc.Config{
+			ClientID: audience,
+		})
+		provider.bearerScopesClaim = cfg.ApiBearer.ScopesClaim
+		if provider.bearerScopesClaim == "" {
+			provider.bearerScopesClaim = "scope"
+		}
+		provider.bearerServiceIdentities = cfg.ApiBearer.ServiceIdentities
+	}
+
 	return provider, nil
 }
 
@@ -131,3 +153,45 @@ func (o OidcAuthenticator) GetUserInfo(ctx context.Context, token *oauth2.Token,
 func (o OidcAuthenticator) ParseUserInfo(raw map[string]any) (*domain.AuthenticatorUserInfo, error) {
 	return parseOauthUserInfo(o.userInfoMapping, o.userAdminMapping, raw)
 }
+
+// BearerEnabled returns whether bearer tokens of this provider are accepted by the REST API.
+func (o OidcAuthenticator) BearerEnabled() bool {
+	return o.bearerVerifier != nil
+}
+
+// ServiceIdentitiesAllowed returns whether bearer tokens may belong to identities that are not stored as users.
+func (o OidcAuthenticator) ServiceIdentitiesAllowed() bool {
+	return o.bearerServiceIdentities
+}
+
+// VerifyBearerToken validates the signature, issuer, audience and expiry of the given bearer token.
+// The claims and the expiry of the token are returned.
+func (o OidcAuthenticator) VerifyBearerToken(ctx context.Context, rawToken string) (map[string]any, time.Time, error) {
+	if o.bearerVerifier == nil {
+		return nil, time.Time{}, errors.New("bearer tokens are disabled")
+	}
+
+	token, err := o.bearerVerifier.Verify(ctx, rawToken)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to validate bearer token: %w", err)
+	}
+
+	var tokenFields map[string]any
+	if err = token.Claims(&tokenFields); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to parse claims: %w", err)
+	}
+
+	if o.userInfoLogging {
+		contents, _ := json.Marshal(tokenFields)
+		slog.Debug("OIDC bearer token info",
+			"source", o.name,
+			"info", string(contents))
+	}
+
+	return tokenFields, token.Expiry, nil
+}
+
+// ParseBearerScopes returns the known API scopes that are granted by the given token claims.
+func (o OidcAuthenticator) ParseBearerScopes(raw map[string]any) []domain.ApiTokenScope {
+	return parseBearerScopes(o.bearerScopesClaim, raw)
+}
@@ -0,0 +1,306 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// testIssuer is a minimal OIDC issuer that publishes its signing keys and signs tokens.
+type testIssuer struct {
+	*httptest.Server
+
+	mux         sync.Mutex
+	keys        map[string]*rsa.PrivateKey
+	jwksFetches int
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	issuer := &testIssuer{keys: map[string]*rsa.PrivateKey{}}
+	issuer.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"issuer":                 issuer.URL,
+				"authorization_endpoint": issuer.URL + "/auth",
+				"token_endpoint":         issuer.URL + "/token",
+				"jwks_uri":               issuer.URL + "/keys",
+			})
+		case "/keys":
+			_ = json.NewEncoder(w).Encode(issuer.jwks())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(issuer.Close)
+
+	return issuer
+}
+
+// rotate adds a new signing key, the old keys are no longer published.
+func (i *testIssuer) rotate(t *testing.T, kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	i.mux.Lock()
+	defer i.mux.Unlock()
+	i.keys = map[string]*rsa.PrivateKey{kid: key}
+}
+
+func (i *testIssuer) jwks() map[string]any {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+	i.jwksFetches++
+
+	keys := make([]map[string]any, 0, len(i.keys))
+	for kid, key := range i.keys {
+		keys = append(keys, map[string]any{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": kid,
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+
+	return map[string]any{"keys": keys}
+}
+
+func (i *testIssuer) fetches() int {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+	return i.jwksFetches
+}
+
+func (i *testIssuer) sign(t *testing.T, kid string, claims map[string]any) string {
+	i.mux.Lock()
+	key := i.keys[kid]
+	i.mux.Unlock()
+	require.NotNil(t, key)
+
+	header, _ := json.Marshal(map[string]any{"alg": "RS256", "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (i *testIssuer) claims(sub, audience string, extra map[string]any) map[string]any {
+	claims := map[string]any{
+		"iss": i.URL,
+		"sub": sub,
+		"aud": audience,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+
+	return claims
+}
+
+type testUserManager struct {
+	users     map[domain.UserIdentifier]*domain.User
+	failures  map[string]int // failed logins per source address, only recorded if set
+	throttled bool
+}
+
+func (m testUserManager) GetUser(_ context.Context, id domain.UserIdentifier) (*domain.User, error) {
+	if user, ok := m.users[id]; ok {
+		return user, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m testUserManager) RegisterUser(_ context.Context, user *domain.User) error {
+	m.users[user.Identifier] = user
+	return nil
+}
+
+func (m testUserManager) UpdateUser(_ context.Context, user *domain.User) (*domain.User, error) {
+	m.users[user.Identifier] = user
+	return user, nil
+}
+
+func (m testUserManager) CheckLoginAllowed(context.Context, domain.UserIdentifier, string) error {
+	if m.throttled {
+		return domain.ErrLoginThrottled
+	}
+	return nil
+}
+
+func (m testUserManager) RegisterLoginFailure(_ context.Context, _ domain.UserIdentifier, sourceAddr string) {
+	if m.failures != nil {
+		m.failures[sourceAddr]++
+	}
+}
+
+func (m testUserManager) RegisterLoginSuccess(context.Context, *domain.User) {}
+
+func (m testUserManager) VerifySecondFactor(context.Context, domain.UserIdentifier, string, string) (
+	*domain.User,
+	domain.SecondFactorMethod,
+	error,
+) {
+	return nil, "", domain.ErrSecondFactorInvalid
+}
+
+func (m testUserManager) StartTotpEnrollment(context.Context, domain.UserIdentifier) (*domain.TotpEnrollment, error) {
+	return nil, domain.ErrInvalidData
+}
+
+func (m testUserManager) FinishTotpEnrollment(context.Context, domain.UserIdentifier, string) ([]string, error) {
+	return nil, domain.ErrInvalidData
+}
+
+type testEventBus struct {
+	topics []string
+}
+
+func (b *testEventBus) Publish(topic string, _ ...any) {
+	b.topics = append(b.topics, topic)
+}
+
+func TestAuthenticator_AuthenticateBearerToken(t *testing.T) {
+	issuer := newTestIssuer(t)
+	issuer.rotate(t, "key-1")
+
+	provider, err := newOidcAuthenticator(context.Background(), "http://localhost/callback",
+		&config.OpenIDConnectProvider{
+			ProviderName: "idp",
+			BaseUrl:      issuer.URL,
+			ClientID:     "wg-portal",
+			FieldMap: config.OauthFields{
+				IsAdmin: "wg_admin",
+				Roles:   "wg_roles",
+			},
+			ApiBearer: config.OidcApiBearer{
+				Enabled:           true,
+				Audience:          "wg-portal-api",
+				ServiceIdentities: true,
+			},
+		})
+	require.NoError(t, err)
+	require.True(t, provider.BearerEnabled())
+
+	bus := &testEventBus{}
+	users := testUserManager{
+		users: map[domain.UserIdentifier]*domain.User{
+			"alice":  {Identifier: "alice"},
+			"locked": {Identifier: "locked", Locked: &time.Time{}},
+		},
+		failures: map[string]int{},
+	}
+	a := &Authenticator{
+		bus:                  bus,
+		users:                users,
+		bearerAuthenticators: []AuthenticatorBearer{provider},
+	}
+	ctx := context.Background()
+
+	// existing user, scopes from a space separated claim
+	token := issuer.sign(t, "key-1", issuer.claims("alice", "wg-portal-api",
+		map[string]any{"scope": "openid peers:read metrics"}))
+	user, apiToken, err := a.AuthenticateBearerToken(ctx, token, "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.UserIdentifier("alice"), user.Identifier)
+	assert.Equal(t, []domain.ApiTokenScope{domain.ApiTokenScopePeersRead, domain.ApiTokenScopeMetrics}, apiToken.Scopes)
+	assert.NotNil(t, apiToken.ExpiresAt)
+	assert.Equal(t, 1, issuer.fetches())
+
+	// service identity, admin flag and roles are mapped from the claims, the cached keys are used
+	token = issuer.sign(t, "key-1", issuer.claims("service-mesh", "wg-portal-api",
+		map[string]any{"scope": []string{"interfaces:read"}, "wg_roles": "operator:wg0"}))
+	user, apiToken, err = a.AuthenticateBearerToken(ctx, token, "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.UserIdentifier("service-mesh"), user.Identifier)
+	assert.False(t, user.IsAdmin)
+	assert.Equal(t, []domain.Role{domain.OperatorRole("wg0")}, user.Roles)
+	assert.True(t, apiToken.HasScope(domain.ApiTokenScopeInterfacesRead))
+	assert.Equal(t, 1, issuer.fetches())
+
+	// wrong audience and expired tokens are rejected
+	token = issuer.sign(t, "key-1", issuer.claims("alice", "wg-portal", map[string]any{"scope": "peers:read"}))
+	_, _, err = a.AuthenticateBearerToken(ctx, token, "127.0.0.1")
+	assert.ErrorIs(t, err, domain.ErrApiTokenInvalid)
+	token = issuer.sign(t, "key-1", issuer.claims("alice", "wg-portal-api",
+		map[string]any{"scope": "peers:read", "exp": time.Now().Add(-time.Minute).Unix()}))
+	_, _, err = a.AuthenticateBearerToken(ctx, token, "127.0.0.1")
+	assert.ErrorIs(t, err, domain.ErrApiTokenInvalid)
+
+	// valid tokens without scopes or of locked users are rejected and audited
+	token = issuer.sign(t, "key-1", issuer.claims("alice", "wg-portal-api", nil))
+	_, _, err = a.AuthenticateBearerToken(ctx, token, "127.0.0.1")
+	assert.Error(t, err)
+	token = issuer.sign(t, "key-1", issuer.claims("locked", "wg-portal-api", map[string]any{"scope": "peers:read"}))
+	_, _, err = a.AuthenticateBearerToken(ctx, token, "127.0.0.1")
+	assert.Error(t, err)
+	assert.Len(t, bus.topics, 2)
+
+	// all rejected tokens count as failed logins of the source address
+	assert.Equal(t, 4, users.failures["127.0.0.1"])
+
+	// throttled source addresses are rejected before the token is checked
+	users.throttled = true
+	a.users = users
+	_, _, err = a.AuthenticateBearerToken(ctx, token, "127.0.0.1")
+	assert.ErrorIs(t, err, domain.ErrLoginThrottled)
+	assert.Equal(t, 4, users.failures["127.0.0.1"])
+	users.throttled = false
+	a.users = users
+
+	// after a key rotation, the new keys are fetched
+	issuer.rotate(t, "key-2")
+	token = issuer.sign(t, "key-2", issuer.claims("alice", "wg-portal-api", map[string]any{"scope": "peers:read"}))
+	user, _, err = a.AuthenticateBearerToken(ctx, token, "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.UserIdentifier("alice"), user.Identifier)
+	assert.Equal(t, 2, issuer.fetches())
+}
+
+func TestAuthenticator_AuthenticateBearerToken_unknownUser(t *testing.T) {
+	issuer := newTestIssuer(t)
+	issuer.rotate(t, "key-1")
+
+	provider, err := newOidcAuthenticator(context.Background(), "http://localhost/callback",
+		&config.OpenIDConnectProvider{
+			ProviderName: "idp",
+			BaseUrl:      issuer.URL,
+			ClientID:     "wg-portal",
+			ApiBearer:    config.OidcApiBearer{Enabled: true},
+		})
+	require.NoError(t, err)
+
+	a := &Authenticator{
+		bus:                  &testEventBus{},
+		users:                testUserManager{users: map[domain.UserIdentifier]*domain.User{}},
+		bearerAuthenticators: []AuthenticatorBearer{provider},
+	}
+
+	token := issuer.sign(t, "key-1", issuer.claims("bob", "wg-portal", map[string]any{"scope": "peers:read"}))
+	_, _, err = a.AuthenticateBearerToken(context.Background(), token, "127.0.0.1")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"slices"
 	"strings"
 
 	"github.com/h44z/wg-portal/internal"
@@ -36,6 +37,26 @@ func parseOauthUserInfo(
 		}
 	}
 
+	// roles can be listed directly or can be mapped from the user's groups
+	var roleNames []string
+	rolesMapped := mapping.Roles != "" || (mapping.UserGroups != "" && len(adminMapping.RoleGroups) > 0)
+	if mapping.Roles != "" {
+		roleNames = append(roleNames, internal.MapDefaultStringSlice(raw, mapping.Roles, nil)...)
+	}
+	if mapping.UserGroups != "" && len(adminMapping.RoleGroups) > 0 {
+		userGroups := internal.MapDefaultStringSlice(raw, mapping.UserGroups, nil)
+		for role, re := range adminMapping.GetRoleGroupRegex() {
+			for _, group := range userGroups {
+				if re.MatchString(strings.TrimSpace(group)) {
+					roleNames = append(roleNames, role)
+					break
+				}
+			}
+		}
+	}
+	roles := domain.ParseRoles(roleNames...)
+	slices.Sort(roles) // map iteration order is random
+
 	userInfo := &domain.AuthenticatorUserInfo{
 		Identifier: domain.UserIdentifier(internal.MapDefaultString(raw, mapping.UserIdentifier, "")),
 		Email:      internal.MapDefaultString(raw, mapping.Email, ""),
@@ -44,11 +65,30 @@ func parseOauthUserInfo(
 		Phone:      internal.MapDefaultString(raw, mapping.Phone, ""),
 		Department: internal.MapDefaultString(raw, mapping.Department, ""),
 		IsAdmin:    isAdmin,
+
+		Roles:       roles,
+		RolesMapped: rolesMapped,
 	}
 
 	return userInfo, nil
 }
 
+// parseBearerScopes extracts the API scopes from the given claim. The claim can either be a space separated string
+// or a list of scopes. Unknown scopes are ignored.
+func parseBearerScopes(claim string, raw map[string]any) []domain.ApiTokenScope {
+	var scopes []domain.ApiTokenScope
+	for _, value := range internal.MapDefaultStringSlice(raw, claim, nil) {
+		for _, field := range strings.Fields(value) {
+			scope := domain.ApiTokenScope(field)
+			if slices.Contains(domain.ApiTokenScopes, scope) && !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+
+	return scopes
+}
+
 // getOauthFieldMapping returns the default field mapping for the oauth provider
 func getOauthFieldMapping(f config.OauthFields) config.OauthFields {
 	defaultMap := config.OauthFields{
@@ -87,6 +127,9 @@ func getOauthFieldMapping(f config.OauthFields) config.OauthFields {
 	if f.UserGroups != "" {
 		defaultMap.UserGroups = f.UserGroups
 	}
+	if f.Roles != "" {
+		defaultMap.Roles = f.Roles
+	}
 
 	return defaultMap
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
 )
 
 func Test_parseOauthUserInfo_no_admin(t *testing.T) {
@@ -181,3 +182,45 @@ func Test_parseOauthUserInfo_admin_value_custom(t *testing.T) {
 	assert.Equal(t, info.Lastname, "")
 	assert.Equal(t, info.Email, "test@mydomain.net")
 }
+
+func Test_parseOauthUserInfo_roles(t *testing.T) {
+	userInfoStr := `
+{
+  "email": "test@mydomain.net",
+  "groups": [
+    "wgportal-helpdesk@mydomain.net",
+    "wgportal-wg0@mydomain.net"
+  ],
+  "roles": ["auditor", "unknown"],
+  "name": "Test User",
+  "sub": "REDACTED"
+}
+`
+
+	userInfo := map[string]any{}
+	err := json.Unmarshal([]byte(userInfoStr), &userInfo)
+	require.NoError(t, err)
+
+	fieldMapping := getOauthFieldMapping(config.OauthFields{
+		BaseFields: config.BaseFields{
+			UserIdentifier: "email",
+			Email:          "email",
+			Firstname:      "name",
+		},
+		UserGroups: "groups",
+		Roles:      "roles",
+	})
+	adminMapping := &config.OauthAdminMapping{
+		RoleGroups: map[string]string{
+			"helpdesk":     "^wgportal-helpdesk@mydomain.net$",
+			"operator:wg0": "^wgportal-wg0@mydomain.net$",
+			"operator:wg1": "^wgportal-wg1@mydomain.net$",
+		},
+	}
+
+	info, err := parseOauthUserInfo(fieldMapping, adminMapping, userInfo)
+	assert.NoError(t, err)
+	assert.False(t, info.IsAdmin)
+	assert.True(t, info.RolesMapped)
+	assert.Equal(t, []domain.Role{domain.RoleAuditor, domain.RoleHelpdesk, domain.OperatorRole("wg0")}, info.Roles)
+}
@@ -25,6 +25,8 @@ type WebAuthnUserManager interface {
 	GetUserByWebAuthnCredential(ctx context.Context, credentialIdBase64 string) (*domain.User, error)
 	// UpdateUser updates an existing user in the database.
 	UpdateUser(ctx context.Context, user *domain.User) (*domain.User, error)
+	// RegisterLoginSuccess resets the failed logins of the account.
+	RegisterLoginSuccess(ctx context.Context, user *domain.User)
 }
 
 type WebAuthnAuthenticator struct {
@@ -285,6 +287,88 @@ func (a *WebAuthnAuthenticator) FinishWebAuthnLogin(
 	return user, nil
 }
 
+// StartWebAuthnSecondFactor starts the passkey verification of a user that completes a password login.
+func (a *WebAuthnAuthenticator) StartWebAuthnSecondFactor(ctx context.Context, userId domain.UserIdentifier) (
+	optionsAsJSON []byte,
+	sessionDataAsJSON []byte,
+	err error,
+) {
+	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo())
+
+	user, err := a.users.GetUser(ctx, userId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if len(user.WebAuthnCredentialList) == 0 {
+		return nil, nil, errors.New("no passkey registered")
+	}
+
+	options, sessionData, err := a.webAuthn.BeginLogin(user)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin WebAuthn login: %w", err)
+	}
+
+	optionsAsJSON, err = json.Marshal(options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal webauthn options to JSON: %w", err)
+	}
+	sessionDataAsJSON, err = json.Marshal(sessionData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal webauthn session data to JSON: %w", err)
+	}
+
+	return optionsAsJSON, sessionDataAsJSON, nil
+}
+
+// FinishWebAuthnSecondFactor completes the password login of a user with one of the passkeys of the user.
+func (a *WebAuthnAuthenticator) FinishWebAuthnSecondFactor(
+	ctx context.Context,
+	userId domain.UserIdentifier,
+	sessionDataAsJSON []byte,
+	r *http.Request,
+) (*domain.User, error) {
+	var webAuthnData webauthn.SessionData
+	err := json.Unmarshal(sessionDataAsJSON, &webAuthnData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webauthn session data: %w", err)
+	}
+
+	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo())
+
+	user, err := a.users.GetUser(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.IsLocked() || user.IsDisabled() {
+		err = errors.New("user is locked")
+	} else {
+		_, err = a.webAuthn.FinishLogin(user, webAuthnData, r)
+	}
+	if err != nil {
+		a.bus.Publish(app.TopicAuditLoginFailed, domain.AuditEventWrapper[audit.AuthEvent]{
+			Ctx:    ctx,
+			Source: "passkey",
+			Event: audit.AuthEvent{
+				Username: string(user.Identifier), Error: err.Error(),
+			},
+		})
+		return nil, err
+	}
+
+	a.users.RegisterLoginSuccess(ctx, user)
+
+	a.bus.Publish(app.TopicAuthLogin, user.Identifier)
+	a.bus.Publish(app.TopicAuditLoginSuccess, domain.AuditEventWrapper[audit.AuthEvent]{
+		Ctx:    ctx,
+		Source: "plain+" + string(domain.SecondFactorWebAuthn),
+		Event: audit.AuthEvent{
+			Username: string(user.Identifier),
+		},
+	})
+
+	return user, nil
+}
+
 func (a *WebAuthnAuthenticator) findUserForWebAuthnSecretFn(ctx context.Context) func(rawID, userHandle []byte) (
 	user webauthn.User,
 	err error,
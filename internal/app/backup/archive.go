@@ -0,0 +1,301 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/h44z/wg-portal/internal/adapters"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// ArchiveVersion is the version of the backup archive format.
+const ArchiveVersion = 1
+
+// batchSize limits the number of rows that are inserted with a single statement.
+const batchSize = 100
+
+// Archive is the portable backup file. The content is compressed and encrypted with the backup passphrase,
+// secrets that are encrypted in the database (private and pre-shared keys) are stored in plain text before
+// the archive encryption is applied.
+type Archive struct {
+	Version       int                      `json:"Version"`
+	CreatedAt     time.Time                `json:"CreatedAt"`
+	SchemaVersion uint64                   `json:"SchemaVersion"`
+	Encryption    *domain.BundleEncryption `json:"Encryption"`
+}
+
+// Content contains all entities of the database.
+type Content struct {
+	Users                   []user                          `json:"Users"`
+	WebauthnCredentials     []domain.UserWebauthnCredential `json:"WebauthnCredentials"`
+	ApiTokens               []domain.ApiToken               `json:"ApiTokens"`
+	Interfaces              []domain.Interface              `json:"Interfaces"`
+	Peers                   []domain.Peer                   `json:"Peers"`
+	PeerTags                []domain.PeerTag                `json:"PeerTags"`
+	PeerShareLinks          []domain.PeerShareLink          `json:"PeerShareLinks"`
+	PeerExpiryNotifications []domain.PeerExpiryNotification `json:"PeerExpiryNotifications"`
+	IpRanges                []domain.IpRange                `json:"IpRanges"`
+	IpReservations          []domain.IpReservation          `json:"IpReservations"`
+	SiteNetworks            []domain.SiteNetwork            `json:"SiteNetworks"`
+	Sites                   []domain.Site                   `json:"Sites"`
+	SiteLinks               []domain.SiteLink               `json:"SiteLinks"`
+	PeerStatuses            []peerStatus                    `json:"PeerStatuses"`
+	InterfaceStatuses       []domain.InterfaceStatus        `json:"InterfaceStatuses"`
+	PeerSessions            []domain.PeerSession            `json:"PeerSessions"`
+	TrafficSamples          []domain.TrafficSample          `json:"TrafficSamples"`
+	AuditEntries            []domain.AuditEntry             `json:"AuditEntries"`
+}
+
+// user keeps the password hash, it is hidden in the JSON representation of domain users.
+type user struct {
+	domain.User
+	Password string `json:"Password"`
+}
+
+// peerStatus keeps the update timestamp, it is hidden in the JSON representation of peer states.
+type peerStatus struct {
+	domain.PeerStatus
+	UpdatedAt time.Time `json:"UpdatedAt"`
+}
+
+// Counts returns the number of stored entities per type.
+func (c *Content) Counts() map[string]int {
+	return map[string]int{
+		"users":                     len(c.Users),
+		"webauthn_credentials":      len(c.WebauthnCredentials),
+		"api_tokens":                len(c.ApiTokens),
+		"interfaces":                len(c.Interfaces),
+		"peers":                     len(c.Peers),
+		"peer_tags":                 len(c.PeerTags),
+		"peer_share_links":          len(c.PeerShareLinks),
+		"peer_expiry_notifications": len(c.PeerExpiryNotifications),
+		"ip_ranges":                 len(c.IpRanges),
+		"ip_reservations":           len(c.IpReservations),
+		"site_networks":             len(c.SiteNetworks),
+		"sites":                     len(c.Sites),
+		"site_links":                len(c.SiteLinks),
+		"peer_statuses":             len(c.PeerStatuses),
+		"interface_statuses":        len(c.InterfaceStatuses),
+		"peer_sessions":             len(c.PeerSessions),
+		"traffic_samples":           len(c.TrafficSamples),
+		"audit_entries":             len(c.AuditEntries),
+	}
+}
+
+// Dump reads all entities from the database. The database must use the encstr serializer of the running
+// instance, so that encrypted values are decrypted while reading.
+func Dump(ctx context.Context, db *gorm.DB) (*Content, error) {
+	db = db.WithContext(ctx)
+	content := &Content{}
+
+	var users []domain.User
+	err := errors.Join(
+		db.Find(&users).Error,
+		db.Find(&content.WebauthnCredentials).Error,
+		db.Find(&content.ApiTokens).Error,
+		db.Preload("Addresses").Find(&content.Interfaces).Error,
+		db.Preload("Addresses").Find(&content.Peers).Error,
+		db.Find(&content.PeerTags).Error,
+		db.Find(&content.PeerShareLinks).Error,
+		db.Find(&content.PeerExpiryNotifications).Error,
+		db.Find(&content.IpRanges).Error,
+		db.Find(&content.IpReservations).Error,
+		db.Find(&content.SiteNetworks).Error,
+		db.Find(&content.Sites).Error,
+		db.Find(&content.SiteLinks).Error,
+		db.Find(&content.InterfaceStatuses).Error,
+		db.Find(&content.PeerSessions).Error,
+		db.Find(&content.TrafficSamples).Error,
+		db.Find(&content.AuditEntries).Error,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database: %w", err)
+	}
+
+	var states []domain.PeerStatus
+	if err := db.Find(&states).Error; err != nil {
+		return nil, fmt.Errorf("failed to read peer states: %w", err)
+	}
+
+	content.Users = make([]user, len(users))
+	for i := range users {
+		content.Users[i] = user{User: users[i], Password: string(users[i].Password)}
+	}
+	content.PeerStatuses = make([]peerStatus, len(states))
+	for i := range states {
+		content.PeerStatuses[i] = peerStatus{PeerStatus: states[i], UpdatedAt: states[i].UpdatedAt}
+	}
+
+	return content, nil
+}
+
+// Load stores all entities in the database. The database must be empty, the encstr serializer of the running
+// instance encrypts the secrets with the database encryption passphrase.
+func Load(ctx context.Context, db *gorm.DB, content *Content) error {
+	for _, model := range []any{&domain.User{}, &domain.Interface{}, &domain.Peer{}} {
+		var count int64
+		if err := db.WithContext(ctx).Model(model).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check target database: %w", err)
+		}
+		if count > 0 {
+			return fmt.Errorf("target database is not empty: %w", domain.ErrDuplicateEntry)
+		}
+	}
+
+	users := make([]domain.User, len(content.Users))
+	for i := range content.Users {
+		users[i] = content.Users[i].User
+		users[i].Password = domain.PrivateString(content.Users[i].Password)
+		users[i].WebAuthnCredentialList = nil // restored separately
+	}
+	states := make([]domain.PeerStatus, len(content.PeerStatuses))
+	for i := range content.PeerStatuses {
+		states[i] = content.PeerStatuses[i].PeerStatus
+		states[i].UpdatedAt = content.PeerStatuses[i].UpdatedAt
+	}
+
+	// generated identifiers are assigned by the target database, so that its sequences stay valid
+	for i := range content.PeerSessions {
+		content.PeerSessions[i].Id = 0
+	}
+	for i := range content.TrafficSamples {
+		content.TrafficSamples[i].Id = 0
+	}
+	for i := range content.AuditEntries {
+		content.AuditEntries[i].UniqueId = 0
+	}
+
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return errors.Join(
+			createAll(tx, users),
+			createAll(tx, content.WebauthnCredentials),
+			createAll(tx, content.ApiTokens),
+			createAll(tx, content.Interfaces), // includes the interface addresses
+			createAll(tx, content.Peers),      // includes the peer addresses
+			createAll(tx, content.PeerTags),
+			createAll(tx, content.PeerShareLinks),
+			createAll(tx, content.PeerExpiryNotifications),
+			createAll(tx, content.IpRanges),
+			createAll(tx, content.IpReservations),
+			createAll(tx, content.SiteNetworks),
+			createAll(tx, content.Sites),
+			createAll(tx, content.SiteLinks),
+			createAll(tx, states),
+			createAll(tx, content.InterfaceStatuses),
+			createAll(tx, content.PeerSessions),
+			createAll(tx, content.TrafficSamples),
+			createAll(tx, content.AuditEntries),
+		)
+	})
+}
+
+func createAll[T any](tx *gorm.DB, rows []T) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	if err := tx.CreateInBatches(&rows, batchSize).Error; err != nil {
+		var model T
+		return fmt.Errorf("failed to restore %T: %w", model, err)
+	}
+
+	return nil
+}
+
+// Write dumps the database and writes an encrypted archive to w.
+func Write(ctx context.Context, db *gorm.DB, w io.Writer, passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("missing backup passphrase: %w", domain.ErrInvalidData)
+	}
+
+	content, err := Dump(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	return writeContent(w, content, passphrase)
+}
+
+// writeContent writes the content as encrypted archive to w.
+func writeContent(w io.Writer, content *Content, passphrase string) error {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if err := json.NewEncoder(zw).Encode(content); err != nil {
+		return fmt.Errorf("failed to encode backup: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress backup: %w", err)
+	}
+
+	encryption, err := domain.EncryptBundleData(compressed.Bytes(), passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt backup: %w", err)
+	}
+
+	archive := Archive{
+		Version:       ArchiveVersion,
+		CreatedAt:     time.Now().UTC(),
+		SchemaVersion: adapters.SchemaVersion,
+		Encryption:    encryption,
+	}
+
+	return json.NewEncoder(w).Encode(archive)
+}
+
+// Read decrypts the archive from r and returns its content.
+func Read(r io.Reader, passphrase string) (*Archive, *Content, error) {
+	var archive Archive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode backup: %w", errors.Join(err, domain.ErrInvalidData))
+	}
+	if archive.Version != ArchiveVersion {
+		return nil, nil, fmt.Errorf("unsupported backup version %d: %w", archive.Version, domain.ErrInvalidData)
+	}
+	if archive.SchemaVersion > adapters.SchemaVersion {
+		return nil, nil, fmt.Errorf("backup was created by a newer schema version %d: %w", archive.SchemaVersion,
+			domain.ErrInvalidData)
+	}
+	if archive.Encryption == nil {
+		return nil, nil, fmt.Errorf("backup is not encrypted: %w", domain.ErrInvalidData)
+	}
+
+	compressed, err := domain.DecryptBundleData(archive.Encryption, passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decompress backup: %w", err)
+	}
+	defer zr.Close()
+
+	var content Content
+	if err := json.NewDecoder(zr).Decode(&content); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode backup content: %w", err)
+	}
+
+	return &archive, &content, nil
+}
+
+// Restore reads the archive from r and stores its content in the empty database.
+func Restore(ctx context.Context, db *gorm.DB, r io.Reader, passphrase string) (*Content, error) {
+	_, content, err := Read(r, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Load(ctx, db, content); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
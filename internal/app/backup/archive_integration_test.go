@@ -0,0 +1,93 @@
+//go:build integration
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/h44z/wg-portal/internal/adapters"
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+func tempDatabase(t *testing.T, name string) (*gorm.DB, *adapters.SqlRepo) {
+	db, err := adapters.NewDatabase(config.DatabaseConfig{
+		Type: config.DatabaseSQLite,
+		DSN:  filepath.Join(t.TempDir(), name),
+	})
+	require.NoError(t, err)
+
+	repo, err := adapters.NewSqlRepository(db)
+	require.NoError(t, err)
+
+	return db, repo
+}
+
+func TestBackupRestore(t *testing.T) {
+	schema.RegisterSerializer("encstr", app.NewGormEncryptedStringSerializer(""))
+
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+	sourceDb, source := tempDatabase(t, "source.db")
+	targetDb, target := tempDatabase(t, "target.db")
+
+	addr, err := domain.CidrFromString("10.0.0.1/24")
+	require.NoError(t, err)
+	peerAddr, err := domain.CidrFromString("10.0.0.2/32")
+	require.NoError(t, err)
+
+	require.NoError(t, source.SaveUser(ctx, "alice", func(u *domain.User) (*domain.User, error) {
+		u.Email = "alice@example.com"
+		u.Password = "hash"
+		return u, nil
+	}))
+	require.NoError(t, source.SaveInterface(ctx, "wg0", func(in *domain.Interface) (*domain.Interface, error) {
+		in.KeyPair = domain.KeyPair{PrivateKey: "private", PublicKey: "public"}
+		in.Addresses = []domain.Cidr{addr}
+		return in, nil
+	}))
+	require.NoError(t, source.SavePeer(ctx, "peer1", func(p *domain.Peer) (*domain.Peer, error) {
+		p.InterfaceIdentifier = "wg0"
+		p.UserIdentifier = "alice"
+		p.PresharedKey = "psk"
+		p.Interface.Addresses = []domain.Cidr{peerAddr}
+		return p, nil
+	}))
+	require.NoError(t, source.SaveAuditEntry(ctx, &domain.AuditEntry{CreatedAt: time.Now(), Message: "test"}))
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(ctx, sourceDb, &buf, "backup passphrase"))
+
+	_, err = Restore(ctx, targetDb, bytes.NewReader(buf.Bytes()), "wrong passphrase")
+	assert.ErrorIs(t, err, domain.ErrInvalidData)
+
+	content, err := Restore(ctx, targetDb, bytes.NewReader(buf.Bytes()), "backup passphrase")
+	require.NoError(t, err)
+	assert.Equal(t, 1, content.Counts()["peers"])
+
+	user, err := target.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, domain.PrivateString("hash"), user.Password)
+
+	in, err := target.GetInterface(ctx, "wg0")
+	require.NoError(t, err)
+	assert.Equal(t, "private", in.PrivateKey)
+	assert.Equal(t, []domain.Cidr{addr}, in.Addresses)
+
+	peer, err := target.GetPeer(ctx, "peer1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.PreSharedKey("psk"), peer.PresharedKey)
+	assert.Equal(t, []domain.Cidr{peerAddr}, peer.Interface.Addresses)
+
+	_, err = Restore(ctx, targetDb, bytes.NewReader(buf.Bytes()), "backup passphrase")
+	assert.ErrorIs(t, err, domain.ErrDuplicateEntry, "target database is not empty")
+}
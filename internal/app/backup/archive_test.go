@@ -0,0 +1,94 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/h44z/wg-portal/internal/adapters"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+func TestWriteContent_roundTrip(t *testing.T) {
+	updated := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+	content := &Content{
+		Users: []user{{User: domain.User{Identifier: "alice", Email: "alice@example.com"}, Password: "hash"}},
+		Interfaces: []domain.Interface{
+			{Identifier: "wg0", KeyPair: domain.KeyPair{PrivateKey: "private", PublicKey: "public"}},
+		},
+		Peers: []domain.Peer{{Identifier: "peer1", InterfaceIdentifier: "wg0", PresharedKey: "psk"}},
+		PeerStatuses: []peerStatus{
+			{PeerStatus: domain.PeerStatus{PeerId: "peer1", BytesReceived: 42}, UpdatedAt: updated},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeContent(&buf, content, "backup passphrase"))
+	assert.NotContains(t, buf.String(), "private", "the content is encrypted")
+
+	archive, restored, err := Read(bytes.NewReader(buf.Bytes()), "backup passphrase")
+	require.NoError(t, err)
+	assert.Equal(t, ArchiveVersion, archive.Version)
+	assert.Equal(t, adapters.SchemaVersion, archive.SchemaVersion)
+
+	// secrets and hidden attributes are part of the archive
+	assert.Equal(t, "hash", restored.Users[0].Password)
+	assert.Equal(t, "private", restored.Interfaces[0].PrivateKey)
+	assert.Equal(t, domain.PreSharedKey("psk"), restored.Peers[0].PresharedKey)
+	assert.Equal(t, updated, restored.PeerStatuses[0].UpdatedAt)
+	assert.Equal(t, uint64(42), restored.PeerStatuses[0].BytesReceived)
+	assert.Equal(t, content.Counts(), restored.Counts())
+
+	_, _, err = Read(bytes.NewReader(buf.Bytes()), "wrong passphrase")
+	assert.ErrorIs(t, err, domain.ErrInvalidData)
+}
+
+func TestWrite_missingPassphrase(t *testing.T) {
+	// the passphrase is checked before the database is read
+	err := Write(context.Background(), nil, &bytes.Buffer{}, "")
+	assert.ErrorIs(t, err, domain.ErrInvalidData)
+}
+
+func TestRead_invalidArchive(t *testing.T) {
+	encryption, err := domain.EncryptBundleData([]byte("content"), "backup passphrase")
+	require.NoError(t, err)
+	encode := func(archive Archive) string {
+		data, err := json.Marshal(archive)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	tests := []struct {
+		name    string
+		archive string
+	}{
+		{"no json", "not a backup"},
+		{"unsupported version", encode(Archive{Version: ArchiveVersion + 1, Encryption: encryption})},
+		{"newer schema", encode(Archive{
+			Version:       ArchiveVersion,
+			SchemaVersion: adapters.SchemaVersion + 1,
+			Encryption:    encryption,
+		})},
+		{"not encrypted", encode(Archive{Version: ArchiveVersion, SchemaVersion: adapters.SchemaVersion})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Read(strings.NewReader(tt.archive), "backup passphrase")
+			assert.ErrorIs(t, err, domain.ErrInvalidData)
+		})
+	}
+
+	// the decrypted content must be a compressed backup
+	_, _, err = Read(strings.NewReader(encode(Archive{
+		Version:       ArchiveVersion,
+		SchemaVersion: adapters.SchemaVersion,
+		Encryption:    encryption,
+	})), "backup passphrase")
+	assert.Error(t, err)
+}
@@ -0,0 +1,151 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/h44z/wg-portal/internal/config"
+)
+
+const (
+	backupDirectory  = "backups"
+	backupFilePrefix = "wg-portal-backup-"
+	backupFileSuffix = ".json"
+)
+
+// Manager writes scheduled backups to the config storage path.
+type Manager struct {
+	cfg *config.Config
+
+	db *gorm.DB
+}
+
+// NewBackupManager creates a new backup manager. Scheduled backups need a config storage path, a backup
+// interval and a backup passphrase.
+func NewBackupManager(cfg *config.Config, db *gorm.DB) (*Manager, error) {
+	if cfg.Advanced.BackupInterval > 0 {
+		if cfg.Advanced.ConfigStoragePath == "" {
+			return nil, fmt.Errorf("scheduled backups require a config storage path")
+		}
+		if cfg.Advanced.BackupPassphrase == "" {
+			return nil, fmt.Errorf("scheduled backups require a backup passphrase")
+		}
+	}
+
+	return &Manager{
+		cfg: cfg,
+		db:  db,
+	}, nil
+}
+
+// StartBackgroundJobs starts the scheduled backups, if they are enabled.
+// This method is non-blocking and returns immediately.
+func (m Manager) StartBackgroundJobs(ctx context.Context) {
+	if m.cfg.Advanced.BackupInterval <= 0 {
+		return
+	}
+
+	go m.runScheduledBackups(ctx)
+}
+
+func (m Manager) runScheduledBackups(ctx context.Context) {
+	running := true
+	for running {
+		select {
+		case <-ctx.Done():
+			running = false
+			continue
+		case <-time.After(m.cfg.Advanced.BackupInterval):
+			// select blocks until one of the cases evaluate to true
+		}
+
+		file, err := m.WriteBackupFile(ctx)
+		if err != nil {
+			slog.Error("failed to write scheduled backup", "error", err)
+			continue
+		}
+		slog.Debug("wrote scheduled backup", "file", file)
+
+		if err := m.removeOldBackups(); err != nil {
+			slog.Error("failed to remove old backups", "error", err)
+		}
+	}
+}
+
+// WriteBackupFile writes a new backup to the backup directory and returns the file name.
+func (m Manager) WriteBackupFile(ctx context.Context) (string, error) {
+	dir := filepath.Join(m.cfg.Advanced.ConfigStoragePath, backupDirectory)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory %s: %w", dir, err)
+	}
+
+	name := filepath.Join(dir, backupFilePrefix+time.Now().UTC().Format("20060102T150405Z")+backupFileSuffix)
+	tmpName := name + ".tmp"
+
+	f, err := os.OpenFile(tmpName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup file: %w", err)
+	}
+
+	err = Write(ctx, m.db, f, m.cfg.Advanced.BackupPassphrase)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return "", err
+	}
+
+	// the file is only renamed once it is complete, so that partial backups are never picked up
+	if err := os.Rename(tmpName, name); err != nil {
+		return "", fmt.Errorf("failed to store backup file: %w", err)
+	}
+
+	return name, nil
+}
+
+func (m Manager) removeOldBackups() error {
+	if m.cfg.Advanced.BackupRetention <= 0 {
+		return nil
+	}
+
+	dir := filepath.Join(m.cfg.Advanced.ConfigStoragePath, backupDirectory)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range expiredBackups(entries, m.cfg.Advanced.BackupRetention) {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expiredBackups returns the names of the backup files that exceed the retention, oldest first.
+// Other files in the backup directory are ignored.
+func expiredBackups(entries []os.DirEntry, retention int) []string {
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, backupFilePrefix) && strings.HasSuffix(name, backupFileSuffix) {
+			backups = append(backups, name)
+		}
+	}
+	if len(backups) <= retention {
+		return nil
+	}
+
+	slices.Sort(backups) // file names contain the UTC timestamp, so the oldest backups come first
+	return backups[:len(backups)-retention]
+}
@@ -0,0 +1,80 @@
+package backup
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/h44z/wg-portal/internal/config"
+)
+
+func Test_expiredBackups(t *testing.T) {
+	entries, err := fs.ReadDir(fstest.MapFS{
+		"wg-portal-backup-20250312T000000Z.json": {},
+		"wg-portal-backup-20250310T000000Z.json": {},
+		"wg-portal-backup-20250311T000000Z.json": {},
+		"notes.json":                             {},
+		"wg-portal-backup-dir.json/backup":       {},
+		// incomplete backup
+		"wg-portal-backup-20250313T000000Z.json.tmp": {},
+	}, ".")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"wg-portal-backup-20250310T000000Z.json"}, expiredBackups(entries, 2))
+	assert.Equal(t, []string{
+		"wg-portal-backup-20250310T000000Z.json",
+		"wg-portal-backup-20250311T000000Z.json",
+	}, expiredBackups(entries, 1))
+	assert.Empty(t, expiredBackups(entries, 3))
+}
+
+func TestManager_removeOldBackups(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Advanced.ConfigStoragePath = t.TempDir()
+	dir := filepath.Join(cfg.Advanced.ConfigStoragePath, backupDirectory)
+	require.NoError(t, os.MkdirAll(dir, 0700))
+
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		name := backupFilePrefix + day.AddDate(0, 0, i).Format("20060102T150405Z") + backupFileSuffix
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0600))
+	}
+
+	// without retention, all backups are kept
+	m := Manager{cfg: cfg}
+	require.NoError(t, m.removeOldBackups())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	cfg.Advanced.BackupRetention = 1
+	require.NoError(t, m.removeOldBackups())
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "wg-portal-backup-20250312T000000Z.json", entries[0].Name())
+}
+
+func TestNewBackupManager(t *testing.T) {
+	cfg := &config.Config{}
+	_, err := NewBackupManager(cfg, nil)
+	assert.NoError(t, err, "scheduled backups are disabled")
+
+	cfg.Advanced.BackupInterval = time.Hour
+	_, err = NewBackupManager(cfg, nil)
+	assert.Error(t, err, "missing storage path")
+
+	cfg.Advanced.ConfigStoragePath = t.TempDir()
+	_, err = NewBackupManager(cfg, nil)
+	assert.Error(t, err, "missing passphrase")
+
+	cfg.Advanced.BackupPassphrase = "backup passphrase"
+	_, err = NewBackupManager(cfg, nil)
+	assert.NoError(t, err)
+}
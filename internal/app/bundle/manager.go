@@ -0,0 +1,337 @@
+package bundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/app/audit"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// region dependencies
+
+type DatabaseRepo interface {
+	// GetInterface returns the interface with the given identifier.
+	GetInterface(ctx context.Context, id domain.InterfaceIdentifier) (*domain.Interface, error)
+	// GetAllInterfaces returns all interfaces.
+	GetAllInterfaces(ctx context.Context) ([]domain.Interface, error)
+	// GetInterfacePeers returns all peers of the given interface.
+	GetInterfacePeers(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.Peer, error)
+	// GetPeer returns the peer with the given identifier.
+	GetPeer(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error)
+	// GetUser returns the user with the given identifier.
+	GetUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
+	// SaveInterfaceBundle stores the interface, the users and the peers of the bundle content in a single
+	// transaction. Existing users keep their local attributes like their credentials.
+	SaveInterfaceBundle(ctx context.Context, content *domain.InterfaceBundleContent) error
+}
+
+type InterfaceStateRestorer interface {
+	// RestoreInterfaceState restores the physical state of the given interfaces from the database.
+	RestoreInterfaceState(ctx context.Context, updateDbOnError bool, filter ...domain.InterfaceIdentifier) error
+}
+
+type EventBus interface {
+	// Publish sends a message to the message bus.
+	Publish(topic string, args ...any)
+}
+
+// endregion dependencies
+
+// Manager exports interfaces with their peers and users to signed bundles and imports such bundles.
+type Manager struct {
+	cfg *config.Config
+	bus EventBus
+
+	db DatabaseRepo
+	wg InterfaceStateRestorer
+}
+
+// NewBundleManager creates a new bundle manager. The interface state restorer may be nil for offline imports,
+// in this case, the physical interfaces are created on the next start of WireGuard Portal.
+func NewBundleManager(
+	cfg *config.Config,
+	bus EventBus,
+	db DatabaseRepo,
+	wg InterfaceStateRestorer,
+) (*Manager, error) {
+	m := &Manager{
+		cfg: cfg,
+		bus: bus,
+
+		db: db,
+		wg: wg,
+	}
+
+	return m, nil
+}
+
+// ExportInterface creates a signed bundle that contains the interface, all its peers and the linked users.
+// If a passphrase is given, the bundle content is encrypted.
+func (m Manager) ExportInterface(
+	ctx context.Context,
+	id domain.InterfaceIdentifier,
+	passphrase string,
+) (*domain.InterfaceBundle, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	iface, err := m.db.GetInterface(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load interface %s: %w", id, err)
+	}
+
+	peers, err := m.db.GetInterfacePeers(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load peers of interface %s: %w", id, err)
+	}
+
+	content := domain.InterfaceBundleContent{
+		Interface: *iface,
+		Peers:     peers,
+		Users:     []domain.User{},
+	}
+
+	seenUsers := make(map[domain.UserIdentifier]struct{})
+	for _, peer := range peers {
+		if _, seen := seenUsers[peer.UserIdentifier]; seen || peer.UserIdentifier == "" {
+			continue
+		}
+		seenUsers[peer.UserIdentifier] = struct{}{}
+
+		user, err := m.db.GetUser(ctx, peer.UserIdentifier)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				continue // the peer is exported without its user
+			}
+			return nil, fmt.Errorf("unable to load user %s: %w", peer.UserIdentifier, err)
+		}
+		content.Users = append(content.Users, *user)
+	}
+
+	bundle, err := domain.NewInterfaceBundle(content, m.cfg.Web.ExternalUrl, m.cfg.Advanced.BundleSigningKey,
+		passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	m.bus.Publish(app.TopicAuditInterfaceChanged, domain.AuditEventWrapper[audit.InterfaceEvent]{
+		Ctx: ctx,
+		Event: audit.InterfaceEvent{
+			Interface: *iface,
+			Action:    "export",
+		},
+	})
+
+	return bundle, nil
+}
+
+// ImportInterface verifies the bundle and stores the interface, its peers and users. Conflicts with existing
+// records are resolved according to the given options. In dry-run mode, only the conflict checks are performed.
+func (m Manager) ImportInterface(
+	ctx context.Context,
+	bundle *domain.InterfaceBundle,
+	passphrase string,
+	opts domain.BundleImportOptions,
+) (*domain.BundleImportResult, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Conflicts == "" {
+		opts.Conflicts = domain.BundleConflictFail
+	}
+
+	content, err := bundle.Open(m.cfg.Advanced.BundleSigningKey, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Interface != "" {
+		content.RenameInterface(opts.Interface)
+	}
+	if opts.ListenPort != 0 {
+		content.Interface.ListenPort = opts.ListenPort
+	}
+
+	if err := content.Interface.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid interface in bundle: %w", errors.Join(err, domain.ErrInvalidData))
+	}
+
+	if err := m.checkInterfaceConflicts(ctx, content, opts); err != nil {
+		return nil, err
+	}
+
+	result := &domain.BundleImportResult{Interface: content.Interface.Identifier, DryRun: opts.DryRun}
+
+	newUsers, err := m.checkUserConflicts(ctx, content.Users, opts.Conflicts, result)
+	if err != nil {
+		return nil, err
+	}
+
+	newPeers, err := m.checkPeerConflicts(ctx, content.Interface.Identifier, content.Peers, opts.Conflicts, result)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if err := m.storeContent(ctx, &content.Interface, newUsers, newPeers); err != nil {
+		return nil, err
+	}
+
+	m.bus.Publish(app.TopicAuditInterfaceChanged, domain.AuditEventWrapper[audit.InterfaceEvent]{
+		Ctx: ctx,
+		Event: audit.InterfaceEvent{
+			Interface: content.Interface,
+			Action:    "import",
+		},
+	})
+
+	if m.wg != nil {
+		if err := m.wg.RestoreInterfaceState(ctx, true, content.Interface.Identifier); err != nil {
+			return nil, fmt.Errorf("failed to restore state of imported interface %s: %w",
+				content.Interface.Identifier, err)
+		}
+	}
+
+	slog.Info("interface bundle imported", "interface", content.Interface.Identifier, "origin", bundle.Origin,
+		"peers", len(newPeers), "users", len(newUsers))
+
+	return result, nil
+}
+
+// checkInterfaceConflicts checks the identifier, the listen port and the networks of the imported interface.
+func (m Manager) checkInterfaceConflicts(
+	ctx context.Context,
+	content *domain.InterfaceBundleContent,
+	opts domain.BundleImportOptions,
+) error {
+	interfaces, err := m.db.GetAllInterfaces(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to load interfaces: %w", err)
+	}
+
+	imported := &content.Interface
+	for i := range interfaces {
+		existing := &interfaces[i]
+		if existing.Identifier == imported.Identifier {
+			if opts.Conflicts != domain.BundleConflictOverwrite {
+				return fmt.Errorf("interface %s already exists, choose another identifier: %w",
+					imported.Identifier, domain.ErrDuplicateEntry)
+			}
+			continue // the existing interface gets replaced
+		}
+
+		if imported.ListenPort != 0 && existing.ListenPort == imported.ListenPort &&
+			existing.AgentHost == imported.AgentHost {
+			return fmt.Errorf("listen port %d is already used by interface %s: %w",
+				imported.ListenPort, existing.Identifier, domain.ErrDuplicateEntry)
+		}
+
+		if opts.AllowOverlap {
+			continue
+		}
+		overlapping := domain.OverlappingCidrs(content.Networks(), domain.InterfaceNetworks(existing))
+		if len(overlapping) > 0 {
+			return fmt.Errorf("networks %s overlap with interface %s: %w",
+				domain.CidrsToString(overlapping), existing.Identifier, domain.ErrDuplicateEntry)
+		}
+	}
+
+	return nil
+}
+
+// checkUserConflicts returns the users that should be stored.
+func (m Manager) checkUserConflicts(
+	ctx context.Context,
+	users []domain.User,
+	strategy domain.BundleConflictStrategy,
+	result *domain.BundleImportResult,
+) ([]domain.User, error) {
+	selected := make([]domain.User, 0, len(users))
+	for _, user := range users {
+		_, err := m.db.GetUser(ctx, user.Identifier)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			result.CreatedUsers = append(result.CreatedUsers, user.Identifier)
+		case err != nil:
+			return nil, fmt.Errorf("unable to load user %s: %w", user.Identifier, err)
+		case strategy == domain.BundleConflictSkip:
+			result.SkippedUsers = append(result.SkippedUsers, user.Identifier)
+			continue
+		case strategy == domain.BundleConflictOverwrite:
+			result.OverwrittenUsers = append(result.OverwrittenUsers, user.Identifier)
+		default:
+			return nil, fmt.Errorf("user %s already exists: %w", user.Identifier, domain.ErrDuplicateEntry)
+		}
+		selected = append(selected, user)
+	}
+
+	return selected, nil
+}
+
+// checkPeerConflicts returns the peers that should be stored. Peers that exist on another interface are never
+// overwritten, as this would silently move them to the imported interface.
+func (m Manager) checkPeerConflicts(
+	ctx context.Context,
+	id domain.InterfaceIdentifier,
+	peers []domain.Peer,
+	strategy domain.BundleConflictStrategy,
+	result *domain.BundleImportResult,
+) ([]domain.Peer, error) {
+	selected := make([]domain.Peer, 0, len(peers))
+	for _, peer := range peers {
+		existing, err := m.db.GetPeer(ctx, peer.Identifier)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			result.CreatedPeers = append(result.CreatedPeers, peer.Identifier)
+		case err != nil:
+			return nil, fmt.Errorf("unable to load peer %s: %w", peer.Identifier, err)
+		case strategy == domain.BundleConflictSkip:
+			result.SkippedPeers = append(result.SkippedPeers, peer.Identifier)
+			continue
+		case existing.InterfaceIdentifier != id:
+			return nil, fmt.Errorf("peer %s already exists on interface %s: %w",
+				peer.Identifier, existing.InterfaceIdentifier, domain.ErrDuplicateEntry)
+		case strategy == domain.BundleConflictOverwrite:
+			result.OverwrittenPeers = append(result.OverwrittenPeers, peer.Identifier)
+		default:
+			return nil, fmt.Errorf("peer %s already exists: %w", peer.Identifier, domain.ErrDuplicateEntry)
+		}
+		selected = append(selected, peer)
+	}
+
+	return selected, nil
+}
+
+// storeContent stores the interface with the selected users and peers. Imported objects are not managed by the
+// declarative spec of this instance.
+func (m Manager) storeContent(
+	ctx context.Context,
+	iface *domain.Interface,
+	users []domain.User,
+	peers []domain.Peer,
+) error {
+	iface.ManagedBy = ""
+	for i := range peers {
+		peers[i].ManagedBy = ""
+	}
+
+	content := &domain.InterfaceBundleContent{Interface: *iface, Users: users, Peers: peers}
+	if err := m.db.SaveInterfaceBundle(ctx, content); err != nil {
+		return fmt.Errorf("failed to store interface %s: %w", iface.Identifier, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package bundle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/h44z/wg-portal/internal/adapters"
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type testEventBus struct{}
+
+func (testEventBus) Publish(string, ...any) {}
+
+func newTestManager(t *testing.T) (*Manager, *adapters.SqlRepo, *gorm.DB) {
+	schema.RegisterSerializer("encstr", app.NewGormEncryptedStringSerializer(""))
+	db, err := adapters.NewDatabase(config.DatabaseConfig{Type: "sqlite", DSN: t.TempDir() + "/test.db"})
+	require.NoError(t, err)
+	repo, err := adapters.NewSqlRepository(db)
+	require.NoError(t, err)
+
+	cfg := &config.Config{}
+	cfg.Advanced.BundleSigningKey = "signing-key"
+	m, err := NewBundleManager(cfg, testEventBus{}, repo, nil)
+	require.NoError(t, err)
+
+	return m, repo, db
+}
+
+func testBundle(t *testing.T, peers ...domain.PeerIdentifier) *domain.InterfaceBundle {
+	content := domain.InterfaceBundleContent{
+		Interface: domain.Interface{
+			Identifier: "wg1",
+			KeyPair:    domain.KeyPair{PrivateKey: "private", PublicKey: "public"},
+			ListenPort: 51821,
+		},
+		Users: []domain.User{{Identifier: "alice", Email: "alice@example.com"}},
+	}
+	for _, id := range peers {
+		content.Peers = append(content.Peers,
+			domain.Peer{Identifier: id, InterfaceIdentifier: "wg1", UserIdentifier: "alice"})
+	}
+
+	bundle, err := domain.NewInterfaceBundle(content, "https://origin.example.com", "signing-key", "")
+	require.NoError(t, err)
+	return bundle
+}
+
+func TestManager_ImportInterface_peerOfOtherInterface(t *testing.T) {
+	m, repo, _ := newTestManager(t)
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	err := repo.SavePeer(ctx, "peer-1", func(p *domain.Peer) (*domain.Peer, error) {
+		p.InterfaceIdentifier = "wg0"
+		return p, nil
+	})
+	require.NoError(t, err)
+
+	opts := domain.BundleImportOptions{Conflicts: domain.BundleConflictOverwrite}
+	_, err = m.ImportInterface(ctx, testBundle(t, "peer-1"), "", opts)
+	assert.ErrorIs(t, err, domain.ErrDuplicateEntry)
+
+	peer, err := repo.GetPeer(ctx, "peer-1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.InterfaceIdentifier("wg0"), peer.InterfaceIdentifier, "the peer is not moved")
+
+	// skipping the peer leaves it on its interface
+	opts.Conflicts = domain.BundleConflictSkip
+	result, err := m.ImportInterface(ctx, testBundle(t, "peer-1"), "", opts)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.PeerIdentifier{"peer-1"}, result.SkippedPeers)
+	peer, err = repo.GetPeer(ctx, "peer-1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.InterfaceIdentifier("wg0"), peer.InterfaceIdentifier)
+}
+
+func TestManager_ImportInterface_failureLeavesNoPartialImport(t *testing.T) {
+	m, repo, db := newTestManager(t)
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	err := db.Exec(`CREATE TRIGGER fail_peer BEFORE INSERT ON peers WHEN NEW.identifier = 'broken'
+		BEGIN SELECT RAISE(ABORT, 'broken peer'); END`).Error
+	require.NoError(t, err)
+
+	_, err = m.ImportInterface(ctx, testBundle(t, "peer-1", "broken"), "", domain.BundleImportOptions{})
+	require.Error(t, err)
+
+	_, err = repo.GetInterface(ctx, "wg1")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repo.GetUser(ctx, "alice")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repo.GetPeer(ctx, "peer-1")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	// the import succeeds once the failure is resolved
+	require.NoError(t, db.Exec("DROP TRIGGER fail_peer").Error)
+	result, err := m.ImportInterface(ctx, testBundle(t, "peer-1", "broken"), "", domain.BundleImportOptions{})
+	require.NoError(t, err)
+	assert.Len(t, result.CreatedPeers, 2)
+	_, err = repo.GetInterface(ctx, "wg1")
+	assert.NoError(t, err)
+}
@@ -32,6 +32,8 @@ type WireguardDatabaseRepo interface {
 	GetPeer(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error)
 	// GetInterface returns the interface with the given identifier.
 	GetInterface(ctx context.Context, id domain.InterfaceIdentifier) (*domain.Interface, error)
+	// GetSiteNetwork returns the site network with the given identifier, including its sites and links.
+	GetSiteNetwork(ctx context.Context, id domain.SiteNetworkIdentifier) (*domain.SiteNetwork, error)
 }
 
 type FileSystemRepo interface {
@@ -47,6 +49,8 @@ type TemplateRenderer interface {
 	GetInterfaceConfig(iface *domain.Interface, peers []domain.Peer) (io.Reader, error)
 	// GetPeerConfig returns the configuration file for the given peer.
 	GetPeerConfig(peer *domain.Peer, style string) (io.Reader, error)
+	// GetSiteConfig returns the configuration file for the given site of the site network.
+	GetSiteConfig(network *domain.SiteNetwork, site *domain.Site) (io.Reader, error)
 }
 
 type EventBus interface {
@@ -116,6 +120,8 @@ func (m Manager) connectToMessageBus() {
 	_ = m.bus.Subscribe(app.TopicInterfaceUpdated, m.handleInterfaceSavedEvent)
 	_ = m.bus.Subscribe(app.TopicInterfaceDeleted, m.handleInterfaceDeleteEvent)
 	_ = m.bus.Subscribe(app.TopicPeerInterfaceUpdated, m.handlePeerInterfaceUpdatedEvent)
+	_ = m.bus.Subscribe(app.TopicSiteNetworkUpdated, m.handleSiteNetworkSavedEvent)
+	_ = m.bus.Subscribe(app.TopicSiteNetworkDeleted, m.handleSiteNetworkDeleteEvent)
 }
 
 func (m Manager) handleInterfaceSavedEvent(iface domain.Interface) {
@@ -169,10 +175,31 @@ func (m Manager) handlePeerInterfaceUpdatedEvent(id domain.InterfaceIdentifier)
 	}
 }
 
+func (m Manager) handleSiteNetworkSavedEvent(network domain.SiteNetwork) {
+	slog.Debug("handling site network save event", "network", network.Identifier)
+
+	err := m.PersistSiteNetworkConfigs(context.Background(), network.Identifier)
+	if err != nil {
+		slog.Error("failed to automatically persist site configs",
+			"network", network.Identifier, "error", err)
+	}
+}
+
+func (m Manager) handleSiteNetworkDeleteEvent(network domain.SiteNetwork) {
+	slog.Debug("handling site network delete event", "network", network.Identifier)
+
+	for _, site := range network.Sites {
+		if err := m.fsRepo.DeleteFile(site.GetConfigFileName()); err != nil {
+			slog.Error("failed to remove persisted site config",
+				"network", network.Identifier, "site", site.Identifier, "error", err)
+		}
+	}
+}
+
 // GetInterfaceConfig returns the configuration file for the given interface.
 // The file is structured in wg-quick format.
 func (m Manager) GetInterfaceConfig(ctx context.Context, id domain.InterfaceIdentifier) (io.Reader, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesWrite, id); err != nil {
 		return nil, err
 	}
 
@@ -192,7 +219,9 @@ func (m Manager) GetPeerConfig(ctx context.Context, id domain.PeerIdentifier, st
 		return nil, fmt.Errorf("failed to fetch peer %s: %w", id, err)
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	err = domain.ValidateUserOrPermission(ctx, peer.UserIdentifier, domain.PermissionPeersManage,
+		peer.InterfaceIdentifier)
+	if err != nil {
 		return nil, err
 	}
 
@@ -206,7 +235,9 @@ func (m Manager) GetPeerConfigQrCode(ctx context.Context, id domain.PeerIdentifi
 		return nil, fmt.Errorf("failed to fetch peer %s: %w", id, err)
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	err = domain.ValidateUserOrPermission(ctx, peer.UserIdentifier, domain.PermissionPeersManage,
+		peer.InterfaceIdentifier)
+	if err != nil {
 		return nil, err
 	}
 
@@ -250,6 +281,57 @@ func (m Manager) GetPeerConfigQrCode(ctx context.Context, id domain.PeerIdentifi
 	return buf, nil
 }
 
+// GetSiteConfig returns the configuration file for the given site of a site network.
+// The file is structured in wg-quick format.
+func (m Manager) GetSiteConfig(
+	ctx context.Context,
+	id domain.SiteNetworkIdentifier,
+	siteId domain.SiteIdentifier,
+) (io.Reader, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	network, err := m.wg.GetSiteNetwork(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch site network %s: %w", id, err)
+	}
+
+	site, err := network.Site(siteId)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.tplHandler.GetSiteConfig(network, site)
+}
+
+// PersistSiteNetworkConfigs writes the configuration files of all sites of the given site network to the file system.
+// Sites that are linked to an interface are skipped, their configuration is written with the interface.
+func (m Manager) PersistSiteNetworkConfigs(ctx context.Context, id domain.SiteNetworkIdentifier) error {
+	network, err := m.wg.GetSiteNetwork(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to fetch site network %s: %w", id, err)
+	}
+
+	for i := range network.Sites {
+		site := &network.Sites[i]
+		if site.InterfaceIdentifier != "" {
+			continue
+		}
+
+		cfg, err := m.tplHandler.GetSiteConfig(network, site)
+		if err != nil {
+			return fmt.Errorf("failed to get site config: %w", err)
+		}
+
+		if err := m.fsRepo.WriteFile(site.GetConfigFileName(), cfg); err != nil {
+			return fmt.Errorf("failed to write site config: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // PersistInterfaceConfig writes the configuration file for the given interface to the file system.
 func (m Manager) PersistInterfaceConfig(ctx context.Context, id domain.InterfaceIdentifier) error {
 	iface, peers, err := m.wg.GetInterfaceAndPeers(ctx, id)
@@ -71,3 +71,22 @@ func (c TemplateHandler) GetPeerConfig(peer *domain.Peer, style string) (io.Read
 
 	return &tplBuff, nil
 }
+
+// GetSiteConfig returns the rendered configuration file for a site of a site network.
+func (c TemplateHandler) GetSiteConfig(network *domain.SiteNetwork, site *domain.Site) (io.Reader, error) {
+	var tplBuff bytes.Buffer
+
+	err := c.templates.ExecuteTemplate(&tplBuff, "wg_site.tpl", map[string]any{
+		"Network": network,
+		"Site":    site,
+		"Peers":   network.Peers(site.Identifier),
+		"Portal": map[string]any{
+			"Version": "unknown",
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute site template for %s: %w", site.Identifier, err)
+	}
+
+	return &tplBuff, nil
+}
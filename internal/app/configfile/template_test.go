@@ -0,0 +1,127 @@
+package configfile
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/h44z/wg-portal/internal/adapters"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+func TestTemplateHandler_GetInterfaceConfig_RoundTrip(t *testing.T) {
+	tplHandler, err := newTemplateHandler()
+	require.NoError(t, err)
+
+	created := time.Date(2024, 5, 1, 12, 30, 15, 0, time.UTC)
+	updated := created.Add(time.Hour)
+
+	ifaceKeys, _ := domain.NewFreshKeypair()
+	peerKeys, _ := domain.NewFreshKeypair()
+	psk, _ := domain.NewPreSharedKey()
+
+	iface := &domain.Interface{
+		BaseModel:    domain.BaseModel{CreatedAt: created, UpdatedAt: updated},
+		Identifier:   "wg0",
+		KeyPair:      ifaceKeys,
+		ListenPort:   51820,
+		Addresses:    []domain.Cidr{mustParseCidr(t, "10.11.12.1/24")},
+		Mtu:          1420,
+		FirewallMark: 51,
+		RoutingTable: "off",
+		PreUp:        "echo pre-up",
+		PostDown:     "echo post-down",
+		DisplayName:  "Main Interface",
+		Type:         domain.InterfaceTypeServer,
+	}
+	peers := []domain.Peer{
+		{
+			BaseModel:          domain.BaseModel{CreatedAt: created, UpdatedAt: updated},
+			Identifier:         domain.PeerIdentifier(peerKeys.PublicKey),
+			DisplayName:        "Laptop",
+			PresharedKey:       psk,
+			ExtraAllowedIPsStr: "192.168.1.0/24",
+			Interface: domain.PeerInterfaceConfig{
+				KeyPair:   peerKeys,
+				Addresses: []domain.Cidr{mustParseCidr(t, "10.11.12.2/32")},
+			},
+		},
+	}
+
+	cfg, err := tplHandler.GetInterfaceConfig(iface, peers)
+	require.NoError(t, err)
+
+	parsed, err := adapters.ParseWgQuickConfig("ignored", cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, iface.Identifier, parsed.Identifier)
+	assert.Equal(t, iface.DisplayName, parsed.DisplayName)
+	assert.Equal(t, iface.Type, parsed.Type)
+	assert.Equal(t, iface.KeyPair, parsed.KeyPair)
+	assert.Equal(t, iface.ListenPort, parsed.ListenPort)
+	assert.Equal(t, iface.Mtu, parsed.Mtu)
+	assert.Equal(t, iface.FirewallMark, parsed.FirewallMark)
+	assert.Equal(t, iface.RoutingTable, parsed.RoutingTable)
+	assert.Equal(t, iface.PreUp, parsed.PreUp)
+	assert.Equal(t, iface.PostDown, parsed.PostDown)
+	assert.Equal(t, iface.AddressStr(), domain.CidrsToString(parsed.Addresses))
+	require.NotNil(t, parsed.CreatedAt)
+	assert.True(t, created.Equal(*parsed.CreatedAt))
+	require.NotNil(t, parsed.UpdatedAt)
+	assert.True(t, updated.Equal(*parsed.UpdatedAt))
+
+	require.Len(t, parsed.Peers, 1)
+	peer := parsed.Peers[0]
+	assert.Equal(t, peers[0].Identifier, peer.Identifier)
+	assert.Equal(t, peers[0].DisplayName, peer.DisplayName)
+	assert.Equal(t, peers[0].PresharedKey, peer.PresharedKey)
+	assert.Equal(t, peerKeys, peer.KeyPair)
+	require.NotNil(t, peer.CreatedAt)
+	assert.True(t, created.Equal(*peer.CreatedAt))
+
+	addresses, extra := peer.SplitAllowedIPs(parsed.Addresses)
+	assert.Equal(t, "10.11.12.2/32", domain.CidrsToString(addresses))
+	assert.Equal(t, peers[0].ExtraAllowedIPsStr, domain.CidrsToString(extra))
+}
+
+func TestTemplateHandler_GetSiteConfig(t *testing.T) {
+	tplHandler, err := newTemplateHandler()
+	require.NoError(t, err)
+
+	hubKeys, _ := domain.NewFreshKeypair()
+	spokeKeys, _ := domain.NewFreshKeypair()
+
+	network := &domain.SiteNetwork{
+		Identifier:          "offices",
+		Topology:            domain.SiteTopologyHubAndSpoke,
+		PersistentKeepalive: 25,
+		Sites: []domain.Site{
+			{Identifier: "hq", Hub: true, KeyPair: hubKeys, Endpoint: "hq.example.com:51820", ListenPort: 51820,
+				AddressStr: "10.99.0.1/24", LanPrefixesStr: "192.168.0.0/24"},
+			{Identifier: "berlin", KeyPair: domain.KeyPair{PublicKey: spokeKeys.PublicKey},
+				AddressStr: "10.99.0.2/24", LanPrefixesStr: "192.168.1.0/24"},
+		},
+	}
+
+	cfg, err := tplHandler.GetSiteConfig(network, &network.Sites[1])
+	require.NoError(t, err)
+	data, err := io.ReadAll(cfg)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(data), "# PrivateKey = <private key of the site>")
+	assert.Contains(t, string(data), "Address = 10.99.0.2/24")
+	assert.Contains(t, string(data), "PublicKey = "+hubKeys.PublicKey)
+	assert.Contains(t, string(data), "AllowedIPs = 10.99.0.1/32,192.168.0.0/24")
+	assert.Contains(t, string(data), "Endpoint = hq.example.com:51820")
+	assert.Contains(t, string(data), "PersistentKeepalive = 25")
+	assert.NotContains(t, string(data), "ListenPort")
+}
+
+func mustParseCidr(t *testing.T, str string) domain.Cidr {
+	cidr, err := domain.CidrFromString(str)
+	require.NoError(t, err)
+	return cidr
+}
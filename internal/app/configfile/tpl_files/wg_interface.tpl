@@ -73,18 +73,19 @@ PublicKey = {{ .Interface.KeyPair.PublicKey }}
 {{- if .PresharedKey}}
 PresharedKey = {{ .PresharedKey }}
 {{- end}}
-{{- if eq $.Interface.Type "server"}}
+{{- $remoteServer := or (eq $.Interface.Type "client") (eq .Interface.Type "server")}}
+{{- if and (eq $.Interface.Type "server") (not $remoteServer)}}
 AllowedIPs = {{ CidrsToString .Interface.Addresses }}{{if ne .ExtraAllowedIPsStr ""}}, {{ .ExtraAllowedIPsStr }}{{end}}
 {{- end}}
-{{- if eq $.Interface.Type "client"}}
+{{- if $remoteServer}}
 {{- if .AllowedIPsStr.GetValue}}
 AllowedIPs = {{ .AllowedIPsStr.GetValue }}
 {{- end}}
 {{- end}}
-{{- if and (ne .Endpoint.GetValue "") (eq $.Interface.Type "client")}}
+{{- if and (ne .Endpoint.GetValue "") $remoteServer}}
 Endpoint = {{ .Endpoint.GetValue }}
 {{- end}}
-{{- if and (ne .PersistentKeepalive.GetValue 0)  (eq $.Interface.Type "client")}}
+{{- if and (ne .PersistentKeepalive.GetValue 0) $remoteServer}}
 PersistentKeepalive = {{ .PersistentKeepalive.GetValue  }}
 {{- end}}
 {{- end}}
@@ -26,9 +26,17 @@ const TopicUserEnabled = "user:enabled"
 const TopicInterfaceCreated = "interface:created"
 const TopicInterfaceUpdated = "interface:updated"
 const TopicInterfaceDeleted = "interface:deleted"
+const TopicInterfaceDriftDetected = "interface:drift:detected"
 
 // endregion interface-events
 
+// region agent-events
+
+const TopicAgentConnected = "agent:connected"
+const TopicAgentDisconnected = "agent:disconnected"
+
+// endregion agent-events
+
 // region peer-events
 
 const TopicPeerCreated = "peer:created"
@@ -37,15 +45,32 @@ const TopicPeerUpdated = "peer:updated"
 const TopicPeerInterfaceUpdated = "peer:interface:updated"
 const TopicPeerIdentifierUpdated = "peer:identifier:updated"
 const TopicPeerStateChanged = "peer:state:changed"
+const TopicPeerQuotaExceeded = "peer:quota:exceeded"
+const TopicPeerExpiryReminder = "peer:expiry:reminder"
+const TopicPeerExpired = "peer:expired"
+const TopicPeerMoved = "peer:moved"
 
 // endregion peer-events
 
+// region site-network-events
+
+const TopicSiteNetworkUpdated = "site-network:updated"
+const TopicSiteNetworkDeleted = "site-network:deleted"
+
+// endregion site-network-events
+
 // region audit-events
 
 const TopicAuditLoginSuccess = "audit:login:success"
 const TopicAuditLoginFailed = "audit:login:failed"
+const TopicAuditLoginLockout = "audit:login:lockout"
+const TopicAuditSecondFactorChanged = "audit:secondfactor:changed"
 
 const TopicAuditInterfaceChanged = "audit:interface:changed"
 const TopicAuditPeerChanged = "audit:peer:changed"
+const TopicAuditShareLinkChanged = "audit:sharelink:changed"
+const TopicAuditApiTokenChanged = "audit:apitoken:changed"
+const TopicAuditBulkAction = "audit:bulk:action"
+const TopicAuditSpecApplied = "audit:spec:applied"
 
 // endregion audit-events
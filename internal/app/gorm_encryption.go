@@ -15,13 +15,26 @@ import (
 	"github.com/h44z/wg-portal/internal/domain"
 )
 
+// LegacyEncryptionKeyId is the key id of values that were encrypted without a key id.
+const LegacyEncryptionKeyId = "legacy"
+
+const (
+	legacyEncryptionPrefix = "WG_ENC_" // WG_ENC_<ciphertext>
+	keyedEncryptionPrefix  = "WG_ENC:" // WG_ENC:<key id>:<ciphertext>
+)
+
 // GormEncryptedStringSerializer is a GORM serializer that encrypts and decrypts string values using AES256.
 // It is used to store sensitive information in the database securely.
 // If the serializer encounters a value that is not a string, it will return an error.
+//
+// Values are encrypted with the current key. If the current key has an id, the id is stored with the value,
+// so that values can be decrypted with previous keys while the database is re-encrypted.
+// Unencrypted values are read as they are.
 type GormEncryptedStringSerializer struct {
 	useEncryption bool
+	keyId         string
 	keyPhrase     string
-	prefix        string
+	previousKeys  map[string]string
 }
 
 // NewGormEncryptedStringSerializer creates a new GormEncryptedStringSerializer.
@@ -31,10 +44,25 @@ type GormEncryptedStringSerializer struct {
 //
 //	EncryptedField string `gorm:"serializer:encstr"`
 func NewGormEncryptedStringSerializer(keyPhrase string) GormEncryptedStringSerializer {
+	return NewGormKeyedEncryptedStringSerializer("", keyPhrase, nil)
+}
+
+// NewGormKeyedEncryptedStringSerializer creates a new GormEncryptedStringSerializer that encrypts with the given
+// key and decrypts with the given key or one of the previous keys. The previous keys are indexed by their key id,
+// values without key id use the LegacyEncryptionKeyId. An empty key id writes values without key id.
+func NewGormKeyedEncryptedStringSerializer(
+	keyId, keyPhrase string,
+	previousKeys map[string]string,
+) GormEncryptedStringSerializer {
+	if keyId == "" {
+		keyId = LegacyEncryptionKeyId
+	}
+
 	return GormEncryptedStringSerializer{
 		useEncryption: keyPhrase != "",
+		keyId:         keyId,
 		keyPhrase:     keyPhrase,
-		prefix:        "WG_ENC_",
+		previousKeys:  previousKeys,
 	}
 }
 
@@ -57,18 +85,7 @@ func (s GormEncryptedStringSerializer) Scan(
 		}
 	}
 
-	if !s.useEncryption {
-		field.ReflectValueOf(ctx, dst).SetString(dbStringValue) // keep the original value
-		return nil
-	}
-
-	if !strings.HasPrefix(dbStringValue, s.prefix) {
-		field.ReflectValueOf(ctx, dst).SetString(dbStringValue) // keep the original value
-		return nil
-	}
-
-	encryptedString := strings.TrimPrefix(dbStringValue, s.prefix)
-	decryptedString, err := DecryptAES256(encryptedString, s.keyPhrase)
+	decryptedString, err := s.Decrypt(dbStringValue)
 	if err != nil {
 		return fmt.Errorf("failed to decrypt value for field %s: %w", field.Name, err)
 	}
@@ -90,34 +107,93 @@ func (s GormEncryptedStringSerializer) Value(
 
 	switch v := fieldValue.(type) {
 	case string:
-		if v == "" {
-			return "", nil // empty string, no need to encrypt
-		}
-		if !s.useEncryption {
-			return v, nil // keep the original value
-		}
-		encryptedString, err := EncryptAES256(v, s.keyPhrase)
-		if err != nil {
-			return nil, err
-		}
-		return s.prefix + encryptedString, nil
+		return s.Encrypt(v)
 	case domain.PreSharedKey:
-		if v == "" {
-			return "", nil // empty string, no need to encrypt
-		}
-		if !s.useEncryption {
-			return string(v), nil // keep the original value
-		}
-		encryptedString, err := EncryptAES256(string(v), s.keyPhrase)
-		if err != nil {
-			return nil, err
-		}
-		return s.prefix + encryptedString, nil
+		return s.Encrypt(string(v))
 	default:
 		return nil, fmt.Errorf("encryption only supports string values, got %T", fieldValue)
 	}
 }
 
+// Encrypt encrypts the given value with the current key. Empty values are not encrypted.
+func (s GormEncryptedStringSerializer) Encrypt(value string) (string, error) {
+	if value == "" {
+		return "", nil // empty string, no need to encrypt
+	}
+	if !s.useEncryption {
+		return value, nil // keep the original value
+	}
+
+	encryptedString, err := EncryptAES256(value, s.keyPhrase)
+	if err != nil {
+		return "", err
+	}
+	if s.keyId == LegacyEncryptionKeyId {
+		return legacyEncryptionPrefix + encryptedString, nil
+	}
+	return keyedEncryptionPrefix + s.keyId + ":" + encryptedString, nil
+}
+
+// Decrypt decrypts the given database value with the key it was encrypted with. Unencrypted values are returned
+// as they are. If encryption is disabled and no previous keys are known, encrypted values are returned as well.
+func (s GormEncryptedStringSerializer) Decrypt(dbValue string) (string, error) {
+	keyId, encryptedString, encrypted := ParseEncryptedValue(dbValue)
+	if !encrypted {
+		return dbValue, nil
+	}
+	if !s.useEncryption && len(s.previousKeys) == 0 {
+		return dbValue, nil // keep the original value
+	}
+
+	keyPhrase, ok := s.key(keyId)
+	if !ok {
+		return "", fmt.Errorf("unknown encryption key id %q", keyId)
+	}
+
+	return DecryptAES256(encryptedString, keyPhrase)
+}
+
+// IsCurrent returns true if the given database value is stored in the format of the current key:
+// encrypted with the current key, or unencrypted if encryption is disabled.
+func (s GormEncryptedStringSerializer) IsCurrent(dbValue string) bool {
+	if dbValue == "" {
+		return true
+	}
+
+	keyId, _, encrypted := ParseEncryptedValue(dbValue)
+	if !s.useEncryption {
+		return !encrypted
+	}
+
+	return encrypted && keyId == s.keyId
+}
+
+func (s GormEncryptedStringSerializer) key(keyId string) (string, bool) {
+	if s.useEncryption && keyId == s.keyId {
+		return s.keyPhrase, true
+	}
+
+	keyPhrase, ok := s.previousKeys[keyId]
+	return keyPhrase, ok && keyPhrase != ""
+}
+
+// ParseEncryptedValue splits the given database value into the key id and the ciphertext.
+// If the value is not encrypted, encrypted is false.
+func ParseEncryptedValue(dbValue string) (keyId, ciphertext string, encrypted bool) {
+	switch {
+	case strings.HasPrefix(dbValue, legacyEncryptionPrefix):
+		return LegacyEncryptionKeyId, strings.TrimPrefix(dbValue, legacyEncryptionPrefix), true
+	case strings.HasPrefix(dbValue, keyedEncryptionPrefix):
+		keyId, ciphertext, found := strings.Cut(strings.TrimPrefix(dbValue, keyedEncryptionPrefix), ":")
+		if !found {
+			return "", "", false
+		}
+		return keyId, ciphertext, true
+	default:
+		return "", "", false
+	}
+}
+
 // EncryptAES256 encrypts the given plaintext with the given key using AES256 in CBC mode with PKCS7 padding
 func EncryptAES256(plaintext, key string) (string, error) {
 	if len(plaintext) == 0 {
@@ -174,7 +250,10 @@ func DecryptAES256(encrypted, key string) (string, error) {
 	mode := cipher.NewCBCDecrypter(block, []byte(iv))
 	mode.CryptBlocks(ciphertext, ciphertext)
 
-	ciphertext = pkcs7UnPadding(ciphertext)
+	ciphertext, err = pkcs7UnPadding(ciphertext, aes.BlockSize)
+	if err != nil {
+		return "", err // most likely, a wrong key was used
+	}
 
 	return string(ciphertext), nil
 }
@@ -185,10 +264,19 @@ func pkcs7Padding(ciphertext []byte, blockSize int) []byte {
 	return append(ciphertext, padtext...)
 }
 
-func pkcs7UnPadding(src []byte) []byte {
+func pkcs7UnPadding(src []byte, blockSize int) ([]byte, error) {
 	length := len(src)
+	if length == 0 {
+		return nil, fmt.Errorf("invalid padding")
+	}
 	unpadding := int(src[length-1])
-	return src[:(length - unpadding)]
+	if unpadding == 0 || unpadding > blockSize || unpadding > length {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	if !bytes.Equal(src[length-unpadding:], bytes.Repeat([]byte{byte(unpadding)}, unpadding)) {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	return src[:(length - unpadding)], nil
 }
 
 func trimEncKey(key string) string {
@@ -5,13 +5,20 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"time"
 
+	"github.com/h44z/wg-portal/internal/app"
 	"github.com/h44z/wg-portal/internal/config"
 	"github.com/h44z/wg-portal/internal/domain"
 )
 
 // region dependencies
 
+type EventBus interface {
+	// Subscribe subscribes to a topic
+	Subscribe(topic string, fn interface{}) error
+}
+
 type Mailer interface {
 	// Send sends an email with the given subject and body to the given recipients.
 	Send(ctx context.Context, subject, body string, to []string, options *domain.MailOptions) error
@@ -49,16 +56,35 @@ type TemplateRenderer interface {
 		io.Reader,
 		error,
 	)
+	// GetExpiryReminderMail returns the text and html template for the mail that reminds of an expiring peer.
+	GetExpiryReminderMail(user *domain.User, peer *domain.Peer, days int) (io.Reader, io.Reader, error)
+	// GetPeerExpiredMail returns the text and html template for the mail that notifies about an expired peer.
+	GetPeerExpiredMail(user *domain.User, peer *domain.Peer) (io.Reader, io.Reader, error)
+}
+
+type ShareLinkManager interface {
+	// CreateShareLink creates a new share link for the given peer. A zero validity and a nil usage limit fall back
+	// to the configured defaults.
+	CreateShareLink(
+		ctx context.Context,
+		peerId domain.PeerIdentifier,
+		validity time.Duration,
+		maxUses *int,
+	) (*domain.PeerShareLink, error)
+	// GetShareLinkUrl returns the public download url for the given plain token.
+	GetShareLinkUrl(token string) string
 }
 
 // endregion dependencies
 
 type Manager struct {
 	cfg *config.Config
+	bus EventBus
 
 	tplHandler  TemplateRenderer
 	mailer      Mailer
 	configFiles ConfigFileManager
+	shareLinks  ShareLinkManager
 	users       UserDatabaseRepo
 	wg          WireguardDatabaseRepo
 }
@@ -66,8 +92,10 @@ type Manager struct {
 // NewMailManager creates a new mail manager.
 func NewMailManager(
 	cfg *config.Config,
+	bus EventBus,
 	mailer Mailer,
 	configFiles ConfigFileManager,
+	shareLinks ShareLinkManager,
 	users UserDatabaseRepo,
 	wg WireguardDatabaseRepo,
 ) (*Manager, error) {
@@ -78,16 +106,114 @@ func NewMailManager(
 
 	m := &Manager{
 		cfg:         cfg,
+		bus:         bus,
 		tplHandler:  tplHandler,
 		mailer:      mailer,
 		configFiles: configFiles,
+		shareLinks:  shareLinks,
 		users:       users,
 		wg:          wg,
 	}
 
+	m.connectToMessageBus()
+
 	return m, nil
 }
 
+func (m Manager) connectToMessageBus() {
+	_ = m.bus.Subscribe(app.TopicPeerMoved, m.handlePeerMovedEvent)
+
+	if len(m.cfg.Advanced.ExpiryReminders) == 0 {
+		return // expiry notifications are disabled
+	}
+
+	_ = m.bus.Subscribe(app.TopicPeerExpiryReminder, m.handlePeerExpiryReminderEvent)
+	_ = m.bus.Subscribe(app.TopicPeerExpired, m.handlePeerExpiredEvent)
+}
+
+func (m Manager) handlePeerExpiryReminderEvent(peer domain.Peer, days int) {
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	user := m.getNotificationRecipient(ctx, &peer)
+	if user == nil {
+		return
+	}
+
+	txtMail, htmlMail, err := m.tplHandler.GetExpiryReminderMail(user, &peer, days)
+	if err != nil {
+		slog.Error("failed to get expiry reminder mail body", "peer", peer.Identifier, "error", err)
+		return
+	}
+
+	m.sendNotificationMail(ctx, "WireGuard VPN Expires Soon", user, txtMail, htmlMail)
+}
+
+func (m Manager) handlePeerExpiredEvent(peer domain.Peer) {
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	user := m.getNotificationRecipient(ctx, &peer)
+	if user == nil {
+		return
+	}
+
+	txtMail, htmlMail, err := m.tplHandler.GetPeerExpiredMail(user, &peer)
+	if err != nil {
+		slog.Error("failed to get peer expired mail body", "peer", peer.Identifier, "error", err)
+		return
+	}
+
+	m.sendNotificationMail(ctx, "WireGuard VPN Expired", user, txtMail, htmlMail)
+}
+
+func (m Manager) handlePeerMovedEvent(peer domain.Peer, _ domain.InterfaceIdentifier) {
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	// the configuration of a moved peer changes, so the user gets the new configuration
+	err := m.SendPeerEmail(ctx, m.cfg.Mail.LinkOnly, domain.ConfigStyleWgQuick, peer.Identifier)
+	if err != nil {
+		slog.Error("failed to send configuration of moved peer", "peer", peer.Identifier, "error", err)
+	}
+}
+
+// getNotificationRecipient returns the user linked to the given peer, or nil if no notification can be sent.
+func (m Manager) getNotificationRecipient(ctx context.Context, peer *domain.Peer) *domain.User {
+	if peer.UserIdentifier == "" {
+		slog.Debug("skipping peer notification", "peer", peer.Identifier, "reason", "no user linked")
+		return nil
+	}
+
+	user, err := m.users.GetUser(ctx, peer.UserIdentifier)
+	if err != nil {
+		slog.Debug("skipping peer notification", "peer", peer.Identifier, "reason", "unable to fetch user",
+			"error", err)
+		return nil
+	}
+
+	if user.Email == "" {
+		slog.Debug("skipping peer notification", "peer", peer.Identifier, "reason", "user has no mail address")
+		return nil
+	}
+
+	return user
+}
+
+func (m Manager) sendNotificationMail(
+	ctx context.Context,
+	subject string,
+	user *domain.User,
+	txtMail, htmlMail io.Reader,
+) {
+	txtMailStr, _ := io.ReadAll(txtMail)
+	htmlMailStr, _ := io.ReadAll(htmlMail)
+
+	err := m.mailer.Send(ctx, subject, string(txtMailStr), []string{user.Email},
+		&domain.MailOptions{HtmlBody: string(htmlMailStr)})
+	if err != nil {
+		slog.Error("failed to send notification mail", "user", user.Identifier, "subject", subject,
+			"error", err)
+	}
+}
+
 // SendPeerEmail sends an email to the user linked to the given peers.
 func (m Manager) SendPeerEmail(ctx context.Context, linkOnly bool, style string, peers ...domain.PeerIdentifier) error {
 	for _, peerId := range peers {
@@ -96,7 +222,9 @@ func (m Manager) SendPeerEmail(ctx context.Context, linkOnly bool, style string,
 			return fmt.Errorf("failed to fetch peer %s: %w", peerId, err)
 		}
 
-		if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+		err = domain.ValidateUserOrPermission(ctx, peer.UserIdentifier, domain.PermissionPeersManage,
+			peer.InterfaceIdentifier)
+		if err != nil {
 			return err
 		}
 
@@ -148,7 +276,12 @@ func (m Manager) sendPeerEmail(
 		mailOptions       domain.MailOptions
 	)
 	if linkOnly {
-		txtMail, htmlMail, err = m.tplHandler.GetConfigMail(user, "deep link TBD")
+		link, err := m.shareLinks.CreateShareLink(ctx, peer.Identifier, 0, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create share link for %s: %w", peer.Identifier, err)
+		}
+
+		txtMail, htmlMail, err = m.tplHandler.GetConfigMail(user, m.shareLinks.GetShareLinkUrl(link.Token))
 		if err != nil {
 			return fmt.Errorf("failed to get mail body: %w", err)
 		}
@@ -49,18 +49,20 @@ func (c TemplateHandler) GetConfigMail(user *domain.User, link string) (io.Reade
 	var htmlTplBuff bytes.Buffer
 
 	err := c.textTemplates.ExecuteTemplate(&tplBuff, "mail_with_link.gotpl", map[string]any{
-		"User":      user,
-		"Link":      link,
-		"PortalUrl": c.portalUrl,
+		"User":       user,
+		"Link":       link,
+		"PortalUrl":  c.portalUrl,
+		"PortalName": c.portalName,
 	})
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to execute template mail_with_link.gotpl: %w", err)
 	}
 
 	err = c.htmlTemplates.ExecuteTemplate(&htmlTplBuff, "mail_with_link.gohtml", map[string]any{
-		"User":      user,
-		"Link":      link,
-		"PortalUrl": c.portalUrl,
+		"User":       user,
+		"Link":       link,
+		"PortalUrl":  c.portalUrl,
+		"PortalName": c.portalName,
 	})
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to execute template mail_with_link.gohtml: %w", err)
@@ -102,3 +104,48 @@ func (c TemplateHandler) GetConfigMailWithAttachment(user *domain.User, cfgName,
 
 	return &tplBuff, &htmlTplBuff, nil
 }
+
+// GetExpiryReminderMail returns the text and html template for the mail that reminds of an expiring peer.
+func (c TemplateHandler) GetExpiryReminderMail(user *domain.User, peer *domain.Peer, days int) (
+	io.Reader,
+	io.Reader,
+	error,
+) {
+	return c.render("peer_expiry_reminder", map[string]any{
+		"User":       user,
+		"Peer":       peer,
+		"Days":       days,
+		"ExpiresAt":  peer.ExpiresAt.Format("2006-01-02"),
+		"PortalUrl":  c.portalUrl,
+		"PortalName": c.portalName,
+	})
+}
+
+// GetPeerExpiredMail returns the text and html template for the mail that notifies about an expired peer.
+func (c TemplateHandler) GetPeerExpiredMail(user *domain.User, peer *domain.Peer) (io.Reader, io.Reader, error) {
+	return c.render("peer_expired", map[string]any{
+		"User":       user,
+		"Peer":       peer,
+		"ExpiresAt":  peer.ExpiresAt.Format("2006-01-02"),
+		"PortalUrl":  c.portalUrl,
+		"PortalName": c.portalName,
+	})
+}
+
+// render executes the text and html template with the given base name.
+func (c TemplateHandler) render(name string, data map[string]any) (io.Reader, io.Reader, error) {
+	var tplBuff bytes.Buffer
+	var htmlTplBuff bytes.Buffer
+
+	err := c.textTemplates.ExecuteTemplate(&tplBuff, name+".gotpl", data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to execute template %s.gotpl: %w", name, err)
+	}
+
+	err = c.htmlTemplates.ExecuteTemplate(&htmlTplBuff, name+".gohtml", data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to execute template %s.gohtml: %w", name, err)
+	}
+
+	return &tplBuff, &htmlTplBuff, nil
+}
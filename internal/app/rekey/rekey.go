@@ -0,0 +1,267 @@
+package rekey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// encryptedSerializer is the name of the serializer that encrypts columns.
+const encryptedSerializer = "encstr"
+
+// models lists all database models. All columns that use the encryption serializer are re-encrypted.
+var models = []any{
+	&domain.User{},
+	&domain.UserWebauthnCredential{},
+	&domain.ApiToken{},
+	&domain.Interface{},
+	&domain.Peer{},
+	&domain.PeerStatus{},
+	&domain.InterfaceStatus{},
+	&domain.TrafficSample{},
+	&domain.PeerSession{},
+	&domain.PeerExpiryNotification{},
+	&domain.AuditEntry{},
+	&domain.PeerShareLink{},
+	&domain.IpRange{},
+	&domain.IpReservation{},
+	&domain.SiteNetwork{},
+	&domain.Site{},
+	&domain.SiteLink{},
+	&domain.PeerTag{},
+}
+
+// ColumnResult is the outcome for a single encrypted column.
+type ColumnResult struct {
+	Table    string
+	Column   string
+	Values   int      // the number of non-empty values
+	Rekeyed  int      // the number of values that were re-encrypted
+	Failures []string // the primary keys and errors of values that failed the verification
+}
+
+// Result is the outcome of a rekey or verification run.
+type Result struct {
+	DryRun  bool
+	Columns []ColumnResult
+}
+
+// Rekeyed returns the number of re-encrypted values of all columns.
+func (r *Result) Rekeyed() int {
+	total := 0
+	for _, c := range r.Columns {
+		total += c.Rekeyed
+	}
+	return total
+}
+
+// Failed returns the number of values of all columns that failed the verification.
+func (r *Result) Failed() int {
+	total := 0
+	for _, c := range r.Columns {
+		total += len(c.Failures)
+	}
+	return total
+}
+
+// encryptedColumn is a column that uses the encryption serializer.
+type encryptedColumn struct {
+	table       string
+	column      string
+	primaryKeys []string
+}
+
+// row is a single value of an encrypted column.
+type row struct {
+	keys  map[string]any
+	value string
+}
+
+// Rekey decrypts all encrypted columns with the key they were encrypted with and encrypts them with the
+// current key of the serializer. All values are re-encrypted in a single transaction, if a value cannot be
+// decrypted, no value is changed. In dry-run mode, the values are only checked.
+func Rekey(ctx context.Context, db *gorm.DB, s app.GormEncryptedStringSerializer, dryRun bool) (*Result, error) {
+	columns, err := encryptedColumns(db)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{DryRun: dryRun}
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, col := range columns {
+			rows, err := readColumn(tx, col)
+			if err != nil {
+				return err
+			}
+
+			colResult := ColumnResult{Table: col.table, Column: col.column, Values: len(rows)}
+			for _, r := range rows {
+				encrypted, changed, err := rekeyValue(s, r.value)
+				if err != nil {
+					return fmt.Errorf("failed to rekey %s.%s of %v: %w", col.table, col.column, r.keys, err)
+				}
+				if !changed {
+					continue
+				}
+
+				if !dryRun {
+					err := tx.Table(col.table).Where(r.keys).UpdateColumn(col.column, encrypted).Error
+					if err != nil {
+						return fmt.Errorf("failed to update %s.%s of %v: %w", col.table, col.column, r.keys, err)
+					}
+				}
+				colResult.Rekeyed++
+			}
+			result.Columns = append(result.Columns, colResult)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Verify checks that all encrypted columns are stored with the current key of the serializer and that they
+// can be decrypted. Values that fail the verification are listed in the result, nothing is changed.
+func Verify(ctx context.Context, db *gorm.DB, s app.GormEncryptedStringSerializer) (*Result, error) {
+	columns, err := encryptedColumns(db)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{DryRun: true}
+	for _, col := range columns {
+		rows, err := readColumn(db.WithContext(ctx), col)
+		if err != nil {
+			return nil, err
+		}
+
+		colResult := ColumnResult{Table: col.table, Column: col.column, Values: len(rows)}
+		for _, r := range rows {
+			if err := verifyValue(s, r.value); err != nil {
+				colResult.Failures = append(colResult.Failures, fmt.Sprintf("%v: %v", r.keys, err))
+			}
+		}
+		result.Columns = append(result.Columns, colResult)
+	}
+
+	return result, nil
+}
+
+// rekeyValue re-encrypts a stored value with the current key of the serializer. Values that are already
+// stored with the current key are returned unchanged.
+func rekeyValue(s app.GormEncryptedStringSerializer, value string) (string, bool, error) {
+	if s.IsCurrent(value) {
+		return value, false, nil
+	}
+
+	plain, err := s.Decrypt(value)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	encrypted, err := s.Encrypt(plain)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to encrypt: %w", err)
+	}
+
+	return encrypted, true, nil
+}
+
+// verifyValue checks that a stored value is encrypted with the current key of the serializer.
+func verifyValue(s app.GormEncryptedStringSerializer, value string) error {
+	if !s.IsCurrent(value) {
+		keyId, _, encrypted := app.ParseEncryptedValue(value)
+		if !encrypted {
+			return errors.New("value is not encrypted")
+		}
+		return fmt.Errorf("value is encrypted with key %q", keyId)
+	}
+
+	plain, err := s.Decrypt(value)
+	if err != nil {
+		return err
+	}
+	if !utf8.ValidString(plain) {
+		return errors.New("decrypted value is invalid, the key is most likely wrong")
+	}
+
+	return nil
+}
+
+// encryptedColumns returns all columns of all models that use the encryption serializer.
+func encryptedColumns(db *gorm.DB) ([]encryptedColumn, error) {
+	var columns []encryptedColumn
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("failed to parse model %T: %w", model, err)
+		}
+
+		primaryKeys := make([]string, len(stmt.Schema.PrimaryFields))
+		for i, field := range stmt.Schema.PrimaryFields {
+			primaryKeys[i] = field.DBName
+		}
+
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" || field.TagSettings["SERIALIZER"] != encryptedSerializer {
+				continue
+			}
+			if len(primaryKeys) == 0 {
+				return nil, fmt.Errorf("table %s has no primary key", stmt.Schema.Table)
+			}
+			columns = append(columns, encryptedColumn{
+				table:       stmt.Schema.Table,
+				column:      field.DBName,
+				primaryKeys: primaryKeys,
+			})
+		}
+	}
+
+	return columns, nil
+}
+
+// readColumn reads all non-empty values of the given column without decrypting them.
+func readColumn(tx *gorm.DB, col encryptedColumn) ([]row, error) {
+	rows, err := tx.Table(col.table).
+		Select(append([]string{col.column}, col.primaryKeys...)).
+		Where(col.column + " IS NOT NULL AND " + col.column + " <> ''").
+		Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s.%s: %w", col.table, col.column, err)
+	}
+	defer rows.Close()
+
+	var result []row
+	for rows.Next() {
+		var value sql.NullString
+		keys := make([]any, len(col.primaryKeys))
+		dest := []any{&value}
+		for i := range keys {
+			dest = append(dest, &keys[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to read %s.%s: %w", col.table, col.column, err)
+		}
+
+		r := row{keys: make(map[string]any, len(keys)), value: value.String}
+		for i, key := range col.primaryKeys {
+			if b, ok := keys[i].([]byte); ok {
+				keys[i] = string(b) // some drivers return text columns as bytes
+			}
+			r.keys[key] = keys[i]
+		}
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
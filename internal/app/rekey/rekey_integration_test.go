@@ -0,0 +1,104 @@
+//go:build integration
+
+package rekey
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/h44z/wg-portal/internal/adapters"
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// openDatabase opens the database with the registered serializer, the serializer is cached per connection.
+func openDatabase(t *testing.T, dsn string) (*gorm.DB, *adapters.SqlRepo) {
+	db, err := adapters.NewDatabase(config.DatabaseConfig{
+		Type: config.DatabaseSQLite,
+		DSN:  dsn,
+	})
+	require.NoError(t, err)
+
+	repo, err := adapters.NewSqlRepository(db)
+	require.NoError(t, err)
+
+	return db, repo
+}
+
+func rawValue(t *testing.T, db *gorm.DB, table, column string) string {
+	var value string
+	require.NoError(t, db.Table(table).Select(column).Row().Scan(&value))
+	return value
+}
+
+func TestRekey(t *testing.T) {
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	// the database is not encrypted yet
+	schema.RegisterSerializer("encstr", app.NewGormEncryptedStringSerializer(""))
+	dsn := filepath.Join(t.TempDir(), "sqlite.db")
+	db, repo := openDatabase(t, dsn)
+	require.NoError(t, repo.SaveInterface(ctx, "wg0", func(in *domain.Interface) (*domain.Interface, error) {
+		in.KeyPair = domain.KeyPair{PrivateKey: "private", PublicKey: "public"}
+		return in, nil
+	}))
+	require.NoError(t, repo.SavePeer(ctx, "peer1", func(p *domain.Peer) (*domain.Peer, error) {
+		p.InterfaceIdentifier = "wg0"
+		p.PresharedKey = "psk"
+		return p, nil
+	}))
+
+	// enable encryption without key id
+	legacy := app.NewGormEncryptedStringSerializer("first secret")
+	verified, err := Verify(ctx, db, legacy)
+	require.NoError(t, err)
+	assert.Equal(t, 2, verified.Failed(), "unencrypted values")
+
+	result, err := Rekey(ctx, db, legacy, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Rekeyed())
+	assert.Contains(t, rawValue(t, db, "interfaces", "private_key"), "WG_ENC_")
+
+	// rotate to a keyed passphrase
+	keyed := app.NewGormKeyedEncryptedStringSerializer("2", "second secret",
+		map[string]string{app.LegacyEncryptionKeyId: "first secret"})
+	result, err = Rekey(ctx, db, keyed, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Rekeyed())
+	assert.Contains(t, rawValue(t, db, "interfaces", "private_key"), "WG_ENC_", "dry run")
+
+	result, err = Rekey(ctx, db, keyed, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Rekeyed())
+	assert.Contains(t, rawValue(t, db, "interfaces", "private_key"), "WG_ENC:2:")
+
+	verified, err = Verify(ctx, db, keyed)
+	require.NoError(t, err)
+	assert.Zero(t, verified.Failed())
+
+	schema.RegisterSerializer("encstr", app.NewGormKeyedEncryptedStringSerializer("2", "second secret", nil))
+	_, repo = openDatabase(t, dsn)
+	iface, err := repo.GetInterface(ctx, "wg0")
+	require.NoError(t, err)
+	assert.Equal(t, "private", iface.PrivateKey)
+	peer, err := repo.GetPeer(ctx, "peer1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.PreSharedKey("psk"), peer.PresharedKey)
+
+	// a missing previous key aborts the rekey without changes
+	_, err = Rekey(ctx, db, app.NewGormKeyedEncryptedStringSerializer("3", "third secret", nil), false)
+	assert.Error(t, err)
+	assert.Contains(t, rawValue(t, db, "interfaces", "private_key"), "WG_ENC:2:")
+
+	// a wrong key is detected by the verification
+	verified, err = Verify(ctx, db, app.NewGormKeyedEncryptedStringSerializer("2", "wrong secret", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 2, verified.Failed())
+}
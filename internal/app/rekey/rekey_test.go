@@ -0,0 +1,134 @@
+package rekey
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/h44z/wg-portal/internal/app"
+)
+
+const (
+	oldKey = "an-old-key-that-is-long-enough-for-aes256"
+	newKey = "a-new-key-that-is-long-enough-for-aes256"
+)
+
+func Test_rekeyValue_legacyToKeyed(t *testing.T) {
+	legacy, err := app.NewGormEncryptedStringSerializer(oldKey).Encrypt("secret")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(legacy, "WG_ENC_"))
+
+	s := app.NewGormKeyedEncryptedStringSerializer("2025", newKey, map[string]string{app.LegacyEncryptionKeyId: oldKey})
+	require.False(t, s.IsCurrent(legacy))
+
+	rekeyed, changed, err := rekeyValue(s, legacy)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, strings.HasPrefix(rekeyed, "WG_ENC:2025:"))
+	assert.True(t, s.IsCurrent(rekeyed))
+	plain, err := s.Decrypt(rekeyed)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", plain)
+
+	// values with the current key are kept
+	again, changed, err := rekeyValue(s, rekeyed)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, rekeyed, again)
+}
+
+func Test_rekeyValue(t *testing.T) {
+	legacy, err := app.NewGormEncryptedStringSerializer(oldKey).Encrypt("secret")
+	require.NoError(t, err)
+	keyed, err := app.NewGormKeyedEncryptedStringSerializer("2024", oldKey, nil).Encrypt("secret")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		serializer app.GormEncryptedStringSerializer
+		value      string
+		wantPrefix string
+		wantErr    bool
+	}{
+		{
+			name:       "unencrypted to legacy",
+			serializer: app.NewGormEncryptedStringSerializer(newKey),
+			value:      "secret",
+			wantPrefix: "WG_ENC_",
+		},
+		{
+			name:       "unencrypted to keyed",
+			serializer: app.NewGormKeyedEncryptedStringSerializer("2025", newKey, nil),
+			value:      "secret",
+			wantPrefix: "WG_ENC:2025:",
+		},
+		{
+			name: "keyed to keyed",
+			serializer: app.NewGormKeyedEncryptedStringSerializer("2025", newKey,
+				map[string]string{"2024": oldKey}),
+			value:      keyed,
+			wantPrefix: "WG_ENC:2025:",
+		},
+		{
+			name: "keyed to legacy",
+			serializer: app.NewGormKeyedEncryptedStringSerializer("", newKey,
+				map[string]string{"2024": oldKey}),
+			value:      keyed,
+			wantPrefix: "WG_ENC_",
+		},
+		{
+			name:       "legacy to unencrypted",
+			serializer: app.NewGormKeyedEncryptedStringSerializer("", "", map[string]string{"legacy": oldKey}),
+			value:      legacy,
+			wantPrefix: "",
+		},
+		{
+			name:       "unknown key",
+			serializer: app.NewGormKeyedEncryptedStringSerializer("2025", newKey, nil),
+			value:      keyed,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rekeyed, changed, err := rekeyValue(tt.serializer, tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, changed)
+			assert.True(t, tt.serializer.IsCurrent(rekeyed))
+
+			_, _, encrypted := app.ParseEncryptedValue(rekeyed)
+			if tt.wantPrefix == "" {
+				assert.False(t, encrypted)
+				assert.Equal(t, "secret", rekeyed)
+				return
+			}
+			assert.True(t, strings.HasPrefix(rekeyed, tt.wantPrefix))
+			assert.True(t, encrypted)
+
+			plain, err := tt.serializer.Decrypt(rekeyed)
+			require.NoError(t, err)
+			assert.Equal(t, "secret", plain)
+		})
+	}
+}
+
+func Test_verifyValue(t *testing.T) {
+	s := app.NewGormKeyedEncryptedStringSerializer("2025", newKey, map[string]string{app.LegacyEncryptionKeyId: oldKey})
+	current, err := s.Encrypt("secret")
+	require.NoError(t, err)
+	legacy, err := app.NewGormEncryptedStringSerializer(oldKey).Encrypt("secret")
+	require.NoError(t, err)
+	wrongKey, err := app.NewGormKeyedEncryptedStringSerializer("2025", oldKey, nil).Encrypt("secret")
+	require.NoError(t, err)
+
+	assert.NoError(t, verifyValue(s, current))
+	assert.ErrorContains(t, verifyValue(s, "secret"), "not encrypted")
+	assert.ErrorContains(t, verifyValue(s, legacy), `encrypted with key "legacy"`)
+	assert.Error(t, verifyValue(s, wrongKey))
+}
@@ -132,6 +132,9 @@ func (m Manager) syncRoutes(ctx context.Context) error {
 		if !iface.ManageRoutingTable() {
 			continue
 		}
+		if iface.IsRemote() {
+			continue // routes of remote interfaces are not managed by the portal
+		}
 
 		peers, err := m.db.GetInterfacePeers(ctx, iface.Identifier)
 		if err != nil {
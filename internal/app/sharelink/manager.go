@@ -0,0 +1,396 @@
+package sharelink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/app/audit"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// region dependencies
+
+type DatabaseRepo interface {
+	// GetPeerShareLink returns the share link with the given identifier.
+	GetPeerShareLink(ctx context.Context, id domain.PeerShareLinkIdentifier) (*domain.PeerShareLink, error)
+	// GetPeerShareLinks returns all share links of the given peer.
+	GetPeerShareLinks(ctx context.Context, peerId domain.PeerIdentifier) ([]domain.PeerShareLink, error)
+	// SavePeerShareLink updates the share link with the given identifier. If the link does not exist, it is created.
+	SavePeerShareLink(
+		ctx context.Context,
+		id domain.PeerShareLinkIdentifier,
+		updateFunc func(l *domain.PeerShareLink) (*domain.PeerShareLink, error),
+	) error
+	// DeletePeerShareLinks deletes all share links of the given peer.
+	DeletePeerShareLinks(ctx context.Context, peerId domain.PeerIdentifier) error
+	// DeleteExpiredPeerShareLinks deletes all share links that expired before the given timestamp.
+	DeleteExpiredPeerShareLinks(ctx context.Context, before time.Time) error
+}
+
+type WireguardDatabaseRepo interface {
+	// GetPeer returns the peer with the given identifier.
+	GetPeer(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error)
+}
+
+type ConfigFileManager interface {
+	// GetPeerConfig returns the configuration for the given peer.
+	GetPeerConfig(ctx context.Context, id domain.PeerIdentifier, style string) (io.Reader, error)
+	// GetPeerConfigQrCode returns the QR code for the given peer.
+	GetPeerConfigQrCode(ctx context.Context, id domain.PeerIdentifier, style string) (io.Reader, error)
+}
+
+type EventBus interface {
+	// Publish sends a message to the message bus.
+	Publish(topic string, args ...any)
+	// Subscribe subscribes to a topic
+	Subscribe(topic string, fn interface{}) error
+}
+
+// endregion dependencies
+
+// Manager is responsible for creating, validating and revoking peer configuration share links.
+type Manager struct {
+	cfg *config.Config
+	bus EventBus
+
+	db          DatabaseRepo
+	wg          WireguardDatabaseRepo
+	configFiles ConfigFileManager
+}
+
+// NewShareLinkManager creates a new share link manager.
+func NewShareLinkManager(
+	cfg *config.Config,
+	bus EventBus,
+	db DatabaseRepo,
+	wg WireguardDatabaseRepo,
+	configFiles ConfigFileManager,
+) (*Manager, error) {
+	m := &Manager{
+		cfg: cfg,
+		bus: bus,
+
+		db:          db,
+		wg:          wg,
+		configFiles: configFiles,
+	}
+
+	m.connectToMessageBus()
+
+	return m, nil
+}
+
+// StartBackgroundJobs starts the background jobs of the share link manager.
+// This method is non-blocking and returns immediately.
+func (m Manager) StartBackgroundJobs(ctx context.Context) {
+	go m.runExpiredLinksCleanup(ctx)
+}
+
+func (m Manager) connectToMessageBus() {
+	_ = m.bus.Subscribe(app.TopicPeerDeleted, m.handlePeerDeleteEvent)
+	_ = m.bus.Subscribe(app.TopicPeerIdentifierUpdated, m.handlePeerIdentifierChangeEvent)
+}
+
+func (m Manager) handlePeerDeleteEvent(peer domain.Peer) {
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	err := m.db.DeletePeerShareLinks(ctx, peer.Identifier)
+	if err != nil {
+		slog.Error("failed to delete share links of deleted peer", "peer", peer.Identifier, "error", err)
+	}
+}
+
+func (m Manager) handlePeerIdentifierChangeEvent(oldIdentifier, newIdentifier domain.PeerIdentifier) {
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	// the public key changed, so old links would deliver a different configuration
+	err := m.db.DeletePeerShareLinks(ctx, oldIdentifier)
+	if err != nil {
+		slog.Error("failed to delete share links of migrated peer", "oldIdentifier", oldIdentifier,
+			"newIdentifier", newIdentifier, "error", err)
+	}
+}
+
+func (m Manager) runExpiredLinksCleanup(ctx context.Context) {
+	running := true
+	for running {
+		select {
+		case <-ctx.Done():
+			running = false
+			continue
+		case <-time.After(1 * time.Hour):
+			// select blocks until one of the cases evaluate to true
+		}
+
+		err := m.db.DeleteExpiredPeerShareLinks(ctx, time.Now())
+		if err != nil {
+			slog.Error("failed to delete expired share links", "error", err)
+		}
+	}
+}
+
+// CreateShareLink creates a new share link for the given peer.
+// If validity is zero or maxUses is nil, the configured defaults are used. A maxUses of zero means unlimited.
+// The returned share link contains the plain token, which is not stored in the database.
+func (m Manager) CreateShareLink(
+	ctx context.Context,
+	peerId domain.PeerIdentifier,
+	validity time.Duration,
+	maxUses *int,
+) (*domain.PeerShareLink, error) {
+	peer, err := m.wg.GetPeer(ctx, peerId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch peer %s: %w", peerId, err)
+	}
+
+	err = domain.ValidateUserOrPermission(ctx, peer.UserIdentifier, domain.PermissionPeersManage,
+		peer.InterfaceIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if validity < 0 || (maxUses != nil && *maxUses < 0) {
+		return nil, fmt.Errorf("validity and usage limit must not be negative: %w", domain.ErrInvalidData)
+	}
+	if validity == 0 {
+		validity = m.cfg.Advanced.ShareLinkValidity
+	}
+	uses := m.cfg.Advanced.ShareLinkMaxUses
+	if maxUses != nil {
+		uses = *maxUses
+	}
+
+	currentUser := domain.GetUserInfo(ctx)
+	link, err := domain.NewPeerShareLink(m.signingKey(), peer.Identifier, currentUser.Id, validity, uses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate share link: %w", err)
+	}
+
+	err = m.db.SavePeerShareLink(ctx, link.Identifier, func(_ *domain.PeerShareLink) (*domain.PeerShareLink, error) {
+		return link, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store share link: %w", err)
+	}
+
+	m.bus.Publish(app.TopicAuditShareLinkChanged, domain.AuditEventWrapper[audit.ShareLinkEvent]{
+		Ctx: ctx,
+		Event: audit.ShareLinkEvent{
+			Action: "create",
+			Link:   *link,
+		},
+	})
+
+	return link, nil
+}
+
+// GetPeerShareLinks returns all share links of the given peer.
+func (m Manager) GetPeerShareLinks(ctx context.Context, peerId domain.PeerIdentifier) (
+	[]domain.PeerShareLink,
+	error,
+) {
+	peer, err := m.wg.GetPeer(ctx, peerId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch peer %s: %w", peerId, err)
+	}
+
+	err = domain.ValidateUserOrPermission(ctx, peer.UserIdentifier, domain.PermissionPeersManage,
+		peer.InterfaceIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.db.GetPeerShareLinks(ctx, peer.Identifier)
+}
+
+// RevokeShareLink invalidates the given share link. Only users that are permitted to manage the peers of the
+// interface are allowed to revoke share links.
+func (m Manager) RevokeShareLink(ctx context.Context, id domain.PeerShareLinkIdentifier) error {
+	link, err := m.db.GetPeerShareLink(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to fetch share link %s: %w", id, err)
+	}
+
+	peer, err := m.wg.GetPeer(ctx, link.PeerIdentifier)
+	if err != nil {
+		return fmt.Errorf("failed to fetch peer %s: %w", link.PeerIdentifier, err)
+	}
+
+	if err := domain.ValidatePermission(ctx, domain.PermissionPeersManage, peer.InterfaceIdentifier); err != nil {
+		return err
+	}
+
+	currentUser := domain.GetUserInfo(ctx)
+	var revokedLink domain.PeerShareLink
+	err = m.db.SavePeerShareLink(ctx, id, func(l *domain.PeerShareLink) (*domain.PeerShareLink, error) {
+		l.Revoke(currentUser.Id)
+		revokedLink = *l
+		return l, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke share link %s: %w", id, err)
+	}
+
+	m.bus.Publish(app.TopicAuditShareLinkChanged, domain.AuditEventWrapper[audit.ShareLinkEvent]{
+		Ctx: ctx,
+		Event: audit.ShareLinkEvent{
+			Action: "revoke",
+			Link:   revokedLink,
+		},
+	})
+
+	return nil
+}
+
+// GetSharedPeerConfig returns the configuration of the peer that belongs to the given token.
+// Each successful call consumes one use of the share link, failed calls do not count.
+func (m Manager) GetSharedPeerConfig(ctx context.Context, token, style string) (*domain.Peer, io.Reader, error) {
+	peer, err := m.resolveToken(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sysCtx := domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo())
+	cfgData, err := m.configFiles.GetPeerConfig(sysCtx, peer.Identifier, style)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch peer config for %s: %w", peer.Identifier, err)
+	}
+
+	if err := m.consumeToken(ctx, token); err != nil {
+		return nil, nil, err
+	}
+
+	return peer, cfgData, nil
+}
+
+// GetSharedPeerConfigQrCode returns the configuration QR code of the peer that belongs to the given token.
+// Each successful call consumes one use of the share link, failed calls do not count.
+func (m Manager) GetSharedPeerConfigQrCode(ctx context.Context, token, style string) (*domain.Peer, io.Reader, error) {
+	peer, err := m.resolveToken(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sysCtx := domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo())
+	qrData, err := m.configFiles.GetPeerConfigQrCode(sysCtx, peer.Identifier, style)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch peer qr code for %s: %w", peer.Identifier, err)
+	}
+
+	if err := m.consumeToken(ctx, token); err != nil {
+		return nil, nil, err
+	}
+
+	return peer, qrData, nil
+}
+
+// GetShareLinkUrl returns the public download url for the given plain token.
+func (m Manager) GetShareLinkUrl(token string) string {
+	return strings.TrimRight(m.cfg.Web.ExternalUrl, "/") + "/api/v0/share/" + token
+}
+
+// resolveToken validates the given token and returns the shared peer. The use of the link is not counted.
+func (m Manager) resolveToken(ctx context.Context, token string) (*domain.Peer, error) {
+	linkId, err := domain.ParseShareLinkToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := m.db.GetPeerShareLink(ctx, linkId)
+	if errors.Is(err, domain.ErrNotFound) {
+		err = domain.ErrShareLinkInvalid
+	}
+	if err == nil {
+		err = m.checkLink(link, token)
+	}
+	if err != nil {
+		return nil, m.denyToken(ctx, linkId, err)
+	}
+
+	sysCtx := domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo())
+	peer, err := m.wg.GetPeer(sysCtx, link.PeerIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch shared peer %s: %w", link.PeerIdentifier, err)
+	}
+
+	return peer, nil
+}
+
+// consumeToken counts a use of the share link. The link is checked again, as it might have been used up or
+// revoked in the meantime.
+func (m Manager) consumeToken(ctx context.Context, token string) error {
+	linkId, err := domain.ParseShareLinkToken(token)
+	if err != nil {
+		return err
+	}
+
+	var usedLink domain.PeerShareLink
+	err = m.db.SavePeerShareLink(ctx, linkId, func(l *domain.PeerShareLink) (*domain.PeerShareLink, error) {
+		if err := m.checkLink(l, token); err != nil {
+			return nil, err
+		}
+
+		l.RegisterUse()
+		usedLink = *l
+		return l, nil
+	})
+	if err != nil {
+		return m.denyToken(ctx, linkId, err)
+	}
+
+	m.bus.Publish(app.TopicAuditShareLinkChanged, domain.AuditEventWrapper[audit.ShareLinkEvent]{
+		Ctx: ctx,
+		Event: audit.ShareLinkEvent{
+			Action: "use",
+			Link:   usedLink,
+		},
+	})
+
+	return nil
+}
+
+func (m Manager) checkLink(link *domain.PeerShareLink, token string) error {
+	if link.TokenHash == "" {
+		return domain.ErrShareLinkInvalid // link does not exist, abort creation
+	}
+	if err := link.VerifyToken(m.signingKey(), token); err != nil {
+		return err
+	}
+
+	return link.CheckUsable()
+}
+
+// denyToken records the rejected use of a share link and returns the error for the caller.
+func (m Manager) denyToken(ctx context.Context, linkId domain.PeerShareLinkIdentifier, err error) error {
+	m.bus.Publish(app.TopicAuditShareLinkChanged, domain.AuditEventWrapper[audit.ShareLinkEvent]{
+		Ctx: ctx,
+		Event: audit.ShareLinkEvent{
+			Action: "denied",
+			Link:   domain.PeerShareLink{Identifier: linkId},
+			Error:  err.Error(),
+		},
+	})
+	if isShareLinkError(err) {
+		return err
+	}
+
+	return fmt.Errorf("failed to validate share link %s: %w", linkId, err)
+}
+
+func (m Manager) signingKey() []byte {
+	return []byte(m.cfg.Web.SessionSecret)
+}
+
+func isShareLinkError(err error) bool {
+	return errors.Is(err, domain.ErrShareLinkInvalid) ||
+		errors.Is(err, domain.ErrShareLinkExpired) ||
+		errors.Is(err, domain.ErrShareLinkRevoked) ||
+		errors.Is(err, domain.ErrShareLinkExhausted)
+}
@@ -0,0 +1,121 @@
+package sharelink
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+
+	"github.com/h44z/wg-portal/internal/adapters"
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type testEventBus struct{}
+
+func (testEventBus) Publish(string, ...any) {}
+
+func (testEventBus) Subscribe(string, interface{}) error { return nil }
+
+type testConfigFiles struct {
+	fail bool
+}
+
+func (c *testConfigFiles) GetPeerConfig(context.Context, domain.PeerIdentifier, string) (io.Reader, error) {
+	if c.fail {
+		return nil, errors.New("render failed")
+	}
+	return strings.NewReader("[Interface]"), nil
+}
+
+func (c *testConfigFiles) GetPeerConfigQrCode(context.Context, domain.PeerIdentifier, string) (io.Reader, error) {
+	return c.GetPeerConfig(context.Background(), "", "")
+}
+
+func newTestManager(t *testing.T) (*Manager, *adapters.SqlRepo, *testConfigFiles) {
+	schema.RegisterSerializer("encstr", app.NewGormEncryptedStringSerializer(""))
+	db, err := adapters.NewDatabase(config.DatabaseConfig{Type: "sqlite", DSN: t.TempDir() + "/test.db"})
+	require.NoError(t, err)
+	repo, err := adapters.NewSqlRepository(db)
+	require.NoError(t, err)
+
+	admin := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+	err = repo.SavePeer(admin, "peer-1", func(p *domain.Peer) (*domain.Peer, error) {
+		p.InterfaceIdentifier = "wg0"
+		return p, nil
+	})
+	require.NoError(t, err)
+
+	cfg := &config.Config{}
+	cfg.Web.SessionSecret = "secret"
+	cfg.Advanced.ShareLinkValidity = time.Hour
+	cfg.Advanced.ShareLinkMaxUses = 1
+
+	configFiles := &testConfigFiles{}
+	m, err := NewShareLinkManager(cfg, testEventBus{}, repo, repo, configFiles)
+	require.NoError(t, err)
+
+	return m, repo, configFiles
+}
+
+func TestManager_CreateShareLink_maxUses(t *testing.T) {
+	m, _, _ := newTestManager(t)
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	link, err := m.CreateShareLink(ctx, "peer-1", 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, link.MaxUses) // configured default
+
+	unlimited := 0
+	link, err = m.CreateShareLink(ctx, "peer-1", 0, &unlimited)
+	require.NoError(t, err)
+	assert.Equal(t, 0, link.MaxUses)
+	for range 3 {
+		_, _, err = m.GetSharedPeerConfig(ctx, link.Token, "")
+		require.NoError(t, err)
+	}
+
+	negative := -1
+	_, err = m.CreateShareLink(ctx, "peer-1", 0, &negative)
+	assert.ErrorIs(t, err, domain.ErrInvalidData)
+}
+
+func TestManager_GetSharedPeerConfig_consumesOnSuccess(t *testing.T) {
+	m, repo, configFiles := newTestManager(t)
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	link, err := m.CreateShareLink(ctx, "peer-1", 0, nil)
+	require.NoError(t, err)
+
+	// failed downloads do not use up the link
+	configFiles.fail = true
+	_, _, err = m.GetSharedPeerConfig(ctx, link.Token, "")
+	assert.Error(t, err)
+	_, _, err = m.GetSharedPeerConfigQrCode(ctx, link.Token, "")
+	assert.Error(t, err)
+	stored, err := repo.GetPeerShareLink(ctx, link.Identifier)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.UseCount)
+
+	configFiles.fail = false
+	peer, _, err := m.GetSharedPeerConfig(ctx, link.Token, "")
+	require.NoError(t, err)
+	assert.Equal(t, domain.PeerIdentifier("peer-1"), peer.Identifier)
+	stored, err = repo.GetPeerShareLink(ctx, link.Identifier)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.UseCount)
+
+	_, _, err = m.GetSharedPeerConfig(ctx, link.Token, "")
+	assert.ErrorIs(t, err, domain.ErrShareLinkExhausted)
+
+	// unknown links are rejected
+	_, _, err = m.GetSharedPeerConfig(ctx, "unknown.token", "")
+	assert.ErrorIs(t, err, domain.ErrShareLinkInvalid)
+}
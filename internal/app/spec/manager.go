@@ -0,0 +1,393 @@
+package spec
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/app/audit"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// region dependencies
+
+type DatabaseRepo interface {
+	// GetAllUsers returns all users.
+	GetAllUsers(ctx context.Context) ([]domain.User, error)
+	// GetUser returns the user with the given identifier.
+	GetUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
+	// GetAllInterfaces returns all interfaces.
+	GetAllInterfaces(ctx context.Context) ([]domain.Interface, error)
+	// GetInterface returns the interface with the given identifier.
+	GetInterface(ctx context.Context, id domain.InterfaceIdentifier) (*domain.Interface, error)
+	// GetInterfacePeers returns all peers of the given interface.
+	GetInterfacePeers(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.Peer, error)
+	// GetPeer returns the peer with the given identifier.
+	GetPeer(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error)
+}
+
+type InterfaceAndPeerManager interface {
+	// PrepareInterface generates a new interface with fresh keys, ip addresses and a listen port.
+	PrepareInterface(ctx context.Context) (*domain.Interface, error)
+	// CreateInterface creates a new interface with the given configuration.
+	CreateInterface(ctx context.Context, in *domain.Interface) (*domain.Interface, error)
+	// UpdateInterface updates the given interface with the new configuration.
+	UpdateInterface(ctx context.Context, in *domain.Interface) (*domain.Interface, []domain.Peer, error)
+	// DeleteInterface deletes the given interface.
+	DeleteInterface(ctx context.Context, id domain.InterfaceIdentifier) error
+	// PreparePeer prepares a new peer for the given interface with fresh keys and ip addresses.
+	PreparePeer(ctx context.Context, id domain.InterfaceIdentifier) (*domain.Peer, error)
+	// CreatePeer creates a new peer.
+	CreatePeer(ctx context.Context, peer *domain.Peer) (*domain.Peer, error)
+	// UpdatePeer updates the given peer.
+	UpdatePeer(ctx context.Context, peer *domain.Peer) (*domain.Peer, error)
+	// DeletePeer deletes the peer with the given identifier.
+	DeletePeer(ctx context.Context, id domain.PeerIdentifier) error
+	// MovePeer moves the peer with the given identifier to the target interface.
+	MovePeer(ctx context.Context, id domain.PeerIdentifier, target domain.InterfaceIdentifier) (*domain.Peer, error)
+}
+
+type UserManager interface {
+	// CreateUser creates a new user.
+	CreateUser(ctx context.Context, user *domain.User) (*domain.User, error)
+	// UpdateUser updates the user with the given identifier.
+	UpdateUser(ctx context.Context, user *domain.User) (*domain.User, error)
+	// DeleteUser deletes the user with the given identifier.
+	DeleteUser(ctx context.Context, id domain.UserIdentifier) error
+}
+
+type EventBus interface {
+	// Publish sends a message to the message bus.
+	Publish(topic string, args ...any)
+}
+
+// endregion dependencies
+
+// Manager reconciles the stored users, interfaces and peers with the declarative spec.
+type Manager struct {
+	cfg *config.Config
+	bus EventBus
+
+	db    DatabaseRepo
+	wg    InterfaceAndPeerManager
+	users UserManager
+}
+
+// NewSpecManager creates a new spec manager. The interface and user managers may be nil, in this case, the
+// manager can only compute plans.
+func NewSpecManager(
+	cfg *config.Config,
+	bus EventBus,
+	db DatabaseRepo,
+	wg InterfaceAndPeerManager,
+	users UserManager,
+) (*Manager, error) {
+	m := &Manager{
+		cfg:   cfg,
+		bus:   bus,
+		db:    db,
+		wg:    wg,
+		users: users,
+	}
+
+	return m, nil
+}
+
+// StartBackgroundJobs applies the configured spec once and afterward in the configured sync interval.
+// This method is non-blocking and returns immediately.
+func (m Manager) StartBackgroundJobs(ctx context.Context) {
+	if m.cfg.Advanced.SpecPath == "" {
+		return
+	}
+
+	go m.runSpecSync(ctx)
+}
+
+func (m Manager) runSpecSync(ctx context.Context) {
+	ctx = domain.SetUserInfo(ctx, domain.SpecContextUserInfo())
+
+	running := true
+	for running {
+		plan, err := m.ApplySpec(ctx)
+		switch {
+		case err != nil:
+			slog.Error("failed to apply spec", "path", m.cfg.Advanced.SpecPath, "error", err)
+		case plan.HasErrors():
+			slog.Warn("spec applied with errors", "path", m.cfg.Advanced.SpecPath, "plan", plan.String())
+		case !plan.IsEmpty():
+			slog.Info("spec applied", "path", m.cfg.Advanced.SpecPath, "changes", len(plan.Changes))
+		}
+
+		if m.cfg.Advanced.SpecSyncInterval <= 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			running = false
+		case <-time.After(m.cfg.Advanced.SpecSyncInterval):
+			// select blocks until one of the cases evaluate to true
+		}
+	}
+}
+
+// PlanSpec loads the configured spec and returns the changes that are needed to apply it.
+func (m Manager) PlanSpec(ctx context.Context) (*domain.SpecPlan, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	spec, err := m.loadConfiguredSpec()
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Plan(ctx, spec)
+}
+
+// ApplySpec loads the configured spec and applies it. The returned plan contains the outcome of each change.
+func (m Manager) ApplySpec(ctx context.Context) (*domain.SpecPlan, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	spec, err := m.loadConfiguredSpec()
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Apply(ctx, spec, m.cfg.Advanced.SpecPath)
+}
+
+func (m Manager) loadConfiguredSpec() (*domain.Spec, error) {
+	if m.cfg.Advanced.SpecPath == "" {
+		return nil, fmt.Errorf("no spec path configured: %w", domain.ErrInvalidData)
+	}
+
+	return LoadSpec(m.cfg.Advanced.SpecPath)
+}
+
+// Plan compares the given spec with the stored objects.
+func (m Manager) Plan(ctx context.Context, spec *domain.Spec) (*domain.SpecPlan, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	users, err := m.db.GetAllUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load users: %w", err)
+	}
+
+	interfaces, err := m.db.GetAllInterfaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load interfaces: %w", err)
+	}
+
+	var peers []domain.Peer
+	for _, iface := range interfaces {
+		interfacePeers, err := m.db.GetInterfacePeers(ctx, iface.Identifier)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load peers of interface %s: %w", iface.Identifier, err)
+		}
+		peers = append(peers, interfacePeers...)
+	}
+
+	return domain.NewSpecPlan(spec, users, interfaces, peers), nil
+}
+
+// Apply reconciles the stored objects with the given spec. A failed change does not stop the reconciliation,
+// the error is recorded in the returned plan instead.
+func (m Manager) Apply(ctx context.Context, spec *domain.Spec, source string) (*domain.SpecPlan, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	if m.wg == nil || m.users == nil {
+		return nil, fmt.Errorf("spec manager cannot apply changes: %w", domain.ErrInvalidData)
+	}
+
+	plan, err := m.Plan(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	if plan.IsEmpty() {
+		return plan, nil
+	}
+
+	// managed objects are read-only for all other users, only the spec context can change them
+	specCtx := domain.SetUserInfo(ctx, domain.SpecContextUserInfo())
+	for i := range plan.Changes {
+		change := &plan.Changes[i]
+		if err := m.applyChange(specCtx, spec, change); err != nil {
+			slog.Warn("failed to apply spec change",
+				"kind", change.Kind, "identifier", change.Identifier, "action", change.Action, "error", err)
+			change.Error = err.Error()
+		}
+	}
+
+	m.bus.Publish(app.TopicAuditSpecApplied, domain.AuditEventWrapper[audit.SpecEvent]{
+		Ctx: ctx,
+		Event: audit.SpecEvent{
+			Source: source,
+			Plan:   *plan,
+		},
+	})
+
+	return plan, nil
+}
+
+func (m Manager) applyChange(ctx context.Context, spec *domain.Spec, change *domain.SpecChange) error {
+	switch change.Kind {
+	case domain.SpecObjectUser:
+		if change.Action == domain.SpecActionDelete {
+			return m.users.DeleteUser(ctx, domain.UserIdentifier(change.Identifier))
+		}
+		idx := slices.IndexFunc(spec.Users, func(s domain.UserSpec) bool {
+			return string(s.Identifier) == change.Identifier
+		})
+		return m.applyUser(ctx, spec.Users[idx], change.Action)
+	case domain.SpecObjectInterface:
+		if change.Action == domain.SpecActionDelete {
+			return m.wg.DeleteInterface(ctx, domain.InterfaceIdentifier(change.Identifier))
+		}
+		idx := slices.IndexFunc(spec.Interfaces, func(s domain.InterfaceSpec) bool {
+			return string(s.Identifier) == change.Identifier
+		})
+		return m.applyInterface(ctx, spec.Interfaces[idx], change.Action)
+	case domain.SpecObjectPeer:
+		if change.Action == domain.SpecActionDelete {
+			return m.wg.DeletePeer(ctx, domain.PeerIdentifier(change.Identifier))
+		}
+		idx := slices.IndexFunc(spec.Peers, func(s domain.PeerSpec) bool {
+			return s.PublicKey == change.Identifier
+		})
+		return m.applyPeer(ctx, spec.Peers[idx], change.Action)
+	default:
+		return fmt.Errorf("unknown object kind %s", change.Kind)
+	}
+}
+
+func (m Manager) applyUser(ctx context.Context, s domain.UserSpec, action domain.SpecAction) error {
+	if action == domain.SpecActionCreate {
+		user := &domain.User{Source: domain.UserSourceDatabase}
+		s.Apply(user, time.Now())
+		_, err := m.users.CreateUser(ctx, user)
+		return err
+	}
+
+	user, err := m.db.GetUser(ctx, s.Identifier)
+	if err != nil {
+		return err
+	}
+	s.Apply(user, time.Now())
+	user.Password = "" // keep the stored password
+	_, err = m.users.UpdateUser(ctx, user)
+	return err
+}
+
+func (m Manager) applyInterface(ctx context.Context, s domain.InterfaceSpec, action domain.SpecAction) error {
+	if action == domain.SpecActionCreate {
+		iface, err := m.wg.PrepareInterface(ctx)
+		if err != nil {
+			return err
+		}
+		s.Apply(iface, time.Now())
+		_, err = m.wg.CreateInterface(ctx, iface)
+		return err
+	}
+
+	iface, err := m.db.GetInterface(ctx, s.Identifier)
+	if err != nil {
+		return err
+	}
+	s.Apply(iface, time.Now())
+	_, _, err = m.wg.UpdateInterface(ctx, iface)
+	return err
+}
+
+func (m Manager) applyPeer(ctx context.Context, s domain.PeerSpec, action domain.SpecAction) error {
+	iface, err := m.db.GetInterface(ctx, s.Interface)
+	if err != nil {
+		return err
+	}
+
+	if action == domain.SpecActionCreate {
+		peer, err := m.wg.PreparePeer(ctx, s.Interface)
+		if err != nil {
+			return err
+		}
+		s.Apply(peer, iface, time.Now())
+		// the private key stays with the client, a preshared key cannot be shared with it
+		peer.Interface.PrivateKey = ""
+		peer.PresharedKey = ""
+		_, err = m.wg.CreatePeer(ctx, peer)
+		return err
+	}
+
+	id := domain.PeerIdentifier(s.PublicKey)
+	peer, err := m.db.GetPeer(ctx, id)
+	if err != nil {
+		return err
+	}
+	if peer.InterfaceIdentifier != s.Interface {
+		if peer, err = m.wg.MovePeer(ctx, id, s.Interface); err != nil {
+			return err
+		}
+	}
+	s.Apply(peer, iface, time.Now())
+	_, err = m.wg.UpdatePeer(ctx, peer)
+	return err
+}
+
+// LoadSpec reads the spec from the given file or from all .yaml, .yml and .json files of the given directory.
+// The files of a directory are merged in lexical order.
+func LoadSpec(path string) (*domain.Spec, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spec directory: %w", err)
+		}
+
+		files = files[:0]
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+				continue
+			}
+			files = append(files, filepath.Join(path, entry.Name())) // entries are sorted by name
+		}
+	}
+
+	spec := &domain.Spec{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spec file %s: %w", file, err)
+		}
+
+		fileSpec, err := domain.ParseSpec(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		spec.Merge(fileSpec)
+	}
+
+	if err := spec.Normalize(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return spec, nil
+}
@@ -2,6 +2,7 @@ package users
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,6 +18,7 @@ func convertRawLdapUser(
 	rawUser map[string]any,
 	fields *config.LdapFields,
 	adminGroupDN *ldap.DN,
+	roleGroupDNs map[string]*ldap.DN,
 ) (*domain.User, error) {
 	now := time.Now()
 
@@ -24,6 +26,10 @@ func convertRawLdapUser(
 	if err != nil {
 		return nil, fmt.Errorf("failed to check admin group: %w", err)
 	}
+	roles, err := internal.LdapMemberRoles(rawUser[fields.GroupMembership].([][]byte), roleGroupDNs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check role groups: %w", err)
+	}
 
 	return &domain.User{
 		BaseModel: domain.BaseModel{
@@ -37,6 +43,7 @@ func convertRawLdapUser(
 		Source:       domain.UserSourceLdap,
 		ProviderName: providerName,
 		IsAdmin:      isAdmin,
+		Roles:        domain.ParseRoles(roles...),
 		Firstname:    internal.MapDefaultString(rawUser, fields.Firstname, ""),
 		Lastname:     internal.MapDefaultString(rawUser, fields.Lastname, ""),
 		Phone:        internal.MapDefaultString(rawUser, fields.Phone, ""),
@@ -72,6 +79,10 @@ func userChangedInLdap(dbUser, ldapUser *domain.User) bool {
 		return true
 	}
 
+	if !slices.Equal(dbUser.Roles, ldapUser.Roles) {
+		return true
+	}
+
 	if dbUser.ProviderName != ldapUser.ProviderName {
 		return true
 	}
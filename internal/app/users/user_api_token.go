@@ -0,0 +1,189 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/app/audit"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// GetApiTokens returns all named API tokens of the given user.
+func (m Manager) GetApiTokens(ctx context.Context, userId domain.UserIdentifier) ([]domain.ApiToken, error) {
+	if err := domain.ValidateUserAccessRights(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	return m.users.GetUserApiTokens(ctx, userId)
+}
+
+// CreateApiToken creates a new named API token for the given user. The name, scopes, source networks and
+// expiry are taken from the given template. The plain token is only available in the returned token.
+func (m Manager) CreateApiToken(ctx context.Context, userId domain.UserIdentifier, template *domain.ApiToken) (
+	*domain.ApiToken,
+	error,
+) {
+	user, err := m.users.GetUser(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find user %s: %w", userId, err)
+	}
+
+	if err := m.validateApiChange(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if m.cfg.Advanced.ApiAdminOnly && !user.IsAdmin {
+		return nil, fmt.Errorf("only admins can access the API: %w", domain.ErrNoPermission)
+	}
+
+	if template.ExpiresAt != nil && template.ExpiresAt.Before(time.Now()) {
+		return nil, fmt.Errorf("token expiry is in the past: %w", domain.ErrInvalidData)
+	}
+
+	token, err := domain.NewApiToken(userId, template.Name, template.Scopes, template.AllowedCidrs,
+		template.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.users.SaveApiToken(ctx, token.Identifier, func(_ *domain.ApiToken) (*domain.ApiToken, error) {
+		return token, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store api token: %w", err)
+	}
+
+	m.bus.Publish(app.TopicAuditApiTokenChanged, domain.AuditEventWrapper[audit.ApiTokenEvent]{
+		Ctx: ctx,
+		Event: audit.ApiTokenEvent{
+			Action: "create",
+			Token:  *token,
+		},
+	})
+
+	return token, nil
+}
+
+// DeleteApiToken revokes the given named API token. Admins can revoke the tokens of all users.
+func (m Manager) DeleteApiToken(ctx context.Context, id domain.ApiTokenIdentifier) error {
+	token, err := m.users.GetApiToken(ctx, id)
+	if err != nil {
+		return fmt.Errorf("unable to find api token %s: %w", id, err)
+	}
+
+	if err := domain.ValidateUserAccessRights(ctx, token.UserIdentifier); err != nil {
+		return err
+	}
+
+	if err := m.users.DeleteApiToken(ctx, id); err != nil {
+		return fmt.Errorf("deletion failure: %w", err)
+	}
+
+	m.bus.Publish(app.TopicAuditApiTokenChanged, domain.AuditEventWrapper[audit.ApiTokenEvent]{
+		Ctx: ctx,
+		Event: audit.ApiTokenEvent{
+			Action: "delete",
+			Token:  *token,
+		},
+	})
+
+	return nil
+}
+
+// AuthenticateApiToken checks the given token of the user. Named tokens are checked for expiry and source
+// network and are returned along with the user. For the legacy user API token, no named token is returned.
+// Failed attempts are tracked by the brute-force protection.
+func (m Manager) AuthenticateApiToken(ctx context.Context, userId domain.UserIdentifier, token, sourceAddr string) (
+	*domain.User,
+	*domain.ApiToken,
+	error,
+) {
+	if err := m.CheckLoginAllowed(ctx, userId, sourceAddr); err != nil {
+		return nil, nil, err
+	}
+
+	user, apiToken, err := m.authenticateApiToken(ctx, userId, token, sourceAddr)
+	if err != nil {
+		m.RegisterLoginFailure(ctx, userId, sourceAddr)
+		if !domain.IsNamedApiToken(token) { // rejected named tokens are audited separately
+			m.bus.Publish(app.TopicAuditLoginFailed, domain.AuditEventWrapper[audit.AuthEvent]{
+				Ctx:    ctx,
+				Source: "api",
+				Event: audit.AuthEvent{
+					Username: string(userId),
+					Error:    fmt.Sprintf("%v (source %s)", err, sourceAddr),
+				},
+			})
+		}
+		return nil, nil, err
+	}
+
+	m.RegisterLoginSuccess(ctx, user)
+
+	return user, apiToken, nil
+}
+
+func (m Manager) authenticateApiToken(ctx context.Context, userId domain.UserIdentifier, token, sourceAddr string) (
+	*domain.User,
+	*domain.ApiToken,
+	error,
+) {
+	user, err := m.users.GetUser(ctx, userId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to find user %s: %w", userId, err)
+	}
+
+	if !domain.IsNamedApiToken(token) {
+		if err := user.CheckApiToken(token); err != nil {
+			return nil, nil, err
+		}
+		return user, nil, nil
+	}
+
+	tokenId, err := domain.ParseApiToken(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	apiToken, err := m.users.GetApiToken(ctx, tokenId)
+	if err != nil {
+		return nil, nil, errors.Join(err, domain.ErrApiTokenInvalid)
+	}
+	if apiToken.UserIdentifier != user.Identifier {
+		return nil, nil, domain.ErrApiTokenInvalid
+	}
+
+	err = apiToken.VerifyToken(token)
+	if err == nil {
+		err = apiToken.CheckUsable(sourceAddr)
+	}
+	if err == nil && (user.IsDisabled() || user.IsLocked()) {
+		err = errors.New("user disabled or locked")
+	}
+	if err != nil {
+		m.bus.Publish(app.TopicAuditApiTokenChanged, domain.AuditEventWrapper[audit.ApiTokenEvent]{
+			Ctx: ctx,
+			Event: audit.ApiTokenEvent{
+				Action: "denied",
+				Token:  *apiToken,
+				Error:  fmt.Sprintf("%v (source %s)", err, sourceAddr),
+			},
+		})
+		return nil, nil, err
+	}
+
+	if apiToken.RegisterUse() {
+		err := m.users.SaveApiToken(ctx, apiToken.Identifier, func(t *domain.ApiToken) (*domain.ApiToken, error) {
+			t.LastUsedAt = apiToken.LastUsedAt
+			return t, nil
+		})
+		if err != nil {
+			slog.Warn("failed to update api token usage", "token", apiToken.Identifier, "error", err)
+		}
+	}
+
+	return user, apiToken, nil
+}
@@ -0,0 +1,106 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/app/audit"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// BulkUpdateUsers applies the action of the request to all selected users. In dry-run mode, the result lists the
+// users that would be changed, but nothing is stored. A single audit entry is recorded for the whole run.
+func (m Manager) BulkUpdateUsers(ctx context.Context, req *domain.UserBulkRequest) (*domain.BulkResult, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	result := &domain.BulkResult{Action: req.Action, DryRun: req.DryRun}
+
+	users, err := m.selectUsers(ctx, req.Selector, result)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range users {
+		user := &users[i]
+		id := string(user.Identifier)
+
+		if req.Action == domain.BulkActionDelete {
+			if req.DryRun {
+				result.Add(id, domain.BulkItemChanged, m.validateDeletion(ctx, user))
+				continue
+			}
+			result.Add(id, domain.BulkItemChanged, m.DeleteUser(ctx, user.Identifier))
+			continue
+		}
+
+		existingUser := *user
+		if !req.Apply(user, now) {
+			result.Add(id, domain.BulkItemUnchanged, nil)
+			continue
+		}
+
+		if req.DryRun {
+			result.Add(id, domain.BulkItemChanged, m.validateModifications(ctx, &existingUser, user))
+			continue
+		}
+		_, err := m.UpdateUser(ctx, user)
+		result.Add(id, domain.BulkItemChanged, err)
+	}
+
+	if !req.DryRun {
+		m.bus.Publish(app.TopicAuditBulkAction, domain.AuditEventWrapper[audit.BulkEvent]{
+			Ctx: ctx,
+			Event: audit.BulkEvent{
+				Target:   "users",
+				Selector: req.Selector.String(),
+				Reason:   req.Reason,
+				Result:   *result,
+			},
+		})
+	}
+
+	return result, nil
+}
+
+// selectUsers loads all users that match the selector. Unknown user identifiers are recorded as failed items.
+func (m Manager) selectUsers(
+	ctx context.Context,
+	selector domain.UserSelector,
+	result *domain.BulkResult,
+) ([]domain.User, error) {
+	var candidates []domain.User
+	if len(selector.Identifiers) > 0 {
+		for _, id := range selector.Identifiers {
+			user, err := m.users.GetUser(ctx, id)
+			if err != nil {
+				result.Add(string(id), domain.BulkItemFailed, fmt.Errorf("unable to load user: %w", err))
+				continue
+			}
+			candidates = append(candidates, *user)
+		}
+	} else {
+		users, err := m.users.GetAllUsers(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load users: %w", err)
+		}
+		candidates = users
+	}
+
+	selected := make([]domain.User, 0, len(candidates))
+	for i := range candidates {
+		if selector.Matches(&candidates[i]) {
+			selected = append(selected, candidates[i])
+		}
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,262 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/app/audit"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// CheckLoginAllowed returns domain.ErrLoginThrottled if the account or the source address has to wait before the
+// next login attempt, either because of the delay after failed logins or because of a temporary block.
+func (m Manager) CheckLoginAllowed(ctx context.Context, id domain.UserIdentifier, sourceAddr string) error {
+	cfg := m.cfg.Auth.LoginProtection
+	if !cfg.Enabled {
+		return nil
+	}
+
+	now := time.Now()
+	for _, key := range loginAttemptKeys(id, sourceAddr) {
+		attempt, err := m.users.GetLoginAttempt(ctx, key)
+		if errors.Is(err, domain.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load login attempts: %w", err)
+		}
+
+		if next := attempt.NextAttempt(cfg.BaseDelay, cfg.MaxDelay); next.After(now) {
+			return fmt.Errorf("next attempt possible at %s: %w", next.Format(time.RFC3339), domain.ErrLoginThrottled)
+		}
+	}
+
+	return nil
+}
+
+// RegisterLoginFailure counts a failed login for the account and the source address. Once the maximum number of
+// failures is reached, the account is locked and the source address is blocked temporarily.
+func (m Manager) RegisterLoginFailure(ctx context.Context, id domain.UserIdentifier, sourceAddr string) {
+	cfg := m.cfg.Auth.LoginProtection
+	if !cfg.Enabled {
+		return
+	}
+
+	now := time.Now()
+	if id != "" {
+		attempt, blocked := m.registerLoginFailure(ctx, domain.LoginAttemptUserKey(id), cfg.MaxFailures, now)
+		if blocked {
+			m.lockUser(ctx, id, *attempt.BlockedUntil)
+		}
+	}
+	if sourceAddr != "" {
+		m.registerLoginFailure(ctx, domain.LoginAttemptSourceKey(sourceAddr), cfg.MaxSourceFailures, now)
+	}
+}
+
+// RegisterLoginSuccess resets the failed logins of the account. An expired temporary lock of the user is removed.
+func (m Manager) RegisterLoginSuccess(ctx context.Context, user *domain.User) {
+	if user.Locked != nil && user.LockedUntil != nil && !user.IsLocked() {
+		err := m.users.SaveUser(ctx, user.Identifier, func(u *domain.User) (*domain.User, error) {
+			u.Locked = nil
+			u.LockedReason = ""
+			u.LockedUntil = nil
+			return u, nil
+		})
+		if err != nil {
+			slog.Warn("failed to remove expired lock", "user", user.Identifier, "error", err)
+		}
+	}
+
+	if !m.cfg.Auth.LoginProtection.Enabled {
+		return
+	}
+
+	key := domain.LoginAttemptUserKey(user.Identifier)
+	if _, err := m.users.GetLoginAttempt(ctx, key); err != nil {
+		return // no failed logins
+	}
+	if err := m.users.DeleteLoginAttempt(ctx, key); err != nil {
+		slog.Warn("failed to reset failed logins", "user", user.Identifier, "error", err)
+	}
+}
+
+// GetBlockedLogins returns the accounts and source addresses that are currently blocked.
+func (m Manager) GetBlockedLogins(ctx context.Context) ([]domain.LoginAttempt, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	return m.users.GetBlockedLoginAttempts(ctx, time.Now())
+}
+
+// UnlockUser removes the lock of the given user and resets the failed logins of the account.
+func (m Manager) UnlockUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	user, err := m.users.GetUser(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find user %s: %w", id, err)
+	}
+
+	user.Locked = nil
+	user.LockedReason = ""
+	user.LockedUntil = nil
+	err = m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		u.Locked = nil
+		u.LockedReason = ""
+		u.LockedUntil = nil
+		u.UpdatedBy = domain.GetUserInfo(ctx).UserId()
+		u.UpdatedAt = time.Now()
+		return u, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("update failure: %w", err)
+	}
+
+	m.resetLoginAttempts(ctx, domain.LoginAttemptUserKey(id))
+	m.bus.Publish(app.TopicUserUpdated, *user)
+
+	return user, nil
+}
+
+// UnlockSource removes the block of the given source address.
+func (m Manager) UnlockSource(ctx context.Context, sourceAddr string) error {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return err
+	}
+
+	key := domain.LoginAttemptSourceKey(sourceAddr)
+	if _, err := m.users.GetLoginAttempt(ctx, key); err != nil {
+		return fmt.Errorf("unable to find failed logins of %s: %w", sourceAddr, err)
+	}
+
+	m.resetLoginAttempts(ctx, key)
+
+	return nil
+}
+
+// removeStaleLoginAttempts deletes the failed logins that are no longer relevant for the brute-force protection.
+func (m Manager) removeStaleLoginAttempts(ctx context.Context) {
+	cfg := m.cfg.Auth.LoginProtection
+	if !cfg.Enabled {
+		return
+	}
+
+	if err := m.users.DeleteStaleLoginAttempts(ctx, time.Now().Add(-cfg.LockoutDuration)); err != nil {
+		slog.Warn("failed to delete stale login attempts", "error", err)
+	}
+}
+
+func (m Manager) runLoginAttemptCleanup(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return // program stopped
+		case <-ticker.C:
+			m.removeStaleLoginAttempts(ctx)
+		}
+	}
+}
+
+func (m Manager) registerLoginFailure(
+	ctx context.Context,
+	key string,
+	maxFailures int,
+	now time.Time,
+) (*domain.LoginAttempt, bool) {
+	var attempt domain.LoginAttempt
+	var blocked bool
+	err := m.users.SaveLoginAttempt(ctx, key, func(a *domain.LoginAttempt) (*domain.LoginAttempt, error) {
+		blocked = a.RegisterFailure(now, maxFailures, m.cfg.Auth.LoginProtection.LockoutDuration)
+		attempt = *a
+		return a, nil
+	})
+	if err != nil {
+		slog.Warn("failed to register failed login", "key", key, "error", err)
+		return nil, false
+	}
+
+	if blocked {
+		slog.Warn("login blocked after too many failures", "key", key, "until", attempt.BlockedUntil)
+		m.bus.Publish(app.TopicAuditLoginLockout, domain.AuditEventWrapper[audit.LockoutEvent]{
+			Ctx: ctx,
+			Event: audit.LockoutEvent{
+				Attempt: attempt,
+				Action:  "lock",
+			},
+		})
+	}
+
+	return &attempt, blocked
+}
+
+func (m Manager) lockUser(ctx context.Context, id domain.UserIdentifier, until time.Time) {
+	user, err := m.users.GetUser(ctx, id)
+	if err != nil {
+		return // unknown accounts are only blocked by their login attempts
+	}
+	if user.IsLocked() && user.LockedUntil == nil {
+		return // keep permanent locks
+	}
+
+	now := time.Now()
+	user.Locked = &now
+	user.LockedReason = domain.LockedReasonLogin
+	user.LockedUntil = &until
+	err = m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		u.Locked = user.Locked
+		u.LockedReason = user.LockedReason
+		u.LockedUntil = user.LockedUntil
+		u.UpdatedBy = domain.CtxSystemLoginProtection
+		u.UpdatedAt = now
+		return u, nil
+	})
+	if err != nil {
+		slog.Warn("failed to lock user", "user", id, "error", err)
+		return
+	}
+
+	m.bus.Publish(app.TopicUserUpdated, *user)
+}
+
+func (m Manager) resetLoginAttempts(ctx context.Context, key string) {
+	attempt, err := m.users.GetLoginAttempt(ctx, key)
+	if err != nil {
+		return // nothing to reset
+	}
+
+	if err := m.users.DeleteLoginAttempt(ctx, key); err != nil {
+		slog.Warn("failed to reset failed logins", "key", key, "error", err)
+		return
+	}
+
+	m.bus.Publish(app.TopicAuditLoginLockout, domain.AuditEventWrapper[audit.LockoutEvent]{
+		Ctx: ctx,
+		Event: audit.LockoutEvent{
+			Attempt: *attempt,
+			Action:  "unlock",
+		},
+	})
+}
+
+func loginAttemptKeys(id domain.UserIdentifier, sourceAddr string) []string {
+	keys := make([]string, 0, 2)
+	if id != "" {
+		keys = append(keys, domain.LoginAttemptUserKey(id))
+	}
+	if sourceAddr != "" {
+		keys = append(keys, domain.LoginAttemptSourceKey(sourceAddr))
+	}
+
+	return keys
+}
@@ -35,6 +35,32 @@ type UserDatabaseRepo interface {
 	SaveUser(ctx context.Context, id domain.UserIdentifier, updateFunc func(u *domain.User) (*domain.User, error)) error
 	// DeleteUser deletes the user with the given identifier.
 	DeleteUser(ctx context.Context, id domain.UserIdentifier) error
+	// GetApiToken returns the API token with the given identifier.
+	GetApiToken(ctx context.Context, id domain.ApiTokenIdentifier) (*domain.ApiToken, error)
+	// GetUserApiTokens returns all API tokens of the given user.
+	GetUserApiTokens(ctx context.Context, userId domain.UserIdentifier) ([]domain.ApiToken, error)
+	// SaveApiToken updates the API token with the given identifier. If the token does not exist, it is created.
+	SaveApiToken(
+		ctx context.Context,
+		id domain.ApiTokenIdentifier,
+		updateFunc func(t *domain.ApiToken) (*domain.ApiToken, error),
+	) error
+	// DeleteApiToken deletes the API token with the given identifier.
+	DeleteApiToken(ctx context.Context, id domain.ApiTokenIdentifier) error
+	// GetLoginAttempt returns the failed logins with the given identifier.
+	GetLoginAttempt(ctx context.Context, id string) (*domain.LoginAttempt, error)
+	// GetBlockedLoginAttempts returns all failed logins that are blocked at the given time.
+	GetBlockedLoginAttempts(ctx context.Context, now time.Time) ([]domain.LoginAttempt, error)
+	// SaveLoginAttempt updates the failed logins with the given identifier. If they do not exist, they are created.
+	SaveLoginAttempt(
+		ctx context.Context,
+		id string,
+		updateFunc func(a *domain.LoginAttempt) (*domain.LoginAttempt, error),
+	) error
+	// DeleteLoginAttempt deletes the failed logins with the given identifier.
+	DeleteLoginAttempt(ctx context.Context, id string) error
+	// DeleteStaleLoginAttempts deletes all failed logins before the given time that are not blocked.
+	DeleteStaleLoginAttempts(ctx context.Context, before time.Time) error
 }
 
 type PeerDatabaseRepo interface {
@@ -93,11 +119,12 @@ func (m Manager) RegisterUser(ctx context.Context, user *domain.User) error {
 // This method is non-blocking and returns immediately.
 func (m Manager) StartBackgroundJobs(ctx context.Context) {
 	go m.runLdapSynchronizationService(ctx)
+	go m.runLoginAttemptCleanup(ctx)
 }
 
 // GetUser returns the user with the given identifier.
 func (m Manager) GetUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, error) {
-	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+	if err := domain.ValidateUserOrPermission(ctx, id, domain.PermissionUsersRead, ""); err != nil {
 		return nil, err
 	}
 
@@ -152,7 +179,7 @@ func (m Manager) GetUserByWebAuthnCredential(ctx context.Context, credentialIdBa
 
 // GetAllUsers returns all users.
 func (m Manager) GetAllUsers(ctx context.Context) ([]domain.User, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionUsersRead, ""); err != nil {
 		return nil, err
 	}
 
@@ -198,6 +225,22 @@ func (m Manager) UpdateUser(ctx context.Context, user *domain.User) (*domain.Use
 		return nil, fmt.Errorf("update not allowed: %w", err)
 	}
 
+	if !domain.GetUserInfo(ctx).IsAdmin {
+		user.PeerQuota = existingUser.PeerQuota // only admins can change the quota
+		user.Roles = existingUser.Roles         // only admins can change the roles
+	}
+
+	// the second factor is only changed by the dedicated enrollment functions
+	user.TotpSecret = existingUser.TotpSecret
+	user.TotpEnabled = existingUser.TotpEnabled
+	user.TotpLastCounter = existingUser.TotpLastCounter
+	user.TotpRecoveryCodes = existingUser.TotpRecoveryCodes
+
+	if user.Locked != nil && existingUser.IsLocked() {
+		user.Locked = existingUser.Locked // keep the original lock, including its expiry
+		user.LockedUntil = existingUser.LockedUntil
+	}
+
 	user.CopyCalculatedAttributes(existingUser)
 	err = user.HashPassword()
 	if err != nil {
@@ -224,6 +267,10 @@ func (m Manager) UpdateUser(ctx context.Context, user *domain.User) (*domain.Use
 		m.bus.Publish(app.TopicUserEnabled, *user)
 	}
 
+	if existingUser.IsLocked() && !user.IsLocked() {
+		m.resetLoginAttempts(ctx, domain.LoginAttemptUserKey(user.Identifier))
+	}
+
 	return user, nil
 }
 
@@ -356,6 +403,14 @@ func (m Manager) validateModifications(ctx context.Context, old, new *domain.Use
 		return fmt.Errorf("insufficient permissions")
 	}
 
+	if err := domain.ValidateManagedAccess(ctx, old.ManagedBy); err != nil {
+		return err
+	}
+
+	if err := domain.ValidateManagedAccess(ctx, new.ManagedBy); err != nil {
+		return err
+	}
+
 	if err := old.EditAllowed(new); err != nil && currentUser.Id != domain.SystemAdminContextUserInfo().Id {
 		return errors.Join(fmt.Errorf("no access: %w", err), domain.ErrInvalidData)
 	}
@@ -384,6 +439,14 @@ func (m Manager) validateModifications(ctx context.Context, old, new *domain.Use
 		return fmt.Errorf("cannot change user source: %w", domain.ErrInvalidData)
 	}
 
+	if err := new.PeerQuota.Validate(); err != nil {
+		return err
+	}
+
+	if err := domain.ValidateRoles(new.Roles); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -398,6 +461,10 @@ func (m Manager) validateCreation(ctx context.Context, new *domain.User) error {
 		return fmt.Errorf("invalid user identifier: %w", domain.ErrInvalidData)
 	}
 
+	if err := domain.ValidateManagedAccess(ctx, new.ManagedBy); err != nil {
+		return err
+	}
+
 	if new.Identifier == "all" { // the 'all' user identifier collides with the rest api routes
 		return fmt.Errorf("reserved user identifier: %w", domain.ErrInvalidData)
 	}
@@ -420,8 +487,8 @@ func (m Manager) validateCreation(ctx context.Context, new *domain.User) error {
 			new.Source, domain.UserSourceDatabase, domain.ErrInvalidData)
 	}
 
-	// database users must have a password
-	if new.Source == domain.UserSourceDatabase && string(new.Password) == "" {
+	// database users must have a password, users that are managed by the spec can only log in via API token
+	if new.Source == domain.UserSourceDatabase && string(new.Password) == "" && new.ManagedBy == "" {
 		return fmt.Errorf("missing password: %w", domain.ErrInvalidData)
 	}
 
@@ -429,6 +496,14 @@ func (m Manager) validateCreation(ctx context.Context, new *domain.User) error {
 		return errors.Join(fmt.Errorf("password too weak: %w", err), domain.ErrInvalidData)
 	}
 
+	if err := new.PeerQuota.Validate(); err != nil {
+		return err
+	}
+
+	if err := domain.ValidateRoles(new.Roles); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -439,6 +514,10 @@ func (m Manager) validateDeletion(ctx context.Context, del *domain.User) error {
 		return domain.ErrNoPermission
 	}
 
+	if err := domain.ValidateManagedAccess(ctx, del.ManagedBy); err != nil {
+		return err
+	}
+
 	if err := del.DeleteAllowed(); err != nil {
 		return errors.Join(fmt.Errorf("no access: %w", err), domain.ErrInvalidData)
 	}
@@ -507,6 +586,11 @@ func (m Manager) synchronizeLdapUsers(ctx context.Context, provider *config.Ldap
 		return fmt.Errorf("failed to parse admin group DN: %w", err)
 	}
 	provider.ParsedAdminGroupDN = dn
+	roleGroups, err := internal.LdapParseRoleGroups(provider.RoleGroups)
+	if err != nil {
+		return err
+	}
+	provider.ParsedRoleGroupDNs = roleGroups
 
 	conn, err := internal.LdapConnect(provider)
 	if err != nil {
@@ -522,7 +606,7 @@ func (m Manager) synchronizeLdapUsers(ctx context.Context, provider *config.Ldap
 	slog.Debug("fetched raw ldap users", "count", len(rawUsers), "provider", provider.ProviderName)
 
 	// Update existing LDAP users
-	err = m.updateLdapUsers(ctx, provider, rawUsers, &provider.FieldMap, provider.ParsedAdminGroupDN)
+	err = m.updateLdapUsers(ctx, provider, rawUsers, &provider.FieldMap)
 	if err != nil {
 		return err
 	}
@@ -543,10 +627,10 @@ func (m Manager) updateLdapUsers(
 	provider *config.LdapProvider,
 	rawUsers []internal.RawLdapUser,
 	fields *config.LdapFields,
-	adminGroupDN *ldap.DN,
 ) error {
 	for _, rawUser := range rawUsers {
-		user, err := convertRawLdapUser(provider.ProviderName, rawUser, fields, adminGroupDN)
+		user, err := convertRawLdapUser(provider.ProviderName, rawUser, fields, provider.ParsedAdminGroupDN,
+			provider.ParsedRoleGroupDNs)
 		if err != nil && !errors.Is(err, domain.ErrNotFound) {
 			return fmt.Errorf("failed to convert LDAP data for %v: %w", rawUser["dn"], err)
 		}
@@ -577,6 +661,9 @@ func (m Manager) updateLdapUsers(
 				user.Disabled = existingUser.Disabled
 				user.DisabledReason = existingUser.DisabledReason
 			}
+			if len(provider.RoleGroups) == 0 {
+				user.Roles = existingUser.Roles // no role mapping, roles are assigned directly
+			}
 			if existingUser.Source == domain.UserSourceLdap && userChangedInLdap(existingUser, user) {
 				err := m.users.SaveUser(tctx, user.Identifier, func(u *domain.User) (*domain.User, error) {
 					u.UpdatedAt = time.Now()
@@ -589,6 +676,7 @@ func (m Manager) updateLdapUsers(
 					u.Phone = user.Phone
 					u.Department = user.Department
 					u.IsAdmin = user.IsAdmin
+					u.Roles = user.Roles
 					u.Disabled = nil
 					u.DisabledReason = ""
 
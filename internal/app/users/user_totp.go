@@ -0,0 +1,285 @@
+package users
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/yeqown/go-qrcode/v2"
+	"github.com/yeqown/go-qrcode/writer/compressed"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/app/audit"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// StartTotpEnrollment creates a new TOTP secret for the user. The enrollment has to be confirmed with a code of
+// the authenticator app, see FinishTotpEnrollment.
+func (m Manager) StartTotpEnrollment(ctx context.Context, id domain.UserIdentifier) (*domain.TotpEnrollment, error) {
+	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if !m.cfg.Auth.TwoFactor.TotpEnabled {
+		return nil, fmt.Errorf("totp is disabled: %w", domain.ErrInvalidData)
+	}
+
+	user, err := m.users.GetUser(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find user %s: %w", id, err)
+	}
+	if user.HasTotp() {
+		return nil, fmt.Errorf("totp already enrolled: %w", domain.ErrInvalidData)
+	}
+
+	secret, err := domain.NewTotpSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+
+	err = m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		u.ResetTotp()
+		u.TotpSecret = secret // pending until the enrollment is confirmed
+		return u, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("update failure: %w", err)
+	}
+
+	issuer := m.cfg.Auth.TwoFactor.Issuer
+	if issuer == "" {
+		issuer = m.cfg.Web.SiteTitle
+	}
+	uri := domain.TotpUri(issuer, string(id), secret)
+
+	qrCode, err := totpQrCode(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TotpEnrollment{
+		Secret: secret,
+		Uri:    uri,
+		QrCode: qrCode,
+	}, nil
+}
+
+// FinishTotpEnrollment confirms the enrollment with a code of the authenticator app. The recovery codes are
+// returned, they cannot be retrieved again.
+func (m Manager) FinishTotpEnrollment(ctx context.Context, id domain.UserIdentifier, code string) ([]string, error) {
+	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+		return nil, err
+	}
+
+	user, err := m.users.GetUser(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find user %s: %w", id, err)
+	}
+	if user.HasTotp() {
+		return nil, fmt.Errorf("totp already enrolled: %w", domain.ErrInvalidData)
+	}
+	if user.TotpSecret == "" {
+		return nil, fmt.Errorf("no pending totp enrollment: %w", domain.ErrInvalidData)
+	}
+
+	now := time.Now()
+	if err := user.VerifyTotp(code, now); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := domain.NewRecoveryCodes(m.cfg.Auth.TwoFactor.RecoveryCodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+
+	err = m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		u.TotpEnabled = &now
+		u.TotpLastCounter = user.TotpLastCounter
+		u.TotpRecoveryCodes = hashes
+		return u, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("update failure: %w", err)
+	}
+
+	m.publishSecondFactorEvent(ctx, id, "enroll")
+
+	return codes, nil
+}
+
+// RenewRecoveryCodes replaces the recovery codes of the user. The renewal has to be confirmed with a code of
+// the authenticator app.
+func (m Manager) RenewRecoveryCodes(ctx context.Context, id domain.UserIdentifier, code string) ([]string, error) {
+	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+		return nil, err
+	}
+
+	user, err := m.users.GetUser(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find user %s: %w", id, err)
+	}
+	if !user.HasTotp() {
+		return nil, fmt.Errorf("totp not enrolled: %w", domain.ErrInvalidData)
+	}
+
+	codes, hashes, err := domain.NewRecoveryCodes(m.cfg.Auth.TwoFactor.RecoveryCodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+
+	var codeErr error
+	err = m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		if codeErr = u.VerifyTotp(code, time.Now()); codeErr != nil {
+			return nil, codeErr
+		}
+		u.TotpRecoveryCodes = hashes
+		return u, nil
+	})
+	if codeErr != nil {
+		return nil, codeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("update failure: %w", err)
+	}
+
+	m.publishSecondFactorEvent(ctx, id, "recovery-codes")
+
+	return codes, nil
+}
+
+// RemoveTotp removes the TOTP second factor of the user. Users have to confirm the removal with a code of the
+// authenticator app or a recovery code. Admins can remove the second factor of other users without a code,
+// for example, if the device of the user got lost.
+func (m Manager) RemoveTotp(ctx context.Context, id domain.UserIdentifier, code string) error {
+	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+		return err
+	}
+
+	user, err := m.users.GetUser(ctx, id)
+	if err != nil {
+		return fmt.Errorf("unable to find user %s: %w", id, err)
+	}
+	if user.TotpSecret == "" {
+		return nil // nothing to remove
+	}
+
+	currentUser := domain.GetUserInfo(ctx)
+	if user.HasTotp() && (!currentUser.IsAdmin || currentUser.Id == id) {
+		if _, err := verifySecondFactorCode(user, code); err != nil {
+			return err
+		}
+	}
+
+	err = m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		u.ResetTotp()
+		return u, nil
+	})
+	if err != nil {
+		return fmt.Errorf("update failure: %w", err)
+	}
+
+	if user.HasTotp() {
+		m.publishSecondFactorEvent(ctx, id, "remove")
+	}
+
+	return nil
+}
+
+// VerifySecondFactor checks the TOTP code or recovery code of a user that completes a password login.
+// Failed attempts are tracked by the brute-force protection.
+func (m Manager) VerifySecondFactor(
+	ctx context.Context,
+	id domain.UserIdentifier,
+	code, sourceAddr string,
+) (*domain.User, domain.SecondFactorMethod, error) {
+	if err := m.CheckLoginAllowed(ctx, id, sourceAddr); err != nil {
+		return nil, "", err
+	}
+
+	user, err := m.users.GetUser(ctx, id)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to find user %s: %w", id, err)
+	}
+	if user.IsLocked() || user.IsDisabled() {
+		return nil, "", errors.New("user is locked")
+	}
+
+	// the code is checked against the stored user within the update, so that concurrent logins cannot use the
+	// same code twice
+	var method domain.SecondFactorMethod
+	var codeErr error
+	err = m.users.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+		method, codeErr = verifySecondFactorCode(u, code)
+		if codeErr != nil {
+			return nil, codeErr
+		}
+		user = u
+		return u, nil
+	})
+	if codeErr != nil {
+		m.RegisterLoginFailure(ctx, id, sourceAddr)
+		return nil, "", codeErr
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("update failure: %w", err)
+	}
+
+	m.RegisterLoginSuccess(ctx, user)
+
+	return user, method, nil
+}
+
+func (m Manager) publishSecondFactorEvent(ctx context.Context, id domain.UserIdentifier, action string) {
+	m.bus.Publish(app.TopicAuditSecondFactorChanged, domain.AuditEventWrapper[audit.SecondFactorEvent]{
+		Ctx: ctx,
+		Event: audit.SecondFactorEvent{
+			Username: string(id),
+			Action:   action,
+		},
+	})
+}
+
+// verifySecondFactorCode accepts a code of the authenticator app or one of the recovery codes.
+func verifySecondFactorCode(user *domain.User, code string) (domain.SecondFactorMethod, error) {
+	if !user.HasTotp() {
+		return "", fmt.Errorf("totp not enrolled: %w", domain.ErrSecondFactorInvalid)
+	}
+	if err := user.VerifyTotp(code, time.Now()); err == nil {
+		return domain.SecondFactorTotp, nil
+	}
+	if err := user.UseRecoveryCode(code); err == nil {
+		return domain.SecondFactorRecovery, nil
+	}
+
+	return "", domain.ErrSecondFactorInvalid
+}
+
+func totpQrCode(uri string) ([]byte, error) {
+	code, err := qrcode.NewWith(uri,
+		qrcode.WithErrorCorrectionLevel(qrcode.ErrorCorrectionMedium), qrcode.WithEncodingMode(qrcode.EncModeByte))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize qr code: %w", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	option := compressed.Option{
+		Padding:   8, // padding pixels around the qr code.
+		BlockSize: 4, // block pixels which represents a bit data.
+	}
+	err = code.Save(compressed.NewWithWriter(nopCloser{Writer: buf}, &option))
+	if err != nil {
+		return nil, fmt.Errorf("failed to write qr code: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+// Close is a no-op for the nopCloser.
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,101 @@
+package users
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+
+	"github.com/h44z/wg-portal/internal/adapters"
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type testEventBus struct{}
+
+func (testEventBus) Publish(string, ...any) {}
+
+// racingUserRepo uses a recovery code right before the next update of the user, like a concurrent login.
+type racingUserRepo struct {
+	UserDatabaseRepo
+	code string
+}
+
+func (r *racingUserRepo) SaveUser(
+	ctx context.Context,
+	id domain.UserIdentifier,
+	updateFunc func(u *domain.User) (*domain.User, error),
+) error {
+	if code := r.code; code != "" {
+		r.code = ""
+		err := r.UserDatabaseRepo.SaveUser(ctx, id, func(u *domain.User) (*domain.User, error) {
+			return u, u.UseRecoveryCode(code)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.UserDatabaseRepo.SaveUser(ctx, id, updateFunc)
+}
+
+func TestManager_VerifySecondFactor_codesAreSingleUse(t *testing.T) {
+	schema.RegisterSerializer("encstr", app.NewGormEncryptedStringSerializer(""))
+	db, err := adapters.NewDatabase(config.DatabaseConfig{Type: "sqlite", DSN: t.TempDir() + "/test.db"})
+	require.NoError(t, err)
+	repo, err := adapters.NewSqlRepository(db)
+	require.NoError(t, err)
+
+	secret, err := domain.NewTotpSecret()
+	require.NoError(t, err)
+	codes, hashes, err := domain.NewRecoveryCodes(2)
+	require.NoError(t, err)
+
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+	enabled := time.Now()
+	err = repo.SaveUser(ctx, "alice", func(u *domain.User) (*domain.User, error) {
+		u.TotpSecret = secret
+		u.TotpEnabled = &enabled
+		u.TotpRecoveryCodes = hashes
+		return u, nil
+	})
+	require.NoError(t, err)
+
+	m, err := NewUserManager(&config.Config{}, testEventBus{}, repo, repo)
+	require.NoError(t, err)
+
+	// a recovery code is accepted once, the other codes remain usable
+	user, method, err := m.VerifySecondFactor(ctx, "alice", codes[0], "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.SecondFactorRecovery, method)
+	assert.Len(t, user.TotpRecoveryCodes, 1)
+
+	_, _, err = m.VerifySecondFactor(ctx, "alice", codes[0], "127.0.0.1")
+	assert.ErrorIs(t, err, domain.ErrSecondFactorInvalid)
+
+	stored, err := repo.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, []string{hashes[1]}, stored.TotpRecoveryCodes)
+
+	// the same applies to the codes of the authenticator app
+	code, err := domain.TotpCode(secret, time.Now())
+	require.NoError(t, err)
+	_, method, err = m.VerifySecondFactor(ctx, "alice", code, "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.SecondFactorTotp, method)
+
+	_, _, err = m.VerifySecondFactor(ctx, "alice", code, "127.0.0.1")
+	assert.ErrorIs(t, err, domain.ErrSecondFactorInvalid)
+
+	// a recovery code that is used by a concurrent login is rejected
+	racing := &racingUserRepo{UserDatabaseRepo: repo, code: codes[1]}
+	m, err = NewUserManager(&config.Config{}, testEventBus{}, racing, repo)
+	require.NoError(t, err)
+	_, _, err = m.VerifySecondFactor(ctx, "alice", codes[1], "127.0.0.1")
+	assert.ErrorIs(t, err, domain.ErrSecondFactorInvalid)
+	assert.Empty(t, racing.code)
+}
@@ -66,6 +66,9 @@ func (m Manager) connectToMessageBus() {
 	_ = m.bus.Subscribe(app.TopicPeerUpdated, m.handlePeerUpdateEvent)
 	_ = m.bus.Subscribe(app.TopicPeerDeleted, m.handlePeerDeleteEvent)
 	_ = m.bus.Subscribe(app.TopicPeerStateChanged, m.handlePeerStateChangeEvent)
+	_ = m.bus.Subscribe(app.TopicPeerQuotaExceeded, m.handlePeerQuotaExceededEvent)
+	_ = m.bus.Subscribe(app.TopicPeerExpiryReminder, m.handlePeerExpiryReminderEvent)
+	_ = m.bus.Subscribe(app.TopicPeerExpired, m.handlePeerExpiredEvent)
 
 	_ = m.bus.Subscribe(app.TopicInterfaceCreated, m.handleInterfaceCreateEvent)
 	_ = m.bus.Subscribe(app.TopicInterfaceUpdated, m.handleInterfaceUpdateEvent)
@@ -145,6 +148,18 @@ func (m Manager) handlePeerStateChangeEvent(peerStatus domain.PeerStatus, peer d
 	}
 }
 
+func (m Manager) handlePeerQuotaExceededEvent(peer domain.Peer, peerStatus domain.PeerStatus) {
+	m.handleGenericEvent(WebhookEventQuotaExceeded, models.NewPeerMetrics(peerStatus, peer))
+}
+
+func (m Manager) handlePeerExpiryReminderEvent(peer domain.Peer, _ int) {
+	m.handleGenericEvent(WebhookEventExpiryReminder, models.NewPeer(peer))
+}
+
+func (m Manager) handlePeerExpiredEvent(peer domain.Peer) {
+	m.handleGenericEvent(WebhookEventExpired, models.NewPeer(peer))
+}
+
 func (m Manager) handleGenericEvent(action WebhookEvent, payload any) {
 	eventData, err := m.createWebhookData(action, payload)
 	if err != nil {
@@ -43,9 +43,12 @@ const (
 type WebhookEvent = string
 
 const (
-	WebhookEventCreate     WebhookEvent = "create"
-	WebhookEventUpdate     WebhookEvent = "update"
-	WebhookEventDelete     WebhookEvent = "delete"
-	WebhookEventConnect    WebhookEvent = "connect"
-	WebhookEventDisconnect WebhookEvent = "disconnect"
+	WebhookEventCreate         WebhookEvent = "create"
+	WebhookEventUpdate         WebhookEvent = "update"
+	WebhookEventDelete         WebhookEvent = "delete"
+	WebhookEventConnect        WebhookEvent = "connect"
+	WebhookEventDisconnect     WebhookEvent = "disconnect"
+	WebhookEventQuotaExceeded  WebhookEvent = "quota_exceeded"
+	WebhookEventExpiryReminder WebhookEvent = "expiry_reminder"
+	WebhookEventExpired        WebhookEvent = "expired"
 )
@@ -29,6 +29,10 @@ type PeerStatus struct {
 	Endpoint         string     `json:"Endpoint"`
 	LastHandshake    *time.Time `json:"LastHandshake,omitempty"`
 	LastSessionStart *time.Time `json:"LastSessionStart,omitempty"`
+
+	QuotaLimit       uint64     `json:"QuotaLimit,omitempty"`
+	QuotaUsed        uint64     `json:"QuotaUsed,omitempty"`
+	QuotaPeriodStart *time.Time `json:"QuotaPeriodStart,omitempty"`
 }
 
 // NewPeerMetrics creates a new PeerMetrics model from the domain.PeerStatus and domain.Peer models.
@@ -44,6 +48,9 @@ func NewPeerMetrics(status domain.PeerStatus, peer domain.Peer) PeerMetrics {
 			Endpoint:         status.Endpoint,
 			LastHandshake:    status.LastHandshake,
 			LastSessionStart: status.LastSessionStart,
+			QuotaLimit:       status.QuotaLimit,
+			QuotaUsed:        status.QuotaUsed(),
+			QuotaPeriodStart: status.QuotaPeriodStart,
 		},
 		Peer: NewPeer(peer),
 	}
@@ -29,6 +29,7 @@ type User struct {
 	DisabledReason string     `json:"DisabledReason,omitempty"`
 	Locked         *time.Time `json:"Locked,omitempty"`
 	LockedReason   string     `json:"LockedReason,omitempty"`
+	LockedUntil    *time.Time `json:"LockedUntil,omitempty"`
 }
 
 // NewUser creates a new User model from a domain.User
@@ -52,5 +53,6 @@ func NewUser(src domain.User) User {
 		DisabledReason: src.DisabledReason,
 		Locked:         src.Locked,
 		LockedReason:   src.LockedReason,
+		LockedUntil:    src.LockedUntil,
 	}
 }
@@ -2,6 +2,7 @@ package wireguard
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"sync"
 	"time"
@@ -17,6 +18,7 @@ type StatisticsDatabaseRepo interface {
 	GetAllInterfaces(ctx context.Context) ([]domain.Interface, error)
 	GetInterfacePeers(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.Peer, error)
 	GetPeer(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error)
+	GetUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
 	UpdatePeerStatus(
 		ctx context.Context,
 		id domain.PeerIdentifier,
@@ -28,6 +30,21 @@ type StatisticsDatabaseRepo interface {
 		updateFunc func(in *domain.InterfaceStatus) (*domain.InterfaceStatus, error),
 	) error
 	DeletePeerStatus(ctx context.Context, id domain.PeerIdentifier) error
+	SaveTrafficSamples(ctx context.Context, samples ...domain.TrafficSample) error
+	DownsampleTrafficSamples(ctx context.Context, source, target domain.TrafficResolution, before time.Time) error
+	DeleteTrafficSamples(ctx context.Context, resolution domain.TrafficResolution, before time.Time) error
+	RenameTrafficSamples(ctx context.Context, objectType domain.TrafficObjectType, oldId, newId string) error
+	CreatePeerSession(ctx context.Context, session *domain.PeerSession) error
+	UpdateActivePeerSession(
+		ctx context.Context,
+		id domain.PeerIdentifier,
+		updateFunc func(session *domain.PeerSession) (*domain.PeerSession, error),
+	) error
+	DeletePeerSessions(ctx context.Context, before time.Time) error
+	RenamePeerSessions(ctx context.Context, oldId, newId domain.PeerIdentifier) error
+	GetUsedIpsPerSubnet(ctx context.Context, subnets []domain.Cidr) (map[domain.Cidr][]domain.Cidr, error)
+	GetIpRanges(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.IpRange, error)
+	GetIpReservations(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.IpReservation, error)
 }
 
 type StatisticsInterfaceController interface {
@@ -38,6 +55,7 @@ type StatisticsInterfaceController interface {
 type StatisticsMetricsServer interface {
 	UpdateInterfaceMetrics(status domain.InterfaceStatus)
 	UpdatePeerMetrics(peer *domain.Peer, status domain.PeerStatus)
+	UpdateIpamMetrics(id domain.InterfaceIdentifier, utilization []domain.IpPoolUtilization)
 }
 
 type StatisticsEventBus interface {
@@ -54,9 +72,10 @@ type StatisticsCollector struct {
 	pingWaitGroup sync.WaitGroup
 	pingJobs      chan domain.Peer
 
-	db StatisticsDatabaseRepo
-	wg StatisticsInterfaceController
-	ms StatisticsMetricsServer
+	db     StatisticsDatabaseRepo
+	wg     StatisticsInterfaceController
+	agents AgentControllerProvider
+	ms     StatisticsMetricsServer
 
 	peerChangeEvent chan domain.PeerIdentifier
 }
@@ -67,15 +86,17 @@ func NewStatisticsCollector(
 	bus StatisticsEventBus,
 	db StatisticsDatabaseRepo,
 	wg StatisticsInterfaceController,
+	agents AgentControllerProvider,
 	ms StatisticsMetricsServer,
 ) (*StatisticsCollector, error) {
 	c := &StatisticsCollector{
 		cfg: cfg,
 		bus: bus,
 
-		db: db,
-		wg: wg,
-		ms: ms,
+		db:     db,
+		wg:     wg,
+		agents: agents,
+		ms:     ms,
 	}
 
 	c.connectToMessageBus()
@@ -89,6 +110,7 @@ func (c *StatisticsCollector) StartBackgroundJobs(ctx context.Context) {
 	c.startPingWorkers(ctx)
 	c.startInterfaceDataFetcher(ctx)
 	c.startPeerDataFetcher(ctx)
+	c.startMaintenance(ctx)
 }
 
 func (c *StatisticsCollector) startInterfaceDataFetcher(ctx context.Context) {
@@ -117,14 +139,25 @@ func (c *StatisticsCollector) collectInterfaceData(ctx context.Context) {
 			}
 
 			for _, in := range interfaces {
-				physicalInterface, err := c.wg.GetInterface(ctx, in.Identifier)
+				c.updateIpamMetrics(ctx, &in)
+
+				wg, ok := c.getController(&in)
+				if !ok {
+					continue
+				}
+				physicalInterface, err := wg.GetInterface(ctx, in.Identifier)
 				if err != nil {
 					slog.Warn("failed to load physical interface for data collection", "interface", in.Identifier,
 						"error", err)
 					continue
 				}
+				var sample domain.TrafficSample
 				err = c.db.UpdateInterfaceStatus(ctx, in.Identifier,
 					func(i *domain.InterfaceStatus) (*domain.InterfaceStatus, error) {
+						sample = newTrafficSample(domain.TrafficObjectInterface, string(in.Identifier),
+							i.BytesReceived, i.BytesTransmitted,
+							physicalInterface.BytesDownload, physicalInterface.BytesUpload)
+
 						i.UpdatedAt = time.Now()
 						i.BytesReceived = physicalInterface.BytesDownload
 						i.BytesTransmitted = physicalInterface.BytesUpload
@@ -136,13 +169,28 @@ func (c *StatisticsCollector) collectInterfaceData(ctx context.Context) {
 					})
 				if err != nil {
 					slog.Warn("failed to update interface status", "interface", in.Identifier, "error", err)
+					continue
 				}
 				slog.Debug("updated interface status", "interface", in.Identifier)
+
+				c.storeTrafficSample(ctx, sample)
 			}
 		}
 	}
 }
 
+// getController returns the controller that provides the data of the given interface.
+// If the interface belongs to a disconnected agent, no data can be collected and false is returned.
+func (c *StatisticsCollector) getController(in *domain.Interface) (StatisticsInterfaceController, bool) {
+	if !in.IsRemote() {
+		return c.wg, true
+	}
+	if !c.agents.IsConnected(in.AgentHost) {
+		return nil, false
+	}
+	return c.agents.InterfaceController(in.AgentHost, in.IsSoftwareDriver()), true
+}
+
 func (c *StatisticsCollector) startPeerDataFetcher(ctx context.Context) {
 	if !c.cfg.Statistics.CollectPeerData {
 		return
@@ -169,17 +217,32 @@ func (c *StatisticsCollector) collectPeerData(ctx context.Context) {
 			}
 
 			for _, in := range interfaces {
-				peers, err := c.wg.GetPeers(ctx, in.Identifier)
+				wg, ok := c.getController(&in)
+				if !ok {
+					continue
+				}
+				peers, err := wg.GetPeers(ctx, in.Identifier)
 				if err != nil {
 					slog.Warn("failed to fetch peers for data collection", "interface", in.Identifier, "error", err)
 					continue
 				}
+				quotas := c.getPeerQuotas(ctx, &in)
 				for _, peer := range peers {
+					quota := quotas[peer.Identifier].quota
+					var quotaExceeded bool
 					var connectionStateChanged bool
-					var newPeerStatus domain.PeerStatus
+					var oldPeerStatus, newPeerStatus domain.PeerStatus
+					var sample domain.TrafficSample
 					err = c.db.UpdatePeerStatus(ctx, peer.Identifier,
 						func(p *domain.PeerStatus) (*domain.PeerStatus, error) {
 							wasConnected := p.IsConnected
+							oldPeerStatus = *p
+
+							sample = newTrafficSample(domain.TrafficObjectPeer, string(peer.Identifier),
+								p.BytesReceived, p.BytesTransmitted, peer.BytesUpload, peer.BytesDownload)
+							p.UpdateQuotaUsage(quota, sample.Timestamp, sample.BytesReceived,
+								sample.BytesTransmitted)
+							quotaExceeded = p.IsQuotaExceeded()
 
 							var lastHandshake *time.Time
 							if !peer.LastHandshake.IsZero() {
@@ -198,8 +261,8 @@ func (c *StatisticsCollector) collectPeerData(ctx context.Context) {
 
 							if wasConnected != p.IsConnected {
 								connectionStateChanged = true
-								newPeerStatus = *p // store new status for event publishing
 							}
+							newPeerStatus = *p // store new status for event publishing and session tracking
 
 							// Update prometheus metrics
 							go c.updatePeerMetrics(ctx, *p)
@@ -210,6 +273,15 @@ func (c *StatisticsCollector) collectPeerData(ctx context.Context) {
 						slog.Warn("failed to update peer status", "peer", peer.Identifier, "error", err)
 					} else {
 						slog.Debug("updated peer status", "peer", peer.Identifier)
+						c.storeTrafficSample(ctx, sample)
+						c.trackPeerSession(ctx, in.Identifier, oldPeerStatus, newPeerStatus, &sample,
+							domain.DisconnectReasonHandshakeTimeout)
+
+						if quotaExceeded {
+							slog.Debug("peer exceeded traffic quota", "peer", peer.Identifier,
+								"used", newPeerStatus.QuotaUsed(), "limit", newPeerStatus.QuotaLimit)
+							c.bus.Publish(app.TopicPeerQuotaExceeded, quotas[peer.Identifier].peer, newPeerStatus)
+						}
 					}
 
 					if connectionStateChanged {
@@ -228,6 +300,136 @@ func (c *StatisticsCollector) collectPeerData(ctx context.Context) {
 	}
 }
 
+type peerQuota struct {
+	peer  domain.Peer
+	quota domain.TrafficQuota
+}
+
+// getPeerQuotas returns the effective traffic quotas of all peers of the given interface.
+func (c *StatisticsCollector) getPeerQuotas(
+	ctx context.Context,
+	in *domain.Interface,
+) map[domain.PeerIdentifier]peerQuota {
+	peers, err := c.db.GetInterfacePeers(ctx, in.Identifier)
+	if err != nil {
+		slog.Warn("failed to fetch peers for quota calculation", "interface", in.Identifier, "error", err)
+		return nil
+	}
+
+	users := make(map[domain.UserIdentifier]*domain.User)
+	quotas := make(map[domain.PeerIdentifier]peerQuota, len(peers))
+	for i, peer := range peers {
+		user, loaded := users[peer.UserIdentifier]
+		if !loaded && peer.UserIdentifier != "" {
+			user, err = c.db.GetUser(ctx, peer.UserIdentifier)
+			if err != nil && !errors.Is(err, domain.ErrNotFound) {
+				slog.Warn("failed to fetch user for quota calculation", "user", peer.UserIdentifier,
+					"error", err)
+			}
+			users[peer.UserIdentifier] = user
+		}
+		quotas[peer.Identifier] = peerQuota{peer: peer, quota: domain.EffectiveQuota(&peers[i], user, in)}
+	}
+
+	return quotas
+}
+
+// newTrafficSample creates a raw traffic sample from the old and new counter values.
+func newTrafficSample(
+	objectType domain.TrafficObjectType,
+	objectId string,
+	oldReceived, oldTransmitted, newReceived, newTransmitted uint64,
+) domain.TrafficSample {
+	return domain.TrafficSample{
+		ObjectType:       objectType,
+		ObjectId:         objectId,
+		Resolution:       domain.TrafficResolutionRaw,
+		Timestamp:        time.Now().UTC(),
+		BytesReceived:    domain.CounterDelta(oldReceived, newReceived),
+		BytesTransmitted: domain.CounterDelta(oldTransmitted, newTransmitted),
+	}
+}
+
+func (c *StatisticsCollector) storeTrafficSample(ctx context.Context, sample domain.TrafficSample) {
+	if !c.cfg.Statistics.CollectTrafficHistory {
+		return
+	}
+	if sample.BytesReceived == 0 && sample.BytesTransmitted == 0 {
+		return // no traffic, nothing to store
+	}
+
+	if err := c.db.SaveTrafficSamples(ctx, sample); err != nil {
+		slog.Warn("failed to store traffic sample", "type", sample.ObjectType, "id", sample.ObjectId,
+			"error", err)
+	}
+}
+
+// startMaintenance starts the hourly cleanup of the traffic history and the session log.
+func (c *StatisticsCollector) startMaintenance(ctx context.Context) {
+	go func() {
+		c.runMaintenance(ctx)
+
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return // program stopped
+			case <-ticker.C:
+				c.runMaintenance(ctx)
+			}
+		}
+	}()
+
+	slog.Debug("started statistics maintenance")
+}
+
+func (c *StatisticsCollector) runMaintenance(ctx context.Context) {
+	if c.cfg.Statistics.CollectTrafficHistory {
+		c.maintainTrafficHistory(ctx)
+	}
+	if c.cfg.Statistics.SessionLogRetention > 0 {
+		before := time.Now().Add(-c.cfg.Statistics.SessionLogRetention)
+		if err := c.db.DeletePeerSessions(ctx, before); err != nil {
+			slog.Warn("failed to delete expired peer sessions", "error", err)
+		}
+	}
+}
+
+// maintainTrafficHistory downsamples raw samples to hourly samples and hourly samples to daily samples
+// once they exceed their retention period. Daily samples are deleted after their retention period.
+func (c *StatisticsCollector) maintainTrafficHistory(ctx context.Context) {
+	now := time.Now()
+	cfg := c.cfg.Statistics
+
+	if cfg.TrafficHistoryRawRetention > 0 {
+		before := domain.TrafficResolutionHourly.Truncate(now.Add(-cfg.TrafficHistoryRawRetention))
+		err := c.db.DownsampleTrafficSamples(ctx, domain.TrafficResolutionRaw, domain.TrafficResolutionHourly, before)
+		if err != nil {
+			slog.Warn("failed to downsample raw traffic samples", "error", err)
+		}
+	}
+
+	if cfg.TrafficHistoryHourlyRetention > 0 {
+		before := domain.TrafficResolutionDaily.Truncate(now.Add(-cfg.TrafficHistoryHourlyRetention))
+		err := c.db.DownsampleTrafficSamples(ctx, domain.TrafficResolutionHourly, domain.TrafficResolutionDaily,
+			before)
+		if err != nil {
+			slog.Warn("failed to downsample hourly traffic samples", "error", err)
+		}
+	}
+
+	if cfg.TrafficHistoryDailyRetention > 0 {
+		before := now.Add(-cfg.TrafficHistoryDailyRetention)
+		err := c.db.DeleteTrafficSamples(ctx, domain.TrafficResolutionDaily, before)
+		if err != nil {
+			slog.Warn("failed to delete expired traffic samples", "error", err)
+		}
+	}
+
+	slog.Debug("traffic history maintenance completed")
+}
+
 func getSessionStartTime(
 	oldStats domain.PeerStatus,
 	newReceived, newTransmitted uint64,
@@ -307,6 +509,9 @@ func (c *StatisticsCollector) enqueuePingChecks(ctx context.Context) {
 			}
 
 			for _, in := range interfaces {
+				if in.IsRemote() {
+					continue // peers of remote hosts are not reachable from the portal
+				}
 				peers, err := c.db.GetInterfacePeers(ctx, in.Identifier)
 				if err != nil {
 					slog.Warn("failed to fetch peers for ping checks", "interface", in.Identifier, "error", err)
@@ -324,7 +529,7 @@ func (c *StatisticsCollector) pingWorker(ctx context.Context) {
 	defer c.pingWaitGroup.Done()
 	for peer := range c.pingJobs {
 		var connectionStateChanged bool
-		var newPeerStatus domain.PeerStatus
+		var oldPeerStatus, newPeerStatus domain.PeerStatus
 
 		peerPingable := c.isPeerPingable(ctx, peer)
 		slog.Debug("peer ping check completed", "peer", peer.Identifier, "pingable", peerPingable)
@@ -333,6 +538,7 @@ func (c *StatisticsCollector) pingWorker(ctx context.Context) {
 		err := c.db.UpdatePeerStatus(ctx, peer.Identifier,
 			func(p *domain.PeerStatus) (*domain.PeerStatus, error) {
 				wasConnected := p.IsConnected
+				oldPeerStatus = *p
 
 				if peerPingable {
 					p.IsPingable = true
@@ -358,6 +564,10 @@ func (c *StatisticsCollector) pingWorker(ctx context.Context) {
 			slog.Warn("failed to update peer ping status", "peer", peer.Identifier, "error", err)
 		} else {
 			slog.Debug("updated peer ping status", "peer", peer.Identifier)
+			if connectionStateChanged {
+				c.trackPeerSession(ctx, peer.InterfaceIdentifier, oldPeerStatus, newPeerStatus, nil,
+					domain.DisconnectReasonPingTimeout)
+			}
 		}
 
 		if connectionStateChanged {
@@ -400,6 +610,15 @@ func (c *StatisticsCollector) updateInterfaceMetrics(status domain.InterfaceStat
 	c.ms.UpdateInterfaceMetrics(status)
 }
 
+func (c *StatisticsCollector) updateIpamMetrics(ctx context.Context, in *domain.Interface) {
+	utilization, err := getIpUtilization(ctx, c.db, in)
+	if err != nil {
+		slog.Warn("failed to calculate ip utilization for metrics", "interface", in.Identifier, "error", err)
+		return
+	}
+	c.ms.UpdateIpamMetrics(in.Identifier, utilization)
+}
+
 func (c *StatisticsCollector) updatePeerMetrics(ctx context.Context, status domain.PeerStatus) {
 	// Fetch peer data from the database
 	peer, err := c.db.GetPeer(ctx, status.PeerId)
@@ -423,4 +642,16 @@ func (c *StatisticsCollector) handlePeerIdentifierChangeEvent(oldIdentifier, new
 		slog.Error("failed to delete old peer status for migrated peer", "oldIdentifier", oldIdentifier,
 			"newIdentifier", newIdentifier, "error", err)
 	}
+
+	// keep the traffic history and the session log of the peer
+	err = c.db.RenameTrafficSamples(ctx, domain.TrafficObjectPeer, string(oldIdentifier), string(newIdentifier))
+	if err != nil {
+		slog.Error("failed to migrate traffic history of peer", "oldIdentifier", oldIdentifier,
+			"newIdentifier", newIdentifier, "error", err)
+	}
+	err = c.db.RenamePeerSessions(ctx, oldIdentifier, newIdentifier)
+	if err != nil {
+		slog.Error("failed to migrate session log of peer", "oldIdentifier", oldIdentifier,
+			"newIdentifier", newIdentifier, "error", err)
+	}
 }
@@ -0,0 +1,102 @@
+package wireguard
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// trackPeerSession updates the session log of a peer based on its old and new status.
+// A session is started if the peer connects and ended if the peer disconnects. The given traffic sample
+// is added to the active session, it may be nil if no traffic data was collected.
+func (c *StatisticsCollector) trackPeerSession(
+	ctx context.Context,
+	interfaceId domain.InterfaceIdentifier,
+	oldStatus, newStatus domain.PeerStatus,
+	sample *domain.TrafficSample,
+	reason domain.PeerDisconnectReason,
+) {
+	if !c.cfg.Statistics.CollectPeerData {
+		return
+	}
+
+	var err error
+	switch {
+	case !oldStatus.IsConnected && newStatus.IsConnected:
+		startedAt := time.Now()
+		if sample != nil && newStatus.LastHandshake != nil {
+			startedAt = *newStatus.LastHandshake // the peer was connected by a handshake
+		}
+		err = c.startPeerSession(ctx, interfaceId, newStatus, startedAt, sample)
+	case oldStatus.IsConnected && newStatus.IsConnected:
+		err = c.db.UpdateActivePeerSession(ctx, newStatus.PeerId,
+			func(session *domain.PeerSession) (*domain.PeerSession, error) {
+				addToPeerSession(session, newStatus, sample)
+				return session, nil
+			})
+		if errors.Is(err, domain.ErrNotFound) {
+			// the peer was already connected before the session log was enabled
+			startedAt := time.Now()
+			if newStatus.LastSessionStart != nil {
+				startedAt = *newStatus.LastSessionStart
+			}
+			err = c.startPeerSession(ctx, interfaceId, newStatus, startedAt, sample)
+		}
+	case oldStatus.IsConnected && !newStatus.IsConnected:
+		err = c.db.UpdateActivePeerSession(ctx, newStatus.PeerId,
+			func(session *domain.PeerSession) (*domain.PeerSession, error) {
+				addToPeerSession(session, newStatus, sample)
+				session.End(lastSeen(oldStatus, newStatus), reason)
+				return session, nil
+			})
+		if errors.Is(err, domain.ErrNotFound) {
+			err = nil // no session to end
+		}
+	}
+	if err != nil {
+		slog.Warn("failed to update peer session log", "peer", newStatus.PeerId, "error", err)
+	}
+}
+
+func (c *StatisticsCollector) startPeerSession(
+	ctx context.Context,
+	interfaceId domain.InterfaceIdentifier,
+	status domain.PeerStatus,
+	startedAt time.Time,
+	sample *domain.TrafficSample,
+) error {
+	session := &domain.PeerSession{
+		PeerId:      status.PeerId,
+		InterfaceId: interfaceId,
+		StartedAt:   startedAt,
+	}
+	addToPeerSession(session, status, sample)
+
+	return c.db.CreatePeerSession(ctx, session)
+}
+
+func addToPeerSession(session *domain.PeerSession, status domain.PeerStatus, sample *domain.TrafficSample) {
+	session.AddEndpoint(status.Endpoint)
+	if sample != nil {
+		session.BytesReceived += sample.BytesReceived
+		session.BytesTransmitted += sample.BytesTransmitted
+	}
+}
+
+// lastSeen returns the last time the peer was known to be connected.
+func lastSeen(oldStatus, newStatus domain.PeerStatus) time.Time {
+	var last time.Time
+	if newStatus.LastHandshake != nil {
+		last = *newStatus.LastHandshake
+	}
+	if oldStatus.LastPing != nil && oldStatus.LastPing.After(last) {
+		last = *oldStatus.LastPing
+	}
+	if last.IsZero() {
+		return time.Now()
+	}
+	return last
+}
@@ -17,6 +17,11 @@ type InterfaceAndPeerDatabaseRepo interface {
 	GetInterface(ctx context.Context, id domain.InterfaceIdentifier) (*domain.Interface, error)
 	GetInterfaceAndPeers(ctx context.Context, id domain.InterfaceIdentifier) (*domain.Interface, []domain.Peer, error)
 	GetPeersStats(ctx context.Context, ids ...domain.PeerIdentifier) ([]domain.PeerStatus, error)
+	UpdatePeerStatus(
+		ctx context.Context,
+		id domain.PeerIdentifier,
+		updateFunc func(in *domain.PeerStatus) (*domain.PeerStatus, error),
+	) error
 	GetAllInterfaces(ctx context.Context) ([]domain.Interface, error)
 	GetInterfaceIps(ctx context.Context) (map[domain.InterfaceIdentifier][]domain.Cidr, error)
 	SaveInterface(
@@ -35,6 +40,41 @@ type InterfaceAndPeerDatabaseRepo interface {
 	DeletePeer(ctx context.Context, id domain.PeerIdentifier) error
 	GetPeer(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error)
 	GetUsedIpsPerSubnet(ctx context.Context, subnets []domain.Cidr) (map[domain.Cidr][]domain.Cidr, error)
+	GetUser(ctx context.Context, id domain.UserIdentifier) (*domain.User, error)
+	GetPeerExpiryNotifications(
+		ctx context.Context,
+		id domain.PeerIdentifier,
+		expiresAt time.Time,
+	) ([]domain.PeerExpiryNotification, error)
+	SavePeerExpiryNotification(ctx context.Context, notification *domain.PeerExpiryNotification) error
+	GetIpRanges(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.IpRange, error)
+	SaveIpRange(
+		ctx context.Context,
+		id domain.IpRangeIdentifier,
+		updateFunc func(in *domain.IpRange) (*domain.IpRange, error),
+	) error
+	DeleteIpRange(ctx context.Context, id domain.InterfaceIdentifier, rangeId domain.IpRangeIdentifier) error
+	GetIpReservations(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.IpReservation, error)
+	SaveIpReservation(ctx context.Context, reservation *domain.IpReservation) error
+	DeleteIpReservation(ctx context.Context, id domain.InterfaceIdentifier, address string) error
+	GetSiteNetworks(ctx context.Context) ([]domain.SiteNetwork, error)
+	GetSiteNetwork(ctx context.Context, id domain.SiteNetworkIdentifier) (*domain.SiteNetwork, error)
+	GetInterfaceSites(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.Site, error)
+	SaveSiteNetwork(
+		ctx context.Context,
+		id domain.SiteNetworkIdentifier,
+		updateFunc func(in *domain.SiteNetwork) (*domain.SiteNetwork, error),
+	) error
+	DeleteSiteNetwork(ctx context.Context, id domain.SiteNetworkIdentifier) error
+	GetTaggedPeers(ctx context.Context, tag string) ([]domain.Peer, error)
+	GetPeerTags(ctx context.Context) ([]domain.PeerTag, error)
+	GetPeerTag(ctx context.Context, name string) (*domain.PeerTag, error)
+	SavePeerTag(
+		ctx context.Context,
+		name string,
+		updateFunc func(in *domain.PeerTag) (*domain.PeerTag, error),
+	) error
+	DeletePeerTag(ctx context.Context, name string) error
 }
 
 type InterfaceController interface {
@@ -62,6 +102,22 @@ type WgQuickController interface {
 	UnsetDNS(id domain.InterfaceIdentifier) error
 }
 
+type ConfigFileImporter interface {
+	// GetInterfaceConfigs returns all wg-quick configuration files that can be imported.
+	GetInterfaceConfigs(ctx context.Context) ([]domain.ConfigFileInterface, error)
+}
+
+type AgentControllerProvider interface {
+	// IsKnownHost returns true if an agent host with the given name is configured.
+	IsKnownHost(host string) bool
+	// IsConnected returns true if the agent with the given name is currently connected.
+	IsConnected(host string) bool
+	// InterfaceController returns the controller for the WireGuard interfaces of the given agent host.
+	InterfaceController(host string, software bool) InterfaceController
+	// WgQuickController returns the controller for the wg-quick actions of the given agent host.
+	WgQuickController(host string) WgQuickController
+}
+
 type EventBus interface {
 	// Publish sends a message to the message bus.
 	Publish(topic string, args ...any)
@@ -72,29 +128,40 @@ type EventBus interface {
 // endregion dependencies
 
 type Manager struct {
-	cfg   *config.Config
-	bus   EventBus
-	db    InterfaceAndPeerDatabaseRepo
-	wg    InterfaceController
-	quick WgQuickController
+	cfg        *config.Config
+	bus        EventBus
+	db         InterfaceAndPeerDatabaseRepo
+	wg         InterfaceController // kernel interfaces, also used to read and configure userspace interfaces
+	wgSoftware InterfaceController // userspace interfaces, only used to create and delete them
+	quick      WgQuickController
+	files      ConfigFileImporter
+	agents     AgentControllerProvider // interfaces on remote hosts
 
 	userLockMap *sync.Map
+	driftState  *sync.Map // the fingerprint of the last detected drift per interface
 }
 
 func NewWireGuardManager(
 	cfg *config.Config,
 	bus EventBus,
 	wg InterfaceController,
+	wgSoftware InterfaceController,
+	agents AgentControllerProvider,
 	quick WgQuickController,
+	files ConfigFileImporter,
 	db InterfaceAndPeerDatabaseRepo,
 ) (*Manager, error) {
 	m := &Manager{
 		cfg:         cfg,
 		bus:         bus,
 		wg:          wg,
+		wgSoftware:  wgSoftware,
 		db:          db,
 		quick:       quick,
+		files:       files,
+		agents:      agents,
 		userLockMap: &sync.Map{},
+		driftState:  &sync.Map{},
 	}
 
 	m.connectToMessageBus()
@@ -102,10 +169,11 @@ func NewWireGuardManager(
 	return m, nil
 }
 
-// StartBackgroundJobs starts background jobs like the expired peers check.
+// StartBackgroundJobs starts background jobs like the expired peers and quota check or the drift check.
 // This method is non-blocking.
 func (m Manager) StartBackgroundJobs(ctx context.Context) {
 	go m.runExpiredPeersCheck(ctx)
+	go m.runDriftCheck(ctx)
 }
 
 func (m Manager) connectToMessageBus() {
@@ -114,6 +182,8 @@ func (m Manager) connectToMessageBus() {
 	_ = m.bus.Subscribe(app.TopicUserDisabled, m.handleUserDisabledEvent)
 	_ = m.bus.Subscribe(app.TopicUserEnabled, m.handleUserEnabledEvent)
 	_ = m.bus.Subscribe(app.TopicUserDeleted, m.handleUserDeletedEvent)
+	_ = m.bus.Subscribe(app.TopicAgentConnected, m.handleAgentConnectedEvent)
+	_ = m.bus.Subscribe(app.TopicPeerQuotaExceeded, m.handlePeerQuotaExceededEvent)
 }
 
 func (m Manager) handleUserCreationEvent(user domain.User) {
@@ -290,6 +360,33 @@ func (m Manager) handleUserDeletedEvent(user domain.User) {
 	}
 }
 
+// handleAgentConnectedEvent restores the state of all interfaces of the agent host.
+// Changes that happened while the agent was unreachable are applied, the database wins.
+func (m Manager) handleAgentConnectedEvent(host string) {
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	interfaces, err := m.db.GetAllInterfaces(ctx)
+	if err != nil {
+		slog.Error("failed to load interfaces for agent resync", "host", host, "error", err)
+		return
+	}
+
+	var hostInterfaces []domain.InterfaceIdentifier
+	for _, iface := range interfaces {
+		if iface.AgentHost == host {
+			hostInterfaces = append(hostInterfaces, iface.Identifier)
+		}
+	}
+	if len(hostInterfaces) == 0 {
+		return
+	}
+
+	slog.Debug("resyncing agent interfaces", "host", host, "interfaces", hostInterfaces)
+	if err := m.RestoreInterfaceState(ctx, false, hostInterfaces...); err != nil {
+		slog.Error("failed to resync agent interfaces", "host", host, "error", err)
+	}
+}
+
 func (m Manager) runExpiredPeersCheck(ctx context.Context) {
 	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo())
 
@@ -318,7 +415,9 @@ func (m Manager) runExpiredPeersCheck(ctx context.Context) {
 				continue
 			}
 
+			m.checkExpiryReminders(ctx, peers)
 			m.checkExpiredPeers(ctx, peers)
+			m.checkQuotaPeriods(ctx, &iface, peers)
 		}
 	}
 }
@@ -336,7 +435,10 @@ func (m Manager) checkExpiredPeers(ctx context.Context, peers []domain.Peer) {
 			_, err := m.UpdatePeer(ctx, &peer)
 			if err != nil {
 				slog.Error("failed to update expired peer", "peer", peer.Identifier, "error", err)
+				continue
 			}
+
+			m.sendExpiredNotification(ctx, peer)
 		}
 	}
 }
@@ -0,0 +1,182 @@
+package wireguard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/app/audit"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// BulkUpdatePeers applies the action of the request to all selected peers. In dry-run mode, the result lists the
+// peers that would be changed, but nothing is stored. A single audit entry is recorded for the whole run.
+func (m Manager) BulkUpdatePeers(ctx context.Context, req *domain.PeerBulkRequest) (*domain.BulkResult, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	result := &domain.BulkResult{Action: req.Action, DryRun: req.DryRun}
+
+	peers, err := m.selectPeers(ctx, req.Selector, result)
+	if err != nil {
+		return nil, err
+	}
+	peers = excludeManagedPeers(ctx, peers, result)
+
+	switch req.Action {
+	case domain.BulkActionDelete:
+		for i := range peers {
+			if req.DryRun {
+				result.Add(string(peers[i].Identifier), domain.BulkItemChanged, nil)
+				continue
+			}
+			err := m.DeletePeer(ctx, peers[i].Identifier)
+			result.Add(string(peers[i].Identifier), domain.BulkItemChanged, err)
+		}
+	case domain.BulkActionMove:
+		if _, err := m.db.GetInterface(ctx, req.Target); err != nil {
+			return nil, fmt.Errorf("unable to load interface %s: %w", req.Target, err)
+		}
+		for i := range peers {
+			if peers[i].InterfaceIdentifier == req.Target {
+				result.Add(string(peers[i].Identifier), domain.BulkItemUnchanged, nil)
+				continue
+			}
+			if req.DryRun {
+				result.Add(string(peers[i].Identifier), domain.BulkItemChanged, nil)
+				continue
+			}
+			_, err := m.movePeer(ctx, peers[i].Identifier, req.Target, false)
+			result.Add(string(peers[i].Identifier), domain.BulkItemChanged, err)
+		}
+	default:
+		now := time.Now()
+		changed := make([]*domain.Peer, 0, len(peers))
+		for i := range peers {
+			if !req.Apply(&peers[i], now) {
+				result.Add(string(peers[i].Identifier), domain.BulkItemUnchanged, nil)
+				continue
+			}
+			changed = append(changed, &peers[i])
+		}
+
+		if req.DryRun {
+			for _, peer := range changed {
+				result.Add(string(peer.Identifier), domain.BulkItemChanged, nil)
+			}
+		} else {
+			m.saveBulkPeers(ctx, changed, result)
+		}
+	}
+
+	if !req.DryRun {
+		m.bus.Publish(app.TopicAuditBulkAction, domain.AuditEventWrapper[audit.BulkEvent]{
+			Ctx: ctx,
+			Event: audit.BulkEvent{
+				Target:   "peers",
+				Selector: req.Selector.String(),
+				Reason:   req.Reason,
+				Result:   *result,
+			},
+		})
+	}
+
+	return result, nil
+}
+
+// selectPeers loads all peers that match the selector. Unknown peer identifiers are recorded as failed items.
+func (m Manager) selectPeers(
+	ctx context.Context,
+	selector domain.PeerSelector,
+	result *domain.BulkResult,
+) ([]domain.Peer, error) {
+	var candidates []domain.Peer
+	switch {
+	case len(selector.Identifiers) > 0:
+		for _, id := range selector.Identifiers {
+			peer, err := m.db.GetPeer(ctx, id)
+			if err != nil {
+				result.Add(string(id), domain.BulkItemFailed, fmt.Errorf("unable to load peer: %w", err))
+				continue
+			}
+			candidates = append(candidates, *peer)
+		}
+	case selector.Interface != "":
+		peers, err := m.db.GetInterfacePeers(ctx, selector.Interface)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load peers of interface %s: %w", selector.Interface, err)
+		}
+		candidates = peers
+	case selector.User != "":
+		peers, err := m.db.GetUserPeers(ctx, selector.User)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load peers of user %s: %w", selector.User, err)
+		}
+		candidates = peers
+	case selector.Tag != "":
+		peers, err := m.db.GetTaggedPeers(ctx, selector.Tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load peers with tag %s: %w", selector.Tag, err)
+		}
+		candidates = peers
+	default:
+		interfaces, err := m.db.GetAllInterfaces(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load interfaces: %w", err)
+		}
+		for _, iface := range interfaces {
+			peers, err := m.db.GetInterfacePeers(ctx, iface.Identifier)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load peers of interface %s: %w", iface.Identifier, err)
+			}
+			candidates = append(candidates, peers...)
+		}
+	}
+
+	selected := make([]domain.Peer, 0, len(candidates))
+	for i := range candidates {
+		if selector.Matches(&candidates[i]) {
+			selected = append(selected, candidates[i])
+		}
+	}
+
+	return selected, nil
+}
+
+// saveBulkPeers stores the changed peers and records the outcome for each of them. A failing peer does not stop
+// the remaining peers from being stored.
+func (m Manager) saveBulkPeers(ctx context.Context, peers []*domain.Peer, result *domain.BulkResult) {
+	for offset := 0; offset < len(peers); {
+		saved, err := m.persistPeers(ctx, false, peers[offset:]...)
+		for _, peer := range peers[offset : offset+saved] {
+			result.Add(string(peer.Identifier), domain.BulkItemChanged, nil)
+			m.bus.Publish(app.TopicPeerUpdated, *peer)
+		}
+		offset += saved
+
+		if err != nil {
+			result.Add(string(peers[offset].Identifier), domain.BulkItemFailed, err)
+			offset++
+		}
+	}
+}
+
+// excludeManagedPeers records all peers that are managed by the declarative spec as failed items.
+func excludeManagedPeers(ctx context.Context, peers []domain.Peer, result *domain.BulkResult) []domain.Peer {
+	editable := make([]domain.Peer, 0, len(peers))
+	for i := range peers {
+		if err := domain.ValidateManagedAccess(ctx, peers[i].ManagedBy); err != nil {
+			result.Add(string(peers[i].Identifier), domain.BulkItemFailed, err)
+			continue
+		}
+		editable = append(editable, peers[i])
+	}
+
+	return editable
+}
@@ -0,0 +1,311 @@
+package wireguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// DetectDrift compares the database state of all enabled interfaces with their physical state.
+// The drift is only detected, no changes are applied.
+func (m Manager) DetectDrift(ctx context.Context) ([]domain.InterfaceDrift, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	interfaces, err := m.db.GetAllInterfaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load all interfaces: %w", err)
+	}
+
+	drifts := make([]domain.InterfaceDrift, 0, len(interfaces))
+	for _, iface := range interfaces {
+		if iface.IsDisabled() {
+			continue // disabled interfaces are not expected to exist on the system
+		}
+		if iface.IsRemote() && !m.agents.IsConnected(iface.AgentHost) {
+			continue // the state of the interface is unknown until the agent reconnects
+		}
+
+		peers, err := m.db.GetInterfacePeers(ctx, iface.Identifier)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load peers for %s: %w", iface.Identifier, err)
+		}
+
+		drift, err := m.detectInterfaceDrift(ctx, &iface, peers)
+		if err != nil {
+			return nil, fmt.Errorf("drift detection for %s failed: %w", iface.Identifier, err)
+		}
+		drifts = append(drifts, drift)
+	}
+
+	return drifts, nil
+}
+
+func (m Manager) runDriftCheck(ctx context.Context) {
+	if m.cfg.Advanced.DriftCheckInterval == 0 {
+		slog.Debug("skipping drift check - feature disabled")
+		return
+	}
+
+	ctx = domain.SetUserInfo(ctx, domain.SystemAdminContextUserInfo())
+
+	running := true
+	for running {
+		select {
+		case <-ctx.Done():
+			running = false
+			continue
+		case <-time.After(m.cfg.Advanced.DriftCheckInterval):
+			// select blocks until one of the cases evaluate to true
+		}
+
+		drifts, err := m.DetectDrift(ctx)
+		if err != nil {
+			slog.Error("failed to detect interface drift", "error", err)
+			continue
+		}
+
+		for _, drift := range drifts {
+			m.handleInterfaceDrift(ctx, drift)
+		}
+	}
+}
+
+// handleInterfaceDrift resolves the drift according to the drift mode of the interface.
+// An event is only published if the drift changed since the last check.
+func (m Manager) handleInterfaceDrift(ctx context.Context, drift domain.InterfaceDrift) {
+	fingerprint := driftFingerprint(drift)
+	lastFingerprint, _ := m.driftState.Swap(drift.InterfaceIdentifier, fingerprint)
+	if !drift.HasDrift() {
+		if lastFingerprint != nil && lastFingerprint != "" {
+			slog.Info("interface drift resolved", "interface", drift.InterfaceIdentifier)
+			m.bus.Publish(app.TopicInterfaceDriftDetected, drift)
+		}
+		return
+	}
+
+	if drift.Mode == domain.DriftModeAlert && lastFingerprint == fingerprint {
+		return // already reported
+	}
+
+	var err error
+	switch drift.Mode {
+	case domain.DriftModeCorrect:
+		err = m.RestoreInterfaceState(ctx, false, drift.InterfaceIdentifier)
+	case domain.DriftModeImport:
+		err = m.importInterfaceDrift(ctx, drift)
+	}
+	if err != nil {
+		drift.Error = err.Error()
+	} else {
+		drift.Resolved = drift.Mode != domain.DriftModeAlert
+	}
+
+	if drift.Resolved {
+		m.driftState.Delete(drift.InterfaceIdentifier) // report future drift again
+	}
+
+	slog.Warn("interface drift detected",
+		"interface", drift.InterfaceIdentifier,
+		"mode", drift.Mode,
+		"differences", len(drift.Items),
+		"resolved", drift.Resolved,
+		"error", err)
+	m.bus.Publish(app.TopicInterfaceDriftDetected, drift)
+}
+
+func (m Manager) detectInterfaceDrift(ctx context.Context, iface *domain.Interface, peers []domain.Peer) (
+	domain.InterfaceDrift,
+	error,
+) {
+	drift := domain.InterfaceDrift{
+		InterfaceIdentifier: iface.Identifier,
+		Mode:                iface.GetDriftMode(),
+		CheckedAt:           time.Now(),
+	}
+
+	wg := m.getController(iface)
+
+	physicalInterface, err := wg.GetInterface(ctx, iface.Identifier)
+	if err != nil {
+		drift.Items = append(drift.Items, domain.DriftItem{Type: domain.DriftTypeInterfaceMissing})
+		return drift, nil
+	}
+	drift.Items = append(drift.Items, domain.CompareInterfaceState(iface, physicalInterface)...)
+
+	physicalPeers, err := wg.GetPeers(ctx, iface.Identifier)
+	if err != nil {
+		return drift, fmt.Errorf("failed to load physical peers: %w", err)
+	}
+
+	physicalPeerMap := make(map[domain.PeerIdentifier]domain.PhysicalPeer, len(physicalPeers))
+	for _, physicalPeer := range physicalPeers {
+		physicalPeerMap[domain.PeerIdentifier(physicalPeer.PublicKey)] = physicalPeer
+	}
+
+	knownPeers := make(map[domain.PeerIdentifier]struct{}, len(peers))
+	for _, peer := range peers {
+		physicalPeer, exists := physicalPeerMap[peer.Identifier]
+		if peer.IsDisabled() {
+			continue // disabled peers are reported as unexpected below
+		}
+		knownPeers[peer.Identifier] = struct{}{}
+
+		if !exists {
+			drift.Items = append(drift.Items, domain.DriftItem{
+				Type:           domain.DriftTypePeerMissing,
+				PeerIdentifier: peer.Identifier,
+			})
+			continue
+		}
+		drift.Items = append(drift.Items, domain.ComparePeerState(&peer, &physicalPeer)...)
+	}
+
+	for _, physicalPeer := range physicalPeers {
+		peerId := domain.PeerIdentifier(physicalPeer.PublicKey)
+		if _, known := knownPeers[peerId]; known {
+			continue
+		}
+		drift.Items = append(drift.Items, domain.DriftItem{
+			Type:           domain.DriftTypePeerUnexpected,
+			PeerIdentifier: peerId,
+		})
+	}
+
+	return drift, nil
+}
+
+// importInterfaceDrift updates the database with the physical state of the interface.
+// Peers that are missing on the system are disabled, unknown peers are imported.
+func (m Manager) importInterfaceDrift(ctx context.Context, drift domain.InterfaceDrift) error {
+	iface, err := m.db.GetInterface(ctx, drift.InterfaceIdentifier)
+	if err != nil {
+		return fmt.Errorf("unable to load interface: %w", err)
+	}
+
+	wg := m.getController(iface)
+
+	now := time.Now()
+	physicalInterface, err := wg.GetInterface(ctx, iface.Identifier)
+	if err != nil {
+		// the interface no longer exists, disable it
+		return m.db.SaveInterface(ctx, iface.Identifier, func(in *domain.Interface) (*domain.Interface, error) {
+			in.Disabled = &now
+			in.DisabledReason = domain.DisabledReasonInterfaceMissing
+			in.UpdatedBy = domain.CtxSystemWgImporter
+			in.UpdatedAt = now
+			return in, nil
+		})
+	}
+
+	if slices.ContainsFunc(drift.Items, func(item domain.DriftItem) bool {
+		return item.Type == domain.DriftTypeInterfaceMismatch
+	}) {
+		err = m.db.SaveInterface(ctx, iface.Identifier, func(in *domain.Interface) (*domain.Interface, error) {
+			in.KeyPair = physicalInterface.KeyPair
+			in.ListenPort = physicalInterface.ListenPort
+			if in.Mtu != 0 {
+				in.Mtu = physicalInterface.Mtu
+			}
+			if in.FirewallMark != 0 {
+				in.FirewallMark = physicalInterface.FirewallMark
+			}
+			in.Addresses = physicalInterface.ManagedAddresses()
+			in.UpdatedBy = domain.CtxSystemWgImporter
+			in.UpdatedAt = now
+			return in, nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update interface: %w", err)
+		}
+		iface.Addresses = physicalInterface.ManagedAddresses()
+	}
+
+	physicalPeers, err := wg.GetPeers(ctx, iface.Identifier)
+	if err != nil {
+		return fmt.Errorf("failed to load physical peers: %w", err)
+	}
+
+	for _, item := range drift.Items {
+		if item.PeerIdentifier == "" {
+			continue
+		}
+
+		physicalPeerIdx := slices.IndexFunc(physicalPeers, func(pp domain.PhysicalPeer) bool {
+			return domain.PeerIdentifier(pp.PublicKey) == item.PeerIdentifier
+		})
+
+		switch item.Type {
+		case domain.DriftTypePeerMissing:
+			err = m.db.SavePeer(ctx, item.PeerIdentifier, func(p *domain.Peer) (*domain.Peer, error) {
+				p.Disabled = &now
+				p.DisabledReason = domain.DisabledReasonPeerMissing
+				p.UpdatedBy = domain.CtxSystemWgImporter
+				p.UpdatedAt = now
+				return p, nil
+			})
+		case domain.DriftTypePeerUnexpected:
+			if physicalPeerIdx == -1 {
+				continue // peer vanished in the meantime
+			}
+			err = m.importUnexpectedPeer(ctx, iface, &physicalPeers[physicalPeerIdx])
+		case domain.DriftTypePeerMismatch:
+			if physicalPeerIdx == -1 {
+				continue // peer vanished in the meantime
+			}
+			err = m.db.SavePeer(ctx, item.PeerIdentifier, func(p *domain.Peer) (*domain.Peer, error) {
+				domain.MergeFromPhysicalPeer(p, &physicalPeers[physicalPeerIdx], iface.Addresses)
+				p.UpdatedBy = domain.CtxSystemWgImporter
+				p.UpdatedAt = now
+				return p, nil
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to import drift of peer %s: %w", item.PeerIdentifier, err)
+		}
+	}
+
+	return nil
+}
+
+// importUnexpectedPeer enables a disabled peer or imports a peer that is unknown to the database.
+func (m Manager) importUnexpectedPeer(ctx context.Context, iface *domain.Interface, pp *domain.PhysicalPeer) error {
+	existingPeer, err := m.db.GetPeer(ctx, domain.PeerIdentifier(pp.PublicKey))
+	if errors.Is(err, domain.ErrNotFound) {
+		return m.importPeer(ctx, iface, pp)
+	}
+	if err != nil {
+		return err
+	}
+	if existingPeer.InterfaceIdentifier != iface.Identifier {
+		return fmt.Errorf("peer belongs to interface %s: %w", existingPeer.InterfaceIdentifier, domain.ErrInvalidData)
+	}
+
+	return m.db.SavePeer(ctx, existingPeer.Identifier, func(p *domain.Peer) (*domain.Peer, error) {
+		now := time.Now()
+		p.Disabled = nil
+		p.DisabledReason = ""
+		domain.MergeFromPhysicalPeer(p, pp, iface.Addresses)
+		p.UpdatedBy = domain.CtxSystemWgImporter
+		p.UpdatedAt = now
+		return p, nil
+	})
+}
+
+// driftFingerprint returns a string representation of the drift items, used to detect changes between checks.
+func driftFingerprint(drift domain.InterfaceDrift) string {
+	items := make([]string, len(drift.Items))
+	for i, item := range drift.Items {
+		items[i] = fmt.Sprintf("%s|%s|%s|%s|%s", item.Type, item.PeerIdentifier, item.Field, item.Expected, item.Actual)
+	}
+	slices.Sort(items)
+	return strings.Join(items, ";")
+}
@@ -0,0 +1,87 @@
+package wireguard
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// checkExpiryReminders publishes a reminder for all peers that expire soon. Each entry of the reminder schedule
+// is only sent once per expiry date. If several entries are due at once, only the most urgent one is sent.
+func (m Manager) checkExpiryReminders(ctx context.Context, peers []domain.Peer) {
+	if len(m.cfg.Advanced.ExpiryReminders) == 0 {
+		return // expiry notifications are disabled
+	}
+
+	now := time.Now()
+
+	for _, peer := range peers {
+		if peer.ExpiresAt == nil || peer.IsDisabled() {
+			continue
+		}
+
+		days, due := domain.DueExpiryReminder(*peer.ExpiresAt, now, m.cfg.Advanced.ExpiryReminders)
+		if !due {
+			continue
+		}
+
+		sent, err := m.db.GetPeerExpiryNotifications(ctx, peer.Identifier, *peer.ExpiresAt)
+		if err != nil {
+			slog.Error("failed to load expiry notifications", "peer", peer.Identifier, "error", err)
+			continue
+		}
+		if domain.IsExpiryReminderSent(sent, days) {
+			continue
+		}
+
+		// record the reminder first, so that a failing delivery never results in duplicate reminders
+		err = m.db.SavePeerExpiryNotification(ctx, &domain.PeerExpiryNotification{
+			PeerId:     peer.Identifier,
+			ExpiresAt:  *peer.ExpiresAt,
+			Type:       domain.ExpiryNotificationReminder,
+			DaysBefore: days,
+			SentAt:     now,
+		})
+		if err != nil {
+			slog.Error("failed to record expiry reminder", "peer", peer.Identifier, "error", err)
+			continue
+		}
+
+		slog.Debug("peer expires soon, sending reminder", "peer", peer.Identifier, "days", days)
+		m.bus.Publish(app.TopicPeerExpiryReminder, peer, days)
+	}
+}
+
+// sendExpiredNotification publishes a notification for a peer that has been disabled because it expired.
+func (m Manager) sendExpiredNotification(ctx context.Context, peer domain.Peer) {
+	if len(m.cfg.Advanced.ExpiryReminders) == 0 || peer.ExpiresAt == nil {
+		return // expiry notifications are disabled
+	}
+
+	sent, err := m.db.GetPeerExpiryNotifications(ctx, peer.Identifier, *peer.ExpiresAt)
+	if err != nil {
+		slog.Error("failed to load expiry notifications", "peer", peer.Identifier, "error", err)
+		return
+	}
+	for _, notification := range sent {
+		if notification.Type == domain.ExpiryNotificationExpired {
+			return // the peer was re-enabled and expired again without a new expiry date
+		}
+	}
+
+	err = m.db.SavePeerExpiryNotification(ctx, &domain.PeerExpiryNotification{
+		PeerId:    peer.Identifier,
+		ExpiresAt: *peer.ExpiresAt,
+		Type:      domain.ExpiryNotificationExpired,
+		SentAt:    time.Now(),
+	})
+	if err != nil {
+		slog.Error("failed to record expiry notification", "peer", peer.Identifier, "error", err)
+		return
+	}
+
+	m.bus.Publish(app.TopicPeerExpired, peer)
+}
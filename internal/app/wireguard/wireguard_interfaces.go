@@ -26,6 +26,19 @@ func (m Manager) GetImportableInterfaces(ctx context.Context) ([]domain.Physical
 		return nil, err
 	}
 
+	fileInterfaces, err := m.files.GetInterfaceConfigs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config files: %w", err)
+	}
+
+	// add interfaces that are only available as configuration file
+	for _, fileInterface := range fileInterfaces {
+		if findPhysicalInterface(physicalInterfaces, fileInterface.Identifier) != nil {
+			continue
+		}
+		physicalInterfaces = append(physicalInterfaces, fileInterface.PhysicalInterface)
+	}
+
 	return physicalInterfaces, nil
 }
 
@@ -35,7 +48,7 @@ func (m Manager) GetInterfaceAndPeers(ctx context.Context, id domain.InterfaceId
 	[]domain.Peer,
 	error,
 ) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesRead, id); err != nil {
 		return nil, nil, err
 	}
 
@@ -43,17 +56,24 @@ func (m Manager) GetInterfaceAndPeers(ctx context.Context, id domain.InterfaceId
 }
 
 // GetAllInterfaces returns all interfaces that are available in the database.
+// Users without admin rights only receive the interfaces that they are permitted to read.
 func (m Manager) GetAllInterfaces(ctx context.Context) ([]domain.Interface, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesRead, ""); err != nil {
+		return nil, err
+	}
+
+	interfaces, err := m.db.GetAllInterfaces(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	return m.db.GetAllInterfaces(ctx)
+	return filterPermittedInterfaces(ctx, interfaces), nil
 }
 
 // GetAllInterfacesAndPeers returns all interfaces and their peers.
+// Users without admin rights only receive the interfaces that they are permitted to read.
 func (m Manager) GetAllInterfacesAndPeers(ctx context.Context) ([]domain.Interface, [][]domain.Peer, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesRead, ""); err != nil {
 		return nil, nil, err
 	}
 
@@ -61,6 +81,7 @@ func (m Manager) GetAllInterfacesAndPeers(ctx context.Context) ([]domain.Interfa
 	if err != nil {
 		return nil, nil, fmt.Errorf("unable to load all interfaces: %w", err)
 	}
+	interfaces = filterPermittedInterfaces(ctx, interfaces)
 
 	allPeers := make([][]domain.Peer, len(interfaces))
 	for i, iface := range interfaces {
@@ -104,6 +125,7 @@ func (m Manager) GetUserInterfaces(ctx context.Context, _ domain.UserIdentifier)
 }
 
 // ImportNewInterfaces imports all new physical interfaces that are available on the system.
+// If a wg-quick configuration file exists for an interface, the configuration file is used for the import.
 func (m Manager) ImportNewInterfaces(ctx context.Context, filter ...domain.InterfaceIdentifier) (int, error) {
 	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
 		return 0, err
@@ -114,6 +136,11 @@ func (m Manager) ImportNewInterfaces(ctx context.Context, filter ...domain.Inter
 		return 0, err
 	}
 
+	fileInterfaces, err := m.files.GetInterfaceConfigs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load config files: %w", err)
+	}
+
 	// if no filter is given, exclude already existing interfaces
 	var excludedInterfaces []domain.InterfaceIdentifier
 	if len(filter) == 0 {
@@ -127,6 +154,39 @@ func (m Manager) ImportNewInterfaces(ctx context.Context, filter ...domain.Inter
 	}
 
 	imported := 0
+	for _, fileInterface := range fileInterfaces {
+		if slices.Contains(excludedInterfaces, fileInterface.Identifier) {
+			continue
+		}
+
+		if len(filter) != 0 && !slices.Contains(filter, fileInterface.Identifier) {
+			continue
+		}
+
+		slog.Info("importing new interface from file",
+			"interface", fileInterface.Identifier, "file", fileInterface.FileName)
+
+		var physicalPeers []domain.PhysicalPeer
+		physicalInterface := findPhysicalInterface(physicalInterfaces, fileInterface.Identifier)
+		if physicalInterface != nil {
+			fileInterface.DeviceType = physicalInterface.DeviceType
+			physicalPeers, err = m.wg.GetPeers(ctx, fileInterface.Identifier)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		err = m.importConfigFileInterface(ctx, &fileInterface, physicalInterface != nil, physicalPeers)
+		if err != nil {
+			return 0, fmt.Errorf("import of %s failed: %w", fileInterface.Identifier, err)
+		}
+
+		slog.Info("imported new interface from file",
+			"interface", fileInterface.Identifier, "peers", len(fileInterface.Peers))
+		excludedInterfaces = append(excludedInterfaces, fileInterface.Identifier)
+		imported++
+	}
+
 	for _, physicalInterface := range physicalInterfaces {
 		if slices.Contains(excludedInterfaces, physicalInterface.Identifier) {
 			continue
@@ -155,9 +215,9 @@ func (m Manager) ImportNewInterfaces(ctx context.Context, filter ...domain.Inter
 	return imported, nil
 }
 
-// ApplyPeerDefaults applies the interface defaults to all peers of the given interface.
+// ApplyPeerDefaults applies the interface defaults and the tag policies to all peers of the given interface.
 func (m Manager) ApplyPeerDefaults(ctx context.Context, in *domain.Interface) error {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesWrite, in.Identifier); err != nil {
 		return err
 	}
 
@@ -175,8 +235,15 @@ func (m Manager) ApplyPeerDefaults(ctx context.Context, in *domain.Interface) er
 		return fmt.Errorf("failed to find peers for interface %s: %w", in.Identifier, err)
 	}
 
+	tags, err := m.db.GetPeerTags(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load tags: %w", err)
+	}
+
+	now := time.Now()
 	for i := range peers {
 		(&peers[i]).ApplyInterfaceDefaults(in)
+		(&peers[i]).ApplyTagPolicy(domain.MergeTagPolicies(tags, peers[i].Tags), now)
 
 		_, err := m.UpdatePeer(ctx, &peers[i])
 		if err != nil {
@@ -207,13 +274,20 @@ func (m Manager) RestoreInterfaceState(
 		if len(filter) != 0 && !slices.Contains(filter, iface.Identifier) {
 			continue // ignore filtered interface
 		}
+		if iface.IsRemote() && !m.agents.IsConnected(iface.AgentHost) {
+			slog.Debug("skipping interface of disconnected agent", "interface", iface.Identifier,
+				"host", iface.AgentHost)
+			continue // the state is restored once the agent connects
+		}
 
 		peers, err := m.db.GetInterfacePeers(ctx, iface.Identifier)
 		if err != nil {
 			return fmt.Errorf("failed to load peers for %s: %w", iface.Identifier, err)
 		}
 
-		_, err = m.wg.GetInterface(ctx, iface.Identifier)
+		wg := m.getController(&iface)
+
+		_, err = wg.GetInterface(ctx, iface.Identifier)
 		if err != nil && !iface.IsDisabled() {
 			slog.Debug("creating missing interface", "interface", iface.Identifier)
 
@@ -261,17 +335,17 @@ func (m Manager) RestoreInterfaceState(
 		for _, peer := range peers {
 			switch {
 			case iface.IsDisabled(): // if interface is disabled, delete all peers
-				if err := m.wg.DeletePeer(ctx, iface.Identifier, peer.Identifier); err != nil {
+				if err := wg.DeletePeer(ctx, iface.Identifier, peer.Identifier); err != nil {
 					return fmt.Errorf("failed to remove peer %s for disabled interface %s: %w",
 						peer.Identifier, iface.Identifier, err)
 				}
 			case peer.IsDisabled(): // if peer is disabled, delete it
-				if err := m.wg.DeletePeer(ctx, iface.Identifier, peer.Identifier); err != nil {
+				if err := wg.DeletePeer(ctx, iface.Identifier, peer.Identifier); err != nil {
 					return fmt.Errorf("failed to remove disbaled peer %s from interface %s: %w",
 						peer.Identifier, iface.Identifier, err)
 				}
 			default: // update peer
-				err := m.wg.SavePeer(ctx, iface.Identifier, peer.Identifier,
+				err := wg.SavePeer(ctx, iface.Identifier, peer.Identifier,
 					func(pp *domain.PhysicalPeer) (*domain.PhysicalPeer, error) {
 						domain.MergeToPhysicalPeer(pp, &peer)
 						return pp, nil
@@ -284,7 +358,7 @@ func (m Manager) RestoreInterfaceState(
 		}
 
 		// remove non-wgportal peers
-		physicalPeers, _ := m.wg.GetPeers(ctx, iface.Identifier)
+		physicalPeers, _ := wg.GetPeers(ctx, iface.Identifier)
 		for _, physicalPeer := range physicalPeers {
 			isWgPortalPeer := false
 			for _, peer := range peers {
@@ -294,7 +368,7 @@ func (m Manager) RestoreInterfaceState(
 				}
 			}
 			if !isWgPortalPeer {
-				err := m.wg.DeletePeer(ctx, iface.Identifier, domain.PeerIdentifier(physicalPeer.PublicKey))
+				err := wg.DeletePeer(ctx, iface.Identifier, domain.PeerIdentifier(physicalPeer.PublicKey))
 				if err != nil {
 					return fmt.Errorf("failed to remove non-wgportal peer %s from interface %s: %w",
 						physicalPeer.PublicKey, iface.Identifier, err)
@@ -366,7 +440,7 @@ func (m Manager) PrepareInterface(ctx context.Context) (*domain.Interface, error
 		SaveConfig:                 m.cfg.Advanced.ConfigStoragePath != "",
 		DisplayName:                string(id),
 		Type:                       domain.InterfaceTypeServer,
-		DriverType:                 "",
+		DriverType:                 domain.InterfaceDriverTypeLinux,
 		Disabled:                   nil,
 		DisabledReason:             "",
 		PeerDefNetworkStr:          domain.CidrsToString(networks),
@@ -417,7 +491,7 @@ func (m Manager) CreateInterface(ctx context.Context, in *domain.Interface) (*do
 
 // UpdateInterface updates the given interface with the new configuration.
 func (m Manager) UpdateInterface(ctx context.Context, in *domain.Interface) (*domain.Interface, []domain.Peer, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesWrite, in.Identifier); err != nil {
 		return nil, nil, err
 	}
 
@@ -435,6 +509,10 @@ func (m Manager) UpdateInterface(ctx context.Context, in *domain.Interface) (*do
 		return nil, nil, fmt.Errorf("update failure: %w", err)
 	}
 
+	if err := m.refreshInterfaceSites(ctx, in.Identifier); err != nil {
+		return nil, nil, fmt.Errorf("failed to update site networks: %w", err)
+	}
+
 	m.bus.Publish(app.TopicInterfaceUpdated, *in)
 
 	return in, existingPeers, nil
@@ -442,7 +520,7 @@ func (m Manager) UpdateInterface(ctx context.Context, in *domain.Interface) (*do
 
 // DeleteInterface deletes the given interface.
 func (m Manager) DeleteInterface(ctx context.Context, id domain.InterfaceIdentifier) error {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesWrite, id); err != nil {
 		return err
 	}
 
@@ -459,7 +537,7 @@ func (m Manager) DeleteInterface(ctx context.Context, id domain.InterfaceIdentif
 	existingInterface.Disabled = &now // simulate a disabled interface
 	existingInterface.DisabledReason = domain.DisabledReasonDeleted
 
-	physicalInterface, _ := m.wg.GetInterface(ctx, id)
+	physicalInterface, _ := m.getController(existingInterface).GetInterface(ctx, id)
 
 	if err := m.handleInterfacePreSaveHooks(existingInterface, !existingInterface.IsDisabled(), false); err != nil {
 		return fmt.Errorf("pre-delete hooks failed: %w", err)
@@ -469,11 +547,11 @@ func (m Manager) DeleteInterface(ctx context.Context, id domain.InterfaceIdentif
 		return fmt.Errorf("pre-delete actions failed: %w", err)
 	}
 
-	if err := m.deleteInterfacePeers(ctx, id); err != nil {
+	if err := m.deleteInterfacePeers(ctx, existingInterface); err != nil {
 		return fmt.Errorf("peer deletion failure: %w", err)
 	}
 
-	if err := m.wg.DeleteInterface(ctx, id); err != nil {
+	if err := m.getController(existingInterface).DeleteInterface(ctx, id); err != nil {
 		return fmt.Errorf("wireguard deletion failure: %w", err)
 	}
 
@@ -481,14 +559,16 @@ func (m Manager) DeleteInterface(ctx context.Context, id domain.InterfaceIdentif
 		return fmt.Errorf("deletion failure: %w", err)
 	}
 
-	fwMark := existingInterface.FirewallMark
-	if physicalInterface != nil && fwMark == 0 {
-		fwMark = physicalInterface.FirewallMark
+	if !existingInterface.IsRemote() {
+		fwMark := existingInterface.FirewallMark
+		if physicalInterface != nil && fwMark == 0 {
+			fwMark = physicalInterface.FirewallMark
+		}
+		m.bus.Publish(app.TopicRouteRemove, domain.RoutingTableInfo{
+			FwMark: fwMark,
+			Table:  existingInterface.GetRoutingTable(),
+		})
 	}
-	m.bus.Publish(app.TopicRouteRemove, domain.RoutingTableInfo{
-		FwMark: fwMark,
-		Table:  existingInterface.GetRoutingTable(),
-	})
 
 	if err := m.handleInterfacePostSaveHooks(existingInterface, !existingInterface.IsDisabled(), false); err != nil {
 		return fmt.Errorf("post-delete hooks failed: %w", err)
@@ -522,7 +602,7 @@ func (m Manager) saveInterface(ctx context.Context, iface *domain.Interface) (
 	err := m.db.SaveInterface(ctx, iface.Identifier, func(i *domain.Interface) (*domain.Interface, error) {
 		iface.CopyCalculatedAttributes(i)
 
-		err := m.wg.SaveInterface(ctx, iface.Identifier,
+		err := m.getController(iface).SaveInterface(ctx, iface.Identifier,
 			func(pi *domain.PhysicalInterface) (*domain.PhysicalInterface, error) {
 				domain.MergeToPhysicalInterface(pi, iface)
 				return pi, nil
@@ -537,8 +617,11 @@ func (m Manager) saveInterface(ctx context.Context, iface *domain.Interface) (
 		return nil, fmt.Errorf("failed to save interface: %w", err)
 	}
 
-	if iface.IsDisabled() {
-		physicalInterface, _ := m.wg.GetInterface(ctx, iface.Identifier)
+	switch {
+	case iface.IsRemote():
+		// routes of remote interfaces are not managed by the portal
+	case iface.IsDisabled():
+		physicalInterface, _ := m.getController(iface).GetInterface(ctx, iface.Identifier)
 		fwMark := iface.FirewallMark
 		if physicalInterface != nil && fwMark == 0 {
 			fwMark = physicalInterface.FirewallMark
@@ -547,7 +630,7 @@ func (m Manager) saveInterface(ctx context.Context, iface *domain.Interface) (
 			FwMark: fwMark,
 			Table:  iface.GetRoutingTable(),
 		})
-	} else {
+	default:
 		m.bus.Publish(app.TopicRouteUpdate, "interface updated: "+string(iface.Identifier))
 	}
 
@@ -566,6 +649,38 @@ func (m Manager) saveInterface(ctx context.Context, iface *domain.Interface) (
 	return iface, nil
 }
 
+// getController returns the interface controller that is responsible for the given interface.
+// Interfaces of remote hosts are managed by the agent, local interfaces by the controller of the driver type.
+func (m Manager) getController(iface *domain.Interface) InterfaceController {
+	if iface.IsRemote() {
+		return m.agents.InterfaceController(iface.AgentHost, iface.IsSoftwareDriver())
+	}
+	if iface.IsSoftwareDriver() {
+		return m.wgSoftware
+	}
+	return m.wg
+}
+
+// getQuickController returns the wg-quick controller that is responsible for the given interface.
+func (m Manager) getQuickController(iface *domain.Interface) WgQuickController {
+	if iface.IsRemote() {
+		return m.agents.WgQuickController(iface.AgentHost)
+	}
+	return m.quick
+}
+
+// getInterfaceController loads the interface with the given id and returns the responsible interface controller.
+func (m Manager) getInterfaceController(ctx context.Context, id domain.InterfaceIdentifier) (
+	InterfaceController,
+	error,
+) {
+	iface, err := m.db.GetInterface(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find interface %s: %w", id, err)
+	}
+	return m.getController(iface), nil
+}
+
 func (m Manager) getInterfaceStateHistory(ctx context.Context, iface *domain.Interface) (oldEnabled, newEnabled bool) {
 	oldInterface, err := m.db.GetInterface(ctx, iface.Identifier)
 	if err != nil {
@@ -577,11 +692,11 @@ func (m Manager) getInterfaceStateHistory(ctx context.Context, iface *domain.Int
 
 func (m Manager) handleInterfacePreSaveActions(iface *domain.Interface) error {
 	if !iface.IsDisabled() {
-		if err := m.quick.SetDNS(iface.Identifier, iface.DnsStr, iface.DnsSearchStr); err != nil {
+		if err := m.getQuickController(iface).SetDNS(iface.Identifier, iface.DnsStr, iface.DnsSearchStr); err != nil {
 			return fmt.Errorf("failed to update dns settings: %w", err)
 		}
 	} else {
-		if err := m.quick.UnsetDNS(iface.Identifier); err != nil {
+		if err := m.getQuickController(iface).UnsetDNS(iface.Identifier); err != nil {
 			return fmt.Errorf("failed to clear dns settings: %w", err)
 		}
 	}
@@ -596,11 +711,11 @@ func (m Manager) handleInterfacePreSaveHooks(iface *domain.Interface, oldEnabled
 	slog.Debug("executing pre-save hooks", "interface", iface.Identifier, "up", newEnabled)
 
 	if newEnabled {
-		if err := m.quick.ExecuteInterfaceHook(iface.Identifier, iface.PreUp); err != nil {
+		if err := m.getQuickController(iface).ExecuteInterfaceHook(iface.Identifier, iface.PreUp); err != nil {
 			return fmt.Errorf("failed to execute pre-up hook: %w", err)
 		}
 	} else {
-		if err := m.quick.ExecuteInterfaceHook(iface.Identifier, iface.PreDown); err != nil {
+		if err := m.getQuickController(iface).ExecuteInterfaceHook(iface.Identifier, iface.PreDown); err != nil {
 			return fmt.Errorf("failed to execute pre-down hook: %w", err)
 		}
 	}
@@ -615,11 +730,11 @@ func (m Manager) handleInterfacePostSaveHooks(iface *domain.Interface, oldEnable
 	slog.Debug("executing post-save hooks", "interface", iface.Identifier, "up", newEnabled)
 
 	if newEnabled {
-		if err := m.quick.ExecuteInterfaceHook(iface.Identifier, iface.PostUp); err != nil {
+		if err := m.getQuickController(iface).ExecuteInterfaceHook(iface.Identifier, iface.PostUp); err != nil {
 			return fmt.Errorf("failed to execute post-up hook: %w", err)
 		}
 	} else {
-		if err := m.quick.ExecuteInterfaceHook(iface.Identifier, iface.PostDown); err != nil {
+		if err := m.getQuickController(iface).ExecuteInterfaceHook(iface.Identifier, iface.PostDown); err != nil {
 			return fmt.Errorf("failed to execute post-down hook: %w", err)
 		}
 	}
@@ -828,13 +943,154 @@ func (m Manager) importPeer(ctx context.Context, in *domain.Interface, p *domain
 	return nil
 }
 
-func (m Manager) deleteInterfacePeers(ctx context.Context, id domain.InterfaceIdentifier) error {
-	allPeers, err := m.db.GetInterfacePeers(ctx, id)
+// importConfigFileInterface imports an interface from a wg-quick configuration file.
+// If the interface is not active on the system, it is imported in disabled state.
+// Peers that are only configured on the physical interface are imported as well.
+func (m Manager) importConfigFileInterface(
+	ctx context.Context,
+	in *domain.ConfigFileInterface,
+	active bool,
+	physicalPeers []domain.PhysicalPeer,
+) error {
+	now := time.Now()
+	iface := domain.ConvertConfigFileInterface(in)
+	iface.BaseModel = domain.BaseModel{
+		CreatedBy: domain.CtxSystemWgImporter,
+		UpdatedBy: domain.CtxSystemWgImporter,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if in.CreatedAt != nil {
+		iface.CreatedAt = *in.CreatedAt
+	}
+	if in.UpdatedAt != nil {
+		iface.UpdatedAt = *in.UpdatedAt
+	}
+	if !active {
+		iface.Disabled = &now
+		iface.DisabledReason = domain.DisabledReasonInterfaceMissing
+	}
+
+	existingInterface, err := m.db.GetInterface(ctx, iface.Identifier)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+	if existingInterface != nil {
+		return errors.New("interface already exists")
+	}
+
+	err = m.db.SaveInterface(ctx, iface.Identifier, func(_ *domain.Interface) (*domain.Interface, error) {
+		return iface, nil
+	})
+	if err != nil {
+		return fmt.Errorf("database save failed: %w", err)
+	}
+
+	// import peers
+	filePeerKeys := make([]string, 0, len(in.Peers))
+	for _, peer := range in.Peers {
+		err = m.importConfigFilePeer(ctx, iface, &peer)
+		if err != nil {
+			return fmt.Errorf("import of peer %s failed: %w", peer.Identifier, err)
+		}
+		filePeerKeys = append(filePeerKeys, peer.PublicKey)
+	}
+
+	for _, peer := range physicalPeers {
+		if slices.Contains(filePeerKeys, peer.PublicKey) {
+			continue // already imported from file
+		}
+		err = m.importPeer(ctx, iface, &peer)
+		if err != nil {
+			return fmt.Errorf("import of peer %s failed: %w", peer.Identifier, err)
+		}
+	}
+
+	return nil
+}
+
+func (m Manager) importConfigFilePeer(ctx context.Context, in *domain.Interface, p *domain.ConfigFilePeer) error {
+	now := time.Now()
+	peer := domain.ConvertPhysicalPeer(&p.PhysicalPeer)
+	peer.BaseModel = domain.BaseModel{
+		CreatedBy: domain.CtxSystemWgImporter,
+		UpdatedBy: domain.CtxSystemWgImporter,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if p.CreatedAt != nil {
+		peer.CreatedAt = *p.CreatedAt
+	}
+	if p.UpdatedAt != nil {
+		peer.UpdatedAt = *p.UpdatedAt
+	}
+
+	peer.InterfaceIdentifier = in.Identifier
+	peer.EndpointPublicKey = domain.NewConfigOption(in.PublicKey, true)
+	peer.AllowedIPsStr = domain.NewConfigOption(in.PeerDefAllowedIPsStr, true)
+	peer.Interface.DnsStr = domain.NewConfigOption(in.PeerDefDnsStr, true)
+	peer.Interface.DnsSearchStr = domain.NewConfigOption(in.PeerDefDnsSearchStr, true)
+	peer.Interface.Mtu = domain.NewConfigOption(in.PeerDefMtu, true)
+	peer.Interface.FirewallMark = domain.NewConfigOption(in.PeerDefFirewallMark, true)
+	peer.Interface.RoutingTable = domain.NewConfigOption(in.PeerDefRoutingTable, true)
+	peer.Interface.PreUp = domain.NewConfigOption(in.PeerDefPreUp, true)
+	peer.Interface.PostUp = domain.NewConfigOption(in.PeerDefPostUp, true)
+	peer.Interface.PreDown = domain.NewConfigOption(in.PeerDefPreDown, true)
+	peer.Interface.PostDown = domain.NewConfigOption(in.PeerDefPostDown, true)
+
+	switch in.Type {
+	case domain.InterfaceTypeAny:
+		peer.Interface.Type = domain.InterfaceTypeAny
+		peer.DisplayName = "Imported Peer (" + peer.Interface.PublicKey[0:8] + ")"
+	case domain.InterfaceTypeClient:
+		peer.Interface.Type = domain.InterfaceTypeServer
+		peer.DisplayName = "Imported Endpoint (" + peer.Interface.PublicKey[0:8] + ")"
+	case domain.InterfaceTypeServer:
+		peer.Interface.Type = domain.InterfaceTypeClient
+		peer.DisplayName = "Imported Client (" + peer.Interface.PublicKey[0:8] + ")"
+	}
+	if p.DisplayName != "" {
+		peer.DisplayName = p.DisplayName
+	}
+
+	if in.Type == domain.InterfaceTypeClient {
+		// the allowed IPs of an endpoint are the networks routed through the tunnel
+		peer.AllowedIPsStr = domain.NewConfigOption(domain.CidrsToString(p.AllowedIPs), true)
+	} else {
+		addresses, extraAllowedIPs := p.SplitAllowedIPs(in.Addresses)
+		peer.Interface.Addresses = addresses
+		peer.ExtraAllowedIPsStr = domain.CidrsToString(extraAllowedIPs)
+	}
+
+	err := m.db.SavePeer(ctx, peer.Identifier, func(_ *domain.Peer) (*domain.Peer, error) {
+		return peer, nil
+	})
+	if err != nil {
+		return fmt.Errorf("database save failed: %w", err)
+	}
+
+	return nil
+}
+
+func findPhysicalInterface(
+	interfaces []domain.PhysicalInterface,
+	id domain.InterfaceIdentifier,
+) *domain.PhysicalInterface {
+	for i := range interfaces {
+		if interfaces[i].Identifier == id {
+			return &interfaces[i]
+		}
+	}
+	return nil
+}
+
+func (m Manager) deleteInterfacePeers(ctx context.Context, iface *domain.Interface) error {
+	allPeers, err := m.db.GetInterfacePeers(ctx, iface.Identifier)
 	if err != nil {
 		return err
 	}
 	for _, peer := range allPeers {
-		err = m.wg.DeletePeer(ctx, id, peer.Identifier)
+		err = m.getController(iface).DeletePeer(ctx, iface.Identifier, peer.Identifier)
 		if err != nil && !errors.Is(err, os.ErrNotExist) {
 			return fmt.Errorf("wireguard peer deletion failure for %s: %w", peer.Identifier, err)
 		}
@@ -848,13 +1104,41 @@ func (m Manager) deleteInterfacePeers(ctx context.Context, id domain.InterfaceId
 	return nil
 }
 
-func (m Manager) validateInterfaceModifications(ctx context.Context, _, _ *domain.Interface) error {
+func (m Manager) validateInterfaceModifications(ctx context.Context, old, new *domain.Interface) error {
 	currentUser := domain.GetUserInfo(ctx)
 
-	if !currentUser.IsAdmin {
+	if !currentUser.HasPermission(domain.PermissionInterfacesWrite, old.Identifier) {
 		return fmt.Errorf("insufficient permissions")
 	}
 
+	if err := domain.ValidateManagedAccess(ctx, old.ManagedBy); err != nil {
+		return err
+	}
+
+	if err := domain.ValidateManagedAccess(ctx, new.ManagedBy); err != nil {
+		return err
+	}
+
+	if old.IsSoftwareDriver() != new.IsSoftwareDriver() {
+		return fmt.Errorf("driver type can not be changed: %w", domain.ErrInvalidData)
+	}
+
+	if old.AgentHost != new.AgentHost {
+		return fmt.Errorf("agent host can not be changed: %w", domain.ErrInvalidData)
+	}
+
+	if err := new.PeerDefQuota.Validate(); err != nil {
+		return err
+	}
+
+	if err := new.PeerRenewalPolicy.Validate(); err != nil {
+		return err
+	}
+
+	if err := new.PeerDefIpv6.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -869,6 +1153,26 @@ func (m Manager) validateInterfaceCreation(ctx context.Context, _, new *domain.I
 		return fmt.Errorf("insufficient permissions")
 	}
 
+	if err := domain.ValidateManagedAccess(ctx, new.ManagedBy); err != nil {
+		return err
+	}
+
+	if new.IsRemote() && !m.agents.IsKnownHost(new.AgentHost) {
+		return fmt.Errorf("unknown agent host %s: %w", new.AgentHost, domain.ErrInvalidData)
+	}
+
+	if err := new.PeerDefQuota.Validate(); err != nil {
+		return err
+	}
+
+	if err := new.PeerRenewalPolicy.Validate(); err != nil {
+		return err
+	}
+
+	if err := new.PeerDefIpv6.Validate(); err != nil {
+		return err
+	}
+
 	// validate public key if it is set
 	if new.PublicKey != "" && new.PrivateKey != "" {
 		if domain.PublicKeyFromPrivateKey(new.PrivateKey) != new.PublicKey {
@@ -879,14 +1183,27 @@ func (m Manager) validateInterfaceCreation(ctx context.Context, _, new *domain.I
 	return nil
 }
 
-func (m Manager) validateInterfaceDeletion(ctx context.Context, _ *domain.Interface) error {
+func (m Manager) validateInterfaceDeletion(ctx context.Context, del *domain.Interface) error {
 	currentUser := domain.GetUserInfo(ctx)
 
-	if !currentUser.IsAdmin {
+	if !currentUser.HasPermission(domain.PermissionInterfacesWrite, del.Identifier) {
 		return fmt.Errorf("insufficient permissions")
 	}
 
+	if err := domain.ValidateManagedAccess(ctx, del.ManagedBy); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// filterPermittedInterfaces removes all interfaces that the current user is not permitted to read.
+func filterPermittedInterfaces(ctx context.Context, interfaces []domain.Interface) []domain.Interface {
+	currentUser := domain.GetUserInfo(ctx)
+
+	return slices.DeleteFunc(interfaces, func(iface domain.Interface) bool {
+		return !currentUser.HasPermission(domain.PermissionInterfacesRead, iface.Identifier)
+	})
+}
+
 // endregion helper-functions
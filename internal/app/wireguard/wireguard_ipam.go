@@ -0,0 +1,367 @@
+package wireguard
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// GetIpRanges returns all address pools and exclusion ranges of the given interface.
+func (m Manager) GetIpRanges(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.IpRange, error) {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesRead, id); err != nil {
+		return nil, err
+	}
+
+	return m.db.GetIpRanges(ctx, id)
+}
+
+// SaveIpRange creates or updates an address pool or exclusion range. If the range has no identifier,
+// a new range is created.
+func (m Manager) SaveIpRange(ctx context.Context, ipRange *domain.IpRange) (*domain.IpRange, error) {
+	err := domain.ValidatePermission(ctx, domain.PermissionInterfacesWrite, ipRange.InterfaceIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ipRange.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := m.validateIpamAddresses(ctx, ipRange.InterfaceIdentifier, ipRange.Start, ipRange.End); err != nil {
+		return nil, err
+	}
+
+	isNew := ipRange.Identifier == ""
+	if isNew {
+		ipRange.Identifier = domain.IpRangeIdentifier(uuid.New().String())
+	}
+	if ipRange.Strategy == "" {
+		ipRange.Strategy = domain.IpAllocationSequential
+	}
+
+	currentUser := domain.GetUserInfo(ctx)
+	err = m.db.SaveIpRange(ctx, ipRange.Identifier, func(r *domain.IpRange) (*domain.IpRange, error) {
+		if !isNew && r.InterfaceIdentifier != ipRange.InterfaceIdentifier {
+			return nil, fmt.Errorf("ip range %s: %w", ipRange.Identifier, domain.ErrNotFound)
+		}
+
+		ipRange.BaseModel = r.BaseModel
+		if ipRange.CreatedAt.IsZero() {
+			ipRange.CreatedBy = string(currentUser.Id)
+			ipRange.CreatedAt = time.Now()
+		}
+		ipRange.UpdatedBy = string(currentUser.Id)
+		ipRange.UpdatedAt = time.Now()
+
+		return ipRange, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save ip range %s: %w", ipRange.Identifier, err)
+	}
+
+	return ipRange, nil
+}
+
+// DeleteIpRange deletes the address pool or exclusion range with the given identifier.
+func (m Manager) DeleteIpRange(
+	ctx context.Context,
+	id domain.InterfaceIdentifier,
+	rangeId domain.IpRangeIdentifier,
+) error {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesWrite, id); err != nil {
+		return err
+	}
+
+	return m.db.DeleteIpRange(ctx, id, rangeId)
+}
+
+// GetIpReservations returns all address reservations of the given interface.
+func (m Manager) GetIpReservations(ctx context.Context, id domain.InterfaceIdentifier) (
+	[]domain.IpReservation,
+	error,
+) {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesRead, id); err != nil {
+		return nil, err
+	}
+
+	return m.db.GetIpReservations(ctx, id)
+}
+
+// SaveIpReservation creates or updates the reservation of a single address.
+// An address that is already assigned to a peer can only be reserved for this peer or its user.
+func (m Manager) SaveIpReservation(ctx context.Context, reservation *domain.IpReservation) (
+	*domain.IpReservation,
+	error,
+) {
+	err := domain.ValidatePermission(ctx, domain.PermissionInterfacesWrite, reservation.InterfaceIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := reservation.Validate(); err != nil {
+		return nil, err
+	}
+
+	addr := netip.MustParseAddr(reservation.Address).Unmap()
+	reservation.Address = addr.String()
+
+	if err := m.validateIpamAddresses(ctx, reservation.InterfaceIdentifier, reservation.Address); err != nil {
+		return nil, err
+	}
+
+	peers, err := m.db.GetInterfacePeers(ctx, reservation.InterfaceIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load peers: %w", err)
+	}
+	for _, peer := range peers {
+		inUse := slices.ContainsFunc(peer.Interface.Addresses, func(c domain.Cidr) bool {
+			return c.Addr == reservation.Address
+		})
+		if inUse && !reservation.IsFor(peer.UserIdentifier, peer.Identifier) {
+			return nil, fmt.Errorf("address %s is assigned to peer %s: %w", addr, peer.Identifier,
+				domain.ErrDuplicateEntry)
+		}
+	}
+
+	currentUser := domain.GetUserInfo(ctx)
+	existing, err := m.db.GetIpReservations(ctx, reservation.InterfaceIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reservations: %w", err)
+	}
+	if idx := slices.IndexFunc(existing, func(r domain.IpReservation) bool {
+		return r.Address == reservation.Address
+	}); idx >= 0 {
+		reservation.BaseModel = existing[idx].BaseModel
+	} else {
+		reservation.CreatedBy = string(currentUser.Id)
+		reservation.CreatedAt = time.Now()
+	}
+	reservation.UpdatedBy = string(currentUser.Id)
+	reservation.UpdatedAt = time.Now()
+
+	if err := m.db.SaveIpReservation(ctx, reservation); err != nil {
+		return nil, fmt.Errorf("failed to save reservation of %s: %w", reservation.Address, err)
+	}
+
+	return reservation, nil
+}
+
+// DeleteIpReservation deletes the reservation of the given address.
+func (m Manager) DeleteIpReservation(ctx context.Context, id domain.InterfaceIdentifier, address string) error {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesWrite, id); err != nil {
+		return err
+	}
+
+	return m.db.DeleteIpReservation(ctx, id, address)
+}
+
+// GetIpUtilization returns the usage of all address pools of the given interface.
+func (m Manager) GetIpUtilization(ctx context.Context, id domain.InterfaceIdentifier) (
+	[]domain.IpPoolUtilization,
+	error,
+) {
+	if err := domain.ValidatePermission(ctx, domain.PermissionInterfacesRead, id); err != nil {
+		return nil, err
+	}
+
+	iface, err := m.db.GetInterface(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find interface %s: %w", id, err)
+	}
+
+	return getIpUtilization(ctx, m.db, iface)
+}
+
+// getFreshPeerIpConfig allocates one address per peer network of the interface for a new peer of the given user.
+// The peer identifier is the public key of the new peer, it is used for hash based allocations.
+// The pending addresses belong to new peers that are not stored yet, they are treated as used.
+func (m Manager) getFreshPeerIpConfig(
+	ctx context.Context,
+	iface *domain.Interface,
+	userId domain.UserIdentifier,
+	peerId domain.PeerIdentifier,
+	pending ...domain.Cidr,
+) ([]domain.Cidr, error) {
+	if iface.PeerDefNetworkStr == "" {
+		return []domain.Cidr{}, nil // cannot suggest new ip addresses if there is no subnet
+	}
+
+	networks, err := domain.CidrsFromString(iface.PeerDefNetworkStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse default network address: %w", err)
+	}
+
+	allocator, err := newIpAllocator(ctx, m.db, iface, networks, pending...)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]domain.Cidr, 0, len(networks))
+	for _, network := range networks {
+		ip, err := allocator.Allocate(network, userId, peerId)
+		if err != nil {
+			return nil, err
+		}
+		ips = append(ips, ip)
+	}
+
+	return ips, nil
+}
+
+// getFreshPeerPrefixes returns the prefix that is delegated to the new peer with the given public key.
+// If the interface does not delegate prefixes, an empty list is returned.
+// The pending prefixes belong to new peers that are not stored yet, they are treated as taken.
+func (m Manager) getFreshPeerPrefixes(
+	ctx context.Context,
+	iface *domain.Interface,
+	peerId domain.PeerIdentifier,
+	pending ...domain.Cidr,
+) ([]domain.Cidr, error) {
+	if iface.PeerDefIpv6.DelegatedPrefix == "" {
+		return []domain.Cidr{}, nil
+	}
+
+	delegation, err := domain.CidrFromString(iface.PeerDefIpv6.DelegatedPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse delegated prefix: %w", err)
+	}
+
+	peers, err := m.db.GetInterfacePeers(ctx, iface.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load peers: %w", err)
+	}
+
+	taken := slices.Clone(pending)
+	for _, peer := range peers {
+		if peer.Identifier == peerId {
+			continue
+		}
+		extraAllowedIPs, _ := domain.CidrsFromString(peer.ExtraAllowedIPsStr)
+		taken = append(taken, extraAllowedIPs...)
+	}
+
+	prefix, err := domain.DelegatePrefix(delegation, iface.PeerDefIpv6.DelegatedPrefixLength, peerId, taken)
+	if err != nil {
+		return nil, err
+	}
+
+	return []domain.Cidr{prefix}, nil
+}
+
+// validateReservedAddresses checks that the peer does not use an address that is reserved for someone else.
+func (m Manager) validateReservedAddresses(ctx context.Context, peer *domain.Peer) error {
+	reservations, err := m.db.GetIpReservations(ctx, peer.InterfaceIdentifier)
+	if err != nil {
+		return fmt.Errorf("failed to load reservations: %w", err)
+	}
+
+	return domain.ValidateReservedAddresses(reservations, peer.Interface.Addresses, peer.UserIdentifier,
+		peer.Identifier)
+}
+
+// validateIpamAddresses checks that all given addresses belong to the same peer network of the interface.
+// If the interface has no peer network, any address is accepted.
+func (m Manager) validateIpamAddresses(ctx context.Context, id domain.InterfaceIdentifier, addrs ...string) error {
+	iface, err := m.db.GetInterface(ctx, id)
+	if err != nil {
+		return fmt.Errorf("invalid interface: %w", domain.ErrInvalidData)
+	}
+	if iface.PeerDefNetworkStr == "" {
+		return nil
+	}
+
+	networks, err := domain.CidrsFromString(iface.PeerDefNetworkStr)
+	if err != nil {
+		return fmt.Errorf("failed to parse default network address: %w", err)
+	}
+
+	for _, network := range networks {
+		prefix := network.Prefix().Masked()
+		if !slices.ContainsFunc(addrs, func(a string) bool {
+			addr, err := netip.ParseAddr(strings.TrimSpace(a))
+			return err != nil || !prefix.Contains(addr.Unmap())
+		}) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("addresses must be part of one peer network (%s): %w", iface.PeerDefNetworkStr,
+		domain.ErrInvalidData)
+}
+
+// ipamDatabaseRepo provides the data that is required to allocate addresses.
+type ipamDatabaseRepo interface {
+	GetUsedIpsPerSubnet(ctx context.Context, subnets []domain.Cidr) (map[domain.Cidr][]domain.Cidr, error)
+	GetIpRanges(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.IpRange, error)
+	GetIpReservations(ctx context.Context, id domain.InterfaceIdentifier) ([]domain.IpReservation, error)
+}
+
+// getIpUtilization calculates the usage of all address pools of the given interface.
+func getIpUtilization(
+	ctx context.Context,
+	db ipamDatabaseRepo,
+	iface *domain.Interface,
+) ([]domain.IpPoolUtilization, error) {
+	if iface.PeerDefNetworkStr == "" {
+		return []domain.IpPoolUtilization{}, nil
+	}
+
+	networks, err := domain.CidrsFromString(iface.PeerDefNetworkStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse default network address: %w", err)
+	}
+
+	allocator, err := newIpAllocator(ctx, db, iface, networks)
+	if err != nil {
+		return nil, err
+	}
+
+	var utilization []domain.IpPoolUtilization
+	for _, network := range networks {
+		utilization = append(utilization, allocator.Utilization(network)...)
+	}
+
+	return utilization, nil
+}
+
+func newIpAllocator(
+	ctx context.Context,
+	db ipamDatabaseRepo,
+	iface *domain.Interface,
+	networks []domain.Cidr,
+	pending ...domain.Cidr,
+) (*domain.IpAllocator, error) {
+	ranges, err := db.GetIpRanges(ctx, iface.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ip ranges: %w", err)
+	}
+
+	reservations, err := db.GetIpReservations(ctx, iface.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reservations: %w", err)
+	}
+
+	existingIps, err := db.GetUsedIpsPerSubnet(ctx, networks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing IP addresses: %w", err)
+	}
+
+	used := slices.Clone(pending)
+	for _, network := range networks {
+		used = append(used, existingIps[network]...)
+	}
+
+	allocator := domain.NewIpAllocator(ranges, reservations, used)
+	if iface.PeerDefIpv6.HashedAddresses {
+		allocator.SetIpv6Strategy(domain.IpAllocationHash)
+	}
+
+	return allocator, nil
+}
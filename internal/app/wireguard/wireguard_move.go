@@ -0,0 +1,157 @@
+package wireguard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/app/audit"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// MovePeer moves the peer with the given identifier to the target interface. The keypair of the peer is kept,
+// new addresses are allocated from the peer networks of the target interface and the peer defaults of the target
+// interface are applied. The peer is removed from the source device and added to the target device in one step,
+// if the target device rejects the peer, the source device and the database stay unchanged.
+// The peers of both interfaces must be writable by the current user.
+func (m Manager) MovePeer(
+	ctx context.Context,
+	id domain.PeerIdentifier,
+	target domain.InterfaceIdentifier,
+) (*domain.Peer, error) {
+	if err := domain.ValidatePermission(ctx, domain.PermissionPeersWrite, target); err != nil {
+		return nil, err
+	}
+
+	return m.movePeer(ctx, id, target, true)
+}
+
+// movePeer moves the peer to the target interface. The audit event is only published if audited is set,
+// bulk actions record a single audit entry instead.
+func (m Manager) movePeer(
+	ctx context.Context,
+	id domain.PeerIdentifier,
+	target domain.InterfaceIdentifier,
+	audited bool,
+) (*domain.Peer, error) {
+	peer, err := m.db.GetPeer(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find peer %s: %w", id, err)
+	}
+
+	if err := domain.ValidatePermission(ctx, domain.PermissionPeersWrite, peer.InterfaceIdentifier); err != nil {
+		return nil, err
+	}
+
+	if err := domain.ValidateManagedAccess(ctx, peer.ManagedBy); err != nil {
+		return nil, err
+	}
+
+	if peer.InterfaceIdentifier == target {
+		return nil, fmt.Errorf("peer %s already belongs to interface %s: %w", id, target, domain.ErrInvalidData)
+	}
+
+	source, err := m.db.GetInterface(ctx, peer.InterfaceIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load interface %s: %w", peer.InterfaceIdentifier, err)
+	}
+
+	destination, err := m.db.GetInterface(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load interface %s: %w", target, err)
+	}
+
+	if source.Type != destination.Type {
+		return nil, fmt.Errorf("peers can only be moved between interfaces of the same type: %w",
+			domain.ErrInvalidData)
+	}
+
+	ips, err := m.getFreshPeerIpConfig(ctx, destination, peer.UserIdentifier, peer.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get fresh ip addresses: %w", err)
+	}
+
+	prefixes, err := m.getFreshPeerPrefixes(ctx, destination, peer.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get fresh prefixes: %w", err)
+	}
+
+	originalPeer := *peer
+	peer.MoveToInterface(source, destination, ips, prefixes)
+
+	if err := m.applyTagPolicies(ctx, peer, peer.Tags); err != nil {
+		return nil, fmt.Errorf("move failure: %w", err)
+	}
+
+	if err := m.movePeerDevices(ctx, &originalPeer, peer); err != nil {
+		return nil, fmt.Errorf("move failure: %w", err)
+	}
+
+	if audited {
+		m.bus.Publish(app.TopicAuditPeerChanged, domain.AuditEventWrapper[audit.PeerEvent]{
+			Ctx: ctx,
+			Event: audit.PeerEvent{
+				Action: "move",
+				Peer:   *peer,
+			},
+		})
+	}
+	m.bus.Publish(app.TopicPeerUpdated, *peer)
+	m.bus.Publish(app.TopicPeerMoved, *peer, source.Identifier)
+	// Update routes after peers have changed
+	m.bus.Publish(app.TopicRouteUpdate, "peers updated")
+	// Update both interfaces after peers have changed
+	m.bus.Publish(app.TopicPeerInterfaceUpdated, source.Identifier)
+	m.bus.Publish(app.TopicPeerInterfaceUpdated, destination.Identifier)
+
+	return peer, nil
+}
+
+// movePeerDevices stores the moved peer in the database and moves it from the source to the target device.
+// If the target device fails, the peer is restored on the source device and the database change is rolled back.
+func (m Manager) movePeerDevices(ctx context.Context, original, moved *domain.Peer) error {
+	sourceWg, err := m.getInterfaceController(ctx, original.InterfaceIdentifier)
+	if err != nil {
+		return err
+	}
+
+	targetWg, err := m.getInterfaceController(ctx, moved.InterfaceIdentifier)
+	if err != nil {
+		return err
+	}
+
+	return m.db.SavePeer(ctx, moved.Identifier, func(p *domain.Peer) (*domain.Peer, error) {
+		moved.CopyCalculatedAttributes(p)
+
+		if err := sourceWg.DeletePeer(ctx, original.InterfaceIdentifier, original.Identifier); err != nil {
+			return nil, fmt.Errorf("failed to delete wireguard peer %s: %w", original.Identifier, err)
+		}
+
+		if moved.IsDisabled() || moved.IsExpired() {
+			return moved, nil // the peer is only stored in the database
+		}
+
+		err := targetWg.SavePeer(ctx, moved.InterfaceIdentifier, moved.Identifier,
+			func(pp *domain.PhysicalPeer) (*domain.PhysicalPeer, error) {
+				domain.MergeToPhysicalPeer(pp, moved)
+				return pp, nil
+			})
+		if err == nil {
+			return moved, nil
+		}
+
+		if !original.IsDisabled() && !original.IsExpired() {
+			restoreErr := sourceWg.SavePeer(ctx, original.InterfaceIdentifier, original.Identifier,
+				func(pp *domain.PhysicalPeer) (*domain.PhysicalPeer, error) {
+					domain.MergeToPhysicalPeer(pp, original)
+					return pp, nil
+				})
+			if restoreErr != nil {
+				return nil, fmt.Errorf("failed to save wireguard peer %s: %w, restore failed: %w",
+					moved.Identifier, err, restoreErr)
+			}
+		}
+
+		return nil, fmt.Errorf("failed to save wireguard peer %s: %w", moved.Identifier, err)
+	})
+}
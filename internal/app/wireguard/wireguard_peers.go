@@ -48,6 +48,10 @@ func (m Manager) CreateDefaultPeer(ctx context.Context, userId domain.UserIdenti
 		}
 
 		peer.UserIdentifier = userId
+		peer.Interface.Addresses, err = m.getFreshPeerIpConfig(ctx, &iface, userId, peer.Identifier)
+		if err != nil {
+			return fmt.Errorf("failed to allocate ip addresses for interface %s: %w", iface.Identifier, err)
+		}
 		peer.Notes = fmt.Sprintf("Default peer created for user %s", userId)
 		peer.AutomaticallyCreated = true
 		peer.GenerateDisplayName("Default")
@@ -71,18 +75,24 @@ func (m Manager) CreateDefaultPeer(ctx context.Context, userId domain.UserIdenti
 }
 
 // GetUserPeers returns all peers for the given user.
+// Users with roles only receive the peers of the interfaces that they are permitted to read.
 func (m Manager) GetUserPeers(ctx context.Context, id domain.UserIdentifier) ([]domain.Peer, error) {
-	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+	if err := domain.ValidateUserOrPermission(ctx, id, domain.PermissionPeersRead, ""); err != nil {
+		return nil, err
+	}
+
+	peers, err := m.db.GetUserPeers(ctx, id)
+	if err != nil {
 		return nil, err
 	}
 
-	return m.db.GetUserPeers(ctx, id)
+	return filterPermittedPeers(ctx, peers), nil
 }
 
 // PreparePeer prepares a new peer for the given interface with fresh keys and ip addresses.
 func (m Manager) PreparePeer(ctx context.Context, id domain.InterfaceIdentifier) (*domain.Peer, error) {
 	if !m.cfg.Core.SelfProvisioningAllowed {
-		if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		if err := domain.ValidatePermission(ctx, domain.PermissionPeersWrite, id); err != nil {
 			return nil, err
 		}
 	}
@@ -94,18 +104,25 @@ func (m Manager) PreparePeer(ctx context.Context, id domain.InterfaceIdentifier)
 		return nil, fmt.Errorf("unable to find interface %s: %w", id, err)
 	}
 
-	if m.cfg.Core.SelfProvisioningAllowed && !currentUser.IsAdmin && iface.Type != domain.InterfaceTypeServer {
+	privileged := currentUser.HasPermission(domain.PermissionPeersWrite, id)
+	if m.cfg.Core.SelfProvisioningAllowed && !privileged && iface.Type != domain.InterfaceTypeServer {
 		return nil, fmt.Errorf("self provisioning is only allowed for server interfaces: %w", domain.ErrNoPermission)
 	}
 
-	ips, err := m.getFreshPeerIpConfig(ctx, iface)
+	kp, err := domain.NewFreshKeypair()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate keys: %w", err)
+	}
+	peerId := domain.PeerIdentifier(kp.PublicKey)
+
+	ips, err := m.getFreshPeerIpConfig(ctx, iface, currentUser.Id, peerId)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get fresh ip addresses: %w", err)
 	}
 
-	kp, err := domain.NewFreshKeypair()
+	prefixes, err := m.getFreshPeerPrefixes(ctx, iface, peerId)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate keys: %w", err)
+		return nil, fmt.Errorf("unable to get fresh prefixes: %w", err)
 	}
 
 	pk, err := domain.NewPreSharedKey()
@@ -118,7 +135,6 @@ func (m Manager) PreparePeer(ctx context.Context, id domain.InterfaceIdentifier)
 		peerMode = domain.InterfaceTypeServer
 	}
 
-	peerId := domain.PeerIdentifier(kp.PublicKey)
 	freshPeer := &domain.Peer{
 		BaseModel: domain.BaseModel{
 			CreatedBy: string(currentUser.Id),
@@ -129,7 +145,7 @@ func (m Manager) PreparePeer(ctx context.Context, id domain.InterfaceIdentifier)
 		Endpoint:            domain.NewConfigOption(iface.PeerDefEndpoint, true),
 		EndpointPublicKey:   domain.NewConfigOption(iface.PublicKey, true),
 		AllowedIPsStr:       domain.NewConfigOption(iface.PeerDefAllowedIPsStr, true),
-		ExtraAllowedIPsStr:  "",
+		ExtraAllowedIPsStr:  domain.CidrsToString(prefixes),
 		PresharedKey:        pk,
 		PersistentKeepalive: domain.NewConfigOption(iface.PeerDefPersistentKeepalive, true),
 		Identifier:          peerId,
@@ -167,7 +183,9 @@ func (m Manager) GetPeer(ctx context.Context, id domain.PeerIdentifier) (*domain
 		return nil, fmt.Errorf("unable to find peer %s: %w", id, err)
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	err = domain.ValidateUserOrPermission(ctx, peer.UserIdentifier, domain.PermissionPeersRead,
+		peer.InterfaceIdentifier)
+	if err != nil {
 		return nil, err
 	}
 
@@ -177,19 +195,22 @@ func (m Manager) GetPeer(ctx context.Context, id domain.PeerIdentifier) (*domain
 // CreatePeer creates a new peer.
 func (m Manager) CreatePeer(ctx context.Context, peer *domain.Peer) (*domain.Peer, error) {
 	if !m.cfg.Core.SelfProvisioningAllowed {
-		if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		if err := domain.ValidatePermission(ctx, domain.PermissionPeersWrite, peer.InterfaceIdentifier); err != nil {
 			return nil, err
 		}
 	} else {
-		if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+		err := domain.ValidateUserOrPermission(ctx, peer.UserIdentifier, domain.PermissionPeersWrite,
+			peer.InterfaceIdentifier)
+		if err != nil {
 			return nil, err
 		}
 	}
 
 	sessionUser := domain.GetUserInfo(ctx)
+	privileged := sessionUser.HasPermission(domain.PermissionPeersWrite, peer.InterfaceIdentifier)
 
     // Enforce peer limit for non-admin users if LimitAdditionalUserPeers is set
-    if m.cfg.Core.SelfProvisioningAllowed && !sessionUser.IsAdmin && m.cfg.Advanced.LimitAdditionalUserPeers > 0 {
+    if m.cfg.Core.SelfProvisioningAllowed && !privileged && m.cfg.Advanced.LimitAdditionalUserPeers > 0 {
         peers, err := m.db.GetUserPeers(ctx, peer.UserIdentifier)
         if err != nil {
             return nil, fmt.Errorf("failed to fetch peers for user %s: %w", peer.UserIdentifier, err)
@@ -221,7 +242,7 @@ func (m Manager) CreatePeer(ctx context.Context, peer *domain.Peer) (*domain.Pee
 	}
 
 	// if a peer is self provisioned, ensure that only allowed fields are set from the request
-	if !sessionUser.IsAdmin {
+	if !privileged {
 		preparedPeer, err := m.PreparePeer(ctx, peer.InterfaceIdentifier)
 		if err != nil {
 			return nil, fmt.Errorf("failed to prepare peer for interface %s: %w", peer.InterfaceIdentifier, err)
@@ -236,6 +257,10 @@ func (m Manager) CreatePeer(ctx context.Context, peer *domain.Peer) (*domain.Pee
 		return nil, fmt.Errorf("creation not allowed: %w", err)
 	}
 
+	if err := m.applyTagPolicies(ctx, peer, peer.Tags); err != nil {
+		return nil, fmt.Errorf("creation failure: %w", err)
+	}
+
 	err = m.savePeers(ctx, peer)
 	if err != nil {
 		return nil, fmt.Errorf("creation failure: %w", err)
@@ -253,11 +278,18 @@ func (m Manager) CreateMultiplePeers(
 	interfaceId domain.InterfaceIdentifier,
 	r *domain.PeerCreationRequest,
 ) ([]domain.Peer, error) {
-	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+	if err := domain.ValidatePermission(ctx, domain.PermissionPeersWrite, interfaceId); err != nil {
 		return nil, err
 	}
 
+	iface, err := m.db.GetInterface(ctx, interfaceId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find interface %s: %w", interfaceId, err)
+	}
+
 	var newPeers []*domain.Peer
+	var pendingIps []domain.Cidr      // addresses of the new peers, they are not stored yet
+	var pendingPrefixes []domain.Cidr // delegated prefixes of the new peers
 
 	for _, id := range r.UserIdentifiers {
 		freshPeer, err := m.PreparePeer(ctx, interfaceId)
@@ -266,6 +298,18 @@ func (m Manager) CreateMultiplePeers(
 		}
 
 		freshPeer.UserIdentifier = domain.UserIdentifier(id) // use id as user identifier. peers are allowed to have invalid user identifiers
+		freshPeer.Interface.Addresses, err = m.getFreshPeerIpConfig(ctx, iface, freshPeer.UserIdentifier,
+			freshPeer.Identifier, pendingIps...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get fresh ip addresses: %w", err)
+		}
+		pendingIps = append(pendingIps, freshPeer.Interface.Addresses...)
+		prefixes, err := m.getFreshPeerPrefixes(ctx, iface, freshPeer.Identifier, pendingPrefixes...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get fresh prefixes: %w", err)
+		}
+		freshPeer.ExtraAllowedIPsStr = domain.CidrsToString(prefixes)
+		pendingPrefixes = append(pendingPrefixes, prefixes...)
 		if r.Suffix != "" {
 			freshPeer.DisplayName += " " + r.Suffix
 		}
@@ -277,7 +321,7 @@ func (m Manager) CreateMultiplePeers(
 		newPeers = append(newPeers, freshPeer)
 	}
 
-	err := m.savePeers(ctx, newPeers...)
+	err = m.savePeers(ctx, newPeers...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new peers: %w", err)
 	}
@@ -299,7 +343,9 @@ func (m Manager) UpdatePeer(ctx context.Context, peer *domain.Peer) (*domain.Pee
 		return nil, fmt.Errorf("unable to load existing peer %s: %w", peer.Identifier, err)
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, existingPeer.UserIdentifier); err != nil {
+	err = domain.ValidateUserOrPermission(ctx, existingPeer.UserIdentifier, domain.PermissionPeersWrite,
+		existingPeer.InterfaceIdentifier)
+	if err != nil {
 		return nil, err
 	}
 
@@ -310,7 +356,7 @@ func (m Manager) UpdatePeer(ctx context.Context, peer *domain.Peer) (*domain.Pee
 	sessionUser := domain.GetUserInfo(ctx)
 
 	// if a peer is self provisioned, ensure that only allowed fields are set from the request
-	if !sessionUser.IsAdmin {
+	if !sessionUser.HasPermission(domain.PermissionPeersWrite, existingPeer.InterfaceIdentifier) {
 		originalPeer, err := m.db.GetPeer(ctx, peer.Identifier)
 		if err != nil {
 			return nil, fmt.Errorf("unable to load existing peer %s: %w", peer.Identifier, err)
@@ -320,6 +366,20 @@ func (m Manager) UpdatePeer(ctx context.Context, peer *domain.Peer) (*domain.Pee
 		peer = originalPeer
 	}
 
+	// renewals are only changed by RenewPeer
+	peer.RenewalCount = existingPeer.RenewalCount
+	peer.RenewalRequestedAt = existingPeer.RenewalRequestedAt
+
+	// the policies of new tags are applied on top of the current settings
+	if err := m.applyTagPolicies(ctx, peer, addedTags(existingPeer, peer)); err != nil {
+		return nil, fmt.Errorf("update failure: %w", err)
+	}
+
+	// a peer that is re-enabled by an admin must not be disabled again because of its quota
+	if err := m.overrideQuota(ctx, existingPeer, peer); err != nil {
+		return nil, fmt.Errorf("failed to override traffic quota: %w", err)
+	}
+
 	// handle peer identifier change (new public key)
 	if existingPeer.Identifier != domain.PeerIdentifier(peer.Interface.PublicKey) {
 		peer.Identifier = domain.PeerIdentifier(peer.Interface.PublicKey) // set new identifier
@@ -368,7 +428,9 @@ func (m Manager) DeletePeer(ctx context.Context, id domain.PeerIdentifier) error
 		return fmt.Errorf("unable to find peer %s: %w", id, err)
 	}
 
-	if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+	err = domain.ValidateUserOrPermission(ctx, peer.UserIdentifier, domain.PermissionPeersWrite,
+		peer.InterfaceIdentifier)
+	if err != nil {
 		return err
 	}
 
@@ -376,7 +438,12 @@ func (m Manager) DeletePeer(ctx context.Context, id domain.PeerIdentifier) error
 		return fmt.Errorf("delete not allowed: %w", err)
 	}
 
-	err = m.wg.DeletePeer(ctx, peer.InterfaceIdentifier, id)
+	wg, err := m.getInterfaceController(ctx, peer.InterfaceIdentifier)
+	if err != nil {
+		return err
+	}
+
+	err = wg.DeletePeer(ctx, peer.InterfaceIdentifier, id)
 	if err != nil {
 		return fmt.Errorf("wireguard failed to delete peer %s: %w", id, err)
 	}
@@ -404,7 +471,9 @@ func (m Manager) GetPeerStats(ctx context.Context, id domain.InterfaceIdentifier
 
 	peerIds := make([]domain.PeerIdentifier, len(peers))
 	for i, peer := range peers {
-		if err := domain.ValidateUserAccessRights(ctx, peer.UserIdentifier); err != nil {
+		err := domain.ValidateUserOrPermission(ctx, peer.UserIdentifier, domain.PermissionPeersRead,
+			peer.InterfaceIdentifier)
+		if err != nil {
 			return nil, err
 		}
 
@@ -416,7 +485,7 @@ func (m Manager) GetPeerStats(ctx context.Context, id domain.InterfaceIdentifier
 
 // GetUserPeerStats returns the status of all peers for the given user.
 func (m Manager) GetUserPeerStats(ctx context.Context, id domain.UserIdentifier) ([]domain.PeerStatus, error) {
-	if err := domain.ValidateUserAccessRights(ctx, id); err != nil {
+	if err := domain.ValidateUserOrPermission(ctx, id, domain.PermissionPeersRead, ""); err != nil {
 		return nil, err
 	}
 
@@ -424,6 +493,7 @@ func (m Manager) GetUserPeerStats(ctx context.Context, id domain.UserIdentifier)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch peers for user %s: %w", id, err)
 	}
+	peers = filterPermittedPeers(ctx, peers)
 
 	peerIds := make([]domain.PeerIdentifier, len(peers))
 	for i, peer := range peers {
@@ -436,16 +506,38 @@ func (m Manager) GetUserPeerStats(ctx context.Context, id domain.UserIdentifier)
 // region helper-functions
 
 func (m Manager) savePeers(ctx context.Context, peers ...*domain.Peer) error {
+	_, err := m.persistPeers(ctx, true, peers...)
+	return err
+}
+
+// persistPeers stores the peers in the database and on the WireGuard interfaces. It stops at the first failure and
+// returns the number of peers that were stored before the failure. Audit events are only published if audited is set, bulk actions
+// record a single audit entry instead.
+func (m Manager) persistPeers(ctx context.Context, audited bool, peers ...*domain.Peer) (int, error) {
 	interfaces := make(map[domain.InterfaceIdentifier]struct{})
+	controllers := make(map[domain.InterfaceIdentifier]InterfaceController)
 
+	saved := 0
+	var saveErr error
 	for i := range peers {
 		peer := peers[i]
+		wg, ok := controllers[peer.InterfaceIdentifier]
+		if !ok {
+			controller, err := m.getInterfaceController(ctx, peer.InterfaceIdentifier)
+			if err != nil {
+				saveErr = err
+				break
+			}
+			wg = controller
+			controllers[peer.InterfaceIdentifier] = controller
+		}
+
 		var err error
 		if peer.IsDisabled() || peer.IsExpired() {
 			err = m.db.SavePeer(ctx, peer.Identifier, func(p *domain.Peer) (*domain.Peer, error) {
 				peer.CopyCalculatedAttributes(p)
 
-				if err := m.wg.DeletePeer(ctx, peer.InterfaceIdentifier, peer.Identifier); err != nil {
+				if err := wg.DeletePeer(ctx, peer.InterfaceIdentifier, peer.Identifier); err != nil {
 					return nil, fmt.Errorf("failed to delete wireguard peer %s: %w", peer.Identifier, err)
 				}
 
@@ -455,7 +547,7 @@ func (m Manager) savePeers(ctx context.Context, peers ...*domain.Peer) error {
 			err = m.db.SavePeer(ctx, peer.Identifier, func(p *domain.Peer) (*domain.Peer, error) {
 				peer.CopyCalculatedAttributes(p)
 
-				err := m.wg.SavePeer(ctx, peer.InterfaceIdentifier, peer.Identifier,
+				err := wg.SavePeer(ctx, peer.InterfaceIdentifier, peer.Identifier,
 					func(pp *domain.PhysicalPeer) (*domain.PhysicalPeer, error) {
 						domain.MergeToPhysicalPeer(pp, peer)
 						return pp, nil
@@ -468,20 +560,24 @@ func (m Manager) savePeers(ctx context.Context, peers ...*domain.Peer) error {
 			})
 		}
 		if err != nil {
-			return fmt.Errorf("save failure for peer %s: %w", peer.Identifier, err)
+			saveErr = fmt.Errorf("save failure for peer %s: %w", peer.Identifier, err)
+			break
 		}
 
 		// publish event
 
-		m.bus.Publish(app.TopicAuditPeerChanged, domain.AuditEventWrapper[audit.PeerEvent]{
-			Ctx: ctx,
-			Event: audit.PeerEvent{
-				Action: "save",
-				Peer:   *peer,
-			},
-		})
+		if audited {
+			m.bus.Publish(app.TopicAuditPeerChanged, domain.AuditEventWrapper[audit.PeerEvent]{
+				Ctx: ctx,
+				Event: audit.PeerEvent{
+					Action: "save",
+					Peer:   *peer,
+				},
+			})
+		}
 
 		interfaces[peer.InterfaceIdentifier] = struct{}{}
+		saved++
 	}
 
 	// Update routes after peers have changed
@@ -493,60 +589,57 @@ func (m Manager) savePeers(ctx context.Context, peers ...*domain.Peer) error {
 		m.bus.Publish(app.TopicPeerInterfaceUpdated, iface)
 	}
 
-	return nil
+	return saved, saveErr
 }
 
-func (m Manager) getFreshPeerIpConfig(ctx context.Context, iface *domain.Interface) (ips []domain.Cidr, err error) {
-	if iface.PeerDefNetworkStr == "" {
-		return []domain.Cidr{}, nil // cannot suggest new ip addresses if there is no subnet
-	}
+func (m Manager) validatePeerModifications(ctx context.Context, old, new *domain.Peer) error {
+	currentUser := domain.GetUserInfo(ctx)
+	privileged := currentUser.HasPermission(domain.PermissionPeersWrite, old.InterfaceIdentifier)
 
-	networks, err := domain.CidrsFromString(iface.PeerDefNetworkStr)
-	if err != nil {
-		err = fmt.Errorf("failed to parse default network address: %w", err)
-		return
+	if !privileged && !m.cfg.Core.SelfProvisioningAllowed {
+		return domain.ErrNoPermission
 	}
 
-	existingIps, err := m.db.GetUsedIpsPerSubnet(ctx, networks)
-	if err != nil {
-		err = fmt.Errorf("failed to get existing IP addresses: %w", err)
-		return
+	if privileged && old.InterfaceIdentifier != new.InterfaceIdentifier &&
+		!currentUser.HasPermission(domain.PermissionPeersWrite, new.InterfaceIdentifier) {
+		return fmt.Errorf("no access to interface %s: %w", new.InterfaceIdentifier, domain.ErrNoPermission)
 	}
 
-	for _, network := range networks {
-		ip := network.NextAddr()
-
-		for {
-			ipConflict := false
-			for _, usedIp := range existingIps[network] {
-				if usedIp.Addr == ip.Addr {
-					ipConflict = true
-					break
-				}
-			}
+	if err := domain.ValidateManagedAccess(ctx, old.ManagedBy); err != nil {
+		return err
+	}
 
-			if !ipConflict {
-				break
-			}
+	if err := domain.ValidateManagedAccess(ctx, new.ManagedBy); err != nil {
+		return err
+	}
 
-			ip = ip.NextAddr()
+	if !privileged && old.IsDisabled() && old.DisabledReason == domain.DisabledReasonQuotaExceeded &&
+		!new.IsDisabled() {
+		return fmt.Errorf("traffic quota exceeded: %w", domain.ErrNoPermission)
+	}
 
-			if !ip.IsValid() {
-				return nil, fmt.Errorf("ip space on subnet %s is exhausted", network.String())
-			}
+	if !privileged && domain.ExpiryDateChanged(old.ExpiresAt, new.ExpiresAt) {
+		iface, err := m.db.GetInterface(ctx, old.InterfaceIdentifier)
+		if err != nil {
+			return fmt.Errorf("unable to load interface %s: %w", old.InterfaceIdentifier, err)
+		}
+		if iface.PeerRenewalPolicy.Enabled {
+			return fmt.Errorf("expiry date can only be extended by renewal: %w", domain.ErrNoPermission)
 		}
-
-		ips = append(ips, ip.HostAddr())
 	}
 
-	return
-}
+	if err := new.Quota.Validate(); err != nil {
+		return err
+	}
 
-func (m Manager) validatePeerModifications(ctx context.Context, _, _ *domain.Peer) error {
-	currentUser := domain.GetUserInfo(ctx)
+	if err := domain.ValidateTags(new.Tags); err != nil {
+		return err
+	}
 
-	if !currentUser.IsAdmin && !m.cfg.Core.SelfProvisioningAllowed {
-		return domain.ErrNoPermission
+	if domain.CidrsToString(old.Interface.Addresses) != domain.CidrsToString(new.Interface.Addresses) {
+		if err := m.validateReservedAddresses(ctx, new); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -559,26 +652,65 @@ func (m Manager) validatePeerCreation(ctx context.Context, _, new *domain.Peer)
 		return fmt.Errorf("invalid peer identifier: %w", domain.ErrInvalidData)
 	}
 
-	if !currentUser.IsAdmin && !m.cfg.Core.SelfProvisioningAllowed {
+	if !currentUser.HasPermission(domain.PermissionPeersWrite, new.InterfaceIdentifier) &&
+		!m.cfg.Core.SelfProvisioningAllowed {
 		return domain.ErrNoPermission
 	}
 
+	if err := domain.ValidateManagedAccess(ctx, new.ManagedBy); err != nil {
+		return err
+	}
+
 	_, err := m.db.GetInterface(ctx, new.InterfaceIdentifier)
 	if err != nil {
 		return fmt.Errorf("invalid interface: %w", domain.ErrInvalidData)
 	}
 
+	if err := new.Quota.Validate(); err != nil {
+		return err
+	}
+
+	if err := domain.ValidateTags(new.Tags); err != nil {
+		return err
+	}
+
+	if err := m.validateReservedAddresses(ctx, new); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func (m Manager) validatePeerDeletion(ctx context.Context, _ *domain.Peer) error {
+func (m Manager) validatePeerDeletion(ctx context.Context, del *domain.Peer) error {
 	currentUser := domain.GetUserInfo(ctx)
 
-	if !currentUser.IsAdmin && !m.cfg.Core.SelfProvisioningAllowed {
+	if !currentUser.HasPermission(domain.PermissionPeersWrite, del.InterfaceIdentifier) &&
+		!m.cfg.Core.SelfProvisioningAllowed {
 		return domain.ErrNoPermission
 	}
 
+	if err := domain.ValidateManagedAccess(ctx, del.ManagedBy); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// filterPermittedPeers removes all peers that the current user neither owns nor is permitted to read.
+// filterPermittedPeers removes the peers that the current user is not permitted to read. Peers are checked for their
+// own interface, as a permission check without interface succeeds for interface-scoped roles of any interface.
+func filterPermittedPeers(ctx context.Context, peers []domain.Peer) []domain.Peer {
+	currentUser := domain.GetUserInfo(ctx)
+
+	return slices.DeleteFunc(peers, func(peer domain.Peer) bool {
+		if peer.UserIdentifier == currentUser.Id {
+			return false // users can access their own peers
+		}
+		if peer.InterfaceIdentifier == "" {
+			return !currentUser.IsAdmin // never grant access through an empty interface scope
+		}
+		return !currentUser.HasPermission(domain.PermissionPeersRead, peer.InterfaceIdentifier)
+	})
+}
+
 // endregion helper-functions
@@ -0,0 +1,90 @@
+package wireguard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+
+	"github.com/h44z/wg-portal/internal/adapters"
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+func TestManager_GetUserPeers_filtersInterfaces(t *testing.T) {
+	schema.RegisterSerializer("encstr", app.NewGormEncryptedStringSerializer(""))
+	db, err := adapters.NewDatabase(config.DatabaseConfig{Type: "sqlite", DSN: t.TempDir() + "/test.db"})
+	require.NoError(t, err)
+	repo, err := adapters.NewSqlRepository(db)
+	require.NoError(t, err)
+
+	admin := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+	for _, peer := range []domain.Peer{
+		{Identifier: "peer-wg0", InterfaceIdentifier: "wg0", UserIdentifier: "alice"},
+		{Identifier: "peer-wg1", InterfaceIdentifier: "wg1", UserIdentifier: "alice"},
+	} {
+		err := repo.SavePeer(admin, peer.Identifier, func(p *domain.Peer) (*domain.Peer, error) {
+			p.InterfaceIdentifier = peer.InterfaceIdentifier
+			p.UserIdentifier = peer.UserIdentifier
+			return p, nil
+		})
+		require.NoError(t, err)
+		err = repo.UpdatePeerStatus(admin, peer.Identifier, func(s *domain.PeerStatus) (*domain.PeerStatus, error) {
+			return s, nil
+		})
+		require.NoError(t, err)
+	}
+
+	m := Manager{cfg: &config.Config{}, db: repo}
+	peerIds := func(peers []domain.Peer) []domain.PeerIdentifier {
+		ids := make([]domain.PeerIdentifier, len(peers))
+		for i, peer := range peers {
+			ids[i] = peer.Identifier
+		}
+		return ids
+	}
+	statusIds := func(stats []domain.PeerStatus) []domain.PeerIdentifier {
+		ids := make([]domain.PeerIdentifier, len(stats))
+		for i, status := range stats {
+			ids[i] = status.PeerId
+		}
+		return ids
+	}
+
+	// an operator of wg0 only receives the peers of wg0
+	operator := domain.SetUserInfo(context.Background(),
+		&domain.ContextUserInfo{Id: "bob", Roles: []domain.Role{domain.OperatorRole("wg0")}})
+	peers, err := m.GetUserPeers(operator, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, []domain.PeerIdentifier{"peer-wg0"}, peerIds(peers))
+	stats, err := m.GetUserPeerStats(operator, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, []domain.PeerIdentifier{"peer-wg0"}, statusIds(stats))
+
+	// the owner and global roles receive all peers
+	owner := domain.SetUserInfo(context.Background(), &domain.ContextUserInfo{Id: "alice"})
+	peers, err = m.GetUserPeers(owner, "alice")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []domain.PeerIdentifier{"peer-wg0", "peer-wg1"}, peerIds(peers))
+	auditor := domain.SetUserInfo(context.Background(),
+		&domain.ContextUserInfo{Id: "carol", Roles: []domain.Role{domain.RoleAuditor}})
+	stats, err = m.GetUserPeerStats(auditor, "alice")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []domain.PeerIdentifier{"peer-wg0", "peer-wg1"}, statusIds(stats))
+
+	// users without permissions are rejected
+	other := domain.SetUserInfo(context.Background(), &domain.ContextUserInfo{Id: "dave"})
+	_, err = m.GetUserPeers(other, "alice")
+	assert.ErrorIs(t, err, domain.ErrNoPermission)
+}
+
+func Test_filterPermittedPeers_emptyInterface(t *testing.T) {
+	operator := domain.SetUserInfo(context.Background(),
+		&domain.ContextUserInfo{Id: "bob", Roles: []domain.Role{domain.OperatorRole("wg0")}})
+
+	peers := filterPermittedPeers(operator, []domain.Peer{{Identifier: "orphan", UserIdentifier: "alice"}})
+	assert.Empty(t, peers)
+}
@@ -0,0 +1,120 @@
+package wireguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// handlePeerQuotaExceededEvent disables a peer that exceeded its traffic quota.
+// The peer is re-enabled by checkQuotaPeriods once the next quota period starts.
+func (m Manager) handlePeerQuotaExceededEvent(exceededPeer domain.Peer, status domain.PeerStatus) {
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+	id := exceededPeer.Identifier
+
+	peer, err := m.db.GetPeer(ctx, id)
+	if err != nil {
+		slog.Error("failed to load peer that exceeded its quota", "peer", id, "error", err)
+		return
+	}
+	if peer.IsDisabled() {
+		return // peer is already disabled
+	}
+
+	slog.Info("peer exceeded its traffic quota, disabling", "peer", id,
+		"used", status.QuotaUsed(), "limit", status.QuotaLimit)
+
+	now := time.Now()
+	peer.Disabled = &now
+	peer.DisabledReason = domain.DisabledReasonQuotaExceeded
+
+	if _, err := m.UpdatePeer(ctx, peer); err != nil {
+		slog.Error("failed to disable peer that exceeded its quota", "peer", id, "error", err)
+	}
+}
+
+// checkQuotaPeriods re-enables peers that were disabled because of their traffic quota,
+// if a new quota period started or the quota no longer applies.
+func (m Manager) checkQuotaPeriods(ctx context.Context, iface *domain.Interface, peers []domain.Peer) {
+	now := time.Now()
+
+	for _, peer := range peers {
+		if !peer.IsDisabled() || peer.DisabledReason != domain.DisabledReasonQuotaExceeded {
+			continue
+		}
+		if peer.IsExpired() {
+			continue // the peer must stay disabled
+		}
+
+		quota, err := m.getPeerQuota(ctx, iface, &peer)
+		if err != nil {
+			slog.Error("failed to load user for quota check", "peer", peer.Identifier, "error", err)
+			continue
+		}
+		if quota.IsEnabled() && !quota.PeriodStart(now).After(*peer.Disabled) {
+			continue // still in the period in which the quota was exceeded
+		}
+
+		slog.Info("new traffic quota period started, enabling peer", "peer", peer.Identifier)
+
+		peer.Disabled = nil
+		peer.DisabledReason = ""
+
+		if _, err := m.UpdatePeer(ctx, &peer); err != nil {
+			slog.Error("failed to re-enable peer after quota reset", "peer", peer.Identifier, "error", err)
+		}
+	}
+}
+
+// overrideQuota suspends the quota enforcement for the rest of the current quota period, if a peer that was
+// disabled because of its traffic quota is enabled again manually. Otherwise, the peer would be disabled again
+// by the next statistics update, as the traffic of the period still exceeds the quota.
+func (m Manager) overrideQuota(ctx context.Context, existingPeer, peer *domain.Peer) error {
+	if !existingPeer.IsDisabled() || existingPeer.DisabledReason != domain.DisabledReasonQuotaExceeded ||
+		peer.IsDisabled() {
+		return nil // the peer was not re-enabled
+	}
+
+	iface, err := m.db.GetInterface(ctx, peer.InterfaceIdentifier)
+	if err != nil {
+		return fmt.Errorf("unable to load interface %s: %w", peer.InterfaceIdentifier, err)
+	}
+	quota, err := m.getPeerQuota(ctx, iface, peer)
+	if err != nil {
+		return fmt.Errorf("unable to load user %s: %w", peer.UserIdentifier, err)
+	}
+
+	periodStart := quota.PeriodStart(time.Now())
+	if !quota.IsEnabled() || periodStart.After(*existingPeer.Disabled) {
+		return nil // the quota was exceeded in a previous period, the traffic of the current period counts
+	}
+
+	slog.Info("peer re-enabled after it exceeded its traffic quota, suspending the quota until the next period",
+		"peer", peer.Identifier, "period", periodStart)
+
+	return m.db.UpdatePeerStatus(ctx, peer.Identifier, func(s *domain.PeerStatus) (*domain.PeerStatus, error) {
+		s.QuotaOverride = &periodStart
+		return s, nil
+	})
+}
+
+// getPeerQuota returns the effective traffic quota of the given peer.
+func (m Manager) getPeerQuota(ctx context.Context, iface *domain.Interface, peer *domain.Peer) (
+	domain.TrafficQuota,
+	error,
+) {
+	var user *domain.User
+	if peer.UserIdentifier != "" {
+		var err error
+		user, err = m.db.GetUser(ctx, peer.UserIdentifier)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return domain.TrafficQuota{}, err
+		}
+	}
+
+	return domain.EffectiveQuota(peer, user, iface), nil
+}
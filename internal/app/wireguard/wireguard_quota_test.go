@@ -0,0 +1,138 @@
+package wireguard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+
+	"github.com/h44z/wg-portal/internal/adapters"
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/config"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+type testEventBus struct{}
+
+func (testEventBus) Publish(string, ...any) {}
+
+func (testEventBus) Subscribe(string, interface{}) error { return nil }
+
+// newQuotaTestManager creates a manager for a netstack interface wg0 with a daily quota for its peers.
+func newQuotaTestManager(t *testing.T) (*Manager, *adapters.SqlRepo) {
+	schema.RegisterSerializer("encstr", app.NewGormEncryptedStringSerializer(""))
+	db, err := adapters.NewDatabase(config.DatabaseConfig{Type: "sqlite", DSN: t.TempDir() + "/test.db"})
+	require.NoError(t, err)
+	repo, err := adapters.NewSqlRepository(db)
+	require.NoError(t, err)
+
+	wgSoftware := adapters.NewWireGuardNetstackRepository()
+	t.Cleanup(wgSoftware.Close)
+
+	admin := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+	keys, err := domain.NewFreshKeypair()
+	require.NoError(t, err)
+	err = wgSoftware.SaveInterface(admin, "wg0", func(pi *domain.PhysicalInterface) (*domain.PhysicalInterface, error) {
+		pi.KeyPair = keys
+		pi.DeviceUp = true
+		return pi, nil
+	})
+	require.NoError(t, err)
+	err = repo.SaveInterface(admin, "wg0", func(in *domain.Interface) (*domain.Interface, error) {
+		in.KeyPair = keys
+		in.DriverType = domain.InterfaceDriverTypeSoftware
+		in.PeerDefQuota = domain.TrafficQuota{
+			Limit:      100,
+			Direction:  domain.QuotaDirectionTotal,
+			ResetCycle: domain.QuotaResetDaily,
+		}
+		return in, nil
+	})
+	require.NoError(t, err)
+
+	m := &Manager{cfg: &config.Config{}, bus: testEventBus{}, db: repo, wgSoftware: wgSoftware}
+	return m, repo
+}
+
+// saveQuotaExceededPeer stores a peer that was disabled at the given time because it exceeded its quota.
+func saveQuotaExceededPeer(t *testing.T, repo *adapters.SqlRepo, disabled time.Time) domain.PeerIdentifier {
+	admin := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+	keys, err := domain.NewFreshKeypair()
+	require.NoError(t, err)
+	id := domain.PeerIdentifier(keys.PublicKey)
+
+	err = repo.SavePeer(admin, id, func(p *domain.Peer) (*domain.Peer, error) {
+		p.InterfaceIdentifier = "wg0"
+		p.Interface.KeyPair = keys
+		p.Disabled = &disabled
+		p.DisabledReason = domain.DisabledReasonQuotaExceeded
+		return p, nil
+	})
+	require.NoError(t, err)
+
+	return id
+}
+
+// collectTraffic updates the quota usage like the statistics collector and reports if the quota is exceeded.
+func collectTraffic(t *testing.T, repo *adapters.SqlRepo, id domain.PeerIdentifier, now time.Time) bool {
+	admin := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+	quota := domain.TrafficQuota{Limit: 100, Direction: domain.QuotaDirectionTotal, ResetCycle: domain.QuotaResetDaily}
+
+	var exceeded bool
+	err := repo.UpdatePeerStatus(admin, id, func(s *domain.PeerStatus) (*domain.PeerStatus, error) {
+		s.UpdateQuotaUsage(quota, now, 60, 60)
+		exceeded = s.IsQuotaExceeded()
+		return s, nil
+	})
+	require.NoError(t, err)
+
+	return exceeded
+}
+
+func TestManager_UpdatePeer_reEnableOverridesQuota(t *testing.T) {
+	m, repo := newQuotaTestManager(t)
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	now := time.Now()
+	id := saveQuotaExceededPeer(t, repo, now)
+	require.True(t, collectTraffic(t, repo, id, now))
+
+	peer, err := repo.GetPeer(ctx, id)
+	require.NoError(t, err)
+	peer.Disabled = nil
+	peer.DisabledReason = ""
+	_, err = m.UpdatePeer(ctx, peer)
+	require.NoError(t, err)
+
+	// the quota is not enforced for the rest of the period
+	assert.False(t, collectTraffic(t, repo, id, now))
+	stats, err := repo.GetPeersStats(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.True(t, stats[0].IsQuotaOverridden())
+	assert.Equal(t, uint64(240), stats[0].QuotaUsed())
+
+	// the next period is enforced again
+	assert.True(t, collectTraffic(t, repo, id, now.Add(24*time.Hour)))
+}
+
+func TestManager_checkQuotaPeriods_doesNotOverrideQuota(t *testing.T) {
+	m, repo := newQuotaTestManager(t)
+	ctx := domain.SetUserInfo(context.Background(), domain.SystemAdminContextUserInfo())
+
+	id := saveQuotaExceededPeer(t, repo, time.Now().Add(-48*time.Hour))
+
+	iface, peers, err := repo.GetInterfaceAndPeers(ctx, "wg0")
+	require.NoError(t, err)
+	m.checkQuotaPeriods(ctx, iface, peers)
+
+	peer, err := repo.GetPeer(ctx, id)
+	require.NoError(t, err)
+	assert.False(t, peer.IsDisabled(), "the peer is enabled in the new period")
+
+	// the quota of the new period is enforced
+	assert.True(t, collectTraffic(t, repo, id, time.Now()))
+}
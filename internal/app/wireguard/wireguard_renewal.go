@@ -0,0 +1,104 @@
+package wireguard
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/app/audit"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// RenewPeer extends the expiry date of the given peer according to the renewal policy of its interface.
+// If the policy requires approval, renewals by regular users are only recorded as pending request.
+// Renewals by users that are permitted to manage the peers of the interface are applied immediately
+// and approve pending requests.
+func (m Manager) RenewPeer(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error) {
+	peer, err := m.db.GetPeer(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find peer %s: %w", id, err)
+	}
+
+	err = domain.ValidateUserOrPermission(ctx, peer.UserIdentifier, domain.PermissionPeersManage,
+		peer.InterfaceIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	iface, err := m.db.GetInterface(ctx, peer.InterfaceIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find interface %s: %w", peer.InterfaceIdentifier, err)
+	}
+
+	now := time.Now()
+	expiresAt, err := iface.PeerRenewalPolicy.NextExpiry(peer, now)
+	if err != nil {
+		return nil, fmt.Errorf("renewal not allowed: %w", err)
+	}
+
+	action := "renew"
+	approved := domain.GetUserInfo(ctx).HasPermission(domain.PermissionPeersManage, peer.InterfaceIdentifier)
+	if iface.PeerRenewalPolicy.RequireApproval && !approved {
+		if peer.IsRenewalRequested() {
+			return peer, nil // the renewal is already waiting for approval
+		}
+
+		action = "renew-request"
+		peer.RenewalRequestedAt = &now
+	} else {
+		peer.Renew(expiresAt)
+	}
+
+	if err := m.savePeers(ctx, peer); err != nil {
+		return nil, fmt.Errorf("renewal failure: %w", err)
+	}
+
+	slog.Debug("peer renewal", "peer", peer.Identifier, "action", action, "expiresAt", expiresAt)
+
+	m.bus.Publish(app.TopicAuditPeerChanged, domain.AuditEventWrapper[audit.PeerEvent]{
+		Ctx: ctx,
+		Event: audit.PeerEvent{
+			Action: action,
+			Peer:   *peer,
+		},
+	})
+	m.bus.Publish(app.TopicPeerUpdated, *peer)
+
+	return peer, nil
+}
+
+// RejectPeerRenewal discards a pending renewal request of the given peer.
+// Only users that are permitted to manage the peers of the interface can reject renewals.
+func (m Manager) RejectPeerRenewal(ctx context.Context, id domain.PeerIdentifier) (*domain.Peer, error) {
+	peer, err := m.db.GetPeer(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find peer %s: %w", id, err)
+	}
+
+	if err := domain.ValidatePermission(ctx, domain.PermissionPeersManage, peer.InterfaceIdentifier); err != nil {
+		return nil, err
+	}
+
+	if !peer.IsRenewalRequested() {
+		return nil, fmt.Errorf("no renewal requested: %w", domain.ErrInvalidData)
+	}
+
+	peer.RenewalRequestedAt = nil
+
+	if err := m.savePeers(ctx, peer); err != nil {
+		return nil, fmt.Errorf("renewal rejection failure: %w", err)
+	}
+
+	m.bus.Publish(app.TopicAuditPeerChanged, domain.AuditEventWrapper[audit.PeerEvent]{
+		Ctx: ctx,
+		Event: audit.PeerEvent{
+			Action: "renew-reject",
+			Peer:   *peer,
+		},
+	})
+	m.bus.Publish(app.TopicPeerUpdated, *peer)
+
+	return peer, nil
+}
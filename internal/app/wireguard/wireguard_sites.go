@@ -0,0 +1,316 @@
+package wireguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/app"
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// GetSiteNetworks returns all site networks.
+func (m Manager) GetSiteNetworks(ctx context.Context) ([]domain.SiteNetwork, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	return m.db.GetSiteNetworks(ctx)
+}
+
+// GetSiteNetwork returns the site network with the given identifier.
+func (m Manager) GetSiteNetwork(ctx context.Context, id domain.SiteNetworkIdentifier) (*domain.SiteNetwork, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	return m.db.GetSiteNetwork(ctx, id)
+}
+
+// CreateSiteNetwork creates a new site network. Keys are generated for all remote sites,
+// sites that are linked to an interface use the keys of the interface.
+func (m Manager) CreateSiteNetwork(ctx context.Context, network *domain.SiteNetwork) (*domain.SiteNetwork, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	if network.Identifier == "" {
+		return nil, fmt.Errorf("site network identifier must not be empty: %w", domain.ErrInvalidData)
+	}
+
+	existingNetwork, err := m.db.GetSiteNetwork(ctx, network.Identifier)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("unable to load existing site network %s: %w", network.Identifier, err)
+	}
+	if existingNetwork != nil {
+		return nil, fmt.Errorf("site network %s already exists: %w", network.Identifier, domain.ErrDuplicateEntry)
+	}
+
+	if err := m.saveSiteNetwork(ctx, nil, network); err != nil {
+		return nil, fmt.Errorf("creation failure: %w", err)
+	}
+
+	return network, nil
+}
+
+// UpdateSiteNetwork updates the given site network. The sites and links are replaced, and the peers of all
+// affected interfaces are updated.
+func (m Manager) UpdateSiteNetwork(ctx context.Context, network *domain.SiteNetwork) (*domain.SiteNetwork, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	existingNetwork, err := m.db.GetSiteNetwork(ctx, network.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load existing site network %s: %w", network.Identifier, err)
+	}
+
+	if err := m.saveSiteNetwork(ctx, existingNetwork, network); err != nil {
+		return nil, fmt.Errorf("update failure: %w", err)
+	}
+
+	return network, nil
+}
+
+// DeleteSiteNetwork deletes the given site network and all peers that were created for it.
+func (m Manager) DeleteSiteNetwork(ctx context.Context, id domain.SiteNetworkIdentifier) error {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return err
+	}
+
+	existingNetwork, err := m.db.GetSiteNetwork(ctx, id)
+	if err != nil {
+		return fmt.Errorf("unable to find site network %s: %w", id, err)
+	}
+
+	if err := m.deleteSitePeers(ctx, sitePeersOf(existingNetwork), nil); err != nil {
+		return err
+	}
+
+	if err := m.db.DeleteSiteNetwork(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete site network %s: %w", id, err)
+	}
+
+	m.bus.Publish(app.TopicSiteNetworkDeleted, *existingNetwork)
+
+	return nil
+}
+
+// refreshInterfaceSites updates all site networks that contain a site of the given interface,
+// so that changed keys, ports or addresses of the interface are published to the other sites.
+func (m Manager) refreshInterfaceSites(ctx context.Context, id domain.InterfaceIdentifier) error {
+	sites, err := m.db.GetInterfaceSites(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to load sites of interface %s: %w", id, err)
+	}
+
+	var refreshed []domain.SiteNetworkIdentifier
+	for _, site := range sites {
+		if slices.Contains(refreshed, site.NetworkIdentifier) {
+			continue
+		}
+		refreshed = append(refreshed, site.NetworkIdentifier)
+
+		network, err := m.db.GetSiteNetwork(ctx, site.NetworkIdentifier)
+		if err != nil {
+			return fmt.Errorf("failed to load site network %s: %w", site.NetworkIdentifier, err)
+		}
+		updatedNetwork := *network
+		updatedNetwork.Sites = slices.Clone(network.Sites)
+		if err := m.saveSiteNetwork(ctx, network, &updatedNetwork); err != nil {
+			return fmt.Errorf("failed to update site network %s: %w", site.NetworkIdentifier, err)
+		}
+	}
+
+	return nil
+}
+
+func (m Manager) saveSiteNetwork(ctx context.Context, old, network *domain.SiteNetwork) error {
+	if err := m.prepareSites(ctx, old, network); err != nil {
+		return err
+	}
+
+	if err := network.Validate(); err != nil {
+		return err
+	}
+
+	newPeers := sitePeersOf(network)
+	if err := m.validateSitePeers(ctx, newPeers); err != nil {
+		return err
+	}
+
+	currentUser := domain.GetUserInfo(ctx)
+	err := m.db.SaveSiteNetwork(ctx, network.Identifier, func(n *domain.SiteNetwork) (*domain.SiteNetwork, error) {
+		network.BaseModel = n.BaseModel
+		if network.CreatedAt.IsZero() {
+			network.CreatedBy = string(currentUser.Id)
+			network.CreatedAt = time.Now()
+		}
+		network.UpdatedBy = string(currentUser.Id)
+		network.UpdatedAt = time.Now()
+
+		for i := range network.Sites {
+			if network.Sites[i].CreatedAt.IsZero() {
+				network.Sites[i].CreatedBy = string(currentUser.Id)
+				network.Sites[i].CreatedAt = time.Now()
+			}
+			network.Sites[i].UpdatedBy = string(currentUser.Id)
+			network.Sites[i].UpdatedAt = time.Now()
+		}
+
+		return network, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save site network %s: %w", network.Identifier, err)
+	}
+
+	if old != nil {
+		if err := m.deleteSitePeers(ctx, sitePeersOf(old), newPeers); err != nil {
+			return err
+		}
+	}
+	if len(newPeers) != 0 {
+		if err := m.savePeers(ctx, newPeers...); err != nil {
+			return fmt.Errorf("failed to save site peers: %w", err)
+		}
+	}
+
+	m.bus.Publish(app.TopicSiteNetworkUpdated, *network)
+
+	return nil
+}
+
+// prepareSites fills in the keys, ports and addresses of all sites. Sites that are linked to an interface use the
+// settings of the interface, remote sites keep their existing keys or get a new key pair.
+func (m Manager) prepareSites(ctx context.Context, old, network *domain.SiteNetwork) error {
+	for i := range network.Sites {
+		site := &network.Sites[i]
+		site.NetworkIdentifier = network.Identifier
+
+		var existingSite *domain.Site
+		if old != nil {
+			existingSite, _ = old.Site(site.Identifier)
+		}
+		if existingSite != nil {
+			site.BaseModel = existingSite.BaseModel
+		}
+
+		if site.InterfaceIdentifier != "" {
+			iface, err := m.db.GetInterface(ctx, site.InterfaceIdentifier)
+			if err != nil {
+				return fmt.Errorf("invalid interface %s of site %s: %w", site.InterfaceIdentifier, site.Identifier,
+					domain.ErrInvalidData)
+			}
+			site.KeyPair = iface.KeyPair
+			site.ListenPort = iface.ListenPort
+			site.AddressStr = domain.CidrsToString(iface.Addresses)
+			continue
+		}
+
+		switch {
+		case site.PublicKey != "" && (existingSite == nil || existingSite.PublicKey != site.PublicKey):
+			// the key of an existing remote site was provided, the private key is optional
+			if !domain.PeerIdentifier(site.PublicKey).IsPublicKey() {
+				return fmt.Errorf("invalid public key of site %s: %w", site.Identifier, domain.ErrInvalidData)
+			}
+		case existingSite != nil && existingSite.InterfaceIdentifier == "" && existingSite.PublicKey != "":
+			site.KeyPair = existingSite.KeyPair
+		default:
+			kp, err := domain.NewFreshKeypair()
+			if err != nil {
+				return fmt.Errorf("failed to generate keys for site %s: %w", site.Identifier, err)
+			}
+			site.KeyPair = kp
+		}
+	}
+
+	return nil
+}
+
+// validateSitePeers checks that every peer is only created once. The public key of a peer is unique, so a remote
+// site cannot be connected to several sites that are managed by this portal.
+func (m Manager) validateSitePeers(ctx context.Context, peers []*domain.Peer) error {
+	for i, peer := range peers {
+		if slices.ContainsFunc(peers[:i], func(p *domain.Peer) bool { return p.Identifier == peer.Identifier }) {
+			return fmt.Errorf("site %s is connected to several local sites: %w", peer.DisplayName,
+				domain.ErrInvalidData)
+		}
+
+		existingPeer, err := m.db.GetPeer(ctx, peer.Identifier)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("unable to load existing peer %s: %w", peer.Identifier, err)
+		}
+		if existingPeer != nil && existingPeer.InterfaceIdentifier != peer.InterfaceIdentifier {
+			return fmt.Errorf("key of site %s is already used by peer %s: %w", peer.DisplayName,
+				existingPeer.DisplayName, domain.ErrDuplicateEntry)
+		}
+		if existingPeer != nil {
+			peer.BaseModel = existingPeer.BaseModel
+			peer.PresharedKey = existingPeer.PresharedKey
+		}
+	}
+
+	return nil
+}
+
+// deleteSitePeers deletes all old peers that are not part of the new peers.
+func (m Manager) deleteSitePeers(ctx context.Context, oldPeers, newPeers []*domain.Peer) error {
+	for _, peer := range oldPeers {
+		if slices.ContainsFunc(newPeers, func(p *domain.Peer) bool {
+			return p.Identifier == peer.Identifier && p.InterfaceIdentifier == peer.InterfaceIdentifier
+		}) {
+			continue
+		}
+
+		err := m.DeletePeer(ctx, peer.Identifier)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("failed to delete site peer %s: %w", peer.Identifier, err)
+		}
+	}
+
+	return nil
+}
+
+// sitePeersOf returns the peers of all sites of the network that are linked to an interface.
+func sitePeersOf(network *domain.SiteNetwork) []*domain.Peer {
+	var peers []*domain.Peer
+	for _, site := range network.Sites {
+		if site.InterfaceIdentifier == "" {
+			continue
+		}
+
+		for _, sitePeer := range network.Peers(site.Identifier) {
+			displayName := sitePeer.Site.DisplayName
+			if displayName == "" {
+				displayName = string(sitePeer.Site.Identifier)
+			}
+
+			peer := &domain.Peer{
+				BaseModel: domain.BaseModel{
+					CreatedBy: string(domain.CtxSystemAdminId),
+					UpdatedBy: string(domain.CtxSystemAdminId),
+					CreatedAt: time.Now(),
+					UpdatedAt: time.Now(),
+				},
+				Endpoint:             domain.NewConfigOption(sitePeer.Site.Endpoint, false),
+				AllowedIPsStr:        domain.NewConfigOption(domain.CidrsToString(sitePeer.AllowedIPs), false),
+				PersistentKeepalive:  domain.NewConfigOption(sitePeer.PersistentKeepalive, false),
+				DisplayName:          "Site " + displayName,
+				Identifier:           domain.PeerIdentifier(sitePeer.Site.PublicKey),
+				InterfaceIdentifier:  site.InterfaceIdentifier,
+				Notes:                fmt.Sprintf("Managed by site network %s", network.Identifier),
+				AutomaticallyCreated: true,
+				Interface: domain.PeerInterfaceConfig{
+					KeyPair:   domain.KeyPair{PublicKey: sitePeer.Site.PublicKey},
+					Type:      domain.InterfaceTypeServer, // the neighbor site is reached via its endpoint
+					Addresses: sitePeer.Site.Addresses(),
+				},
+			}
+			peers = append(peers, peer)
+		}
+	}
+
+	return peers
+}
@@ -0,0 +1,257 @@
+package wireguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/domain"
+)
+
+// GetPeerTags returns all tags that carry a policy.
+func (m Manager) GetPeerTags(ctx context.Context) ([]domain.PeerTag, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	return m.db.GetPeerTags(ctx)
+}
+
+// GetPeerTag returns the tag with the given name.
+func (m Manager) GetPeerTag(ctx context.Context, name string) (*domain.PeerTag, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	return m.db.GetPeerTag(ctx, name)
+}
+
+// CreatePeerTag stores the description and the policy of a new tag.
+func (m Manager) CreatePeerTag(ctx context.Context, tag *domain.PeerTag) (*domain.PeerTag, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	existingTag, err := m.db.GetPeerTag(ctx, tag.Name)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("unable to load existing tag %s: %w", tag.Name, err)
+	}
+	if existingTag != nil {
+		return nil, fmt.Errorf("tag %s already exists: %w", tag.Name, domain.ErrDuplicateEntry)
+	}
+
+	if err := m.savePeerTag(ctx, tag); err != nil {
+		return nil, fmt.Errorf("creation failure: %w", err)
+	}
+
+	return tag, nil
+}
+
+// UpdatePeerTag updates the description and the policy of the given tag.
+// The policy is not applied to already tagged peers, use ApplyTagDefaults to update them.
+func (m Manager) UpdatePeerTag(ctx context.Context, tag *domain.PeerTag) (*domain.PeerTag, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err := m.db.GetPeerTag(ctx, tag.Name); err != nil {
+		return nil, fmt.Errorf("unable to load existing tag %s: %w", tag.Name, err)
+	}
+
+	if err := m.savePeerTag(ctx, tag); err != nil {
+		return nil, fmt.Errorf("update failure: %w", err)
+	}
+
+	return tag, nil
+}
+
+// DeletePeerTag deletes the policy of the given tag. Tagged peers keep the tag and all applied settings.
+func (m Manager) DeletePeerTag(ctx context.Context, name string) error {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return err
+	}
+
+	if _, err := m.db.GetPeerTag(ctx, name); err != nil {
+		return fmt.Errorf("unable to find tag %s: %w", name, err)
+	}
+
+	if err := m.db.DeletePeerTag(ctx, name); err != nil {
+		return fmt.Errorf("failed to delete tag %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// GetTaggedPeers returns all peers that are tagged with the given tag.
+func (m Manager) GetTaggedPeers(ctx context.Context, tag string) ([]domain.Peer, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	return m.db.GetTaggedPeers(ctx, tag)
+}
+
+// EnableTaggedPeers enables all disabled peers with the given tag. The enabled peers are returned.
+func (m Manager) EnableTaggedPeers(ctx context.Context, tag string) ([]domain.Peer, error) {
+	return m.updateTaggedPeers(ctx, tag, func(peer *domain.Peer) bool {
+		if !peer.IsDisabled() {
+			return false
+		}
+
+		peer.Disabled = nil
+		peer.DisabledReason = ""
+		return true
+	})
+}
+
+// DisableTaggedPeers disables all enabled peers with the given tag. The disabled peers are returned.
+func (m Manager) DisableTaggedPeers(ctx context.Context, tag string) ([]domain.Peer, error) {
+	now := time.Now()
+
+	return m.updateTaggedPeers(ctx, tag, func(peer *domain.Peer) bool {
+		if peer.IsDisabled() {
+			return false
+		}
+
+		peer.Disabled = &now
+		peer.DisabledReason = domain.DisabledReasonAdmin
+		return true
+	})
+}
+
+// ApplyTagDefaults applies the interface defaults and the policies of all tags to the peers with the given tag.
+// The updated peers are returned.
+func (m Manager) ApplyTagDefaults(ctx context.Context, tag string) ([]domain.Peer, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	tags, err := m.db.GetPeerTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tags: %w", err)
+	}
+
+	interfaces := make(map[domain.InterfaceIdentifier]*domain.Interface)
+	now := time.Now()
+
+	return m.updateTaggedPeers(ctx, tag, func(peer *domain.Peer) bool {
+		iface, ok := interfaces[peer.InterfaceIdentifier]
+		if !ok {
+			iface, err = m.db.GetInterface(ctx, peer.InterfaceIdentifier)
+			if err != nil {
+				return false // skip peers of unknown interfaces
+			}
+			interfaces[peer.InterfaceIdentifier] = iface
+		}
+
+		peer.ApplyInterfaceDefaults(iface)
+		peer.ApplyTagPolicy(domain.MergeTagPolicies(tags, peer.Tags), now)
+		return true
+	})
+}
+
+// DeleteTaggedPeers deletes all peers with the given tag. The deleted peers are returned.
+func (m Manager) DeleteTaggedPeers(ctx context.Context, tag string) ([]domain.Peer, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	peers, err := m.db.GetTaggedPeers(ctx, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load peers with tag %s: %w", tag, err)
+	}
+
+	for i, peer := range peers {
+		if err := m.DeletePeer(ctx, peer.Identifier); err != nil {
+			return peers[:i], fmt.Errorf("failed to delete peer %s: %w", peer.Identifier, err)
+		}
+	}
+
+	return peers, nil
+}
+
+// updateTaggedPeers calls the given function for all peers with the given tag and saves every peer
+// for which the function returns true.
+func (m Manager) updateTaggedPeers(
+	ctx context.Context,
+	tag string,
+	updateFunc func(peer *domain.Peer) bool,
+) ([]domain.Peer, error) {
+	if err := domain.ValidateAdminAccessRights(ctx); err != nil {
+		return nil, err
+	}
+
+	peers, err := m.db.GetTaggedPeers(ctx, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load peers with tag %s: %w", tag, err)
+	}
+
+	updatedPeers := make([]domain.Peer, 0, len(peers))
+	for i := range peers {
+		if !updateFunc(&peers[i]) {
+			continue
+		}
+
+		updatedPeer, err := m.UpdatePeer(ctx, &peers[i])
+		if err != nil {
+			return updatedPeers, fmt.Errorf("failed to update peer %s: %w", peers[i].Identifier, err)
+		}
+		updatedPeers = append(updatedPeers, *updatedPeer)
+	}
+
+	return updatedPeers, nil
+}
+
+// applyTagPolicies applies the policies of the given tags to the peer.
+func (m Manager) applyTagPolicies(ctx context.Context, peer *domain.Peer, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	allTags, err := m.db.GetPeerTags(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load tags: %w", err)
+	}
+
+	peer.ApplyTagPolicy(domain.MergeTagPolicies(allTags, tags), time.Now())
+
+	return nil
+}
+
+// addedTags returns all tags of the new peer that the old peer did not have.
+func addedTags(old, new *domain.Peer) []string {
+	var added []string
+	for _, tag := range new.Tags {
+		if !slices.Contains(old.Tags, tag) {
+			added = append(added, tag)
+		}
+	}
+
+	return added
+}
+
+func (m Manager) savePeerTag(ctx context.Context, tag *domain.PeerTag) error {
+	if err := tag.Validate(); err != nil {
+		return err
+	}
+
+	currentUser := domain.GetUserInfo(ctx)
+	err := m.db.SavePeerTag(ctx, tag.Name, func(t *domain.PeerTag) (*domain.PeerTag, error) {
+		tag.BaseModel = t.BaseModel
+		if tag.CreatedAt.IsZero() {
+			tag.CreatedBy = string(currentUser.Id)
+			tag.CreatedAt = time.Now()
+		}
+		tag.UpdatedBy = string(currentUser.Id)
+		tag.UpdatedAt = time.Now()
+
+		return tag, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save tag %s: %w", tag.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import "time"
+
+// AgentConfig contains the configuration of the agent listener. Agents run on remote WireGuard hosts
+// and connect to the portal, so that the interfaces of these hosts can be managed centrally.
+type AgentConfig struct {
+	// ListeningAddress is the address the portal listens on for agent connections.
+	// If empty, the agent listener is disabled and only local interfaces can be managed.
+	ListeningAddress string `yaml:"listening_address"`
+	// CertFile is the path to the TLS certificate of the agent listener. It is required if the listener is enabled.
+	CertFile string `yaml:"certificate_file"`
+	// KeyFile is the path to the TLS certificate key of the agent listener.
+	KeyFile string `yaml:"key_file"`
+	// ClientCaFile is the path to a CA certificate. If set, agents must present a client certificate that is signed
+	// by this CA and issued for their host name (mutual TLS).
+	ClientCaFile string `yaml:"client_ca_file"`
+	// RequestTimeout is the maximum duration of a single request to an agent.
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// Hosts contains all agents that are allowed to connect.
+	Hosts []AgentHostConfig `yaml:"hosts"`
+}
+
+// AgentHostConfig contains the credentials of a single agent.
+type AgentHostConfig struct {
+	// Name is the unique name of the agent host, interfaces reference the host by this name.
+	Name string `yaml:"name"`
+	// Token is the shared secret that the agent uses to authenticate.
+	Token string `yaml:"token"`
+}
+
+// FindHost returns the configuration of the agent host with the given name.
+func (c AgentConfig) FindHost(name string) (AgentHostConfig, bool) {
+	for _, host := range c.Hosts {
+		if host.Name == name {
+			return host, true
+		}
+	}
+	return AgentHostConfig{}, false
+}
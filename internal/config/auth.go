@@ -24,6 +24,45 @@ type Auth struct {
 	// HideLoginForm specifies whether the login form should be hidden. If no social login providers are configured,
 	// the login form will be shown regardless of this setting.
 	HideLoginForm bool `yaml:"hide_login_form"`
+	// LoginProtection contains the settings of the brute-force protection for logins and the REST API.
+	LoginProtection LoginProtection `yaml:"login_protection"`
+	// TwoFactor contains the settings of the second factor for password logins.
+	TwoFactor TwoFactor `yaml:"two_factor"`
+}
+
+// LoginProtection contains the configuration of the brute-force protection. Failed logins are tracked per account
+// and per source address. After each failure, further attempts are delayed exponentially. Once the maximum number
+// of failures is reached, the account or source address is blocked temporarily.
+type LoginProtection struct {
+	// Enabled specifies whether failed logins are tracked.
+	Enabled bool `yaml:"enabled"`
+	// MaxFailures is the number of consecutive failed logins after which an account is locked.
+	MaxFailures int `yaml:"max_failures"`
+	// MaxSourceFailures is the number of consecutive failed logins after which a source address is blocked.
+	MaxSourceFailures int `yaml:"max_source_failures"`
+	// LockoutDuration is the duration of the lock. Afterward, the account is unlocked automatically.
+	// Failures that are older than this duration are forgotten.
+	LockoutDuration time.Duration `yaml:"lockout_duration"`
+	// BaseDelay is the delay after the first failed login, it doubles with each further failure.
+	BaseDelay time.Duration `yaml:"base_delay"`
+	// MaxDelay is the maximum delay between two login attempts.
+	MaxDelay time.Duration `yaml:"max_delay"`
+}
+
+// TwoFactor contains the configuration of the second factor for password logins (database and LDAP users).
+// Users that enrolled a TOTP authenticator app always have to provide a second factor. Registered passkeys
+// can be used as second factor as well.
+type TwoFactor struct {
+	// TotpEnabled specifies whether users can enroll a TOTP authenticator app.
+	TotpEnabled bool `yaml:"totp_enabled"`
+	// Issuer is the name that is shown in the authenticator app. If empty, the site title is used.
+	Issuer string `yaml:"issuer"`
+	// RecoveryCodes is the number of single-use recovery codes that are generated on enrollment.
+	RecoveryCodes int `yaml:"recovery_codes"`
+	// EnforceSources lists the user sources (db, ldap) that must use a second factor.
+	EnforceSources []string `yaml:"enforce_sources"`
+	// EnforceRoles lists the roles that must use a second factor. The special value "admin" matches all admins.
+	EnforceRoles []string `yaml:"enforce_roles"`
 }
 
 // BaseFields contains the basic fields that are used to map user information from the authentication providers.
@@ -51,6 +90,9 @@ type OauthFields struct {
 	// UserGroups is the name of the field that contains the user's groups.
 	// If the value matches the admin_group_regex, the user is an admin. See OauthAdminMapping for more details.
 	UserGroups string `yaml:"user_groups"`
+	// Roles is the name of the field that contains the names of the user's roles, e.g. auditor or operator:wg0.
+	// Unknown role names are ignored. See OauthAdminMapping for group based role mappings.
+	Roles string `yaml:"roles"`
 }
 
 // OauthAdminMapping contains all necessary information to extract information about administrative privileges
@@ -68,10 +110,15 @@ type OauthAdminMapping struct {
 	// the user is an admin.
 	AdminGroupRegex string `yaml:"admin_group_regex"`
 
+	// RoleGroups maps role names to regular expressions. If any of the groups listed in the groups field matches
+	// the regular expression, the user receives the role.
+	RoleGroups map[string]string `yaml:"role_groups"`
+
 	// internal cache fields
 
 	adminValueRegex *regexp.Regexp
 	adminGroupRegex *regexp.Regexp
+	roleGroupRegex  map[string]*regexp.Regexp
 }
 
 // GetAdminValueRegex returns the compiled regular expression for the admin_value_regex field.
@@ -118,6 +165,25 @@ func (o *OauthAdminMapping) GetAdminGroupRegex() *regexp.Regexp {
 	return o.adminGroupRegex
 }
 
+// GetRoleGroupRegex returns the compiled regular expressions for the role_groups field, keyed by role name.
+func (o *OauthAdminMapping) GetRoleGroupRegex() map[string]*regexp.Regexp {
+	if o.roleGroupRegex != nil {
+		return o.roleGroupRegex // return cached value
+	}
+
+	o.roleGroupRegex = make(map[string]*regexp.Regexp, len(o.RoleGroups))
+	for role, expr := range o.RoleGroups {
+		groupRegex, err := regexp.Compile(expr)
+		if err != nil {
+			slog.Error("failed to compile role_groups regex", "role", role, "error", err)
+			panic("failed to compile role_groups regex")
+		}
+		o.roleGroupRegex[role] = groupRegex
+	}
+
+	return o.roleGroupRegex
+}
+
 // LdapFields contains extra fields that are used to map user information from LDAP providers.
 type LdapFields struct {
 	BaseFields `yaml:",inline"`
@@ -159,6 +225,10 @@ type LdapProvider struct {
 	AdminGroupDN string `yaml:"admin_group"`
 	// ParsedAdminGroupDN is the parsed version of AdminGroupDN
 	ParsedAdminGroupDN *ldap.DN `yaml:"-"`
+	// RoleGroups maps role names to group DNs. Members of the group receive the role in wg-portal.
+	RoleGroups map[string]string `yaml:"role_groups"`
+	// ParsedRoleGroupDNs is the parsed version of RoleGroups
+	ParsedRoleGroupDNs map[string]*ldap.DN `yaml:"-"`
 
 	// If DisableMissing is true, missing users will be deactivated
 	DisableMissing bool `yaml:"disable_missing"`
@@ -211,6 +281,29 @@ type OpenIDConnectProvider struct {
 
 	// If LogUserInfo is set to true, the user info retrieved from the OIDC provider will be logged in trace level.
 	LogUserInfo bool `yaml:"log_user_info"`
+
+	// ApiBearer configures whether bearer tokens issued by this provider are accepted by the REST API.
+	ApiBearer OidcApiBearer `yaml:"api_bearer"`
+}
+
+// OidcApiBearer contains the configuration for the validation of bearer JWTs that are issued by an OIDC provider.
+// The tokens are validated against the signing keys published by the provider (JWKS). The claims of the token are
+// mapped to the user identity using the field and admin mapping of the provider.
+type OidcApiBearer struct {
+	// Enabled specifies whether the REST API accepts bearer tokens issued by this provider.
+	Enabled bool `yaml:"enabled"`
+
+	// Audience is the expected audience (aud claim) of the tokens. If it is empty, the client_id is used.
+	Audience string `yaml:"audience"`
+
+	// ScopesClaim is the name of the claim that contains the granted API scopes, either as a space separated
+	// string or as a list. If it is empty, the "scope" claim is used.
+	ScopesClaim string `yaml:"scopes_claim"`
+
+	// If ServiceIdentities is set to true, tokens whose user identifier does not belong to an existing user are
+	// accepted as well, for example tokens of the client credentials flow. Such service identities only receive
+	// the admin flag and roles that are mapped from the token claims.
+	ServiceIdentities bool `yaml:"service_identities"`
 }
 
 // OAuthProvider contains the configuration for the OAuth provider.
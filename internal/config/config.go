@@ -37,11 +37,22 @@ type Config struct {
 		StartCidrV6              string        `yaml:"start_cidr_v6"`
 		UseIpV6                  bool          `yaml:"use_ip_v6"`
 		ConfigStoragePath        string        `yaml:"config_storage_path"` // keep empty to disable config export to file
+		ConfigImportPath         string        `yaml:"config_import_path"`  // keep empty to disable config import from file
 		ExpiryCheckInterval      time.Duration `yaml:"expiry_check_interval"`
+		ExpiryReminders          []int         `yaml:"expiry_reminders"`     // days before expiry, keep empty to disable expiry notifications
+		DriftCheckInterval       time.Duration `yaml:"drift_check_interval"` // set to 0 to disable the drift check
 		RulePrioOffset           int           `yaml:"rule_prio_offset"`
 		RouteTableOffset         int           `yaml:"route_table_offset"`
 		ApiAdminOnly             bool          `yaml:"api_admin_only"` // if true, only admin users can access the API
 		LimitAdditionalUserPeers int           `yaml:"limit_additional_user_peers"`
+		ShareLinkValidity        time.Duration `yaml:"share_link_validity"` // default validity of config download links
+		ShareLinkMaxUses         int           `yaml:"share_link_max_uses"` // default number of downloads per link, 0 = unlimited
+		BundleSigningKey         string        `yaml:"bundle_signing_key"`  // shared key that signs interface bundles, keep empty to disable bundles
+		BackupInterval           time.Duration `yaml:"backup_interval"`     // interval of scheduled backups, set to 0 to disable them
+		BackupPassphrase         string        `yaml:"backup_passphrase"`   // passphrase that encrypts scheduled backups
+		BackupRetention          int           `yaml:"backup_retention"`    // number of scheduled backups to keep, 0 = unlimited
+		SpecPath                 string        `yaml:"spec_path"`           // declarative spec file or directory, keep empty to disable reconciliation
+		SpecSyncInterval         time.Duration `yaml:"spec_sync_interval"`  // interval of spec reconciliation, set to 0 to only apply the spec at startup
 	} `yaml:"advanced"`
 
 	Statistics struct {
@@ -54,6 +65,12 @@ type Config struct {
 		CollectPeerData        bool          `yaml:"collect_peer_data"`
 		CollectAuditData       bool          `yaml:"collect_audit_data"`
 		ListeningAddress       string        `yaml:"listening_address"`
+
+		CollectTrafficHistory         bool          `yaml:"collect_traffic_history"`
+		TrafficHistoryRawRetention    time.Duration `yaml:"traffic_history_raw_retention"`    // raw samples are merged into hourly samples afterward
+		TrafficHistoryHourlyRetention time.Duration `yaml:"traffic_history_hourly_retention"` // hourly samples are merged into daily samples afterward
+		TrafficHistoryDailyRetention  time.Duration `yaml:"traffic_history_daily_retention"`  // daily samples are deleted afterward
+		SessionLogRetention           time.Duration `yaml:"session_log_retention"`            // ended peer sessions are deleted afterward
 	} `yaml:"statistics"`
 
 	Mail MailConfig `yaml:"mail"`
@@ -65,6 +82,8 @@ type Config struct {
 	Web WebConfig `yaml:"web"`
 
 	Webhook WebhookConfig `yaml:"webhook"`
+
+	Agents AgentConfig `yaml:"agents"`
 }
 
 // LogStartupValues logs the startup values of the configuration in debug level
@@ -84,10 +103,16 @@ func (c *Config) LogStartupValues() {
 		"collectInterfaceData", c.Statistics.CollectInterfaceData,
 		"collectPeerData", c.Statistics.CollectPeerData,
 		"collectAuditData", c.Statistics.CollectAuditData,
+		"collectTrafficHistory", c.Statistics.CollectTrafficHistory,
 	)
 
 	slog.Debug("Config Settings",
 		"configStoragePath", c.Advanced.ConfigStoragePath,
+		"configImportPath", c.Advanced.ConfigImportPath,
+		"backupInterval", c.Advanced.BackupInterval,
+		"specPath", c.Advanced.SpecPath,
+		"agentListeningAddress", c.Agents.ListeningAddress,
+		"agentHosts", len(c.Agents.Hosts),
 		"externalUrl", c.Web.ExternalUrl,
 	)
 
@@ -139,10 +164,16 @@ func defaultConfig() *Config {
 	cfg.Advanced.StartCidrV6 = "fdfd:d3ad:c0de:1234::0/64"
 	cfg.Advanced.UseIpV6 = true
 	cfg.Advanced.ExpiryCheckInterval = 15 * time.Minute
+	cfg.Advanced.DriftCheckInterval = 5 * time.Minute
 	cfg.Advanced.RulePrioOffset = 20000
 	cfg.Advanced.RouteTableOffset = 20000
 	cfg.Advanced.ApiAdminOnly = true
 	cfg.Advanced.LimitAdditionalUserPeers = 0
+	cfg.Advanced.ShareLinkValidity = 72 * time.Hour
+	cfg.Advanced.ShareLinkMaxUses = 1
+	cfg.Advanced.BackupInterval = 0
+	cfg.Advanced.BackupRetention = 7
+	cfg.Advanced.SpecSyncInterval = 0
 
 	cfg.Statistics.UsePingChecks = true
 	cfg.Statistics.PingCheckWorkers = 10
@@ -153,6 +184,11 @@ func defaultConfig() *Config {
 	cfg.Statistics.CollectPeerData = true
 	cfg.Statistics.CollectAuditData = true
 	cfg.Statistics.ListeningAddress = ":8787"
+	cfg.Statistics.CollectTrafficHistory = true
+	cfg.Statistics.TrafficHistoryRawRetention = 24 * time.Hour
+	cfg.Statistics.TrafficHistoryHourlyRetention = 30 * 24 * time.Hour
+	cfg.Statistics.TrafficHistoryDailyRetention = 365 * 24 * time.Hour
+	cfg.Statistics.SessionLogRetention = 90 * 24 * time.Hour
 
 	cfg.Mail = MailConfig{
 		Host:           "127.0.0.1",
@@ -170,9 +206,24 @@ func defaultConfig() *Config {
 	cfg.Webhook.Authentication = ""
 	cfg.Webhook.Timeout = 10 * time.Second
 
+	cfg.Agents.ListeningAddress = "" // the agent listener is disabled by default
+	cfg.Agents.RequestTimeout = 30 * time.Second
+
 	cfg.Auth.WebAuthn.Enabled = true
 	cfg.Auth.MinPasswordLength = 16
 	cfg.Auth.HideLoginForm = false
+	cfg.Auth.LoginProtection = LoginProtection{
+		Enabled:           false, // the source address is only reliable if the trusted proxies are configured
+		MaxFailures:       10,
+		MaxSourceFailures: 50,
+		LockoutDuration:   15 * time.Minute,
+		BaseDelay:         time.Second,
+		MaxDelay:          time.Minute,
+	}
+	cfg.Auth.TwoFactor = TwoFactor{
+		TotpEnabled:   true,
+		RecoveryCodes: 10,
+	}
 
 	return cfg
 }
@@ -28,4 +28,10 @@ type DatabaseConfig struct {
 	// EncryptionPassphrase is the passphrase used to encrypt sensitive data (WireGuard keys) in the database.
 	// If no passphrase is provided, no encryption will be used.
 	EncryptionPassphrase string `yaml:"encryption_passphrase"`
+	// EncryptionKeyId identifies the encryption passphrase. If set, the id is stored with each encrypted value,
+	// so that the passphrase can be rotated with the rekey command.
+	EncryptionKeyId string `yaml:"encryption_key_id"`
+	// PreviousEncryptionPassphrases are only used to decrypt values that were encrypted with an older passphrase.
+	// They are indexed by their key id, values that were encrypted without key id use the id "legacy".
+	PreviousEncryptionPassphrases map[string]string `yaml:"previous_encryption_passphrases"`
 }
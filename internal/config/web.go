@@ -27,6 +27,9 @@ type WebConfig struct {
 	CertFile string `yaml:"cert_file"`
 	// KeyFile is the path to the TLS certificate key file.
 	KeyFile string `yaml:"key_file"`
+	// TrustedProxies lists the addresses of reverse proxies whose X-Real-Ip or X-Forwarded-For header is used as
+	// the client address. The special value "PRIVATE" trusts all private addresses.
+	TrustedProxies []string `yaml:"trusted_proxies"`
 }
 
 func (c *WebConfig) Sanitize() {
@@ -0,0 +1,222 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ApiTokenIdentifier string
+
+type ApiTokenScope string
+
+const (
+	ApiTokenScopePeersRead       ApiTokenScope = "peers:read"
+	ApiTokenScopePeersManage     ApiTokenScope = "peers:manage" // reject renewals and revoke share links
+	ApiTokenScopePeersWrite      ApiTokenScope = "peers:write"
+	ApiTokenScopeInterfacesRead  ApiTokenScope = "interfaces:read"
+	ApiTokenScopeInterfacesWrite ApiTokenScope = "interfaces:write"
+	ApiTokenScopeUsersRead       ApiTokenScope = "users:read"
+	ApiTokenScopeUsersWrite      ApiTokenScope = "users:write"
+	ApiTokenScopeProvisioning    ApiTokenScope = "provisioning"
+	ApiTokenScopeMetrics         ApiTokenScope = "metrics"
+	ApiTokenScopeSpec            ApiTokenScope = "spec"   // plan and apply the declarative spec
+	ApiTokenScopeTokens          ApiTokenScope = "tokens" // manage the API tokens of the user
+)
+
+// ApiTokenScopes lists all known API token scopes.
+var ApiTokenScopes = []ApiTokenScope{
+	ApiTokenScopePeersRead,
+	ApiTokenScopePeersManage,
+	ApiTokenScopePeersWrite,
+	ApiTokenScopeInterfacesRead,
+	ApiTokenScopeInterfacesWrite,
+	ApiTokenScopeUsersRead,
+	ApiTokenScopeUsersWrite,
+	ApiTokenScopeProvisioning,
+	ApiTokenScopeMetrics,
+	ApiTokenScopeSpec,
+	ApiTokenScopeTokens,
+}
+
+// apiTokenPrefix marks named API tokens, so that they can be distinguished from the legacy user API token.
+const apiTokenPrefix = "wgp_"
+
+// apiTokenUsageInterval limits how often the last usage timestamp of a token is persisted.
+const apiTokenUsageInterval = time.Minute
+
+// ApiToken is a named token that grants access to the REST API on behalf of a user.
+// The access is limited to the scopes of the token and to the permissions of the user.
+type ApiToken struct {
+	BaseModel
+
+	Identifier     ApiTokenIdentifier `gorm:"primaryKey;column:identifier"` // the public part of the token
+	UserIdentifier UserIdentifier     `gorm:"index;column:user_identifier"`
+	Name           string             `gorm:"column:name"`
+	SecretHash     string             `gorm:"column:secret_hash"` // the sha256 hash of the full token
+
+	Scopes       []ApiTokenScope `gorm:"serializer:json;column:scopes"`
+	AllowedCidrs []string        `gorm:"serializer:json;column:allowed_cidrs"` // if set, the token can only be used from these networks
+	ExpiresAt    *time.Time      `gorm:"column:expires_at"`                    // if set, the token can not be used after this timestamp
+	LastUsedAt   *time.Time      `gorm:"column:last_used_at"`
+
+	Token string `gorm:"-"` // the plain token, only available right after creation
+}
+
+// NewApiToken creates a new named token for the given user. The plain token is stored in the Token field,
+// only a hash of the token is persisted.
+func NewApiToken(
+	userId UserIdentifier,
+	name string,
+	scopes []ApiTokenScope,
+	allowedCidrs []string,
+	expiresAt *time.Time,
+) (*ApiToken, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	tokenId := ApiTokenIdentifier(strings.ReplaceAll(uuid.New().String(), "-", ""))
+	token := apiTokenPrefix + string(tokenId) + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	now := time.Now()
+	apiToken := &ApiToken{
+		BaseModel: BaseModel{
+			CreatedBy: string(userId),
+			UpdatedBy: string(userId),
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		Identifier:     tokenId,
+		UserIdentifier: userId,
+		Name:           strings.TrimSpace(name),
+		SecretHash:     hashApiToken(token),
+		Scopes:         scopes,
+		AllowedCidrs:   allowedCidrs,
+		ExpiresAt:      expiresAt,
+		Token:          token,
+	}
+
+	if err := apiToken.Validate(); err != nil {
+		return nil, err
+	}
+
+	return apiToken, nil
+}
+
+// IsNamedApiToken returns true if the given plain token is a named token and not the legacy user API token.
+func IsNamedApiToken(token string) bool {
+	return strings.HasPrefix(token, apiTokenPrefix)
+}
+
+// ParseApiToken extracts the token identifier from the given plain token.
+func ParseApiToken(token string) (ApiTokenIdentifier, error) {
+	tokenId, secret, found := strings.Cut(strings.TrimPrefix(token, apiTokenPrefix), "_")
+	if !IsNamedApiToken(token) || !found || tokenId == "" || secret == "" {
+		return "", ErrApiTokenInvalid
+	}
+
+	return ApiTokenIdentifier(tokenId), nil
+}
+
+// Validate checks the name, scopes and source networks of the token.
+func (t *ApiToken) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("missing token name: %w", ErrInvalidData)
+	}
+	if len(t.Scopes) == 0 {
+		return fmt.Errorf("missing token scopes: %w", ErrInvalidData)
+	}
+	for _, scope := range t.Scopes {
+		if !slices.Contains(ApiTokenScopes, scope) {
+			return fmt.Errorf("invalid token scope %q: %w", scope, ErrInvalidData)
+		}
+	}
+	for _, cidr := range t.AllowedCidrs {
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			return fmt.Errorf("invalid source network %q: %w", cidr, ErrInvalidData)
+		}
+	}
+
+	return nil
+}
+
+// VerifyToken checks that the given plain token belongs to this API token.
+func (t *ApiToken) VerifyToken(token string) error {
+	if subtle.ConstantTimeCompare([]byte(t.SecretHash), []byte(hashApiToken(token))) != 1 {
+		return ErrApiTokenInvalid
+	}
+
+	return nil
+}
+
+func (t *ApiToken) IsExpired() bool {
+	return t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now())
+}
+
+// CheckUsable returns an error if the token can not be used from the given source address.
+func (t *ApiToken) CheckUsable(sourceAddr string) error {
+	if t.IsExpired() {
+		return ErrApiTokenExpired
+	}
+
+	if len(t.AllowedCidrs) == 0 {
+		return nil
+	}
+
+	addr, err := netip.ParseAddr(sourceAddr)
+	if err != nil {
+		return ErrApiTokenSourceDenied
+	}
+	addr = addr.Unmap()
+	for _, cidr := range t.AllowedCidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err == nil && prefix.Contains(addr) {
+			return nil
+		}
+	}
+
+	return ErrApiTokenSourceDenied
+}
+
+// HasScope returns true if the token grants the given scope. A write scope includes the matching read and
+// manage scopes.
+func (t *ApiToken) HasScope(scope ApiTokenScope) bool {
+	if slices.Contains(t.Scopes, scope) {
+		return true
+	}
+
+	resource, access, found := strings.Cut(string(scope), ":")
+	if found && (access == "read" || access == "manage") {
+		return slices.Contains(t.Scopes, ApiTokenScope(resource+":write"))
+	}
+
+	return false
+}
+
+// RegisterUse updates the last usage timestamp. It returns false if the previous usage is so recent that
+// the token does not need to be persisted again.
+func (t *ApiToken) RegisterUse() bool {
+	now := time.Now()
+	if t.LastUsedAt != nil && now.Sub(*t.LastUsedAt) < apiTokenUsageInterval {
+		return false
+	}
+
+	t.LastUsedAt = &now
+	return true
+}
+
+func hashApiToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
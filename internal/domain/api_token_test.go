@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewApiToken(t *testing.T) {
+	token, err := NewApiToken("user", " ci ", []ApiTokenScope{ApiTokenScopePeersRead}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ci", token.Name)
+	assert.True(t, IsNamedApiToken(token.Token))
+	assert.NotContains(t, token.SecretHash, token.Token)
+
+	tokenId, err := ParseApiToken(token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, token.Identifier, tokenId)
+
+	assert.NoError(t, token.VerifyToken(token.Token))
+	assert.ErrorIs(t, token.VerifyToken(token.Token+"x"), ErrApiTokenInvalid)
+}
+
+func TestNewApiToken_Invalid(t *testing.T) {
+	_, err := NewApiToken("user", "", []ApiTokenScope{ApiTokenScopePeersRead}, nil, nil)
+	assert.ErrorIs(t, err, ErrInvalidData)
+
+	_, err = NewApiToken("user", "ci", nil, nil, nil)
+	assert.ErrorIs(t, err, ErrInvalidData)
+
+	_, err = NewApiToken("user", "ci", []ApiTokenScope{"peers:delete"}, nil, nil)
+	assert.ErrorIs(t, err, ErrInvalidData)
+
+	_, err = NewApiToken("user", "ci", []ApiTokenScope{ApiTokenScopePeersRead}, []string{"10.0.0.300/8"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidData)
+}
+
+func TestParseApiToken(t *testing.T) {
+	_, err := ParseApiToken("8a8c3c7e-4bb1-4d35-9a61-1b0e4b9cd2a1") // legacy token
+	assert.ErrorIs(t, err, ErrApiTokenInvalid)
+
+	_, err = ParseApiToken("wgp_abc")
+	assert.ErrorIs(t, err, ErrApiTokenInvalid)
+
+	tokenId, err := ParseApiToken("wgp_abc_de_f")
+	require.NoError(t, err)
+	assert.Equal(t, ApiTokenIdentifier("abc"), tokenId)
+}
+
+func TestApiToken_HasScope(t *testing.T) {
+	token := ApiToken{Scopes: []ApiTokenScope{ApiTokenScopePeersWrite, ApiTokenScopeMetrics}}
+
+	assert.True(t, token.HasScope(ApiTokenScopePeersWrite))
+	assert.True(t, token.HasScope(ApiTokenScopePeersRead), "write includes read")
+	assert.True(t, token.HasScope(ApiTokenScopePeersManage), "write includes manage")
+	assert.True(t, token.HasScope(ApiTokenScopeMetrics))
+	assert.False(t, token.HasScope(ApiTokenScopeUsersRead))
+	assert.False(t, token.HasScope(ApiTokenScopeInterfacesWrite))
+}
+
+func TestApiToken_CheckUsable(t *testing.T) {
+	token := ApiToken{AllowedCidrs: []string{"10.0.0.0/24", "fd00::/64"}}
+
+	assert.NoError(t, token.CheckUsable("10.0.0.5"))
+	assert.NoError(t, token.CheckUsable("::ffff:10.0.0.5"))
+	assert.NoError(t, token.CheckUsable("fd00::1"))
+	assert.ErrorIs(t, token.CheckUsable("10.0.1.5"), ErrApiTokenSourceDenied)
+	assert.ErrorIs(t, token.CheckUsable(""), ErrApiTokenSourceDenied)
+
+	expired := time.Now().Add(-time.Minute)
+	token.ExpiresAt = &expired
+	assert.ErrorIs(t, token.CheckUsable("10.0.0.5"), ErrApiTokenExpired)
+}
+
+func TestApiToken_RegisterUse(t *testing.T) {
+	token := ApiToken{}
+	assert.True(t, token.RegisterUse())
+	assert.NotNil(t, token.LastUsedAt)
+	assert.False(t, token.RegisterUse(), "recently used")
+}
@@ -17,4 +17,7 @@ type AuthenticatorUserInfo struct {
 	Phone      string
 	Department string
 	IsAdmin    bool
+	Roles      []Role
+	// RolesMapped is true if the provider has a role mapping configured. Only then, Roles replaces the user's roles.
+	RolesMapped bool
 }
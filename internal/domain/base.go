@@ -59,9 +59,13 @@ const (
 	DisabledReasonLdapMissing      = "missing in ldap"
 	DisabledReasonMigrationDummy   = "migration dummy user"
 	DisabledReasonInterfaceMissing = "missing WireGuard interface"
+	DisabledReasonPeerMissing      = "missing WireGuard peer"
+	DisabledReasonQuotaExceeded    = "traffic quota exceeded"
+	DisabledReasonSpec             = "disabled by spec"
 
 	LockedReasonAdmin = "locked by admin"
 	LockedReasonApi   = "locked by admin"
+	LockedReasonLogin = "too many failed logins"
 
 	ConfigStyleRaw     = "raw"
 	ConfigStyleWgQuick = "wgquick"
@@ -0,0 +1,309 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type BulkAction string
+
+const (
+	BulkActionEnable    BulkAction = "enable"
+	BulkActionDisable   BulkAction = "disable"
+	BulkActionDelete    BulkAction = "delete"
+	BulkActionSetExpiry BulkAction = "set-expiry" // peers only
+	BulkActionMove      BulkAction = "move"       // peers only
+)
+
+type BulkItemStatus string
+
+const (
+	BulkItemChanged   BulkItemStatus = "changed"   // the item was changed, or would be changed in a dry run
+	BulkItemUnchanged BulkItemStatus = "unchanged" // the action does not change the item, for example if it is already enabled
+	BulkItemFailed    BulkItemStatus = "failed"
+)
+
+// BulkItemResult is the outcome of a bulk action for a single peer or user.
+type BulkItemResult struct {
+	Identifier string
+	Status     BulkItemStatus
+	Error      string // only set for failed items
+}
+
+// BulkResult contains the outcome of a bulk action for all selected items.
+type BulkResult struct {
+	Action BulkAction
+	DryRun bool
+	Items  []BulkItemResult
+}
+
+// Add records the outcome for the item with the given identifier.
+func (r *BulkResult) Add(identifier string, status BulkItemStatus, err error) {
+	item := BulkItemResult{Identifier: identifier, Status: status}
+	if err != nil {
+		item.Status = BulkItemFailed
+		item.Error = err.Error()
+	}
+	r.Items = append(r.Items, item)
+}
+
+// Count returns the number of items with the given status.
+func (r BulkResult) Count(status BulkItemStatus) int {
+	count := 0
+	for _, item := range r.Items {
+		if item.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// PeerSelector selects the peers of a bulk action. All given criteria must match.
+type PeerSelector struct {
+	Identifiers []PeerIdentifier
+	Interface   InterfaceIdentifier
+	User        UserIdentifier
+	Tag         string
+	Search      string // a search expression, see MatchesSearch
+}
+
+// IsEmpty returns true if no criteria are set. An empty selector does not select any peer.
+func (s PeerSelector) IsEmpty() bool {
+	return len(s.Identifiers) == 0 && s.Interface == "" && s.User == "" && s.Tag == "" &&
+		strings.TrimSpace(s.Search) == ""
+}
+
+// Matches returns true if the peer matches all criteria of the selector.
+func (s PeerSelector) Matches(p *Peer) bool {
+	if len(s.Identifiers) > 0 && !slices.Contains(s.Identifiers, p.Identifier) {
+		return false
+	}
+	if s.Interface != "" && p.InterfaceIdentifier != s.Interface {
+		return false
+	}
+	if s.User != "" && p.UserIdentifier != s.User {
+		return false
+	}
+	if s.Tag != "" && !p.HasTag(s.Tag) {
+		return false
+	}
+
+	return MatchesSearch(s.Search, map[string][]string{
+		"id":    {string(p.Identifier)},
+		"name":  {p.DisplayName},
+		"user":  {string(p.UserIdentifier)},
+		"iface": {string(p.InterfaceIdentifier)},
+		"tag":   p.Tags,
+		"addr":  CidrsToStringSlice(p.Interface.Addresses),
+		"notes": {p.Notes},
+	})
+}
+
+// String returns a short description of the selector, used for audit entries.
+func (s PeerSelector) String() string {
+	var parts []string
+	if len(s.Identifiers) > 0 {
+		parts = append(parts, fmt.Sprintf("%d ids", len(s.Identifiers)))
+	}
+	if s.Interface != "" {
+		parts = append(parts, "interface="+string(s.Interface))
+	}
+	if s.User != "" {
+		parts = append(parts, "user="+string(s.User))
+	}
+	if s.Tag != "" {
+		parts = append(parts, "tag="+s.Tag)
+	}
+	if s.Search != "" {
+		parts = append(parts, fmt.Sprintf("search=%q", s.Search))
+	}
+	return strings.Join(parts, " ")
+}
+
+// PeerBulkRequest describes a bulk action for peers.
+type PeerBulkRequest struct {
+	Selector  PeerSelector
+	Action    BulkAction
+	Reason    string              // the reason for disabled peers, defaults to DisabledReasonAdmin
+	ExpiresAt *time.Time          // the new expiry date for BulkActionSetExpiry, nil removes the expiry date
+	Target    InterfaceIdentifier // the target interface for BulkActionMove
+	DryRun    bool                // if set, no changes are stored
+}
+
+// Validate checks the selector and the action.
+func (r PeerBulkRequest) Validate() error {
+	if r.Selector.IsEmpty() {
+		return fmt.Errorf("at least one selector is required: %w", ErrInvalidData)
+	}
+
+	switch r.Action {
+	case BulkActionEnable, BulkActionDisable, BulkActionDelete, BulkActionSetExpiry:
+	case BulkActionMove:
+		if r.Target == "" {
+			return fmt.Errorf("missing target interface: %w", ErrInvalidData)
+		}
+	default:
+		return fmt.Errorf("invalid bulk action %q: %w", r.Action, ErrInvalidData)
+	}
+
+	return nil
+}
+
+// Apply changes the given peer according to the action and returns true if the peer was changed.
+// Deletions and moves are not handled by Apply.
+func (r PeerBulkRequest) Apply(p *Peer, now time.Time) bool {
+	switch r.Action {
+	case BulkActionEnable:
+		if !p.IsDisabled() {
+			return false
+		}
+		p.Disabled = nil
+		p.DisabledReason = ""
+	case BulkActionDisable:
+		if p.IsDisabled() {
+			return false
+		}
+		p.Disabled = &now
+		p.DisabledReason = r.Reason
+		if p.DisabledReason == "" {
+			p.DisabledReason = DisabledReasonAdmin
+		}
+	case BulkActionSetExpiry:
+		if !ExpiryDateChanged(p.ExpiresAt, r.ExpiresAt) {
+			return false
+		}
+		p.ExpiresAt = r.ExpiresAt
+	default:
+		return false
+	}
+
+	return true
+}
+
+// UserSelector selects the users of a bulk action. All given criteria must match.
+type UserSelector struct {
+	Identifiers []UserIdentifier
+	Search      string // a search expression, see MatchesSearch
+}
+
+// IsEmpty returns true if no criteria are set. An empty selector does not select any user.
+func (s UserSelector) IsEmpty() bool {
+	return len(s.Identifiers) == 0 && strings.TrimSpace(s.Search) == ""
+}
+
+// Matches returns true if the user matches all criteria of the selector.
+func (s UserSelector) Matches(u *User) bool {
+	if len(s.Identifiers) > 0 && !slices.Contains(s.Identifiers, u.Identifier) {
+		return false
+	}
+
+	return MatchesSearch(s.Search, map[string][]string{
+		"id":         {string(u.Identifier)},
+		"email":      {u.Email},
+		"name":       {u.Firstname + " " + u.Lastname},
+		"department": {u.Department},
+		"source":     {string(u.Source)},
+		"notes":      {u.Notes},
+	})
+}
+
+// String returns a short description of the selector, used for audit entries.
+func (s UserSelector) String() string {
+	var parts []string
+	if len(s.Identifiers) > 0 {
+		parts = append(parts, fmt.Sprintf("%d ids", len(s.Identifiers)))
+	}
+	if s.Search != "" {
+		parts = append(parts, fmt.Sprintf("search=%q", s.Search))
+	}
+	return strings.Join(parts, " ")
+}
+
+// UserBulkRequest describes a bulk action for users.
+type UserBulkRequest struct {
+	Selector UserSelector
+	Action   BulkAction
+	Reason   string // the reason for disabled users, defaults to DisabledReasonAdmin
+	DryRun   bool   // if set, no changes are stored
+}
+
+// Validate checks the selector and the action.
+func (r UserBulkRequest) Validate() error {
+	if r.Selector.IsEmpty() {
+		return fmt.Errorf("at least one selector is required: %w", ErrInvalidData)
+	}
+
+	switch r.Action {
+	case BulkActionEnable, BulkActionDisable, BulkActionDelete:
+	default:
+		return fmt.Errorf("invalid bulk action %q for users: %w", r.Action, ErrInvalidData)
+	}
+
+	return nil
+}
+
+// Apply changes the given user according to the action and returns true if the user was changed.
+// Deletions are not handled by Apply.
+func (r UserBulkRequest) Apply(u *User, now time.Time) bool {
+	switch r.Action {
+	case BulkActionEnable:
+		if !u.IsDisabled() {
+			return false
+		}
+		u.Disabled = nil
+		u.DisabledReason = ""
+	case BulkActionDisable:
+		if u.IsDisabled() {
+			return false
+		}
+		u.Disabled = &now
+		u.DisabledReason = r.Reason
+		if u.DisabledReason == "" {
+			u.DisabledReason = DisabledReasonAdmin
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
+// MatchesSearch returns true if the given fields match all terms of the search expression.
+// Terms are separated by whitespace and matched case-insensitively as substrings. A term of the form
+// "key:value" only matches the field with the given key, other terms match any field.
+// Terms prefixed with "-" must not match. An empty expression matches everything.
+func MatchesSearch(expression string, fields map[string][]string) bool {
+	for _, term := range strings.Fields(strings.ToLower(expression)) {
+		negated := strings.HasPrefix(term, "-") && len(term) > 1
+		if negated {
+			term = term[1:]
+		}
+
+		if matchesSearchTerm(term, fields) == negated {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchesSearchTerm(term string, fields map[string][]string) bool {
+	contains := func(values []string, value string) bool {
+		return slices.ContainsFunc(values, func(v string) bool { return strings.Contains(strings.ToLower(v), value) })
+	}
+
+	if key, value, ok := strings.Cut(term, ":"); ok {
+		if values, known := fields[key]; known {
+			return contains(values, value)
+		}
+	}
+
+	for _, values := range fields {
+		if contains(values, term) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesSearch(t *testing.T) {
+	fields := map[string][]string{
+		"name": {"Laptop Alice"},
+		"user": {"alice"},
+		"tag":  {"contractors", "berlin"},
+		"addr": {"10.0.0.5/32", "fd00::5/128"},
+	}
+
+	assert.True(t, MatchesSearch("", fields))
+	assert.True(t, MatchesSearch("laptop", fields))
+	assert.True(t, MatchesSearch("tag:contract user:alice", fields))
+	assert.True(t, MatchesSearch("addr:10.0.0.", fields))
+	assert.True(t, MatchesSearch("fd00::5", fields), "unknown keys are matched against all fields")
+	assert.True(t, MatchesSearch("-tag:staff", fields))
+
+	assert.False(t, MatchesSearch("laptop bob", fields))
+	assert.False(t, MatchesSearch("name:alice user:bob", fields))
+	assert.False(t, MatchesSearch("-berlin", fields))
+}
+
+func TestPeerSelector_Matches(t *testing.T) {
+	peer := &Peer{
+		Identifier:          "peer1",
+		DisplayName:         "Contractor Laptop",
+		UserIdentifier:      "bob",
+		InterfaceIdentifier: "wg0",
+		Tags:                []string{"contractors"},
+	}
+
+	assert.True(t, PeerSelector{Interface: "wg0", Tag: "contractors"}.Matches(peer))
+	assert.True(t, PeerSelector{Identifiers: []PeerIdentifier{"peer0", "peer1"}}.Matches(peer))
+	assert.True(t, PeerSelector{User: "bob", Search: "laptop"}.Matches(peer))
+
+	assert.False(t, PeerSelector{Interface: "wg1"}.Matches(peer))
+	assert.False(t, PeerSelector{Identifiers: []PeerIdentifier{"peer0"}}.Matches(peer))
+	assert.False(t, PeerSelector{Tag: "staff"}.Matches(peer))
+	assert.False(t, PeerSelector{Interface: "wg0", Search: "desktop"}.Matches(peer))
+}
+
+func TestPeerBulkRequest_Validate(t *testing.T) {
+	assert.NoError(t, PeerBulkRequest{Selector: PeerSelector{Tag: "x"}, Action: BulkActionSetExpiry}.Validate())
+	assert.ErrorIs(t, PeerBulkRequest{Action: BulkActionDelete}.Validate(), ErrInvalidData)
+	assert.ErrorIs(t, PeerBulkRequest{Selector: PeerSelector{Tag: "x"}, Action: "rename"}.Validate(), ErrInvalidData)
+	assert.ErrorIs(t, PeerBulkRequest{Selector: PeerSelector{Tag: "x"}, Action: BulkActionMove}.Validate(),
+		ErrInvalidData)
+	assert.NoError(t, PeerBulkRequest{Selector: PeerSelector{Tag: "x"}, Action: BulkActionMove, Target: "wg1"}.Validate())
+
+	assert.ErrorIs(t, UserBulkRequest{Selector: UserSelector{Search: "x"}, Action: BulkActionSetExpiry}.Validate(),
+		ErrInvalidData)
+	assert.ErrorIs(t, UserBulkRequest{Selector: UserSelector{Search: " "}, Action: BulkActionDisable}.Validate(),
+		ErrInvalidData)
+}
+
+func TestPeerBulkRequest_Apply(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	peer := &Peer{}
+
+	disable := PeerBulkRequest{Action: BulkActionDisable, Reason: "contract ended"}
+	assert.True(t, disable.Apply(peer, now))
+	assert.Equal(t, now, *peer.Disabled)
+	assert.Equal(t, "contract ended", peer.DisabledReason)
+	assert.False(t, disable.Apply(peer, now), "already disabled")
+
+	enable := PeerBulkRequest{Action: BulkActionEnable}
+	assert.True(t, enable.Apply(peer, now))
+	assert.Nil(t, peer.Disabled)
+	assert.Empty(t, peer.DisabledReason)
+
+	assert.True(t, PeerBulkRequest{Action: BulkActionDisable}.Apply(peer, now))
+	assert.Equal(t, DisabledReasonAdmin, peer.DisabledReason)
+
+	expiry := now.AddDate(0, 1, 0)
+	setExpiry := PeerBulkRequest{Action: BulkActionSetExpiry, ExpiresAt: &expiry}
+	assert.True(t, setExpiry.Apply(peer, now))
+	assert.Equal(t, expiry, *peer.ExpiresAt)
+	assert.False(t, setExpiry.Apply(peer, now))
+	assert.True(t, PeerBulkRequest{Action: BulkActionSetExpiry}.Apply(peer, now))
+	assert.Nil(t, peer.ExpiresAt)
+}
+
+func TestBulkResult_Count(t *testing.T) {
+	result := BulkResult{Action: BulkActionEnable}
+	result.Add("a", BulkItemChanged, nil)
+	result.Add("b", BulkItemUnchanged, nil)
+	result.Add("c", BulkItemChanged, assert.AnError)
+
+	assert.Equal(t, 1, result.Count(BulkItemChanged))
+	assert.Equal(t, 1, result.Count(BulkItemFailed))
+	assert.Equal(t, assert.AnError.Error(), result.Items[2].Error)
+}
@@ -0,0 +1,356 @@
+package domain
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// InterfaceBundleVersion is the version of the bundle format that is written by this release.
+const InterfaceBundleVersion = 1
+
+const bundleEncryptionAlgorithm = "scrypt-aes256-gcm"
+
+type BundleFormat string
+
+const (
+	BundleFormatJson BundleFormat = "json"
+	BundleFormatYaml BundleFormat = "yaml"
+)
+
+type BundleConflictStrategy string
+
+const (
+	BundleConflictFail      BundleConflictStrategy = "fail"      // abort the import if a peer or user already exists
+	BundleConflictSkip      BundleConflictStrategy = "skip"      // keep existing peers and users
+	BundleConflictOverwrite BundleConflictStrategy = "overwrite" // replace existing records with the bundle content
+)
+
+// InterfaceBundleContent is the signed content of a bundle: an interface with all its peers and the linked users.
+type InterfaceBundleContent struct {
+	Interface Interface
+	Peers     []Peer
+	Users     []User
+}
+
+// InterfaceBundle is the transport format used to move an interface between WireGuard Portal instances.
+// The content is signed with a shared key, and it may be encrypted with a passphrase.
+type InterfaceBundle struct {
+	Version    int                     `json:"Version"`
+	CreatedAt  time.Time               `json:"CreatedAt"`
+	Origin     string                  `json:"Origin"`    // the instance that created the bundle
+	Interface  InterfaceIdentifier     `json:"Interface"` // informational, the identifier of the bundled interface
+	Signature  string                  `json:"Signature"` // base64 encoded HMAC-SHA256 of the content
+	Content    *InterfaceBundleContent `json:"Content,omitempty"`
+	Encryption *BundleEncryption       `json:"Encryption,omitempty"` // set instead of Content for encrypted bundles
+}
+
+// BundleEncryption contains the encrypted content of a bundle. All values are base64 encoded.
+type BundleEncryption struct {
+	Algorithm string `json:"Algorithm"`
+	Salt      string `json:"Salt"`
+	Nonce     string `json:"Nonce"`
+	Data      string `json:"Data"`
+}
+
+// BundleImportOptions control how conflicts with existing records are resolved during an import.
+type BundleImportOptions struct {
+	Interface    InterfaceIdentifier    // if set, the interface is imported with this identifier
+	ListenPort   int                    // if set, overrides the listen port of the interface
+	Conflicts    BundleConflictStrategy // how existing peers and users are handled, defaults to BundleConflictFail
+	AllowOverlap bool                   // allow networks that overlap with the networks of other interfaces
+	DryRun       bool                   // only check for conflicts, nothing is stored
+}
+
+// Validate checks the import options.
+func (o BundleImportOptions) Validate() error {
+	switch o.Conflicts {
+	case "", BundleConflictFail, BundleConflictSkip, BundleConflictOverwrite:
+	default:
+		return fmt.Errorf("invalid conflict strategy %q: %w", o.Conflicts, ErrInvalidData)
+	}
+
+	if o.ListenPort < 0 || o.ListenPort > 65535 {
+		return fmt.Errorf("invalid listen port %d: %w", o.ListenPort, ErrInvalidData)
+	}
+
+	return nil
+}
+
+// BundleImportResult summarizes an import.
+type BundleImportResult struct {
+	Interface        InterfaceIdentifier
+	DryRun           bool
+	CreatedPeers     []PeerIdentifier
+	OverwrittenPeers []PeerIdentifier
+	SkippedPeers     []PeerIdentifier
+	CreatedUsers     []UserIdentifier
+	OverwrittenUsers []UserIdentifier
+	SkippedUsers     []UserIdentifier
+}
+
+// NewInterfaceBundle creates a signed bundle for the given content. If a passphrase is given, the content is
+// encrypted. Secrets that are only valid on the exporting instance, like passwords and API tokens of users, are
+// removed from the content.
+func NewInterfaceBundle(
+	content InterfaceBundleContent,
+	origin, signingKey, passphrase string,
+) (*InterfaceBundle, error) {
+	if signingKey == "" {
+		return nil, fmt.Errorf("missing bundle signing key: %w", ErrInvalidData)
+	}
+
+	content.Users = slices.Clone(content.Users)
+	for i := range content.Users {
+		content.Users[i].Password = ""
+		content.Users[i].ApiToken = ""
+		content.Users[i].ApiTokenCreated = nil
+		content.Users[i].WebAuthnId = ""
+		content.Users[i].WebAuthnCredentialList = nil
+		content.Users[i].ResetTotp()
+		content.Users[i].LinkedPeerCount = 0
+	}
+
+	plain, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle content: %w", err)
+	}
+
+	bundle := &InterfaceBundle{
+		Version:   InterfaceBundleVersion,
+		CreatedAt: time.Now().UTC(),
+		Origin:    origin,
+		Interface: content.Interface.Identifier,
+		Signature: base64.StdEncoding.EncodeToString(signBundleContent(plain, signingKey)),
+	}
+
+	if passphrase == "" {
+		bundle.Content = &content
+		return bundle, nil
+	}
+
+	bundle.Encryption, err = EncryptBundleData(plain, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
+
+// Open decrypts the bundle content if needed and verifies the signature.
+func (b *InterfaceBundle) Open(signingKey, passphrase string) (*InterfaceBundleContent, error) {
+	if b.Version != InterfaceBundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d: %w", b.Version, ErrInvalidData)
+	}
+	if signingKey == "" {
+		return nil, fmt.Errorf("missing bundle signing key: %w", ErrInvalidData)
+	}
+
+	var content InterfaceBundleContent
+	switch {
+	case b.Encryption != nil:
+		if passphrase == "" {
+			return nil, fmt.Errorf("bundle is encrypted, passphrase required: %w", ErrInvalidData)
+		}
+		plain, err := DecryptBundleData(b.Encryption, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(plain, &content); err != nil {
+			return nil, fmt.Errorf("failed to decode bundle content: %w", err)
+		}
+	case b.Content != nil:
+		content = *b.Content
+	default:
+		return nil, fmt.Errorf("bundle has no content: %w", ErrInvalidData)
+	}
+
+	// the signature covers the canonical JSON encoding, so it does not depend on the file format
+	canonical, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle content: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(b.Signature)
+	if err != nil || !hmac.Equal(signature, signBundleContent(canonical, signingKey)) {
+		return nil, fmt.Errorf("invalid bundle signature: %w", ErrInvalidData)
+	}
+
+	return &content, nil
+}
+
+// MarshalInterfaceBundle encodes the bundle in the given format.
+func MarshalInterfaceBundle(bundle *InterfaceBundle, format BundleFormat) ([]byte, error) {
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle: %w", err)
+	}
+
+	switch format {
+	case "", BundleFormatJson:
+		return data, nil
+	case BundleFormatYaml:
+		// JSON is valid YAML, re-encoding the node tree keeps the field names and the number formats
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, fmt.Errorf("failed to convert bundle: %w", err)
+		}
+		resetYamlStyle(&node)
+		return yaml.Marshal(&node)
+	default:
+		return nil, fmt.Errorf("unsupported bundle format %q: %w", format, ErrInvalidData)
+	}
+}
+
+// UnmarshalInterfaceBundle decodes a bundle in JSON or YAML format.
+func UnmarshalInterfaceBundle(data []byte) (*InterfaceBundle, error) {
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("{")) {
+		var raw any
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("failed to decode bundle: %w", errors.Join(err, ErrInvalidData))
+		}
+		converted, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert bundle: %w", errors.Join(err, ErrInvalidData))
+		}
+		data = converted
+	}
+
+	var bundle InterfaceBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to decode bundle: %w", errors.Join(err, ErrInvalidData))
+	}
+
+	return &bundle, nil
+}
+
+// RenameInterface changes the identifier of the bundled interface and of all its peers.
+func (c *InterfaceBundleContent) RenameInterface(id InterfaceIdentifier) {
+	c.Interface.Identifier = id
+	for i := range c.Peers {
+		c.Peers[i].InterfaceIdentifier = id
+	}
+}
+
+// Networks returns the interface addresses and the peer networks of the bundled interface.
+func (c *InterfaceBundleContent) Networks() []Cidr {
+	networks := slices.Clone(c.Interface.Addresses)
+	peerNetworks, _ := CidrsFromString(c.Interface.PeerDefNetworkStr)
+	return append(networks, peerNetworks...)
+}
+
+// InterfaceNetworks returns the interface addresses and the peer networks of the given interface.
+func InterfaceNetworks(in *Interface) []Cidr {
+	content := InterfaceBundleContent{Interface: *in}
+	return content.Networks()
+}
+
+// OverlappingCidrs returns all networks of a that overlap with at least one network of b.
+func OverlappingCidrs(a, b []Cidr) []Cidr {
+	var overlapping []Cidr
+	for _, x := range a {
+		if slices.ContainsFunc(b, func(y Cidr) bool { return x.Prefix().Masked().Overlaps(y.Prefix().Masked()) }) {
+			overlapping = append(overlapping, x)
+		}
+	}
+	return overlapping
+}
+
+func signBundleContent(content []byte, key string) []byte {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(fmt.Sprintf("wg-portal-bundle-v%d\n", InterfaceBundleVersion)))
+	mac.Write(content)
+	return mac.Sum(nil)
+}
+
+func bundleEncryptionKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+}
+
+// EncryptBundleData encrypts the given data with a key that is derived from the passphrase.
+// It is used for interface bundles and backup archives.
+func EncryptBundleData(plain []byte, passphrase string) (*BundleEncryption, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	gcm, err := newBundleCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return &BundleEncryption{
+		Algorithm: bundleEncryptionAlgorithm,
+		Salt:      base64.StdEncoding.EncodeToString(salt),
+		Nonce:     base64.StdEncoding.EncodeToString(nonce),
+		Data:      base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, plain, nil)),
+	}, nil
+}
+
+// DecryptBundleData decrypts data that was encrypted with EncryptBundleData.
+func DecryptBundleData(enc *BundleEncryption, passphrase string) ([]byte, error) {
+	if enc.Algorithm != bundleEncryptionAlgorithm {
+		return nil, fmt.Errorf("unsupported bundle encryption %q: %w", enc.Algorithm, ErrInvalidData)
+	}
+
+	salt, errSalt := base64.StdEncoding.DecodeString(enc.Salt)
+	nonce, errNonce := base64.StdEncoding.DecodeString(enc.Nonce)
+	data, errData := base64.StdEncoding.DecodeString(enc.Data)
+	if err := errors.Join(errSalt, errNonce, errData); err != nil {
+		return nil, fmt.Errorf("invalid bundle encryption data: %w", errors.Join(err, ErrInvalidData))
+	}
+
+	gcm, err := newBundleCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid bundle nonce: %w", ErrInvalidData)
+	}
+
+	plain, err := gcm.Open(nil, nonce, data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data, wrong passphrase: %w", ErrInvalidData)
+	}
+
+	return plain, nil
+}
+
+func newBundleCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := bundleEncryptionKey(passphrase, salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func resetYamlStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYamlStyle(child)
+	}
+}
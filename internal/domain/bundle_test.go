@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBundleContent(t *testing.T) InterfaceBundleContent {
+	expiresAt := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	return InterfaceBundleContent{
+		Interface: Interface{
+			Identifier:        "wg0",
+			KeyPair:           KeyPair{PrivateKey: "private", PublicKey: "public"},
+			ListenPort:        51820,
+			Addresses:         []Cidr{mustCidr(t, "10.0.0.1/24")},
+			FirewallMark:      4294967295,
+			PeerDefNetworkStr: "10.0.0.0/24",
+			PeerDefDnsStr:     "yes", // a string that YAML would read as bool
+		},
+		Peers: []Peer{
+			{
+				Identifier:          "peer1",
+				InterfaceIdentifier: "wg0",
+				UserIdentifier:      "alice",
+				ExpiresAt:           &expiresAt,
+				Tags:                []string{"staff"},
+				Interface:           PeerInterfaceConfig{Addresses: []Cidr{mustCidr(t, "10.0.0.2/32")}},
+			},
+		},
+		Users: []User{
+			{Identifier: "alice", Email: "alice@example.com", Password: "hash", ApiToken: "token"},
+		},
+	}
+}
+
+func TestInterfaceBundle_RoundTrip(t *testing.T) {
+	for _, format := range []BundleFormat{BundleFormatJson, BundleFormatYaml} {
+		for _, passphrase := range []string{"", "secret passphrase"} {
+			bundle, err := NewInterfaceBundle(testBundleContent(t), "test", "signing-key", passphrase)
+			require.NoError(t, err)
+			assert.Equal(t, passphrase != "", bundle.Encryption != nil)
+
+			data, err := MarshalInterfaceBundle(bundle, format)
+			require.NoError(t, err)
+
+			decoded, err := UnmarshalInterfaceBundle(data)
+			require.NoError(t, err)
+
+			content, err := decoded.Open("signing-key", passphrase)
+			require.NoError(t, err, "format %s, encrypted %t", format, passphrase != "")
+			assert.Equal(t, "private", content.Interface.PrivateKey)
+			assert.Equal(t, uint32(4294967295), content.Interface.FirewallMark)
+			assert.Equal(t, "yes", content.Interface.PeerDefDnsStr)
+			assert.Equal(t, []string{"staff"}, content.Peers[0].Tags)
+			assert.Empty(t, content.Users[0].Password, "passwords are not exported")
+			assert.Empty(t, content.Users[0].ApiToken, "api tokens are not exported")
+		}
+	}
+}
+
+func TestInterfaceBundle_Open(t *testing.T) {
+	bundle, err := NewInterfaceBundle(testBundleContent(t), "test", "signing-key", "")
+	require.NoError(t, err)
+
+	_, err = bundle.Open("other-key", "")
+	assert.ErrorIs(t, err, ErrInvalidData)
+
+	bundle.Content.Interface.ListenPort = 51821
+	_, err = bundle.Open("signing-key", "")
+	assert.ErrorIs(t, err, ErrInvalidData, "modified content")
+
+	encrypted, err := NewInterfaceBundle(testBundleContent(t), "test", "signing-key", "secret")
+	require.NoError(t, err)
+	_, err = encrypted.Open("signing-key", "")
+	assert.ErrorIs(t, err, ErrInvalidData, "missing passphrase")
+	_, err = encrypted.Open("signing-key", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidData, "wrong passphrase")
+
+	encrypted.Version = 2
+	_, err = encrypted.Open("signing-key", "secret")
+	assert.ErrorIs(t, err, ErrInvalidData, "unsupported version")
+}
+
+func TestInterfaceBundleContent_RenameInterface(t *testing.T) {
+	content := testBundleContent(t)
+	content.RenameInterface("wg5")
+
+	assert.Equal(t, InterfaceIdentifier("wg5"), content.Interface.Identifier)
+	assert.Equal(t, InterfaceIdentifier("wg5"), content.Peers[0].InterfaceIdentifier)
+}
+
+func TestOverlappingCidrs(t *testing.T) {
+	a := []Cidr{mustCidr(t, "10.0.0.1/24"), mustCidr(t, "fd00::1/64")}
+	b := []Cidr{mustCidr(t, "10.0.0.0/16"), mustCidr(t, "fd01::/64")}
+
+	assert.Equal(t, []Cidr{a[0]}, OverlappingCidrs(a, b))
+	assert.Empty(t, OverlappingCidrs(a[1:], b))
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"time"
+)
+
+// ConfigFileInterface contains all information of a wg-quick configuration file that can be imported.
+// Unlike PhysicalInterface, it also holds the wg-quick specific settings and the peer private keys, if available.
+type ConfigFileInterface struct {
+	PhysicalInterface // the ImportSource is always "file"
+
+	FileName     string        // the name of the configuration file, for example: wg0.conf
+	DisplayName  string        // parsed from the -WGP- tag, may be empty
+	Type         InterfaceType // parsed from the -WGP- tag or detected from the peer configuration
+	DnsStr       string        // the dns servers, comma separated
+	DnsSearchStr string        // the dns search domains, comma separated
+	RoutingTable string        // the routing table number or "off"
+	PreUp        string
+	PostUp       string
+	PreDown      string
+	PostDown     string
+	SaveConfig   bool
+
+	CreatedAt *time.Time // parsed from the -WGP- tag, may be nil
+	UpdatedAt *time.Time // parsed from the -WGP- tag, may be nil
+
+	Peers []ConfigFilePeer
+}
+
+// ConfigFilePeer contains all information of a [Peer] section of a wg-quick configuration file.
+type ConfigFilePeer struct {
+	PhysicalPeer // the KeyPair also contains the private key, if it was tagged in the configuration file
+
+	DisplayName string // parsed from the -WGP- tag or the friendly_name comment, may be empty
+
+	CreatedAt *time.Time // parsed from the -WGP- tag, may be nil
+	UpdatedAt *time.Time // parsed from the -WGP- tag, may be nil
+}
+
+// ConvertConfigFileInterface converts a parsed configuration file to an interface.
+// Peer defaults are derived the same way as for interfaces imported from the kernel.
+func ConvertConfigFileInterface(ci *ConfigFileInterface) *Interface {
+	iface := ConvertPhysicalInterface(&ci.PhysicalInterface)
+
+	iface.DnsStr = ci.DnsStr
+	iface.DnsSearchStr = ci.DnsSearchStr
+	iface.RoutingTable = ci.RoutingTable
+	iface.PreUp = ci.PreUp
+	iface.PostUp = ci.PostUp
+	iface.PreDown = ci.PreDown
+	iface.PostDown = ci.PostDown
+	iface.SaveConfig = ci.SaveConfig
+
+	if ci.DisplayName != "" {
+		iface.DisplayName = ci.DisplayName
+	}
+	if ci.Type != "" {
+		iface.Type = ci.Type
+	}
+
+	iface.PeerDefAllowedIPsStr = iface.AddressStr()
+	iface.PeerDefRoutingTable = ci.RoutingTable
+	if iface.Type == InterfaceTypeServer {
+		iface.PeerDefNetworkStr = CidrsToString(networkAddresses(ci.Addresses))
+	}
+
+	return iface
+}
+
+// SplitAllowedIPs splits the allowed IPs of a peer into addresses that belong to one of the given
+// interface networks and additional (extra) allowed IPs.
+func (p ConfigFilePeer) SplitAllowedIPs(interfaceAddresses []Cidr) (addresses, extra []Cidr) {
+	return SplitAllowedIPs(p.AllowedIPs, interfaceAddresses)
+}
+
+func networkAddresses(cidrs []Cidr) []Cidr {
+	networks := make([]Cidr, len(cidrs))
+	for i, cidr := range cidrs {
+		networks[i] = cidr.NetworkAddr()
+	}
+	return networks
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigFilePeer_SplitAllowedIPs(t *testing.T) {
+	ifaceAddresses, _ := CidrsFromString("10.0.0.1/24,fd00::1/64")
+	allowedIPs, _ := CidrsFromString("10.0.0.2/32,fd00::2/128,192.168.1.0/24,10.0.0.0/16,0.0.0.0/0")
+	peer := ConfigFilePeer{PhysicalPeer: PhysicalPeer{AllowedIPs: allowedIPs}}
+
+	addresses, extra := peer.SplitAllowedIPs(ifaceAddresses)
+
+	assert.Equal(t, "10.0.0.2/32,fd00::2/128", CidrsToString(addresses))
+	assert.Equal(t, "192.168.1.0/24,10.0.0.0/16,0.0.0.0/0", CidrsToString(extra))
+}
+
+func TestConvertConfigFileInterface(t *testing.T) {
+	addresses, _ := CidrsFromString("10.0.0.1/24")
+	ci := &ConfigFileInterface{
+		PhysicalInterface: PhysicalInterface{
+			Identifier: "wg0",
+			Addresses:  addresses,
+			ListenPort: 51820,
+		},
+		DisplayName:  "Office",
+		Type:         InterfaceTypeServer,
+		DnsStr:       "1.1.1.1",
+		RoutingTable: "off",
+		PostUp:       "echo up",
+	}
+
+	iface := ConvertConfigFileInterface(ci)
+
+	assert.Equal(t, InterfaceIdentifier("wg0"), iface.Identifier)
+	assert.Equal(t, "Office", iface.DisplayName)
+	assert.Equal(t, InterfaceTypeServer, iface.Type)
+	assert.Equal(t, "1.1.1.1", iface.DnsStr)
+	assert.Equal(t, "echo up", iface.PostUp)
+	assert.Equal(t, "10.0.0.1/24", iface.PeerDefAllowedIPsStr)
+	assert.Equal(t, "10.0.0.0/24", iface.PeerDefNetworkStr)
+	assert.Equal(t, "off", iface.PeerDefRoutingTable)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strings"
 )
 
 const CtxUserInfo = "userInfo"
@@ -14,11 +15,15 @@ const (
 	CtxSystemLdapSyncer = "_WG_SYS_LDAP_SYNCER_"
 	CtxSystemWgImporter = "_WG_SYS_WG_IMPORTER_"
 	CtxSystemV1Migrator = "_WG_SYS_V1_MIGRATOR_"
+	CtxSystemSpec       = "_WG_SYS_SPEC_"
+
+	CtxSystemLoginProtection = "_WG_SYS_LOGIN_PROTECTION_"
 )
 
 type ContextUserInfo struct {
 	Id      UserIdentifier
 	IsAdmin bool
+	Roles   []Role
 }
 
 func (u *ContextUserInfo) String() string {
@@ -29,6 +34,21 @@ func (u *ContextUserInfo) UserId() string {
 	return string(u.Id)
 }
 
+// HasPermission checks if the user has the given permission for the interface. Admins have all permissions.
+// If no interface is given, the permission must be granted for at least one interface.
+func (u *ContextUserInfo) HasPermission(permission Permission, iface InterfaceIdentifier) bool {
+	if u.IsAdmin {
+		return true
+	}
+
+	return RolesHavePermission(u.Roles, permission, iface)
+}
+
+// IsSystem returns true if the context belongs to an internal service, like the system admin or the LDAP syncer.
+func (u *ContextUserInfo) IsSystem() bool {
+	return u.IsAdmin && strings.HasPrefix(string(u.Id), "_WG_SYS_")
+}
+
 // DefaultContextUserInfo returns a default context user info.
 func DefaultContextUserInfo() *ContextUserInfo {
 	return &ContextUserInfo{
@@ -53,6 +73,14 @@ func LdapSyncContextUserInfo() *ContextUserInfo {
 	}
 }
 
+// SpecContextUserInfo returns a context user info for the spec reconciler.
+func SpecContextUserInfo() *ContextUserInfo {
+	return &ContextUserInfo{
+		Id:      CtxSystemSpec,
+		IsAdmin: true,
+	}
+}
+
 // SetUserInfo sets the user info in the context.
 func SetUserInfo(ctx context.Context, info *ContextUserInfo) context.Context {
 	ctx = context.WithValue(ctx, CtxUserInfo, info)
@@ -106,3 +134,51 @@ func ValidateAdminAccessRights(ctx context.Context) error {
 		"stack", GetStackTrace())
 	return ErrNoPermission
 }
+
+// ValidatePermission checks if the current user has the given permission for the interface.
+// If no interface is given, the permission must be granted for at least one interface.
+func ValidatePermission(ctx context.Context, permission Permission, iface InterfaceIdentifier) error {
+	sessionUser := GetUserInfo(ctx)
+
+	if sessionUser.HasPermission(permission, iface) {
+		return nil
+	}
+
+	slog.Warn("insufficient permissions",
+		"user", sessionUser.Id,
+		"permission", permission,
+		"interface", iface,
+		"stack", GetStackTrace())
+	return ErrNoPermission
+}
+
+// ValidateUserOrPermission checks if the current user is the requested user or has the given permission
+// for the interface. It is used for objects that belong to a user, like peers.
+func ValidateUserOrPermission(
+	ctx context.Context,
+	requiredUser UserIdentifier,
+	permission Permission,
+	iface InterfaceIdentifier,
+) error {
+	sessionUser := GetUserInfo(ctx)
+
+	if sessionUser.Id == requiredUser {
+		return nil // User can access own data
+	}
+
+	return ValidatePermission(ctx, permission, iface)
+}
+
+// ValidateManagedAccess checks if the current user can modify an object with the given manager.
+// Managed objects can only be changed by internal services, users and API clients only have read access.
+func ValidateManagedAccess(ctx context.Context, managedBy string) error {
+	if managedBy == "" {
+		return nil
+	}
+
+	if GetUserInfo(ctx).IsSystem() {
+		return nil
+	}
+
+	return fmt.Errorf("object is managed by %s and read-only: %w", managedBy, ErrNoPermission)
+}
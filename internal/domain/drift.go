@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type DriftMode string
+
+const (
+	DriftModeAlert   DriftMode = "alert"   // drift is only reported
+	DriftModeCorrect DriftMode = "correct" // the database wins, the physical state is restored
+	DriftModeImport  DriftMode = "import"  // the physical state wins, the database is updated
+)
+
+type DriftType string
+
+const (
+	DriftTypeInterfaceMissing  DriftType = "interface-missing"  // the interface does not exist on the system
+	DriftTypeInterfaceMismatch DriftType = "interface-mismatch" // an interface setting differs
+	DriftTypePeerMissing       DriftType = "peer-missing"       // an enabled peer does not exist on the system
+	DriftTypePeerUnexpected    DriftType = "peer-unexpected"    // a peer exists on the system, but is unknown or disabled
+	DriftTypePeerMismatch      DriftType = "peer-mismatch"      // a peer setting differs
+)
+
+// DriftItem describes a single difference between the database and the physical WireGuard state.
+type DriftItem struct {
+	Type           DriftType
+	PeerIdentifier PeerIdentifier // empty for interface related drift
+	Field          string         // the name of the differing setting, only set for mismatches
+	Expected       string         // the value stored in the database
+	Actual         string         // the value found on the system
+}
+
+// InterfaceDrift contains all differences between the database and the physical state of a single interface.
+type InterfaceDrift struct {
+	InterfaceIdentifier InterfaceIdentifier
+	Mode                DriftMode
+	CheckedAt           time.Time
+	Items               []DriftItem
+	Resolved            bool   // true if the drift was corrected or imported
+	Error               string // set if the drift could not be resolved
+}
+
+// HasDrift returns true if the database and the physical state differ.
+func (d InterfaceDrift) HasDrift() bool {
+	return len(d.Items) > 0
+}
+
+// CompareInterfaceState compares the stored interface with the physical interface.
+// Settings that are not managed by WireGuard Portal (for example an unset firewall mark) are ignored.
+func CompareInterfaceState(iface *Interface, pi *PhysicalInterface) []DriftItem {
+	var items []DriftItem
+	mismatch := func(field, expected, actual string) {
+		if expected != actual {
+			items = append(items, DriftItem{
+				Type:     DriftTypeInterfaceMismatch,
+				Field:    field,
+				Expected: expected,
+				Actual:   actual,
+			})
+		}
+	}
+
+	mismatch("PublicKey", iface.PublicKey, pi.PublicKey)
+	mismatch("ListenPort", strconv.Itoa(iface.ListenPort), strconv.Itoa(pi.ListenPort))
+	if iface.Mtu != 0 {
+		mismatch("Mtu", strconv.Itoa(iface.Mtu), strconv.Itoa(pi.Mtu))
+	}
+	if iface.FirewallMark != 0 {
+		mismatch("FirewallMark",
+			strconv.FormatUint(uint64(iface.FirewallMark), 10), strconv.FormatUint(uint64(pi.FirewallMark), 10))
+	}
+	mismatch("Addresses", sortedCidrString(iface.Addresses, false), sortedCidrString(pi.ManagedAddresses(), false))
+
+	return items
+}
+
+// ComparePeerState compares the stored peer with the physical peer.
+// The endpoint is not compared, as it is updated by WireGuard if the remote address changes.
+func ComparePeerState(peer *Peer, pp *PhysicalPeer) []DriftItem {
+	expected := PhysicalPeer{}
+	MergeToPhysicalPeer(&expected, peer)
+
+	var items []DriftItem
+	mismatch := func(field, expected, actual string) {
+		if expected != actual {
+			items = append(items, DriftItem{
+				Type:           DriftTypePeerMismatch,
+				PeerIdentifier: peer.Identifier,
+				Field:          field,
+				Expected:       expected,
+				Actual:         actual,
+			})
+		}
+	}
+
+	mismatch("AllowedIPs", sortedCidrString(expected.AllowedIPs, true), sortedCidrString(pp.AllowedIPs, true))
+	mismatch("PersistentKeepalive",
+		strconv.Itoa(expected.PersistentKeepalive), strconv.Itoa(pp.PersistentKeepalive))
+	if expected.PresharedKey != pp.PresharedKey {
+		// do not leak the preshared key
+		items = append(items, DriftItem{
+			Type:           DriftTypePeerMismatch,
+			PeerIdentifier: peer.Identifier,
+			Field:          "PresharedKey",
+		})
+	}
+
+	return items
+}
+
+// ManagedAddresses returns all addresses of the physical interface, except link-local addresses which are
+// assigned by the system.
+func (p *PhysicalInterface) ManagedAddresses() []Cidr {
+	addresses := make([]Cidr, 0, len(p.Addresses))
+	for _, addr := range p.Addresses {
+		if addr.Prefix().Addr().IsLinkLocalUnicast() {
+			continue
+		}
+		addresses = append(addresses, addr)
+	}
+	return addresses
+}
+
+// MergeFromPhysicalPeer updates the peer with the settings of the physical peer.
+// It is the counterpart of MergeToPhysicalPeer.
+func MergeFromPhysicalPeer(p *Peer, pp *PhysicalPeer, interfaceAddresses []Cidr) {
+	if p.Interface.Type == InterfaceTypeServer {
+		p.AllowedIPsStr.SetValue(CidrsToString(pp.AllowedIPs))
+		p.ExtraAllowedIPsStr = ""
+	} else {
+		addresses, extraAllowedIPs := SplitAllowedIPs(pp.AllowedIPs, interfaceAddresses)
+		p.Interface.Addresses = addresses
+		p.ExtraAllowedIPsStr = CidrsToString(extraAllowedIPs)
+	}
+	p.PresharedKey = pp.PresharedKey
+	p.PersistentKeepalive.SetValue(pp.PersistentKeepalive)
+}
+
+// sortedCidrString returns a sorted, comma separated list of the given cidrs.
+// If masked is true, the network addresses are used, as WireGuard does for allowed IPs.
+func sortedCidrString(cidrs []Cidr, masked bool) string {
+	cidrStrings := make([]string, len(cidrs))
+	for i, cidr := range cidrs {
+		if masked {
+			cidr = cidr.NetworkAddr()
+		}
+		cidrStrings[i] = cidr.String()
+	}
+	slices.Sort(cidrStrings)
+	return strings.Join(cidrStrings, ",")
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterface_GetDriftMode(t *testing.T) {
+	iface := &Interface{}
+	assert.Equal(t, DriftModeAlert, iface.GetDriftMode())
+
+	iface.DriftMode = DriftModeImport
+	assert.Equal(t, DriftModeImport, iface.GetDriftMode())
+
+	iface.DriftMode = "invalid"
+	assert.Equal(t, DriftModeAlert, iface.GetDriftMode())
+}
+
+func TestCompareInterfaceState(t *testing.T) {
+	addresses, _ := CidrsFromString("10.0.0.1/24,fd00::1/64")
+	iface := &Interface{
+		KeyPair:    KeyPair{PublicKey: "pub"},
+		ListenPort: 51820,
+		Addresses:  addresses,
+	}
+
+	physicalAddresses, _ := CidrsFromString("fd00::1/64,10.0.0.1/24,fe80::1/64")
+	pi := &PhysicalInterface{
+		KeyPair:      KeyPair{PublicKey: "pub"},
+		ListenPort:   51820,
+		Mtu:          1420,
+		FirewallMark: 100,
+		Addresses:    physicalAddresses,
+	}
+	assert.Empty(t, CompareInterfaceState(iface, pi), "order and link-local addresses are ignored")
+
+	pi.ListenPort = 51821
+	items := CompareInterfaceState(iface, pi)
+	require.Len(t, items, 1)
+	assert.Equal(t, DriftTypeInterfaceMismatch, items[0].Type)
+	assert.Equal(t, "ListenPort", items[0].Field)
+	assert.Equal(t, "51820", items[0].Expected)
+	assert.Equal(t, "51821", items[0].Actual)
+}
+
+func TestComparePeerState(t *testing.T) {
+	addresses, _ := CidrsFromString("10.0.0.2/32")
+	peer := &Peer{
+		Identifier:          "peer",
+		ExtraAllowedIPsStr:  "192.168.1.0/24",
+		PresharedKey:        "psk",
+		PersistentKeepalive: NewConfigOption(25, true),
+		Interface: PeerInterfaceConfig{
+			Type:      InterfaceTypeClient,
+			Addresses: addresses,
+		},
+	}
+
+	allowedIPs, _ := CidrsFromString("192.168.1.0/24,10.0.0.2/32")
+	pp := &PhysicalPeer{
+		AllowedIPs:          allowedIPs,
+		PresharedKey:        "psk",
+		PersistentKeepalive: 25,
+	}
+	assert.Empty(t, ComparePeerState(peer, pp))
+
+	pp.PresharedKey = "other"
+	pp.AllowedIPs = allowedIPs[:1]
+	items := ComparePeerState(peer, pp)
+	require.Len(t, items, 2)
+	assert.Equal(t, "AllowedIPs", items[0].Field)
+	assert.Equal(t, "PresharedKey", items[1].Field)
+	assert.Empty(t, items[1].Actual, "preshared key must not be exposed")
+}
+
+func TestMergeFromPhysicalPeer(t *testing.T) {
+	ifaceAddresses, _ := CidrsFromString("10.0.0.1/24")
+	allowedIPs, _ := CidrsFromString("10.0.0.5/32,192.168.1.0/24")
+	pp := &PhysicalPeer{
+		AllowedIPs:          allowedIPs,
+		PresharedKey:        "psk",
+		PersistentKeepalive: 15,
+	}
+
+	peer := &Peer{Interface: PeerInterfaceConfig{Type: InterfaceTypeClient}}
+	MergeFromPhysicalPeer(peer, pp, ifaceAddresses)
+	assert.Equal(t, "10.0.0.5/32", CidrsToString(peer.Interface.Addresses))
+	assert.Equal(t, "192.168.1.0/24", peer.ExtraAllowedIPsStr)
+	assert.Equal(t, PreSharedKey("psk"), peer.PresharedKey)
+	assert.Equal(t, 15, peer.PersistentKeepalive.GetValue())
+	assert.Empty(t, ComparePeerState(peer, pp))
+
+	peer = &Peer{Interface: PeerInterfaceConfig{Type: InterfaceTypeServer}}
+	MergeFromPhysicalPeer(peer, pp, ifaceAddresses)
+	assert.Equal(t, "10.0.0.5/32,192.168.1.0/24", peer.AllowedIPsStr.GetValue())
+	assert.Empty(t, ComparePeerState(peer, pp))
+}
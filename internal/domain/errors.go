@@ -10,6 +10,16 @@ var ErrNotUnique = errors.New("record not unique")
 var ErrNoPermission = errors.New("no permission")
 var ErrDuplicateEntry = errors.New("duplicate entry")
 var ErrInvalidData = errors.New("invalid data")
+var ErrShareLinkInvalid = errors.New("share link invalid")
+var ErrShareLinkExpired = errors.New("share link expired")
+var ErrShareLinkRevoked = errors.New("share link revoked")
+var ErrShareLinkExhausted = errors.New("share link usage limit reached")
+var ErrApiTokenInvalid = errors.New("api token invalid")
+var ErrApiTokenExpired = errors.New("api token expired")
+var ErrApiTokenSourceDenied = errors.New("api token not allowed from source address")
+var ErrLoginThrottled = errors.New("too many failed login attempts")
+var ErrSecondFactorInvalid = errors.New("invalid second factor")
+var ErrAgentNotConnected = errors.New("agent not connected")
 
 // GetStackTrace returns a stack trace of the current goroutine. The stack trace has at most 1024 bytes.
 func GetStackTrace() string {
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+type ExpiryNotificationType string
+
+const (
+	ExpiryNotificationReminder ExpiryNotificationType = "reminder" // sent before the peer expires
+	ExpiryNotificationExpired  ExpiryNotificationType = "expired"  // sent once the peer has been disabled
+)
+
+// PeerExpiryNotification records a notification that was sent for the expiry of a peer.
+// Notifications are bound to the expiry date, so changing the expiry date of a peer re-arms all reminders.
+type PeerExpiryNotification struct {
+	PeerId     PeerIdentifier         `gorm:"primaryKey"`
+	ExpiresAt  time.Time              `gorm:"primaryKey"`
+	Type       ExpiryNotificationType `gorm:"primaryKey"`
+	DaysBefore int                    `gorm:"primaryKey"` // the reminder schedule entry, 0 for expired notifications
+
+	SentAt time.Time
+}
+
+// DueExpiryReminder returns the most urgent entry of the reminder schedule (in days before expiry) that is due
+// at the given time. If no reminder is due, false is returned.
+func DueExpiryReminder(expiresAt, now time.Time, schedule []int) (int, bool) {
+	if !now.Before(expiresAt) {
+		return 0, false // already expired
+	}
+
+	due := 0
+	for _, days := range schedule {
+		if days <= 0 {
+			continue
+		}
+		if now.Before(expiresAt.AddDate(0, 0, -days)) {
+			continue // not yet due
+		}
+		if due == 0 || days < due {
+			due = days
+		}
+	}
+
+	return due, due != 0
+}
+
+// IsExpiryReminderSent returns true if a reminder for the given schedule entry or a more urgent one
+// has already been sent.
+func IsExpiryReminderSent(sent []PeerExpiryNotification, days int) bool {
+	return slices.ContainsFunc(sent, func(n PeerExpiryNotification) bool {
+		return n.Type == ExpiryNotificationReminder && n.DaysBefore <= days
+	})
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDueExpiryReminder(t *testing.T) {
+	expiresAt := time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC)
+	schedule := []int{14, 3, 1}
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		wantDay int
+		wantOk  bool
+	}{
+		{"too early", time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), 0, false},
+		{"first reminder", time.Date(2025, 3, 6, 0, 0, 0, 0, time.UTC), 14, true},
+		{"between reminders", time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), 14, true},
+		{"second reminder", time.Date(2025, 3, 17, 12, 0, 0, 0, time.UTC), 3, true},
+		{"last reminder", time.Date(2025, 3, 19, 0, 0, 0, 0, time.UTC), 1, true},
+		{"expired", time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day, ok := DueExpiryReminder(expiresAt, tt.now, schedule)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantDay, day)
+		})
+	}
+
+	_, ok := DueExpiryReminder(expiresAt, time.Date(2025, 3, 19, 0, 0, 0, 0, time.UTC), nil)
+	assert.False(t, ok, "no reminders without a schedule")
+}
+
+func TestIsExpiryReminderSent(t *testing.T) {
+	sent := []PeerExpiryNotification{
+		{Type: ExpiryNotificationReminder, DaysBefore: 3},
+		{Type: ExpiryNotificationExpired},
+	}
+
+	assert.True(t, IsExpiryReminderSent(sent, 14), "a more urgent reminder was sent")
+	assert.True(t, IsExpiryReminderSent(sent, 3))
+	assert.False(t, IsExpiryReminderSent(sent, 1))
+	assert.False(t, IsExpiryReminderSent(nil, 14))
+}
@@ -19,6 +19,11 @@ const (
 	InterfaceTypeAny    InterfaceType = "any"
 )
 
+const (
+	InterfaceDriverTypeLinux    = "linux"    // the WireGuard kernel module
+	InterfaceDriverTypeSoftware = "software" // the userspace implementation (wireguard-go)
+)
+
 var allowedFileNameRegex = regexp.MustCompile("[^a-zA-Z0-9-_]+")
 
 type InterfaceIdentifier string
@@ -52,8 +57,11 @@ type Interface struct {
 	DisplayName    string        // a nice display name/ description for the interface
 	Type           InterfaceType // the interface type, either InterfaceTypeServer or InterfaceTypeClient
 	DriverType     string        // the interface driver type (linux, software, ...)
+	AgentHost      string        `gorm:"index"` // the name of the agent host that manages the interface, empty for local interfaces
 	Disabled       *time.Time    `gorm:"index"` // flag that specifies if the interface is enabled (up) or not (down)
 	DisabledReason string        // the reason why the interface has been disabled
+	DriftMode      DriftMode     // how differences between database and physical state are handled, defaults to DriftModeAlert
+	ManagedBy      string        `gorm:"column:managed_by"` // set if the interface is managed by the declarative spec, managed interfaces are read-only
 
 	// Default settings for the peer, used for new peers, those settings will be published to ConfigOption options of
 	// the peer config
@@ -72,6 +80,12 @@ type Interface struct {
 	PeerDefPostUp   string // default action that is executed after the device is up
 	PeerDefPreDown  string // default action that is executed before the device is down
 	PeerDefPostDown string // default action that is executed after the device is down
+
+	PeerDefQuota TrafficQuota `gorm:"embedded;embeddedPrefix:peer_def_quota_"` // the traffic quota for peers without a peer or user quota
+
+	PeerRenewalPolicy PeerRenewalPolicy `gorm:"embedded;embeddedPrefix:peer_renewal_"` // defines how users can renew their peers
+
+	PeerDefIpv6 PeerIpv6Settings `gorm:"embedded;embeddedPrefix:peer_def_ipv6_"` // IPv6 address and prefix assignment
 }
 
 // PublicInfo returns a copy of the interface with only the public information.
@@ -112,6 +126,26 @@ func (i *Interface) IsDisabled() bool {
 	return i.Disabled != nil
 }
 
+// GetDriftMode returns the drift mode of the interface. If no mode is set, drift is only reported.
+func (i *Interface) GetDriftMode() DriftMode {
+	switch i.DriftMode {
+	case DriftModeCorrect, DriftModeImport:
+		return i.DriftMode
+	default:
+		return DriftModeAlert
+	}
+}
+
+// IsSoftwareDriver returns true if the interface is managed by the userspace WireGuard implementation.
+func (i *Interface) IsSoftwareDriver() bool {
+	return i.DriverType == InterfaceDriverTypeSoftware
+}
+
+// IsRemote returns true if the interface is managed by an agent on a remote host.
+func (i *Interface) IsRemote() bool {
+	return i.AgentHost != ""
+}
+
 func (i *Interface) AddressStr() string {
 	return CidrsToString(i.Addresses)
 }
@@ -224,7 +258,7 @@ func ConvertPhysicalInterface(pi *PhysicalInterface) *Interface {
 		SaveConfig:                 false,
 		DisplayName:                string(pi.Identifier),
 		Type:                       InterfaceTypeAny,
-		DriverType:                 pi.DeviceType,
+		DriverType:                 driverTypeFromDeviceType(pi.DeviceType),
 		Disabled:                   nil,
 		PeerDefNetworkStr:          "",
 		PeerDefDnsStr:              "",
@@ -244,6 +278,14 @@ func ConvertPhysicalInterface(pi *PhysicalInterface) *Interface {
 	return iface
 }
 
+// driverTypeFromDeviceType maps the device type reported by wgctrl to the interface driver type.
+func driverTypeFromDeviceType(deviceType string) string {
+	if deviceType == "userspace" {
+		return InterfaceDriverTypeSoftware
+	}
+	return InterfaceDriverTypeLinux
+}
+
 func MergeToPhysicalInterface(pi *PhysicalInterface, i *Interface) {
 	pi.Identifier = i.Identifier
 	pi.PublicKey = i.PublicKey
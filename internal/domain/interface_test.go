@@ -81,3 +81,22 @@ func TestInterface_GetRoutingTableReturnsCorrectValue(t *testing.T) {
 	iface.RoutingTable = "200"
 	assert.Equal(t, 200, iface.GetRoutingTable())
 }
+
+func TestInterface_IsSoftwareDriver(t *testing.T) {
+	iface := &Interface{}
+	assert.False(t, iface.IsSoftwareDriver())
+
+	iface.DriverType = InterfaceDriverTypeLinux
+	assert.False(t, iface.IsSoftwareDriver())
+
+	iface.DriverType = InterfaceDriverTypeSoftware
+	assert.True(t, iface.IsSoftwareDriver())
+}
+
+func TestConvertPhysicalInterface_DriverType(t *testing.T) {
+	iface := ConvertPhysicalInterface(&PhysicalInterface{Identifier: "wg0", DeviceType: "userspace"})
+	assert.Equal(t, InterfaceDriverTypeSoftware, iface.DriverType)
+
+	iface = ConvertPhysicalInterface(&PhysicalInterface{Identifier: "wg0", DeviceType: "Linux kernel"})
+	assert.Equal(t, InterfaceDriverTypeLinux, iface.DriverType)
+}
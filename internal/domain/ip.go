@@ -199,3 +199,31 @@ func (c Cidr) Contains(other Cidr) bool {
 
 	return subnet.Contains(otherIP)
 }
+
+// SplitAllowedIPs splits the given allowed IPs into addresses that belong to one of the given
+// interface networks and additional (extra) allowed IPs.
+func SplitAllowedIPs(allowedIPs, interfaceAddresses []Cidr) (addresses, extra []Cidr) {
+	for _, allowedIP := range allowedIPs {
+		isPeerAddress := false
+		for _, ifaceAddress := range interfaceAddresses {
+			if ifaceAddress.IsV4() != allowedIP.IsV4() {
+				continue
+			}
+			if allowedIP.NetLength < ifaceAddress.NetLength {
+				continue // allowed ip network is larger than the interface network
+			}
+			if ifaceAddress.Contains(allowedIP) {
+				isPeerAddress = true
+				break
+			}
+		}
+
+		if isPeerAddress {
+			addresses = append(addresses, allowedIP)
+		} else {
+			extra = append(extra, allowedIP)
+		}
+	}
+
+	return addresses, extra
+}
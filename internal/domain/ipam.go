@@ -0,0 +1,648 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+type IpRangeIdentifier string
+
+type IpRangeType string
+
+const (
+	IpRangeTypePool      IpRangeType = "pool"      // addresses for new peers are allocated from this range
+	IpRangeTypeExclusion IpRangeType = "exclusion" // addresses of this range are never allocated
+)
+
+type IpAllocationStrategy string
+
+const (
+	IpAllocationSequential IpAllocationStrategy = "sequential" // the lowest free address is used
+	IpAllocationReverse    IpAllocationStrategy = "reverse"    // the highest free address is used
+	IpAllocationRandom     IpAllocationStrategy = "random"     // a random free address is used
+	IpAllocationHash       IpAllocationStrategy = "hash"       // the address is derived from the peer public key
+)
+
+// IpRange is an address range of an interface network that is managed by the IP address management (IPAM).
+// Pools define where addresses for new peers are allocated, exclusions define addresses that are never allocated.
+type IpRange struct {
+	BaseModel
+
+	Identifier          IpRangeIdentifier   `gorm:"primaryKey;column:identifier"`
+	InterfaceIdentifier InterfaceIdentifier `gorm:"index;column:interface_identifier"`
+	Type                IpRangeType         `gorm:"column:type"`
+	Name                string              `gorm:"column:name"`
+	Start               string              `gorm:"column:start_addr"` // the first address of the range
+	End                 string              `gorm:"column:end_addr"`   // the last address of the range
+
+	Strategy IpAllocationStrategy `gorm:"column:strategy"` // only used for pools, defaults to sequential
+	Priority int                  `gorm:"column:priority"` // only used for pools, pools with a lower value are used first
+}
+
+// Validate checks the type, the strategy and the boundaries of the range.
+func (r IpRange) Validate() error {
+	switch r.Type {
+	case IpRangeTypePool, IpRangeTypeExclusion:
+	default:
+		return fmt.Errorf("invalid range type %q: %w", r.Type, ErrInvalidData)
+	}
+
+	switch r.Strategy {
+	case "", IpAllocationSequential, IpAllocationReverse, IpAllocationRandom, IpAllocationHash:
+	default:
+		return fmt.Errorf("invalid allocation strategy %q: %w", r.Strategy, ErrInvalidData)
+	}
+
+	if _, err := r.span(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r IpRange) span() (addrSpan, error) {
+	start, err := netip.ParseAddr(strings.TrimSpace(r.Start))
+	if err != nil {
+		return addrSpan{}, fmt.Errorf("invalid start address %q: %w", r.Start, ErrInvalidData)
+	}
+	end, err := netip.ParseAddr(strings.TrimSpace(r.End))
+	if err != nil {
+		return addrSpan{}, fmt.Errorf("invalid end address %q: %w", r.End, ErrInvalidData)
+	}
+	start, end = start.Unmap(), end.Unmap()
+
+	if start.Is4() != end.Is4() {
+		return addrSpan{}, fmt.Errorf("start and end address must be of the same family: %w", ErrInvalidData)
+	}
+	if end.Less(start) {
+		return addrSpan{}, fmt.Errorf("end address must not be lower than the start address: %w", ErrInvalidData)
+	}
+
+	return addrSpan{start: start, end: end}, nil
+}
+
+// IpReservation reserves a single address of an interface network for a user or a peer.
+// Reserved addresses are only allocated for the owner of the reservation.
+type IpReservation struct {
+	BaseModel
+
+	InterfaceIdentifier InterfaceIdentifier `gorm:"primaryKey;column:interface_identifier"`
+	Address             string              `gorm:"primaryKey;column:address"` // a single host address
+
+	UserIdentifier UserIdentifier `gorm:"index;column:user_identifier"` // the address is offered to new peers of the user
+	PeerIdentifier PeerIdentifier `gorm:"index;column:peer_identifier"` // the address may only be used by this peer
+	Description    string         `gorm:"column:description"`
+}
+
+// Validate checks the address and the owner of the reservation.
+func (r IpReservation) Validate() error {
+	if _, err := netip.ParseAddr(r.Address); err != nil {
+		return fmt.Errorf("invalid reserved address %q: %w", r.Address, ErrInvalidData)
+	}
+	if r.UserIdentifier == "" && r.PeerIdentifier == "" {
+		return fmt.Errorf("reservation requires a user or a peer: %w", ErrInvalidData)
+	}
+
+	return nil
+}
+
+// IsFor returns true if the reservation belongs to the given user or peer.
+func (r IpReservation) IsFor(user UserIdentifier, peer PeerIdentifier) bool {
+	if r.PeerIdentifier != "" && r.PeerIdentifier == peer {
+		return true
+	}
+	if r.UserIdentifier != "" && r.UserIdentifier == user {
+		return true
+	}
+	return false
+}
+
+// ValidateReservedAddresses checks that none of the given addresses is reserved for another user or peer.
+func ValidateReservedAddresses(
+	reservations []IpReservation,
+	addresses []Cidr,
+	user UserIdentifier,
+	peer PeerIdentifier,
+) error {
+	for _, address := range addresses {
+		addr, err := netip.ParseAddr(address.Addr)
+		if err != nil {
+			continue
+		}
+		for _, reservation := range reservations {
+			reserved, err := netip.ParseAddr(reservation.Address)
+			if err != nil || reserved.Unmap() != addr.Unmap() {
+				continue
+			}
+			if !reservation.IsFor(user, peer) {
+				return fmt.Errorf("address %s is reserved: %w", addr, ErrInvalidData)
+			}
+		}
+	}
+
+	return nil
+}
+
+// PeerIpv6Settings define how IPv6 addresses and prefixes are assigned to new peers of an interface.
+type PeerIpv6Settings struct {
+	HashedAddresses       bool   // derive the host part of IPv6 peer addresses from the peer public key
+	DelegatedPrefix       string // the prefix out of which a subnet is delegated to each new peer, empty to disable
+	DelegatedPrefixLength int    // the length of the delegated subnets, e.g. 64
+}
+
+// Validate checks the delegated prefix and the length of the delegated subnets.
+func (s PeerIpv6Settings) Validate() error {
+	if s.DelegatedPrefix == "" {
+		return nil
+	}
+
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(s.DelegatedPrefix))
+	if err != nil || !prefix.Addr().Is6() || prefix.Addr().Is4In6() {
+		return fmt.Errorf("invalid delegated prefix %q: %w", s.DelegatedPrefix, ErrInvalidData)
+	}
+	if s.DelegatedPrefixLength <= prefix.Bits() || s.DelegatedPrefixLength > 128 {
+		return fmt.Errorf("delegated prefix length must be between %d and 128: %w", prefix.Bits()+1,
+			ErrInvalidData)
+	}
+
+	return nil
+}
+
+// DelegatePrefix returns a subnet of the given length out of the delegation prefix for the peer with the given
+// public key. The subnet index is the SHA-256 digest of the key modulo the number of subnets. If the subnet
+// overlaps one of the taken prefixes, the next free subnet is used.
+func DelegatePrefix(delegation Cidr, length int, key PeerIdentifier, taken []Cidr) (Cidr, error) {
+	base := delegation.Prefix().Masked()
+	bitLen := base.Addr().BitLen()
+	if length <= base.Bits() || length > bitLen {
+		return Cidr{}, fmt.Errorf("invalid delegated prefix length %d for %s: %w", length, base, ErrInvalidData)
+	}
+
+	var inside []netip.Prefix
+	for _, t := range taken {
+		if p := t.Prefix().Masked(); p.Overlaps(base) {
+			inside = append(inside, p)
+		}
+	}
+
+	count := new(big.Int).Lsh(big.NewInt(1), uint(length-base.Bits()))
+	step := new(big.Int).Lsh(big.NewInt(1), uint(bitLen-length))
+	baseInt := addrToInt(base.Addr())
+
+	idx := keyDigest(key)
+	idx.Mod(idx, count)
+	for checked := new(big.Int); checked.Cmp(count) < 0; {
+		start := new(big.Int).Add(baseInt, new(big.Int).Mul(idx, step))
+		candidate := netip.PrefixFrom(intToAddr(start, base.Addr().Is4()), length)
+
+		skip := big.NewInt(1)
+		free := true
+		for _, t := range inside {
+			if !t.Overlaps(candidate) {
+				continue
+			}
+			free = false
+			if t.Bits() < length { // the taken prefix covers several subnets, continue after its last address
+				end := new(big.Int).Lsh(big.NewInt(1), uint(bitLen-t.Bits()))
+				end.Add(end, addrToInt(t.Addr()))
+				skip = end.Sub(end, start).Div(end, step)
+			}
+			break
+		}
+		if free {
+			return CidrFromPrefix(candidate), nil
+		}
+
+		idx.Add(idx, skip).Mod(idx, count)
+		checked.Add(checked, skip)
+	}
+
+	return Cidr{}, fmt.Errorf("prefix space of %s is exhausted", base)
+}
+
+// keyDigest returns the SHA-256 digest of the given public key as integer.
+func keyDigest(key PeerIdentifier) *big.Int {
+	digest := sha256.Sum256([]byte(key))
+	return new(big.Int).SetBytes(digest[:])
+}
+
+// IpPoolUtilization describes the usage of a single address pool.
+type IpPoolUtilization struct {
+	Network string            // the interface network of the pool
+	Pool    IpRangeIdentifier // empty for the default pool that spans the whole network
+	Name    string
+	Start   string
+	End     string
+
+	Size     uint64 // number of addresses in the pool, capped at the maximum uint64 value
+	Excluded uint64 // addresses that are covered by exclusion ranges
+	Used     uint64 // addresses that are assigned to peers or interfaces
+	Reserved uint64 // reserved addresses that are not assigned yet
+	Free     uint64 // addresses that can still be allocated
+}
+
+// Usage returns the ratio of used and reserved addresses to all allocatable addresses of the pool.
+func (u IpPoolUtilization) Usage() float64 {
+	if u.Size <= u.Excluded {
+		return 0
+	}
+	return float64(u.Used+u.Reserved) / float64(u.Size-u.Excluded)
+}
+
+// IpAllocator allocates free addresses for new peers from the pools of an interface.
+// Addresses that are in use, covered by an exclusion range or reserved for someone else are skipped.
+// The allocator is not safe for concurrent use.
+type IpAllocator struct {
+	pools        []IpRange
+	exclusions   []addrSpan // sorted and merged
+	reservations map[netip.Addr]IpReservation
+	reserved     []netip.Addr // sorted reserved addresses
+	used         map[netip.Addr]struct{}
+	v6Strategy   IpAllocationStrategy // strategy of the default pool of IPv6 networks
+}
+
+// NewIpAllocator creates a new allocator. Invalid ranges and reservations are ignored.
+func NewIpAllocator(ranges []IpRange, reservations []IpReservation, used []Cidr) *IpAllocator {
+	a := &IpAllocator{
+		reservations: make(map[netip.Addr]IpReservation, len(reservations)),
+		used:         make(map[netip.Addr]struct{}, len(used)),
+	}
+
+	for _, r := range ranges {
+		if r.Validate() != nil {
+			continue
+		}
+		switch r.Type {
+		case IpRangeTypePool:
+			a.pools = append(a.pools, r)
+		case IpRangeTypeExclusion:
+			span, _ := r.span()
+			a.exclusions = append(a.exclusions, span)
+		}
+	}
+	slices.SortStableFunc(a.pools, func(x, y IpRange) int {
+		return x.Priority - y.Priority
+	})
+	a.exclusions = mergeSpans(a.exclusions)
+
+	for _, r := range reservations {
+		addr, err := netip.ParseAddr(r.Address)
+		if err != nil {
+			continue
+		}
+		addr = addr.Unmap()
+		a.reservations[addr] = r
+		a.reserved = append(a.reserved, addr)
+	}
+	slices.SortFunc(a.reserved, netip.Addr.Compare)
+
+	for _, cidr := range used {
+		if addr, err := netip.ParseAddr(cidr.Addr); err == nil {
+			a.used[addr.Unmap()] = struct{}{}
+		}
+	}
+
+	return a
+}
+
+// SetIpv6Strategy sets the allocation strategy of the default pool of IPv6 networks. Explicitly defined pools
+// keep their own strategy.
+func (a *IpAllocator) SetIpv6Strategy(strategy IpAllocationStrategy) {
+	a.v6Strategy = strategy
+}
+
+// Allocate returns a free host address of the given network for a new peer of the given user.
+// Addresses that are reserved for the user or the peer are preferred. The allocated address is marked as used,
+// so subsequent calls return different addresses. Pools with the hash strategy derive the address from the
+// peer identifier, which is the public key of the peer.
+func (a *IpAllocator) Allocate(network Cidr, user UserIdentifier, peer PeerIdentifier) (Cidr, error) {
+	prefix := network.Prefix().Masked()
+
+	for _, addr := range a.reserved {
+		if !prefix.Contains(addr) || a.isUsed(addr) {
+			continue
+		}
+		if a.reservations[addr].IsFor(user, peer) {
+			return a.take(addr), nil
+		}
+	}
+
+	for _, pool := range a.poolsOf(network) {
+		addr, ok := a.find(pool, user, peer)
+		if ok {
+			return a.take(addr), nil
+		}
+	}
+
+	return Cidr{}, fmt.Errorf("ip space on subnet %s is exhausted", network.String())
+}
+
+// Utilization returns the usage of all pools of the given network.
+func (a *IpAllocator) Utilization(network Cidr) []IpPoolUtilization {
+	pools := a.poolsOf(network)
+	result := make([]IpPoolUtilization, 0, len(pools))
+
+	for _, pool := range pools {
+		u := IpPoolUtilization{
+			Network: network.Prefix().Masked().String(),
+			Pool:    pool.id,
+			Name:    pool.name,
+			Start:   pool.start.String(),
+			End:     pool.end.String(),
+			Size:    pool.size(),
+		}
+
+		for _, exclusion := range a.exclusions {
+			if overlap, ok := pool.intersect(exclusion); ok {
+				u.Excluded = saturatingAdd(u.Excluded, overlap.size())
+			}
+		}
+		for addr := range a.used {
+			if pool.contains(addr) && !a.isExcluded(addr) {
+				u.Used++
+			}
+		}
+		for _, addr := range a.reserved {
+			if pool.contains(addr) && !a.isExcluded(addr) && !a.isUsed(addr) {
+				u.Reserved++
+			}
+		}
+
+		if taken := saturatingAdd(u.Excluded, saturatingAdd(u.Used, u.Reserved)); taken < u.Size {
+			u.Free = u.Size - taken
+		}
+
+		result = append(result, u)
+	}
+
+	return result
+}
+
+type addrSpan struct {
+	start netip.Addr
+	end   netip.Addr
+}
+
+func (s addrSpan) contains(addr netip.Addr) bool {
+	return s.start.Compare(addr) <= 0 && addr.Compare(s.end) <= 0
+}
+
+func (s addrSpan) intersect(o addrSpan) (addrSpan, bool) {
+	if s.start.Is4() != o.start.Is4() || o.end.Less(s.start) || s.end.Less(o.start) {
+		return addrSpan{}, false
+	}
+
+	result := s
+	if result.start.Less(o.start) {
+		result.start = o.start
+	}
+	if o.end.Less(result.end) {
+		result.end = o.end
+	}
+	return result, true
+}
+
+// size returns the number of addresses in the span, capped at the maximum uint64 value.
+func (s addrSpan) size() uint64 {
+	diff := new(big.Int).Sub(addrToInt(s.end), addrToInt(s.start))
+	if !diff.IsUint64() || diff.Uint64() == math.MaxUint64 {
+		return math.MaxUint64
+	}
+	return diff.Uint64() + 1
+}
+
+// random returns a random address of the span.
+func (s addrSpan) random() netip.Addr {
+	diff := new(big.Int).Sub(addrToInt(s.end), addrToInt(s.start))
+	offset, err := rand.Int(rand.Reader, diff.Add(diff, big.NewInt(1)))
+	if err != nil {
+		return s.start
+	}
+	return intToAddr(offset.Add(offset, addrToInt(s.start)), s.start.Is4())
+}
+
+type ipPool struct {
+	addrSpan
+	network  netip.Prefix
+	id       IpRangeIdentifier
+	name     string
+	strategy IpAllocationStrategy
+}
+
+// poolsOf returns the pools of the given network, limited to the usable addresses of the network.
+// If no pool is defined for the network, a default pool that starts after the network address is returned.
+func (a *IpAllocator) poolsOf(network Cidr) []ipPool {
+	prefix := network.Prefix().Masked()
+	usable := addrSpan{start: prefix.Addr(), end: lastUsableAddr(network)}
+	if network.IsV4() && network.NetLength < 31 {
+		usable.start = usable.start.Next() // the network address is not usable
+	}
+
+	var pools []ipPool
+	for _, r := range a.pools {
+		span, _ := r.span()
+		span, ok := span.intersect(usable)
+		if !ok {
+			continue
+		}
+		pools = append(pools, ipPool{addrSpan: span, network: prefix, id: r.Identifier, name: r.Name,
+			strategy: r.Strategy})
+	}
+	if len(pools) != 0 {
+		return pools
+	}
+
+	start, _ := netip.ParseAddr(network.NextAddr().Addr)
+	if !usable.contains(start) {
+		return nil
+	}
+	strategy := IpAllocationSequential
+	if !network.IsV4() && a.v6Strategy != "" {
+		strategy = a.v6Strategy
+	}
+	return []ipPool{{addrSpan: addrSpan{start: start, end: usable.end}, network: prefix, strategy: strategy}}
+}
+
+func (a *IpAllocator) find(pool ipPool, user UserIdentifier, peer PeerIdentifier) (netip.Addr, bool) {
+	switch pool.strategy {
+	case IpAllocationReverse:
+		return a.scanDown(pool.end, pool.start, user, peer)
+	case IpAllocationRandom:
+		return a.scanFrom(pool, pool.random(), user, peer)
+	case IpAllocationHash:
+		if peer == "" {
+			return a.scanUp(pool.start, pool.end, user, peer)
+		}
+		return a.scanFrom(pool, pool.hashed(peer), user, peer)
+	default:
+		return a.scanUp(pool.start, pool.end, user, peer)
+	}
+}
+
+// scanFrom returns the lowest free address of the pool starting at the given offset, wrapping around at the end
+// of the pool.
+func (a *IpAllocator) scanFrom(
+	pool ipPool,
+	offset netip.Addr,
+	user UserIdentifier,
+	peer PeerIdentifier,
+) (netip.Addr, bool) {
+	if addr, ok := a.scanUp(offset, pool.end, user, peer); ok {
+		return addr, true
+	}
+	if offset == pool.start {
+		return netip.Addr{}, false
+	}
+	return a.scanUp(pool.start, offset.Prev(), user, peer)
+}
+
+// hashed returns the address of the pool that is derived from the given public key. The host bits of the network
+// are taken from the lowest bits of the SHA-256 digest of the key. If this address is not part of the pool,
+// the digest modulo the pool size is used as offset from the start of the pool.
+func (p ipPool) hashed(key PeerIdentifier) netip.Addr {
+	digest := keyDigest(key)
+
+	hostBits := uint(p.network.Addr().BitLen() - p.network.Bits())
+	hostMask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), hostBits), big.NewInt(1))
+	host := new(big.Int).And(digest, hostMask)
+	addr := intToAddr(host.Or(host, addrToInt(p.network.Addr())), p.start.Is4())
+	if p.contains(addr) {
+		return addr
+	}
+
+	size := new(big.Int).Sub(addrToInt(p.end), addrToInt(p.start))
+	offset := digest.Mod(digest, size.Add(size, big.NewInt(1)))
+	return intToAddr(offset.Add(offset, addrToInt(p.start)), p.start.Is4())
+}
+
+// scanUp returns the lowest free address between from and to. Exclusion ranges are skipped at once, so the
+// number of iterations only depends on the number of used and reserved addresses, not on the size of the pool.
+func (a *IpAllocator) scanUp(from, to netip.Addr, user UserIdentifier, peer PeerIdentifier) (netip.Addr, bool) {
+	for addr := from; addr.IsValid() && addr.Compare(to) <= 0; addr = addr.Next() {
+		if exclusion, ok := a.exclusionOf(addr); ok {
+			addr = exclusion.end
+			continue
+		}
+		if a.isFree(addr, user, peer) {
+			return addr, true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// scanDown returns the highest free address between from and to.
+func (a *IpAllocator) scanDown(from, to netip.Addr, user UserIdentifier, peer PeerIdentifier) (netip.Addr, bool) {
+	for addr := from; addr.IsValid() && addr.Compare(to) >= 0; addr = addr.Prev() {
+		if exclusion, ok := a.exclusionOf(addr); ok {
+			addr = exclusion.start
+			continue
+		}
+		if a.isFree(addr, user, peer) {
+			return addr, true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+func (a *IpAllocator) isFree(addr netip.Addr, user UserIdentifier, peer PeerIdentifier) bool {
+	if a.isUsed(addr) {
+		return false
+	}
+	if reservation, ok := a.reservations[addr]; ok && !reservation.IsFor(user, peer) {
+		return false
+	}
+	return true
+}
+
+func (a *IpAllocator) isUsed(addr netip.Addr) bool {
+	_, used := a.used[addr]
+	return used
+}
+
+func (a *IpAllocator) isExcluded(addr netip.Addr) bool {
+	_, excluded := a.exclusionOf(addr)
+	return excluded
+}
+
+func (a *IpAllocator) exclusionOf(addr netip.Addr) (addrSpan, bool) {
+	idx, _ := slices.BinarySearchFunc(a.exclusions, addr, func(s addrSpan, t netip.Addr) int {
+		return s.end.Compare(t)
+	})
+	if idx < len(a.exclusions) && a.exclusions[idx].contains(addr) {
+		return a.exclusions[idx], true
+	}
+	return addrSpan{}, false
+}
+
+func (a *IpAllocator) take(addr netip.Addr) Cidr {
+	a.used[addr] = struct{}{}
+	return CidrFromPrefix(netip.PrefixFrom(addr, addr.BitLen()))
+}
+
+// mergeSpans sorts the given spans and merges overlapping or adjacent spans.
+func mergeSpans(spans []addrSpan) []addrSpan {
+	slices.SortFunc(spans, func(x, y addrSpan) int {
+		if x.start.Is4() != y.start.Is4() {
+			if x.start.Is4() {
+				return -1
+			}
+			return 1
+		}
+		return x.start.Compare(y.start)
+	})
+
+	var merged []addrSpan
+	for _, span := range spans {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			// spans that overlap or touch are merged, the last address of the address space has no successor
+			overlaps := span.start.Compare(last.end) <= 0 || span.start == last.end.Next()
+			if last.start.Is4() == span.start.Is4() && overlaps {
+				if last.end.Less(span.end) {
+					last.end = span.end
+				}
+				continue
+			}
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// lastUsableAddr returns the last address of the network that can be assigned to a host.
+// For IPv4 networks with more than two addresses, the broadcast address is not usable.
+func lastUsableAddr(network Cidr) netip.Addr {
+	last, _ := netip.ParseAddr(network.BroadcastAddr().Addr)
+	if network.IsV4() && network.NetLength < 31 {
+		return last.Prev()
+	}
+	return last
+}
+
+func addrToInt(addr netip.Addr) *big.Int {
+	b := addr.As16()
+	return new(big.Int).SetBytes(b[:])
+}
+
+func intToAddr(i *big.Int, v4 bool) netip.Addr {
+	var b [16]byte
+	i.FillBytes(b[:])
+	addr := netip.AddrFrom16(b)
+	if v4 {
+		return addr.Unmap()
+	}
+	return addr
+}
+
+func saturatingAdd(a, b uint64) uint64 {
+	if a > math.MaxUint64-b {
+		return math.MaxUint64
+	}
+	return a + b
+}
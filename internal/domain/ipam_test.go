@@ -0,0 +1,315 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustCidr(t *testing.T, str string) Cidr {
+	t.Helper()
+	cidr, err := CidrFromString(str)
+	require.NoError(t, err)
+	return cidr
+}
+
+func TestIpRange_Validate(t *testing.T) {
+	assert.NoError(t, IpRange{Type: IpRangeTypePool, Start: "10.0.0.10", End: "10.0.0.20"}.Validate())
+	assert.NoError(t, IpRange{Type: IpRangeTypeExclusion, Start: "fd00::1", End: "fd00::1"}.Validate())
+
+	assert.ErrorIs(t, IpRange{Type: "other", Start: "10.0.0.1", End: "10.0.0.2"}.Validate(), ErrInvalidData)
+	assert.ErrorIs(t, IpRange{Type: IpRangeTypePool, Start: "10.0.0.1", End: "10.0.0.2", Strategy: "best"}.Validate(),
+		ErrInvalidData)
+	assert.ErrorIs(t, IpRange{Type: IpRangeTypePool, Start: "10.0.0.2", End: "10.0.0.1"}.Validate(), ErrInvalidData)
+	assert.ErrorIs(t, IpRange{Type: IpRangeTypePool, Start: "10.0.0.1", End: "fd00::1"}.Validate(), ErrInvalidData)
+	assert.ErrorIs(t, IpRange{Type: IpRangeTypePool, Start: "10.0.0", End: "10.0.0.1"}.Validate(), ErrInvalidData)
+}
+
+func TestIpAllocator_Allocate(t *testing.T) {
+	network := mustCidr(t, "10.0.0.1/24")
+	used := []Cidr{mustCidr(t, "10.0.0.1/24"), mustCidr(t, "10.0.0.2/32")}
+
+	a := NewIpAllocator(nil, nil, used)
+	ip, err := a.Allocate(network, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.3/32", ip.Cidr)
+
+	ip, err = a.Allocate(network, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.4/32", ip.Cidr, "allocated addresses are marked as used")
+}
+
+func TestIpAllocator_AllocateExclusionsAndReservations(t *testing.T) {
+	network := mustCidr(t, "10.0.0.0/24")
+	ranges := []IpRange{
+		{Type: IpRangeTypeExclusion, Start: "10.0.0.1", End: "10.0.0.9"},
+		{Type: IpRangeTypeExclusion, Start: "10.0.0.5", End: "10.0.0.10"},
+	}
+	reservations := []IpReservation{
+		{Address: "10.0.0.11", UserIdentifier: "alice"},
+		{Address: "10.0.0.50", PeerIdentifier: "peer-a"},
+	}
+
+	a := NewIpAllocator(ranges, reservations, nil)
+
+	ip, err := a.Allocate(network, "bob", "")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.12", ip.Addr, "excluded and reserved addresses are skipped")
+
+	ip, err = a.Allocate(network, "alice", "")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.11", ip.Addr, "the reservation of the user is preferred")
+
+	ip, err = a.Allocate(network, "alice", "peer-a")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.50", ip.Addr, "the reservation of the peer is preferred")
+}
+
+func TestIpAllocator_AllocatePools(t *testing.T) {
+	network := mustCidr(t, "10.0.0.0/24")
+	ranges := []IpRange{
+		{Identifier: "low", Type: IpRangeTypePool, Start: "10.0.0.100", End: "10.0.0.101", Priority: 1},
+		{Identifier: "high", Type: IpRangeTypePool, Start: "10.0.0.200", End: "10.0.0.255",
+			Strategy: IpAllocationReverse},
+		{Identifier: "other", Type: IpRangeTypePool, Start: "10.1.0.1", End: "10.1.0.10"},
+	}
+
+	a := NewIpAllocator(ranges, nil, nil)
+
+	var got []string
+	for range 3 {
+		ip, err := a.Allocate(network, "", "")
+		require.NoError(t, err)
+		got = append(got, ip.Addr)
+	}
+	assert.Equal(t, []string{"10.0.0.254", "10.0.0.253", "10.0.0.252"}, got,
+		"pools are ordered by priority, the broadcast address is never allocated")
+
+	edge := NewIpAllocator([]IpRange{{Type: IpRangeTypePool, Start: "10.0.0.0", End: "10.0.0.1"}}, nil, nil)
+	ip, err := edge.Allocate(network, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", ip.Addr, "the network address is never allocated")
+
+	single := NewIpAllocator(ranges[:1], nil, nil)
+	_, err = single.Allocate(network, "", "")
+	require.NoError(t, err)
+	_, err = single.Allocate(network, "", "")
+	require.NoError(t, err)
+	_, err = single.Allocate(network, "", "")
+	assert.Error(t, err, "pool is exhausted")
+}
+
+func TestIpAllocator_AllocateRandom(t *testing.T) {
+	network := mustCidr(t, "fd00::/64")
+	ranges := []IpRange{{Type: IpRangeTypePool, Start: "fd00::1:0", End: "fd00::1:ffff", Strategy: IpAllocationRandom}}
+
+	a := NewIpAllocator(ranges, nil, nil)
+	seen := map[string]bool{}
+	for range 100 {
+		ip, err := a.Allocate(network, "", "")
+		require.NoError(t, err)
+		assert.True(t, mustCidr(t, "fd00::1:0/112").Contains(ip))
+		assert.False(t, seen[ip.Addr], "address allocated twice")
+		seen[ip.Addr] = true
+	}
+
+	full := NewIpAllocator([]IpRange{{Type: IpRangeTypePool, Start: "fd00::1", End: "fd00::4",
+		Strategy: IpAllocationRandom}}, nil, nil)
+	for range 4 {
+		_, err := full.Allocate(network, "", "")
+		require.NoError(t, err)
+	}
+	_, err := full.Allocate(network, "", "")
+	assert.Error(t, err, "pool is exhausted")
+}
+
+func TestIpAllocator_AllocateLargeNetwork(t *testing.T) {
+	network := mustCidr(t, "10.10.0.0/16")
+
+	used := make([]Cidr, 0, 65000)
+	for i := 1; i <= 65000; i++ {
+		used = append(used, mustCidr(t, fmt.Sprintf("10.10.%d.%d/32", i/256, i%256)))
+	}
+	ranges := []IpRange{{Type: IpRangeTypeExclusion, Start: "10.10.254.0", End: "10.10.254.255"}}
+
+	start := time.Now()
+	a := NewIpAllocator(ranges, nil, used)
+	ip, err := a.Allocate(network, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "10.10.253.233", ip.Addr)
+	assert.Less(t, time.Since(start), time.Second)
+
+	ip, err = a.Allocate(network, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "10.10.253.234", ip.Addr)
+}
+
+func TestIpAllocator_Utilization(t *testing.T) {
+	network := mustCidr(t, "10.0.0.1/24")
+	ranges := []IpRange{{Type: IpRangeTypeExclusion, Start: "10.0.0.240", End: "10.0.0.255"}}
+	reservations := []IpReservation{
+		{Address: "10.0.0.10", UserIdentifier: "alice"},
+		{Address: "10.0.0.11", UserIdentifier: "bob"},
+		{Address: "10.0.0.250", UserIdentifier: "bob"},
+	}
+	used := []Cidr{mustCidr(t, "10.0.0.1/24"), mustCidr(t, "10.0.0.2/32"), mustCidr(t, "10.0.0.11/32")}
+
+	u := NewIpAllocator(ranges, reservations, used).Utilization(network)
+	require.Len(t, u, 1)
+	assert.Equal(t, "10.0.0.0/24", u[0].Network)
+	assert.Equal(t, "10.0.0.2", u[0].Start, "the default pool starts after the interface address")
+	assert.Equal(t, "10.0.0.254", u[0].End)
+	assert.Equal(t, uint64(253), u[0].Size)
+	assert.Equal(t, uint64(15), u[0].Excluded)
+	assert.Equal(t, uint64(2), u[0].Used)
+	assert.Equal(t, uint64(1), u[0].Reserved)
+	assert.Equal(t, uint64(235), u[0].Free)
+	assert.InDelta(t, 3.0/238.0, u[0].Usage(), 0.0001)
+
+	v6 := NewIpAllocator(nil, nil, nil).Utilization(mustCidr(t, "fd00::/48"))
+	require.Len(t, v6, 1)
+	assert.Equal(t, uint64(1<<64-1), v6[0].Size, "size is capped")
+}
+
+func TestIpAllocator_UtilizationOverlappingExclusions(t *testing.T) {
+	network := mustCidr(t, "10.0.0.1/24")
+	ranges := []IpRange{
+		{Type: IpRangeTypeExclusion, Start: "10.0.0.40", End: "10.0.0.60"},
+		{Type: IpRangeTypeExclusion, Start: "10.0.0.10", End: "10.0.0.50"},
+		{Type: IpRangeTypeExclusion, Start: "10.0.0.20", End: "10.0.0.30"},
+		{Type: IpRangeTypeExclusion, Start: "10.0.0.250", End: "10.0.0.255"},
+		{Type: IpRangeTypeExclusion, Start: "10.0.0.250", End: "10.0.0.255"},
+	}
+	used := []Cidr{mustCidr(t, "10.0.0.1/24"), mustCidr(t, "10.0.0.45/32")}
+
+	u := NewIpAllocator(ranges, nil, used).Utilization(network)
+	require.Len(t, u, 1)
+	assert.Equal(t, uint64(253), u[0].Size)
+	assert.Equal(t, uint64(51+5), u[0].Excluded, "overlapping exclusions are counted once")
+	assert.Equal(t, uint64(0), u[0].Used, "excluded addresses are not counted as used")
+	assert.Equal(t, uint64(253-56), u[0].Free)
+
+	// exclusions that end at the last address of the address space
+	ranges = []IpRange{
+		{Type: IpRangeTypeExclusion, Start: "255.255.255.250", End: "255.255.255.255"},
+		{Type: IpRangeTypeExclusion, Start: "255.255.255.240", End: "255.255.255.255"},
+	}
+	u = NewIpAllocator(ranges, nil, nil).Utilization(mustCidr(t, "255.255.255.1/24"))
+	require.Len(t, u, 1)
+	assert.Equal(t, uint64(15), u[0].Excluded)
+
+	top := "ffff:ffff:ffff:ffff:ffff:ffff:ffff:"
+	ranges = []IpRange{
+		{Type: IpRangeTypeExclusion, Start: top + "ff10", End: top + "ffff"},
+		{Type: IpRangeTypeExclusion, Start: top + "ff20", End: top + "ffff"},
+	}
+	u = NewIpAllocator(ranges, nil, nil).Utilization(mustCidr(t, top+"ff01/120"))
+	require.Len(t, u, 1)
+	assert.Equal(t, uint64(240), u[0].Excluded)
+	assert.LessOrEqual(t, u[0].Excluded, u[0].Size)
+}
+
+func TestValidateReservedAddresses(t *testing.T) {
+	reservations := []IpReservation{{Address: "10.0.0.5", UserIdentifier: "alice"}}
+	addresses := []Cidr{mustCidr(t, "10.0.0.5/32")}
+
+	assert.NoError(t, ValidateReservedAddresses(reservations, addresses, "alice", ""))
+	assert.ErrorIs(t, ValidateReservedAddresses(reservations, addresses, "bob", ""), ErrInvalidData)
+	assert.NoError(t, ValidateReservedAddresses(reservations, []Cidr{mustCidr(t, "10.0.0.6/32")}, "bob", ""))
+}
+
+func TestIpAllocator_AllocateHash(t *testing.T) {
+	network := mustCidr(t, "fd00::1/64")
+	key := PeerIdentifier("xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=")
+
+	a := NewIpAllocator(nil, nil, nil)
+	a.SetIpv6Strategy(IpAllocationHash)
+	ip, err := a.Allocate(network, "", key)
+	require.NoError(t, err)
+	assert.True(t, mustCidr(t, "fd00::/64").Contains(ip))
+	assert.NotEqual(t, "fd00::2", ip.Addr)
+
+	again := NewIpAllocator(nil, nil, nil)
+	again.SetIpv6Strategy(IpAllocationHash)
+	same, err := again.Allocate(network, "", key)
+	require.NoError(t, err)
+	assert.Equal(t, ip, same, "the address is stable for the same key")
+
+	collision, err := again.Allocate(network, "", key)
+	require.NoError(t, err)
+	assert.NotEqual(t, ip, collision, "collisions use the next free address")
+
+	v4, err := a.Allocate(mustCidr(t, "10.0.0.1/24"), "", key)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.2", v4.Addr, "IPv4 networks keep the sequential strategy")
+
+	keyless, err := a.Allocate(network, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "fd00::2", keyless.Addr, "without a key, the lowest free address is used")
+}
+
+func TestIpAllocator_AllocateHashPool(t *testing.T) {
+	network := mustCidr(t, "fd00::1/64")
+	ranges := []IpRange{{Type: IpRangeTypePool, Start: "fd00::10", End: "fd00::13", Strategy: IpAllocationHash}}
+
+	a := NewIpAllocator(ranges, nil, nil)
+	seen := map[string]bool{}
+	for i := range 4 {
+		ip, err := a.Allocate(network, "", PeerIdentifier(fmt.Sprintf("key-%d", i)))
+		require.NoError(t, err)
+		assert.True(t, mustCidr(t, "fd00::10/126").Contains(ip))
+		assert.False(t, seen[ip.Addr], "address allocated twice")
+		seen[ip.Addr] = true
+	}
+	_, err := a.Allocate(network, "", "key-4")
+	assert.Error(t, err, "pool is exhausted")
+}
+
+func TestPeerIpv6Settings_Validate(t *testing.T) {
+	assert.NoError(t, PeerIpv6Settings{}.Validate())
+	assert.NoError(t, PeerIpv6Settings{DelegatedPrefix: "fd01::/48", DelegatedPrefixLength: 64}.Validate())
+
+	assert.ErrorIs(t, PeerIpv6Settings{DelegatedPrefix: "10.0.0.0/8", DelegatedPrefixLength: 24}.Validate(),
+		ErrInvalidData)
+	assert.ErrorIs(t, PeerIpv6Settings{DelegatedPrefix: "fd01::/48", DelegatedPrefixLength: 48}.Validate(),
+		ErrInvalidData)
+	assert.ErrorIs(t, PeerIpv6Settings{DelegatedPrefix: "fd01::/48"}.Validate(), ErrInvalidData)
+}
+
+func TestDelegatePrefix(t *testing.T) {
+	delegation := mustCidr(t, "fd01::/48")
+	key := PeerIdentifier("xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=")
+
+	prefix, err := DelegatePrefix(delegation, 64, key, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 64, prefix.NetLength)
+	assert.True(t, delegation.Contains(prefix))
+
+	again, err := DelegatePrefix(delegation, 64, key, []Cidr{mustCidr(t, "10.0.0.0/8")})
+	require.NoError(t, err)
+	assert.Equal(t, prefix, again, "the prefix is stable for the same key")
+
+	next, err := DelegatePrefix(delegation, 64, key, []Cidr{prefix})
+	require.NoError(t, err)
+	assert.NotEqual(t, prefix, next, "taken prefixes are skipped")
+
+	small := mustCidr(t, "fd02::/62")
+	var taken []Cidr
+	for range 4 {
+		p, err := DelegatePrefix(small, 64, key, taken)
+		require.NoError(t, err)
+		taken = append(taken, p)
+	}
+	_, err = DelegatePrefix(small, 64, key, taken)
+	assert.Error(t, err, "prefix space is exhausted")
+
+	p, err := DelegatePrefix(small, 64, key, []Cidr{mustCidr(t, "fd02::/63"), mustCidr(t, "fd02:0:0:2::/64")})
+	require.NoError(t, err)
+	assert.Equal(t, "fd02:0:0:3::/64", p.Cidr, "larger taken prefixes are skipped at once")
+
+	_, err = DelegatePrefix(small, 62, key, nil)
+	assert.ErrorIs(t, err, ErrInvalidData)
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// LoginAttempt tracks the consecutive failed logins of an account or of a source address.
+// The attempts are stored in the database, so that all instances share the same state.
+type LoginAttempt struct {
+	Identifier   string     `gorm:"primaryKey;column:identifier"` // see LoginAttemptUserKey and LoginAttemptSourceKey
+	Failures     int        `gorm:"column:failures"`
+	LastFailure  time.Time  `gorm:"column:last_failure"`
+	BlockedUntil *time.Time `gorm:"index;column:blocked_until"` // if set, no login is possible until this time
+}
+
+const (
+	loginAttemptUserPrefix   = "user:"
+	loginAttemptSourcePrefix = "source:"
+)
+
+// LoginAttemptUserKey returns the identifier of the login attempts of the given account.
+func LoginAttemptUserKey(id UserIdentifier) string {
+	return loginAttemptUserPrefix + string(id)
+}
+
+// LoginAttemptSourceKey returns the identifier of the login attempts from the given source address.
+func LoginAttemptSourceKey(sourceAddr string) string {
+	return loginAttemptSourcePrefix + sourceAddr
+}
+
+// Subject returns the account or the source address of the attempts.
+func (a *LoginAttempt) Subject() string {
+	if a.IsSource() {
+		return strings.TrimPrefix(a.Identifier, loginAttemptSourcePrefix)
+	}
+
+	return strings.TrimPrefix(a.Identifier, loginAttemptUserPrefix)
+}
+
+// IsSource returns true if the attempts belong to a source address and not to an account.
+func (a *LoginAttempt) IsSource() bool {
+	return strings.HasPrefix(a.Identifier, loginAttemptSourcePrefix)
+}
+
+// IsBlocked returns true if no login is possible because of too many failures.
+func (a *LoginAttempt) IsBlocked(now time.Time) bool {
+	return a.BlockedUntil != nil && a.BlockedUntil.After(now)
+}
+
+// NextAttempt returns the earliest time of the next login attempt. The delay starts with baseDelay after
+// the first failure and doubles with each further failure, up to maxDelay.
+func (a *LoginAttempt) NextAttempt(baseDelay, maxDelay time.Duration) time.Time {
+	if a.IsBlocked(a.LastFailure) {
+		return *a.BlockedUntil
+	}
+	if a.Failures == 0 || baseDelay <= 0 {
+		return a.LastFailure
+	}
+
+	maxDelay = max(maxDelay, baseDelay)
+	delay := baseDelay
+	for i := 1; i < a.Failures && delay < maxDelay; i++ {
+		delay = min(delay*2, maxDelay)
+	}
+
+	return a.LastFailure.Add(delay)
+}
+
+// RegisterFailure counts a failed login. Failures older than the given window are forgotten.
+// If the failures reach maxFailures, the attempts are blocked for the given window and true is returned.
+func (a *LoginAttempt) RegisterFailure(now time.Time, maxFailures int, window time.Duration) bool {
+	if now.Sub(a.LastFailure) > window && !a.IsBlocked(now) {
+		a.Failures = 0
+		a.BlockedUntil = nil
+	}
+
+	a.Failures++
+	a.LastFailure = now
+
+	if maxFailures > 0 && a.Failures >= maxFailures && !a.IsBlocked(now) {
+		blockedUntil := now.Add(window)
+		a.BlockedUntil = &blockedUntil
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginAttempt_NextAttempt(t *testing.T) {
+	now := time.Now()
+	attempt := LoginAttempt{Identifier: LoginAttemptUserKey("alice"), LastFailure: now}
+
+	assert.Equal(t, now, attempt.NextAttempt(time.Second, time.Minute))
+
+	attempt.Failures = 1
+	assert.Equal(t, now.Add(time.Second), attempt.NextAttempt(time.Second, time.Minute))
+	attempt.Failures = 4
+	assert.Equal(t, now.Add(8*time.Second), attempt.NextAttempt(time.Second, time.Minute))
+	attempt.Failures = 40
+	assert.Equal(t, now.Add(time.Minute), attempt.NextAttempt(time.Second, time.Minute))
+
+	blockedUntil := now.Add(time.Hour)
+	attempt.BlockedUntil = &blockedUntil
+	assert.Equal(t, blockedUntil, attempt.NextAttempt(time.Second, time.Minute))
+}
+
+func TestLoginAttempt_RegisterFailure(t *testing.T) {
+	now := time.Now()
+	attempt := LoginAttempt{Identifier: LoginAttemptSourceKey("10.0.0.1")}
+	assert.True(t, attempt.IsSource())
+	assert.Equal(t, "10.0.0.1", attempt.Subject())
+
+	assert.False(t, attempt.RegisterFailure(now, 3, time.Minute))
+	assert.False(t, attempt.RegisterFailure(now, 3, time.Minute))
+	assert.True(t, attempt.RegisterFailure(now, 3, time.Minute))
+	assert.True(t, attempt.IsBlocked(now))
+	assert.False(t, attempt.IsBlocked(now.Add(2*time.Minute)))
+
+	// failures outside the window are forgotten
+	assert.False(t, attempt.RegisterFailure(now.Add(2*time.Minute), 3, time.Minute))
+	assert.Equal(t, 1, attempt.Failures)
+	assert.Nil(t, attempt.BlockedUntil)
+}
@@ -3,6 +3,7 @@ package domain
 import (
 	"fmt"
 	"net"
+	"slices"
 	"strings"
 	"time"
 
@@ -47,9 +48,14 @@ type Peer struct {
 	InterfaceIdentifier  InterfaceIdentifier `gorm:"index;column:interface_identifier"` // the interface id
 	Disabled             *time.Time          `gorm:"column:disabled"`                   // if this field is set, the peer is disabled
 	DisabledReason       string              // the reason why the peer has been disabled
-	ExpiresAt            *time.Time          `gorm:"column:expires_at"`         // expiry dates for peers
-	Notes                string              `form:"notes" binding:"omitempty"` // a note field for peers
-	AutomaticallyCreated bool                `gorm:"column:auto_created"`       // specifies if the peer was automatically created
+	ExpiresAt            *time.Time          `gorm:"column:expires_at"`              // expiry dates for peers
+	Notes                string              `form:"notes" binding:"omitempty"`      // a note field for peers
+	AutomaticallyCreated bool                `gorm:"column:auto_created"`            // specifies if the peer was automatically created
+	RenewalCount         int                 `gorm:"column:renewal_count"`           // the number of renewals of the expiry date
+	RenewalRequestedAt   *time.Time          `gorm:"column:renewal_requested_at"`    // if this field is set, a renewal is waiting for approval
+	Quota                TrafficQuota        `gorm:"embedded;embeddedPrefix:quota_"` // the traffic quota of the peer, overrides user and interface quotas
+	Tags                 []string            `gorm:"serializer:json;column:tags"`    // the tags of the peer, lower case and sorted
+	ManagedBy            string              `gorm:"column:managed_by"`              // set if the peer is managed by the declarative spec, managed peers are read-only
 
 	// Interface settings for the peer, used to generate the [interface] section in the peer config file
 	Interface PeerInterfaceConfig `gorm:"embedded"`
@@ -122,6 +128,41 @@ func (p *Peer) ApplyInterfaceDefaults(in *Interface) {
 	p.Interface.PostDown.TrySetValue(in.PeerDefPostDown)
 }
 
+// MoveToInterface links the peer to the given interface and replaces its addresses. Unlike ApplyInterfaceDefaults,
+// all peer defaults of the new interface are applied, even if they were not overridable on the previous interface.
+// Extra allowed IPs that were delegated by the previous interface are replaced by the given prefixes.
+func (p *Peer) MoveToInterface(from, to *Interface, addresses, prefixes []Cidr) {
+	peerMode := InterfaceTypeClient
+	if to.Type == InterfaceTypeClient {
+		peerMode = InterfaceTypeServer
+	}
+
+	extraAllowedIPs, _ := CidrsFromString(p.ExtraAllowedIPsStr)
+	if from.PeerDefIpv6.DelegatedPrefix != "" {
+		if delegation, err := CidrFromString(from.PeerDefIpv6.DelegatedPrefix); err == nil {
+			extraAllowedIPs = slices.DeleteFunc(extraAllowedIPs, delegation.Contains)
+		}
+	}
+
+	p.InterfaceIdentifier = to.Identifier
+	p.Interface.Type = peerMode
+	p.Interface.Addresses = addresses
+	p.ExtraAllowedIPsStr = CidrsToString(appendMissingCidrs(extraAllowedIPs, prefixes...))
+	p.Endpoint = NewConfigOption(to.PeerDefEndpoint, true)
+	p.EndpointPublicKey = NewConfigOption(to.PublicKey, true)
+	p.AllowedIPsStr = NewConfigOption(to.PeerDefAllowedIPsStr, true)
+	p.PersistentKeepalive = NewConfigOption(to.PeerDefPersistentKeepalive, true)
+	p.Interface.DnsStr = NewConfigOption(to.PeerDefDnsStr, true)
+	p.Interface.DnsSearchStr = NewConfigOption(to.PeerDefDnsSearchStr, true)
+	p.Interface.Mtu = NewConfigOption(to.PeerDefMtu, true)
+	p.Interface.FirewallMark = NewConfigOption(to.PeerDefFirewallMark, true)
+	p.Interface.RoutingTable = NewConfigOption(to.PeerDefRoutingTable, true)
+	p.Interface.PreUp = NewConfigOption(to.PeerDefPreUp, true)
+	p.Interface.PostUp = NewConfigOption(to.PeerDefPostUp, true)
+	p.Interface.PreDown = NewConfigOption(to.PeerDefPreDown, true)
+	p.Interface.PostDown = NewConfigOption(to.PeerDefPostDown, true)
+}
+
 func (p *Peer) GenerateDisplayName(prefix string) {
 	if prefix != "" {
 		prefix = fmt.Sprintf("%s ", strings.TrimSpace(prefix)) // add a space after the prefix
@@ -164,3 +164,40 @@ func TestPeer_GetAllowedIPs(t *testing.T) {
 	assert.Equal(t, "192.168.1.0/24", ips2[0].String())
 	assert.Equal(t, "fe80::/64", ips2[1].String())
 }
+
+func TestPeer_MoveToInterface(t *testing.T) {
+	from := &Interface{
+		Identifier:  "wg0",
+		Type:        InterfaceTypeServer,
+		PeerDefIpv6: PeerIpv6Settings{DelegatedPrefix: "fd00:0:0:100::/56"},
+	}
+	to := &Interface{
+		Identifier:      "wg1",
+		Type:            InterfaceTypeServer,
+		KeyPair:         KeyPair{PublicKey: "target-key"},
+		PeerDefEndpoint: "vpn.example.com:51821",
+		PeerDefMtu:      1380,
+	}
+	peer := &Peer{
+		InterfaceIdentifier: "wg0",
+		Endpoint:            NewConfigOption("old.example.com:51820", false),
+		ExtraAllowedIPsStr:  "10.1.0.0/24,fd00:0:0:1a0::/60",
+		Interface: PeerInterfaceConfig{
+			Addresses: []Cidr{mustCidr(t, "10.0.0.5/32")},
+			Mtu:       NewConfigOption(1420, false),
+		},
+	}
+
+	addresses := []Cidr{mustCidr(t, "10.0.1.7/32")}
+	prefixes := []Cidr{mustCidr(t, "fd00:0:0:2b0::/60")}
+	peer.MoveToInterface(from, to, addresses, prefixes)
+
+	assert.Equal(t, InterfaceIdentifier("wg1"), peer.InterfaceIdentifier)
+	assert.Equal(t, InterfaceTypeClient, peer.Interface.Type)
+	assert.Equal(t, addresses, peer.Interface.Addresses)
+	assert.Equal(t, "10.1.0.0/24,fd00:0:0:2b0::/60", peer.ExtraAllowedIPsStr)
+	assert.Equal(t, "vpn.example.com:51821", peer.Endpoint.GetValue())
+	assert.True(t, peer.Endpoint.Overridable)
+	assert.Equal(t, "target-key", peer.EndpointPublicKey.GetValue())
+	assert.Equal(t, 1380, peer.Interface.Mtu.GetValue())
+}
@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuotaDirection specifies which traffic is counted for a quota.
+// Directions are seen from the server: rx is the traffic received from the peer, tx is the traffic sent to the peer.
+type QuotaDirection string
+
+const (
+	QuotaDirectionReceived    QuotaDirection = "rx"
+	QuotaDirectionTransmitted QuotaDirection = "tx"
+	QuotaDirectionTotal       QuotaDirection = "total"
+)
+
+type QuotaResetCycle string
+
+const (
+	QuotaResetDaily   QuotaResetCycle = "daily"
+	QuotaResetWeekly  QuotaResetCycle = "weekly"
+	QuotaResetMonthly QuotaResetCycle = "monthly"
+	QuotaResetCustom  QuotaResetCycle = "custom" // every ResetInterval days
+)
+
+// TrafficQuota limits the traffic of a peer per period. Periods are aligned to the reset anchor,
+// for example a monthly quota with an anchor on the 15th resets on the 15th of each month.
+// Without an anchor, periods start at midnight UTC, weeks on monday and months on the first day.
+type TrafficQuota struct {
+	Limit         uint64          // the maximum number of bytes per period, 0 disables the quota
+	Direction     QuotaDirection  // the traffic that is counted
+	ResetCycle    QuotaResetCycle // the length of a period
+	ResetAnchor   *time.Time      // the start of an arbitrary period, all other periods are aligned to it
+	ResetInterval int             // the length of a custom period in days
+}
+
+// IsEnabled returns true if the quota limits the traffic.
+func (q TrafficQuota) IsEnabled() bool {
+	return q.Limit > 0
+}
+
+// Validate checks the settings of an enabled quota.
+func (q TrafficQuota) Validate() error {
+	if !q.IsEnabled() {
+		return nil
+	}
+
+	switch q.Direction {
+	case QuotaDirectionReceived, QuotaDirectionTransmitted, QuotaDirectionTotal:
+	default:
+		return fmt.Errorf("invalid quota direction %q: %w", q.Direction, ErrInvalidData)
+	}
+
+	switch q.ResetCycle {
+	case QuotaResetDaily, QuotaResetWeekly, QuotaResetMonthly:
+	case QuotaResetCustom:
+		if q.ResetInterval <= 0 {
+			return fmt.Errorf("custom quota reset cycles require an interval: %w", ErrInvalidData)
+		}
+	default:
+		return fmt.Errorf("invalid quota reset cycle %q: %w", q.ResetCycle, ErrInvalidData)
+	}
+
+	return nil
+}
+
+// Usage returns the traffic that is counted for the quota.
+func (q TrafficQuota) Usage(received, transmitted uint64) uint64 {
+	switch q.Direction {
+	case QuotaDirectionReceived:
+		return received
+	case QuotaDirectionTransmitted:
+		return transmitted
+	default:
+		return received + transmitted
+	}
+}
+
+// PeriodStart returns the start of the quota period that contains the given timestamp.
+func (q TrafficQuota) PeriodStart(t time.Time) time.Time {
+	t = t.UTC()
+	anchor := q.anchor()
+
+	if q.ResetCycle == QuotaResetMonthly {
+		start := monthlyPeriodStart(anchor, t.Year(), t.Month())
+		if start.After(t) {
+			start = monthlyPeriodStart(anchor, t.Year(), t.Month()-1)
+		}
+		return start
+	}
+
+	period := q.periodLength()
+	elapsed := t.Sub(anchor)
+	periods := elapsed / period
+	if elapsed < 0 && elapsed%period != 0 {
+		periods-- // round towards the past for timestamps before the anchor
+	}
+	return anchor.Add(periods * period)
+}
+
+// NextPeriodStart returns the start of the quota period that follows the period containing the given timestamp.
+func (q TrafficQuota) NextPeriodStart(t time.Time) time.Time {
+	start := q.PeriodStart(t)
+	if q.ResetCycle == QuotaResetMonthly {
+		return monthlyPeriodStart(q.anchor(), start.Year(), start.Month()+1)
+	}
+	return start.Add(q.periodLength())
+}
+
+func (q TrafficQuota) anchor() time.Time {
+	if q.ResetAnchor != nil {
+		return q.ResetAnchor.UTC()
+	}
+	if q.ResetCycle == QuotaResetWeekly {
+		return time.Date(1970, 1, 5, 0, 0, 0, 0, time.UTC) // a monday
+	}
+	return time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (q TrafficQuota) periodLength() time.Duration {
+	switch q.ResetCycle {
+	case QuotaResetWeekly:
+		return 7 * 24 * time.Hour
+	case QuotaResetCustom:
+		if q.ResetInterval > 0 {
+			return time.Duration(q.ResetInterval) * 24 * time.Hour
+		}
+	}
+	return 24 * time.Hour
+}
+
+// monthlyPeriodStart returns the start of the monthly period in the given month. If the month is shorter
+// than the day of the anchor, the period starts on the last day of the month.
+func monthlyPeriodStart(anchor time.Time, year int, month time.Month) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC) // normalizes overflowing months
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := min(anchor.Day(), lastDay)
+
+	return time.Date(first.Year(), first.Month(), day, anchor.Hour(), anchor.Minute(), anchor.Second(), 0,
+		time.UTC)
+}
+
+// EffectiveQuota returns the quota that applies to the peer. The quota of the peer takes precedence over
+// the quota of the user, which takes precedence over the default quota of the interface.
+// The user and the interface may be nil.
+func EffectiveQuota(peer *Peer, user *User, iface *Interface) TrafficQuota {
+	switch {
+	case peer.Quota.IsEnabled():
+		return peer.Quota
+	case user != nil && user.PeerQuota.IsEnabled():
+		return user.PeerQuota
+	case iface != nil && iface.PeerDefQuota.IsEnabled():
+		return iface.PeerDefQuota
+	default:
+		return TrafficQuota{}
+	}
+}
@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrafficQuota_Validate(t *testing.T) {
+	assert.NoError(t, TrafficQuota{}.Validate(), "disabled quotas are not validated")
+	assert.NoError(t, TrafficQuota{Limit: 1, Direction: QuotaDirectionTotal, ResetCycle: QuotaResetMonthly}.Validate())
+	assert.NoError(t, TrafficQuota{Limit: 1, Direction: QuotaDirectionReceived, ResetCycle: QuotaResetCustom,
+		ResetInterval: 14}.Validate())
+
+	err := TrafficQuota{Limit: 1, Direction: "up", ResetCycle: QuotaResetDaily}.Validate()
+	assert.True(t, errors.Is(err, ErrInvalidData))
+	err = TrafficQuota{Limit: 1, Direction: QuotaDirectionTotal, ResetCycle: "yearly"}.Validate()
+	assert.True(t, errors.Is(err, ErrInvalidData))
+	err = TrafficQuota{Limit: 1, Direction: QuotaDirectionTotal, ResetCycle: QuotaResetCustom}.Validate()
+	assert.True(t, errors.Is(err, ErrInvalidData))
+}
+
+func TestTrafficQuota_Usage(t *testing.T) {
+	assert.Equal(t, uint64(10), TrafficQuota{Direction: QuotaDirectionReceived}.Usage(10, 5))
+	assert.Equal(t, uint64(5), TrafficQuota{Direction: QuotaDirectionTransmitted}.Usage(10, 5))
+	assert.Equal(t, uint64(15), TrafficQuota{Direction: QuotaDirectionTotal}.Usage(10, 5))
+}
+
+func TestTrafficQuota_PeriodStart(t *testing.T) {
+	anchor := time.Date(2025, 1, 31, 6, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		quota     TrafficQuota
+		timestamp time.Time
+		wantStart time.Time
+		wantNext  time.Time
+	}{
+		{
+			name:      "daily",
+			quota:     TrafficQuota{ResetCycle: QuotaResetDaily},
+			timestamp: time.Date(2025, 3, 10, 14, 30, 0, 0, time.UTC),
+			wantStart: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+			wantNext:  time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "weekly starts on monday",
+			quota:     TrafficQuota{ResetCycle: QuotaResetWeekly},
+			timestamp: time.Date(2025, 3, 13, 14, 30, 0, 0, time.UTC), // a thursday
+			wantStart: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+			wantNext:  time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "monthly",
+			quota:     TrafficQuota{ResetCycle: QuotaResetMonthly},
+			timestamp: time.Date(2025, 3, 10, 14, 30, 0, 0, time.UTC),
+			wantStart: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantNext:  time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "monthly anchor is clamped to the end of short months",
+			quota:     TrafficQuota{ResetCycle: QuotaResetMonthly, ResetAnchor: &anchor},
+			timestamp: time.Date(2025, 3, 10, 14, 30, 0, 0, time.UTC),
+			wantStart: time.Date(2025, 2, 28, 6, 0, 0, 0, time.UTC),
+			wantNext:  time.Date(2025, 3, 31, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "monthly anchor across the year boundary",
+			quota:     TrafficQuota{ResetCycle: QuotaResetMonthly, ResetAnchor: &anchor},
+			timestamp: time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC),
+			wantStart: time.Date(2024, 12, 31, 6, 0, 0, 0, time.UTC),
+			wantNext:  time.Date(2025, 1, 31, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "custom interval",
+			quota:     TrafficQuota{ResetCycle: QuotaResetCustom, ResetInterval: 10, ResetAnchor: &anchor},
+			timestamp: time.Date(2025, 2, 12, 0, 0, 0, 0, time.UTC),
+			wantStart: time.Date(2025, 2, 10, 6, 0, 0, 0, time.UTC),
+			wantNext:  time.Date(2025, 2, 20, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "custom interval before the anchor",
+			quota:     TrafficQuota{ResetCycle: QuotaResetCustom, ResetInterval: 10, ResetAnchor: &anchor},
+			timestamp: time.Date(2025, 1, 25, 0, 0, 0, 0, time.UTC),
+			wantStart: time.Date(2025, 1, 21, 6, 0, 0, 0, time.UTC),
+			wantNext:  time.Date(2025, 1, 31, 6, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantStart, tt.quota.PeriodStart(tt.timestamp))
+			assert.Equal(t, tt.wantNext, tt.quota.NextPeriodStart(tt.timestamp))
+		})
+	}
+}
+
+func TestEffectiveQuota(t *testing.T) {
+	peerQuota := TrafficQuota{Limit: 1}
+	userQuota := TrafficQuota{Limit: 2}
+	ifaceQuota := TrafficQuota{Limit: 3}
+
+	assert.Equal(t, peerQuota, EffectiveQuota(&Peer{Quota: peerQuota}, &User{PeerQuota: userQuota},
+		&Interface{PeerDefQuota: ifaceQuota}))
+	assert.Equal(t, userQuota, EffectiveQuota(&Peer{}, &User{PeerQuota: userQuota},
+		&Interface{PeerDefQuota: ifaceQuota}))
+	assert.Equal(t, ifaceQuota, EffectiveQuota(&Peer{}, nil, &Interface{PeerDefQuota: ifaceQuota}))
+	assert.False(t, EffectiveQuota(&Peer{}, nil, nil).IsEnabled())
+}
+
+func TestPeerStatus_UpdateQuotaUsage(t *testing.T) {
+	quota := TrafficQuota{Limit: 100, Direction: QuotaDirectionReceived, ResetCycle: QuotaResetDaily}
+	day := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+
+	s := PeerStatus{}
+	s.UpdateQuotaUsage(quota, day, 60, 500)
+	assert.Equal(t, uint64(60), s.QuotaUsed())
+	assert.False(t, s.IsQuotaExceeded())
+
+	s.UpdateQuotaUsage(quota, day.Add(time.Hour), 40, 500)
+	assert.Equal(t, uint64(100), s.QuotaUsed())
+	assert.True(t, s.IsQuotaExceeded())
+
+	// the usage is reset in a new period
+	s.UpdateQuotaUsage(quota, day.Add(24*time.Hour), 10, 0)
+	assert.Equal(t, uint64(10), s.QuotaUsed())
+	assert.Equal(t, time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC), *s.QuotaPeriodStart)
+
+	// the usage is cleared if no quota applies anymore
+	s.UpdateQuotaUsage(TrafficQuota{}, day.Add(24*time.Hour), 10, 0)
+	assert.Equal(t, uint64(0), s.QuotaUsed())
+	assert.Nil(t, s.QuotaPeriodStart)
+	assert.False(t, s.IsQuotaExceeded())
+}
+
+func TestPeerStatus_IsQuotaOverridden(t *testing.T) {
+	quota := TrafficQuota{Limit: 100, Direction: QuotaDirectionTotal, ResetCycle: QuotaResetDaily}
+	day := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+
+	s := PeerStatus{}
+	s.UpdateQuotaUsage(quota, day, 200, 0)
+	assert.True(t, s.IsQuotaExceeded())
+
+	// the override only applies to the period it was set for
+	periodStart := quota.PeriodStart(day)
+	s.QuotaOverride = &periodStart
+	s.UpdateQuotaUsage(quota, day.Add(time.Hour), 100, 0)
+	assert.True(t, s.IsQuotaOverridden())
+	assert.False(t, s.IsQuotaExceeded())
+	assert.Equal(t, uint64(300), s.QuotaUsed())
+
+	s.UpdateQuotaUsage(quota, day.Add(24*time.Hour), 100, 0)
+	assert.False(t, s.IsQuotaOverridden())
+	assert.True(t, s.IsQuotaExceeded())
+
+	// the override is cleared if no quota applies anymore
+	s.UpdateQuotaUsage(TrafficQuota{}, day, 0, 0)
+	assert.Nil(t, s.QuotaOverride)
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// PeerRenewalPolicy defines how users can extend the expiry date of their own peers.
+type PeerRenewalPolicy struct {
+	Enabled         bool // if set, users can renew their expiring peers
+	Step            int  // the number of days that are added to the expiry date per renewal
+	MaxLifetime     int  // the maximum number of days between peer creation and expiry, 0 means unlimited
+	MaxRenewals     int  // the maximum number of renewals per peer, 0 means unlimited
+	RequireApproval bool // if set, renewals requested by users must be approved by an administrator
+}
+
+// Validate checks the settings of an enabled renewal policy.
+func (p PeerRenewalPolicy) Validate() error {
+	if !p.Enabled {
+		return nil
+	}
+
+	if p.Step <= 0 {
+		return fmt.Errorf("renewal step must be at least one day: %w", ErrInvalidData)
+	}
+	if p.MaxLifetime < 0 || p.MaxRenewals < 0 {
+		return fmt.Errorf("renewal limits must not be negative: %w", ErrInvalidData)
+	}
+
+	return nil
+}
+
+// NextExpiry returns the new expiry date of the given peer after a renewal at the given time.
+// Expired peers are renewed starting from today. The expiry date is capped at the maximum lifetime of the peer.
+func (p PeerRenewalPolicy) NextExpiry(peer *Peer, now time.Time) (time.Time, error) {
+	if !p.Enabled {
+		return time.Time{}, fmt.Errorf("renewal is disabled for this interface: %w", ErrNoPermission)
+	}
+	if peer.ExpiresAt == nil {
+		return time.Time{}, fmt.Errorf("peer does not expire: %w", ErrInvalidData)
+	}
+	if p.MaxRenewals > 0 && peer.RenewalCount >= p.MaxRenewals {
+		return time.Time{}, fmt.Errorf("maximum number of renewals reached: %w", ErrNoPermission)
+	}
+
+	base := truncateToDate(*peer.ExpiresAt)
+	if today := truncateToDate(now); base.Before(today) {
+		base = today
+	}
+	next := base.AddDate(0, 0, p.Step)
+
+	if p.MaxLifetime > 0 && !peer.CreatedAt.IsZero() {
+		limit := truncateToDate(peer.CreatedAt).AddDate(0, 0, p.MaxLifetime)
+		if next.After(limit) {
+			next = limit
+		}
+	}
+	if !next.After(*peer.ExpiresAt) {
+		return time.Time{}, fmt.Errorf("maximum lifetime reached: %w", ErrNoPermission)
+	}
+
+	return next, nil
+}
+
+// Renew sets the new expiry date of the peer and counts the renewal. A pending renewal request is cleared.
+// If the peer was disabled because it expired, it is enabled again.
+func (p *Peer) Renew(expiresAt time.Time) {
+	p.ExpiresAt = &expiresAt
+	p.RenewalCount++
+	p.RenewalRequestedAt = nil
+
+	if p.IsDisabled() && p.DisabledReason == DisabledReasonExpired {
+		p.Disabled = nil
+		p.DisabledReason = ""
+	}
+}
+
+// IsRenewalRequested returns true if a renewal of the peer is waiting for approval.
+func (p *Peer) IsRenewalRequested() bool {
+	return p.RenewalRequestedAt != nil
+}
+
+// ExpiryDateChanged returns true if the two expiry dates refer to different days.
+func ExpiryDateChanged(a, b *time.Time) bool {
+	switch {
+	case a == nil && b == nil:
+		return false
+	case a == nil || b == nil:
+		return true
+	default:
+		return !truncateToDate(*a).Equal(truncateToDate(*b))
+	}
+}
+
+func truncateToDate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerRenewalPolicy_NextExpiry(t *testing.T) {
+	now := time.Date(2025, 3, 10, 15, 30, 0, 0, time.UTC)
+	date := func(m time.Month, d int) *time.Time {
+		t := time.Date(2025, m, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	policy := PeerRenewalPolicy{Enabled: true, Step: 30}
+
+	next, err := policy.NextExpiry(&Peer{ExpiresAt: date(3, 20)}, now)
+	require.NoError(t, err)
+	assert.Equal(t, *date(4, 19), next, "renewal extends the current expiry date")
+
+	next, err = policy.NextExpiry(&Peer{ExpiresAt: date(3, 1)}, now)
+	require.NoError(t, err)
+	assert.Equal(t, *date(4, 9), next, "expired peers are renewed from today")
+
+	limited := PeerRenewalPolicy{Enabled: true, Step: 30, MaxLifetime: 60}
+	peer := &Peer{ExpiresAt: date(3, 20), BaseModel: BaseModel{CreatedAt: *date(2, 1)}}
+	next, err = limited.NextExpiry(peer, now)
+	require.NoError(t, err)
+	assert.Equal(t, *date(4, 2), next, "renewal is capped at the maximum lifetime")
+
+	peer.ExpiresAt = date(4, 2)
+	_, err = limited.NextExpiry(peer, now)
+	assert.ErrorIs(t, err, ErrNoPermission, "maximum lifetime reached")
+
+	counted := PeerRenewalPolicy{Enabled: true, Step: 30, MaxRenewals: 2}
+	_, err = counted.NextExpiry(&Peer{ExpiresAt: date(3, 20), RenewalCount: 2}, now)
+	assert.ErrorIs(t, err, ErrNoPermission, "maximum renewals reached")
+
+	_, err = PeerRenewalPolicy{Step: 30}.NextExpiry(&Peer{ExpiresAt: date(3, 20)}, now)
+	assert.ErrorIs(t, err, ErrNoPermission, "disabled policy")
+
+	_, err = policy.NextExpiry(&Peer{}, now)
+	assert.ErrorIs(t, err, ErrInvalidData, "peer without expiry date")
+}
+
+func TestPeer_Renew(t *testing.T) {
+	disabled := time.Now()
+	requested := time.Now()
+	peer := &Peer{
+		Disabled:           &disabled,
+		DisabledReason:     DisabledReasonExpired,
+		RenewalRequestedAt: &requested,
+	}
+
+	peer.Renew(time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.False(t, peer.IsDisabled())
+	assert.Empty(t, peer.DisabledReason)
+	assert.False(t, peer.IsRenewalRequested())
+	assert.Equal(t, 1, peer.RenewalCount)
+
+	peer.Disabled = &disabled
+	peer.DisabledReason = DisabledReasonAdmin
+	peer.Renew(time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC))
+	assert.True(t, peer.IsDisabled(), "peers disabled for other reasons stay disabled")
+	assert.Equal(t, 2, peer.RenewalCount)
+}
+
+func TestExpiryDateChanged(t *testing.T) {
+	a := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	b := time.Date(2025, 3, 10, 18, 0, 0, 0, time.UTC)
+	c := time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC)
+
+	assert.False(t, ExpiryDateChanged(nil, nil))
+	assert.True(t, ExpiryDateChanged(&a, nil))
+	assert.False(t, ExpiryDateChanged(&a, &b))
+	assert.True(t, ExpiryDateChanged(&a, &c))
+}
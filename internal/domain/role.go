@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Permission is a fine-grained access right. Admins implicitly have all permissions,
+// other users receive permissions through their roles.
+type Permission string
+
+const (
+	PermissionInterfacesRead  Permission = "interfaces:read"
+	PermissionInterfacesWrite Permission = "interfaces:write" // edit and delete interfaces, including IP ranges
+	PermissionPeersRead       Permission = "peers:read"
+	PermissionPeersManage     Permission = "peers:manage" // renew peers, send mails, handle share links and configs
+	PermissionPeersWrite      Permission = "peers:write"  // create, edit, move and delete peers
+	PermissionUsersRead       Permission = "users:read"
+	PermissionAuditRead       Permission = "audit:read"
+)
+
+// KnownPermissions lists all known permissions.
+var KnownPermissions = []Permission{
+	PermissionInterfacesRead,
+	PermissionInterfacesWrite,
+	PermissionPeersRead,
+	PermissionPeersManage,
+	PermissionPeersWrite,
+	PermissionUsersRead,
+	PermissionAuditRead,
+}
+
+// Role bundles a set of permissions. Roles can be assigned to users directly or can be mapped from
+// LDAP groups and OAuth claims.
+type Role string
+
+const (
+	RoleAuditor  Role = "auditor"  // read-only access to everything, including the audit log
+	RoleHelpdesk Role = "helpdesk" // read access, can renew peers and send configurations, cannot edit interfaces
+
+	// roleOperatorPrefix is followed by the interface identifier. Operators have full control over the
+	// given interface and its peers.
+	roleOperatorPrefix = "operator:"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleAuditor: {
+		PermissionInterfacesRead,
+		PermissionPeersRead,
+		PermissionUsersRead,
+		PermissionAuditRead,
+	},
+	RoleHelpdesk: {
+		PermissionInterfacesRead,
+		PermissionPeersRead,
+		PermissionPeersManage,
+		PermissionUsersRead,
+	},
+}
+
+var operatorPermissions = []Permission{
+	PermissionInterfacesRead,
+	PermissionInterfacesWrite,
+	PermissionPeersRead,
+	PermissionPeersManage,
+	PermissionPeersWrite,
+	PermissionUsersRead,
+}
+
+// OperatorRole returns the operator role for the given interface.
+func OperatorRole(id InterfaceIdentifier) Role {
+	return Role(roleOperatorPrefix + string(id))
+}
+
+// ParseRoles converts the given strings to roles. Empty and unknown roles are skipped.
+func ParseRoles(values ...string) []Role {
+	var roles []Role
+	for _, value := range values {
+		role := Role(strings.TrimSpace(value))
+		if role.Validate() != nil || slices.Contains(roles, role) {
+			continue
+		}
+		roles = append(roles, role)
+	}
+
+	return roles
+}
+
+// Validate checks if the role is known.
+func (r Role) Validate() error {
+	if _, ok := rolePermissions[r]; ok {
+		return nil
+	}
+	if strings.HasPrefix(string(r), roleOperatorPrefix) && r.Interface() != "" {
+		return nil
+	}
+
+	return fmt.Errorf("unknown role %q: %w", r, ErrInvalidData)
+}
+
+// ValidateRoles checks if all given roles are known.
+func ValidateRoles(roles []Role) error {
+	for _, role := range roles {
+		if err := role.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Interface returns the interface the role is limited to. For global roles, an empty identifier is returned.
+func (r Role) Interface() InterfaceIdentifier {
+	iface, ok := strings.CutPrefix(string(r), roleOperatorPrefix)
+	if !ok {
+		return ""
+	}
+
+	return InterfaceIdentifier(strings.TrimSpace(iface))
+}
+
+// Permissions returns the permissions that are granted by the role.
+func (r Role) Permissions() []Permission {
+	if permissions, ok := rolePermissions[r]; ok {
+		return permissions
+	}
+	if r.Interface() != "" {
+		return operatorPermissions
+	}
+
+	return nil
+}
+
+// HasPermission checks if the role grants the permission for the given interface.
+// If no interface is given, the permission must be granted for at least one interface.
+func (r Role) HasPermission(permission Permission, iface InterfaceIdentifier) bool {
+	if !slices.Contains(r.Permissions(), permission) {
+		return false
+	}
+
+	roleIface := r.Interface()
+	return iface == "" || roleIface == "" || roleIface == iface
+}
+
+// PermissionsOfRoles returns all permissions that the given roles grant for at least one interface.
+func PermissionsOfRoles(roles []Role) []Permission {
+	var permissions []Permission
+	for _, permission := range KnownPermissions {
+		if RolesHavePermission(roles, permission, "") {
+			permissions = append(permissions, permission)
+		}
+	}
+
+	return permissions
+}
+
+// RolesHavePermission checks if any of the given roles grants the permission for the given interface.
+func RolesHavePermission(roles []Role, permission Permission, iface InterfaceIdentifier) bool {
+	for _, role := range roles {
+		if role.HasPermission(permission, iface) {
+			return true
+		}
+	}
+
+	return false
+}
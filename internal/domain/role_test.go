@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRole_Validate(t *testing.T) {
+	assert.NoError(t, RoleAuditor.Validate())
+	assert.NoError(t, RoleHelpdesk.Validate())
+	assert.NoError(t, OperatorRole("wg0").Validate())
+	assert.ErrorIs(t, Role("operator:").Validate(), ErrInvalidData)
+	assert.ErrorIs(t, Role("superuser").Validate(), ErrInvalidData)
+}
+
+func TestParseRoles(t *testing.T) {
+	roles := ParseRoles(" auditor ", "unknown", "", "operator:wg0", "auditor")
+	assert.Equal(t, []Role{RoleAuditor, OperatorRole("wg0")}, roles)
+}
+
+func TestRole_HasPermission(t *testing.T) {
+	assert.True(t, RoleAuditor.HasPermission(PermissionAuditRead, ""))
+	assert.True(t, RoleAuditor.HasPermission(PermissionPeersRead, "wg0"))
+	assert.False(t, RoleAuditor.HasPermission(PermissionPeersManage, "wg0"))
+
+	assert.True(t, RoleHelpdesk.HasPermission(PermissionPeersManage, "wg0"))
+	assert.False(t, RoleHelpdesk.HasPermission(PermissionPeersWrite, "wg0"))
+	assert.False(t, RoleHelpdesk.HasPermission(PermissionInterfacesWrite, "wg0"))
+
+	operator := OperatorRole("wg0")
+	assert.Equal(t, InterfaceIdentifier("wg0"), operator.Interface())
+	assert.True(t, operator.HasPermission(PermissionInterfacesWrite, "wg0"))
+	assert.True(t, operator.HasPermission(PermissionPeersWrite, ""), "granted for at least one interface")
+	assert.False(t, operator.HasPermission(PermissionPeersWrite, "wg1"))
+	assert.False(t, operator.HasPermission(PermissionAuditRead, ""))
+}
+
+func TestValidatePermission(t *testing.T) {
+	ctx := SetUserInfo(context.Background(), &ContextUserInfo{Id: "op", Roles: []Role{OperatorRole("wg0")}})
+
+	assert.NoError(t, ValidatePermission(ctx, PermissionPeersWrite, "wg0"))
+	assert.ErrorIs(t, ValidatePermission(ctx, PermissionPeersWrite, "wg1"), ErrNoPermission)
+	assert.NoError(t, ValidateUserOrPermission(ctx, "op", PermissionPeersWrite, "wg1"), "own data")
+	assert.ErrorIs(t, ValidateUserOrPermission(ctx, "other", PermissionPeersWrite, "wg1"), ErrNoPermission)
+
+	adminCtx := SetUserInfo(context.Background(), SystemAdminContextUserInfo())
+	assert.NoError(t, ValidatePermission(adminCtx, PermissionAuditRead, ""))
+}
@@ -0,0 +1,235 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/h44z/wg-portal/internal/config"
+)
+
+type SecondFactorMethod string
+
+const (
+	SecondFactorTotp     SecondFactorMethod = "totp"     // a code of the TOTP authenticator app
+	SecondFactorRecovery SecondFactorMethod = "recovery" // a single-use recovery code
+	SecondFactorWebAuthn SecondFactorMethod = "webauthn" // a registered passkey
+)
+
+// SecondFactorChallenge describes the second factor that is required to complete a password login.
+type SecondFactorChallenge struct {
+	Methods            []SecondFactorMethod // the methods that can be used to complete the login
+	EnrollmentRequired bool                 // the second factor is enforced, but the user has not enrolled TOTP yet
+}
+
+// TotpEnrollment contains the data that is needed to set up a TOTP authenticator app.
+type TotpEnrollment struct {
+	Secret string // the base32 encoded shared secret
+	Uri    string // the otpauth:// URI of the secret
+	QrCode []byte // the URI as PNG encoded qr code
+}
+
+const (
+	TotpDigits = 6
+	TotpPeriod = 30 * time.Second
+
+	totpSecretLength  = 20 // 160 bits, as recommended by RFC 4226
+	totpSkew          = 1  // the number of time steps before and after the current one that are accepted
+	recoveryCodeBytes = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTotpSecret generates a new random base32 encoded TOTP secret.
+func NewTotpSecret() (string, error) {
+	secret := make([]byte, totpSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TotpUri returns the otpauth:// URI of the given secret, it is understood by all common authenticator apps.
+func TotpUri(issuer, account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprintf("%d", TotpDigits))
+	params.Set("period", fmt.Sprintf("%d", int(TotpPeriod.Seconds())))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// TotpCode calculates the TOTP code (RFC 6238) of the given secret at the given time.
+func TotpCode(secret string, t time.Time) (string, error) {
+	key, err := decodeTotpSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return totpCode(key, totpCounter(t)), nil
+}
+
+// NewRecoveryCodes generates the given number of random recovery codes. The plain codes are returned along with
+// their hashes, only the hashes are persisted.
+func NewRecoveryCodes(count int) (codes, hashes []string, err error) {
+	codes = make([]string, count)
+	hashes = make([]string, count)
+	for i := range codes {
+		raw := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(totpEncoding.EncodeToString(raw))
+		codes[i] = code[:8] + "-" + code[8:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+// HasTotp returns true if the user has confirmed the enrollment of a TOTP authenticator app.
+func (u *User) HasTotp() bool {
+	return u.TotpEnabled != nil && u.TotpSecret != ""
+}
+
+// SecondFactorEnforced checks if the configuration enforces a second factor for the password login of the user.
+// The role "admin" matches all admins. A second factor is only enforced if TOTP enrollment is possible.
+func (u *User) SecondFactorEnforced(cfg config.TwoFactor) bool {
+	if !cfg.TotpEnabled {
+		return false
+	}
+	if slices.Contains(cfg.EnforceSources, string(u.Source)) {
+		return true
+	}
+	for _, role := range cfg.EnforceRoles {
+		if role == "admin" && u.IsAdmin {
+			return true
+		}
+		if slices.Contains(u.Roles, Role(role)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SecondFactorChallenge returns the challenge that must be solved to complete a password login.
+// If no second factor is required, nil is returned.
+func (u *User) SecondFactorChallenge(cfg config.TwoFactor, webAuthnEnabled bool) *SecondFactorChallenge {
+	hasWebAuthn := webAuthnEnabled && len(u.WebAuthnCredentialList) > 0
+	enforced := u.SecondFactorEnforced(cfg)
+	if !u.HasTotp() && !enforced {
+		return nil // passkeys alone do not activate the second factor, they can be used for passwordless logins
+	}
+
+	challenge := &SecondFactorChallenge{}
+	if u.HasTotp() {
+		challenge.Methods = append(challenge.Methods, SecondFactorTotp)
+		if len(u.TotpRecoveryCodes) > 0 {
+			challenge.Methods = append(challenge.Methods, SecondFactorRecovery)
+		}
+	}
+	if hasWebAuthn {
+		challenge.Methods = append(challenge.Methods, SecondFactorWebAuthn)
+	}
+	challenge.EnrollmentRequired = len(challenge.Methods) == 0
+
+	return challenge
+}
+
+// VerifyTotp checks the given code against the TOTP secret of the user. Codes of the previous and the next time
+// step are accepted as well. Each code can only be used once, the last accepted time step is stored in the user.
+func (u *User) VerifyTotp(code string, now time.Time) error {
+	if u.TotpSecret == "" {
+		return fmt.Errorf("totp not enrolled: %w", ErrSecondFactorInvalid)
+	}
+
+	key, err := decodeTotpSecret(u.TotpSecret)
+	if err != nil {
+		return err
+	}
+
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	current := int64(totpCounter(now))
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= u.TotpLastCounter {
+			continue // the code has already been used
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, uint64(step))), []byte(code)) == 1 {
+			u.TotpLastCounter = step
+			return nil
+		}
+	}
+
+	return ErrSecondFactorInvalid
+}
+
+// UseRecoveryCode checks the given recovery code and removes it from the unused codes of the user.
+func (u *User) UseRecoveryCode(code string) error {
+	hash := hashRecoveryCode(code)
+	for i, storedHash := range u.TotpRecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(storedHash), []byte(hash)) == 1 {
+			u.TotpRecoveryCodes = slices.Delete(u.TotpRecoveryCodes, i, i+1)
+			return nil
+		}
+	}
+
+	return ErrSecondFactorInvalid
+}
+
+// ResetTotp removes the TOTP secret and the recovery codes of the user.
+func (u *User) ResetTotp() {
+	u.TotpSecret = ""
+	u.TotpEnabled = nil
+	u.TotpLastCounter = 0
+	u.TotpRecoveryCodes = nil
+}
+
+func decodeTotpSecret(secret string) ([]byte, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid totp secret: %w", ErrInvalidData)
+	}
+
+	return key, nil
+}
+
+func totpCounter(t time.Time) uint64 {
+	return uint64(t.Unix() / int64(TotpPeriod.Seconds()))
+}
+
+func totpCode(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation, see RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", TotpDigits, value%1000000)
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	hash := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/h44z/wg-portal/internal/config"
+)
+
+// rfcTotpSecret is the base32 encoded SHA1 secret of the RFC 6238 test vectors.
+const rfcTotpSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTotpCode(t *testing.T) {
+	tests := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range tests {
+		code, err := TotpCode(rfcTotpSecret, time.Unix(unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, want, code, "time %d", unix)
+	}
+
+	_, err := TotpCode("not base32!", time.Now())
+	assert.ErrorIs(t, err, ErrInvalidData)
+}
+
+func TestUser_VerifyTotp(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	u := &User{TotpSecret: rfcTotpSecret}
+
+	previous, _ := TotpCode(rfcTotpSecret, now.Add(-TotpPeriod))
+	require.NoError(t, u.VerifyTotp(previous, now), "previous time step is accepted")
+	assert.ErrorIs(t, u.VerifyTotp(previous, now), ErrSecondFactorInvalid, "codes can not be reused")
+
+	assert.NoError(t, u.VerifyTotp("005 924", now))
+	assert.ErrorIs(t, u.VerifyTotp("005924", now), ErrSecondFactorInvalid)
+
+	old, _ := TotpCode(rfcTotpSecret, now.Add(-5*TotpPeriod))
+	assert.ErrorIs(t, u.VerifyTotp(old, now.Add(time.Hour)), ErrSecondFactorInvalid)
+
+	assert.ErrorIs(t, (&User{}).VerifyTotp("123456", now), ErrSecondFactorInvalid)
+}
+
+func TestUser_UseRecoveryCode(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes(3)
+	require.NoError(t, err)
+	require.Len(t, codes, 3)
+	assert.Regexp(t, `^[a-z2-7]{8}-[a-z2-7]{8}$`, codes[0])
+
+	u := &User{TotpRecoveryCodes: hashes}
+	assert.NoError(t, u.UseRecoveryCode(" "+codes[1]+" "))
+	assert.Len(t, u.TotpRecoveryCodes, 2)
+	assert.ErrorIs(t, u.UseRecoveryCode(codes[1]), ErrSecondFactorInvalid, "codes are single-use")
+	assert.NoError(t, u.UseRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))), "normalized")
+}
+
+func TestUser_SecondFactorChallenge(t *testing.T) {
+	cfg := config.TwoFactor{TotpEnabled: true, EnforceSources: []string{"ldap"}, EnforceRoles: []string{"admin"}}
+	now := time.Now()
+
+	dbUser := &User{Source: UserSourceDatabase}
+	assert.Nil(t, dbUser.SecondFactorChallenge(cfg, true), "optional and not enrolled")
+
+	dbUser.WebAuthnCredentialList = []UserWebauthnCredential{{}}
+	assert.Nil(t, dbUser.SecondFactorChallenge(cfg, true), "passkeys alone do not require a second factor")
+
+	dbUser.TotpSecret = rfcTotpSecret
+	dbUser.TotpEnabled = &now
+	dbUser.TotpRecoveryCodes = []string{"hash"}
+	assert.Equal(t, &SecondFactorChallenge{
+		Methods: []SecondFactorMethod{SecondFactorTotp, SecondFactorRecovery, SecondFactorWebAuthn},
+	}, dbUser.SecondFactorChallenge(cfg, true))
+	assert.Equal(t, []SecondFactorMethod{SecondFactorTotp, SecondFactorRecovery},
+		dbUser.SecondFactorChallenge(cfg, false).Methods)
+
+	ldapUser := &User{Source: UserSourceLdap}
+	assert.Equal(t, &SecondFactorChallenge{EnrollmentRequired: true}, ldapUser.SecondFactorChallenge(cfg, true))
+
+	admin := &User{Source: UserSourceDatabase, IsAdmin: true, WebAuthnCredentialList: []UserWebauthnCredential{{}}}
+	assert.Equal(t, &SecondFactorChallenge{Methods: []SecondFactorMethod{SecondFactorWebAuthn}},
+		admin.SecondFactorChallenge(cfg, true))
+
+	cfg.TotpEnabled = false
+	assert.Nil(t, ldapUser.SecondFactorChallenge(cfg, true), "not enforced if enrollment is impossible")
+}
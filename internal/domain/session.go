@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+type PeerDisconnectReason string
+
+const (
+	DisconnectReasonHandshakeTimeout PeerDisconnectReason = "handshake-timeout" // no handshake within the last 2 minutes
+	DisconnectReasonPingTimeout      PeerDisconnectReason = "ping-timeout"      // the peer stopped responding to pings
+)
+
+// PeerSession is a single connection session of a peer. A session starts when the peer is detected as connected
+// and ends when the disconnect is detected. Active sessions have no end time.
+type PeerSession struct {
+	Id          uint64              `gorm:"primaryKey;autoIncrement"`
+	PeerId      PeerIdentifier      `gorm:"index:idx_session_peer,priority:1"`
+	InterfaceId InterfaceIdentifier `gorm:"index"`
+
+	StartedAt time.Time  `gorm:"index:idx_session_peer,priority:2"`
+	EndedAt   *time.Time `gorm:"index"`
+
+	Endpoints        []string `gorm:"serializer:json"` // all endpoints seen during the session
+	BytesReceived    uint64   // bytes received from the peer during the session
+	BytesTransmitted uint64   // bytes sent to the peer during the session
+
+	DisconnectReason PeerDisconnectReason
+}
+
+// IsActive returns true if the session has not ended yet.
+func (s *PeerSession) IsActive() bool {
+	return s.EndedAt == nil
+}
+
+// Duration returns the duration of the session. For active sessions, the duration up to now is returned.
+func (s *PeerSession) Duration() time.Duration {
+	if s.EndedAt == nil {
+		return time.Since(s.StartedAt)
+	}
+	return s.EndedAt.Sub(s.StartedAt)
+}
+
+// AddEndpoint records the given endpoint if it has not been seen during the session yet.
+func (s *PeerSession) AddEndpoint(endpoint string) {
+	if endpoint == "" || slices.Contains(s.Endpoints, endpoint) {
+		return
+	}
+	s.Endpoints = append(s.Endpoints, endpoint)
+}
+
+// End marks the session as ended at the given time. The end time is never before the start time.
+func (s *PeerSession) End(endedAt time.Time, reason PeerDisconnectReason) {
+	if endedAt.Before(s.StartedAt) {
+		endedAt = s.StartedAt
+	}
+	s.EndedAt = &endedAt
+	s.DisconnectReason = reason
+}
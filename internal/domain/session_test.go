@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerSession_AddEndpoint(t *testing.T) {
+	s := PeerSession{}
+	s.AddEndpoint("1.2.3.4:51820")
+	s.AddEndpoint("")
+	s.AddEndpoint("1.2.3.4:51820")
+	s.AddEndpoint("5.6.7.8:51820")
+
+	assert.Equal(t, []string{"1.2.3.4:51820", "5.6.7.8:51820"}, s.Endpoints)
+}
+
+func TestPeerSession_End(t *testing.T) {
+	start := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+	s := PeerSession{StartedAt: start}
+	assert.True(t, s.IsActive())
+
+	s.End(start.Add(90*time.Minute), DisconnectReasonHandshakeTimeout)
+	assert.False(t, s.IsActive())
+	assert.Equal(t, 90*time.Minute, s.Duration())
+	assert.Equal(t, DisconnectReasonHandshakeTimeout, s.DisconnectReason)
+
+	// the end time is never before the start time
+	s = PeerSession{StartedAt: start}
+	s.End(start.Add(-time.Minute), DisconnectReasonPingTimeout)
+	assert.Equal(t, time.Duration(0), s.Duration())
+}
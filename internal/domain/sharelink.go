@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PeerShareLinkIdentifier string
+
+// PeerShareLink is a short-lived token that grants access to the configuration of a single peer
+// without the need of a login session.
+type PeerShareLink struct {
+	Identifier PeerShareLinkIdentifier `gorm:"primaryKey;column:identifier"` // the public part of the token
+	CreatedBy  string                  `gorm:"column:created_by"`
+	CreatedAt  time.Time               `gorm:"column:created_at"`
+
+	PeerIdentifier PeerIdentifier `gorm:"index;column:peer_identifier"` // the peer that is shared through the link
+	TokenHash      string         `gorm:"column:token_hash"`            // the signed hash of the full token
+
+	ExpiresAt  time.Time  `gorm:"index;column:expires_at"` // the link can not be used after this timestamp
+	MaxUses    int        `gorm:"column:max_uses"`         // maximum number of downloads, 0 means unlimited
+	UseCount   int        `gorm:"column:use_count"`        // number of successful downloads
+	LastUsedAt *time.Time `gorm:"column:last_used_at"`
+	Revoked    *time.Time `gorm:"column:revoked"` // if this field is set, the link can no longer be used
+	RevokedBy  string     `gorm:"column:revoked_by"`
+
+	Token string `gorm:"-"` // the plain token, only available right after creation
+}
+
+// NewPeerShareLink creates a new share link for the given peer. The plain token is stored in the Token field,
+// only a signed hash of the token is persisted.
+func NewPeerShareLink(
+	signingKey []byte,
+	peerId PeerIdentifier,
+	creator UserIdentifier,
+	validity time.Duration,
+	maxUses int,
+) (*PeerShareLink, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	linkId := PeerShareLinkIdentifier(strings.ReplaceAll(uuid.New().String(), "-", ""))
+	token := string(linkId) + "." + base64.RawURLEncoding.EncodeToString(secret)
+
+	now := time.Now()
+	link := &PeerShareLink{
+		Identifier:     linkId,
+		CreatedBy:      string(creator),
+		CreatedAt:      now,
+		PeerIdentifier: peerId,
+		TokenHash:      signShareLinkToken(signingKey, token),
+		ExpiresAt:      now.Add(validity),
+		MaxUses:        maxUses,
+		UseCount:       0,
+		Token:          token,
+	}
+
+	return link, nil
+}
+
+// ParseShareLinkToken extracts the link identifier from the given plain token.
+func ParseShareLinkToken(token string) (PeerShareLinkIdentifier, error) {
+	linkId, secret, found := strings.Cut(token, ".")
+	if !found || linkId == "" || secret == "" {
+		return "", ErrShareLinkInvalid
+	}
+
+	return PeerShareLinkIdentifier(linkId), nil
+}
+
+// VerifyToken checks that the given plain token belongs to this share link.
+func (l *PeerShareLink) VerifyToken(signingKey []byte, token string) error {
+	expected, err := hex.DecodeString(l.TokenHash)
+	if err != nil {
+		return ErrShareLinkInvalid
+	}
+	actual, _ := hex.DecodeString(signShareLinkToken(signingKey, token))
+
+	if !hmac.Equal(expected, actual) {
+		return ErrShareLinkInvalid
+	}
+
+	return nil
+}
+
+func (l *PeerShareLink) IsExpired() bool {
+	return l.ExpiresAt.Before(time.Now())
+}
+
+func (l *PeerShareLink) IsRevoked() bool {
+	return l.Revoked != nil
+}
+
+func (l *PeerShareLink) IsExhausted() bool {
+	if l.MaxUses <= 0 {
+		return false
+	}
+	return l.UseCount >= l.MaxUses
+}
+
+// CheckUsable returns an error if the share link can no longer be used.
+func (l *PeerShareLink) CheckUsable() error {
+	switch {
+	case l.IsRevoked():
+		return ErrShareLinkRevoked
+	case l.IsExpired():
+		return ErrShareLinkExpired
+	case l.IsExhausted():
+		return ErrShareLinkExhausted
+	}
+
+	return nil
+}
+
+// RegisterUse increments the usage counter of the share link.
+func (l *PeerShareLink) RegisterUse() {
+	now := time.Now()
+	l.UseCount++
+	l.LastUsedAt = &now
+}
+
+// Revoke invalidates the share link.
+func (l *PeerShareLink) Revoke(by UserIdentifier) {
+	if l.IsRevoked() {
+		return
+	}
+	now := time.Now()
+	l.Revoked = &now
+	l.RevokedBy = string(by)
+}
+
+func signShareLinkToken(signingKey []byte, token string) string {
+	mac := hmac.New(sha256.New, signingKey)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
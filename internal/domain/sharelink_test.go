@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPeerShareLink(t *testing.T) {
+	key := []byte("secret")
+	link, err := NewPeerShareLink(key, "peer", "admin", time.Hour, 1)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, link.Token)
+	assert.NotEqual(t, link.Token, link.TokenHash)
+	assert.Equal(t, PeerIdentifier("peer"), link.PeerIdentifier)
+	assert.Equal(t, "admin", link.CreatedBy)
+
+	linkId, err := ParseShareLinkToken(link.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, link.Identifier, linkId)
+}
+
+func TestParseShareLinkToken(t *testing.T) {
+	_, err := ParseShareLinkToken("")
+	assert.ErrorIs(t, err, ErrShareLinkInvalid)
+
+	_, err = ParseShareLinkToken("abc")
+	assert.ErrorIs(t, err, ErrShareLinkInvalid)
+
+	_, err = ParseShareLinkToken(".abc")
+	assert.ErrorIs(t, err, ErrShareLinkInvalid)
+
+	linkId, err := ParseShareLinkToken("abc.def")
+	assert.NoError(t, err)
+	assert.Equal(t, PeerShareLinkIdentifier("abc"), linkId)
+}
+
+func TestPeerShareLink_VerifyToken(t *testing.T) {
+	key := []byte("secret")
+	link, err := NewPeerShareLink(key, "peer", "admin", time.Hour, 1)
+	assert.NoError(t, err)
+
+	assert.NoError(t, link.VerifyToken(key, link.Token))
+	assert.ErrorIs(t, link.VerifyToken(key, link.Token+"x"), ErrShareLinkInvalid)
+	assert.ErrorIs(t, link.VerifyToken([]byte("other"), link.Token), ErrShareLinkInvalid)
+}
+
+func TestPeerShareLink_CheckUsable(t *testing.T) {
+	link, err := NewPeerShareLink([]byte("secret"), "peer", "admin", time.Hour, 2)
+	assert.NoError(t, err)
+	assert.NoError(t, link.CheckUsable())
+
+	link.RegisterUse()
+	assert.NoError(t, link.CheckUsable())
+	assert.NotNil(t, link.LastUsedAt)
+
+	link.RegisterUse()
+	assert.ErrorIs(t, link.CheckUsable(), ErrShareLinkExhausted)
+
+	link.UseCount = 0
+	link.ExpiresAt = time.Now().Add(-time.Minute)
+	assert.ErrorIs(t, link.CheckUsable(), ErrShareLinkExpired)
+
+	link.ExpiresAt = time.Now().Add(time.Minute)
+	link.Revoke("admin")
+	assert.ErrorIs(t, link.CheckUsable(), ErrShareLinkRevoked)
+	assert.Equal(t, "admin", link.RevokedBy)
+}
+
+func TestPeerShareLink_IsExhausted_Unlimited(t *testing.T) {
+	link := &PeerShareLink{MaxUses: 0, UseCount: 100}
+	assert.False(t, link.IsExhausted())
+}
@@ -0,0 +1,221 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+	"slices"
+
+	"github.com/h44z/wg-portal/internal"
+)
+
+type SiteNetworkIdentifier string
+
+type SiteIdentifier string
+
+type SiteTopology string
+
+const (
+	SiteTopologyHubAndSpoke SiteTopology = "hub-and-spoke" // spokes connect to the hub, traffic between spokes is routed via the hub
+	SiteTopologyFullMesh    SiteTopology = "full-mesh"     // every site connects to all other sites
+	SiteTopologyPartialMesh SiteTopology = "partial-mesh"  // only explicitly linked sites connect to each other
+)
+
+// SiteNetwork connects several sites, for example, office locations, with WireGuard tunnels.
+// The tunnels between the sites are derived from the topology of the network.
+type SiteNetwork struct {
+	BaseModel
+
+	Identifier          SiteNetworkIdentifier `gorm:"primaryKey;column:identifier"`
+	DisplayName         string                `gorm:"column:display_name"`
+	Topology            SiteTopology          `gorm:"column:topology"`
+	PersistentKeepalive int                   `gorm:"column:persistent_keepalive"` // keep-alive interval of all tunnels, 0 to disable
+
+	Sites []Site     `gorm:"-"` // all sites of the network
+	Links []SiteLink `gorm:"-"` // the tunnels of a partial mesh, ignored for other topologies
+}
+
+// Site is a single location of a site network.
+type Site struct {
+	BaseModel
+
+	NetworkIdentifier SiteNetworkIdentifier `gorm:"primaryKey;column:network_identifier"`
+	Identifier        SiteIdentifier        `gorm:"primaryKey;column:identifier"`
+	DisplayName       string                `gorm:"column:display_name"`
+	Hub               bool                  `gorm:"column:hub"` // only used for the hub-and-spoke topology
+
+	// InterfaceIdentifier links the site to an interface that is managed by this portal. The keys, the listen port
+	// and the addresses of the site are taken from the interface and the tunnels to the other sites are created
+	// as peers of the interface. Empty for remote sites.
+	InterfaceIdentifier InterfaceIdentifier `gorm:"index;column:interface_identifier"`
+
+	KeyPair    `gorm:"embedded"`
+	Endpoint   string `gorm:"column:endpoint"`    // the public address (host:port) of the site, empty if it is not reachable
+	ListenPort int    `gorm:"column:listen_port"` // the listen port of the site
+
+	AddressStr     string `gorm:"column:addresses"`    // the tunnel addresses of the site, comma separated
+	LanPrefixesStr string `gorm:"column:lan_prefixes"` // the local networks behind the site, comma separated
+}
+
+// Addresses returns the tunnel addresses of the site.
+func (s Site) Addresses() []Cidr {
+	addresses, _ := CidrsFromString(s.AddressStr)
+	return addresses
+}
+
+// LanPrefixes returns the local networks behind the site.
+func (s Site) LanPrefixes() []Cidr {
+	prefixes, _ := CidrsFromString(s.LanPrefixesStr)
+	return prefixes
+}
+
+// routes returns all prefixes that are reachable through a tunnel to the site.
+func (s Site) routes() []Cidr {
+	routes := make([]Cidr, 0)
+	for _, address := range s.Addresses() {
+		routes = append(routes, address.HostAddr())
+	}
+	return append(routes, s.LanPrefixes()...)
+}
+
+// SiteLink is a tunnel between two sites of a partial mesh.
+type SiteLink struct {
+	NetworkIdentifier SiteNetworkIdentifier `gorm:"primaryKey;column:network_identifier"`
+	SiteA             SiteIdentifier        `gorm:"primaryKey;column:site_a"`
+	SiteB             SiteIdentifier        `gorm:"primaryKey;column:site_b"`
+}
+
+// Connects returns true if the link connects the two given sites.
+func (l SiteLink) Connects(a, b SiteIdentifier) bool {
+	return (l.SiteA == a && l.SiteB == b) || (l.SiteA == b && l.SiteB == a)
+}
+
+// SitePeer is the tunnel endpoint of a neighbor site, as seen from another site.
+type SitePeer struct {
+	Site                Site
+	AllowedIPs          []Cidr // the tunnel address and the local networks that are routed to the neighbor
+	PersistentKeepalive int
+}
+
+// Validate checks the topology, the sites and the links of the network.
+func (n SiteNetwork) Validate() error {
+	switch n.Topology {
+	case SiteTopologyHubAndSpoke, SiteTopologyFullMesh, SiteTopologyPartialMesh:
+	default:
+		return fmt.Errorf("invalid topology %q: %w", n.Topology, ErrInvalidData)
+	}
+	if n.PersistentKeepalive < 0 {
+		return fmt.Errorf("persistent keep-alive must not be negative: %w", ErrInvalidData)
+	}
+
+	hubs := 0
+	var prefixes []Cidr
+	for i, site := range n.Sites {
+		if site.Identifier == "" {
+			return fmt.Errorf("site identifier must not be empty: %w", ErrInvalidData)
+		}
+		if slices.ContainsFunc(n.Sites[:i], func(s Site) bool { return s.Identifier == site.Identifier }) {
+			return fmt.Errorf("duplicate site %s: %w", site.Identifier, ErrInvalidData)
+		}
+		if site.Hub {
+			hubs++
+		}
+
+		if _, err := CidrsFromString(site.AddressStr); err != nil {
+			return fmt.Errorf("invalid addresses of site %s: %w", site.Identifier, ErrInvalidData)
+		}
+		lanPrefixes, err := CidrsFromString(site.LanPrefixesStr)
+		if err != nil {
+			return fmt.Errorf("invalid lan prefixes of site %s: %w", site.Identifier, ErrInvalidData)
+		}
+		for _, prefix := range lanPrefixes {
+			if slices.ContainsFunc(prefixes, func(p Cidr) bool { return p.Prefix().Overlaps(prefix.Prefix()) }) {
+				return fmt.Errorf("lan prefix %s of site %s overlaps another site: %w", prefix.Cidr,
+					site.Identifier, ErrInvalidData)
+			}
+		}
+		prefixes = append(prefixes, lanPrefixes...)
+	}
+
+	if n.Topology == SiteTopologyHubAndSpoke && len(n.Sites) > 0 && hubs != 1 {
+		return fmt.Errorf("a hub-and-spoke network requires exactly one hub: %w", ErrInvalidData)
+	}
+
+	if n.Topology == SiteTopologyPartialMesh {
+		for _, link := range n.Links {
+			if link.SiteA == link.SiteB {
+				return fmt.Errorf("site %s cannot be linked to itself: %w", link.SiteA, ErrInvalidData)
+			}
+			_, errA := n.Site(link.SiteA)
+			_, errB := n.Site(link.SiteB)
+			if errA != nil || errB != nil {
+				return fmt.Errorf("link %s - %s references an unknown site: %w", link.SiteA, link.SiteB,
+					ErrInvalidData)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Peers returns the neighbors of the given site, including the prefixes that are routed through each tunnel.
+// In a hub-and-spoke network, spokes route the prefixes of all other spokes through the hub.
+func (n SiteNetwork) Peers(id SiteIdentifier) []SitePeer {
+	site, err := n.Site(id)
+	if err != nil {
+		return nil
+	}
+
+	var peers []SitePeer
+	for _, neighbor := range n.Sites {
+		if neighbor.Identifier == id || !n.connected(*site, neighbor) {
+			continue
+		}
+
+		peer := SitePeer{
+			Site:                neighbor,
+			AllowedIPs:          neighbor.routes(),
+			PersistentKeepalive: n.PersistentKeepalive,
+		}
+		if n.Topology == SiteTopologyHubAndSpoke && neighbor.Hub {
+			for _, spoke := range n.Sites {
+				if !spoke.Hub && spoke.Identifier != id {
+					peer.AllowedIPs = append(peer.AllowedIPs, spoke.routes()...)
+				}
+			}
+		}
+		peers = append(peers, peer)
+	}
+
+	return peers
+}
+
+func (n SiteNetwork) connected(a, b Site) bool {
+	switch n.Topology {
+	case SiteTopologyHubAndSpoke:
+		return a.Hub != b.Hub
+	case SiteTopologyFullMesh:
+		return true
+	case SiteTopologyPartialMesh:
+		return slices.ContainsFunc(n.Links, func(l SiteLink) bool { return l.Connects(a.Identifier, b.Identifier) })
+	default:
+		return false
+	}
+}
+
+// Site returns the site with the given identifier.
+func (n SiteNetwork) Site(id SiteIdentifier) (*Site, error) {
+	idx := slices.IndexFunc(n.Sites, func(s Site) bool { return s.Identifier == id })
+	if idx < 0 {
+		return nil, fmt.Errorf("site %s: %w", id, ErrNotFound)
+	}
+	return &n.Sites[idx], nil
+}
+
+// GetConfigFileName returns the path of the configuration file of the site, relative to the config storage path.
+func (s Site) GetConfigFileName() string {
+	network := allowedFileNameRegex.ReplaceAllString(string(s.NetworkIdentifier), "")
+	filename := allowedFileNameRegex.ReplaceAllString(string(s.Identifier), "")
+	filename = internal.TruncateString(filename, 16)
+
+	return path.Join("sites", network, filename+".conf")
+}
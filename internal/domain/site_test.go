@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSites() []Site {
+	return []Site{
+		{Identifier: "hq", Hub: true, AddressStr: "10.99.0.1/24", LanPrefixesStr: "192.168.0.0/24"},
+		{Identifier: "berlin", AddressStr: "10.99.0.2/24", LanPrefixesStr: "192.168.1.0/24"},
+		{Identifier: "vienna", AddressStr: "10.99.0.3/24", LanPrefixesStr: "192.168.2.0/24,192.168.3.0/24"},
+	}
+}
+
+func allowedIPs(peer SitePeer) []string {
+	return CidrsToStringSlice(peer.AllowedIPs)
+}
+
+func TestSiteNetwork_Validate(t *testing.T) {
+	assert.NoError(t, SiteNetwork{Topology: SiteTopologyHubAndSpoke, Sites: testSites()}.Validate())
+	assert.NoError(t, SiteNetwork{Topology: SiteTopologyFullMesh, Sites: testSites()}.Validate())
+
+	assert.ErrorIs(t, SiteNetwork{Topology: "ring", Sites: testSites()}.Validate(), ErrInvalidData)
+
+	noHub := testSites()
+	noHub[0].Hub = false
+	assert.ErrorIs(t, SiteNetwork{Topology: SiteTopologyHubAndSpoke, Sites: noHub}.Validate(), ErrInvalidData)
+
+	overlapping := testSites()
+	overlapping[2].LanPrefixesStr = "192.168.0.128/25"
+	assert.ErrorIs(t, SiteNetwork{Topology: SiteTopologyFullMesh, Sites: overlapping}.Validate(), ErrInvalidData)
+
+	duplicate := append(testSites(), Site{Identifier: "hq"})
+	assert.ErrorIs(t, SiteNetwork{Topology: SiteTopologyFullMesh, Sites: duplicate}.Validate(), ErrInvalidData)
+
+	assert.ErrorIs(t, SiteNetwork{Topology: SiteTopologyPartialMesh, Sites: testSites(),
+		Links: []SiteLink{{SiteA: "hq", SiteB: "paris"}}}.Validate(), ErrInvalidData)
+	assert.ErrorIs(t, SiteNetwork{Topology: SiteTopologyPartialMesh, Sites: testSites(),
+		Links: []SiteLink{{SiteA: "hq", SiteB: "hq"}}}.Validate(), ErrInvalidData)
+}
+
+func TestSiteNetwork_PeersHubAndSpoke(t *testing.T) {
+	n := SiteNetwork{Topology: SiteTopologyHubAndSpoke, PersistentKeepalive: 25, Sites: testSites()}
+
+	hub := n.Peers("hq")
+	require.Len(t, hub, 2)
+	assert.Equal(t, SiteIdentifier("berlin"), hub[0].Site.Identifier)
+	assert.Equal(t, []string{"10.99.0.2/32", "192.168.1.0/24"}, allowedIPs(hub[0]))
+	assert.Equal(t, []string{"10.99.0.3/32", "192.168.2.0/24", "192.168.3.0/24"}, allowedIPs(hub[1]))
+	assert.Equal(t, 25, hub[0].PersistentKeepalive)
+
+	spoke := n.Peers("berlin")
+	require.Len(t, spoke, 1)
+	assert.Equal(t, SiteIdentifier("hq"), spoke[0].Site.Identifier)
+	assert.Equal(t, []string{"10.99.0.1/32", "192.168.0.0/24", "10.99.0.3/32", "192.168.2.0/24", "192.168.3.0/24"},
+		allowedIPs(spoke[0]), "other spokes are routed via the hub")
+
+	assert.Empty(t, n.Peers("paris"))
+}
+
+func TestSiteNetwork_PeersMesh(t *testing.T) {
+	full := SiteNetwork{Topology: SiteTopologyFullMesh, Sites: testSites()}
+	peers := full.Peers("berlin")
+	require.Len(t, peers, 2)
+	assert.Equal(t, []string{"10.99.0.1/32", "192.168.0.0/24"}, allowedIPs(peers[0]))
+	assert.Equal(t, []string{"10.99.0.3/32", "192.168.2.0/24", "192.168.3.0/24"}, allowedIPs(peers[1]))
+
+	partial := SiteNetwork{Topology: SiteTopologyPartialMesh, Sites: testSites(),
+		Links: []SiteLink{{SiteA: "vienna", SiteB: "berlin"}}}
+	peers = partial.Peers("berlin")
+	require.Len(t, peers, 1)
+	assert.Equal(t, SiteIdentifier("vienna"), peers[0].Site.Identifier)
+	assert.Empty(t, partial.Peers("hq"))
+}
@@ -0,0 +1,590 @@
+package domain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	"gopkg.in/yaml.v3"
+)
+
+// ManagedBySpec marks users, interfaces and peers that are managed by the declarative specification.
+const ManagedBySpec = "spec"
+
+// Spec is the declarative specification of users, interfaces and peers. Objects that are listed in the spec are
+// created or updated, objects that were created by the spec and are no longer listed are deleted.
+// Objects that are not managed by the spec are never touched.
+// The disabled flag of the spec only controls objects that were disabled by the spec, objects that were disabled
+// for other reasons (expiry, traffic quota, ...) are not re-enabled.
+type Spec struct {
+	Users      []UserSpec      `yaml:"users"`
+	Interfaces []InterfaceSpec `yaml:"interfaces"`
+	Peers      []PeerSpec      `yaml:"peers"`
+}
+
+// UserSpec is the declarative specification of a user. Managed users are database users without a password.
+type UserSpec struct {
+	Identifier UserIdentifier `yaml:"identifier"`
+	Email      string         `yaml:"email"`
+	Firstname  string         `yaml:"firstname"`
+	Lastname   string         `yaml:"lastname"`
+	Phone      string         `yaml:"phone"`
+	Department string         `yaml:"department"`
+	Notes      string         `yaml:"notes"`
+	IsAdmin    bool           `yaml:"is_admin"`
+	Roles      []Role         `yaml:"roles"`
+	Disabled   bool           `yaml:"disabled"`
+}
+
+// InterfaceSpec is the declarative specification of an interface. If no private key is given, a new key is
+// generated when the interface is created and kept afterward.
+type InterfaceSpec struct {
+	Identifier   InterfaceIdentifier `yaml:"identifier"`
+	DisplayName  string              `yaml:"display_name"`
+	Mode         InterfaceType       `yaml:"mode"`
+	PrivateKey   string              `yaml:"private_key"`
+	ListenPort   int                 `yaml:"listen_port"`
+	Addresses    []string            `yaml:"addresses"`
+	Dns          []string            `yaml:"dns"`
+	DnsSearch    []string            `yaml:"dns_search"`
+	Mtu          int                 `yaml:"mtu"`
+	FirewallMark uint32              `yaml:"firewall_mark"`
+	RoutingTable string              `yaml:"routing_table"`
+	Disabled     bool                `yaml:"disabled"`
+	PeerDefaults PeerDefaultsSpec    `yaml:"peer_defaults"`
+}
+
+// PeerDefaultsSpec contains the default settings for the peers of an interface.
+type PeerDefaultsSpec struct {
+	Networks            []string `yaml:"networks"`
+	Endpoint            string   `yaml:"endpoint"`
+	AllowedIPs          []string `yaml:"allowed_ips"`
+	Dns                 []string `yaml:"dns"`
+	Mtu                 int      `yaml:"mtu"`
+	PersistentKeepalive int      `yaml:"persistent_keepalive"`
+}
+
+// PeerSpec is the declarative specification of a peer. The peer is identified by its public key, the private key
+// stays with the client. The endpoint and the allowed IPs default to the interface defaults if they are empty.
+type PeerSpec struct {
+	PublicKey       string              `yaml:"public_key"`
+	Interface       InterfaceIdentifier `yaml:"interface"`
+	DisplayName     string              `yaml:"display_name"`
+	User            UserIdentifier      `yaml:"user"`
+	Addresses       []string            `yaml:"addresses"`
+	ExtraAllowedIPs []string            `yaml:"extra_allowed_ips"`
+	AllowedIPs      []string            `yaml:"allowed_ips"`
+	Endpoint        string              `yaml:"endpoint"`
+	Tags            []string            `yaml:"tags"`
+	Notes           string              `yaml:"notes"`
+	Disabled        bool                `yaml:"disabled"`
+}
+
+// ParseSpec decodes a spec in YAML or JSON format. Unknown fields are rejected.
+func ParseSpec(data []byte) (*Spec, error) {
+	var spec Spec
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&spec); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to decode spec: %w", errors.Join(err, ErrInvalidData))
+	}
+
+	return &spec, nil
+}
+
+// Merge appends all objects of the other spec.
+func (s *Spec) Merge(other *Spec) {
+	s.Users = append(s.Users, other.Users...)
+	s.Interfaces = append(s.Interfaces, other.Interfaces...)
+	s.Peers = append(s.Peers, other.Peers...)
+}
+
+// Normalize validates the spec and brings all values into their canonical form, so that they can be compared
+// with the stored objects.
+func (s *Spec) Normalize() error {
+	var errs []error
+
+	users := make(map[UserIdentifier]struct{}, len(s.Users))
+	for i := range s.Users {
+		u := &s.Users[i]
+		switch _, duplicate := users[u.Identifier]; {
+		case u.Identifier == "":
+			errs = append(errs, fmt.Errorf("user %d: missing identifier", i))
+		case duplicate:
+			errs = append(errs, fmt.Errorf("user %s: duplicate identifier", u.Identifier))
+		}
+		users[u.Identifier] = struct{}{}
+
+		if err := ValidateRoles(u.Roles); err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", u.Identifier, err))
+		}
+	}
+
+	interfaces := make(map[InterfaceIdentifier]struct{}, len(s.Interfaces))
+	for i := range s.Interfaces {
+		in := &s.Interfaces[i]
+		switch _, duplicate := interfaces[in.Identifier]; {
+		case in.Identifier == "":
+			errs = append(errs, fmt.Errorf("interface %d: missing identifier", i))
+		case duplicate:
+			errs = append(errs, fmt.Errorf("interface %s: duplicate identifier", in.Identifier))
+		}
+		interfaces[in.Identifier] = struct{}{}
+
+		if in.Mode == "" {
+			in.Mode = InterfaceTypeServer
+		}
+		if in.Mode != InterfaceTypeServer && in.Mode != InterfaceTypeClient && in.Mode != InterfaceTypeAny {
+			errs = append(errs, fmt.Errorf("interface %s: invalid mode %q", in.Identifier, in.Mode))
+		}
+		if in.PrivateKey != "" {
+			if _, err := wgtypes.ParseKey(in.PrivateKey); err != nil {
+				errs = append(errs, fmt.Errorf("interface %s: invalid private key", in.Identifier))
+			}
+		}
+		if in.ListenPort < 0 || in.ListenPort > 65535 {
+			errs = append(errs, fmt.Errorf("interface %s: invalid listen port %d", in.Identifier, in.ListenPort))
+		}
+
+		var err error
+		if in.Addresses, err = normalizeSpecCidrs(in.Addresses); err != nil {
+			errs = append(errs, fmt.Errorf("interface %s: invalid addresses: %w", in.Identifier, err))
+		}
+		if in.PeerDefaults.Networks, err = normalizeSpecCidrs(in.PeerDefaults.Networks); err != nil {
+			errs = append(errs, fmt.Errorf("interface %s: invalid peer networks: %w", in.Identifier, err))
+		}
+		if in.PeerDefaults.AllowedIPs, err = normalizeSpecCidrs(in.PeerDefaults.AllowedIPs); err != nil {
+			errs = append(errs, fmt.Errorf("interface %s: invalid peer allowed IPs: %w", in.Identifier, err))
+		}
+	}
+
+	peers := make(map[string]struct{}, len(s.Peers))
+	for i := range s.Peers {
+		p := &s.Peers[i]
+		switch _, duplicate := peers[p.PublicKey]; {
+		case p.PublicKey == "":
+			errs = append(errs, fmt.Errorf("peer %d: missing public key", i))
+		case duplicate:
+			errs = append(errs, fmt.Errorf("peer %s: duplicate public key", p.PublicKey))
+		default:
+			if _, err := wgtypes.ParseKey(p.PublicKey); err != nil {
+				errs = append(errs, fmt.Errorf("peer %s: invalid public key", p.PublicKey))
+			}
+		}
+		peers[p.PublicKey] = struct{}{}
+
+		if p.Interface == "" {
+			errs = append(errs, fmt.Errorf("peer %s: missing interface", p.PublicKey))
+		}
+		if len(p.Addresses) == 0 {
+			errs = append(errs, fmt.Errorf("peer %s: missing addresses", p.PublicKey))
+		}
+
+		var err error
+		if p.Addresses, err = normalizeSpecCidrs(p.Addresses); err != nil {
+			errs = append(errs, fmt.Errorf("peer %s: invalid addresses: %w", p.PublicKey, err))
+		}
+		if p.ExtraAllowedIPs, err = normalizeSpecCidrs(p.ExtraAllowedIPs); err != nil {
+			errs = append(errs, fmt.Errorf("peer %s: invalid extra allowed IPs: %w", p.PublicKey, err))
+		}
+		if p.AllowedIPs, err = normalizeSpecCidrs(p.AllowedIPs); err != nil {
+			errs = append(errs, fmt.Errorf("peer %s: invalid allowed IPs: %w", p.PublicKey, err))
+		}
+		p.Tags = NormalizeTags(p.Tags)
+		if err := ValidateTags(p.Tags); err != nil {
+			errs = append(errs, fmt.Errorf("peer %s: %w", p.PublicKey, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid spec: %w", errors.Join(append(errs, ErrInvalidData)...))
+	}
+
+	return nil
+}
+
+// NewUserSpec returns the spec of the given user.
+func NewUserSpec(u *User) UserSpec {
+	return UserSpec{
+		Identifier: u.Identifier,
+		Email:      u.Email,
+		Firstname:  u.Firstname,
+		Lastname:   u.Lastname,
+		Phone:      u.Phone,
+		Department: u.Department,
+		Notes:      u.Notes,
+		IsAdmin:    u.IsAdmin,
+		Roles:      u.Roles,
+		Disabled:   u.IsDisabled() && u.DisabledReason == DisabledReasonSpec,
+	}
+}
+
+// Apply updates the user with the values of the spec.
+func (s UserSpec) Apply(u *User, now time.Time) {
+	u.Identifier = s.Identifier
+	u.Email = s.Email
+	u.Firstname = s.Firstname
+	u.Lastname = s.Lastname
+	u.Phone = s.Phone
+	u.Department = s.Department
+	u.Notes = s.Notes
+	u.IsAdmin = s.IsAdmin
+	u.Roles = s.Roles
+	switch {
+	case s.Disabled && !u.IsDisabled():
+		u.Disabled = &now
+		u.DisabledReason = DisabledReasonSpec
+	case !s.Disabled && u.IsDisabled() && u.DisabledReason == DisabledReasonSpec:
+		u.Disabled = nil
+		u.DisabledReason = ""
+	}
+	u.ManagedBy = ManagedBySpec
+}
+
+// NewInterfaceSpec returns the spec of the given interface. The private key is not included.
+func NewInterfaceSpec(in *Interface) InterfaceSpec {
+	return InterfaceSpec{
+		Identifier:   in.Identifier,
+		DisplayName:  in.DisplayName,
+		Mode:         in.Type,
+		ListenPort:   in.ListenPort,
+		Addresses:    sortedCidrStrings(in.Addresses),
+		Dns:          splitSpecList(in.DnsStr),
+		DnsSearch:    splitSpecList(in.DnsSearchStr),
+		Mtu:          in.Mtu,
+		FirewallMark: in.FirewallMark,
+		RoutingTable: in.RoutingTable,
+		Disabled:     in.IsDisabled() && in.DisabledReason == DisabledReasonSpec,
+		PeerDefaults: PeerDefaultsSpec{
+			Networks:            sortedSpecList(in.PeerDefNetworkStr),
+			Endpoint:            in.PeerDefEndpoint,
+			AllowedIPs:          sortedSpecList(in.PeerDefAllowedIPsStr),
+			Dns:                 splitSpecList(in.PeerDefDnsStr),
+			Mtu:                 in.PeerDefMtu,
+			PersistentKeepalive: in.PeerDefPersistentKeepalive,
+		},
+	}
+}
+
+// Apply updates the interface with the values of the spec. The private key is only changed if the spec
+// contains one.
+func (s InterfaceSpec) Apply(in *Interface, now time.Time) {
+	in.Identifier = s.Identifier
+	in.DisplayName = s.DisplayName
+	in.Type = s.Mode
+	if s.PrivateKey != "" {
+		in.KeyPair = KeyPair{PrivateKey: s.PrivateKey, PublicKey: PublicKeyFromPrivateKey(s.PrivateKey)}
+	}
+	in.ListenPort = s.ListenPort
+	in.Addresses, _ = CidrsFromArray(s.Addresses) // validated by Spec.Normalize
+	in.DnsStr = strings.Join(s.Dns, ",")
+	in.DnsSearchStr = strings.Join(s.DnsSearch, ",")
+	in.Mtu = s.Mtu
+	in.FirewallMark = s.FirewallMark
+	in.RoutingTable = s.RoutingTable
+	switch {
+	case s.Disabled && !in.IsDisabled():
+		in.Disabled = &now
+		in.DisabledReason = DisabledReasonSpec
+	case !s.Disabled && in.IsDisabled() && in.DisabledReason == DisabledReasonSpec:
+		in.Disabled = nil
+		in.DisabledReason = ""
+	}
+	in.PeerDefNetworkStr = strings.Join(s.PeerDefaults.Networks, ",")
+	in.PeerDefEndpoint = s.PeerDefaults.Endpoint
+	in.PeerDefAllowedIPsStr = strings.Join(s.PeerDefaults.AllowedIPs, ",")
+	in.PeerDefDnsStr = strings.Join(s.PeerDefaults.Dns, ",")
+	in.PeerDefMtu = s.PeerDefaults.Mtu
+	in.PeerDefPersistentKeepalive = s.PeerDefaults.PersistentKeepalive
+	in.ManagedBy = ManagedBySpec
+}
+
+// NewPeerSpec returns the spec of the given peer. The endpoint and the allowed IPs are only included if they
+// override the interface defaults.
+func NewPeerSpec(p *Peer) PeerSpec {
+	spec := PeerSpec{
+		PublicKey:       string(p.Identifier),
+		Interface:       p.InterfaceIdentifier,
+		DisplayName:     p.DisplayName,
+		User:            p.UserIdentifier,
+		Addresses:       sortedCidrStrings(p.Interface.Addresses),
+		ExtraAllowedIPs: sortedSpecList(p.ExtraAllowedIPsStr),
+		Tags:            p.Tags,
+		Notes:           p.Notes,
+		Disabled:        p.IsDisabled() && p.DisabledReason == DisabledReasonSpec,
+	}
+	if !p.AllowedIPsStr.Overridable {
+		spec.AllowedIPs = sortedSpecList(p.AllowedIPsStr.GetValue())
+	}
+	if !p.Endpoint.Overridable {
+		spec.Endpoint = p.Endpoint.GetValue()
+	}
+
+	return spec
+}
+
+// Apply updates the peer with the values of the spec. Empty endpoints and allowed IPs are reset to the defaults
+// of the given interface.
+func (s PeerSpec) Apply(p *Peer, iface *Interface, now time.Time) {
+	p.Identifier = PeerIdentifier(s.PublicKey)
+	p.Interface.PublicKey = s.PublicKey
+	p.InterfaceIdentifier = s.Interface
+	p.DisplayName = s.DisplayName
+	p.UserIdentifier = s.User
+	p.Interface.Addresses, _ = CidrsFromArray(s.Addresses) // validated by Spec.Normalize
+	p.ExtraAllowedIPsStr = strings.Join(s.ExtraAllowedIPs, ",")
+	if len(s.AllowedIPs) > 0 {
+		p.AllowedIPsStr = NewConfigOption(strings.Join(s.AllowedIPs, ","), false)
+	} else if !p.AllowedIPsStr.Overridable {
+		p.AllowedIPsStr = NewConfigOption(iface.PeerDefAllowedIPsStr, true)
+	}
+	if s.Endpoint != "" {
+		p.Endpoint = NewConfigOption(s.Endpoint, false)
+	} else if !p.Endpoint.Overridable {
+		p.Endpoint = NewConfigOption(iface.PeerDefEndpoint, true)
+	}
+	p.Tags = s.Tags
+	p.Notes = s.Notes
+	switch {
+	case s.Disabled && !p.IsDisabled():
+		p.Disabled = &now
+		p.DisabledReason = DisabledReasonSpec
+	case !s.Disabled && p.IsDisabled() && p.DisabledReason == DisabledReasonSpec:
+		p.Disabled = nil
+		p.DisabledReason = ""
+	}
+	p.ManagedBy = ManagedBySpec
+}
+
+// region plan
+
+type SpecObjectKind string
+
+const (
+	SpecObjectUser      SpecObjectKind = "user"
+	SpecObjectInterface SpecObjectKind = "interface"
+	SpecObjectPeer      SpecObjectKind = "peer"
+)
+
+type SpecAction string
+
+const (
+	SpecActionCreate SpecAction = "create"
+	SpecActionUpdate SpecAction = "update"
+	SpecActionAdopt  SpecAction = "adopt" // an existing, unmanaged object is taken over by the spec
+	SpecActionDelete SpecAction = "delete"
+)
+
+// SpecChange is a single change that is needed to reconcile the stored objects with the spec.
+type SpecChange struct {
+	Kind       SpecObjectKind
+	Identifier string
+	Action     SpecAction
+	Fields     []string // the changed fields, only set for updates and adoptions
+	Error      string   // the error that occurred while applying the change
+}
+
+// SpecPlan lists all changes that are needed to reconcile the stored objects with the spec, in the order
+// in which they are applied.
+type SpecPlan struct {
+	Changes []SpecChange
+}
+
+// NewSpecPlan compares the spec with the stored objects. Objects that are not managed by the spec and are not
+// listed in the spec are ignored.
+func NewSpecPlan(spec *Spec, users []User, interfaces []Interface, peers []Peer) *SpecPlan {
+	plan := &SpecPlan{}
+	var deletions []SpecChange
+
+	existingUsers := make(map[UserIdentifier]*User, len(users))
+	for i := range users {
+		existingUsers[users[i].Identifier] = &users[i]
+	}
+	for _, s := range spec.Users {
+		if u, ok := existingUsers[s.Identifier]; ok {
+			plan.addChange(SpecObjectUser, string(s.Identifier), u.ManagedBy, specFieldChanges(NewUserSpec(u), s))
+		} else {
+			plan.Changes = append(plan.Changes,
+				SpecChange{Kind: SpecObjectUser, Identifier: string(s.Identifier), Action: SpecActionCreate})
+		}
+	}
+
+	existingInterfaces := make(map[InterfaceIdentifier]*Interface, len(interfaces))
+	for i := range interfaces {
+		existingInterfaces[interfaces[i].Identifier] = &interfaces[i]
+	}
+	for _, s := range spec.Interfaces {
+		if in, ok := existingInterfaces[s.Identifier]; ok {
+			current := NewInterfaceSpec(in)
+			if s.PrivateKey != "" {
+				current.PrivateKey = in.PrivateKey
+			}
+			plan.addChange(SpecObjectInterface, string(s.Identifier), in.ManagedBy, specFieldChanges(current, s))
+		} else {
+			plan.Changes = append(plan.Changes,
+				SpecChange{Kind: SpecObjectInterface, Identifier: string(s.Identifier), Action: SpecActionCreate})
+		}
+	}
+
+	existingPeers := make(map[PeerIdentifier]*Peer, len(peers))
+	for i := range peers {
+		existingPeers[peers[i].Identifier] = &peers[i]
+	}
+	for _, s := range spec.Peers {
+		if p, ok := existingPeers[PeerIdentifier(s.PublicKey)]; ok {
+			plan.addChange(SpecObjectPeer, s.PublicKey, p.ManagedBy, specFieldChanges(NewPeerSpec(p), s))
+		} else {
+			plan.Changes = append(plan.Changes,
+				SpecChange{Kind: SpecObjectPeer, Identifier: s.PublicKey, Action: SpecActionCreate})
+		}
+	}
+
+	// deletions are applied in reverse dependency order: peers, interfaces, users
+	for i := range peers {
+		if peers[i].ManagedBy == ManagedBySpec && !slices.ContainsFunc(spec.Peers, func(s PeerSpec) bool {
+			return s.PublicKey == string(peers[i].Identifier)
+		}) {
+			deletions = append(deletions,
+				SpecChange{Kind: SpecObjectPeer, Identifier: string(peers[i].Identifier), Action: SpecActionDelete})
+		}
+	}
+	for i := range interfaces {
+		if interfaces[i].ManagedBy == ManagedBySpec && !slices.ContainsFunc(spec.Interfaces,
+			func(s InterfaceSpec) bool { return s.Identifier == interfaces[i].Identifier }) {
+			deletions = append(deletions, SpecChange{Kind: SpecObjectInterface,
+				Identifier: string(interfaces[i].Identifier), Action: SpecActionDelete})
+		}
+	}
+	for i := range users {
+		if users[i].ManagedBy == ManagedBySpec && !slices.ContainsFunc(spec.Users,
+			func(s UserSpec) bool { return s.Identifier == users[i].Identifier }) {
+			deletions = append(deletions,
+				SpecChange{Kind: SpecObjectUser, Identifier: string(users[i].Identifier), Action: SpecActionDelete})
+		}
+	}
+	plan.Changes = append(plan.Changes, deletions...)
+
+	return plan
+}
+
+func (p *SpecPlan) addChange(kind SpecObjectKind, id, managedBy string, fields []string) {
+	switch {
+	case managedBy != ManagedBySpec:
+		p.Changes = append(p.Changes, SpecChange{Kind: kind, Identifier: id, Action: SpecActionAdopt, Fields: fields})
+	case len(fields) > 0:
+		p.Changes = append(p.Changes, SpecChange{Kind: kind, Identifier: id, Action: SpecActionUpdate, Fields: fields})
+	}
+}
+
+// IsEmpty returns true if the stored objects already match the spec.
+func (p *SpecPlan) IsEmpty() bool {
+	return len(p.Changes) == 0
+}
+
+// HasErrors returns true if at least one change failed.
+func (p *SpecPlan) HasErrors() bool {
+	return slices.ContainsFunc(p.Changes, func(c SpecChange) bool { return c.Error != "" })
+}
+
+// String returns a human-readable representation of the plan, one change per line.
+func (p *SpecPlan) String() string {
+	if p.IsEmpty() {
+		return "no changes, all objects match the spec\n"
+	}
+
+	var sb strings.Builder
+	for _, c := range p.Changes {
+		symbol := map[SpecAction]string{
+			SpecActionCreate: "+",
+			SpecActionUpdate: "~",
+			SpecActionAdopt:  "*",
+			SpecActionDelete: "-",
+		}[c.Action]
+		fmt.Fprintf(&sb, "%s %s %s %s", symbol, c.Action, c.Kind, c.Identifier)
+		if len(c.Fields) > 0 {
+			fmt.Fprintf(&sb, " (%s)", strings.Join(c.Fields, ", "))
+		}
+		if c.Error != "" {
+			fmt.Fprintf(&sb, ": failed: %s", c.Error)
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// specFieldChanges returns the yaml names of all fields that differ between the two specs.
+// Fields of nested structs are prefixed with the name of the parent field.
+func specFieldChanges(current, desired any) []string {
+	return appendSpecFieldChanges(nil, "", reflect.ValueOf(current), reflect.ValueOf(desired))
+}
+
+func appendSpecFieldChanges(changes []string, prefix string, current, desired reflect.Value) []string {
+	for i := 0; i < current.NumField(); i++ {
+		field := current.Type().Field(i)
+		name := prefix + strings.Split(field.Tag.Get("yaml"), ",")[0]
+
+		a, b := current.Field(i), desired.Field(i)
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			changes = appendSpecFieldChanges(changes, name+".", a, b)
+		case field.Type.Kind() == reflect.Slice:
+			if a.Len() != 0 || b.Len() != 0 { // nil and empty slices are equal
+				if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+					changes = append(changes, name)
+				}
+			}
+		case !a.Equal(b):
+			changes = append(changes, name)
+		}
+	}
+
+	return changes
+}
+
+// endregion plan
+
+// normalizeSpecCidrs validates the given CIDRs and returns them in canonical, sorted form.
+func normalizeSpecCidrs(values []string) ([]string, error) {
+	cidrs, err := CidrsFromArray(values)
+	if err != nil {
+		return nil, err
+	}
+
+	return sortedCidrStrings(cidrs), nil
+}
+
+func sortedCidrStrings(cidrs []Cidr) []string {
+	if len(cidrs) == 0 {
+		return nil
+	}
+
+	values := CidrsToStringSlice(cidrs)
+	slices.Sort(values)
+	return values
+}
+
+// splitSpecList splits a comma separated list, empty entries are dropped.
+func splitSpecList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+func sortedSpecList(value string) []string {
+	values := splitSpecList(value)
+	slices.Sort(values)
+	return values
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSpecPeerKey  = "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="
+	testSpecOtherKey = "HIgo9xNzJMWLKASShiTqIybxZ0U3wGLiUeJ1PKf8ykw="
+)
+
+func testSpec(t *testing.T) *Spec {
+	spec, err := ParseSpec([]byte(`
+users:
+  - identifier: alice
+    email: alice@example.com
+interfaces:
+  - identifier: wg0
+    listen_port: 51820
+    addresses: [10.0.0.1/24]
+    peer_defaults:
+      networks: [10.0.0.0/24]
+      allowed_ips: [10.0.0.0/24]
+peers:
+  - public_key: ` + testSpecPeerKey + `
+    interface: wg0
+    user: alice
+    addresses: [10.0.0.2/32]
+    tags: [Staff]
+`))
+	require.NoError(t, err)
+	require.NoError(t, spec.Normalize())
+
+	return spec
+}
+
+// testSpecObjects returns stored objects that match the test spec.
+func testSpecObjects(t *testing.T) ([]User, []Interface, []Peer) {
+	spec := testSpec(t)
+	now := time.Now()
+
+	user := User{}
+	spec.Users[0].Apply(&user, now)
+	iface := Interface{}
+	spec.Interfaces[0].Apply(&iface, now)
+	peer := Peer{}
+	spec.Peers[0].Apply(&peer, &iface, now)
+
+	return []User{user}, []Interface{iface}, []Peer{peer}
+}
+
+func TestParseSpec_UnknownField(t *testing.T) {
+	_, err := ParseSpec([]byte("interfaces:\n  - identifier: wg0\n    listen_prot: 51820\n"))
+	assert.ErrorIs(t, err, ErrInvalidData)
+}
+
+func TestSpec_Normalize(t *testing.T) {
+	spec := testSpec(t)
+	assert.Equal(t, InterfaceTypeServer, spec.Interfaces[0].Mode)
+	assert.Equal(t, []string{"staff"}, spec.Peers[0].Tags)
+
+	invalid := &Spec{
+		Users:      []UserSpec{{Identifier: "alice"}, {Identifier: "alice"}},
+		Interfaces: []InterfaceSpec{{Identifier: "wg0", Mode: "router", Addresses: []string{"10.0.0.300/24"}}},
+		Peers:      []PeerSpec{{PublicKey: "invalid", Interface: "wg0"}},
+	}
+	err := invalid.Normalize()
+	require.ErrorIs(t, err, ErrInvalidData)
+	assert.ErrorContains(t, err, "user alice: duplicate identifier")
+	assert.ErrorContains(t, err, "interface wg0: invalid mode")
+	assert.ErrorContains(t, err, "interface wg0: invalid addresses")
+	assert.ErrorContains(t, err, "peer invalid: invalid public key")
+	assert.ErrorContains(t, err, "peer invalid: missing addresses")
+}
+
+func TestNewSpecPlan_Create(t *testing.T) {
+	plan := NewSpecPlan(testSpec(t), nil, nil, nil)
+
+	require.Len(t, plan.Changes, 3)
+	assert.Equal(t, SpecChange{Kind: SpecObjectUser, Identifier: "alice", Action: SpecActionCreate}, plan.Changes[0])
+	assert.Equal(t, SpecObjectInterface, plan.Changes[1].Kind)
+	assert.Equal(t, SpecObjectPeer, plan.Changes[2].Kind)
+}
+
+func TestNewSpecPlan_NoChanges(t *testing.T) {
+	users, interfaces, peers := testSpecObjects(t)
+	plan := NewSpecPlan(testSpec(t), users, interfaces, peers)
+
+	assert.True(t, plan.IsEmpty(), plan.String())
+}
+
+func TestNewSpecPlan_UpdateAndAdopt(t *testing.T) {
+	users, interfaces, peers := testSpecObjects(t)
+	users[0].ManagedBy = "" // created manually
+	interfaces[0].ListenPort = 51821
+	peers[0].AllowedIPsStr = NewConfigOption("0.0.0.0/0", false)
+
+	plan := NewSpecPlan(testSpec(t), users, interfaces, peers)
+
+	require.Len(t, plan.Changes, 3)
+	assert.Equal(t, SpecActionAdopt, plan.Changes[0].Action)
+	assert.Empty(t, plan.Changes[0].Fields)
+	assert.Equal(t, SpecActionUpdate, plan.Changes[1].Action)
+	assert.Equal(t, []string{"listen_port"}, plan.Changes[1].Fields)
+	assert.Equal(t, SpecActionUpdate, plan.Changes[2].Action)
+	assert.Equal(t, []string{"allowed_ips"}, plan.Changes[2].Fields)
+}
+
+func TestNewSpecPlan_Delete(t *testing.T) {
+	users, interfaces, peers := testSpecObjects(t)
+	peers = append(peers,
+		Peer{Identifier: testSpecOtherKey, InterfaceIdentifier: "wg0", ManagedBy: ManagedBySpec},
+		Peer{Identifier: "unmanaged", InterfaceIdentifier: "wg0"})
+
+	plan := NewSpecPlan(testSpec(t), users, interfaces, peers)
+
+	require.Len(t, plan.Changes, 1)
+	assert.Equal(t, SpecChange{Kind: SpecObjectPeer, Identifier: testSpecOtherKey, Action: SpecActionDelete},
+		plan.Changes[0])
+}
+
+func TestSpecApply_Disabled(t *testing.T) {
+	now := time.Now()
+	expired := Peer{Disabled: &now, DisabledReason: DisabledReasonExpired}
+
+	PeerSpec{PublicKey: testSpecPeerKey}.Apply(&expired, &Interface{}, now)
+	assert.True(t, expired.IsDisabled(), "peers that were disabled for other reasons stay disabled")
+
+	peer := Peer{}
+	PeerSpec{PublicKey: testSpecPeerKey, Disabled: true}.Apply(&peer, &Interface{}, now)
+	assert.Equal(t, DisabledReasonSpec, peer.DisabledReason)
+
+	PeerSpec{PublicKey: testSpecPeerKey}.Apply(&peer, &Interface{}, now)
+	assert.False(t, peer.IsDisabled())
+}
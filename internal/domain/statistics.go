@@ -17,6 +17,58 @@ type PeerStatus struct {
 	LastHandshake    *time.Time `gorm:"column:last_handshake" json:"LastHandshake"`
 	Endpoint         string     `gorm:"column:endpoint" json:"Endpoint"`
 	LastSessionStart *time.Time `gorm:"column:last_session_start" json:"LastSessionStart"`
+
+	// traffic quota usage, only tracked if a quota applies to the peer
+	QuotaLimit            uint64         `gorm:"column:quota_limit" json:"QuotaLimit"`
+	QuotaDirection        QuotaDirection `gorm:"column:quota_direction" json:"QuotaDirection"`
+	QuotaPeriodStart      *time.Time     `gorm:"column:quota_period_start" json:"QuotaPeriodStart"`
+	QuotaBytesReceived    uint64         `gorm:"column:quota_received" json:"QuotaBytesReceived"`
+	QuotaBytesTransmitted uint64         `gorm:"column:quota_transmitted" json:"QuotaBytesTransmitted"`
+	// the start of the quota period in which the peer was re-enabled manually, the quota is not enforced in it
+	QuotaOverride *time.Time `gorm:"column:quota_override" json:"QuotaOverride"`
+}
+
+// UpdateQuotaUsage adds the given traffic to the quota usage of the current period.
+// If a new period started, the usage is reset first. If no quota applies, the usage is cleared.
+func (s *PeerStatus) UpdateQuotaUsage(quota TrafficQuota, now time.Time, received, transmitted uint64) {
+	if !quota.IsEnabled() {
+		s.QuotaLimit = 0
+		s.QuotaDirection = ""
+		s.QuotaPeriodStart = nil
+		s.QuotaBytesReceived = 0
+		s.QuotaBytesTransmitted = 0
+		s.QuotaOverride = nil
+		return
+	}
+
+	periodStart := quota.PeriodStart(now)
+	if s.QuotaPeriodStart == nil || !s.QuotaPeriodStart.Equal(periodStart) {
+		s.QuotaPeriodStart = &periodStart
+		s.QuotaBytesReceived = 0
+		s.QuotaBytesTransmitted = 0
+	}
+
+	s.QuotaLimit = quota.Limit
+	s.QuotaDirection = quota.Direction
+	s.QuotaBytesReceived += received
+	s.QuotaBytesTransmitted += transmitted
+}
+
+// QuotaUsed returns the traffic of the current quota period that counts towards the quota.
+func (s *PeerStatus) QuotaUsed() uint64 {
+	return TrafficQuota{Direction: s.QuotaDirection}.Usage(s.QuotaBytesReceived, s.QuotaBytesTransmitted)
+}
+
+// IsQuotaExceeded returns true if a quota applies and the traffic of the current period reached its limit.
+// A quota that is overridden for the current period is never exceeded.
+func (s *PeerStatus) IsQuotaExceeded() bool {
+	return s.QuotaLimit > 0 && !s.IsQuotaOverridden() && s.QuotaUsed() >= s.QuotaLimit
+}
+
+// IsQuotaOverridden returns true if the quota is not enforced in the current period,
+// because the peer was re-enabled manually after it exceeded its quota.
+func (s *PeerStatus) IsQuotaOverridden() bool {
+	return s.QuotaOverride != nil && s.QuotaPeriodStart != nil && s.QuotaOverride.Equal(*s.QuotaPeriodStart)
 }
 
 func (s *PeerStatus) CalcConnected() {
@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var tagNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:-]{0,31}$`)
+
+// PeerTag stores the description and the policy of a tag. Peers can be tagged with any tag,
+// a PeerTag record is only required if the tag carries a policy.
+type PeerTag struct {
+	BaseModel
+
+	Name        string        `gorm:"primaryKey;column:name"`
+	Description string        `gorm:"column:description"`
+	Policy      PeerTagPolicy `gorm:"embedded;embeddedPrefix:policy_"`
+}
+
+// PeerTagPolicy contains overrides for tagged peers. The policy is applied on top of the interface defaults.
+// Empty values do not change the peer.
+type PeerTagPolicy struct {
+	ExpiryDays         int          // peers without an expiry date expire this many days after the policy was applied
+	ExtraAllowedIPsStr string       // additional allowed ip subnets on the server side, comma separated
+	DnsStr             string       // the dns server of the peers, comma separated
+	DnsSearchStr       string       // the dns search options of the peers, comma separated
+	Quota              TrafficQuota `gorm:"embedded;embeddedPrefix:quota_"` // the traffic quota for peers without an own quota
+}
+
+// Validate checks the tag name and the policy.
+func (t PeerTag) Validate() error {
+	if err := ValidateTags([]string{t.Name}); err != nil {
+		return err
+	}
+
+	return t.Policy.Validate()
+}
+
+// Validate checks the settings of the policy.
+func (p PeerTagPolicy) Validate() error {
+	if p.ExpiryDays < 0 {
+		return fmt.Errorf("expiry days must not be negative: %w", ErrInvalidData)
+	}
+	if _, err := CidrsFromString(p.ExtraAllowedIPsStr); err != nil {
+		return fmt.Errorf("invalid extra allowed ips: %w", ErrInvalidData)
+	}
+
+	return p.Quota.Validate()
+}
+
+// NormalizeTags converts all tags to lower case and removes empty and duplicate tags. The result is sorted.
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	slices.Sort(normalized)
+
+	return normalized
+}
+
+// ValidateTags checks that all tags are valid tag names. Tags start with a letter or a digit and may contain
+// the characters '_', '.', ':' and '-'.
+func ValidateTags(tags []string) error {
+	for _, tag := range tags {
+		if !tagNameRegex.MatchString(tag) {
+			return fmt.Errorf("invalid tag %q: %w", tag, ErrInvalidData)
+		}
+	}
+
+	return nil
+}
+
+// HasTag returns true if the peer is tagged with the given tag.
+func (p *Peer) HasTag(tag string) bool {
+	return slices.Contains(p.Tags, tag)
+}
+
+// FilterPeersByTags returns all peers that are tagged with all the given tags.
+func FilterPeersByTags(peers []Peer, tags []string) []Peer {
+	if len(tags) == 0 {
+		return peers
+	}
+
+	filtered := make([]Peer, 0, len(peers))
+	for _, peer := range peers {
+		if !slices.ContainsFunc(tags, func(tag string) bool { return !peer.HasTag(tag) }) {
+			filtered = append(filtered, peer)
+		}
+	}
+
+	return filtered
+}
+
+// MergeTagPolicies combines the policies of the given tags. If several tags set the same value,
+// the tag that comes first in alphabetical order wins. Extra allowed ips of all tags are combined.
+func MergeTagPolicies(tags []PeerTag, names []string) PeerTagPolicy {
+	sorted := slices.Clone(tags)
+	slices.SortFunc(sorted, func(a, b PeerTag) int { return strings.Compare(a.Name, b.Name) })
+
+	var merged PeerTagPolicy
+	var extraAllowedIPs []Cidr
+	for _, tag := range sorted {
+		if !slices.Contains(names, tag.Name) {
+			continue
+		}
+
+		policy := tag.Policy
+		if merged.ExpiryDays == 0 {
+			merged.ExpiryDays = policy.ExpiryDays
+		}
+		if merged.DnsStr == "" {
+			merged.DnsStr = policy.DnsStr
+		}
+		if merged.DnsSearchStr == "" {
+			merged.DnsSearchStr = policy.DnsSearchStr
+		}
+		if !merged.Quota.IsEnabled() {
+			merged.Quota = policy.Quota
+		}
+		extra, _ := CidrsFromString(policy.ExtraAllowedIPsStr)
+		extraAllowedIPs = appendMissingCidrs(extraAllowedIPs, extra...)
+	}
+	merged.ExtraAllowedIPsStr = CidrsToString(extraAllowedIPs)
+
+	return merged
+}
+
+// ApplyTagPolicy applies the given policy on top of the interface defaults of the peer.
+// Dns settings are only changed if they are overridable, the quota and the expiry date are only set
+// if the peer does not have its own quota or expiry date.
+func (p *Peer) ApplyTagPolicy(policy PeerTagPolicy, now time.Time) {
+	if policy.DnsStr != "" {
+		p.Interface.DnsStr.TrySetValue(policy.DnsStr)
+	}
+	if policy.DnsSearchStr != "" {
+		p.Interface.DnsSearchStr.TrySetValue(policy.DnsSearchStr)
+	}
+	if policy.ExtraAllowedIPsStr != "" {
+		current, _ := CidrsFromString(p.ExtraAllowedIPsStr)
+		extra, _ := CidrsFromString(policy.ExtraAllowedIPsStr)
+		p.ExtraAllowedIPsStr = CidrsToString(appendMissingCidrs(current, extra...))
+	}
+	if policy.Quota.IsEnabled() && !p.Quota.IsEnabled() {
+		p.Quota = policy.Quota
+	}
+	if policy.ExpiryDays > 0 && p.ExpiresAt == nil {
+		expiresAt := truncateToDate(now).AddDate(0, 0, policy.ExpiryDays)
+		p.ExpiresAt = &expiresAt
+	}
+}
+
+func appendMissingCidrs(cidrs []Cidr, additional ...Cidr) []Cidr {
+	for _, cidr := range additional {
+		if !slices.ContainsFunc(cidrs, func(c Cidr) bool { return c.Cidr == cidr.Cidr }) {
+			cidrs = append(cidrs, cidr)
+		}
+	}
+
+	return cidrs
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	assert.Equal(t, []string{"berlin", "staff"}, NormalizeTags([]string{" Staff", "berlin", "", "staff"}))
+	assert.Empty(t, NormalizeTags(nil))
+}
+
+func TestValidateTags(t *testing.T) {
+	assert.NoError(t, ValidateTags([]string{"staff", "site:berlin", "team_a-1.2"}))
+	assert.ErrorIs(t, ValidateTags([]string{"Staff"}), ErrInvalidData)
+	assert.ErrorIs(t, ValidateTags([]string{"-staff"}), ErrInvalidData)
+	assert.ErrorIs(t, ValidateTags([]string{"two words"}), ErrInvalidData)
+	assert.ErrorIs(t, ValidateTags([]string{`"quoted"`}), ErrInvalidData)
+}
+
+func TestFilterPeersByTags(t *testing.T) {
+	peers := []Peer{
+		{Identifier: "a", Tags: []string{"berlin", "staff"}},
+		{Identifier: "b", Tags: []string{"staff"}},
+		{Identifier: "c"},
+	}
+
+	assert.Len(t, FilterPeersByTags(peers, nil), 3)
+	assert.Len(t, FilterPeersByTags(peers, []string{"staff"}), 2)
+
+	filtered := FilterPeersByTags(peers, []string{"staff", "berlin"})
+	assert.Len(t, filtered, 1)
+	assert.Equal(t, PeerIdentifier("a"), filtered[0].Identifier)
+}
+
+func TestMergeTagPolicies(t *testing.T) {
+	tags := []PeerTag{
+		{Name: "staff", Policy: PeerTagPolicy{ExpiryDays: 90, DnsStr: "10.0.0.53", ExtraAllowedIPsStr: "10.1.0.0/24"}},
+		{Name: "berlin", Policy: PeerTagPolicy{DnsStr: "10.0.1.53", ExtraAllowedIPsStr: "10.1.0.0/24,10.2.0.0/24"}},
+		{Name: "guests", Policy: PeerTagPolicy{ExpiryDays: 7}},
+	}
+
+	merged := MergeTagPolicies(tags, []string{"berlin", "staff"})
+	assert.Equal(t, 90, merged.ExpiryDays)
+	assert.Equal(t, "10.0.1.53", merged.DnsStr, "the first tag in alphabetical order wins")
+	assert.Equal(t, "10.1.0.0/24,10.2.0.0/24", merged.ExtraAllowedIPsStr)
+
+	assert.Equal(t, PeerTagPolicy{}, MergeTagPolicies(tags, nil))
+}
+
+func TestPeer_ApplyTagPolicy(t *testing.T) {
+	now := time.Date(2025, 3, 10, 15, 30, 0, 0, time.UTC)
+	quota := TrafficQuota{Limit: 1000, Direction: QuotaDirectionTotal, ResetCycle: QuotaResetMonthly}
+	policy := PeerTagPolicy{
+		ExpiryDays:         30,
+		ExtraAllowedIPsStr: "10.1.0.0/24",
+		DnsStr:             "10.0.0.53",
+		DnsSearchStr:       "corp.local",
+		Quota:              quota,
+	}
+
+	peer := Peer{
+		ExtraAllowedIPsStr: "10.1.0.0/24,10.9.0.0/24",
+		Interface: PeerInterfaceConfig{
+			DnsStr:       NewConfigOption("1.1.1.1", true),
+			DnsSearchStr: NewConfigOption("", false),
+		},
+	}
+	peer.ApplyTagPolicy(policy, now)
+
+	assert.Equal(t, "10.0.0.53", peer.Interface.DnsStr.GetValue())
+	assert.Equal(t, "", peer.Interface.DnsSearchStr.GetValue(), "non-overridable values are kept")
+	assert.Equal(t, "10.1.0.0/24,10.9.0.0/24", peer.ExtraAllowedIPsStr)
+	assert.Equal(t, quota, peer.Quota)
+	assert.Equal(t, time.Date(2025, 4, 9, 0, 0, 0, 0, time.UTC), *peer.ExpiresAt)
+
+	expiresAt := now.AddDate(1, 0, 0)
+	ownQuota := TrafficQuota{Limit: 5, Direction: QuotaDirectionReceived, ResetCycle: QuotaResetDaily}
+	peer = Peer{ExpiresAt: &expiresAt, Quota: ownQuota}
+	peer.ApplyTagPolicy(policy, now)
+
+	assert.Equal(t, expiresAt, *peer.ExpiresAt)
+	assert.Equal(t, ownQuota, peer.Quota)
+	assert.Equal(t, "10.1.0.0/24", peer.ExtraAllowedIPsStr)
+}
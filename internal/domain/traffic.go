@@ -0,0 +1,230 @@
+package domain
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// MaxTrafficDataPoints limits the number of data points of a single traffic history query.
+const MaxTrafficDataPoints = 1000
+
+type TrafficObjectType string
+
+const (
+	TrafficObjectPeer      TrafficObjectType = "peer"
+	TrafficObjectInterface TrafficObjectType = "interface"
+)
+
+type TrafficResolution string
+
+const (
+	TrafficResolutionRaw    TrafficResolution = "raw"    // one sample per data collection interval
+	TrafficResolutionHourly TrafficResolution = "hourly" // one sample per hour
+	TrafficResolutionDaily  TrafficResolution = "daily"  // one sample per day
+)
+
+// TrafficSample contains the traffic of a peer or interface in the period that starts at the timestamp.
+// The byte values are deltas and not counters, so samples can be summed up.
+type TrafficSample struct {
+	Id               uint64            `gorm:"primaryKey;autoIncrement"`
+	ObjectType       TrafficObjectType `gorm:"index:idx_traffic_object,priority:1"`
+	ObjectId         string            `gorm:"index:idx_traffic_object,priority:2"`
+	Resolution       TrafficResolution `gorm:"index:idx_traffic_resolution,priority:1"`
+	Timestamp        time.Time         `gorm:"column:sampled_at;index:idx_traffic_object,priority:3;index:idx_traffic_resolution,priority:2"`
+	BytesReceived    uint64
+	BytesTransmitted uint64
+}
+
+// Truncate returns the start of the period of the given resolution that contains the timestamp.
+// Periods are aligned to UTC.
+func (r TrafficResolution) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	switch r {
+	case TrafficResolutionHourly:
+		return t.Truncate(time.Hour)
+	case TrafficResolutionDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	default:
+		return t
+	}
+}
+
+// CounterDelta returns the difference between two counter values.
+// If the counter was reset, for example because the interface was recreated, the new value is the delta.
+func CounterDelta(oldValue, newValue uint64) uint64 {
+	if newValue < oldValue {
+		return newValue
+	}
+	return newValue - oldValue
+}
+
+// DownsampleTraffic merges the samples into samples of the given resolution.
+// Samples of different objects are kept separate. The result is sorted by object and timestamp.
+func DownsampleTraffic(samples []TrafficSample, resolution TrafficResolution) []TrafficSample {
+	type bucketKey struct {
+		objectType TrafficObjectType
+		objectId   string
+		timestamp  time.Time
+	}
+
+	buckets := make(map[bucketKey]*TrafficSample)
+	for _, sample := range samples {
+		key := bucketKey{
+			objectType: sample.ObjectType,
+			objectId:   sample.ObjectId,
+			timestamp:  resolution.Truncate(sample.Timestamp),
+		}
+		bucket, exists := buckets[key]
+		if !exists {
+			bucket = &TrafficSample{
+				ObjectType: key.objectType,
+				ObjectId:   key.objectId,
+				Resolution: resolution,
+				Timestamp:  key.timestamp,
+			}
+			buckets[key] = bucket
+		}
+		bucket.BytesReceived += sample.BytesReceived
+		bucket.BytesTransmitted += sample.BytesTransmitted
+	}
+
+	result := make([]TrafficSample, 0, len(buckets))
+	for _, bucket := range buckets {
+		result = append(result, *bucket)
+	}
+	slices.SortFunc(result, func(a, b TrafficSample) int {
+		return cmp.Or(
+			cmp.Compare(a.ObjectType, b.ObjectType),
+			cmp.Compare(a.ObjectId, b.ObjectId),
+			a.Timestamp.Compare(b.Timestamp),
+		)
+	})
+
+	return result
+}
+
+// TrafficStep is the bucket size of a traffic history query. It is either one of the calendar steps
+// hour, day, week (starting on monday) and month, or a duration like 15m. All buckets are aligned to UTC.
+type TrafficStep string
+
+const (
+	TrafficStepHour  TrafficStep = "hour"
+	TrafficStepDay   TrafficStep = "day"
+	TrafficStepWeek  TrafficStep = "week"
+	TrafficStepMonth TrafficStep = "month"
+)
+
+// ParseTrafficStep validates the given step. Durations must be at least one minute.
+func ParseTrafficStep(step string) (TrafficStep, error) {
+	switch TrafficStep(step) {
+	case TrafficStepHour, TrafficStepDay, TrafficStepWeek, TrafficStepMonth:
+		return TrafficStep(step), nil
+	}
+
+	duration, err := time.ParseDuration(step)
+	if err != nil {
+		return "", fmt.Errorf("invalid step %q: %w", step, ErrInvalidData)
+	}
+	if duration < time.Minute {
+		return "", fmt.Errorf("step must be at least one minute: %w", ErrInvalidData)
+	}
+
+	return TrafficStep(step), nil
+}
+
+// Start returns the start of the bucket that contains the timestamp.
+func (s TrafficStep) Start(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch s {
+	case TrafficStepHour:
+		return t.Truncate(time.Hour)
+	case TrafficStepDay:
+		return day
+	case TrafficStepWeek:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -daysSinceMonday)
+	case TrafficStepMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		duration, _ := time.ParseDuration(string(s))
+		return t.Truncate(duration)
+	}
+}
+
+// Next returns the start of the bucket that follows the bucket starting at the given timestamp.
+func (s TrafficStep) Next(start time.Time) time.Time {
+	switch s {
+	case TrafficStepHour:
+		return start.Add(time.Hour)
+	case TrafficStepDay:
+		return start.AddDate(0, 0, 1)
+	case TrafficStepWeek:
+		return start.AddDate(0, 0, 7)
+	case TrafficStepMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		duration, _ := time.ParseDuration(string(s))
+		return start.Add(duration)
+	}
+}
+
+// TrafficQuery describes a traffic history query. From is inclusive, To is exclusive.
+type TrafficQuery struct {
+	From time.Time
+	To   time.Time
+	Step TrafficStep
+}
+
+// Validate checks the time range and the number of resulting data points.
+func (q TrafficQuery) Validate() error {
+	if !q.From.Before(q.To) {
+		return fmt.Errorf("from must be before to: %w", ErrInvalidData)
+	}
+	if _, err := ParseTrafficStep(string(q.Step)); err != nil {
+		return err
+	}
+
+	points := 0
+	for start := q.Step.Start(q.From); start.Before(q.To); start = q.Step.Next(start) {
+		points++
+		if points > MaxTrafficDataPoints {
+			return fmt.Errorf("too many data points, use a larger step: %w", ErrInvalidData)
+		}
+	}
+
+	return nil
+}
+
+// TrafficDataPoint contains the traffic of a single bucket of a traffic history query.
+type TrafficDataPoint struct {
+	Timestamp        time.Time // the start of the bucket
+	BytesReceived    uint64
+	BytesTransmitted uint64
+}
+
+// AggregateTraffic sums up the samples into the buckets of the query. Empty buckets are included,
+// so that the result can be plotted directly. Downsampled samples are attributed to the bucket
+// that contains the start of their period.
+func AggregateTraffic(samples []TrafficSample, query TrafficQuery) []TrafficDataPoint {
+	var points []TrafficDataPoint
+	index := make(map[time.Time]int)
+	for start := query.Step.Start(query.From); start.Before(query.To); start = query.Step.Next(start) {
+		index[start] = len(points)
+		points = append(points, TrafficDataPoint{Timestamp: start})
+	}
+
+	for _, sample := range samples {
+		i, ok := index[query.Step.Start(sample.Timestamp)]
+		if !ok || !sample.Timestamp.Before(query.To) {
+			continue // outside of the queried range
+		}
+		points[i].BytesReceived += sample.BytesReceived
+		points[i].BytesTransmitted += sample.BytesTransmitted
+	}
+
+	return points
+}
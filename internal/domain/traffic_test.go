@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounterDelta(t *testing.T) {
+	assert.Equal(t, uint64(50), CounterDelta(100, 150))
+	assert.Equal(t, uint64(0), CounterDelta(100, 100))
+	assert.Equal(t, uint64(20), CounterDelta(100, 20)) // counter reset
+}
+
+func TestDownsampleTraffic(t *testing.T) {
+	base := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+	samples := []TrafficSample{
+		{ObjectType: TrafficObjectPeer, ObjectId: "a", Timestamp: base.Add(1 * time.Minute), BytesReceived: 1},
+		{ObjectType: TrafficObjectPeer, ObjectId: "a", Timestamp: base.Add(59 * time.Minute), BytesReceived: 2},
+		{ObjectType: TrafficObjectPeer, ObjectId: "a", Timestamp: base.Add(61 * time.Minute), BytesReceived: 4},
+		{ObjectType: TrafficObjectPeer, ObjectId: "b", Timestamp: base.Add(5 * time.Minute), BytesTransmitted: 8},
+	}
+
+	hourly := DownsampleTraffic(samples, TrafficResolutionHourly)
+	require.Len(t, hourly, 3)
+	assert.Equal(t, TrafficSample{
+		ObjectType: TrafficObjectPeer, ObjectId: "a", Resolution: TrafficResolutionHourly, Timestamp: base,
+		BytesReceived: 3,
+	}, hourly[0])
+	assert.Equal(t, base.Add(time.Hour), hourly[1].Timestamp)
+	assert.Equal(t, uint64(4), hourly[1].BytesReceived)
+	assert.Equal(t, "b", hourly[2].ObjectId)
+	assert.Equal(t, uint64(8), hourly[2].BytesTransmitted)
+
+	daily := DownsampleTraffic(hourly, TrafficResolutionDaily)
+	require.Len(t, daily, 2)
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), daily[0].Timestamp)
+	assert.Equal(t, uint64(7), daily[0].BytesReceived)
+}
+
+func TestParseTrafficStep(t *testing.T) {
+	for _, valid := range []string{"hour", "day", "week", "month", "15m", "6h"} {
+		_, err := ParseTrafficStep(valid)
+		assert.NoError(t, err, valid)
+	}
+	for _, invalid := range []string{"", "year", "30s", "abc"} {
+		_, err := ParseTrafficStep(invalid)
+		assert.ErrorIs(t, err, ErrInvalidData, invalid)
+	}
+}
+
+func TestTrafficStep_Start(t *testing.T) {
+	ts := time.Date(2025, 3, 13, 14, 35, 10, 0, time.UTC) // a thursday
+
+	assert.Equal(t, time.Date(2025, 3, 13, 14, 0, 0, 0, time.UTC), TrafficStepHour.Start(ts))
+	assert.Equal(t, time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC), TrafficStepDay.Start(ts))
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), TrafficStepWeek.Start(ts))
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), TrafficStepMonth.Start(ts))
+	assert.Equal(t, time.Date(2025, 3, 13, 14, 30, 0, 0, time.UTC), TrafficStep("15m").Start(ts))
+
+	assert.Equal(t, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+		TrafficStepMonth.Next(TrafficStepMonth.Start(ts)))
+}
+
+func TestTrafficQuery_Validate(t *testing.T) {
+	now := time.Date(2025, 3, 13, 14, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, TrafficQuery{From: now.AddDate(0, -1, 0), To: now, Step: TrafficStepDay}.Validate())
+	assert.ErrorIs(t, TrafficQuery{From: now, To: now, Step: TrafficStepDay}.Validate(), ErrInvalidData)
+	assert.ErrorIs(t, TrafficQuery{From: now.AddDate(-1, 0, 0), To: now, Step: "1m"}.Validate(), ErrInvalidData)
+}
+
+func TestAggregateTraffic(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	query := TrafficQuery{From: from, To: from.AddDate(0, 0, 3), Step: TrafficStepDay}
+	samples := []TrafficSample{
+		{Timestamp: from.Add(2 * time.Hour), BytesReceived: 10, BytesTransmitted: 1},
+		{Timestamp: from.Add(20 * time.Hour), BytesReceived: 5},
+		{Timestamp: from.AddDate(0, 0, 2), BytesTransmitted: 7},
+		{Timestamp: from.AddDate(0, 0, 3), BytesReceived: 100}, // outside of range
+	}
+
+	points := AggregateTraffic(samples, query)
+	require.Len(t, points, 3)
+	assert.Equal(t, TrafficDataPoint{Timestamp: from, BytesReceived: 15, BytesTransmitted: 1}, points[0])
+	assert.Equal(t, TrafficDataPoint{Timestamp: from.AddDate(0, 0, 1)}, points[1])
+	assert.Equal(t, uint64(7), points[2].BytesTransmitted)
+}
@@ -35,6 +35,7 @@ type User struct {
 	Source       UserSource
 	ProviderName string
 	IsAdmin      bool
+	Roles        []Role `gorm:"serializer:json;column:roles"` // additional roles, admins have all permissions
 
 	// optional fields
 	Firstname  string `form:"firstname" binding:"omitempty"`
@@ -49,15 +50,26 @@ type User struct {
 	DisabledReason string        // the reason why the user has been disabled
 	Locked         *time.Time    `gorm:"index;column:locked"` // if this field is set, the user is locked and can no longer login (WireGuard peers still can connect)
 	LockedReason   string        // the reason why the user has been locked
+	LockedUntil    *time.Time    `gorm:"column:locked_until"` // if this field is set, the lock is lifted automatically
+
+	PeerQuota TrafficQuota `gorm:"embedded;embeddedPrefix:peer_quota_"` // the traffic quota for all peers of the user that have no own quota
 
 	// Passwordless authentication
 	WebAuthnId             string                   `gorm:"column:webauthn_id"`         // the webauthn id of the user, used for webauthn authentication
 	WebAuthnCredentialList []UserWebauthnCredential `gorm:"foreignKey:user_identifier"` // the webauthn credentials of the user, used for webauthn authentication
 
+	// Second factor for password logins
+	TotpSecret        string     `gorm:"serializer:encstr;column:totp_secret"`       // the shared secret of the TOTP authenticator app
+	TotpEnabled       *time.Time `gorm:"column:totp_enabled"`                        // if this field is set, the TOTP enrollment has been confirmed
+	TotpLastCounter   int64      `gorm:"column:totp_last_counter"`                   // the time step of the last accepted code, codes can not be reused
+	TotpRecoveryCodes []string   `gorm:"serializer:json;column:totp_recovery_codes"` // the sha256 hashes of the unused recovery codes
+
 	// API token for REST API access
 	ApiToken        string `form:"api_token" binding:"omitempty"`
 	ApiTokenCreated *time.Time
 
+	ManagedBy string `gorm:"column:managed_by"` // set if the user is managed by the declarative spec, managed users are read-only
+
 	LinkedPeerCount int `gorm:"-"`
 }
 
@@ -68,8 +80,9 @@ func (u *User) IsDisabled() bool {
 }
 
 // IsLocked returns true if the user is locked. In such a case, no login is possible, WireGuard connections still work.
+// Temporary locks expire at LockedUntil.
 func (u *User) IsLocked() bool {
-	return u.Locked != nil
+	return u.Locked != nil && (u.LockedUntil == nil || u.LockedUntil.After(time.Now()))
 }
 
 func (u *User) IsApiEnabled() bool {
@@ -185,6 +198,21 @@ func (u *User) CopyCalculatedAttributes(src *User) {
 	u.LinkedPeerCount = src.LinkedPeerCount
 }
 
+// CopyLocalAttributes copies the attributes that only apply to this instance and are therefore not part of an
+// interface bundle: the credentials of the user and the ownership of the declarative spec.
+func (u *User) CopyLocalAttributes(src *User) {
+	u.Password = src.Password
+	u.ApiToken = src.ApiToken
+	u.ApiTokenCreated = src.ApiTokenCreated
+	u.WebAuthnId = src.WebAuthnId
+	u.WebAuthnCredentialList = src.WebAuthnCredentialList
+	u.TotpSecret = src.TotpSecret
+	u.TotpEnabled = src.TotpEnabled
+	u.TotpLastCounter = src.TotpLastCounter
+	u.TotpRecoveryCodes = src.TotpRecoveryCodes
+	u.ManagedBy = src.ManagedBy
+}
+
 // region webauthn
 
 func (u *User) WebAuthnID() []byte {
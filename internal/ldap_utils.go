@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 
 	"github.com/go-ldap/ldap/v3"
 
@@ -137,3 +138,34 @@ func LdapIsMemberOf(groupData [][]byte, groupDN *ldap.DN) (bool, error) {
 
 	return false, nil
 }
+
+// LdapParseRoleGroups parses the group DNs of the given role to group DN mapping.
+func LdapParseRoleGroups(roleGroups map[string]string) (map[string]*ldap.DN, error) {
+	parsed := make(map[string]*ldap.DN, len(roleGroups))
+	for role, group := range roleGroups {
+		dn, err := ldap.ParseDN(group)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse group DN of role %s: %w", role, err)
+		}
+		parsed[role] = dn
+	}
+
+	return parsed, nil
+}
+
+// LdapMemberRoles returns the sorted names of all roles whose group DN is contained in the groupData array.
+func LdapMemberRoles(groupData [][]byte, roleGroups map[string]*ldap.DN) ([]string, error) {
+	var roles []string
+	for role, groupDN := range roleGroups {
+		isMember, err := LdapIsMemberOf(groupData, groupDN)
+		if err != nil {
+			return nil, err
+		}
+		if isMember {
+			roles = append(roles, role)
+		}
+	}
+	slices.Sort(roles) // map iteration order is random
+
+	return roles, nil
+}